@@ -0,0 +1,99 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/LICENSE
+
+#include <limits.h>
+#include <string.h>
+#include <algorithm>
+#include <memory>
+#include <openssl/evp.h>
+#include <openssl/rand.h>
+#include "crypto.h"
+
+namespace {
+
+struct CipherCtxDeleter {
+  void operator()(EVP_CIPHER_CTX* ctx) const { EVP_CIPHER_CTX_free(ctx); }
+};
+
+}  // namespace
+
+bool RandomBytes(char* buf, size_t n) {
+  while (n > 0) {
+    const int len = int(std::min(n, size_t(INT_MAX)));
+    if (RAND_bytes(reinterpret_cast<unsigned char*>(buf), len) != 1) {
+      return false;
+    }
+    buf += len;
+    n -= len;
+  }
+  return true;
+}
+
+AESCipher::AESCipher() : ctr_(nullptr) {}
+
+bool AESCipher::Init(const std::string& key) {
+  switch (key.size()) {
+    case 16:
+      ctr_ = EVP_aes_128_ctr();
+      break;
+    case 24:
+      ctr_ = EVP_aes_192_ctr();
+      break;
+    case 32:
+      ctr_ = EVP_aes_256_ctr();
+      break;
+    default:
+      return false;
+  }
+  key_ = key;
+  return true;
+}
+
+CTRCipherStream::CTRCipherStream(const AESCipher* cipher, const std::string& iv)
+    : cipher_(cipher) {
+  memset(iv_, 0, sizeof(iv_));
+  memcpy(iv_, iv.data(), std::min(iv.size(), sizeof(iv_)));
+}
+
+bool CTRCipherStream::Crypt(uint64_t offset, char* data, size_t n) const {
+  // The counter is the initialization vector plus the block index, as a
+  // 128-bit big-endian integer. OpenSSL increments it the same way for the
+  // following blocks.
+  uint8_t counter[kAESBlockSize];
+  memcpy(counter, iv_, sizeof(counter));
+  uint64_t carry = offset / kAESBlockSize;
+  for (int i = kAESBlockSize - 1; i >= 0 && carry != 0; i--) {
+    carry += counter[i];
+    counter[i] = uint8_t(carry);
+    carry >>= 8;
+  }
+
+  std::unique_ptr<EVP_CIPHER_CTX, CipherCtxDeleter> ctx(EVP_CIPHER_CTX_new());
+  if (ctx == nullptr ||
+      EVP_EncryptInit_ex(ctx.get(), cipher_->ctr_, nullptr,
+                         reinterpret_cast<const unsigned char*>(cipher_->key_.data()),
+                         counter) != 1) {
+    return false;
+  }
+  // Skip the part of the key stream before the offset in the first block.
+  uint8_t skip[kAESBlockSize] = {0};
+  int len;
+  if (EVP_EncryptUpdate(ctx.get(), skip, &len, skip, int(offset % kAESBlockSize)) != 1) {
+    return false;
+  }
+  unsigned char* buf = reinterpret_cast<unsigned char*>(data);
+  while (n > 0) {
+    const int chunk = int(std::min(n, size_t(INT_MAX - kAESBlockSize)));
+    if (EVP_EncryptUpdate(ctx.get(), buf, &len, buf, chunk) != 1) {
+      return false;
+    }
+    buf += chunk;
+    n -= chunk;
+  }
+  return true;
+}
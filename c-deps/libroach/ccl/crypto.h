@@ -0,0 +1,62 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/LICENSE
+
+#ifndef ROACHLIB_CCL_CRYPTO_H
+#define ROACHLIB_CCL_CRYPTO_H
+
+#include <stddef.h>
+#include <stdint.h>
+#include <string>
+
+// evp_cipher_st is OpenSSL's EVP_CIPHER.
+struct evp_cipher_st;
+
+// kAESBlockSize is the size in bytes of an AES block.
+const size_t kAESBlockSize = 16;
+
+// RandomBytes fills buf with n bytes from a cryptographically secure random
+// number generator. It returns false if the generator failed.
+bool RandomBytes(char* buf, size_t n);
+
+// AESCipher holds an AES key of 128, 192 or 256 bits. The cipher itself is
+// provided by OpenSSL's libcrypto.
+class AESCipher {
+ public:
+  AESCipher();
+
+  // Init sets the key. It returns false if the key does not have a valid
+  // AES key size.
+  bool Init(const std::string& key);
+
+ private:
+  friend class CTRCipherStream;
+
+  // ctr_ is the OpenSSL counter mode cipher for the size of the key.
+  const evp_cipher_st* ctr_;
+  std::string key_;
+};
+
+// CTRCipherStream encrypts and decrypts a file using AES in counter mode.
+// The counter block for the n-th block of the file is the initialization
+// vector plus n, so any part of the file can be processed independently of
+// the rest. The cipher must outlive the stream.
+class CTRCipherStream {
+ public:
+  CTRCipherStream(const AESCipher* cipher, const std::string& iv);
+
+  // Crypt XORs n bytes of data, which start at the given offset in the file,
+  // with the key stream. As counter mode is symmetric, this both encrypts
+  // and decrypts. It returns false if the cipher failed.
+  bool Crypt(uint64_t offset, char* data, size_t n) const;
+
+ private:
+  const AESCipher* cipher_;
+  uint8_t iv_[kAESBlockSize];
+};
+
+#endif  // ROACHLIB_CCL_CRYPTO_H
@@ -6,13 +6,17 @@
 //
 //     https://github.com/cockroachdb/cockroach/blob/master/LICENSE
 
+#include <map>
 #include <memory>
+#include <rocksdb/env.h>
 #include <rocksdb/iterator.h>
 #include <rocksdb/comparator.h>
 #include <rocksdb/write_batch.h>
 #include <rocksdb/utilities/write_batch_with_index.h>
 #include <libroachccl.h>
 #include "../db.h"
+#include "crypto.h"
+#include "encrypted_env.h"
 
 const DBStatus kSuccess = { NULL, 0 };
 
@@ -49,3 +53,80 @@ DBStatus DBBatchReprVerify(
 
   return kSuccess;
 }
+
+DBStatus DBEncryptCTR(DBSlice key, DBSlice iv, uint64_t offset, DBSlice data) {
+  AESCipher cipher;
+  if (!cipher.Init(ToString(key))) {
+    return FmtStatus("invalid AES key size %d", key.len);
+  }
+  CTRCipherStream stream(&cipher, ToString(iv));
+  if (!stream.Crypt(offset, data.data, data.len)) {
+    return FmtStatus("AES counter mode encryption failed");
+  }
+  return kSuccess;
+}
+
+namespace {
+
+// ParseEncryptionOptions decodes the encryption options passed as the extra
+// options of DBOpen. They are a sequence of records, each holding a one byte
+// key ID length, the key ID, a one byte key length and the key. The first
+// record describes the active key; an empty active key means that new files
+// are written in plaintext. The remaining records hold old keys which may
+// still be in use.
+rocksdb::Status ParseEncryptionOptions(DBSlice opts, std::map<std::string, std::string>* keys,
+                                       std::string* active_key_id) {
+  const std::string data = ToString(opts);
+  size_t pos = 0;
+  auto next = [&](std::string* field) {
+    if (pos >= data.size()) {
+      return false;
+    }
+    const size_t n = static_cast<unsigned char>(data[pos]);
+    if (n > data.size() - pos - 1) {
+      return false;
+    }
+    *field = data.substr(pos + 1, n);
+    pos += 1 + n;
+    return true;
+  };
+  bool first = true;
+  while (pos < data.size()) {
+    std::string id, key;
+    if (!next(&id) || !next(&key) || id.empty()) {
+      return rocksdb::Status::InvalidArgument("malformed encryption options");
+    }
+    if (first) {
+      *active_key_id = key.empty() ? kPlainKeyID : id;
+      first = false;
+    }
+    if (!key.empty()) {
+      (*keys)[id] = key;
+    }
+  }
+  if (first) {
+    return rocksdb::Status::InvalidArgument("no active encryption key");
+  }
+  return rocksdb::Status::OK();
+}
+
+// DBOpenHookCCL sets up encryption at rest if the extra options ask for it.
+rocksdb::Status DBOpenHookCCL(const std::string& db_dir, const DBOptions db_opts,
+                              rocksdb::Env** env) {
+  if (db_opts.extra_options.len == 0) {
+    return rocksdb::Status::OK();
+  }
+  std::map<std::string, std::string> keys;
+  std::string active_key_id;
+  rocksdb::Status status = ParseEncryptionOptions(db_opts.extra_options, &keys, &active_key_id);
+  if (!status.ok()) {
+    return status;
+  }
+  return NewEncryptedEnv(rocksdb::Env::Default(), db_dir, keys, active_key_id, env);
+}
+
+}  // namespace
+
+void DBSetOpenHookCCL() {
+  DBSetOpenHook(DBOpenHookCCL);
+}
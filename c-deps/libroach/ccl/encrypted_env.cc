@@ -0,0 +1,772 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/LICENSE
+
+#include <string.h>
+#include <algorithm>
+#include <functional>
+#include <memory>
+#include <mutex>
+#include <sstream>
+#include <vector>
+#include "crypto.h"
+#include "encrypted_env.h"
+
+const char* const kPlainKeyID = "plain";
+const char* const kFileRegistryName = "COCKROACHDB_REGISTRY";
+
+namespace {
+
+const char* const kFileRegistryHeader = "cockroachdb-file-registry v1";
+
+std::string HexEncode(const std::string& s) {
+  static const char kHexDigits[] = "0123456789abcdef";
+  std::string result;
+  result.reserve(2 * s.size());
+  for (unsigned char c : s) {
+    result.push_back(kHexDigits[c >> 4]);
+    result.push_back(kHexDigits[c & 0xf]);
+  }
+  return result;
+}
+
+bool HexDecode(const std::string& s, std::string* result) {
+  if (s.size() % 2 != 0) {
+    return false;
+  }
+  result->clear();
+  for (size_t i = 0; i < s.size(); i += 2) {
+    int v = 0;
+    for (size_t j = i; j < i + 2; j++) {
+      const char c = s[j];
+      v <<= 4;
+      if (c >= '0' && c <= '9') {
+        v |= c - '0';
+      } else if (c >= 'a' && c <= 'f') {
+        v |= c - 'a' + 10;
+      } else {
+        return false;
+      }
+    }
+    result->push_back(char(v));
+  }
+  return true;
+}
+
+// FileEncryption describes how a file is encrypted.
+struct FileEncryption {
+  std::string key_id;
+  // iv is the initialization vector of the file's counter mode stream.
+  std::string iv;
+};
+
+// FileRegistry records the encryption of the files in a database
+// directory. Files written while new files are plaintext are recorded with
+// kPlainKeyID; files which aren't in the registry at all predate encryption
+// and are plaintext too.
+//
+// The registry is stored as an append-only log with one record per line:
+//
+//   + <key ID> <hex initialization vector> <path>   adds an entry
+//   - <path>                                        removes an entry
+//   > <source path>\t<destination path>             a rename has begun
+//   < <source path>\t<destination path>             a rename has finished
+//
+// Paths inside the database directory are relative to it. An entry is added
+// before the file it describes is created and removed after the file is
+// gone, so a crash can only leave behind entries for files which don't
+// exist; those are dropped when the registry is loaded. A rename whose end
+// wasn't recorded is completed when the registry is loaded if the source no
+// longer exists and the destination does. The log is rewritten without
+// obsolete records when the registry is loaded and when most of it is
+// obsolete.
+class FileRegistry {
+ public:
+  FileRegistry(rocksdb::Env* env, const std::string& db_dir)
+      : env_(env),
+        db_dir_(db_dir),
+        records_(0) {
+    while (db_dir_.size() > 1 && db_dir_.back() == '/') {
+      db_dir_.pop_back();
+    }
+  }
+
+  // Load reads the registry from disk and brings it in line with the files
+  // which exist.
+  rocksdb::Status Load() {
+    std::lock_guard<std::mutex> guard(mu_);
+    std::string contents;
+    rocksdb::Status status = rocksdb::ReadFileToString(env_, RegistryPath(), &contents);
+    if (status.IsNotFound()) {
+      contents.clear();
+    } else if (!status.ok()) {
+      return status;
+    }
+    std::vector<std::pair<std::string, std::string>> pending;
+    std::istringstream in(contents);
+    std::string line;
+    bool header = true;
+    while (std::getline(in, line)) {
+      if (header) {
+        if (line != kFileRegistryHeader) {
+          return rocksdb::Status::Corruption("unknown file registry format", line);
+        }
+        header = false;
+        continue;
+      }
+      if (line.size() < 3 || line[1] != ' ') {
+        return rocksdb::Status::Corruption("invalid file registry record", line);
+      }
+      const std::string rest = line.substr(2);
+      switch (line[0]) {
+        case '+': {
+          std::istringstream fields(rest);
+          std::string key_id, iv_hex, name;
+          FileEncryption enc;
+          if (!(fields >> key_id >> iv_hex) || fields.get() != ' ' ||
+              !std::getline(fields, name) || !HexDecode(iv_hex, &enc.iv) ||
+              enc.iv.size() != kAESBlockSize) {
+            return rocksdb::Status::Corruption("invalid file registry record", line);
+          }
+          enc.key_id = key_id;
+          files_[name] = enc;
+          break;
+        }
+        case '-':
+          files_.erase(rest);
+          break;
+        case '>':
+        case '<': {
+          const size_t tab = rest.find('\t');
+          if (tab == std::string::npos) {
+            return rocksdb::Status::Corruption("invalid file registry record", line);
+          }
+          auto rename = std::make_pair(rest.substr(0, tab), rest.substr(tab + 1));
+          if (line[0] == '>') {
+            pending.push_back(rename);
+            break;
+          }
+          pending.erase(std::remove(pending.begin(), pending.end(), rename), pending.end());
+          MoveLocked(rename.first, rename.second);
+          break;
+        }
+        default:
+          return rocksdb::Status::Corruption("invalid file registry record", line);
+      }
+    }
+
+    for (const auto& rename : pending) {
+      const rocksdb::Status src = env_->FileExists(AbsolutePath(rename.first));
+      const rocksdb::Status dst = env_->FileExists(AbsolutePath(rename.second));
+      if (src.IsNotFound() && dst.ok()) {
+        MoveLocked(rename.first, rename.second);
+      }
+    }
+    for (auto it = files_.begin(); it != files_.end();) {
+      status = env_->FileExists(AbsolutePath(it->first));
+      if (status.IsNotFound()) {
+        it = files_.erase(it);
+      } else if (!status.ok()) {
+        return status;
+      } else {
+        ++it;
+      }
+    }
+    return RewriteLocked();
+  }
+
+  // Get looks up the encryption of a file. It returns false for files which
+  // predate encryption.
+  bool Get(const std::string& fname, FileEncryption* enc) {
+    std::lock_guard<std::mutex> guard(mu_);
+    auto it = files_.find(RelativePath(fname));
+    if (it == files_.end()) {
+      return false;
+    }
+    *enc = it->second;
+    return true;
+  }
+
+  // Put records the encryption of a file.
+  rocksdb::Status Put(const std::string& fname, const FileEncryption& enc) {
+    std::lock_guard<std::mutex> guard(mu_);
+    const std::string name = RelativePath(fname);
+    rocksdb::Status status = CheckName(name);
+    if (!status.ok()) {
+      return status;
+    }
+    files_[name] = enc;
+    return AppendAndMaybeRewriteLocked(AddRecord(name, enc));
+  }
+
+  // Remove records that a file no longer exists.
+  rocksdb::Status Remove(const std::string& fname) {
+    std::lock_guard<std::mutex> guard(mu_);
+    const std::string name = RelativePath(fname);
+    if (files_.erase(name) == 0) {
+      return rocksdb::Status::OK();
+    }
+    return AppendAndMaybeRewriteLocked("- " + name);
+  }
+
+  // Link records that dst is a new link to the file src.
+  rocksdb::Status Link(const std::string& src, const std::string& dst) {
+    std::lock_guard<std::mutex> guard(mu_);
+    const std::string dst_name = RelativePath(dst);
+    rocksdb::Status status = CheckName(dst_name);
+    if (!status.ok()) {
+      return status;
+    }
+    auto it = files_.find(RelativePath(src));
+    if (it == files_.end()) {
+      // Drop any stale entry of the destination, which doesn't exist yet.
+      if (files_.erase(dst_name) == 0) {
+        return rocksdb::Status::OK();
+      }
+      return AppendAndMaybeRewriteLocked("- " + dst_name);
+    }
+    files_[dst_name] = it->second;
+    return AppendAndMaybeRewriteLocked(AddRecord(dst_name, it->second));
+  }
+
+  // Rename calls rename to rename the file or directory src to dst, and
+  // moves the entries of src and of the files under it along.
+  rocksdb::Status Rename(const std::string& src, const std::string& dst,
+                         const std::function<rocksdb::Status()>& rename) {
+    std::lock_guard<std::mutex> guard(mu_);
+    const std::string src_name = RelativePath(src);
+    const std::string dst_name = RelativePath(dst);
+    rocksdb::Status status = CheckName(src_name);
+    if (status.ok()) {
+      status = CheckName(dst_name);
+    }
+    if (!status.ok()) {
+      return status;
+    }
+    const std::string paths = src_name + "\t" + dst_name;
+    status = AppendLocked("> " + paths);
+    if (!status.ok()) {
+      return status;
+    }
+    status = rename();
+    if (!status.ok()) {
+      return status;
+    }
+    MoveLocked(src_name, dst_name);
+    return AppendAndMaybeRewriteLocked("< " + paths);
+  }
+
+  // KeyIDs returns the IDs of the keys used by the registered encrypted
+  // files.
+  std::vector<std::string> KeyIDs() {
+    std::lock_guard<std::mutex> guard(mu_);
+    std::vector<std::string> ids;
+    for (const auto& e : files_) {
+      if (e.second.key_id != kPlainKeyID &&
+          std::find(ids.begin(), ids.end(), e.second.key_id) == ids.end()) {
+        ids.push_back(e.second.key_id);
+      }
+    }
+    return ids;
+  }
+
+ private:
+  std::string RegistryPath() const {
+    return db_dir_ + "/" + kFileRegistryName;
+  }
+
+  std::string RelativePath(const std::string& fname) const {
+    if (fname.size() > db_dir_.size() && fname.compare(0, db_dir_.size(), db_dir_) == 0 &&
+        fname[db_dir_.size()] == '/') {
+      return fname.substr(db_dir_.size() + 1);
+    }
+    return fname;
+  }
+
+  std::string AbsolutePath(const std::string& name) const {
+    if (!name.empty() && name[0] == '/') {
+      return name;
+    }
+    return db_dir_ + "/" + name;
+  }
+
+  // CheckName verifies that a path can be recorded in the registry.
+  static rocksdb::Status CheckName(const std::string& name) {
+    if (name.empty() || name.find_first_of("\t\n") != std::string::npos) {
+      return rocksdb::Status::InvalidArgument("unsupported file name for encryption", name);
+    }
+    return rocksdb::Status::OK();
+  }
+
+  static std::string AddRecord(const std::string& name, const FileEncryption& enc) {
+    return "+ " + enc.key_id + " " + HexEncode(enc.iv) + " " + name;
+  }
+
+  // MoveLocked moves the entries of src and of the files under it to dst.
+  // If src predates encryption, so does dst afterwards.
+  void MoveLocked(const std::string& src, const std::string& dst) {
+    std::vector<std::pair<std::string, FileEncryption>> moved;
+    for (auto it = files_.begin(); it != files_.end();) {
+      if (it->first == src) {
+        moved.emplace_back(dst, it->second);
+      } else if (it->first.size() > src.size() && it->first.compare(0, src.size(), src) == 0 &&
+                 it->first[src.size()] == '/') {
+        moved.emplace_back(dst + it->first.substr(src.size()), it->second);
+      } else {
+        ++it;
+        continue;
+      }
+      it = files_.erase(it);
+    }
+    if (moved.empty()) {
+      files_.erase(dst);
+    }
+    for (const auto& e : moved) {
+      files_[e.first] = e.second;
+    }
+  }
+
+  // AppendLocked durably appends a record to the log.
+  rocksdb::Status AppendLocked(const std::string& record) {
+    rocksdb::Status status = log_->Append(record + "\n");
+    if (!status.ok()) {
+      return status;
+    }
+    status = log_->Sync();
+    if (!status.ok()) {
+      return status;
+    }
+    records_++;
+    return rocksdb::Status::OK();
+  }
+
+  // AppendAndMaybeRewriteLocked appends a record and rewrites the log once
+  // most of it is obsolete. It must not be used while a rename is in
+  // progress, as the rewrite would drop the record of its start.
+  rocksdb::Status AppendAndMaybeRewriteLocked(const std::string& record) {
+    rocksdb::Status status = AppendLocked(record);
+    if (!status.ok() || records_ <= 2 * files_.size() + 1000) {
+      return status;
+    }
+    return RewriteLocked();
+  }
+
+  // RewriteLocked replaces the log with one holding only the current
+  // entries and keeps it open for appending.
+  rocksdb::Status RewriteLocked() {
+    const std::string path = RegistryPath();
+    const std::string tmp_path = path + ".tmp";
+    std::unique_ptr<rocksdb::WritableFile> log;
+    rocksdb::Status status = env_->NewWritableFile(tmp_path, &log, rocksdb::EnvOptions());
+    if (!status.ok()) {
+      return status;
+    }
+    std::string contents = std::string(kFileRegistryHeader) + "\n";
+    for (const auto& e : files_) {
+      contents += AddRecord(e.first, e.second) + "\n";
+    }
+    status = log->Append(contents);
+    if (status.ok()) {
+      status = log->Sync();
+    }
+    if (status.ok()) {
+      status = env_->RenameFile(tmp_path, path);
+    }
+    if (status.ok()) {
+      std::unique_ptr<rocksdb::Directory> dir;
+      status = env_->NewDirectory(db_dir_, &dir);
+      if (status.ok()) {
+        status = dir->Fsync();
+      }
+    }
+    if (!status.ok()) {
+      return status;
+    }
+    // The renamed file is still open, so appends continue where the
+    // rewritten contents end.
+    log_ = std::move(log);
+    records_ = files_.size();
+    return rocksdb::Status::OK();
+  }
+
+  rocksdb::Env* const env_;
+  std::string db_dir_;
+  std::mutex mu_;
+  std::map<std::string, FileEncryption> files_;
+  std::unique_ptr<rocksdb::WritableFile> log_;
+  // records_ is the number of records in the log.
+  size_t records_;
+};
+
+class EncryptedSequentialFile : public rocksdb::SequentialFile {
+ public:
+  EncryptedSequentialFile(std::unique_ptr<rocksdb::SequentialFile> file,
+                          std::unique_ptr<CTRCipherStream> stream)
+      : file_(std::move(file)),
+        stream_(std::move(stream)),
+        offset_(0) {
+  }
+
+  virtual rocksdb::Status Read(size_t n, rocksdb::Slice* result, char* scratch) override {
+    rocksdb::Status status = file_->Read(n, result, scratch);
+    if (!status.ok()) {
+      return status;
+    }
+    if (result->data() != scratch) {
+      memcpy(scratch, result->data(), result->size());
+    }
+    if (!stream_->Crypt(offset_, scratch, result->size())) {
+      return rocksdb::Status::IOError("decrypting file");
+    }
+    *result = rocksdb::Slice(scratch, result->size());
+    offset_ += result->size();
+    return status;
+  }
+
+  virtual rocksdb::Status Skip(uint64_t n) override {
+    rocksdb::Status status = file_->Skip(n);
+    if (status.ok()) {
+      offset_ += n;
+    }
+    return status;
+  }
+
+  virtual rocksdb::Status InvalidateCache(size_t offset, size_t length) override {
+    return file_->InvalidateCache(offset, length);
+  }
+
+ private:
+  std::unique_ptr<rocksdb::SequentialFile> file_;
+  std::unique_ptr<CTRCipherStream> stream_;
+  uint64_t offset_;
+};
+
+class EncryptedRandomAccessFile : public rocksdb::RandomAccessFile {
+ public:
+  EncryptedRandomAccessFile(std::unique_ptr<rocksdb::RandomAccessFile> file,
+                            std::unique_ptr<CTRCipherStream> stream)
+      : file_(std::move(file)),
+        stream_(std::move(stream)) {
+  }
+
+  virtual rocksdb::Status Read(uint64_t offset, size_t n, rocksdb::Slice* result,
+                               char* scratch) const override {
+    rocksdb::Status status = file_->Read(offset, n, result, scratch);
+    if (!status.ok()) {
+      return status;
+    }
+    // The data may have been returned from a memory mapping rather than in
+    // scratch, in which case it must not be decrypted in place.
+    if (result->data() != scratch) {
+      memcpy(scratch, result->data(), result->size());
+    }
+    if (!stream_->Crypt(offset, scratch, result->size())) {
+      return rocksdb::Status::IOError("decrypting file");
+    }
+    *result = rocksdb::Slice(scratch, result->size());
+    return status;
+  }
+
+  virtual rocksdb::Status Prefetch(uint64_t offset, size_t n) override {
+    return file_->Prefetch(offset, n);
+  }
+
+  virtual size_t GetUniqueId(char* id, size_t max_size) const override {
+    return file_->GetUniqueId(id, max_size);
+  }
+
+  virtual void Hint(AccessPattern pattern) override {
+    file_->Hint(pattern);
+  }
+
+  virtual rocksdb::Status InvalidateCache(size_t offset, size_t length) override {
+    return file_->InvalidateCache(offset, length);
+  }
+
+ private:
+  std::unique_ptr<rocksdb::RandomAccessFile> file_;
+  std::unique_ptr<CTRCipherStream> stream_;
+};
+
+class EncryptedWritableFile : public rocksdb::WritableFile {
+ public:
+  EncryptedWritableFile(std::unique_ptr<rocksdb::WritableFile> file,
+                        std::unique_ptr<CTRCipherStream> stream, uint64_t offset)
+      : file_(std::move(file)),
+        stream_(std::move(stream)),
+        offset_(offset) {
+  }
+
+  virtual rocksdb::Status Append(const rocksdb::Slice& data) override {
+    buf_.assign(data.data(), data.size());
+    if (!stream_->Crypt(offset_, &buf_[0], buf_.size())) {
+      return rocksdb::Status::IOError("encrypting file");
+    }
+    rocksdb::Status status = file_->Append(buf_);
+    if (status.ok()) {
+      offset_ += data.size();
+    }
+    return status;
+  }
+
+  virtual rocksdb::Status Truncate(uint64_t size) override {
+    rocksdb::Status status = file_->Truncate(size);
+    if (status.ok()) {
+      offset_ = size;
+    }
+    return status;
+  }
+
+  virtual rocksdb::Status Close() override { return file_->Close(); }
+  virtual rocksdb::Status Flush() override { return file_->Flush(); }
+  virtual rocksdb::Status Sync() override { return file_->Sync(); }
+  virtual rocksdb::Status Fsync() override { return file_->Fsync(); }
+  virtual bool IsSyncThreadSafe() const override { return file_->IsSyncThreadSafe(); }
+  virtual uint64_t GetFileSize() override { return file_->GetFileSize(); }
+
+  virtual rocksdb::Status RangeSync(uint64_t offset, uint64_t nbytes) override {
+    return file_->RangeSync(offset, nbytes);
+  }
+
+  virtual rocksdb::Status Allocate(uint64_t offset, uint64_t len) override {
+    return file_->Allocate(offset, len);
+  }
+
+  virtual rocksdb::Status InvalidateCache(size_t offset, size_t length) override {
+    return file_->InvalidateCache(offset, length);
+  }
+
+  virtual size_t GetUniqueId(char* id, size_t max_size) const override {
+    return file_->GetUniqueId(id, max_size);
+  }
+
+ private:
+  std::unique_ptr<rocksdb::WritableFile> file_;
+  std::unique_ptr<CTRCipherStream> stream_;
+  uint64_t offset_;
+  // buf_ holds the encrypted data of the current append.
+  std::string buf_;
+};
+
+// EncryptedEnv encrypts the files of a database directory. See
+// NewEncryptedEnv.
+class EncryptedEnv : public rocksdb::EnvWrapper {
+ public:
+  EncryptedEnv(rocksdb::Env* base_env, std::unique_ptr<FileRegistry> registry,
+               std::map<std::string, std::unique_ptr<AESCipher>> ciphers,
+               const std::string& active_key_id)
+      : rocksdb::EnvWrapper(base_env),
+        registry_(std::move(registry)),
+        ciphers_(std::move(ciphers)),
+        active_key_id_(active_key_id) {
+  }
+
+  virtual rocksdb::Status NewSequentialFile(
+      const std::string& fname, std::unique_ptr<rocksdb::SequentialFile>* result,
+      const rocksdb::EnvOptions& options) override {
+    std::unique_ptr<CTRCipherStream> stream;
+    rocksdb::Status status = ExistingStream(fname, options, &stream);
+    if (!status.ok()) {
+      return status;
+    }
+    std::unique_ptr<rocksdb::SequentialFile> file;
+    status = target()->NewSequentialFile(fname, &file, options);
+    if (!status.ok() || stream == nullptr) {
+      *result = std::move(file);
+      return status;
+    }
+    result->reset(new EncryptedSequentialFile(std::move(file), std::move(stream)));
+    return status;
+  }
+
+  virtual rocksdb::Status NewRandomAccessFile(
+      const std::string& fname, std::unique_ptr<rocksdb::RandomAccessFile>* result,
+      const rocksdb::EnvOptions& options) override {
+    std::unique_ptr<CTRCipherStream> stream;
+    rocksdb::Status status = ExistingStream(fname, options, &stream);
+    if (!status.ok()) {
+      return status;
+    }
+    std::unique_ptr<rocksdb::RandomAccessFile> file;
+    status = target()->NewRandomAccessFile(fname, &file, options);
+    if (!status.ok() || stream == nullptr) {
+      *result = std::move(file);
+      return status;
+    }
+    result->reset(new EncryptedRandomAccessFile(std::move(file), std::move(stream)));
+    return status;
+  }
+
+  virtual rocksdb::Status NewWritableFile(
+      const std::string& fname, std::unique_ptr<rocksdb::WritableFile>* result,
+      const rocksdb::EnvOptions& options) override {
+    std::unique_ptr<CTRCipherStream> stream;
+    rocksdb::Status status = NewStream(fname, options, &stream);
+    if (!status.ok()) {
+      return status;
+    }
+    std::unique_ptr<rocksdb::WritableFile> file;
+    status = target()->NewWritableFile(fname, &file, options);
+    if (!status.ok() || stream == nullptr) {
+      *result = std::move(file);
+      return status;
+    }
+    result->reset(new EncryptedWritableFile(std::move(file), std::move(stream), 0));
+    return status;
+  }
+
+  virtual rocksdb::Status ReopenWritableFile(
+      const std::string& fname, std::unique_ptr<rocksdb::WritableFile>* result,
+      const rocksdb::EnvOptions& options) override {
+    // Reopening a file which doesn't exist creates it.
+    std::unique_ptr<CTRCipherStream> stream;
+    rocksdb::Status status = target()->FileExists(fname);
+    if (status.IsNotFound()) {
+      status = NewStream(fname, options, &stream);
+    } else if (status.ok()) {
+      status = ExistingStream(fname, options, &stream);
+    }
+    if (!status.ok()) {
+      return status;
+    }
+    std::unique_ptr<rocksdb::WritableFile> file;
+    status = target()->ReopenWritableFile(fname, &file, options);
+    if (!status.ok() || stream == nullptr) {
+      *result = std::move(file);
+      return status;
+    }
+    const uint64_t size = file->GetFileSize();
+    result->reset(new EncryptedWritableFile(std::move(file), std::move(stream), size));
+    return status;
+  }
+
+  virtual rocksdb::Status ReuseWritableFile(
+      const std::string& fname, const std::string& old_fname,
+      std::unique_ptr<rocksdb::WritableFile>* result,
+      const rocksdb::EnvOptions& options) override {
+    // The reused file gets a new initialization vector, so its old
+    // contents are of no use.
+    rocksdb::Status status = RenameFile(old_fname, fname);
+    if (!status.ok()) {
+      return status;
+    }
+    return NewWritableFile(fname, result, options);
+  }
+
+  virtual rocksdb::Status DeleteFile(const std::string& fname) override {
+    rocksdb::Status status = target()->DeleteFile(fname);
+    if (!status.ok()) {
+      return status;
+    }
+    return registry_->Remove(fname);
+  }
+
+  virtual rocksdb::Status RenameFile(const std::string& src, const std::string& dst) override {
+    return registry_->Rename(src, dst, [&]() { return target()->RenameFile(src, dst); });
+  }
+
+  virtual rocksdb::Status LinkFile(const std::string& src, const std::string& dst) override {
+    rocksdb::Status status = registry_->Link(src, dst);
+    if (!status.ok()) {
+      return status;
+    }
+    return target()->LinkFile(src, dst);
+  }
+
+ private:
+  // ExistingStream sets stream to the cipher stream of an existing file,
+  // or to nullptr if the file is plaintext.
+  rocksdb::Status ExistingStream(const std::string& fname, const rocksdb::EnvOptions& options,
+                                 std::unique_ptr<CTRCipherStream>* stream) {
+    FileEncryption enc;
+    if (!registry_->Get(fname, &enc) || enc.key_id == kPlainKeyID) {
+      stream->reset();
+      return rocksdb::Status::OK();
+    }
+    if (options.use_direct_reads || options.use_direct_writes) {
+      return rocksdb::Status::NotSupported("direct I/O on encrypted files", fname);
+    }
+    auto it = ciphers_.find(enc.key_id);
+    if (it == ciphers_.end()) {
+      return rocksdb::Status::InvalidArgument("missing encryption key " + enc.key_id, fname);
+    }
+    stream->reset(new CTRCipherStream(it->second.get(), enc.iv));
+    return rocksdb::Status::OK();
+  }
+
+  // NewStream records the encryption of a file which is about to be
+  // created in the registry and sets stream to its cipher stream, or to
+  // nullptr if new files are plaintext.
+  rocksdb::Status NewStream(const std::string& fname, const rocksdb::EnvOptions& options,
+                            std::unique_ptr<CTRCipherStream>* stream) {
+    FileEncryption enc;
+    enc.key_id = active_key_id_;
+    auto it = ciphers_.find(active_key_id_);
+    if (it == ciphers_.end()) {
+      enc.iv.assign(kAESBlockSize, '\0');
+      stream->reset();
+      return registry_->Put(fname, enc);
+    }
+    if (options.use_direct_reads || options.use_direct_writes) {
+      return rocksdb::Status::NotSupported("direct I/O on encrypted files", fname);
+    }
+    // Counter mode is only secure if no two files encrypted with the same
+    // key share a counter block, so the initialization vectors must come
+    // from a cryptographically secure generator.
+    enc.iv.assign(kAESBlockSize, '\0');
+    if (!RandomBytes(&enc.iv[0], enc.iv.size())) {
+      return rocksdb::Status::IOError("generating initialization vector", fname);
+    }
+    rocksdb::Status status = registry_->Put(fname, enc);
+    if (!status.ok()) {
+      return status;
+    }
+    stream->reset(new CTRCipherStream(it->second.get(), enc.iv));
+    return rocksdb::Status::OK();
+  }
+
+  std::unique_ptr<FileRegistry> registry_;
+  std::map<std::string, std::unique_ptr<AESCipher>> ciphers_;
+  const std::string active_key_id_;
+};
+
+}  // namespace
+
+rocksdb::Status NewEncryptedEnv(
+    rocksdb::Env* base_env, const std::string& db_dir,
+    const std::map<std::string, std::string>& keys, const std::string& active_key_id,
+    rocksdb::Env** result) {
+  std::map<std::string, std::unique_ptr<AESCipher>> ciphers;
+  for (const auto& k : keys) {
+    std::unique_ptr<AESCipher> cipher(new AESCipher);
+    if (!cipher->Init(k.second)) {
+      return rocksdb::Status::InvalidArgument("invalid AES key size for key " + k.first);
+    }
+    ciphers[k.first] = std::move(cipher);
+  }
+  if (active_key_id != kPlainKeyID && ciphers.find(active_key_id) == ciphers.end()) {
+    return rocksdb::Status::InvalidArgument("unknown active key " + active_key_id);
+  }
+
+  rocksdb::Status status = base_env->CreateDirIfMissing(db_dir);
+  if (!status.ok()) {
+    return status;
+  }
+  std::unique_ptr<FileRegistry> registry(new FileRegistry(base_env, db_dir));
+  status = registry->Load();
+  if (!status.ok()) {
+    return status;
+  }
+  for (const auto& id : registry->KeyIDs()) {
+    if (ciphers.find(id) == ciphers.end()) {
+      return rocksdb::Status::InvalidArgument(
+          "store has files encrypted with key " + id + ", which was not provided");
+    }
+  }
+  *result = new EncryptedEnv(base_env, std::move(registry), std::move(ciphers), active_key_id);
+  return rocksdb::Status::OK();
+}
@@ -0,0 +1,36 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/LICENSE
+
+#ifndef ROACHLIB_CCL_ENCRYPTED_ENV_H
+#define ROACHLIB_CCL_ENCRYPTED_ENV_H
+
+#include <map>
+#include <string>
+#include <rocksdb/env.h>
+
+// kPlainKeyID is the key ID of files which are not encrypted.
+extern const char* const kPlainKeyID;
+
+// kFileRegistryName is the name of the file, in the database directory,
+// which records the key and initialization vector used for each encrypted
+// file.
+extern const char* const kFileRegistryName;
+
+// NewEncryptedEnv returns an Env which encrypts the files it creates with
+// AES in counter mode, using the key named by active_key_id, or writes them
+// in plaintext if active_key_id is kPlainKeyID. Existing files are decrypted
+// with the key recorded for them in the file registry in db_dir; files
+// missing from the registry are plaintext. keys maps key IDs to AES keys and
+// must contain every key referenced by the registry. The caller takes
+// ownership of the returned Env, which must outlive any database using it.
+rocksdb::Status NewEncryptedEnv(
+    rocksdb::Env* base_env, const std::string& db_dir,
+    const std::map<std::string, std::string>& keys, const std::string& active_key_id,
+    rocksdb::Env** result);
+
+#endif  // ROACHLIB_CCL_ENCRYPTED_ENV_H
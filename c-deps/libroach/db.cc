@@ -83,13 +83,16 @@ struct DBEngine {
   virtual DBStatus GetStats(DBStatsResult* stats) = 0;
   virtual DBString GetCompactionStats() = 0;
   virtual DBStatus EnvWriteFile(DBSlice path, DBSlice contents) = 0;
+  virtual DBStatus EnvReadFile(DBSlice path, DBString* contents) = 0;
+  virtual DBStatus EnvDeleteFile(DBSlice path) = 0;
+  virtual DBStatus EnvRenameFile(DBSlice old_path, DBSlice new_path) = 0;
 
   DBSSTable* GetSSTables(int* n);
   DBString GetUserProperties();
 };
 
 struct DBImpl : public DBEngine {
-  std::unique_ptr<rocksdb::Env> memenv;
+  std::unique_ptr<rocksdb::Env> env;
   std::unique_ptr<rocksdb::DB> rep_deleter;
   std::shared_ptr<rocksdb::Cache> block_cache;
   std::shared_ptr<DBEventListener> event_listener;
@@ -100,7 +103,7 @@ struct DBImpl : public DBEngine {
   DBImpl(rocksdb::DB* r, rocksdb::Env* m, std::shared_ptr<rocksdb::Cache> bc,
     std::shared_ptr<DBEventListener> event_listener)
       : DBEngine(r),
-        memenv(m),
+        env(m),
         rep_deleter(r),
         block_cache(bc),
         event_listener(event_listener) {
@@ -125,6 +128,9 @@ struct DBImpl : public DBEngine {
   virtual DBStatus GetStats(DBStatsResult* stats);
   virtual DBString GetCompactionStats();
   virtual DBStatus EnvWriteFile(DBSlice path, DBSlice contents);
+  virtual DBStatus EnvReadFile(DBSlice path, DBString* contents);
+  virtual DBStatus EnvDeleteFile(DBSlice path);
+  virtual DBStatus EnvRenameFile(DBSlice old_path, DBSlice new_path);
 };
 
 struct DBBatch : public DBEngine {
@@ -147,6 +153,9 @@ struct DBBatch : public DBEngine {
   virtual DBStatus GetStats(DBStatsResult* stats);
   virtual DBString GetCompactionStats();
   virtual DBStatus EnvWriteFile(DBSlice path, DBSlice contents);
+  virtual DBStatus EnvReadFile(DBSlice path, DBString* contents);
+  virtual DBStatus EnvDeleteFile(DBSlice path);
+  virtual DBStatus EnvRenameFile(DBSlice old_path, DBSlice new_path);
 };
 
 struct DBWriteOnlyBatch : public DBEngine {
@@ -169,6 +178,9 @@ struct DBWriteOnlyBatch : public DBEngine {
   virtual DBStatus GetStats(DBStatsResult* stats);
   virtual DBString GetCompactionStats();
   virtual DBStatus EnvWriteFile(DBSlice path, DBSlice contents);
+  virtual DBStatus EnvReadFile(DBSlice path, DBString* contents);
+  virtual DBStatus EnvDeleteFile(DBSlice path);
+  virtual DBStatus EnvRenameFile(DBSlice old_path, DBSlice new_path);
 };
 
 struct DBSnapshot : public DBEngine {
@@ -193,6 +205,9 @@ struct DBSnapshot : public DBEngine {
   virtual DBStatus GetStats(DBStatsResult* stats);
   virtual DBString GetCompactionStats();
   virtual DBStatus EnvWriteFile(DBSlice path, DBSlice contents);
+  virtual DBStatus EnvReadFile(DBSlice path, DBString* contents);
+  virtual DBStatus EnvDeleteFile(DBSlice path);
+  virtual DBStatus EnvRenameFile(DBSlice old_path, DBSlice new_path);
 };
 
 struct DBIterator {
@@ -1672,6 +1687,27 @@ rocksdb::Options DBMakeOptions(DBOptions db_opts) {
   return options;
 }
 
+namespace {
+
+// DBOpenHookOSS is the default open hook. Extra options are only
+// understood by CCL code.
+rocksdb::Status DBOpenHookOSS(const std::string& db_dir, const DBOptions db_opts,
+                              rocksdb::Env** env) {
+  if (db_opts.extra_options.len != 0) {
+    return rocksdb::Status::InvalidArgument(
+        "DBOptions has extra_options, but OSS code cannot handle them");
+  }
+  return rocksdb::Status::OK();
+}
+
+DBOpenHook db_open_hook = DBOpenHookOSS;
+
+}  // namespace
+
+void DBSetOpenHook(DBOpenHook hook) {
+  db_open_hook = hook;
+}
+
 DBStatus DBOpen(DBEngine **db, DBSlice dir, DBOptions db_opts) {
   rocksdb::Options options = DBMakeOptions(db_opts);
 
@@ -1679,10 +1715,19 @@ DBStatus DBOpen(DBEngine **db, DBSlice dir, DBOptions db_opts) {
   std::shared_ptr<DBEventListener> event_listener(new DBEventListener);
   options.listeners.emplace_back(event_listener);
 
-  std::unique_ptr<rocksdb::Env> memenv;
+  std::unique_ptr<rocksdb::Env> env;
   if (dir.len == 0) {
-    memenv.reset(rocksdb::NewMemEnv(rocksdb::Env::Default()));
-    options.env = memenv.get();
+    env.reset(rocksdb::NewMemEnv(rocksdb::Env::Default()));
+  } else {
+    rocksdb::Env* hook_env = nullptr;
+    rocksdb::Status status = db_open_hook(ToString(dir), db_opts, &hook_env);
+    if (!status.ok()) {
+      return ToDBStatus(status);
+    }
+    env.reset(hook_env);
+  }
+  if (env != nullptr) {
+    options.env = env.get();
   }
 
   rocksdb::DB *db_ptr;
@@ -1690,7 +1735,7 @@ DBStatus DBOpen(DBEngine **db, DBSlice dir, DBOptions db_opts) {
   if (!status.ok()) {
     return ToDBStatus(status);
   }
-  *db = new DBImpl(db_ptr, memenv.release(),
+  *db = new DBImpl(db_ptr, env.release(),
       db_opts.cache != nullptr ? db_opts.cache->rep : nullptr,
       event_listener);
   return kSuccess;
@@ -2125,6 +2170,75 @@ DBStatus DBEnvWriteFile(DBEngine* db, DBSlice path, DBSlice contents) {
   return db->EnvWriteFile(path, contents);
 }
 
+// EnvReadFile reads the contents of the given "file" in the given engine.
+DBStatus DBImpl::EnvReadFile(DBSlice path, DBString* contents) {
+  std::string data;
+  rocksdb::Status s = rocksdb::ReadFileToString(this->rep->GetEnv(), ToString(path), &data);
+  if (!s.ok()) {
+    return ToDBStatus(s);
+  }
+  *contents = ToDBString(data);
+  return kSuccess;
+}
+
+DBStatus DBBatch::EnvReadFile(DBSlice path, DBString* contents) {
+  return FmtStatus("unsupported");
+}
+
+DBStatus DBWriteOnlyBatch::EnvReadFile(DBSlice path, DBString* contents) {
+  return FmtStatus("unsupported");
+}
+
+DBStatus DBSnapshot::EnvReadFile(DBSlice path, DBString* contents) {
+  return FmtStatus("unsupported");
+}
+
+DBStatus DBEnvReadFile(DBEngine* db, DBSlice path, DBString* contents) {
+  return db->EnvReadFile(path, contents);
+}
+
+// EnvDeleteFile deletes the given "file" in the given engine.
+DBStatus DBImpl::EnvDeleteFile(DBSlice path) {
+  return ToDBStatus(this->rep->GetEnv()->DeleteFile(ToString(path)));
+}
+
+DBStatus DBBatch::EnvDeleteFile(DBSlice path) {
+  return FmtStatus("unsupported");
+}
+
+DBStatus DBWriteOnlyBatch::EnvDeleteFile(DBSlice path) {
+  return FmtStatus("unsupported");
+}
+
+DBStatus DBSnapshot::EnvDeleteFile(DBSlice path) {
+  return FmtStatus("unsupported");
+}
+
+DBStatus DBEnvDeleteFile(DBEngine* db, DBSlice path) {
+  return db->EnvDeleteFile(path);
+}
+
+// EnvRenameFile renames the given "file" in the given engine.
+DBStatus DBImpl::EnvRenameFile(DBSlice old_path, DBSlice new_path) {
+  return ToDBStatus(this->rep->GetEnv()->RenameFile(ToString(old_path), ToString(new_path)));
+}
+
+DBStatus DBBatch::EnvRenameFile(DBSlice old_path, DBSlice new_path) {
+  return FmtStatus("unsupported");
+}
+
+DBStatus DBWriteOnlyBatch::EnvRenameFile(DBSlice old_path, DBSlice new_path) {
+  return FmtStatus("unsupported");
+}
+
+DBStatus DBSnapshot::EnvRenameFile(DBSlice old_path, DBSlice new_path) {
+  return FmtStatus("unsupported");
+}
+
+DBStatus DBEnvRenameFile(DBEngine* db, DBSlice old_path, DBSlice new_path) {
+  return db->EnvRenameFile(old_path, new_path);
+}
+
 DBIterator* DBNewIter(DBEngine* db, bool prefix) {
   rocksdb::ReadOptions opts;
   opts.prefix_same_as_start = prefix;
@@ -14,6 +14,7 @@
 
 #include <rocksdb/iterator.h>
 #include <rocksdb/comparator.h>
+#include <rocksdb/env.h>
 #include <rocksdb/write_batch.h>
 #include <rocksdb/write_batch_base.h>
 #include <libroach.h>
@@ -32,6 +33,16 @@ DBStatus ToDBStatus(const rocksdb::Status& status);
 // FmtStatus formats the given arguments printf-style into a DBStatus.
 DBStatus FmtStatus(const char *fmt, ...);
 
+// DBOpenHook is called by DBOpen before opening an on-disk database. It
+// interprets the extra options and may set *env to an Env to open the
+// database with, which the database then owns.
+typedef rocksdb::Status (*DBOpenHook)(const std::string& db_dir, const DBOptions opts,
+                                      rocksdb::Env** env);
+
+// DBSetOpenHook replaces the open hook. The default hook fails if any
+// extra options are given.
+void DBSetOpenHook(DBOpenHook hook);
+
 // CockroachComparator returns CockroachDB's custom mvcc-aware RocksDB
 // comparator. The caller does not assume ownership.
 const ::rocksdb::Comparator* CockroachComparator();
@@ -71,6 +71,10 @@ typedef struct {
   bool logging_enabled;
   int num_cpu;
   int max_open_files;
+  // extra_options holds options which are opaque to libroach and are
+  // interpreted by the open hook. The CCL hook uses them to configure
+  // encryption at rest.
+  DBSlice extra_options;
 } DBOptions;
 
 // Create a new cache with the specified size.
@@ -291,6 +295,15 @@ void DBRunLDB(int argc, char** argv);
 // DBEnvWriteFile writes the given data as a new "file" in the given engine.
 DBStatus DBEnvWriteFile(DBEngine* db, DBSlice path, DBSlice contents);
 
+// DBEnvReadFile reads the contents of the given "file" in the given engine.
+DBStatus DBEnvReadFile(DBEngine* db, DBSlice path, DBString* contents);
+
+// DBEnvDeleteFile deletes the given "file" in the given engine.
+DBStatus DBEnvDeleteFile(DBEngine* db, DBSlice path);
+
+// DBEnvRenameFile renames the given "file" in the given engine.
+DBStatus DBEnvRenameFile(DBEngine* db, DBSlice old_path, DBSlice new_path);
+
 #ifdef __cplusplus
 }  // extern "C"
 #endif
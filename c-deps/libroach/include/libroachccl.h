@@ -20,6 +20,16 @@ extern "C" {
 DBStatus DBBatchReprVerify(
   DBSlice repr, DBKey start, DBKey end, int64_t now_nanos, MVCCStatsResult* stats);
 
+// DBSetOpenHookCCL installs the CCL open hook, which sets up encryption at
+// rest as configured by the extra options of DBOpen.
+void DBSetOpenHookCCL();
+
+// DBEncryptCTR encrypts or decrypts data in place with the AES counter mode
+// cipher stream used for encrypted files, as if data started at the given
+// offset of a file with the given key and initialization vector. It is
+// exposed for testing.
+DBStatus DBEncryptCTR(DBSlice key, DBSlice iv, uint64_t offset, DBSlice data);
+
 #ifdef __cplusplus
 }  // extern "C"
 #endif
@@ -51,6 +51,10 @@ type StoreSpec struct {
 	SizePercent float64
 	InMemory    bool
 	Attributes  roachpb.Attributes
+	// ExtraOptions is set by Go CCL code and passed through to the engine
+	// (see engine.RocksDBConfig). It is not part of the string
+	// representation.
+	ExtraOptions []byte
 }
 
 // String returns a fully parsable version of the store spec.
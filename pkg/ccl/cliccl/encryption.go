@@ -0,0 +1,250 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/LICENSE
+
+package cliccl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl/engineccl"
+	"github.com/cockroachdb/cockroach/pkg/cli"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	// New files are encrypted with key. When rotating keys, old-key is the key
+	// the store was last started with, which is needed to read the files
+	// written with it until they have been rewritten.
+	cli.StartCmd.Flags().Var(&storeEncryptionSpecs, "enterprise-encryption",
+		"encryption at rest for a store, as path=<store dir>,key=<key file>[,old-key=<key file>]; "+
+			`key files hold a raw 128, 192 or 256 bit AES key, or are "plain" for no encryption`)
+	cli.PopulateStoreSpecsHook = populateStoreSpecsEncryption
+
+	debugEncryptionStatusCmd := &cobra.Command{
+		Use:   "encryption-status <directory>",
+		Short: "show the encryption status of the files in a store",
+		Long: `
+List the files of a store along with the ID of the key each one is
+encrypted with, or "plain" for unencrypted files. The store must not be
+running. After a key rotation, files using the old key are rewritten with
+the new key as they are compacted; the old key is no longer needed once
+no file uses it.
+`,
+		RunE: cli.MaybeDecorateGRPCError(runDebugEncryptionStatus),
+	}
+	cli.DebugCmd.AddCommand(debugEncryptionStatusCmd)
+}
+
+// plainKeyFile is the key file name which stands for writing or reading
+// files in plaintext.
+const plainKeyFile = "plain"
+
+// storeEncryptionSpec is the encryption configuration of a store, as given
+// by an --enterprise-encryption flag.
+type storeEncryptionSpec struct {
+	path       string
+	keyFile    string
+	oldKeyFile string
+}
+
+// newStoreEncryptionSpec parses the value of an --enterprise-encryption
+// flag.
+func newStoreEncryptionSpec(value string) (storeEncryptionSpec, error) {
+	var es storeEncryptionSpec
+	for _, field := range strings.Split(value, ",") {
+		if len(field) == 0 {
+			continue
+		}
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 || len(kv[1]) == 0 {
+			return storeEncryptionSpec{}, errors.Errorf("%s field has no value", field)
+		}
+		switch kv[0] {
+		case "path":
+			es.path = kv[1]
+		case "key":
+			es.keyFile = kv[1]
+		case "old-key":
+			es.oldKeyFile = kv[1]
+		default:
+			return storeEncryptionSpec{}, errors.Errorf("%s is not a valid encryption field", kv[0])
+		}
+	}
+	if len(es.path) == 0 {
+		return storeEncryptionSpec{}, errors.New("no path specified")
+	}
+	if len(es.keyFile) == 0 {
+		return storeEncryptionSpec{}, errors.New("no key specified")
+	}
+	return es, nil
+}
+
+func (es storeEncryptionSpec) String() string {
+	s := fmt.Sprintf("path=%s,key=%s", es.path, es.keyFile)
+	if len(es.oldKeyFile) > 0 {
+		s += ",old-key=" + es.oldKeyFile
+	}
+	return s
+}
+
+// extraOptions loads the keys and encodes them as the extra options of the
+// store's engine.
+func (es storeEncryptionSpec) extraOptions() ([]byte, error) {
+	load := func(file string) (engineccl.EncryptionKey, error) {
+		if file == plainKeyFile {
+			return engineccl.PlainKey, nil
+		}
+		return engineccl.LoadEncryptionKey(file)
+	}
+	active, err := load(es.keyFile)
+	if err != nil {
+		return nil, err
+	}
+	var old []engineccl.EncryptionKey
+	if len(es.oldKeyFile) > 0 {
+		key, err := load(es.oldKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		old = append(old, key)
+	}
+	return engineccl.EncryptionOptions(active, old...)
+}
+
+// storeEncryptionSpecList implements pflag.Value for repeated
+// --enterprise-encryption flags.
+type storeEncryptionSpecList struct {
+	specs []storeEncryptionSpec
+}
+
+var storeEncryptionSpecs storeEncryptionSpecList
+
+func (l *storeEncryptionSpecList) String() string {
+	var specs []string
+	for _, es := range l.specs {
+		specs = append(specs, es.String())
+	}
+	return strings.Join(specs, "\n")
+}
+
+func (l *storeEncryptionSpecList) Type() string {
+	return "EncryptionSpec"
+}
+
+func (l *storeEncryptionSpecList) Set(value string) error {
+	es, err := newStoreEncryptionSpec(value)
+	if err != nil {
+		return err
+	}
+	l.specs = append(l.specs, es)
+	return nil
+}
+
+// populateStoreSpecsEncryption sets the encryption options of the stores
+// named by the --enterprise-encryption flags.
+func populateStoreSpecsEncryption(_ *cobra.Command, specs *base.StoreSpecList) error {
+	return applyStoreEncryptionSpecs(storeEncryptionSpecs.specs, specs)
+}
+
+func applyStoreEncryptionSpecs(
+	encryptionSpecs []storeEncryptionSpec, specs *base.StoreSpecList,
+) error {
+	for _, es := range encryptionSpecs {
+		path, err := filepath.Abs(es.path)
+		if err != nil {
+			return err
+		}
+		found := false
+		for i := range specs.Specs {
+			spec := &specs.Specs[i]
+			if spec.InMemory {
+				continue
+			}
+			if storePath, err := filepath.Abs(spec.Path); err != nil {
+				return err
+			} else if storePath != path {
+				continue
+			}
+			if spec.ExtraOptions != nil {
+				return errors.Errorf("store %s has more than one encryption spec", es.path)
+			}
+			if spec.ExtraOptions, err = es.extraOptions(); err != nil {
+				return errors.Wrapf(err, "store %s", es.path)
+			}
+			found = true
+			break
+		}
+		if !found {
+			return errors.Errorf("encryption spec for %s does not match any on-disk store", es.path)
+		}
+	}
+	return nil
+}
+
+func runDebugEncryptionStatus(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return errors.New("one argument is required")
+	}
+	dir := args[0]
+
+	files, err := engineccl.ReadFileRegistry(dir)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	if err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if name != engineccl.FileRegistryName {
+			names = append(names, filepath.ToSlash(name))
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	counts := map[string]int{}
+	tw := tabwriter.NewWriter(os.Stdout, 2, 1, 2, ' ', 0)
+	fmt.Fprintln(tw, "file\tkey")
+	for _, name := range names {
+		keyID, ok := files[name]
+		if !ok {
+			keyID = engineccl.PlainKeyID
+		}
+		counts[keyID]++
+		fmt.Fprintf(tw, "%s\t%s\n", name, keyID)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	var keyIDs []string
+	for keyID := range counts {
+		keyIDs = append(keyIDs, keyID)
+	}
+	sort.Strings(keyIDs)
+	fmt.Println()
+	for _, keyID := range keyIDs {
+		fmt.Printf("%s: %d files\n", keyID, counts[keyID])
+	}
+	return nil
+}
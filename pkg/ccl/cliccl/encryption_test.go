@@ -0,0 +1,97 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/LICENSE
+
+package cliccl
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestStoreEncryptionSpec(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testCases := []struct {
+		value    string
+		expected storeEncryptionSpec
+		err      string
+	}{
+		{"path=/a,key=/k", storeEncryptionSpec{path: "/a", keyFile: "/k"}, ""},
+		{"key=plain,path=/a,", storeEncryptionSpec{path: "/a", keyFile: "plain"}, ""},
+		{"path=/a,key=/k2,old-key=/k1", storeEncryptionSpec{path: "/a", keyFile: "/k2", oldKeyFile: "/k1"}, ""},
+		{"key=/k", storeEncryptionSpec{}, "no path specified"},
+		{"path=/a", storeEncryptionSpec{}, "no key specified"},
+		{"path=/a,key=", storeEncryptionSpec{}, "key= field has no value"},
+		{"path=/a,key=/k,size=1", storeEncryptionSpec{}, "size is not a valid encryption field"},
+	}
+	for _, tc := range testCases {
+		es, err := newStoreEncryptionSpec(tc.value)
+		if !testutils.IsError(err, tc.err) {
+			t.Errorf("%s: expected error %q, got %v", tc.value, tc.err, err)
+			continue
+		}
+		if es != tc.expected {
+			t.Errorf("%s: expected %+v, got %+v", tc.value, tc.expected, es)
+		}
+		if err == nil {
+			if again, err := newStoreEncryptionSpec(es.String()); err != nil || again != es {
+				t.Errorf("%s: round trip through %s failed: %+v, %v", tc.value, es, again, err)
+			}
+		}
+	}
+}
+
+func TestApplyStoreEncryptionSpecs(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	dir, cleanup := testutils.TempDir(t)
+	defer cleanup()
+	keyFile := filepath.Join(dir, "aes128.key")
+	if err := ioutil.WriteFile(keyFile, make([]byte, 16), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	makeSpecs := func() *base.StoreSpecList {
+		return &base.StoreSpecList{Specs: []base.StoreSpec{
+			{Path: "/mnt/a"}, {Path: "/mnt/b"}, {InMemory: true},
+		}}
+	}
+
+	specs := makeSpecs()
+	if err := applyStoreEncryptionSpecs([]storeEncryptionSpec{
+		{path: "/mnt/b/", keyFile: keyFile, oldKeyFile: "plain"},
+	}, specs); err != nil {
+		t.Fatal(err)
+	}
+	if specs.Specs[0].ExtraOptions != nil || specs.Specs[2].ExtraOptions != nil {
+		t.Errorf("unexpected encryption options: %+v", specs.Specs)
+	}
+	if len(specs.Specs[1].ExtraOptions) == 0 {
+		t.Errorf("expected encryption options for /mnt/b")
+	}
+
+	for _, tc := range []struct {
+		specs []storeEncryptionSpec
+		err   string
+	}{
+		{[]storeEncryptionSpec{{path: "/mnt/c", keyFile: keyFile}}, "does not match any on-disk store"},
+		{[]storeEncryptionSpec{{path: "/mnt/a", keyFile: filepath.Join(dir, "missing")}}, "could not read encryption key"},
+		{[]storeEncryptionSpec{
+			{path: "/mnt/a", keyFile: keyFile}, {path: "/mnt/a", keyFile: "plain"},
+		}, "more than one encryption spec"},
+	} {
+		if err := applyStoreEncryptionSpecs(tc.specs, makeSpecs()); !testutils.IsError(err, tc.err) {
+			t.Errorf("%+v: expected error %q, got %v", tc.specs, tc.err, err)
+		}
+	}
+}
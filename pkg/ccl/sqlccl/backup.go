@@ -62,6 +62,7 @@ var BackupImplicitSQLDescriptors = []sqlbase.Descriptor{
 	*sqlbase.WrapDescriptor(&sqlbase.SystemDB),
 	*sqlbase.WrapDescriptor(&sqlbase.DescriptorTable),
 	*sqlbase.WrapDescriptor(&sqlbase.UsersTable),
+	*sqlbase.WrapDescriptor(&sqlbase.RoleMembersTable),
 }
 
 // exportStorageFromURI returns an ExportStorage for the given URI.
@@ -182,6 +183,14 @@ func allRangeDescriptors(ctx context.Context, txn *client.Txn) ([]roachpb.RangeD
 func spansForAllTableIndexes(tables []*sqlbase.TableDescriptor) []roachpb.Span {
 	sstIntervalTree := interval.NewTree(interval.ExclusiveOverlapper)
 	for _, table := range tables {
+		if table.IsSequence() {
+			// A sequence has no indexes; its value is stored under
+			// keys.SequenceIndexID.
+			span := table.IndexSpan(keys.SequenceIndexID)
+			if err := sstIntervalTree.Insert(intervalSpan(span), false); err != nil {
+				panic(errors.Wrap(err, "IndexSpan"))
+			}
+		}
 		for _, index := range table.AllNonDropIndexes() {
 			if err := sstIntervalTree.Insert(intervalSpan(table.IndexSpan(index.ID)), false); err != nil {
 				panic(errors.Wrap(err, "IndexSpan"))
@@ -380,7 +389,7 @@ func makeBackupDescriptor(
 
 	for _, desc := range sqlDescs {
 		if dbDesc := desc.GetDatabase(); dbDesc != nil {
-			if err := p.CheckPrivilege(dbDesc, privilege.SELECT); err != nil {
+			if err := p.CheckPrivilege(ctx, dbDesc, privilege.SELECT); err != nil {
 				return BackupDescriptor{}, err
 			}
 		}
@@ -394,7 +403,7 @@ func makeBackupDescriptor(
 	}
 
 	for _, desc := range tables {
-		if err := p.CheckPrivilege(desc, privilege.SELECT); err != nil {
+		if err := p.CheckPrivilege(ctx, desc, privilege.SELECT); err != nil {
 			return BackupDescriptor{}, err
 		}
 	}
@@ -279,8 +279,8 @@ func backupAndRestore(
 			&unused, &unused, &unused, &exported.rows, &exported.idx, &exported.sys, &exported.bytes,
 		)
 		// When numAccounts == 0, our approxBytes formula breaks down because
-		// backups of no data still contain the system.users and system.descriptor
-		// tables. Just skip the check in this case.
+		// backups of no data still contain the system.users, system.role_members
+		// and system.descriptor tables. Just skip the check in this case.
 		if numAccounts > 0 {
 			approxBytes := int64(backupRestoreRowPayloadSize * numAccounts)
 			if max := approxBytes * 3; exported.bytes < approxBytes || exported.bytes > max {
@@ -414,6 +414,7 @@ func TestBackupRestoreSystemJobs(t *testing.T) {
 			keys.SystemDatabaseID,
 			keys.DescriptorTableID,
 			keys.UsersTableID,
+			keys.RoleMembersTableID,
 			sqlbase.ID(backupDatabaseID),
 			sqlbase.ID(backupTableID),
 		},
@@ -1793,6 +1794,53 @@ func TestRestoredPrivileges(t *testing.T) {
 	})
 }
 
+func TestRestoredRoles(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const numAccounts = 1
+	_, dir, _, sqlDB, cleanupFn := backupRestoreTestSetup(t, singleNode, numAccounts, initNone)
+	defer cleanupFn()
+
+	sqlDB.Exec(`CREATE ROLE readers`)
+	sqlDB.Exec(`CREATE ROLE writers`)
+	sqlDB.Exec(`CREATE USER someone`)
+	sqlDB.Exec(`CREATE USER nobody`)
+	sqlDB.Exec(`GRANT readers TO writers`)
+	sqlDB.Exec(`GRANT writers TO someone WITH ADMIN OPTION`)
+	sqlDB.Exec(`GRANT readers TO nobody`)
+	sqlDB.Exec(`BACKUP DATABASE data TO $1`, dir)
+
+	tc := testcluster.StartTestCluster(t, singleNode, base.TestClusterArgs{})
+	defer tc.Stopper().Stop(context.TODO())
+	sqlDBRestore := sqlutils.MakeSQLRunner(t, tc.Conns[0])
+	sqlDBRestore.Exec(`CREATE DATABASE data`)
+	// Users aren't restored, but the memberships of the existing ones are.
+	sqlDBRestore.Exec(`CREATE USER someone`)
+	sqlDBRestore.Exec(`RESTORE data.bank FROM $1`, dir)
+
+	sqlDBRestore.CheckQueryResults(`SHOW ROLES`, [][]string{{"readers"}, {"writers"}})
+	sqlDBRestore.CheckQueryResults(`SHOW USERS`, [][]string{{"someone"}})
+	sqlDBRestore.CheckQueryResults(
+		`SELECT role, member, "isAdmin" FROM system.role_members ORDER BY role, member`,
+		[][]string{
+			{"readers", "writers", "false"},
+			{"writers", "someone", "true"},
+		},
+	)
+
+	// Restoring again leaves the roles and memberships untouched.
+	sqlDBRestore.Exec(`REVOKE ADMIN OPTION FOR writers FROM someone`)
+	sqlDBRestore.Exec(`DROP TABLE data.bank`)
+	sqlDBRestore.Exec(`RESTORE data.bank FROM $1`, dir)
+	sqlDBRestore.CheckQueryResults(
+		`SELECT role, member, "isAdmin" FROM system.role_members ORDER BY role, member`,
+		[][]string{
+			{"readers", "writers", "false"},
+			{"writers", "someone", "false"},
+		},
+	)
+}
+
 func TestRestoreInto(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
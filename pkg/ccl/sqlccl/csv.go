@@ -567,7 +567,10 @@ func convertRecord(
 				}
 			}
 
-			row, err := sql.GenerateInsertRow(defaultExprs, ri.InsertColIDtoRowIndex, cols, evalCtx, tableDesc, datums)
+			row, err := sql.GenerateInsertRow(
+				defaultExprs, nil /* computeExprs */, ri.InsertColIDtoRowIndex, cols, evalCtx, tableDesc, datums,
+				nil, /* computedColsContainer */
+			)
 			if err != nil {
 				return errors.Wrapf(err, "generate insert row: %s: row %d", batch.file, rowNum)
 			}
@@ -285,7 +285,8 @@ func insertStmtToKVs(
 			}
 		}
 		row, err := sql.GenerateInsertRow(
-			defaultExprs, ri.InsertColIDtoRowIndex, cols, evalCtx, tableDesc, row,
+			defaultExprs, nil /* computeExprs */, ri.InsertColIDtoRowIndex, cols, evalCtx, tableDesc, row,
+			nil, /* computedColsContainer */
 		)
 		if err != nil {
 			return errors.Wrapf(err, "process insert %q", row)
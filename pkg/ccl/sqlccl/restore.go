@@ -9,6 +9,8 @@
 package sqlccl
 
 import (
+	"bytes"
+	"io/ioutil"
 	"math"
 	"runtime"
 	"sort"
@@ -20,6 +22,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl"
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl/engineccl"
 	"github.com/cockroachdb/cockroach/pkg/ccl/utilccl"
 	"github.com/cockroachdb/cockroach/pkg/ccl/utilccl/intervalccl"
 	"github.com/cockroachdb/cockroach/pkg/gossip"
@@ -31,6 +34,8 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlutil"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/interval"
@@ -203,7 +208,7 @@ func allocateTableRewrites(
 					return errors.Wrapf(err, "failed to lookup parent DB %d", parentID)
 				}
 
-				if err := p.CheckPrivilege(parentDB, privilege.CREATE); err != nil {
+				if err := p.CheckPrivilege(ctx, parentDB, privilege.CREATE); err != nil {
 					return err
 				}
 			}
@@ -698,6 +703,200 @@ func restoreTableDescs(
 	return errors.Wrap(err, "restoring table desc and namespace entries")
 }
 
+// backedUpTable returns the descriptor of the table with the given ID if all
+// of the backups contain it, and otherwise nil.
+func backedUpTable(backupDescs []BackupDescriptor, id sqlbase.ID) *sqlbase.TableDescriptor {
+	var table *sqlbase.TableDescriptor
+	for _, backupDesc := range backupDescs {
+		table = nil
+		for _, desc := range backupDesc.Descriptors {
+			if t := desc.GetTable(); t != nil && t.ID == id {
+				table = t
+			}
+		}
+		if table == nil {
+			return nil
+		}
+	}
+	return table
+}
+
+// readBackedUpRows reads the rows of the given table as of the time of the
+// last of the backups, directly from the backed up files. It is only meant
+// for small tables, as all of the rows are held in memory.
+func readBackedUpRows(
+	ctx context.Context, backupDescs []BackupDescriptor, table *sqlbase.TableDescriptor,
+) ([]parser.Datums, error) {
+	importSpans, _, err := makeImportSpans(
+		[]roachpb.Span{table.PrimaryIndexSpan()}, backupDescs, nil /* lowWaterMark */)
+	if err != nil {
+		return nil, err
+	}
+	var kvs []roachpb.KeyValue
+	for _, importSpan := range importSpans {
+		var iters []engine.SimpleIterator
+		for _, file := range importSpan.files {
+			dir, err := storageccl.MakeExportStorage(ctx, file.Dir)
+			if err != nil {
+				return nil, err
+			}
+			f, err := dir.ReadFile(ctx, file.Path)
+			if err != nil {
+				_ = dir.Close()
+				return nil, errors.Wrapf(err, "fetching %q", file.Path)
+			}
+			fileContents, err := ioutil.ReadAll(f)
+			_ = f.Close()
+			_ = dir.Close()
+			if err != nil {
+				return nil, errors.Wrapf(err, "fetching %q", file.Path)
+			}
+			if len(file.Sha512) > 0 {
+				checksum, err := storageccl.SHA512ChecksumData(fileContents)
+				if err != nil {
+					return nil, err
+				}
+				if !bytes.Equal(checksum, file.Sha512) {
+					return nil, errors.Errorf("checksum mismatch for %s", file.Path)
+				}
+			}
+			iter, err := engineccl.NewMemSSTIterator(fileContents, false)
+			if err != nil {
+				return nil, err
+			}
+			defer iter.Close()
+			iters = append(iters, iter)
+		}
+
+		// The newest version of each key comes first. Keys whose newest
+		// version is empty were deleted.
+		iter := engineccl.MakeMultiIterator(iters)
+		defer iter.Close()
+		endKey := engine.MVCCKey{Key: importSpan.Span.EndKey}
+		for iter.Seek(engine.MVCCKey{Key: importSpan.Span.Key}); ; iter.NextKey() {
+			if ok, err := iter.Valid(); err != nil {
+				return nil, err
+			} else if !ok || !iter.UnsafeKey().Less(endKey) {
+				break
+			}
+			if len(iter.UnsafeValue()) == 0 {
+				continue
+			}
+			kvs = append(kvs, roachpb.KeyValue{
+				Key:   append(roachpb.Key(nil), iter.UnsafeKey().Key...),
+				Value: roachpb.Value{RawBytes: append([]byte(nil), iter.UnsafeValue()...)},
+			})
+		}
+	}
+
+	colIdxMap := make(map[sqlbase.ColumnID]int, len(table.Columns))
+	valNeededForCol := make([]bool, len(table.Columns))
+	for i, col := range table.Columns {
+		colIdxMap[col.ID] = i
+		valNeededForCol[i] = true
+	}
+	var rf sqlbase.RowFetcher
+	if err := rf.Init(
+		table, colIdxMap, &table.PrimaryIndex, false /* reverse */, false, /* isSecondaryIndex */
+		table.Columns, valNeededForCol, false /* returnRangeInfo */, &sqlbase.DatumAlloc{},
+	); err != nil {
+		return nil, err
+	}
+	if err := rf.StartScanFrom(ctx, &sqlbase.SpanKVFetcher{KVs: kvs}); err != nil {
+		return nil, err
+	}
+	var rows []parser.Datums
+	for {
+		row, err := rf.NextRowDecoded(ctx, false /* traceKV */)
+		if err != nil {
+			return nil, err
+		}
+		if row == nil {
+			return rows, nil
+		}
+		rows = append(rows, append(parser.Datums(nil), row...))
+	}
+}
+
+// restoreRoles restores the roles, and the memberships in roles, contained in
+// the backups. Users are not restored, but the memberships of the users which
+// exist in the cluster are. Roles and memberships which already exist are left
+// untouched, and a membership in a role which exists as a user is skipped.
+func restoreRoles(
+	ctx context.Context, db *client.DB, ie sqlutil.InternalExecutor, backupDescs []BackupDescriptor,
+) error {
+	ctx, span := tracing.ChildSpan(ctx, "restoreRoles")
+	defer tracing.FinishSpan(span)
+
+	// Backups taken before roles existed don't contain system.role_members.
+	usersTable := backedUpTable(backupDescs, keys.UsersTableID)
+	roleMembersTable := backedUpTable(backupDescs, keys.RoleMembersTableID)
+	if usersTable == nil || roleMembersTable == nil {
+		return nil
+	}
+	users, err := readBackedUpRows(ctx, backupDescs, usersTable)
+	if err != nil {
+		return errors.Wrap(err, "reading backed up system.users")
+	}
+	memberships, err := readBackedUpRows(ctx, backupDescs, roleMembersTable)
+	if err != nil {
+		return errors.Wrap(err, "reading backed up system.role_members")
+	}
+	columnIdx := func(table *sqlbase.TableDescriptor, name string) (int, error) {
+		for i, col := range table.Columns {
+			if col.Name == name {
+				return i, nil
+			}
+		}
+		return 0, errors.Errorf("backed up %s table has no column %q", table.Name, name)
+	}
+	var usernameIdx, isRoleIdx, roleIdx, memberIdx, isAdminIdx int
+	for _, c := range []struct {
+		table *sqlbase.TableDescriptor
+		name  string
+		idx   *int
+	}{
+		{usersTable, "username", &usernameIdx},
+		{usersTable, "isRole", &isRoleIdx},
+		{roleMembersTable, "role", &roleIdx},
+		{roleMembersTable, "member", &memberIdx},
+		{roleMembersTable, "isAdmin", &isAdminIdx},
+	} {
+		if *c.idx, err = columnIdx(c.table, c.name); err != nil {
+			return err
+		}
+	}
+
+	return db.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		for _, row := range users {
+			if row[isRoleIdx] != parser.DBoolTrue {
+				continue
+			}
+			if _, err := ie.ExecuteStatementInTransaction(ctx, "restore-role", txn,
+				`INSERT INTO system.users (username, "hashedPassword", "isRole") VALUES ($1, '', true)
+				ON CONFLICT (username) DO NOTHING`,
+				row[usernameIdx],
+			); err != nil {
+				return errors.Wrapf(err, "restoring role %s", row[usernameIdx])
+			}
+		}
+		for _, row := range memberships {
+			if _, err := ie.ExecuteStatementInTransaction(ctx, "restore-role-membership", txn,
+				`INSERT INTO system.role_members (role, member, "isAdmin")
+				SELECT $1::STRING, $2::STRING, $3::BOOL
+				WHERE EXISTS (SELECT 1 FROM system.users WHERE username = $1 AND "isRole")
+				AND EXISTS (SELECT 1 FROM system.users WHERE username = $2)
+				ON CONFLICT (role, member) DO NOTHING`,
+				row[roleIdx], row[memberIdx], row[isAdminIdx],
+			); err != nil {
+				return errors.Wrapf(err, "restoring membership of %s in role %s",
+					row[memberIdx], row[roleIdx])
+			}
+		}
+		return nil
+	})
+}
+
 func restoreJobDescription(restore *parser.Restore, from []string) (string, error) {
 	r := &parser.Restore{
 		AsOf:    restore.AsOf,
@@ -723,6 +922,7 @@ func restore(
 	restoreCtx context.Context,
 	db *client.DB,
 	gossip *gossip.Gossip,
+	ie sqlutil.InternalExecutor,
 	backupDescs []BackupDescriptor,
 	sqlDescs []sqlbase.Descriptor,
 	tableRewrites tableRewriteMap,
@@ -931,6 +1131,11 @@ func restore(
 		return failed, errors.Wrapf(err, "restoring %d TableDescriptors", len(tables))
 	}
 
+	log.Event(restoreCtx, "restoring roles")
+	if err := restoreRoles(restoreCtx, db, ie, backupDescs); err != nil {
+		return failed, err
+	}
+
 	// TODO(dan): Delete any old table data here. The first version of restore
 	// assumes that it's operating on a new cluster. If it's not empty,
 	// everything works but the table data is left abandoned.
@@ -1043,6 +1248,7 @@ func doRestorePlan(
 		ctx,
 		p.ExecCfg().DB,
 		p.ExecCfg().Gossip,
+		sql.InternalExecutor{LeaseManager: p.ExecCfg().LeaseManager},
 		backupDescs,
 		sqlDescs,
 		tableRewrites,
@@ -1074,6 +1280,10 @@ func restoreResumeHook(typ jobs.Type) func(ctx context.Context, job *jobs.Job) e
 
 	return func(ctx context.Context, job *jobs.Job) error {
 		details := job.Record.Details.(jobs.RestoreDetails)
+		execCfg, ok := job.ExecutorConfig().(*sql.ExecutorConfig)
+		if !ok {
+			return errors.Errorf("no SQL executor to resume restore job %d", *job.ID())
+		}
 
 		backupDescs, err := loadBackupDescs(ctx, details.URIs)
 		if err != nil {
@@ -1092,6 +1302,7 @@ func restoreResumeHook(typ jobs.Type) func(ctx context.Context, job *jobs.Job) e
 			ctx,
 			job.DB(),
 			job.Gossip(),
+			sql.InternalExecutor{LeaseManager: execCfg.LeaseManager},
 			backupDescs,
 			sqlDescs,
 			details.TableRewrites,
@@ -0,0 +1,194 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/LICENSE
+
+package engineccl
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// PlainKeyID is the key ID recorded for files written in plaintext.
+const PlainKeyID = "plain"
+
+// FileRegistryName is the name of the file, in the root of a store
+// directory, which records which key each file of the store is encrypted
+// with. It is maintained by the encrypted env in libroach.
+const FileRegistryName = "COCKROACHDB_REGISTRY"
+
+const fileRegistryHeader = "cockroachdb-file-registry v1"
+
+// EncryptionKey is an AES key used to encrypt the files of a store.
+type EncryptionKey struct {
+	// ID identifies the key in the file registry. It is derived from the key
+	// so that the same key file always yields the same ID.
+	ID string
+	// Key is the raw key. It is empty for the plaintext "key".
+	Key []byte
+}
+
+// PlainKey is the key to specify to write new files in plaintext.
+var PlainKey = EncryptionKey{ID: PlainKeyID}
+
+// LoadEncryptionKey reads an AES-128, AES-192 or AES-256 key from a file
+// holding nothing but the raw key.
+func LoadEncryptionKey(path string) (EncryptionKey, error) {
+	key, err := ioutil.ReadFile(path)
+	if err != nil {
+		return EncryptionKey{}, errors.Wrap(err, "could not read encryption key")
+	}
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return EncryptionKey{}, errors.Errorf(
+			"key file %s is %d bytes long; expected 16, 24 or 32 bytes", path, len(key))
+	}
+	sum := sha256.Sum256(key)
+	return EncryptionKey{ID: hex.EncodeToString(sum[:8]), Key: key}, nil
+}
+
+// EncryptionOptions encodes the encryption configuration of a store to be
+// passed to the engine in RocksDBConfig.ExtraOptions. New files are written
+// with the active key; the old keys are still needed to read files written
+// with them, which get rewritten with the active key as RocksDB compacts
+// them.
+func EncryptionOptions(active EncryptionKey, old ...EncryptionKey) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, k := range append([]EncryptionKey{active}, old...) {
+		if len(k.ID) == 0 || len(k.ID) > 255 || len(k.Key) > 255 {
+			return nil, errors.Errorf("invalid encryption key %q", k.ID)
+		}
+		buf.WriteByte(byte(len(k.ID)))
+		buf.WriteString(k.ID)
+		buf.WriteByte(byte(len(k.Key)))
+		buf.Write(k.Key)
+	}
+	return buf.Bytes(), nil
+}
+
+// ReadFileRegistry reads the file registry of a store and returns the key
+// ID of each registered file, by path relative to the store directory.
+// Files which aren't registered predate encryption and are plaintext. The
+// store must not be running.
+func ReadFileRegistry(dir string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, FileRegistryName))
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return parseFileRegistry(data, func(name string) bool {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(dir, name)
+		}
+		_, err := os.Stat(name)
+		return err == nil
+	})
+}
+
+// parseFileRegistry interprets the contents of a file registry the same way
+// the encrypted env does when loading it. See FileRegistry in
+// c-deps/libroach/ccl/encrypted_env.cc for the format.
+func parseFileRegistry(data []byte, exists func(name string) bool) (map[string]string, error) {
+	type rename struct{ src, dst string }
+	files := map[string]string{}
+	var pending []rename
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	header := true
+	for scanner.Scan() {
+		line := scanner.Text()
+		if header {
+			if line != fileRegistryHeader {
+				return nil, errors.Errorf("unknown file registry format: %q", line)
+			}
+			header = false
+			continue
+		}
+		if len(line) < 3 || line[1] != ' ' {
+			return nil, errors.Errorf("invalid file registry record: %q", line)
+		}
+		rest := line[2:]
+		switch line[0] {
+		case '+':
+			fields := strings.SplitN(rest, " ", 3)
+			if len(fields) != 3 {
+				return nil, errors.Errorf("invalid file registry record: %q", line)
+			}
+			files[fields[2]] = fields[0]
+		case '-':
+			delete(files, rest)
+		case '>', '<':
+			paths := strings.SplitN(rest, "\t", 2)
+			if len(paths) != 2 {
+				return nil, errors.Errorf("invalid file registry record: %q", line)
+			}
+			r := rename{src: paths[0], dst: paths[1]}
+			if line[0] == '>' {
+				pending = append(pending, r)
+				continue
+			}
+			for i := range pending {
+				if pending[i] == r {
+					pending = append(pending[:i], pending[i+1:]...)
+					break
+				}
+			}
+			moveRegistryEntries(files, r.src, r.dst)
+		default:
+			return nil, errors.Errorf("invalid file registry record: %q", line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// A rename which was interrupted by a crash happened iff its source is
+	// gone and its destination exists.
+	for _, r := range pending {
+		if !exists(r.src) && exists(r.dst) {
+			moveRegistryEntries(files, r.src, r.dst)
+		}
+	}
+	for name := range files {
+		if !exists(name) {
+			delete(files, name)
+		}
+	}
+	return files, nil
+}
+
+// moveRegistryEntries moves the entries of src and of the files under it to
+// dst. If src predates encryption, so does dst afterwards.
+func moveRegistryEntries(files map[string]string, src, dst string) {
+	moved := map[string]string{}
+	for name, keyID := range files {
+		if name == src {
+			moved[dst] = keyID
+		} else if strings.HasPrefix(name, src+"/") {
+			moved[dst+name[len(src):]] = keyID
+		} else {
+			continue
+		}
+		delete(files, name)
+	}
+	if len(moved) == 0 {
+		delete(files, dst)
+	}
+	for name, keyID := range moved {
+		files[name] = keyID
+	}
+}
@@ -0,0 +1,304 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/LICENSE
+
+package engineccl
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func writeKeyFile(t *testing.T, dir, name string, size int) EncryptionKey {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, bytes.Repeat([]byte(name[:1]), size), 0600); err != nil {
+		t.Fatal(err)
+	}
+	key, err := LoadEncryptionKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestLoadEncryptionKey(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	dir, cleanup := testutils.TempDir(t)
+	defer cleanup()
+
+	a := writeKeyFile(t, dir, "a128", 16)
+	if again := writeKeyFile(t, dir, "a128", 16); again.ID != a.ID {
+		t.Errorf("expected stable key ID %s, got %s", a.ID, again.ID)
+	}
+	if b := writeKeyFile(t, dir, "b256", 32); b.ID == a.ID {
+		t.Errorf("expected different keys to have different IDs, both are %s", a.ID)
+	}
+	for _, size := range []int{0, 15, 17, 64} {
+		path := filepath.Join(dir, "bad")
+		if err := ioutil.WriteFile(path, make([]byte, size), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadEncryptionKey(path); !testutils.IsError(err, "expected 16, 24 or 32 bytes") {
+			t.Errorf("%d byte key: unexpected error %v", size, err)
+		}
+	}
+}
+
+func TestParseFileRegistry(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const iv = "000102030405060708090a0b0c0d0e0f"
+	registry := fileRegistryHeader + "\n" +
+		"+ k1 " + iv + " 000001.log\n" +
+		"+ plain " + iv + " 000002.sst\n" +
+		"+ k1 " + iv + " deleted.sst\n" +
+		"- deleted.sst\n" +
+		"+ k2 " + iv + " CURRENT.dbtmp\n" +
+		"> CURRENT.dbtmp\tCURRENT\n" +
+		"< CURRENT.dbtmp\tCURRENT\n" +
+		"+ k2 " + iv + " auxiliary/old/i1.t1\n" +
+		"> auxiliary/old\tauxiliary/new\n" +
+		"< auxiliary/old\tauxiliary/new\n" +
+		"+ k2 " + iv + " name with spaces\n" +
+		"+ k1 " + iv + " gone.sst\n" +
+		"+ k1 " + iv + " crashed.tmp\n" +
+		"> crashed.tmp\tcrashed\n"
+	existing := map[string]bool{
+		"000001.log":          true,
+		"000002.sst":          true,
+		"CURRENT":             true,
+		"auxiliary/new/i1.t1": true,
+		"name with spaces":    true,
+		"crashed":             true,
+	}
+	files, err := parseFileRegistry([]byte(registry), func(name string) bool {
+		return existing[name]
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"000001.log":          "k1",
+		"000002.sst":          PlainKeyID,
+		"CURRENT":             "k2",
+		"auxiliary/new/i1.t1": "k2",
+		"name with spaces":    "k2",
+		"crashed":             "k1",
+	}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("expected %v, got %v", expected, files)
+	}
+
+	for _, bad := range []string{
+		"cockroachdb-file-registry v0\n",
+		fileRegistryHeader + "\n+ k1 " + iv + "\n",
+		fileRegistryHeader + "\n> src dst\n",
+		fileRegistryHeader + "\n* foo\n",
+	} {
+		if _, err := parseFileRegistry([]byte(bad), func(string) bool { return true }); err == nil {
+			t.Errorf("expected error parsing %q", bad)
+		}
+	}
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// TestEncryptCTRVectors checks the cipher used for encrypted files against
+// the AES test vectors of FIPS-197 and the AES-CTR test vectors of NIST SP
+// 800-38A.
+func TestEncryptCTRVectors(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	// FIPS-197, appendix C: encrypting zeros with the plaintext block as the
+	// counter yields the encryption of that block.
+	for _, tc := range []struct {
+		key, ciphertext string
+	}{
+		{"000102030405060708090a0b0c0d0e0f", "69c4e0d86a7b0430d8cdb78070b4c55a"},
+		{"000102030405060708090a0b0c0d0e0f1011121314151617", "dda97ca4864cdfe06eaf70a0ec0d7191"},
+		{
+			"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+			"8ea2b7ca516745bfeafc49904b496089",
+		},
+	} {
+		data := make([]byte, 16)
+		iv := mustDecodeHex(t, "00112233445566778899aabbccddeeff")
+		if err := encryptCTR(mustDecodeHex(t, tc.key), iv, 0, data); err != nil {
+			t.Fatal(err)
+		}
+		if expected := mustDecodeHex(t, tc.ciphertext); !bytes.Equal(data, expected) {
+			t.Errorf("key %s: expected %x, got %x", tc.key, expected, data)
+		}
+	}
+
+	// NIST SP 800-38A, appendix F.5. Each part of the plaintext, starting at
+	// any offset, encrypts to the same part of the ciphertext.
+	iv := mustDecodeHex(t, "f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff")
+	plaintext := mustDecodeHex(t, "6bc1bee22e409f96e93d7e117393172a"+
+		"ae2d8a571e03ac9c9eb76fac45af8e51"+
+		"30c81c46a35ce411e5fbc1191a0a52ef"+
+		"f69f2445df4f9b17ad2b417be66c3710")
+	for _, tc := range []struct {
+		key, ciphertext string
+	}{
+		{
+			"2b7e151628aed2a6abf7158809cf4f3c",
+			"874d6191b620e3261bef6864990db6ce" +
+				"9806f66b7970fdff8617187bb9fffdff" +
+				"5ae4df3edbd5d35e5b4f09020db03eab" +
+				"1e031dda2fbe03d1792170a0f3009cee",
+		},
+		{
+			"8e73b0f7da0e6452c810f32b809079e562f8ead2522c6b7b",
+			"1abc932417521ca24f2b0459fe7e6e0b" +
+				"090339ec0aa6faefd5ccc2c6f4ce8e94" +
+				"1e36b26bd1ebc670d1bd1d665620abf7" +
+				"4f78a7f6d29809585a97daec58c6b050",
+		},
+		{
+			"603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4",
+			"601ec313775789a5b7a7f504bbf3d228" +
+				"f443e3ca4d62b59aca84e990cacaf5c5" +
+				"2b0930daa23de94ce87017ba2d84988d" +
+				"dfc9c58db67aada613c2dd08457941a6",
+		},
+	} {
+		key := mustDecodeHex(t, tc.key)
+		ciphertext := mustDecodeHex(t, tc.ciphertext)
+		for offset := range plaintext {
+			data := append([]byte(nil), plaintext[offset:]...)
+			if err := encryptCTR(key, iv, uint64(offset), data); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, ciphertext[offset:]) {
+				t.Errorf("key %s, offset %d: expected %x, got %x",
+					tc.key, offset, ciphertext[offset:], data)
+			}
+			// Counter mode is symmetric.
+			if err := encryptCTR(key, iv, uint64(offset), data); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, plaintext[offset:]) {
+				t.Errorf("key %s, offset %d: decrypting yielded %x", tc.key, offset, data)
+			}
+		}
+	}
+
+	if err := encryptCTR(make([]byte, 17), iv, 0, make([]byte, 16)); !testutils.IsError(
+		err, "invalid AES key size 17",
+	) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestEncryptedEngine(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	dir, cleanup := testutils.TempDir(t)
+	defer cleanup()
+	keyDir, keyCleanup := testutils.TempDir(t)
+	defer keyCleanup()
+	oldKey := writeKeyFile(t, keyDir, "old", 16)
+	newKey := writeKeyFile(t, keyDir, "new", 32)
+
+	open := func(active EncryptionKey, old ...EncryptionKey) (*engine.RocksDB, error) {
+		opts, err := EncryptionOptions(active, old...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return engine.NewRocksDB(
+			engine.RocksDBConfig{
+				Settings:     cluster.MakeTestingClusterSettings(),
+				Dir:          dir,
+				ExtraOptions: opts,
+			},
+			engine.RocksDBCache{},
+		)
+	}
+
+	secret := []byte("a value which must not hit the disk in plaintext")
+	key := engine.MakeMVCCMetadataKey(roachpb.Key("secret"))
+	eng, err := open(oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := eng.Put(key, secret); err != nil {
+		t.Fatal(err)
+	}
+	if err := eng.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := eng.WriteFile(filepath.Join(dir, "auxiliary", "aux"), secret); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := eng.ReadFile(filepath.Join(dir, "auxiliary", "aux")); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(data, secret) {
+		t.Fatalf("expected %q, got %q", secret, data)
+	}
+	eng.Close()
+
+	// No file may contain the value in plaintext.
+	if err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.Contains(data, secret) {
+			t.Errorf("found plaintext in %s", path)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := ReadFileRegistry(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyID := files["auxiliary/aux"]; keyID != oldKey.ID {
+		t.Errorf("expected auxiliary/aux to use key %s, got %q", oldKey.ID, keyID)
+	}
+
+	// The store can't be opened without the key its files use.
+	if _, err := open(newKey); !testutils.IsError(err, "which was not provided") {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// Rotate to the new key; the old data stays readable.
+	eng, err = open(newKey, oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer eng.Close()
+	if value, err := eng.Get(key); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(value, secret) {
+		t.Fatalf("expected %q, got %q", secret, value)
+	}
+}
@@ -25,6 +25,7 @@ import (
 // #cgo LDFLAGS: -lprotobuf
 // #cgo LDFLAGS: -lrocksdb
 // #cgo LDFLAGS: -lsnappy
+// #cgo LDFLAGS: -lcrypto
 // #cgo linux LDFLAGS: -lrt -lpthread
 // #cgo windows LDFLAGS: -lrpcrt4
 //
@@ -32,6 +33,11 @@ import (
 // #include <libroachccl.h>
 import "C"
 
+func init() {
+	// Let the stores be opened with encryption at rest.
+	C.DBSetOpenHookCCL()
+}
+
 // VerifyBatchRepr asserts that all keys in a BatchRepr are between the specified
 // start and end keys and computes the enginepb.MVCCStats for it.
 func VerifyBatchRepr(
@@ -79,6 +85,15 @@ func VerifyBatchRepr(
 	return cStatsToGoStats(stats, nowNanos)
 }
 
+// encryptCTR encrypts or decrypts data in place with the AES counter mode
+// cipher stream used for encrypted files, as if data started at the given
+// offset of a file with the given key and initialization vector.
+func encryptCTR(key, iv []byte, offset uint64, data []byte) error {
+	return statusToError(C.DBEncryptCTR(
+		goToCSlice(key), goToCSlice(iv), C.uint64_t(offset), goToCSlice(data),
+	))
+}
+
 // TODO(dan): The following are all duplicated from storage/engine/rocksdb.go,
 // but if you export the ones there and reuse them here, it doesn't work.
 //
@@ -78,9 +78,18 @@ func MakeKeyRewriter(rekeys []roachpb.ImportRequest_TableRekey) (*KeyRewriter, e
 		// The PrefixEnd() of index 1 is the same as the prefix of index 2, so use a
 		// map to avoid duplicating entries.
 
+		var indexIDs []sqlbase.IndexID
+		if desc.IsSequence() {
+			// Sequences have no indexes, but their value is stored as if in an
+			// index with ID keys.SequenceIndexID.
+			indexIDs = append(indexIDs, keys.SequenceIndexID)
+		}
 		for _, index := range desc.AllNonDropIndexes() {
-			oldPrefix := roachpb.Key(makeKeyRewriterPrefixIgnoringInterleaved(oldID, index.ID))
-			newPrefix := roachpb.Key(makeKeyRewriterPrefixIgnoringInterleaved(desc.ID, index.ID))
+			indexIDs = append(indexIDs, index.ID)
+		}
+		for _, indexID := range indexIDs {
+			oldPrefix := roachpb.Key(makeKeyRewriterPrefixIgnoringInterleaved(oldID, indexID))
+			newPrefix := roachpb.Key(makeKeyRewriterPrefixIgnoringInterleaved(desc.ID, indexID))
 			if !seenPrefixes[string(oldPrefix)] {
 				seenPrefixes[string(oldPrefix)] = true
 				prefixes = append(prefixes, prefixRewrite{
@@ -149,6 +158,10 @@ func (kr *KeyRewriter) RewriteKey(key []byte) ([]byte, bool, error) {
 		// If there isn't any more data, we are at some split boundary.
 		return key, true, nil
 	}
+	if desc.IsSequence() {
+		// Sequences are never interleaved.
+		return key, true, nil
+	}
 	idx, err := desc.FindIndexByID(indexID)
 	if err != nil {
 		return nil, false, err
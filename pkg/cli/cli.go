@@ -129,7 +129,7 @@ func init() {
 	})
 
 	cockroachCmd.AddCommand(
-		startCmd,
+		StartCmd,
 		initCmd,
 		certCmd,
 		quitCmd,
@@ -144,7 +144,7 @@ func init() {
 		// TODO(pmattis): stats
 		genCmd,
 		versionCmd,
-		debugCmd,
+		DebugCmd,
 	)
 }
 
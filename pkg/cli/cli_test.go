@@ -670,6 +670,71 @@ func Example_zone() {
 	// DELETE 0
 }
 
+func Example_zone_index() {
+	c := newCLITest(cliTestParams{})
+	defer c.cleanup()
+
+	c.RunWithArgs([]string{"sql", "-e", "create database t; create table t.f (x int primary key, y int, index y_idx (y))"})
+	c.Run("zone get t.f@y_idx")
+	c.Run("zone set t.f@y_idx --file=./testdata/zone_attrs.yaml")
+	c.Run("zone get t.f@y_idx")
+	c.Run("zone get t.f")
+	c.Run("zone ls")
+	c.Run("zone set t.f@nonexistent --file=./testdata/zone_attrs.yaml")
+	c.Run("zone set f@y_idx --file=./testdata/zone_attrs.yaml")
+	c.Run("zone rm t.f@y_idx")
+	c.Run("zone ls")
+	c.Run("zone rm t.f@y_idx")
+
+	// Output:
+	// sql -e create database t; create table t.f (x int primary key, y int, index y_idx (y))
+	// CREATE TABLE
+	// zone get t.f@y_idx
+	// .default
+	// range_min_bytes: 1048576
+	// range_max_bytes: 67108864
+	// gc:
+	//   ttlseconds: 90000
+	// num_replicas: 1
+	// constraints: []
+	// zone set t.f@y_idx --file=./testdata/zone_attrs.yaml
+	// range_min_bytes: 1048576
+	// range_max_bytes: 67108864
+	// gc:
+	//   ttlseconds: 90000
+	// num_replicas: 1
+	// constraints: [us-east-1a, ssd]
+	// zone get t.f@y_idx
+	// t.f@y_idx
+	// range_min_bytes: 1048576
+	// range_max_bytes: 67108864
+	// gc:
+	//   ttlseconds: 90000
+	// num_replicas: 1
+	// constraints: [us-east-1a, ssd]
+	// zone get t.f
+	// .default
+	// range_min_bytes: 1048576
+	// range_max_bytes: 67108864
+	// gc:
+	//   ttlseconds: 90000
+	// num_replicas: 1
+	// constraints: []
+	// zone ls
+	// .default
+	// t.f@y_idx
+	// zone set t.f@nonexistent --file=./testdata/zone_attrs.yaml
+	// index "nonexistent" does not exist
+	// zone set f@y_idx --file=./testdata/zone_attrs.yaml
+	// index zone name must include database and table: f@y_idx
+	// zone rm t.f@y_idx
+	// DELETE 1
+	// zone ls
+	// .default
+	// zone rm t.f@y_idx
+	// t.f@y_idx has no zone config
+}
+
 func Example_sql() {
 	c := newCLITest(cliTestParams{})
 	defer c.cleanup()
@@ -1818,6 +1883,7 @@ writing ` + os.DevNull + `
   debug/nodes/1/ranges/14
   debug/nodes/1/ranges/15
   debug/nodes/1/ranges/16
+  debug/nodes/1/ranges/17
   debug/schema/system@details
   debug/schema/system/descriptor
   debug/schema/system/eventlog
@@ -1825,7 +1891,9 @@ writing ` + os.DevNull + `
   debug/schema/system/lease
   debug/schema/system/namespace
   debug/schema/system/rangelog
+  debug/schema/system/role_members
   debug/schema/system/settings
+  debug/schema/system/table_statistics
   debug/schema/system/ui
   debug/schema/system/users
   debug/schema/system/web_sessions
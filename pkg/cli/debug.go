@@ -890,7 +890,7 @@ func parseGossipValues(gossipInfo *gossip.InfoStatus) (string, error) {
 }
 
 func init() {
-	debugCmd.AddCommand(debugCmds...)
+	DebugCmd.AddCommand(debugCmds...)
 }
 
 var debugCmds = []*cobra.Command{
@@ -909,7 +909,9 @@ var debugCmds = []*cobra.Command{
 	debugZipCmd,
 }
 
-var debugCmd = &cobra.Command{
+// DebugCmd is the root of all debug commands. It is exported to allow CCL
+// code to add commands.
+var DebugCmd = &cobra.Command{
 	Use:   "debug [command]",
 	Short: "debugging commands",
 	Long: `Various commands for debugging.
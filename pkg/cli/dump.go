@@ -112,6 +112,12 @@ func runDump(cmd *cobra.Command, args []string) error {
 			if md.isView {
 				continue
 			}
+			if md.isSequence {
+				if err := dumpSequenceData(w, conn, ts, md); err != nil {
+					return err
+				}
+				continue
+			}
 			if err := dumpTableData(w, conn, ts, md); err != nil {
 				return err
 			}
@@ -143,6 +149,7 @@ type tableMetadata struct {
 	createStmt  string
 	dependsOn   []int64
 	isView      bool
+	isSequence  bool
 }
 
 // getDumpMetadata retrieves the table information for the specified table(s).
@@ -279,7 +286,7 @@ func getMetadataForTable(
 	}
 
 	vals, err = conn.QueryRow(fmt.Sprintf(`
-		SELECT create_statement, descriptor_type = 'view'
+		SELECT create_statement, descriptor_type
 		FROM %s.crdb_internal.create_statements
 		AS OF SYSTEM TIME '%s'
 		WHERE descriptor_name = $1
@@ -289,7 +296,7 @@ func getMetadataForTable(
 		return tableMetadata{}, err
 	}
 	create := vals[0].(string)
-	descType := vals[1].(bool)
+	descType := vals[1].(string)
 
 	rows, err = conn.Query(fmt.Sprintf(`
 		SELECT dependson_id
@@ -323,7 +330,8 @@ func getMetadataForTable(
 		columnTypes: coltypes,
 		createStmt:  create,
 		dependsOn:   refs,
-		isView:      descType,
+		isView:      descType == "view",
+		isSequence:  descType == "sequence",
 	}, nil
 }
 
@@ -338,6 +346,25 @@ func dumpCreateTable(w io.Writer, md tableMetadata) error {
 	return nil
 }
 
+// dumpSequenceData dumps the current value of the specified sequence to w,
+// as a call to setval.
+func dumpSequenceData(w io.Writer, conn *sqlConn, clusterTS string, md tableMetadata) error {
+	vals, err := conn.QueryRow(fmt.Sprintf(
+		"SELECT last_value, is_called FROM %s AS OF SYSTEM TIME '%s'",
+		md.name,
+		clusterTS,
+	), nil)
+	if err != nil {
+		return err
+	}
+	lastValue := vals[0].(int64)
+	isCalled := vals[1].(bool)
+
+	fmt.Fprintf(w, "\nSELECT setval(%s, %d, %t);\n",
+		parser.EscapeSQLString(md.name.TableName.String()), lastValue, isCalled)
+	return nil
+}
+
 const (
 	// insertRows is the number of rows per INSERT statement.
 	insertRows = 100
@@ -430,6 +457,11 @@ func dumpTableData(w io.Writer, conn *sqlConn, clusterTS string, md tableMetadat
 						if err != nil {
 							return err
 						}
+					case "JSONB":
+						d, err = parser.ParseDJSON(string(t))
+						if err != nil {
+							return err
+						}
 					default:
 						// STRING and DECIMAL types can have optional length
 						// suffixes, so only examine the prefix of the type.
@@ -863,3 +863,45 @@ CREATE VIEW bar ("1") AS SELECT 1;
 		t.Fatalf("expected: %s\ngot: %s", expect, out)
 	}
 }
+
+// TestDumpSequence verifies dump restores the value of sequences.
+func TestDumpSequence(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	c := newCLITest(cliTestParams{t: t})
+	defer c.cleanup()
+
+	const create = `
+	CREATE DATABASE d;
+	CREATE SEQUENCE d.s INCREMENT 2 START 5;
+	SELECT nextval('d.s');
+	SELECT nextval('d.s');
+	CREATE SEQUENCE d.unused;
+`
+	if out, err := c.RunWithCaptureArgs([]string{"sql", "-e", create}); err != nil {
+		t.Fatal(err)
+	} else {
+		t.Log(string(out))
+	}
+
+	out, err := c.RunWithCaptureArgs([]string{"dump", "d"})
+	if err != nil {
+		t.Fatal(err)
+	} else {
+		t.Log(string(out))
+	}
+
+	const expect = `dump d
+CREATE SEQUENCE s MINVALUE 1 MAXVALUE 9223372036854775807 INCREMENT 2 START 5;
+
+CREATE SEQUENCE unused MINVALUE 1 MAXVALUE 9223372036854775807 INCREMENT 1 START 1;
+
+SELECT setval('s', 7, true);
+
+SELECT setval('unused', 1, false);
+`
+
+	if string(out) != expect {
+		t.Fatalf("expected: %s\ngot: %s", expect, out)
+	}
+}
@@ -167,7 +167,7 @@ func init() {
 	}
 
 	// The following only runs for `start`.
-	startCmd.PersistentPreRunE = func(cmd *cobra.Command, _ []string) error {
+	StartCmd.PersistentPreRunE = func(cmd *cobra.Command, _ []string) error {
 		extraServerFlagInit()
 		return setDefaultStderrVerbosity(cmd, log.Severity_INFO)
 	}
@@ -207,7 +207,7 @@ func init() {
 	// Security flags.
 
 	{
-		f := startCmd.Flags()
+		f := StartCmd.Flags()
 
 		// Server flags.
 		stringFlag(f, &serverConnHost, cliflags.ServerHost, "")
@@ -291,7 +291,7 @@ func init() {
 		genHAProxyCmd,
 		quitCmd,
 		sqlShellCmd,
-		/* startCmd is covered above */
+		/* StartCmd is covered above */
 	}
 	clientCmds = append(clientCmds, rangeCmds...)
 	clientCmds = append(clientCmds, userCmds...)
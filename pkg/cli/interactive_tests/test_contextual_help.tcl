@@ -14,26 +14,26 @@ eexpect "HINT: try \\\\h <SOURCE>"
 eexpect root@
 end_test
 
-start_test "Check that a standalone '?' prints all help."
-send "?\r"
+start_test "Check that a standalone '??' prints all help."
+send "??\r"
 eexpect "TRUNCATE"
 eexpect "SHOW"
 eexpect "ROLLBACK"
 eexpect root@
 
-send "?\t"
+send "??\t"
 eexpect "TRUNCATE"
 eexpect "SHOW"
 eexpect "ROLLBACK"
-eexpect "?"
-send "\010"
+eexpect "??"
+send "\010\010"
 send "select 1;\r"
 eexpect "1 row"
 eexpect root@
 end_test
 
-start_test "Check that a ? after a simple statement prints help."
-send "select ?\r"
+start_test "Check that a ?? after a simple statement prints help."
+send "select ??\r"
 eexpect "Command: "
 eexpect "SELECT"
 eexpect "data manipulation"
@@ -42,7 +42,7 @@ eexpect "ORDER BY"
 eexpect "See also"
 eexpect root@
 
-send "select * from ?\r"
+send "select * from ??\r"
 eexpect "Command: "
 eexpect "data source"
 eexpect "JOIN"
@@ -56,45 +56,45 @@ end_test
 start_test "Check that the last statement with help text made it to history."
 send "\033\[A"
 eexpect "select"
-eexpect "from ?"
+eexpect "from ??"
 send "\r"
 eexpect "See also"
 eexpect root@
 end_test
 
 
-start_test "Check that ?-tab works."
-send "select ?\t"
+start_test "Check that ??-tab works."
+send "select ??\t"
 eexpect "Command: "
 eexpect "SELECT"
 eexpect "data manipulation"
 eexpect "FROM"
 eexpect "ORDER BY"
 eexpect "See also"
-eexpect "select ?"
-send "\010"
+eexpect "select ??"
+send "\010\010"
 send "1;\r"
 eexpect "1 row"
 eexpect root@
 
-send "select * from ?\t"
+send "select * from ??\t"
 eexpect "Command: "
 eexpect "data source"
 eexpect "JOIN"
 eexpect "EXPLAIN"
 eexpect "SHOW"
 eexpect "See also"
-eexpect "select * from ?"
-send "\010"
+eexpect "select * from ??"
+send "\010\010"
 send "(values (1));\r"
 eexpect "1 row"
 eexpect root@
 
 end_test
 
-start_test "Check that a ? in a function call context prints help about that function."
+start_test "Check that a ?? in a function call context prints help about that function."
 
-send "select count(?\r"
+send "select count(??\r"
 eexpect "Function: "
 eexpect "count"
 eexpect "built-in functions"
@@ -102,14 +102,14 @@ eexpect "Signature"
 eexpect "See also"
 eexpect root@
 
-send "select count(?\t"
+send "select count(??\t"
 eexpect "Function: "
 eexpect "count"
 eexpect "built-in functions"
 eexpect "Signature"
 eexpect "See also"
-eexpect "select count(?"
-send "\010"
+eexpect "select count(??"
+send "\010\010"
 send "1);\r"
 eexpect "1 row"
 eexpect root@
@@ -65,9 +65,15 @@ import (
 // The function takes a filename to write the profile to.
 var jemallocHeapDump func(string) error
 
-// startCmd starts a node by initializing the stores and joining
-// the cluster.
-var startCmd = &cobra.Command{
+// PopulateStoreSpecsHook is an optional function to be called after the
+// flags of the start command are parsed and before the stores are opened.
+// It is set by CCL code to fill in the store options which OSS code doesn't
+// know about.
+var PopulateStoreSpecsHook func(cmd *cobra.Command, specs *base.StoreSpecList) error
+
+// StartCmd starts a node by initializing the stores and joining
+// the cluster. It is exported to allow CCL code to add flags.
+var StartCmd = &cobra.Command{
 	Use:   "start",
 	Short: "start a node",
 	Long: `
@@ -414,6 +420,12 @@ func runStart(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if PopulateStoreSpecsHook != nil {
+		if err := PopulateStoreSpecsHook(cmd, &serverCfg.Stores); err != nil {
+			return err
+		}
+	}
+
 	// Deal with flags that may depend on other flags.
 
 	// The temp store size can depend on the location of the first regular store
@@ -28,6 +28,7 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/config"
 	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/sql"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
@@ -112,8 +113,13 @@ func queryZone(conn *sqlConn, id sqlbase.ID) (config.ZoneConfig, bool, error) {
 func queryZonePath(conn *sqlConn, path []sqlbase.ID) (sqlbase.ID, config.ZoneConfig, error) {
 	for i := len(path) - 1; i >= 0; i-- {
 		zone, found, err := queryZone(conn, path[i])
-		if err != nil || found {
-			return path[i], zone, err
+		if err != nil {
+			return 0, config.ZoneConfig{}, err
+		}
+		// A zone config that only stores the subzones of a table inherits the
+		// rest of its fields from its parent.
+		if found && !zone.IsSubzonePlaceholder() {
+			return path[i], zone, nil
 		}
 	}
 	return 0, config.ZoneConfig{}, nil
@@ -196,39 +202,112 @@ func queryDescriptorIDPath(conn *sqlConn, names []string) ([]sqlbase.ID, error)
 	return path, nil
 }
 
-func parseZoneName(s string) ([]string, error) {
+// parseZoneName returns the database and table names making up the given
+// zone name, as well as the name of the index if the zone name refers to an
+// index (i.e. <database.table@index>).
+func parseZoneName(s string) ([]string, string, error) {
 	switch t := strings.ToLower(s); s {
 	case defaultZoneName, metaZoneName, timeseriesZoneName, systemZoneName:
-		return []string{t}, nil
+		return []string{t}, "", nil
 	}
 
 	// TODO(knz): we are passing a name that might not be escaped correctly.
 	// See #8389.
-	tn, err := parser.ParseTableName(s)
+	var tn *parser.TableName
+	var indexName string
+	var err error
+	if strings.Contains(s, "@") {
+		var tni *parser.TableNameWithIndex
+		if tni, err = parser.ParseTableNameWithIndex(s); err != nil || tni.SearchTable {
+			return nil, "", fmt.Errorf("malformed name: %s", s)
+		}
+		tn, err = tni.Table.Normalize()
+		indexName = string(tni.Index)
+	} else {
+		tn, err = parser.ParseTableName(s)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("malformed name: %s", s)
+		return nil, "", fmt.Errorf("malformed name: %s", s)
 	}
 	// This is a bit of a hack: "." is not a valid database name.
 	// We use this to detect when a database name was not specified, in
 	// which case we interpret the table name as a database name below.
 	if err := tn.QualifyWithDatabase("."); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	var names []string
 	if n := tn.Database(); n != "." {
 		names = append(names, n)
+	} else if indexName != "" {
+		return nil, "", fmt.Errorf("index zone name must include database and table: %s", s)
 	}
 	names = append(names, tn.Table())
-	return names, nil
+	return names, indexName, nil
+}
+
+// queryIndex returns the descriptor of the table with the given ID and the
+// ID of its index with the given name.
+func queryIndex(
+	conn *sqlConn, tableID sqlbase.ID, indexName string,
+) (*sqlbase.TableDescriptor, sqlbase.IndexID, error) {
+	rows, err := makeQuery(`SELECT descriptor FROM system.descriptor WHERE id = $1`, tableID)(conn)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	vals := make([]driver.Value, 1)
+	if err := rows.Next(vals); err != nil {
+		return nil, 0, err
+	}
+	desc := &sqlbase.Descriptor{}
+	if err := unmarshalProto(vals[0], desc); err != nil {
+		return nil, 0, err
+	}
+	tableDesc := desc.GetTable()
+	if tableDesc == nil {
+		return nil, 0, fmt.Errorf("%q is not a table", desc.GetName())
+	}
+	if tableDesc.PrimaryIndex.Name == indexName {
+		return tableDesc, tableDesc.PrimaryIndex.ID, nil
+	}
+	index, dropped, err := tableDesc.FindIndexByName(indexName)
+	if err != nil {
+		return nil, 0, err
+	}
+	if dropped {
+		return nil, 0, fmt.Errorf("index %q is being dropped", indexName)
+	}
+	return tableDesc, index.ID, nil
+}
+
+// makeTableZoneQuery returns the query writing the zone config of a table,
+// after regenerating the spans of its subzones. A placeholder zone config left
+// without subzones is deleted instead.
+func makeTableZoneQuery(
+	tableDesc *sqlbase.TableDescriptor, zone config.ZoneConfig,
+) (queryFunc, error) {
+	var err error
+	if zone.SubzoneSpans, err = sql.GenerateSubzoneSpans(tableDesc, zone.Subzones); err != nil {
+		return nil, err
+	}
+	if zone.NumReplicas == 0 && len(zone.Subzones) == 0 {
+		return makeQuery(`DELETE FROM system.zones WHERE id=$1`, tableDesc.ID), nil
+	}
+	buf, err := protoutil.Marshal(&zone)
+	if err != nil {
+		return nil, err
+	}
+	return makeQuery(`UPSERT INTO system.zones (id, config) VALUES ($1, $2)`, tableDesc.ID, buf), nil
 }
 
 // A getZoneCmd command displays a zone config.
 var getZoneCmd = &cobra.Command{
-	Use:   "get [options] <database[.table]>",
+	Use:   "get [options] <database[.table[@index]]>",
 	Short: "fetches and displays the zone config",
 	Long: `
-Fetches and displays the zone configuration for the specified database or
-table.
+Fetches and displays the zone configuration for the specified database, table
+or index.
 `,
 	RunE: MaybeDecorateGRPCError(runGetZone),
 }
@@ -240,7 +319,7 @@ func runGetZone(cmd *cobra.Command, args []string) error {
 		return usageAndError(cmd)
 	}
 
-	names, err := parseZoneName(args[0])
+	names, indexName, err := parseZoneName(args[0])
 	if err != nil {
 		return err
 	}
@@ -260,6 +339,27 @@ func runGetZone(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if indexName != "" {
+		tableID := path[len(path)-1]
+		_, indexID, err := queryIndex(conn, tableID, indexName)
+		if err != nil {
+			return err
+		}
+		tableZone, _, err := queryZone(conn, tableID)
+		if err != nil {
+			return err
+		}
+		if subzone := tableZone.GetSubzone(uint32(indexID), ""); subzone != nil {
+			fmt.Println(strings.Join(names, ".") + "@" + indexName)
+			res, err := yaml.Marshal(subzone.Config)
+			if err != nil {
+				return err
+			}
+			fmt.Print(string(res))
+			return nil
+		}
+	}
+
 	id, zone, err := queryZonePath(conn, path)
 	if err != nil {
 		return err
@@ -325,7 +425,7 @@ func runLsZones(cmd *cobra.Command, args []string) error {
 	// Loop over the zones and determine the name for each based on the name of
 	// the corresponding descriptor.
 	var output []string
-	for id := range zones {
+	for id, zone := range zones {
 		if id == 0 {
 			// We handle the default zone below.
 			continue
@@ -335,7 +435,8 @@ func runLsZones(cmd *cobra.Command, args []string) error {
 			continue
 		}
 		var name string
-		if tableDesc := desc.GetTable(); tableDesc != nil {
+		tableDesc := desc.GetTable()
+		if tableDesc != nil {
 			dbDesc, ok := descs[tableDesc.ParentID]
 			if !ok {
 				continue
@@ -343,7 +444,22 @@ func runLsZones(cmd *cobra.Command, args []string) error {
 			name = parser.Name(dbDesc.GetName()).String() + "."
 		}
 		name += parser.Name(desc.GetName()).String()
-		output = append(output, name)
+		if !zone.IsSubzonePlaceholder() {
+			output = append(output, name)
+		}
+		if tableDesc == nil {
+			continue
+		}
+		for _, subzone := range zone.Subzones {
+			if subzone.PartitionName != "" {
+				continue
+			}
+			index, err := tableDesc.FindIndexByID(sqlbase.IndexID(subzone.IndexID))
+			if err != nil {
+				continue
+			}
+			output = append(output, name+"@"+parser.Name(index.Name).String())
+		}
 	}
 
 	for id, zoneName := range specialZonesByID {
@@ -362,10 +478,10 @@ func runLsZones(cmd *cobra.Command, args []string) error {
 
 // A rmZoneCmd command removes a zone config.
 var rmZoneCmd = &cobra.Command{
-	Use:   "rm [options] <database[.table]>",
+	Use:   "rm [options] <database[.table[@index]]>",
 	Short: "remove a zone config",
 	Long: `
-Remove an existing zone config for the specified database or table.
+Remove an existing zone config for the specified database, table or index.
 `,
 	RunE: MaybeDecorateGRPCError(runRmZone),
 }
@@ -375,7 +491,7 @@ func runRmZone(cmd *cobra.Command, args []string) error {
 		return usageAndError(cmd)
 	}
 
-	names, err := parseZoneName(args[0])
+	names, indexName, err := parseZoneName(args[0])
 	if err != nil {
 		return err
 	}
@@ -400,6 +516,39 @@ func runRmZone(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("unable to remove special zone %s", args[0])
 		}
 
+		zone, _, err := queryZone(conn, id)
+		if err != nil {
+			return err
+		}
+		if indexName != "" {
+			tableDesc, indexID, err := queryIndex(conn, id, indexName)
+			if err != nil {
+				return err
+			}
+			if !zone.DeleteSubzone(uint32(indexID), "") {
+				fmt.Printf("%s has no zone config\n", args[0])
+				return nil
+			}
+			query, err := makeTableZoneQuery(tableDesc, zone)
+			if err != nil {
+				return err
+			}
+			return runQueryAndFormatResults(conn, os.Stdout, query)
+		}
+		if len(zone.Subzones) > 0 {
+			// Keep the zone configs of the table's indexes and partitions.
+			placeholder := config.ZoneConfig{
+				Subzones:     zone.Subzones,
+				SubzoneSpans: zone.SubzoneSpans,
+			}
+			buf, err := protoutil.Marshal(&placeholder)
+			if err != nil {
+				return err
+			}
+			return runQueryAndFormatResults(conn, os.Stdout,
+				makeQuery(`UPSERT INTO system.zones (id, config) VALUES ($1, $2)`, id, buf))
+		}
+
 		if err := runQueryAndFormatResults(conn, os.Stdout,
 			makeQuery(`DELETE FROM system.zones WHERE id=$1`, id)); err != nil {
 			return err
@@ -411,16 +560,18 @@ func runRmZone(cmd *cobra.Command, args []string) error {
 
 // A setZoneCmd command creates a new or updates an existing zone config.
 var setZoneCmd = &cobra.Command{
-	Use:   "set [options] <database[.table]> -f file.yaml",
+	Use:   "set [options] <database[.table[@index]]> -f file.yaml",
 	Short: "create or update zone config for object ID",
 	Long: `
-Create or update the zone config for the specified database or table to the
-specified zone-config from the given file ("-" for stdin).
+Create or update the zone config for the specified database, table or index to
+the specified zone-config from the given file ("-" for stdin).
 
 The zone config format has the following YAML schema:
 
   num_replicas: <num>
   constraints: [comma-separated attribute list]
+  lease_preferences: [[comma-separated attribute list], ...]
+  replica_constraints: [{num_replicas: <num>, constraints: [comma-separated attribute list]}, ...]
   range_min_bytes: <size-in-bytes>
   range_max_bytes: <size-in-bytes>
   gc:
@@ -432,8 +583,32 @@ num_replicas: 3
 constraints: [ssd, -mem]
 EOF
 
+Lease preferences are ordered from most to least preferred. Each preference is
+a list of required (+) or prohibited (-) attributes, and the range lease is
+placed on a replica matching the first preference that any replica matches:
+$ cockroach zone set db.t -f - << EOF
+lease_preferences: [[+region=us-east], [+region=us-west]]
+EOF
+
+Replica constraints apply to the given number of replicas only, and may only
+contain required (+) or prohibited (-) attributes. Their numbers of replicas
+must add up to at most num_replicas; any remaining replicas are placed subject
+to the constraints alone. For example, to place two replicas in us-east and
+one in us-west, run:
+$ cockroach zone set db.t -f - << EOF
+num_replicas: 3
+replica_constraints: [{num_replicas: 2, constraints: [+region=us-east]}, {num_replicas: 1, constraints: [+region=us-west]}]
+EOF
+
+The zone config of an index applies to the index's key span only. For example,
+to keep the replicas of a secondary index in us-west, run:
+$ cockroach zone set db.t@idx -f - << EOF
+constraints: [+region=us-west]
+lease_preferences: [[+region=us-west]]
+EOF
+
 Note that the specified zone config is merged with the existing zone config for
-the database or table.
+the database, table or index.
 `,
 	RunE: MaybeDecorateGRPCError(runSetZone),
 }
@@ -470,7 +645,7 @@ func runSetZone(cmd *cobra.Command, args []string) error {
 	}
 	defer conn.Close()
 
-	names, err := parseZoneName(args[0])
+	names, indexName, err := parseZoneName(args[0])
 	if err != nil {
 		return err
 	}
@@ -490,10 +665,30 @@ func runSetZone(cmd *cobra.Command, args []string) error {
 				"try setting your config on the entire \"system\" database instead")
 		}
 
-		_, zone, err := queryZonePath(conn, path)
+		id := path[len(path)-1]
+		// The zone configs of the table's indexes and partitions are kept as
+		// they are, even if the table previously inherited its own zone config.
+		existing, _, err := queryZone(conn, id)
 		if err != nil {
 			return err
 		}
+		var tableDesc *sqlbase.TableDescriptor
+		var indexID sqlbase.IndexID
+		var subzone *config.Subzone
+		if indexName != "" {
+			if tableDesc, indexID, err = queryIndex(conn, id, indexName); err != nil {
+				return err
+			}
+			subzone = existing.GetSubzone(uint32(indexID), "")
+		}
+		var zone config.ZoneConfig
+		if subzone != nil {
+			zone = subzone.Config
+		} else if _, zone, err = queryZonePath(conn, path); err != nil {
+			return err
+		}
+		zone.Subzones = nil
+		zone.SubzoneSpans = nil
 		// Convert it to proto and marshal it again to put into the table. This is a
 		// bit more tedious than taking protos directly, but yaml is a more widely
 		// understood format.
@@ -511,16 +706,24 @@ func runSetZone(cmd *cobra.Command, args []string) error {
 			return err
 		}
 
-		buf, err := protoutil.Marshal(&zone)
-		if err != nil {
-			return fmt.Errorf("unable to parse zone config file %q: %s", args[1], err)
+		var query queryFunc
+		if indexName != "" {
+			existing.SetSubzone(config.Subzone{IndexID: uint32(indexID), Config: zone})
+			if query, err = makeTableZoneQuery(tableDesc, existing); err != nil {
+				return err
+			}
+		} else {
+			stored := zone
+			stored.Subzones = existing.Subzones
+			stored.SubzoneSpans = existing.SubzoneSpans
+			buf, err := protoutil.Marshal(&stored)
+			if err != nil {
+				return fmt.Errorf("unable to parse zone config file %q: %s", args[1], err)
+			}
+			query = makeQuery(`UPSERT INTO system.zones (id, config) VALUES ($1, $2)`, id, buf)
 		}
 
-		id := path[len(path)-1]
-		_, _, err = runQuery(conn, makeQuery(
-			`UPSERT INTO system.zones (id, config) VALUES ($1, $2)`,
-			id, buf), false)
-		if err != nil {
+		if _, _, err := runQuery(conn, query, false); err != nil {
 			return err
 		}
 
@@ -111,20 +111,39 @@ func (c *Constraint) FromString(short string) error {
 var _ yaml.Marshaler = Constraints{}
 var _ yaml.Unmarshaler = &Constraints{}
 
-// MarshalYAML implements yaml.Marshaler.
+// replicaConstraintsYAML is the YAML representation of Constraints which
+// apply to a given number of replicas.
+type replicaConstraintsYAML struct {
+	NumReplicas int32    `yaml:"num_replicas"`
+	Constraints []string `yaml:"constraints,flow"`
+}
+
+// MarshalYAML implements yaml.Marshaler. Constraints which apply to all
+// replicas are marshaled as a list of constraints in shorthand notation, and
+// those which apply to a given number of replicas as a map holding that
+// number along with the list.
 func (c Constraints) MarshalYAML() (interface{}, error) {
 	short := make([]string, len(c.Constraints))
 	for i, c := range c.Constraints {
 		short[i] = c.String()
 	}
-	return short, nil
+	if c.NumReplicas == 0 {
+		return short, nil
+	}
+	return replicaConstraintsYAML{NumReplicas: c.NumReplicas, Constraints: short}, nil
 }
 
 // UnmarshalYAML implements yaml.Unmarshaler.
 func (c *Constraints) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var numReplicas int32
 	var shortConstraints []string
 	if err := unmarshal(&shortConstraints); err != nil {
-		return err
+		var replicaConstraints replicaConstraintsYAML
+		if err := unmarshal(&replicaConstraints); err != nil {
+			return err
+		}
+		numReplicas = replicaConstraints.NumReplicas
+		shortConstraints = replicaConstraints.Constraints
 	}
 	constraints := make([]Constraint, len(shortConstraints))
 	for i, short := range shortConstraints {
@@ -133,6 +152,25 @@ func (c *Constraints) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		}
 	}
 	c.Constraints = constraints
+	c.NumReplicas = numReplicas
+	return nil
+}
+
+var _ yaml.Marshaler = LeasePreference{}
+var _ yaml.Unmarshaler = &LeasePreference{}
+
+// MarshalYAML implements yaml.Marshaler.
+func (l LeasePreference) MarshalYAML() (interface{}, error) {
+	return Constraints{Constraints: l.Constraints}.MarshalYAML()
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (l *LeasePreference) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var c Constraints
+	if err := c.UnmarshalYAML(unmarshal); err != nil {
+		return err
+	}
+	l.Constraints = c.Constraints
 	return nil
 }
 
@@ -176,6 +214,103 @@ func (z ZoneConfig) Validate() error {
 		return fmt.Errorf("RangeMinBytes %d is greater than or equal to RangeMaxBytes %d",
 			z.RangeMinBytes, z.RangeMaxBytes)
 	}
+	if z.Constraints.NumReplicas != 0 {
+		return fmt.Errorf("constraints can't specify a number of replicas; " +
+			"use replica_constraints instead")
+	}
+	var numConstrainedReplicas int32
+	for _, replicaConstraints := range z.ReplicaConstraints {
+		if replicaConstraints.NumReplicas <= 0 {
+			return fmt.Errorf("every set of replica constraints must apply to a positive number of replicas")
+		}
+		if len(replicaConstraints.Constraints) == 0 {
+			return fmt.Errorf("every set of replica constraints must include at least one constraint")
+		}
+		for _, constraint := range replicaConstraints.Constraints {
+			if constraint.Type == Constraint_POSITIVE {
+				return fmt.Errorf("replica constraints must either be required " +
+					"(e.g. '+foo') or prohibited (e.g. '-foo')")
+			}
+		}
+		numConstrainedReplicas += replicaConstraints.NumReplicas
+	}
+	if numConstrainedReplicas > z.NumReplicas {
+		return fmt.Errorf("replica constraints apply to %d replicas, more than the %d replicas of the zone",
+			numConstrainedReplicas, z.NumReplicas)
+	}
+	for _, leasePref := range z.LeasePreferences {
+		if len(leasePref.Constraints) == 0 {
+			return fmt.Errorf("every lease preference must include at least one constraint")
+		}
+		for _, constraint := range leasePref.Constraints {
+			if constraint.Type == Constraint_POSITIVE {
+				return fmt.Errorf("lease preference constraints must either be required " +
+					"(e.g. '+foo') or prohibited (e.g. '-foo')")
+			}
+		}
+	}
+	return nil
+}
+
+// IsSubzonePlaceholder returns whether the zone config exists only to store
+// the subzones of a table. Such a zone config does not specify any fields of
+// its own and inherits them from the zone config of the table's database.
+func (z ZoneConfig) IsSubzonePlaceholder() bool {
+	return z.NumReplicas == 0 && len(z.Subzones) > 0
+}
+
+// GetSubzone returns the subzone for the given index and partition, or nil if
+// no such subzone exists.
+func (z *ZoneConfig) GetSubzone(indexID uint32, partition string) *Subzone {
+	for i := range z.Subzones {
+		s := &z.Subzones[i]
+		if s.IndexID == indexID && s.PartitionName == partition {
+			return s
+		}
+	}
+	return nil
+}
+
+// DeleteSubzone removes the subzone for the given index and partition and
+// returns whether it existed. The SubzoneSpans are not updated and must be
+// recomputed by the caller.
+func (z *ZoneConfig) DeleteSubzone(indexID uint32, partition string) bool {
+	for i, s := range z.Subzones {
+		if s.IndexID == indexID && s.PartitionName == partition {
+			z.Subzones = append(z.Subzones[:i], z.Subzones[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// SetSubzone installs the given subzone, replacing any existing subzone for
+// the same index and partition. The SubzoneSpans are not updated and must be
+// recomputed by the caller.
+func (z *ZoneConfig) SetSubzone(subzone Subzone) {
+	if s := z.GetSubzone(subzone.IndexID, subzone.PartitionName); s != nil {
+		*s = subzone
+		return
+	}
+	z.Subzones = append(z.Subzones, subzone)
+}
+
+// GetSubzoneForKeySuffix returns the subzone applying to the given key of the
+// table, with the table prefix stripped, or nil if the key is subject to the
+// zone config of the table itself.
+func (z *ZoneConfig) GetSubzoneForKeySuffix(keySuffix []byte) *Subzone {
+	for _, s := range z.SubzoneSpans {
+		if bytes.Compare(s.Key, keySuffix) > 0 {
+			break
+		}
+		endKey := s.EndKey
+		if len(endKey) == 0 {
+			endKey = s.Key.PrefixEnd()
+		}
+		if bytes.Compare(keySuffix, endKey) < 0 {
+			return &z.Subzones[s.SubzoneIndex]
+		}
+	}
 	return nil
 }
 
@@ -373,7 +508,19 @@ func (s SystemConfig) GetZoneConfigForKey(key roachpb.RKey) (ZoneConfig, error)
 		objectID = keys.SystemRangesID
 	}
 
-	return s.getZoneConfigForID(objectID)
+	zone, err := s.getZoneConfigForID(objectID)
+	if err != nil {
+		return ZoneConfig{}, err
+	}
+	if objectID > keys.MaxReservedDescID && len(zone.SubzoneSpans) > 0 {
+		// The key may belong to an index or partition of the table with a zone
+		// config of its own.
+		keySuffix := bytes.TrimPrefix(key, keys.MakeTablePrefix(objectID))
+		if subzone := zone.GetSubzoneForKeySuffix(keySuffix); subzone != nil {
+			return subzone.Config, nil
+		}
+	}
+	return zone, nil
 }
 
 // getZoneConfigForID looks up the zone config for the object (table or database)
@@ -388,6 +535,43 @@ func (s SystemConfig) getZoneConfigForID(id uint32) (ZoneConfig, error) {
 	return DefaultZoneConfig(), nil
 }
 
+// getSubzoneSplitKey returns the first key in (startKey, endKey) at which the
+// subzones of the table with the given ID require a split, or nil if there is
+// no such key.
+func (s SystemConfig) getSubzoneSplitKey(id uint32, startKey, endKey roachpb.RKey) roachpb.RKey {
+	testingLock.Lock()
+	hook := ZoneConfigHook
+	testingLock.Unlock()
+	if hook == nil {
+		return nil
+	}
+	zone, _, err := hook(s, id)
+	if err != nil {
+		log.Errorf(context.TODO(), "unable to determine zone config of table %d from system config: %s", id, err)
+		return nil
+	}
+	tablePrefix := keys.MakeTablePrefix(id)
+	for _, span := range zone.SubzoneSpans {
+		spanEndKey := span.EndKey
+		if len(spanEndKey) == 0 {
+			spanEndKey = span.Key.PrefixEnd()
+		}
+		// The spans are sorted, so the first boundary after startKey is the
+		// split key.
+		for _, suffix := range []roachpb.Key{span.Key, spanEndKey} {
+			key := roachpb.RKey(append(append([]byte(nil), tablePrefix...), suffix...))
+			if !startKey.Less(key) {
+				continue
+			}
+			if !key.Less(endKey) {
+				return nil
+			}
+			return key
+		}
+	}
+	return nil
+}
+
 // StaticSplits is the list of pre-defined split points in the beginning of
 // the keyspace that are there to support separate zone configs for different
 // parts of the system / system config ranges.
@@ -431,9 +615,11 @@ var StaticSplits = []struct {
 // ComputeSplitKey takes a start and end key and returns the first key at which
 // to split the span [start, end). Returns nil if no splits are required.
 //
-// Splits are required between user tables (i.e. /table/<id>), at the start
-// of the system-config tables (i.e. /table/0), and at certain points within the
-// system ranges that come before the system tables. The system-config range is
+// Splits are required between user tables (i.e. /table/<id>), at the
+// boundaries of the indexes and partitions of user tables that have zone
+// configs of their own, at the start of the system-config tables (i.e.
+// /table/0), and at certain points within the system ranges that come before
+// the system tables. The system-config range is
 // somewhat special in that it can contain multiple SQL tables
 // (/table/0-/table/<max-system-config-desc>) within a single range.
 func (s SystemConfig) ComputeSplitKey(startKey, endKey roachpb.RKey) roachpb.RKey {
@@ -464,6 +650,15 @@ func (s SystemConfig) ComputeSplitKey(startKey, endKey roachpb.RKey) roachpb.RKe
 		// In either case, start looking for splits at the first ID usable
 		// by the user data span.
 		startID = keys.MaxSystemConfigDescID + 1
+	} else if startID > keys.MaxReservedDescID {
+		// The start key is either already a split key, or after the split
+		// key for its ID. The only splits remaining in its table are those
+		// required by subzones; after them, we can skip straight to the next
+		// ID.
+		if splitKey := s.getSubzoneSplitKey(startID, startKey, endKey); splitKey != nil {
+			return splitKey
+		}
+		startID++
 	} else {
 		// The start key is either already a split key, or after the split
 		// key for its ID. We can skip straight to the next one.
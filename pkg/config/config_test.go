@@ -427,6 +427,96 @@ func TestGetZoneConfigForKey(t *testing.T) {
 	}
 }
 
+func TestSubzones(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const tableID = keys.MaxReservedDescID + 1
+	tablePrefix := keys.MakeTablePrefix(tableID)
+	suffix := func(vals ...int64) roachpb.Key {
+		k := encoding.EncodeUvarintAscending(nil, 1) // Index ID.
+		for _, v := range vals {
+			k = encoding.EncodeVarintAscending(k, v)
+		}
+		return k
+	}
+	key := func(vals ...int64) roachpb.RKey {
+		return roachpb.RKey(append(append([]byte(nil), tablePrefix...), suffix(vals...)...))
+	}
+
+	zone := config.ZoneConfig{
+		NumReplicas: 1,
+		Subzones: []config.Subzone{
+			{IndexID: 1, PartitionName: "p1", Config: config.ZoneConfig{NumReplicas: 3}},
+			{IndexID: 1, PartitionName: "p2", Config: config.ZoneConfig{NumReplicas: 5}},
+		},
+		SubzoneSpans: []config.SubzoneSpan{
+			{Key: suffix(1), SubzoneIndex: 0},
+			{Key: suffix(5), EndKey: suffix(8), SubzoneIndex: 1},
+		},
+	}
+
+	originalZoneConfigHook := config.ZoneConfigHook
+	defer func() {
+		config.ZoneConfigHook = originalZoneConfigHook
+	}()
+	config.ZoneConfigHook = func(_ config.SystemConfig, id uint32) (config.ZoneConfig, bool, error) {
+		if id == tableID {
+			return zone, true, nil
+		}
+		return config.ZoneConfig{}, false, nil
+	}
+
+	schema := sqlbase.MakeMetadataSchema()
+	cfg := config.SystemConfig{
+		Values: append(schema.GetInitialValues(), descriptor(tableID), descriptor(tableID+1)),
+	}
+	sort.Sort(roachpb.KeyValueByKey(cfg.Values))
+
+	zoneTestCases := []struct {
+		key                 roachpb.RKey
+		expectedNumReplicas int32
+	}{
+		{roachpb.RKey(tablePrefix), 1},
+		{key(), 1},
+		{key(1), 3},
+		{testutils.MakeKey(key(1), roachpb.RKey("foo")), 3},
+		{key(2), 1},
+		{key(5), 5},
+		{key(7), 5},
+		{key(8), 1},
+	}
+	for tcNum, tc := range zoneTestCases {
+		z, err := cfg.GetZoneConfigForKey(tc.key)
+		if err != nil {
+			t.Fatalf("#%d: GetZoneConfigForKey(%v) got error: %v", tcNum, tc.key, err)
+		}
+		if z.NumReplicas != tc.expectedNumReplicas {
+			t.Errorf("#%d: GetZoneConfigForKey(%v) got %d replicas; want %d",
+				tcNum, tc.key, z.NumReplicas, tc.expectedNumReplicas)
+		}
+	}
+
+	splitTestCases := []struct {
+		start, end roachpb.RKey
+		split      roachpb.RKey
+	}{
+		{roachpb.RKey(tablePrefix), roachpb.RKeyMax, key(1)},
+		{key(1), roachpb.RKeyMax, key(1).PrefixEnd()},
+		{key(1).PrefixEnd(), roachpb.RKeyMax, key(5)},
+		{key(5), roachpb.RKeyMax, key(8)},
+		{key(8), roachpb.RKeyMax, keys.MakeTablePrefix(tableID + 1)},
+		{key(6), key(7), nil},
+		{roachpb.RKey(tablePrefix), key(1), nil},
+		{keys.MakeTablePrefix(tableID - 1), roachpb.RKeyMax, roachpb.RKey(tablePrefix)},
+	}
+	for tcNum, tc := range splitTestCases {
+		splitKey := cfg.ComputeSplitKey(tc.start, tc.end)
+		if !splitKey.Equal(tc.split) {
+			t.Errorf("#%d: bad split:\ngot: %v\nexpected: %v", tcNum, splitKey, tc.split)
+		}
+	}
+}
+
 func TestZoneConfigValidate(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -465,6 +555,111 @@ func TestZoneConfigValidate(t *testing.T) {
 			},
 			"is greater than or equal to RangeMaxBytes",
 		},
+		{
+			config.ZoneConfig{
+				NumReplicas:      1,
+				RangeMaxBytes:    config.DefaultZoneConfig().RangeMaxBytes,
+				LeasePreferences: []config.LeasePreference{{}},
+			},
+			"every lease preference must include at least one constraint",
+		},
+		{
+			config.ZoneConfig{
+				NumReplicas:   1,
+				RangeMaxBytes: config.DefaultZoneConfig().RangeMaxBytes,
+				LeasePreferences: []config.LeasePreference{
+					{Constraints: []config.Constraint{{Type: config.Constraint_POSITIVE, Value: "a"}}},
+				},
+			},
+			"lease preference constraints must either be required",
+		},
+		{
+			config.ZoneConfig{
+				NumReplicas:   1,
+				RangeMaxBytes: config.DefaultZoneConfig().RangeMaxBytes,
+				LeasePreferences: []config.LeasePreference{
+					{Constraints: []config.Constraint{{Type: config.Constraint_REQUIRED, Value: "a"}}},
+					{Constraints: []config.Constraint{{Type: config.Constraint_PROHIBITED, Value: "b"}}},
+				},
+			},
+			"",
+		},
+		{
+			config.ZoneConfig{
+				NumReplicas:   3,
+				RangeMaxBytes: config.DefaultZoneConfig().RangeMaxBytes,
+				Constraints: config.Constraints{
+					NumReplicas: 1,
+					Constraints: []config.Constraint{{Type: config.Constraint_REQUIRED, Value: "a"}},
+				},
+			},
+			"constraints can't specify a number of replicas",
+		},
+		{
+			config.ZoneConfig{
+				NumReplicas:   3,
+				RangeMaxBytes: config.DefaultZoneConfig().RangeMaxBytes,
+				ReplicaConstraints: []config.Constraints{
+					{Constraints: []config.Constraint{{Type: config.Constraint_REQUIRED, Value: "a"}}},
+				},
+			},
+			"must apply to a positive number of replicas",
+		},
+		{
+			config.ZoneConfig{
+				NumReplicas:        3,
+				RangeMaxBytes:      config.DefaultZoneConfig().RangeMaxBytes,
+				ReplicaConstraints: []config.Constraints{{NumReplicas: 1}},
+			},
+			"every set of replica constraints must include at least one constraint",
+		},
+		{
+			config.ZoneConfig{
+				NumReplicas:   3,
+				RangeMaxBytes: config.DefaultZoneConfig().RangeMaxBytes,
+				ReplicaConstraints: []config.Constraints{
+					{
+						NumReplicas: 1,
+						Constraints: []config.Constraint{{Type: config.Constraint_POSITIVE, Value: "a"}},
+					},
+				},
+			},
+			"replica constraints must either be required",
+		},
+		{
+			config.ZoneConfig{
+				NumReplicas:   3,
+				RangeMaxBytes: config.DefaultZoneConfig().RangeMaxBytes,
+				ReplicaConstraints: []config.Constraints{
+					{
+						NumReplicas: 2,
+						Constraints: []config.Constraint{{Type: config.Constraint_REQUIRED, Value: "a"}},
+					},
+					{
+						NumReplicas: 2,
+						Constraints: []config.Constraint{{Type: config.Constraint_REQUIRED, Value: "b"}},
+					},
+				},
+			},
+			"replica constraints apply to 4 replicas, more than the 3 replicas of the zone",
+		},
+		{
+			config.ZoneConfig{
+				NumReplicas:   3,
+				RangeMaxBytes: config.DefaultZoneConfig().RangeMaxBytes,
+				ReplicaConstraints: []config.Constraints{
+					{
+						NumReplicas: 2,
+						Constraints: []config.Constraint{{Type: config.Constraint_REQUIRED, Value: "a"}},
+					},
+					{
+						NumReplicas: 1,
+						Constraints: []config.Constraint{{Type: config.Constraint_PROHIBITED, Value: "a"}},
+					},
+				},
+			},
+			"",
+		},
 	}
 	for i, c := range testCases {
 		err := c.cfg.Validate()
@@ -504,6 +699,42 @@ func TestZoneConfigMarshalYAML(t *testing.T) {
 				},
 			},
 		},
+		LeasePreferences: []config.LeasePreference{
+			{
+				Constraints: []config.Constraint{
+					{
+						Type:  config.Constraint_REQUIRED,
+						Key:   "region",
+						Value: "us-east",
+					},
+				},
+			},
+			{
+				Constraints: []config.Constraint{
+					{
+						Type:  config.Constraint_REQUIRED,
+						Key:   "region",
+						Value: "us-west",
+					},
+					{
+						Type:  config.Constraint_PROHIBITED,
+						Value: "hdd",
+					},
+				},
+			},
+		},
+		ReplicaConstraints: []config.Constraints{
+			{
+				NumReplicas: 1,
+				Constraints: []config.Constraint{
+					{
+						Type:  config.Constraint_REQUIRED,
+						Key:   "region",
+						Value: "us-east",
+					},
+				},
+			},
+		},
 	}
 
 	expected := `range_min_bytes: 1
@@ -512,6 +743,8 @@ gc:
   ttlseconds: 1
 num_replicas: 1
 constraints: [foo, +duck=foo, -duck=foo]
+lease_preferences: [[+region=us-east], [+region=us-west, -hdd]]
+replica_constraints: [{num_replicas: 1, constraints: [+region=us-east]}]
 `
 
 	body, err := yaml.Marshal(original)
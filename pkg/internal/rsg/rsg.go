@@ -25,6 +25,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/ipaddr"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
@@ -246,6 +247,11 @@ func (r *RSG) GenerateRandomArg(typ parser.Type) string {
 		ipAddr := ipaddr.RandIPAddr(r.src)
 		r.lock.Unlock()
 		v = fmt.Sprintf(`'%s'`, ipAddr)
+	case parser.TypeJSON:
+		r.lock.Lock()
+		j := json.RandJSON(r.src, 2)
+		r.lock.Unlock()
+		v = parser.NewDJSON(j).String()
 	case parser.TypeOid,
 		parser.TypeRegClass,
 		parser.TypeRegNamespace,
@@ -17,6 +17,7 @@ package kv
 import (
 	"fmt"
 	"sync/atomic"
+	"time"
 	"unsafe"
 
 	"golang.org/x/net/context"
@@ -29,6 +30,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/rpc"
 	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
 	"github.com/cockroachdb/cockroach/pkg/util/grpcutil"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
@@ -428,17 +430,24 @@ func (ds *DistSender) sendSingleRange(
 	// Try to send the call.
 	replicas := NewReplicaSlice(ds.gossip, desc)
 
-	// Rearrange the replicas so that those replicas with long common
-	// prefix of attributes end up first. If there's no prefix, this is a
-	// no-op.
-	replicas.OptimizeReplicaOrder(ds.getNodeDescriptor())
-
-	// If this request needs to go to a lease holder and we know who that is, move
-	// it to the front.
-	if !(ba.IsReadOnly() && ba.ReadConsistency == roachpb.INCONSISTENT) {
-		if storeID, ok := ds.leaseHolderCache.Lookup(ctx, desc.RangeID); ok {
-			if i := replicas.FindReplica(storeID); i >= 0 {
-				replicas.MoveToFront(i)
+	if ds.canSendToFollower(ba) {
+		// The request can be served by any replica which has caught up to the
+		// closed timestamp, so try the nearest one first. A replica which
+		// can't serve it redirects us to the lease holder.
+		replicas.SortByProximity(ds.getNodeDescriptor(), ds.nodeLatency)
+	} else {
+		// Rearrange the replicas so that those replicas with long common
+		// prefix of attributes end up first. If there's no prefix, this is a
+		// no-op.
+		replicas.OptimizeReplicaOrder(ds.getNodeDescriptor())
+
+		// If this request needs to go to a lease holder and we know who that is,
+		// move it to the front.
+		if !(ba.IsReadOnly() && ba.ReadConsistency == roachpb.INCONSISTENT) {
+			if storeID, ok := ds.leaseHolderCache.Lookup(ctx, desc.RangeID); ok {
+				if i := replicas.FindReplica(storeID); i >= 0 {
+					replicas.MoveToFront(i)
+				}
 			}
 		}
 	}
@@ -462,6 +471,31 @@ func (ds *DistSender) sendSingleRange(
 	return br, pErr
 }
 
+// canSendToFollower returns true if the batch is a consistent read whose
+// timestamp (including the uncertainty interval of its transaction) is old
+// enough for follower replicas to be expected to serve it.
+func (ds *DistSender) canSendToFollower(ba roachpb.BatchRequest) bool {
+	if !storagebase.FollowerReadsEnabled.Get(&ds.st.SV) ||
+		!ba.IsReadOnly() || ba.ReadConsistency != roachpb.CONSISTENT {
+		return false
+	}
+	threshold := storagebase.FollowerReadTimestamp(&ds.st.SV, ds.clock.Now())
+	ts := ba.Timestamp
+	if ba.Txn != nil {
+		ts.Forward(ba.Txn.MaxTimestamp)
+	}
+	return ts != (hlc.Timestamp{}) && !threshold.Less(ts)
+}
+
+// nodeLatency returns the measured RPC latency to the node with the given
+// address, if known.
+func (ds *DistSender) nodeLatency(addr string) (time.Duration, bool) {
+	if ds.rpcContext == nil {
+		return 0, false
+	}
+	return ds.rpcContext.RemoteClocks.Latency(addr)
+}
+
 // initAndVerifyBatch initializes timestamp-related information and
 // verifies batch constraints before splitting.
 func (ds *DistSender) initAndVerifyBatch(
@@ -741,10 +775,15 @@ func (ds *DistSender) divideAndSendBatchToRanges(
 			}
 			// If the request is more than but ends with EndTransaction, we
 			// want the caller to come again with the EndTransaction in an
-			// extra call.
-			if l := len(ba.Requests) - 1; l > 0 && ba.Requests[l].GetInner().Method() == roachpb.EndTransaction {
-				responseCh <- response{pErr: errNo1PCTxn}
-				return
+			// extra call. The exception is an EndTransaction which lists the
+			// writes it is sent in parallel with, as these are proven to have
+			// succeeded before the transaction is considered committed.
+			if l := len(ba.Requests) - 1; l > 0 {
+				if et, ok := ba.Requests[l].GetInner().(*roachpb.EndTransactionRequest); ok &&
+					len(et.InFlightWrites) == 0 {
+					responseCh <- response{pErr: errNo1PCTxn}
+					return
+				}
 			}
 		}
 
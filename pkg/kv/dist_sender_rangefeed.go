@@ -0,0 +1,197 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package kv
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
+)
+
+// RangeFeed divides a RangeFeed request on range boundaries and establishes a
+// RangeFeed to each of the individual ranges, streaming back their events on
+// the provided channel. Values committed after the given timestamp are
+// delivered at least once; checkpoints are delivered per range. RangeFeeds
+// which are disconnected, for instance because their range split or merged,
+// are re-established from their last checkpoint.
+//
+// RangeFeed returns when the context is canceled or an error occurs which
+// can't be retried.
+func (ds *DistSender) RangeFeed(
+	ctx context.Context, span roachpb.Span, ts hlc.Timestamp, eventCh chan<- *roachpb.RangeFeedEvent,
+) error {
+	ctx = ds.AnnotateCtx(ctx)
+	startRKey, err := keys.Addr(span.Key)
+	if err != nil {
+		return err
+	}
+	endRKey, err := keys.AddrUpperBound(span.EndKey)
+	if err != nil {
+		return err
+	}
+	rs := roachpb.RSpan{Key: startRKey, EndKey: endRKey}
+
+	g, gCtx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		return ds.divideAndSendRangeFeedToRanges(gCtx, g, rs, ts, eventCh)
+	})
+	return g.Wait()
+}
+
+// divideAndSendRangeFeedToRanges starts a partial RangeFeed in the group for
+// each of the ranges overlapping the given span.
+func (ds *DistSender) divideAndSendRangeFeedToRanges(
+	ctx context.Context,
+	g *errgroup.Group,
+	rs roachpb.RSpan,
+	ts hlc.Timestamp,
+	eventCh chan<- *roachpb.RangeFeedEvent,
+) error {
+	ri := NewRangeIterator(ds)
+	for ri.Seek(ctx, rs.Key, Ascending); ri.Valid(); ri.Next(ctx) {
+		partialRS, err := rs.Intersect(ri.Desc())
+		if err != nil {
+			return err
+		}
+		token := ri.Token()
+		g.Go(func() error {
+			return ds.partialRangeFeed(ctx, g, partialRS, ts, token, eventCh)
+		})
+		if !ri.NeedAnother(rs) {
+			return nil
+		}
+	}
+	return ri.Error().GoError()
+}
+
+// partialRangeFeed establishes a RangeFeed to the range covering the given
+// span and re-establishes it from its last checkpoint whenever it's
+// disconnected. If the range is found to have split or merged, the span is
+// divided anew.
+func (ds *DistSender) partialRangeFeed(
+	ctx context.Context,
+	g *errgroup.Group,
+	rs roachpb.RSpan,
+	ts hlc.Timestamp,
+	token *EvictionToken,
+	eventCh chan<- *roachpb.RangeFeedEvent,
+) error {
+	span := roachpb.Span{Key: rs.Key.AsRawKey(), EndKey: rs.EndKey.AsRawKey()}
+	for r := retry.StartWithCtx(ctx, ds.rpcRetryOptions); r.Next(); {
+		if !storagebase.RangefeedEnabled.Get(&ds.st.SV) {
+			return errors.New("rangefeeds require the kv.rangefeed.enabled setting")
+		}
+		desc, newToken, err := ds.getDescriptor(ctx, rs.Key, token, false /* useReverseScan */)
+		if err != nil {
+			log.VEventf(ctx, 1, "range descriptor lookup failed: %s", err)
+			continue
+		}
+		token = newToken
+		if !desc.ContainsKeyRange(rs.Key, rs.EndKey) {
+			// The span no longer fits into a single range.
+			return ds.divideAndSendRangeFeedToRanges(ctx, g, rs, ts, eventCh)
+		}
+
+		err = ds.singleRangeFeed(ctx, span, desc, &ts, eventCh)
+		switch errors.Cause(err).(type) {
+		case nil:
+			return nil
+		case *roachpb.RangeKeyMismatchError, *roachpb.RangeNotFoundError:
+			// The range split, merged or moved. Evict the cached descriptor and
+			// divide the span anew.
+			if err := token.Evict(ctx); err != nil {
+				return err
+			}
+			return ds.divideAndSendRangeFeedToRanges(ctx, g, rs, ts, eventCh)
+		default:
+			log.VEventf(ctx, 1, "rangefeed on %s disconnected: %s", span, err)
+		}
+	}
+	return ctx.Err()
+}
+
+// singleRangeFeed establishes a RangeFeed to one of the replicas of the given
+// range and streams its events until it's disconnected. The timestamp is
+// forwarded to the last checkpoint received, so that a new RangeFeed can
+// pick up where this one stopped. A disconnection reported by the replica is
+// returned as the error detail the replica sent.
+func (ds *DistSender) singleRangeFeed(
+	ctx context.Context,
+	span roachpb.Span,
+	desc *roachpb.RangeDescriptor,
+	ts *hlc.Timestamp,
+	eventCh chan<- *roachpb.RangeFeedEvent,
+) error {
+	replicas := NewReplicaSlice(ds.gossip, desc)
+	// Any replica can serve a RangeFeed, so try the nearest one first.
+	replicas.SortByProximity(ds.getNodeDescriptor(), ds.nodeLatency)
+
+	var stream roachpb.Internal_RangeFeedClient
+	var err error
+	for _, replica := range replicas {
+		args := roachpb.RangeFeedRequest{
+			Header: roachpb.Header{
+				Timestamp: *ts,
+				RangeID:   desc.RangeID,
+				Replica:   replica.ReplicaDescriptor,
+			},
+			Span: span,
+		}
+		conn, dialErr := ds.rpcContext.GRPCDial(replica.NodeDesc.Address.String())
+		if dialErr != nil {
+			err = dialErr
+			continue
+		}
+		stream, err = roachpb.NewInternalClient(conn).RangeFeed(ctx, &args)
+		if err == nil {
+			break
+		}
+	}
+	if stream == nil {
+		if err == nil {
+			err = errors.Errorf("no replica of r%d is reachable", desc.RangeID)
+		}
+		return err
+	}
+
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			return errors.Errorf("rangefeed on r%d closed by the server", desc.RangeID)
+		} else if err != nil {
+			return err
+		}
+		switch t := event.GetValue().(type) {
+		case *roachpb.RangeFeedCheckpoint:
+			ts.Forward(t.ResolvedTS)
+		case *roachpb.RangeFeedError:
+			return t.Error.GoError()
+		}
+		select {
+		case eventCh <- event:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -15,6 +15,9 @@
 package kv
 
 import (
+	"sort"
+	"time"
+
 	"golang.org/x/net/context"
 
 	"github.com/cockroachdb/cockroach/pkg/gossip"
@@ -166,3 +169,45 @@ func (rs ReplicaSlice) OptimizeReplicaOrder(nodeDesc *roachpb.NodeDescriptor) {
 		rs.MoveToFront(i)
 	}
 }
+
+// SortByProximity sorts the replicas in the order in which they're expected
+// to respond fastest, for requests which can be served by any replica. A
+// replica on the current node comes first, followed by replicas whose
+// localities have the most in common with the current node's locality.
+// Replicas with the same locality are ordered by the measured RPC latency to
+// their nodes, as reported by latencyFn; those without a latency measurement
+// come last.
+//
+// nodeDesc is the descriptor of the current node. It can be nil, in which case
+// the replicas are shuffled.
+func (rs ReplicaSlice) SortByProximity(
+	nodeDesc *roachpb.NodeDescriptor, latencyFn func(string) (time.Duration, bool),
+) {
+	// Shuffle first so that replicas which are equally close are tried in a
+	// random order.
+	shuffle.Shuffle(rs)
+	if nodeDesc == nil {
+		return
+	}
+	sort.SliceStable(rs, func(i, j int) bool {
+		iLocal := rs[i].NodeID == nodeDesc.NodeID
+		jLocal := rs[j].NodeID == nodeDesc.NodeID
+		if iLocal != jLocal {
+			return iLocal
+		}
+		iScore := nodeDesc.Locality.DiversityScore(rs[i].NodeDesc.Locality)
+		jScore := nodeDesc.Locality.DiversityScore(rs[j].NodeDesc.Locality)
+		if iScore != jScore {
+			return iScore < jScore
+		}
+		if latencyFn == nil {
+			return false
+		}
+		iLatency, iOK := latencyFn(rs[i].NodeDesc.Address.String())
+		jLatency, jOK := latencyFn(rs[j].NodeDesc.Address.String())
+		if iOK != jOK {
+			return iOK
+		}
+		return iLatency < jLatency
+	})
+}
@@ -15,10 +15,13 @@
 package kv
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 )
 
@@ -166,3 +169,47 @@ func TestMoveLocalReplicaToFront(t *testing.T) {
 	}
 
 }
+
+func TestReplicaSliceSortByProximity(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	locality := func(region, zone string) roachpb.Locality {
+		return roachpb.Locality{Tiers: []roachpb.Tier{
+			{Key: "region", Value: region}, {Key: "zone", Value: zone},
+		}}
+	}
+	addr := func(nodeID roachpb.NodeID) string {
+		return fmt.Sprintf("node%d:26257", nodeID)
+	}
+	replica := func(nodeID roachpb.NodeID, l roachpb.Locality) ReplicaInfo {
+		return ReplicaInfo{
+			ReplicaDescriptor: roachpb.ReplicaDescriptor{NodeID: nodeID, StoreID: roachpb.StoreID(nodeID)},
+			NodeDesc:          &roachpb.NodeDescriptor{NodeID: nodeID, Address: util.MakeUnresolvedAddr("tcp", addr(nodeID)), Locality: l},
+		}
+	}
+	latencies := map[string]time.Duration{
+		addr(2): 50 * time.Millisecond,
+		addr(3): 10 * time.Millisecond,
+		addr(5): 5 * time.Millisecond,
+	}
+	latencyFn := func(addr string) (time.Duration, bool) {
+		l, ok := latencies[addr]
+		return l, ok
+	}
+
+	localNodeDesc := &roachpb.NodeDescriptor{NodeID: 1, Locality: locality("us", "a")}
+	rs := ReplicaSlice{
+		replica(5, locality("eu", "a")),
+		replica(4, locality("us", "b")),
+		replica(3, locality("us", "b")),
+		replica(2, locality("us", "a")),
+		replica(1, locality("us", "a")),
+	}
+	// The local replica comes first, then the replica in the same zone, then
+	// the replicas in the same region ordered by latency (with the unknown
+	// latency last), and then the replica in another region.
+	rs.SortByProximity(localNodeDesc, latencyFn)
+	exp := []roachpb.StoreID{1, 2, 3, 4, 5}
+	if stores := getStores(rs); !reflect.DeepEqual(stores, exp) {
+		t.Errorf("expected order %s, got %s", exp, stores)
+	}
+}
@@ -16,6 +16,7 @@ package kv
 
 import (
 	"fmt"
+	"sort"
 	"sync/atomic"
 	"time"
 
@@ -56,6 +57,26 @@ var maxIntents = settings.RegisterIntSetting(
 	100000,
 )
 
+// pipelinedWritesEnabled controls whether transactional writes are
+// acknowledged as soon as they have been proposed to Raft, instead of
+// once they have applied. All nodes in the cluster must support the
+// QueryIntent request before this is enabled.
+var pipelinedWritesEnabled = settings.RegisterBoolSetting(
+	"kv.transaction.write_pipelining_enabled",
+	"if enabled, transactional writes are pipelined through Raft consensus",
+	false,
+)
+
+// parallelCommitsEnabled controls whether a transaction's EndTransaction
+// request is sent in parallel with the final writes of the transaction,
+// instead of after them. All nodes in the cluster must support the STAGING
+// transaction status and the RecoverTxn request before this is enabled.
+var parallelCommitsEnabled = settings.RegisterBoolSetting(
+	"kv.transaction.parallel_commits_enabled",
+	"if enabled, transactional commits are parallelized with transactional writes",
+	false,
+)
+
 // txnMetadata holds information about an ongoing transaction, as
 // seen from the perspective of this coordinator. It records all
 // keys (and key ranges) mutated as part of the transaction for
@@ -69,6 +90,13 @@ type txnMetadata struct {
 	// to update the write intent when the transaction is committed.
 	keys []roachpb.Span
 
+	// inFlightWrites holds the point writes of this transaction which were
+	// acknowledged before they finished replicating, keyed by the written
+	// key. Each of them is proven with a QueryIntent request before a
+	// request which touches its key is sent and before the transaction
+	// commits.
+	inFlightWrites map[string]inFlightWrite
+
 	// lastUpdateNanos is the latest wall time in nanos the client sent
 	// transaction operations to this coordinator. Accessed and updated
 	// atomically.
@@ -88,6 +116,174 @@ type txnMetadata struct {
 	txnEnd chan struct{}
 }
 
+// inFlightWrite is a point write which was acknowledged before it finished
+// replicating. Its intent is expected to belong to the given epoch of the
+// transaction and to have been written at a sequence number no lower than
+// the given one.
+type inFlightWrite struct {
+	epoch    uint32
+	sequence int32
+}
+
+// prependQueryIntents prepends a QueryIntent request to the batch for each
+// in-flight write the batch depends on: all of them if the batch commits the
+// transaction, and otherwise those whose keys are touched by a request in the
+// batch. In-flight writes of earlier epochs are dropped, since a restart
+// discards them. Returns the number of requests prepended.
+func (tm *txnMetadata) prependQueryIntents(ba *roachpb.BatchRequest, commit bool) int {
+	var queried []roachpb.Key
+	for k, w := range tm.inFlightWrites {
+		if w.epoch != ba.Txn.Epoch {
+			delete(tm.inFlightWrites, k)
+			continue
+		}
+		key := roachpb.Key(k)
+		if commit || batchTouchesKey(ba, key) {
+			queried = append(queried, key)
+		}
+	}
+	if len(queried) == 0 {
+		return 0
+	}
+	sort.Slice(queried, func(i, j int) bool {
+		return queried[i].Compare(queried[j]) < 0
+	})
+	reqs := make([]roachpb.RequestUnion, len(queried), len(queried)+len(ba.Requests))
+	for i, key := range queried {
+		meta := ba.Txn.TxnMeta
+		meta.Sequence = tm.inFlightWrites[string(key)].sequence
+		reqs[i].MustSetInner(&roachpb.QueryIntentRequest{
+			Span:           roachpb.Span{Key: key},
+			Txn:            meta,
+			ErrorIfMissing: true,
+		})
+	}
+	// The batch's requests may be in use by an outgoing goroutine, so a new
+	// slice is allocated instead of modifying them in place.
+	ba.Requests = append(reqs, ba.Requests...)
+	return len(queried)
+}
+
+// updateInFlightWrites updates the in-flight writes after the given batch
+// succeeded. The writes proven by QueryIntent requests in the batch are
+// removed and, if the batch was acknowledged before it finished replicating,
+// the point writes it contained are added.
+//
+// Writes of a batch which failed are not tracked: a failed batch may have been
+// partially applied regardless of whether it was pipelined, so the client
+// cannot rely on any of its writes.
+func (tm *txnMetadata) updateInFlightWrites(ba roachpb.BatchRequest) {
+	for _, union := range ba.Requests {
+		switch req := union.GetInner().(type) {
+		case *roachpb.QueryIntentRequest:
+			if w, ok := tm.inFlightWrites[string(req.Key)]; ok &&
+				w.epoch == req.Txn.Epoch && w.sequence == req.Txn.Sequence {
+				delete(tm.inFlightWrites, string(req.Key))
+			}
+		case *roachpb.BeginTransactionRequest:
+			// The transaction record is written atomically with the write to
+			// the transaction's anchor key, which is proven instead.
+		default:
+			if !ba.AsyncConsensus || !roachpb.IsTransactionWrite(req) {
+				continue
+			}
+			if tm.inFlightWrites == nil {
+				tm.inFlightWrites = map[string]inFlightWrite{}
+			}
+			// The DistSender increments the sequence number before sending
+			// the batch, so the write's intent carries a higher sequence
+			// number than the one the batch was handed to us with.
+			tm.inFlightWrites[string(req.Header().Key)] = inFlightWrite{
+				epoch:    ba.Txn.Epoch,
+				sequence: ba.Txn.Sequence + 1,
+			}
+		}
+	}
+}
+
+// batchTouchesKey returns whether any request in the batch accesses the
+// given key.
+func batchTouchesKey(ba *roachpb.BatchRequest, key roachpb.Key) bool {
+	for _, union := range ba.Requests {
+		if union.GetInner().Header().Overlaps(roachpb.Span{Key: key}) {
+			return true
+		}
+	}
+	return false
+}
+
+// canPipelineWrites returns whether the batch may be acknowledged before its
+// writes finish replicating. This is the case for batches consisting only of
+// transactional point writes (optionally accompanied by the request beginning
+// the transaction and by QueryIntent requests), as the success of each such
+// write can later be proven by querying its intent.
+func canPipelineWrites(ba roachpb.BatchRequest) bool {
+	var hasWrite bool
+	for _, union := range ba.Requests {
+		switch req := union.GetInner().(type) {
+		case *roachpb.BeginTransactionRequest, *roachpb.QueryIntentRequest:
+		default:
+			if !roachpb.IsTransactionWrite(req) || roachpb.IsRange(req) {
+				return false
+			}
+			hasWrite = true
+		}
+	}
+	return hasWrite
+}
+
+// canParallelCommit returns whether the batch, which ends with a committing
+// EndTransaction, may be sent with the EndTransaction in parallel with the
+// other requests. This is the case if the batch consists only of
+// transactional point writes (optionally accompanied by QueryIntent
+// requests), as the success of each such write can be proven by querying its
+// intent. Batches beginning the transaction or carrying a commit trigger
+// must commit in the conventional way.
+func canParallelCommit(ba roachpb.BatchRequest) bool {
+	var hasWrite bool
+	for _, union := range ba.Requests {
+		switch req := union.GetInner().(type) {
+		case *roachpb.QueryIntentRequest:
+		case *roachpb.EndTransactionRequest:
+			if req.InternalCommitTrigger != nil {
+				return false
+			}
+		default:
+			if !roachpb.IsTransactionWrite(req) || roachpb.IsRange(req) {
+				return false
+			}
+			hasWrite = true
+		}
+	}
+	return hasWrite
+}
+
+// parallelCommitWrites returns the writes which must have succeeded for the
+// transaction to be implicitly committed: the point writes of the batch,
+// which are sent in parallel with its EndTransaction, and the earlier
+// in-flight writes queried by the batch's QueryIntent requests, which are
+// sent in parallel with it as well. The DistSender increments the sequence
+// number before sending the batch, so each write's intent carries a higher
+// sequence number than the one the batch was handed to us with.
+func parallelCommitWrites(ba roachpb.BatchRequest) []roachpb.SequencedWrite {
+	var writes []roachpb.SequencedWrite
+	for _, union := range ba.Requests {
+		req := union.GetInner()
+		if qi, ok := req.(*roachpb.QueryIntentRequest); ok {
+			writes = append(writes, roachpb.SequencedWrite{
+				Key:      qi.Key,
+				Sequence: qi.Txn.Sequence,
+			})
+		} else if roachpb.IsTransactionWrite(req) {
+			writes = append(writes, roachpb.SequencedWrite{
+				Key:      req.Header().Key,
+				Sequence: ba.Txn.Sequence + 1,
+			})
+		}
+	}
+	return writes
+}
+
 // setLastUpdate updates the wall time (in nanoseconds) since the most
 // recent client operation for this transaction through the coordinator.
 func (tm *txnMetadata) setLastUpdate(nowNanos int64) {
@@ -120,10 +316,11 @@ type TxnMetrics struct {
 	Restarts *metric.Histogram
 
 	// Counts of restart types.
-	RestartsWriteTooOld    *metric.Counter
-	RestartsDeleteRange    *metric.Counter
-	RestartsSerializable   *metric.Counter
-	RestartsPossibleReplay *metric.Counter
+	RestartsWriteTooOld       *metric.Counter
+	RestartsDeleteRange       *metric.Counter
+	RestartsSerializable      *metric.Counter
+	RestartsPossibleReplay    *metric.Counter
+	RestartsAsyncWriteFailure *metric.Counter
 }
 
 var (
@@ -157,22 +354,26 @@ var (
 	metaRestartsPossibleReplay = metric.Metadata{
 		Name: "txn.restarts.possiblereplay",
 		Help: "Number of restarts due to possible replays of command batches at the storage layer"}
+	metaRestartsAsyncWriteFailure = metric.Metadata{
+		Name: "txn.restarts.asyncwritefailure",
+		Help: "Number of restarts due to pipelined writes which failed to replicate"}
 )
 
 // MakeTxnMetrics returns a TxnMetrics struct that contains metrics whose
 // windowed portions retain data for approximately histogramWindow.
 func MakeTxnMetrics(histogramWindow time.Duration) TxnMetrics {
 	return TxnMetrics{
-		Aborts:                 metric.NewCounterWithRates(metaAbortsRates),
-		Commits:                metric.NewCounterWithRates(metaCommitsRates),
-		Commits1PC:             metric.NewCounterWithRates(metaCommits1PCRates),
-		Abandons:               metric.NewCounterWithRates(metaAbandonsRates),
-		Durations:              metric.NewLatency(metaDurationsHistograms, histogramWindow),
-		Restarts:               metric.NewHistogram(metaRestartsHistogram, histogramWindow, 100, 3),
-		RestartsWriteTooOld:    metric.NewCounter(metaRestartsWriteTooOld),
-		RestartsDeleteRange:    metric.NewCounter(metaRestartsDeleteRange),
-		RestartsSerializable:   metric.NewCounter(metaRestartsSerializable),
-		RestartsPossibleReplay: metric.NewCounter(metaRestartsPossibleReplay),
+		Aborts:                    metric.NewCounterWithRates(metaAbortsRates),
+		Commits:                   metric.NewCounterWithRates(metaCommitsRates),
+		Commits1PC:                metric.NewCounterWithRates(metaCommits1PCRates),
+		Abandons:                  metric.NewCounterWithRates(metaAbandonsRates),
+		Durations:                 metric.NewLatency(metaDurationsHistograms, histogramWindow),
+		Restarts:                  metric.NewHistogram(metaRestartsHistogram, histogramWindow, 100, 3),
+		RestartsWriteTooOld:       metric.NewCounter(metaRestartsWriteTooOld),
+		RestartsDeleteRange:       metric.NewCounter(metaRestartsDeleteRange),
+		RestartsSerializable:      metric.NewCounter(metaRestartsSerializable),
+		RestartsPossibleReplay:    metric.NewCounter(metaRestartsPossibleReplay),
+		RestartsAsyncWriteFailure: metric.NewCounter(metaRestartsAsyncWriteFailure),
 	}
 }
 
@@ -333,6 +534,9 @@ func (tc *TxnCoordSender) Send(
 	}
 
 	startNS := tc.clock.PhysicalNow()
+	// The number of QueryIntent requests prepended to the batch to prove
+	// in-flight writes; their responses are not returned to the client.
+	var numQueryIntents int
 
 	if ba.Txn != nil {
 		// If this request is part of a transaction...
@@ -364,6 +568,9 @@ func (tc *TxnCoordSender) Send(
 					// write on multiple coordinators.
 					return nil, roachpb.NewErrorf("client must not pass intents to EndTransaction")
 				}
+				if len(et.InFlightWrites) > 0 {
+					return nil, roachpb.NewErrorf("client must not pass in-flight writes to EndTransaction")
+				}
 			}
 		}
 
@@ -374,6 +581,14 @@ func (tc *TxnCoordSender) Send(
 				return pErr
 			}
 
+			txnMeta := tc.txnMu.txns[txnID]
+			if txnMeta != nil {
+				// Writes which were acknowledged before they finished
+				// replicating must be proven before the batch can depend on
+				// them.
+				numQueryIntents = txnMeta.prependQueryIntents(&ba, hasET && et.Commit)
+			}
+
 			if !hasET {
 				return nil
 			}
@@ -381,7 +596,6 @@ func (tc *TxnCoordSender) Send(
 
 			// Populate et.IntentSpans, taking into account both any existing
 			// and new writes, and taking care to perform proper deduplication.
-			txnMeta := tc.txnMu.txns[txnID]
 			distinctSpans := true
 			if txnMeta != nil {
 				et.IntentSpans = txnMeta.keys
@@ -424,6 +638,9 @@ func (tc *TxnCoordSender) Send(
 			if txnMeta != nil {
 				txnMeta.keys = et.IntentSpans
 			}
+			if et.Commit && parallelCommitsEnabled.Get(&tc.st.SV) && canParallelCommit(ba) {
+				et.InFlightWrites = parallelCommitWrites(ba)
+			}
 			return nil
 		}(); pErr != nil {
 			return nil, pErr
@@ -434,6 +651,10 @@ func (tc *TxnCoordSender) Send(
 				log.Eventf(ctx, "intent: [%s,%s)", intent.Key, intent.EndKey)
 			}
 		}
+
+		if !hasET && pipelinedWritesEnabled.Get(&tc.st.SV) && canPipelineWrites(ba) {
+			ba.AsyncConsensus = true
+		}
 	}
 
 	// Send the command through wrapped sender, taking appropriate measures
@@ -447,11 +668,21 @@ func (tc *TxnCoordSender) Send(
 			br, pErr = tc.resendWithTxn(ctx, ba)
 		}
 
+		if pErr == nil && br.Txn != nil && br.Txn.Status == roachpb.STAGING {
+			br, pErr = tc.finishParallelCommit(ctx, ba, br)
+		}
+
 		if pErr = tc.updateState(ctx, startNS, ba, br, pErr); pErr != nil {
 			log.Eventf(ctx, "error: %s", pErr)
-			return nil, pErr
+			return nil, stripQueryIntentsFromError(pErr, numQueryIntents)
 		}
 	}
+	if numQueryIntents > 0 {
+		// Hide the responses to the QueryIntent requests added above.
+		brShallow := *br
+		brShallow.Responses = br.Responses[numQueryIntents:]
+		br = &brShallow
+	}
 
 	if br.Txn == nil {
 		return br, nil
@@ -492,6 +723,83 @@ func (tc *TxnCoordSender) Send(
 	return br, nil
 }
 
+// finishParallelCommit is called once a batch whose EndTransaction was sent
+// in parallel with the transaction's final writes has succeeded, leaving the
+// transaction record STAGING. If the transaction's timestamp was not pushed
+// by any of the writes, they all succeeded at the timestamp the transaction
+// was staged at and the transaction is implicitly committed: the client is
+// told so right away, while the record is finalized asynchronously.
+// Otherwise, the transaction is committed explicitly at its new timestamp,
+// which fails if that timestamp isn't acceptable.
+func (tc *TxnCoordSender) finishParallelCommit(
+	ctx context.Context, ba roachpb.BatchRequest, br *roachpb.BatchResponse,
+) (*roachpb.BatchResponse, *roachpb.Error) {
+	txn := br.Txn.Clone()
+	txn.Status = roachpb.PENDING
+	txn.InFlightWrites = nil
+
+	et := *ba.Requests[len(ba.Requests)-1].GetInner().(*roachpb.EndTransactionRequest)
+	et.InFlightWrites = nil
+	etBa := roachpb.BatchRequest{Header: ba.Header}
+	etBa.Txn = &txn
+	etBa.Add(&et)
+
+	if br.Txn.Timestamp != ba.Txn.Timestamp {
+		log.Eventf(ctx, "parallel commit of %s was pushed; committing explicitly", txn.Short())
+		etBr, pErr := tc.wrapped.Send(ctx, etBa)
+		if pErr != nil {
+			if pErr.Index != nil {
+				pErr.SetErrorIndex(int32(len(ba.Requests) - 1))
+			}
+			return nil, pErr
+		}
+		brShallow := *br
+		brShallow.Responses = append([]roachpb.ResponseUnion(nil), br.Responses...)
+		brShallow.Responses[len(brShallow.Responses)-1] = etBr.Responses[0]
+		brShallow.Txn = etBr.Txn
+		return &brShallow, nil
+	}
+
+	// NB: use context.Background() here because the caller's context may be
+	// cancelled as soon as the client has been told about the commit.
+	asyncCtx := tc.AnnotateCtx(context.Background())
+	if err := tc.stopper.RunAsyncTask(
+		asyncCtx, "kv.TxnCoordSender: finalizing parallel commit", func(ctx context.Context) {
+			if _, pErr := tc.wrapped.Send(ctx, etBa); pErr != nil {
+				log.Warningf(ctx, "finalizing parallel commit of %s failed: %s", txn, pErr)
+			}
+		}); err != nil {
+		// The record will be recovered by whoever runs into the transaction.
+		log.Warning(asyncCtx, err)
+	}
+
+	committedTxn := br.Txn.Clone()
+	committedTxn.Status = roachpb.COMMITTED
+	committedTxn.InFlightWrites = nil
+	brShallow := *br
+	brShallow.Txn = &committedTxn
+	return &brShallow, nil
+}
+
+// stripQueryIntentsFromError adjusts the index of an error returned for a
+// batch to which numQueryIntents QueryIntent requests were prepended so that
+// it refers to the client's requests. Errors caused by the QueryIntent
+// requests themselves are not attributed to any of the client's requests.
+func stripQueryIntentsFromError(pErr *roachpb.Error, numQueryIntents int) *roachpb.Error {
+	if numQueryIntents == 0 || pErr.Index == nil {
+		return pErr
+	}
+	// Avoid changing the existing error as it may have escaped into other
+	// goroutines.
+	pErrShallow := *pErr
+	if idx := pErr.Index.Index - int32(numQueryIntents); idx >= 0 {
+		pErrShallow.Index = &roachpb.ErrPosition{Index: idx}
+	} else {
+		pErrShallow.Index = nil
+	}
+	return &pErrShallow
+}
+
 // maybeRejectClientLocked checks whether the (transactional) request is in a
 // state that prevents it from continuing, such as the coordinator having
 // considered the client abandoned, or a heartbeat having reported an error.
@@ -789,7 +1097,16 @@ func (tc *TxnCoordSender) heartbeat(ctx context.Context, txnID uuid.UUID) bool {
 		tc.tryAsyncAbort(txn.ID)
 		txn.Status = roachpb.ABORTED
 	} else {
-		txn.Update(br.Responses[0].GetInner().(*roachpb.HeartbeatTxnResponse).Txn)
+		hbTxn := br.Responses[0].GetInner().(*roachpb.HeartbeatTxnResponse).Txn
+		if hbTxn.Status == roachpb.STAGING {
+			// The record is STAGING while we're committing in parallel; that's
+			// not news to the client.
+			hbTxnCopy := hbTxn.Clone()
+			hbTxnCopy.Status = roachpb.PENDING
+			hbTxnCopy.InFlightWrites = nil
+			hbTxn = &hbTxnCopy
+		}
+		txn.Update(hbTxn)
 	}
 
 	// Give the news to the txn in the txns map. This will update long-running
@@ -833,6 +1150,13 @@ func (tc *TxnCoordSender) updateState(
 	if pErr == nil {
 		newTxn.Update(ba.Txn)
 		newTxn.Update(br.Txn)
+		// If an intent proven by a QueryIntent was pushed, so was the
+		// transaction.
+		for _, union := range br.Responses {
+			if resp, ok := union.GetInner().(*roachpb.QueryIntentResponse); ok && resp.Intent != nil {
+				newTxn.Timestamp.Forward(resp.Intent.Txn.Timestamp)
+			}
+		}
 	} else {
 		if pErr.TransactionRestart != roachpb.TransactionRestart_NONE {
 			errTxnID := pErr.GetTxn().ID // The ID of the txn that needs to be restarted.
@@ -856,6 +1180,8 @@ func (tc *TxnCoordSender) updateState(
 					tc.metrics.RestartsSerializable.Inc(1)
 				case roachpb.RETRY_POSSIBLE_REPLAY:
 					tc.metrics.RestartsPossibleReplay.Inc(1)
+				case roachpb.RETRY_ASYNC_WRITE_FAILURE:
+					tc.metrics.RestartsAsyncWriteFailure.Inc(1)
 				}
 			}
 			newTxn = roachpb.PrepareTransactionForRetry(ctx, pErr, ba.UserPriority, tc.clock)
@@ -972,6 +1298,9 @@ func (tc *TxnCoordSender) updateState(
 	if txnMeta != nil {
 		txnMeta.txn.Update(&newTxn)
 		txnMeta.setLastUpdate(tc.clock.PhysicalNow())
+		if pErr == nil {
+			txnMeta.updateInFlightWrites(ba)
+		}
 	}
 
 	return pErr
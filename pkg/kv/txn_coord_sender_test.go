@@ -39,6 +39,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/metric"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 )
@@ -1731,3 +1732,287 @@ func TestTooManyIntentsAtCommit(t *testing.T) {
 		t.Fatal("did not expect value to exist")
 	}
 }
+
+// TestTxnCoordSenderPipelinesWrites verifies that, with write pipelining
+// enabled, batches of point writes are sent with AsyncConsensus set and
+// that the in-flight writes are proven with QueryIntent requests before a
+// request which touches their keys and before the transaction commits.
+func TestTxnCoordSenderPipelinesWrites(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	stopper := stop.NewStopper()
+	manual := hlc.NewManualClock(123)
+	clock := hlc.NewClock(manual.UnixNano, time.Nanosecond)
+
+	var batches []roachpb.BatchRequest
+	var senderFn client.SenderFunc = func(_ context.Context, ba roachpb.BatchRequest) (
+		*roachpb.BatchResponse, *roachpb.Error) {
+		batches = append(batches, ba)
+		br := ba.CreateReply()
+		for i, union := range ba.Requests {
+			if _, ok := union.GetInner().(*roachpb.QueryIntentRequest); ok {
+				br.Responses[i].GetInner().(*roachpb.QueryIntentResponse).FoundIntent = true
+			}
+		}
+		txnClone := ba.Txn.Clone()
+		br.Txn = &txnClone
+		br.Txn.Writing = true
+		if _, ok := ba.GetArg(roachpb.EndTransaction); ok {
+			br.Txn.Status = roachpb.COMMITTED
+		}
+		return br, nil
+	}
+	st := cluster.MakeTestingClusterSettings()
+	pipelinedWritesEnabled.Override(&st.SV, true)
+	ambient := log.AmbientContext{Tracer: tracing.NewTracer()}
+	ts := NewTxnCoordSender(
+		ambient,
+		st,
+		senderFn,
+		clock,
+		false,
+		stopper,
+		MakeTxnMetrics(metric.TestSampleInterval),
+	)
+
+	defer stopper.Stop(context.TODO())
+	defer teardownHeartbeats(ts)
+
+	db := client.NewDB(ts, clock)
+	txn := client.NewTxn(db, 0 /* gatewayNodeID */)
+	ctx := context.TODO()
+
+	if err := txn.Put(ctx, roachpb.Key("a"), []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	if err := txn.Put(ctx, roachpb.Key("b"), []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := txn.Get(ctx, roachpb.Key("a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := txn.DelRange(ctx, roachpb.Key("c"), roachpb.Key("d")); err != nil {
+		t.Fatal(err)
+	}
+	if err := txn.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	queriedKeys := func(ba roachpb.BatchRequest) []string {
+		var keys []string
+		for _, union := range ba.Requests {
+			if qi, ok := union.GetInner().(*roachpb.QueryIntentRequest); ok {
+				keys = append(keys, string(qi.Key))
+			}
+		}
+		return keys
+	}
+	expected := []struct {
+		async   bool
+		queried []string
+	}{
+		{async: true},                          // BeginTransaction, Put(a)
+		{async: true},                          // Put(b)
+		{async: false, queried: []string{"a"}}, // Get(a)
+		{async: false},                         // DelRange(c, d)
+		{async: false, queried: []string{"b"}}, // EndTransaction
+	}
+	if len(batches) != len(expected) {
+		t.Fatalf("expected %d batches, got %d: %v", len(expected), len(batches), batches)
+	}
+	for i, e := range expected {
+		ba := batches[i]
+		if ba.AsyncConsensus != e.async {
+			t.Errorf("%d: expected AsyncConsensus=%t for %s", i, e.async, ba)
+		}
+		if q := queriedKeys(ba); !reflect.DeepEqual(q, e.queried) {
+			t.Errorf("%d: expected queried intents %v, got %v", i, e.queried, q)
+		}
+	}
+}
+
+// TestTxnCoordSenderParallelCommits verifies that, with parallel commits
+// enabled, the EndTransaction of a batch of point writes lists these writes
+// as in flight, and that the STAGING transaction it results in is reported
+// as committed to the client and then committed explicitly. The explicit
+// commit is carried out before returning to the client only if the writes
+// pushed the transaction's timestamp.
+func TestTxnCoordSenderParallelCommits(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	for _, pushed := range []bool{false, true} {
+		t.Run(fmt.Sprintf("pushed=%t", pushed), func(t *testing.T) {
+			stopper := stop.NewStopper()
+			manual := hlc.NewManualClock(123)
+			clock := hlc.NewClock(manual.UnixNano, time.Nanosecond)
+
+			var mu struct {
+				syncutil.Mutex
+				ets []roachpb.EndTransactionRequest
+			}
+			var senderFn client.SenderFunc = func(_ context.Context, ba roachpb.BatchRequest) (
+				*roachpb.BatchResponse, *roachpb.Error) {
+				br := ba.CreateReply()
+				txnClone := ba.Txn.Clone()
+				br.Txn = &txnClone
+				br.Txn.Writing = true
+				if args, ok := ba.GetArg(roachpb.EndTransaction); ok {
+					et := args.(*roachpb.EndTransactionRequest)
+					mu.Lock()
+					mu.ets = append(mu.ets, *et)
+					mu.Unlock()
+					if len(et.InFlightWrites) > 0 {
+						br.Txn.Status = roachpb.STAGING
+						br.Txn.InFlightWrites = et.InFlightWrites
+						if pushed {
+							br.Txn.Timestamp = br.Txn.Timestamp.Add(1, 0)
+						}
+					} else {
+						br.Txn.Status = roachpb.COMMITTED
+					}
+				}
+				return br, nil
+			}
+			st := cluster.MakeTestingClusterSettings()
+			parallelCommitsEnabled.Override(&st.SV, true)
+			ambient := log.AmbientContext{Tracer: tracing.NewTracer()}
+			ts := NewTxnCoordSender(
+				ambient,
+				st,
+				senderFn,
+				clock,
+				false,
+				stopper,
+				MakeTxnMetrics(metric.TestSampleInterval),
+			)
+
+			defer stopper.Stop(context.TODO())
+			defer teardownHeartbeats(ts)
+
+			db := client.NewDB(ts, clock)
+			txn := client.NewTxn(db, 0 /* gatewayNodeID */)
+			ctx := context.TODO()
+
+			if err := txn.Put(ctx, roachpb.Key("a"), []byte("value")); err != nil {
+				t.Fatal(err)
+			}
+			b := txn.NewBatch()
+			b.Put(roachpb.Key("b"), []byte("value"))
+			if err := txn.CommitInBatch(ctx, b); err != nil {
+				t.Fatal(err)
+			}
+			if status := txn.Proto().Status; status != roachpb.COMMITTED {
+				t.Fatalf("expected COMMITTED txn, got %s", status)
+			}
+
+			getETs := func() []roachpb.EndTransactionRequest {
+				mu.Lock()
+				defer mu.Unlock()
+				return append([]roachpb.EndTransactionRequest(nil), mu.ets...)
+			}
+			if pushed {
+				if ets := getETs(); len(ets) != 2 {
+					t.Fatalf("expected explicit commit before returning, got %d EndTransactions", len(ets))
+				}
+			}
+			testutils.SucceedsSoon(t, func() error {
+				if ets := getETs(); len(ets) != 2 {
+					return errors.Errorf("expected 2 EndTransactions, got %d", len(ets))
+				}
+				return nil
+			})
+			ets := getETs()
+			if w := ets[0].InFlightWrites; len(w) != 1 || !w[0].Key.Equal(roachpb.Key("b")) {
+				t.Errorf("expected in-flight write to b, got %v", w)
+			}
+			if w := ets[1].InFlightWrites; len(w) != 0 {
+				t.Errorf("expected explicit commit without in-flight writes, got %v", w)
+			}
+			if len(ets[1].IntentSpans) != 2 {
+				t.Errorf("expected explicit commit to carry 2 intents, got %v", ets[1].IntentSpans)
+			}
+		})
+	}
+}
+
+// TestTxnCoordSenderParallelCommitAfterPipelinedWrite verifies that the
+// EndTransaction of a parallel commit also lists the earlier pipelined
+// writes which are queried in parallel with it, so that the transaction
+// isn't considered implicitly committed if one of them failed.
+func TestTxnCoordSenderParallelCommitAfterPipelinedWrite(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	stopper := stop.NewStopper()
+	manual := hlc.NewManualClock(123)
+	clock := hlc.NewClock(manual.UnixNano, time.Nanosecond)
+
+	var mu struct {
+		syncutil.Mutex
+		ets []roachpb.EndTransactionRequest
+	}
+	var senderFn client.SenderFunc = func(_ context.Context, ba roachpb.BatchRequest) (
+		*roachpb.BatchResponse, *roachpb.Error) {
+		br := ba.CreateReply()
+		for i, union := range ba.Requests {
+			if _, ok := union.GetInner().(*roachpb.QueryIntentRequest); ok {
+				br.Responses[i].GetInner().(*roachpb.QueryIntentResponse).FoundIntent = true
+			}
+		}
+		txnClone := ba.Txn.Clone()
+		br.Txn = &txnClone
+		br.Txn.Writing = true
+		if args, ok := ba.GetArg(roachpb.EndTransaction); ok {
+			et := args.(*roachpb.EndTransactionRequest)
+			mu.Lock()
+			mu.ets = append(mu.ets, *et)
+			mu.Unlock()
+			if len(et.InFlightWrites) > 0 {
+				br.Txn.Status = roachpb.STAGING
+				br.Txn.InFlightWrites = et.InFlightWrites
+			} else {
+				br.Txn.Status = roachpb.COMMITTED
+			}
+		}
+		return br, nil
+	}
+	st := cluster.MakeTestingClusterSettings()
+	pipelinedWritesEnabled.Override(&st.SV, true)
+	parallelCommitsEnabled.Override(&st.SV, true)
+	ambient := log.AmbientContext{Tracer: tracing.NewTracer()}
+	ts := NewTxnCoordSender(
+		ambient,
+		st,
+		senderFn,
+		clock,
+		false,
+		stopper,
+		MakeTxnMetrics(metric.TestSampleInterval),
+	)
+
+	defer stopper.Stop(context.TODO())
+	defer teardownHeartbeats(ts)
+
+	db := client.NewDB(ts, clock)
+	txn := client.NewTxn(db, 0 /* gatewayNodeID */)
+	ctx := context.TODO()
+
+	if err := txn.Put(ctx, roachpb.Key("a"), []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	b := txn.NewBatch()
+	b.Put(roachpb.Key("b"), []byte("value"))
+	if err := txn.CommitInBatch(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(mu.ets) == 0 {
+		t.Fatal("expected an EndTransaction")
+	}
+	w := mu.ets[0].InFlightWrites
+	if len(w) != 2 || !w[0].Key.Equal(roachpb.Key("a")) || !w[1].Key.Equal(roachpb.Key("b")) {
+		t.Fatalf("expected in-flight writes to a and b, got %v", w)
+	}
+	if w[0].Sequence >= w[1].Sequence {
+		t.Errorf("expected the pipelined write to a to have a lower sequence than the write to b, got %v", w)
+	}
+}
@@ -9,7 +9,7 @@ import (
 	"strconv"
 )
 
-type reqCounts [36]int32
+type reqCounts [39]int32
 
 // getReqCounts returns the number of times each
 // request type appears in the batch.
@@ -89,6 +89,12 @@ func (ba *BatchRequest) getReqCounts() reqCounts {
 			counts[34]++
 		case r.AddSstable != nil:
 			counts[35]++
+		case r.RangeStats != nil:
+			counts[36]++
+		case r.QueryIntent != nil:
+			counts[37]++
+		case r.RecoverTxn != nil:
+			counts[38]++
 		default:
 			panic(fmt.Sprintf("unsupported request: %+v", r))
 		}
@@ -133,6 +139,9 @@ var requestNames = []string{
 	"QueryTxn",
 	"AdmScatter",
 	"AddSstable",
+	"RngStats",
+	"QueryIntent",
+	"RecoverTxn",
 }
 
 // Summary prints a short summary of the requests in a batch.
@@ -203,6 +212,9 @@ func (ba *BatchRequest) CreateReply() *BatchResponse {
 	var buf33 []QueryTxnResponse
 	var buf34 []AdminScatterResponse
 	var buf35 []AddSSTableResponse
+	var buf36 []RangeStatsResponse
+	var buf37 []QueryIntentResponse
+	var buf38 []RecoverTxnResponse
 
 	for i, r := range ba.Requests {
 		switch {
@@ -422,6 +434,24 @@ func (ba *BatchRequest) CreateReply() *BatchResponse {
 			}
 			br.Responses[i].AddSstable = &buf35[0]
 			buf35 = buf35[1:]
+		case r.RangeStats != nil:
+			if buf36 == nil {
+				buf36 = make([]RangeStatsResponse, counts[36])
+			}
+			br.Responses[i].RangeStats = &buf36[0]
+			buf36 = buf36[1:]
+		case r.QueryIntent != nil:
+			if buf37 == nil {
+				buf37 = make([]QueryIntentResponse, counts[37])
+			}
+			br.Responses[i].QueryIntent = &buf37[0]
+			buf37 = buf37[1:]
+		case r.RecoverTxn != nil:
+			if buf38 == nil {
+				buf38 = make([]RecoverTxnResponse, counts[38])
+			}
+			br.Responses[i].RecoverTxn = &buf38[0]
+			buf38 = buf38[1:]
 		default:
 			panic(fmt.Sprintf("unsupported request: %+v", r))
 		}
@@ -133,4 +133,11 @@ const (
 	AdminScatter
 	// AddSSTable links a file into the RocksDB log-structured merge-tree.
 	AddSSTable
+	// RangeStats returns the MVCC statistics for a range.
+	RangeStats
+	// QueryIntent checks whether a transaction's intent exists at a key.
+	QueryIntent
+	// RecoverTxn commits or aborts a STAGING transaction, depending on
+	// whether all of its in-flight writes were found to have succeeded.
+	RecoverTxn
 )
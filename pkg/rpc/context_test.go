@@ -112,6 +112,12 @@ func (*internalServer) Batch(
 	return nil, nil
 }
 
+func (*internalServer) RangeFeed(
+	*roachpb.RangeFeedRequest, roachpb.Internal_RangeFeedServer,
+) error {
+	panic("unimplemented")
+}
+
 // TestHeartbeatHealth verifies that the health status changes after
 // heartbeats succeed or fail.
 func TestHeartbeatHealth(t *testing.T) {
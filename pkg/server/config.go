@@ -360,7 +360,8 @@ func MakeTempStoreSpecFromStoreSpec(spec base.StoreSpec) base.StoreSpec {
 		}
 	}
 	return base.StoreSpec{
-		Path: filepath.Join(spec.Path, defaultTempStoreRelativePath),
+		Path:         filepath.Join(spec.Path, defaultTempStoreRelativePath),
+		ExtraOptions: spec.ExtraOptions,
 	}
 }
 
@@ -517,6 +518,7 @@ func (cfg *Config) CreateEngines(ctx context.Context) (Engines, error) {
 				MaxOpenFiles:            openFileLimitPerStore,
 				WarnLargeBatchThreshold: 500 * time.Millisecond,
 				Settings:                cfg.Settings,
+				ExtraOptions:            spec.ExtraOptions,
 			}
 
 			eng, err := engine.NewRocksDB(rocksDBConfig, cache)
@@ -53,12 +53,181 @@ func (p *planner) AlterTable(ctx context.Context, n *parser.AlterTable) (planNod
 		return nil, sqlbase.NewUndefinedRelationError(tn)
 	}
 
-	if err := p.CheckPrivilege(tableDesc, privilege.CREATE); err != nil {
+	if err := p.CheckPrivilege(ctx, tableDesc, privilege.CREATE); err != nil {
 		return nil, err
 	}
 	return &alterTableNode{n: n, tableDesc: tableDesc}, nil
 }
 
+// checkColumnNotReferencedByComputedColumns returns an error if col is
+// referenced by a computed column of the table, including computed columns
+// being added.
+func checkColumnNotReferencedByComputedColumns(
+	tableDesc *sqlbase.TableDescriptor, col sqlbase.ColumnDescriptor,
+) error {
+	cols := append([]sqlbase.ColumnDescriptor(nil), tableDesc.Columns...)
+	for _, m := range tableDesc.Mutations {
+		if c := m.GetColumn(); c != nil && m.Direction == sqlbase.DescriptorMutation_ADD {
+			cols = append(cols, *c)
+		}
+	}
+	for i := range cols {
+		if !cols[i].IsComputed() {
+			continue
+		}
+		names, err := sqlbase.ComputedColumnReferences(&cols[i])
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if name == col.Name {
+				return fmt.Errorf("column %q is referenced by computed column %q",
+					col.Name, cols[i].Name)
+			}
+		}
+	}
+	return nil
+}
+
+// checkColumnNotBeingAltered returns an error if a column alteration of col is
+// in progress.
+func checkColumnNotBeingAltered(
+	tableDesc *sqlbase.TableDescriptor, col sqlbase.ColumnDescriptor,
+) error {
+	for _, m := range tableDesc.Mutations {
+		if a := m.GetAlteration(); a != nil && a.ColumnID == col.ID {
+			return fmt.Errorf("column %q in the middle of being altered, try again later", col.Name)
+		}
+	}
+	return nil
+}
+
+// findColumnToAlter finds the public column with the given name, which must
+// not be in the middle of another alteration.
+func findColumnToAlter(
+	tableDesc *sqlbase.TableDescriptor, name parser.Name,
+) (sqlbase.ColumnDescriptor, error) {
+	col, dropped, err := tableDesc.FindColumnByName(name)
+	if err != nil {
+		return sqlbase.ColumnDescriptor{}, err
+	}
+	if dropped {
+		return sqlbase.ColumnDescriptor{}, fmt.Errorf("column %q in the middle of being dropped", name)
+	}
+	if _, err := tableDesc.FindActiveColumnByName(string(name)); err != nil {
+		return sqlbase.ColumnDescriptor{}, fmt.Errorf("column %q in the middle of being added, try again later", name)
+	}
+	if err := checkColumnNotBeingAltered(tableDesc, col); err != nil {
+		return sqlbase.ColumnDescriptor{}, err
+	}
+	return col, nil
+}
+
+// addColumnTypeAlteration adds the mutations changing the type of col: a
+// hidden shadow column of the new type computed from the USING expression,
+// and a column alteration replacing col by the shadow column once it has been
+// backfilled.
+func (p *planner) addColumnTypeAlteration(
+	ctx context.Context,
+	tableDesc *sqlbase.TableDescriptor,
+	col sqlbase.ColumnDescriptor,
+	t *parser.AlterTableSetType,
+) error {
+	if col.IsComputed() {
+		return fmt.Errorf("cannot alter type of computed column %q", col.Name)
+	}
+	if err := checkColumnNotReferencedByComputedColumns(tableDesc, col); err != nil {
+		return err
+	}
+	for _, idx := range tableDesc.AllNonDropIndexes() {
+		if idx.ContainsColumnID(col.ID) {
+			if idx.ID == tableDesc.PrimaryIndex.ID {
+				return fmt.Errorf("column %q is referenced by the primary key", col.Name)
+			}
+			return fmt.Errorf("column %q is referenced by existing index %q", col.Name, idx.Name)
+		}
+	}
+	for _, ck := range tableDesc.Checks {
+		names, err := sqlbase.ColumnReferences(ck.Expr)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if name == col.Name {
+				return fmt.Errorf("column %q is referenced by check constraint %q", col.Name, ck.Name)
+			}
+		}
+	}
+	for _, ref := range tableDesc.DependedOnBy {
+		for _, colID := range ref.ColumnIDs {
+			if colID != col.ID {
+				continue
+			}
+			viewDesc, err := sqlbase.GetTableDescFromID(ctx, p.txn, ref.ID)
+			if err != nil {
+				return err
+			}
+			return sqlbase.NewDependentObjectError(fmt.Sprintf(
+				"cannot alter type of column %q because view %q depends on it", col.Name, viewDesc.Name))
+		}
+	}
+
+	// The existing values are converted by casting the USING expression, which
+	// defaults to the column itself, to the new type.
+	using := t.Using
+	if using == nil {
+		using = parser.UnresolvedName{parser.Name(col.Name)}
+	}
+	shadowName := col.Name + "_shadow"
+	for i := 1; ; i++ {
+		if _, _, err := tableDesc.FindColumnByName(parser.Name(shadowName)); err != nil {
+			break
+		}
+		shadowName = fmt.Sprintf("%s_shadow%d", col.Name, i)
+	}
+	d := &parser.ColumnTableDef{Name: parser.Name(shadowName), Type: t.ToType}
+	d.Nullable.Nullability = parser.Null
+	if !col.Nullable {
+		d.Nullable.Nullability = parser.NotNull
+	}
+	d.Computed.Computed = true
+	d.Computed.Expr = &parser.CastExpr{Expr: using, Type: t.ToType}
+	shadow, _, err := sqlbase.MakeColumnDefDescs(d, p.session.SearchPath, &p.evalCtx)
+	if err != nil {
+		return err
+	}
+	if t.Using == nil && shadow.Type.Equal(col.Type) {
+		// Nothing to do.
+		return nil
+	}
+	if err := sqlbase.ValidateComputedColumn(tableDesc, shadow, p.session.SearchPath); err != nil {
+		return err
+	}
+	// The shadow column takes over the DEFAULT expression of the column, which
+	// must thus be valid for the new type.
+	if col.DefaultExpr != nil {
+		expr, err := parser.ParseExpr(*col.DefaultExpr)
+		if err != nil {
+			return err
+		}
+		if _, err := sqlbase.SanitizeVarFreeExpr(
+			expr, shadow.Type.ToDatumType(), "DEFAULT", p.session.SearchPath,
+		); err != nil {
+			return errors.Wrapf(err, "cannot alter type of column %q", col.Name)
+		}
+	}
+	shadow.Hidden = true
+	shadow.ID = tableDesc.NextColumnID
+	tableDesc.NextColumnID++
+
+	tableDesc.AddColumnMutation(*shadow, sqlbase.DescriptorMutation_ADD)
+	tableDesc.AddColumnAlterationMutation(sqlbase.ColumnAlteration{
+		ColumnID:       col.ID,
+		ShadowColumnID: shadow.ID,
+	}, sqlbase.DescriptorMutation_ADD)
+	return nil
+}
+
 func (n *alterTableNode) Start(params runParams) error {
 	// Commands can either change the descriptor directly (for
 	// alterations that don't require a backfill) or add a mutation to
@@ -83,9 +252,18 @@ func (n *alterTableNode) Start(params runParams) error {
 			if err != nil {
 				return err
 			}
+			if col.IsComputed() {
+				// A computed column added by ALTER can only refer to the public
+				// columns of the table, whose values are known to the backfill.
+				if err := sqlbase.ValidateComputedColumn(
+					n.tableDesc, col, params.p.session.SearchPath,
+				); err != nil {
+					return err
+				}
+			}
 			// We're checking to see if a user is trying add a non-nullable column without a default to a
 			// non empty table by scanning the primary index span with a limit of 1 to see if any key exists.
-			if !col.Nullable && col.DefaultExpr == nil {
+			if !col.Nullable && col.DefaultExpr == nil && !col.IsComputed() {
 				kvs, err := params.p.txn.Scan(params.ctx, n.tableDesc.PrimaryIndexSpan().Key, n.tableDesc.PrimaryIndexSpan().EndKey, 1)
 				if err != nil {
 					return err
@@ -230,6 +408,12 @@ func (n *alterTableNode) Start(params runParams) error {
 			if n.tableDesc.PrimaryIndex.ContainsColumnID(col.ID) {
 				return fmt.Errorf("column %q is referenced by the primary key", col.Name)
 			}
+			if err := checkColumnNotReferencedByComputedColumns(n.tableDesc, col); err != nil {
+				return err
+			}
+			if err := checkColumnNotBeingAltered(n.tableDesc, col); err != nil {
+				return err
+			}
 			for _, idx := range n.tableDesc.AllNonDropIndexes() {
 				// We automatically drop indexes on that column that only
 				// index that column (and no other columns). If CASCADE is
@@ -411,6 +595,30 @@ func (n *alterTableNode) Start(params runParams) error {
 				return errors.Errorf("validating %s constraint %q unsupported", constraint.Kind, t.Constraint)
 			}
 
+		case *parser.AlterTableSetNotNull:
+			// The column becomes NOT NULL once the schema changer has validated
+			// that it doesn't contain any NULL values.
+			col, err := findColumnToAlter(n.tableDesc, t.Column)
+			if err != nil {
+				return err
+			}
+			if !col.Nullable {
+				continue
+			}
+			n.tableDesc.AddColumnAlterationMutation(sqlbase.ColumnAlteration{
+				ColumnID:   col.ID,
+				SetNotNull: true,
+			}, sqlbase.DescriptorMutation_ADD)
+
+		case *parser.AlterTableSetType:
+			col, err := findColumnToAlter(n.tableDesc, t.Column)
+			if err != nil {
+				return err
+			}
+			if err := params.p.addColumnTypeAlteration(params.ctx, n.tableDesc, col, t); err != nil {
+				return err
+			}
+
 		case parser.ColumnMutationCmd:
 			// Column mutations
 			col, dropped, err := n.tableDesc.FindColumnByName(t.GetColumn())
@@ -420,6 +628,9 @@ func (n *alterTableNode) Start(params runParams) error {
 			if dropped {
 				return fmt.Errorf("column %q in the middle of being dropped", t.GetColumn())
 			}
+			if err := checkColumnNotBeingAltered(n.tableDesc, col); err != nil {
+				return err
+			}
 			if err := applyColumnMutation(
 				&col, t, params.p.session.SearchPath,
 			); err != nil {
@@ -1396,6 +1396,12 @@ func simplifyComparisonExpr(
 	// <datum>" unless they could not be simplified further in which case
 	// simplifyExpr cannot handle them. For example, "lower(a) = 'foo'"
 	left, right := n.TypedLeft(), n.TypedRight()
+	if n.Operator == parser.ContainedBy && isDatum(left) && isVar(right) {
+		// Transform "<datum> <@ <var>" into "<var> @> <datum>", which can be used
+		// during index selection.
+		return simplifyComparisonExpr(
+			evalCtx, parser.NewTypedComparisonExpr(parser.Contains, right, left))
+	}
 	if isVar(left) && isDatum(right) {
 		if right == parser.DNull {
 			switch n.Operator {
@@ -1487,6 +1493,10 @@ func simplifyComparisonExpr(
 				return parser.MakeDBool(false), true
 			}
 			return n, true
+		case parser.Contains:
+			// "a @> x" can be used during index selection to restrict the range
+			// of scanned keys of an inverted index.
+			return n, true
 		case parser.In, parser.NotIn:
 			tuple := right.(*parser.DTuple).D
 			if len(tuple) == 0 {
@@ -17,20 +17,25 @@ package sql
 import (
 	"fmt"
 
+	"golang.org/x/net/context"
+
 	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 )
 
 // AuthorizationAccessor for checking authorization (e.g. desc privileges).
 type AuthorizationAccessor interface {
-	// CheckPrivilege verifies that the user has `privilege` on `descriptor`.
+	// CheckPrivilege verifies that the user has `privilege` on `descriptor`,
+	// either directly or through one of the roles it is a member of.
 	CheckPrivilege(
-		descriptor sqlbase.DescriptorProto, privilege privilege.Kind,
+		ctx context.Context, descriptor sqlbase.DescriptorProto, privilege privilege.Kind,
 	) error
 
-	// anyPrivilege verifies that the user has any privilege on `descriptor`.
-	anyPrivilege(descriptor sqlbase.DescriptorProto) error
+	// anyPrivilege verifies that the user has any privilege on `descriptor`,
+	// either directly or through one of the roles it is a member of.
+	anyPrivilege(ctx context.Context, descriptor sqlbase.DescriptorProto) error
 
 	// RequiresSuperUser errors if the session user isn't a super-user (i.e. root
 	// or node). Includes the named action in the error message.
@@ -52,18 +57,95 @@ func CheckPrivilege(
 
 // CheckPrivilege implements the AuthorizationAccessor interface.
 func (p *planner) CheckPrivilege(
-	descriptor sqlbase.DescriptorProto, privilege privilege.Kind,
+	ctx context.Context, descriptor sqlbase.DescriptorProto, privilege privilege.Kind,
 ) error {
-	return CheckPrivilege(p.session.User, descriptor, privilege)
+	user := p.session.User
+	privs := descriptor.GetPrivileges()
+	if privs.CheckPrivilege(user, privilege) {
+		return nil
+	}
+
+	memberOf, err := p.MemberOfWithAdminOption(ctx, user)
+	if err != nil {
+		return err
+	}
+	for role := range memberOf {
+		if privs.CheckPrivilege(role, privilege) {
+			return nil
+		}
+	}
+	return fmt.Errorf("user %s does not have %s privilege on %s %s",
+		user, privilege, descriptor.TypeName(), descriptor.GetName())
 }
 
 // anyPrivilege implements the AuthorizationAccessor interface.
-func (p *planner) anyPrivilege(descriptor sqlbase.DescriptorProto) error {
-	if userCanSeeDescriptor(descriptor, p.session.User) {
+func (p *planner) anyPrivilege(ctx context.Context, descriptor sqlbase.DescriptorProto) error {
+	user := p.session.User
+	if userCanSeeDescriptor(descriptor, user) {
 		return nil
 	}
+
+	memberOf, err := p.MemberOfWithAdminOption(ctx, user)
+	if err != nil {
+		return err
+	}
+	for role := range memberOf {
+		if userCanSeeDescriptor(descriptor, role) {
+			return nil
+		}
+	}
 	return fmt.Errorf("user %s has no privileges on %s %s",
-		p.session.User, descriptor.TypeName(), descriptor.GetName())
+		user, descriptor.TypeName(), descriptor.GetName())
+}
+
+// MemberOfWithAdminOption returns the roles the given member is a member of,
+// directly or indirectly, mapped to whether the member may administer the
+// role. The superusers are not looked up as they already hold all the
+// privileges a role could grant them. The result is cached on the planner and
+// must not be modified.
+func (p *planner) MemberOfWithAdminOption(
+	ctx context.Context, member string,
+) (map[string]bool, error) {
+	if member == security.RootUser || member == security.NodeUser {
+		return map[string]bool{}, nil
+	}
+	if ret, ok := p.memberOf[member]; ok {
+		return ret, nil
+	}
+
+	// The memberships are walked out from the member one role at a time, using
+	// the index on role_members.member. The table is read as root: the user
+	// whose privileges are being checked usually can't read it.
+	const lookupRoles = `SELECT role, "isAdmin" FROM system.role_members WHERE member = $1`
+	internalExecutor := InternalExecutor{LeaseManager: p.LeaseMgr()}
+	ret := map[string]bool{}
+	visited := map[string]struct{}{member: {}}
+	toVisit := []string{member}
+	for len(toVisit) > 0 {
+		m := toVisit[0]
+		toVisit = toVisit[1:]
+
+		rows, err := internalExecutor.QueryRowsInTransaction(
+			ctx, "expand-roles", p.txn, lookupRoles, m)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			role := string(parser.MustBeDString(row[0]))
+			isAdmin := bool(*row[1].(*parser.DBool))
+			ret[role] = ret[role] || isAdmin
+			if _, ok := visited[role]; !ok {
+				visited[role] = struct{}{}
+				toVisit = append(toVisit, role)
+			}
+		}
+	}
+
+	if p.memberOf == nil {
+		p.memberOf = make(map[string]map[string]bool)
+	}
+	p.memberOf[member] = ret
+	return ret, nil
 }
 
 // RequireSuperUser implements the AuthorizationAccessor interface.
@@ -15,6 +15,7 @@
 package sql
 
 import (
+	"fmt"
 	"sort"
 	"time"
 
@@ -111,6 +112,7 @@ func (sc *SchemaChanger) runBackfill(
 	// mutations. Collect the elements that are part of the mutation.
 	var droppedIndexDescs []sqlbase.IndexDescriptor
 	var addedIndexDescs []sqlbase.IndexDescriptor
+	var notNullColumnIDs []sqlbase.ColumnID
 	// Indexes within the Mutations slice for checkpointing.
 	mutationSentinel := -1
 	var droppedIndexMutationIdx int
@@ -142,11 +144,17 @@ func (sc *SchemaChanger) runBackfill(
 			switch t := m.Descriptor_.(type) {
 			case *sqlbase.DescriptorMutation_Column:
 				desc := m.GetColumn()
-				if desc.DefaultExpr != nil || !desc.Nullable {
+				if desc.DefaultExpr != nil || !desc.Nullable || desc.IsComputed() {
 					needColumnBackfill = true
 				}
 			case *sqlbase.DescriptorMutation_Index:
 				addedIndexDescs = append(addedIndexDescs, *t.Index)
+			case *sqlbase.DescriptorMutation_Alteration:
+				// The values of a shadow column are backfilled by its own
+				// column mutation.
+				if t.Alteration.SetNotNull {
+					notNullColumnIDs = append(notNullColumnIDs, t.Alteration.ColumnID)
+				}
 			default:
 				return errors.Errorf("unsupported mutation: %+v", m)
 			}
@@ -160,6 +168,8 @@ func (sc *SchemaChanger) runBackfill(
 				if droppedIndexMutationIdx == mutationSentinel {
 					droppedIndexMutationIdx = i
 				}
+			case *sqlbase.DescriptorMutation_Alteration:
+				// A column alteration being dropped leaves its column untouched.
 			default:
 				return errors.Errorf("unsupported mutation: %+v", m)
 			}
@@ -189,9 +199,51 @@ func (sc *SchemaChanger) runBackfill(
 		}
 	}
 
+	// Validate the columns being made NOT NULL.
+	if len(notNullColumnIDs) > 0 {
+		if err := sc.validateNotNull(ctx, version, notNullColumnIDs); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// validateNotNull checks that none of the columns with the given IDs contain
+// NULL values. Writers do not write NULL values to these columns once their
+// column alteration has been published, so the check is run only once.
+func (sc *SchemaChanger) validateNotNull(
+	ctx context.Context, version sqlbase.DescriptorVersion, colIDs []sqlbase.ColumnID,
+) error {
+	return sc.db.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		tableDesc, err := sqlbase.GetTableDescFromID(ctx, txn, sc.tableID)
+		if err != nil {
+			return err
+		}
+		if tableDesc.Version != version {
+			return errors.Errorf("table version mismatch: %d, expected: %d", tableDesc.Version, version)
+		}
+		ie := InternalExecutor{LeaseManager: sc.leaseMgr}
+		for _, id := range colIDs {
+			col, err := tableDesc.FindColumnByID(id)
+			if err != nil {
+				return err
+			}
+			row, err := ie.QueryRowInTransaction(ctx, "validate-not-null", txn,
+				fmt.Sprintf(`SELECT 1 FROM [%d AS t] WHERE %s IS NULL LIMIT 1`,
+					sc.tableID, parser.AsString(parser.Name(col.Name))),
+			)
+			if err != nil {
+				return err
+			}
+			if row != nil {
+				return sqlbase.NewNonNullViolationError(col.Name)
+			}
+		}
+		return nil
+	})
+}
+
 func (sc *SchemaChanger) maybeWriteResumeSpan(
 	ctx context.Context,
 	txn *client.Txn,
@@ -532,13 +584,20 @@ func (sc *SchemaChanger) distBackfill(
 			// backfiller processor.
 			var otherTableDescs []sqlbase.TableDescriptor
 			if backfillType == columnBackfill {
-				fkTables := sqlbase.TablesNeededForFKs(*tableDesc, sqlbase.CheckUpdates)
-				for k := range fkTables {
-					table, err := tc.getTableVersionByID(ctx, txn, k)
-					if err != nil {
-						return err
-					}
-					otherTableDescs = append(otherTableDescs, *table)
+				fkTables, err := sqlbase.TablesNeededForFKs(
+					ctx, *tableDesc, sqlbase.CheckUpdates,
+					func(ctx context.Context, id sqlbase.ID) (sqlbase.TableLookup, error) {
+						table, err := tc.getTableVersionByID(ctx, txn, id)
+						if err != nil {
+							return sqlbase.TableLookup{}, err
+						}
+						return sqlbase.TableLookup{Table: table}, nil
+					})
+				if err != nil {
+					return err
+				}
+				for _, table := range fkTables {
+					otherTableDescs = append(otherTableDescs, *table.Table)
 				}
 			}
 			// TODO(andrei): pass the right caches. I think this will crash without
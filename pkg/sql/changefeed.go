@@ -0,0 +1,632 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/jobs"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+const (
+	// changefeedEventBufferSize is the number of rangefeed events buffered
+	// between the rangefeeds of a changefeed and its emitter.
+	changefeedEventBufferSize = 1024
+	// changefeedHighWaterInterval is the minimum interval between two updates
+	// of the high-water mark of a changefeed. Every update flushes the sink.
+	changefeedHighWaterInterval = time.Second
+)
+
+var errChangefeedsDisabled = errors.New("changefeeds require the kv.rangefeed.enabled setting")
+
+func init() {
+	jobs.AddResumeHook(changefeedResumeHook)
+}
+
+type createChangefeedNode struct {
+	optColumnsSlot
+
+	n       *parser.CreateChangefeed
+	sinkURI func() (string, error)
+	tables  []*sqlbase.TableDescriptor
+
+	jobID int64
+	done  bool
+}
+
+var createChangefeedColumns = sqlbase.ResultColumns{
+	{Name: "job_id", Typ: parser.TypeInt},
+}
+
+// CreateChangefeed creates a changefeed job which emits the changes to the
+// given tables to a sink.
+// Privileges: SELECT on the tables.
+func (p *planner) CreateChangefeed(
+	ctx context.Context, n *parser.CreateChangefeed,
+) (planNode, error) {
+	if n.Targets.Databases != nil {
+		return nil, errors.New("changefeeds can only watch tables")
+	}
+	sinkURI, err := p.TypeAsString(n.SinkURI, "CREATE CHANGEFEED")
+	if err != nil {
+		return nil, err
+	}
+	descs, err := getDescriptorsFromTargetList(
+		ctx, p.txn, p.getVirtualTabler(), p.session.Database, n.Targets)
+	if err != nil {
+		return nil, err
+	}
+	tables := make([]*sqlbase.TableDescriptor, 0, len(descs))
+	for _, desc := range descs {
+		tableDesc, ok := desc.(*sqlbase.TableDescriptor)
+		if !ok || tableDesc.IsView() || tableDesc.IsSequence() || tableDesc.IsVirtualTable() {
+			return nil, errors.Errorf("cannot create a changefeed on %q, which is not a table",
+				desc.GetName())
+		}
+		if err := p.CheckPrivilege(ctx, tableDesc, privilege.SELECT); err != nil {
+			return nil, err
+		}
+		tables = append(tables, tableDesc)
+	}
+	return &createChangefeedNode{n: n, sinkURI: sinkURI, tables: tables}, nil
+}
+
+func (n *createChangefeedNode) Start(params runParams) error {
+	p := params.p
+	execCfg := p.ExecCfg()
+	if !storagebase.RangefeedEnabled.Get(&execCfg.Settings.SV) {
+		return errChangefeedsDisabled
+	}
+	sinkURI, err := n.sinkURI()
+	if err != nil {
+		return err
+	}
+	// Reject unusable sinks before creating the job.
+	sink, err := makeChangefeedSink(sinkURI)
+	if err != nil {
+		return err
+	}
+	if err := sink.Close(); err != nil {
+		return err
+	}
+
+	descIDs := make(sqlbase.IDs, len(n.tables))
+	for i, tableDesc := range n.tables {
+		descIDs[i] = tableDesc.ID
+	}
+	job := execCfg.JobRegistry.NewJob(jobs.Record{
+		Description:   n.n.String(),
+		Username:      p.User(),
+		DescriptorIDs: descIDs,
+		Details: jobs.ChangefeedDetails{
+			SinkURI:   sinkURI,
+			HighWater: p.txn.OrigTimestamp(),
+		},
+	})
+
+	// The job is created in the statement's transaction, and the changefeed
+	// only runs once that transaction commits. The changefeed outlives the
+	// statement.
+	ctx, cancel := context.WithCancel(execCfg.AmbientCtx.AnnotateCtx(context.Background()))
+	if err := job.WithTxn(p.txn).Created(params.ctx, cancel); err != nil {
+		cancel()
+		return err
+	}
+	if err := job.WithTxn(p.txn).Started(params.ctx); err != nil {
+		cancel()
+		return err
+	}
+	n.jobID = *job.ID()
+	p.txn.AddCommitTrigger(func() {
+		startChangefeed(ctx, cancel, execCfg, job)
+	})
+	return nil
+}
+
+// startChangefeed runs a changefeed job created by this node in an async task.
+// The job finishes when the changefeed stops, unless the server is shutting
+// down, in which case another node adopts it.
+func startChangefeed(
+	ctx context.Context, cancel context.CancelFunc, execCfg *ExecutorConfig, job *jobs.Job,
+) {
+	stopper := execCfg.RPCContext.Stopper
+	ctx = stopper.WithCancel(ctx)
+	if err := stopper.RunAsyncTask(ctx, "changefeed", func(ctx context.Context) {
+		defer cancel()
+		err := runChangefeed(ctx, execCfg, job)
+		select {
+		case <-stopper.ShouldQuiesce():
+			// Leave the job running so that another node adopts it once its
+			// lease expires.
+			return
+		default:
+		}
+		if err := job.FinishedWith(ctx, err); err != nil {
+			log.Errorf(ctx, "changefeed job %d: %s", *job.ID(), err)
+		}
+	}); err != nil {
+		cancel()
+		log.Warningf(ctx, "changefeed job %d not started: %s", *job.ID(), err)
+	}
+}
+
+func (n *createChangefeedNode) Next(runParams) (bool, error) {
+	if n.done {
+		return false, nil
+	}
+	n.done = true
+	return true, nil
+}
+
+func (n *createChangefeedNode) Values() parser.Datums {
+	return parser.Datums{parser.NewDInt(parser.DInt(n.jobID))}
+}
+
+func (*createChangefeedNode) Close(context.Context) {}
+
+// changefeedResumeHook resumes changefeed jobs adopted by the job registry.
+func changefeedResumeHook(typ jobs.Type) func(context.Context, *jobs.Job) error {
+	if typ != jobs.TypeChangefeed {
+		return nil
+	}
+	return func(ctx context.Context, job *jobs.Job) error {
+		execCfg, ok := job.ExecutorConfig().(*ExecutorConfig)
+		if !ok {
+			return errors.New("changefeeds can't be resumed without a SQL executor")
+		}
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		if err := job.Created(ctx, cancel); err != nil {
+			return err
+		}
+		return runChangefeed(ctx, execCfg, job)
+	}
+}
+
+// runChangefeed emits the changes to the tables watched by a changefeed job to
+// its sink until the context is canceled or an error occurs. It watches the
+// tables from the job's high-water mark on, which it advances whenever all the
+// changes up to a new timestamp have been emitted and flushed. Changes are
+// delivered at least once: when a changefeed is resumed, the changes above the
+// high-water mark are emitted again.
+//
+// Every change is emitted as a JSON message holding the table name, the
+// primary key, the values of the row after the change or null if it was
+// deleted, and the timestamp of the change. When the high-water mark advances,
+// a message holding the new resolved timestamp is emitted; no change at or
+// below it will be emitted afterwards.
+//
+// The schemas of the tables are read at the high-water mark. Schema changes
+// which happen afterwards are not picked up.
+func runChangefeed(ctx context.Context, execCfg *ExecutorConfig, job *jobs.Job) error {
+	if !storagebase.RangefeedEnabled.Get(&execCfg.Settings.SV) {
+		return errChangefeedsDisabled
+	}
+	details, ok := job.Record.Details.(jobs.ChangefeedDetails)
+	if !ok {
+		return errors.Errorf("unexpected job details %T", job.Record.Details)
+	}
+	tables, err := loadChangefeedTables(ctx, execCfg.DB, job.Payload().DescriptorIDs, details.HighWater)
+	if err != nil {
+		return err
+	}
+	sink, err := makeChangefeedSink(details.SinkURI)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := sink.Close(); err != nil {
+			log.Warningf(ctx, "closing changefeed sink: %s", err)
+		}
+	}()
+
+	e := makeChangefeedEmitter(execCfg.DB, tables, sink)
+	spans := make([]roachpb.Span, len(e.tables))
+	for i := range e.tables {
+		spans[i] = e.tables[i].span
+	}
+	frontier := makeSpanFrontier(details.HighWater, spans...)
+	highWater := details.HighWater
+	var lastUpdate time.Time
+
+	eventCh := make(chan *roachpb.RangeFeedEvent, changefeedEventBufferSize)
+	g, gCtx := errgroup.WithContext(ctx)
+	for _, span := range spans {
+		span := span
+		g.Go(func() error {
+			return execCfg.DistSender.RangeFeed(gCtx, span, details.HighWater, eventCh)
+		})
+	}
+	g.Go(func() error {
+		for {
+			var event *roachpb.RangeFeedEvent
+			select {
+			case event = <-eventCh:
+			case <-gCtx.Done():
+				return gCtx.Err()
+			}
+			switch t := event.GetValue().(type) {
+			case *roachpb.RangeFeedValue:
+				if err := e.addChange(gCtx, t.Key, t.Value.Timestamp); err != nil {
+					return err
+				}
+			case *roachpb.RangeFeedCheckpoint:
+				frontier.Forward(t.Span, t.ResolvedTS)
+				resolved := frontier.Frontier()
+				if !highWater.Less(resolved) || timeutil.Since(lastUpdate) < changefeedHighWaterInterval {
+					continue
+				}
+				// Every change at or below the resolved timestamp has been
+				// received. Make sure they've been delivered before promising
+				// as much and moving the high-water mark.
+				if err := e.flush(gCtx); err != nil {
+					return err
+				}
+				if err := e.emitResolved(gCtx, resolved); err != nil {
+					return err
+				}
+				if err := sink.Flush(gCtx); err != nil {
+					return err
+				}
+				if err := job.Progressed(gCtx, 0, func(_ context.Context, details interface{}) {
+					details.(*jobs.Payload_Changefeed).Changefeed.HighWater = resolved
+				}); err != nil {
+					return err
+				}
+				highWater, lastUpdate = resolved, timeutil.Now()
+			}
+		}
+	})
+	return g.Wait()
+}
+
+// loadChangefeedTables reads the descriptors of the tables watched by a
+// changefeed as of the given timestamp.
+func loadChangefeedTables(
+	ctx context.Context, db *client.DB, descIDs sqlbase.IDs, ts hlc.Timestamp,
+) ([]*sqlbase.TableDescriptor, error) {
+	var tables []*sqlbase.TableDescriptor
+	err := db.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		tables = tables[:0]
+		txn.SetFixedTimestamp(ctx, ts)
+		for _, id := range descIDs {
+			desc := &sqlbase.Descriptor{}
+			if err := txn.GetProto(ctx, sqlbase.MakeDescMetadataKey(id), desc); err != nil {
+				return err
+			}
+			tableDesc := desc.GetTable()
+			if tableDesc == nil {
+				return errors.Errorf("descriptor %d is not a table", id)
+			}
+			tables = append(tables, tableDesc)
+		}
+		return nil
+	})
+	return tables, err
+}
+
+// changefeedTable is a table watched by a changefeed.
+type changefeedTable struct {
+	desc      *sqlbase.TableDescriptor
+	span      roachpb.Span
+	colIdxMap map[sqlbase.ColumnID]int
+}
+
+// changefeedEmitter turns the values published by rangefeeds into messages
+// describing row changes and emits them to a sink.
+//
+// The changes are emitted in batches of changes made at the same timestamp,
+// whose rows are read in a single transaction at that timestamp.
+type changefeedEmitter struct {
+	db     *client.DB
+	tables []changefeedTable
+	sink   changefeedSink
+	alloc  sqlbase.DatumAlloc
+
+	// batch contains the rows changed at batchTS which haven't been emitted
+	// yet. A change to a row with several column families produces a value for
+	// every family, but only one message; batchKeys contains the keys of the
+	// rows in the batch.
+	batch     []changefeedRow
+	batchTS   hlc.Timestamp
+	batchKeys map[string]struct{}
+}
+
+// changefeedRow is a row changed in a batch of a changefeedEmitter.
+type changefeedRow struct {
+	table  *changefeedTable
+	rowKey roachpb.Key
+}
+
+func makeChangefeedEmitter(
+	db *client.DB, tableDescs []*sqlbase.TableDescriptor, sink changefeedSink,
+) *changefeedEmitter {
+	e := &changefeedEmitter{db: db, sink: sink, batchKeys: make(map[string]struct{})}
+	for _, tableDesc := range tableDescs {
+		colIdxMap := make(map[sqlbase.ColumnID]int, len(tableDesc.Columns))
+		for i, col := range tableDesc.Columns {
+			colIdxMap[col.ID] = i
+		}
+		e.tables = append(e.tables, changefeedTable{
+			desc:      tableDesc,
+			span:      tableDesc.PrimaryIndexSpan(),
+			colIdxMap: colIdxMap,
+		})
+	}
+	return e
+}
+
+// addChange adds the change to the row containing the given key at the given
+// timestamp to the batch of changes to emit. The batch is emitted first if
+// its changes were made at another timestamp.
+func (e *changefeedEmitter) addChange(
+	ctx context.Context, key roachpb.Key, ts hlc.Timestamp,
+) error {
+	var table *changefeedTable
+	for i := range e.tables {
+		if e.tables[i].span.Contains(roachpb.Span{Key: key}) {
+			table = &e.tables[i]
+			break
+		}
+	}
+	if table == nil {
+		return nil
+	}
+	rowKey, err := keys.EnsureSafeSplitKey(key)
+	if err != nil {
+		return err
+	}
+	if len(e.batch) > 0 && ts != e.batchTS {
+		if err := e.flush(ctx); err != nil {
+			return err
+		}
+	}
+	e.batchTS = ts
+	if _, ok := e.batchKeys[string(rowKey)]; ok {
+		return nil
+	}
+	e.batchKeys[string(rowKey)] = struct{}{}
+	e.batch = append(e.batch, changefeedRow{table: table, rowKey: rowKey})
+	return nil
+}
+
+// flush emits a message for every change in the batch and empties it.
+func (e *changefeedEmitter) flush(ctx context.Context) error {
+	if len(e.batch) == 0 {
+		return nil
+	}
+	rows, err := e.fetchRows(ctx, e.batch, e.batchTS)
+	if err != nil {
+		return err
+	}
+	// The primary keys of deleted rows are read from their last version.
+	var deleted []changefeedRow
+	for i, row := range rows {
+		if row == nil {
+			deleted = append(deleted, e.batch[i])
+		}
+	}
+	var prevRows []parser.Datums
+	if len(deleted) > 0 {
+		if prevRows, err = e.fetchRows(ctx, deleted, e.batchTS.Prev()); err != nil {
+			return err
+		}
+	}
+	for i, row := range rows {
+		isDeleted := row == nil
+		if isDeleted {
+			row, prevRows = prevRows[0], prevRows[1:]
+			if row == nil {
+				continue
+			}
+		}
+		if err := e.emitRow(ctx, e.batch[i].table, row, isDeleted, e.batchTS); err != nil {
+			return err
+		}
+	}
+	e.batch = e.batch[:0]
+	for k := range e.batchKeys {
+		delete(e.batchKeys, k)
+	}
+	return nil
+}
+
+// emitRow emits a message for the change to the given row at the given
+// timestamp. The row holds the values after the change or, if it was
+// deleted, the values before the change.
+func (e *changefeedEmitter) emitRow(
+	ctx context.Context, table *changefeedTable, row parser.Datums, deleted bool, ts hlc.Timestamp,
+) error {
+	var err error
+	keyElems := make([]json.JSON, len(table.desc.PrimaryIndex.ColumnIDs))
+	for i, colID := range table.desc.PrimaryIndex.ColumnIDs {
+		if keyElems[i], err = parser.AsJSON(row[table.colIdxMap[colID]]); err != nil {
+			return err
+		}
+	}
+	value := json.NullJSONValue
+	if !deleted {
+		b := json.NewObjectBuilder(len(row))
+		for i := range table.desc.Columns {
+			j, err := parser.AsJSON(row[i])
+			if err != nil {
+				return err
+			}
+			b.Add(table.desc.Columns[i].Name, j)
+		}
+		value = b.Build()
+	}
+
+	b := json.NewObjectBuilder(4)
+	b.Add("table", json.FromString(table.desc.Name))
+	b.Add("key", json.FromArray(keyElems))
+	b.Add("value", value)
+	b.Add("updated", json.FromString(changefeedTimestamp(ts)))
+	return e.emit(ctx, b.Build())
+}
+
+// emitResolved emits a message promising that no change at or below the
+// given timestamp will be emitted anymore.
+func (e *changefeedEmitter) emitResolved(ctx context.Context, ts hlc.Timestamp) error {
+	b := json.NewObjectBuilder(1)
+	b.Add("resolved", json.FromString(changefeedTimestamp(ts)))
+	return e.emit(ctx, b.Build())
+}
+
+func (e *changefeedEmitter) emit(ctx context.Context, msg json.JSON) error {
+	var buf bytes.Buffer
+	msg.Format(&buf)
+	return e.sink.EmitMessage(ctx, buf.Bytes())
+}
+
+// fetchRows reads the given rows as of the given timestamp, in a single
+// transaction. The rows which don't exist are returned as nil.
+func (e *changefeedEmitter) fetchRows(
+	ctx context.Context, changed []changefeedRow, ts hlc.Timestamp,
+) ([]parser.Datums, error) {
+	rows := make([]parser.Datums, len(changed))
+	err := e.db.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		txn.SetFixedTimestamp(ctx, ts)
+		for i, c := range changed {
+			valNeededForCol := make([]bool, len(c.table.desc.Columns))
+			for j := range valNeededForCol {
+				valNeededForCol[j] = true
+			}
+			var rf sqlbase.RowFetcher
+			if err := rf.Init(
+				c.table.desc, c.table.colIdxMap, &c.table.desc.PrimaryIndex,
+				false /* reverse */, false, /* isSecondaryIndex */
+				c.table.desc.Columns, valNeededForCol, false /* returnRangeInfo */, &e.alloc,
+			); err != nil {
+				return err
+			}
+			span := roachpb.Span{Key: c.rowKey, EndKey: c.rowKey.PrefixEnd()}
+			if err := rf.StartScan(
+				ctx, txn, roachpb.Spans{span}, false /* limitBatches */, 0 /* limitHint */, false, /* traceKV */
+			); err != nil {
+				return err
+			}
+			var err error
+			if rows[i], err = rf.NextRowDecoded(ctx, false /* traceKV */); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return rows, err
+}
+
+// changefeedTimestamp formats a timestamp the way AS OF SYSTEM TIME accepts
+// it, so that the messages of a changefeed can be correlated with historical
+// queries.
+func changefeedTimestamp(ts hlc.Timestamp) string {
+	return fmt.Sprintf("%d.%010d", ts.WallTime, ts.Logical)
+}
+
+// spanFrontier tracks the resolved timestamps of a set of spans. The frontier
+// is the lowest resolved timestamp of any part of the spans.
+type spanFrontier struct {
+	// entries are sorted by key and don't overlap.
+	entries []spanFrontierEntry
+}
+
+type spanFrontierEntry struct {
+	span roachpb.Span
+	ts   hlc.Timestamp
+}
+
+// makeSpanFrontier returns a frontier for the given disjoint spans, all of
+// which are resolved at the given timestamp.
+func makeSpanFrontier(ts hlc.Timestamp, spans ...roachpb.Span) *spanFrontier {
+	f := &spanFrontier{}
+	for _, span := range spans {
+		f.entries = append(f.entries, spanFrontierEntry{span: span, ts: ts})
+	}
+	sort.Slice(f.entries, func(i, j int) bool {
+		return f.entries[i].span.Key.Compare(f.entries[j].span.Key) < 0
+	})
+	return f
+}
+
+// Frontier returns the lowest resolved timestamp of the tracked spans.
+func (f *spanFrontier) Frontier() hlc.Timestamp {
+	var frontier hlc.Timestamp
+	for i, e := range f.entries {
+		if i == 0 || e.ts.Less(frontier) {
+			frontier = e.ts
+		}
+	}
+	return frontier
+}
+
+// Forward forwards the resolved timestamp of the parts of the tracked spans
+// which overlap the given span.
+func (f *spanFrontier) Forward(span roachpb.Span, ts hlc.Timestamp) {
+	entries := make([]spanFrontierEntry, 0, len(f.entries)+2)
+	for _, e := range f.entries {
+		if !e.span.Overlaps(span) || !e.ts.Less(ts) {
+			entries = append(entries, e)
+			continue
+		}
+		// Split the entry into the parts before, inside and after the span.
+		if e.span.Key.Compare(span.Key) < 0 {
+			entries = append(entries, spanFrontierEntry{
+				span: roachpb.Span{Key: e.span.Key, EndKey: span.Key}, ts: e.ts,
+			})
+			e.span.Key = span.Key
+		}
+		var after *spanFrontierEntry
+		if span.EndKey.Compare(e.span.EndKey) < 0 {
+			after = &spanFrontierEntry{
+				span: roachpb.Span{Key: span.EndKey, EndKey: e.span.EndKey}, ts: e.ts,
+			}
+			e.span.EndKey = span.EndKey
+		}
+		e.ts = ts
+		entries = append(entries, e)
+		if after != nil {
+			entries = append(entries, *after)
+		}
+	}
+	// Merge adjacent entries with the same timestamp to keep the number of
+	// entries proportional to the number of distinct timestamps.
+	f.entries = entries[:0]
+	for _, e := range entries {
+		if n := len(f.entries); n > 0 && f.entries[n-1].ts == e.ts &&
+			f.entries[n-1].span.EndKey.Equal(e.span.Key) {
+			f.entries[n-1].span.EndKey = e.span.EndKey
+			continue
+		}
+		f.entries = append(f.entries, e)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// changefeedSink is the destination of the messages emitted by a changefeed.
+// Messages are only guaranteed to have been delivered once Flush returns.
+type changefeedSink interface {
+	// EmitMessage adds a message to the sink.
+	EmitMessage(ctx context.Context, msg []byte) error
+	// Flush delivers all messages emitted so far.
+	Flush(ctx context.Context) error
+	// Close releases the resources of the sink.
+	Close() error
+}
+
+// makeChangefeedSink returns the sink for the given URI. The supported schemes
+// are file, which appends newline-delimited messages to a file on the node
+// running the changefeed, and http(s), which POSTs batches of
+// newline-delimited messages to the URI.
+func makeChangefeedSink(sinkURI string) (changefeedSink, error) {
+	u, err := url.Parse(sinkURI)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "file":
+		if u.Host != "" {
+			return nil, errors.Errorf("file sink %q must not have a host", sinkURI)
+		}
+		if u.Path == "" {
+			return nil, errors.Errorf("file sink %q must have a path", sinkURI)
+		}
+		f, err := os.OpenFile(u.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, err
+		}
+		return &fileChangefeedSink{f: f, w: bufio.NewWriter(f)}, nil
+	case "http", "https":
+		return &httpChangefeedSink{uri: sinkURI, client: &http.Client{}}, nil
+	default:
+		return nil, errors.Errorf("unsupported changefeed sink scheme %q", u.Scheme)
+	}
+}
+
+// fileChangefeedSink appends messages to a local file.
+type fileChangefeedSink struct {
+	f *os.File
+	w *bufio.Writer
+}
+
+var _ changefeedSink = &fileChangefeedSink{}
+
+// EmitMessage implements the changefeedSink interface.
+func (s *fileChangefeedSink) EmitMessage(_ context.Context, msg []byte) error {
+	if _, err := s.w.Write(msg); err != nil {
+		return err
+	}
+	return s.w.WriteByte('\n')
+}
+
+// Flush implements the changefeedSink interface.
+func (s *fileChangefeedSink) Flush(context.Context) error {
+	if err := s.w.Flush(); err != nil {
+		return err
+	}
+	return s.f.Sync()
+}
+
+// Close implements the changefeedSink interface.
+func (s *fileChangefeedSink) Close() error {
+	return s.f.Close()
+}
+
+// httpChangefeedSink POSTs the messages emitted since the last flush to a URI.
+type httpChangefeedSink struct {
+	uri    string
+	client *http.Client
+	buf    bytes.Buffer
+}
+
+var _ changefeedSink = &httpChangefeedSink{}
+
+// EmitMessage implements the changefeedSink interface.
+func (s *httpChangefeedSink) EmitMessage(_ context.Context, msg []byte) error {
+	s.buf.Write(msg)
+	s.buf.WriteByte('\n')
+	return nil
+}
+
+// Flush implements the changefeedSink interface.
+func (s *httpChangefeedSink) Flush(ctx context.Context) error {
+	if s.buf.Len() == 0 {
+		return nil
+	}
+	req, err := http.NewRequest("POST", s.uri, bytes.NewReader(s.buf.Bytes()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	resp, err := s.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return errors.Errorf("changefeed sink %s returned %s: %s", s.uri, resp.Status, body)
+	}
+	s.buf.Reset()
+	return nil
+}
+
+// Close implements the changefeedSink interface.
+func (s *httpChangefeedSink) Close() error {
+	return nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+// TestChangefeedJobCreatedInTxn verifies that the job of a changefeed is
+// created in the transaction of the CREATE CHANGEFEED statement, and that the
+// changefeed only runs once that transaction commits.
+func TestChangefeedJobCreatedInTxn(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+	sqlDB := sqlutils.MakeSQLRunner(t, db)
+	dir, cleanup := testutils.TempDir(t)
+	defer cleanup()
+	path := filepath.Join(dir, "changes")
+	sinkURI := "file://" + path
+
+	sqlDB.Exec(`SET CLUSTER SETTING kv.rangefeed.enabled = true`)
+	sqlDB.Exec(`CREATE DATABASE d; CREATE TABLE d.t (a INT PRIMARY KEY, b STRING)`)
+
+	countJobs := func() int {
+		var count int
+		sqlDB.QueryRow(`SELECT COUNT(*) FROM crdb_internal.jobs WHERE type = 'CHANGEFEED'`).Scan(&count)
+		return count
+	}
+
+	// Rolling back the transaction leaves no job behind.
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec(`CREATE CHANGEFEED FOR TABLE d.t INTO $1`, sinkURI); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if count := countJobs(); count != 0 {
+		t.Fatalf("expected no changefeed job, found %d", count)
+	}
+
+	var jobID int64
+	sqlDB.QueryRow(`CREATE CHANGEFEED FOR TABLE d.t INTO $1`, sinkURI).Scan(&jobID)
+	if count := countJobs(); count != 1 {
+		t.Fatalf("expected one changefeed job, found %d", count)
+	}
+	// The rows written by one transaction are emitted together.
+	sqlDB.Exec(`INSERT INTO d.t VALUES (1, 'x'), (2, 'y')`)
+	testutils.SucceedsSoon(t, func() error {
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, value := range []string{`{"a": 1, "b": "x"}`, `{"a": 2, "b": "y"}`} {
+			if !strings.Contains(string(contents), value) {
+				return errors.Errorf("change to %s not emitted yet: %s", value, contents)
+			}
+		}
+		return nil
+	})
+}
+
+func TestSpanFrontier(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	span := func(start, end string) roachpb.Span {
+		return roachpb.Span{Key: roachpb.Key(start), EndKey: roachpb.Key(end)}
+	}
+	ts := func(wallTime int64) hlc.Timestamp { return hlc.Timestamp{WallTime: wallTime} }
+
+	f := makeSpanFrontier(ts(1), span("m", "z"), span("a", "f"))
+	testCases := []struct {
+		span     roachpb.Span
+		ts       hlc.Timestamp
+		frontier hlc.Timestamp
+		entries  int
+	}{
+		// Forwarding part of a span splits its entry.
+		{span("b", "c"), ts(3), ts(1), 4},
+		// Forwarding to an older timestamp is a no-op.
+		{span("a", "z"), ts(2), ts(2), 4},
+		{span("a", "f"), ts(3), ts(2), 2},
+		// Spans which don't overlap the tracked spans are ignored.
+		{span("f", "m"), ts(5), ts(2), 2},
+		{span("l", "zz"), ts(4), ts(3), 2},
+		{span("a", "zz"), ts(4), ts(4), 2},
+	}
+	for i, tc := range testCases {
+		f.Forward(tc.span, tc.ts)
+		if frontier := f.Frontier(); frontier != tc.frontier {
+			t.Errorf("%d: expected frontier %s, got %s", i, tc.frontier, frontier)
+		}
+		if len(f.entries) != tc.entries {
+			t.Errorf("%d: expected %d entries, got %+v", i, tc.entries, f.entries)
+		}
+	}
+}
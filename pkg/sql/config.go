@@ -34,12 +34,18 @@ func getZoneConfig(
 	id uint32, get func(roachpb.Key) (*roachpb.Value, error),
 ) (config.ZoneConfig, bool, error) {
 	// Look in the zones table.
+	var placeholder *config.ZoneConfig
 	if zoneVal, err := get(sqlbase.MakeZoneKey(sqlbase.ID(id))); err != nil {
 		return config.ZoneConfig{}, false, err
 	} else if zoneVal != nil {
-		// We're done.
 		zone, err := config.MigrateZoneConfig(zoneVal)
-		return zone, true, err
+		if err != nil || !zone.IsSubzonePlaceholder() {
+			// We're done.
+			return zone, true, err
+		}
+		// The zone config only stores the subzones of a table. The rest of it
+		// is inherited from the table's database below.
+		placeholder = &zone
 	}
 
 	// No zone config for this ID. We need to figure out if it's a database
@@ -54,7 +60,12 @@ func getZoneConfig(
 		}
 		if tableDesc := desc.GetTable(); tableDesc != nil {
 			// This is a table descriptor. Lookup its parent database zone config.
-			return getZoneConfig(uint32(tableDesc.ParentID), get)
+			zone, found, err := getZoneConfig(uint32(tableDesc.ParentID), get)
+			if err == nil && found && placeholder != nil {
+				zone.Subzones = placeholder.Subzones
+				zone.SubzoneSpans = placeholder.SubzoneSpans
+			}
+			return zone, found, err
 		}
 	}
 
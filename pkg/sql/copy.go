@@ -186,6 +186,7 @@ func (n *copyNode) addRow(ctx context.Context, line []byte) error {
 			parser.TypeDate,
 			parser.TypeInterval,
 			parser.TypeINet,
+			parser.TypeJSON,
 			parser.TypeString,
 			parser.TypeTimestamp,
 			parser.TypeTimestampTZ,
@@ -273,6 +273,12 @@ CREATE TABLE crdb_internal.schema_changes (
 					mutType = "INDEX"
 					targetID = parser.NewDInt(parser.DInt(int64(d.Index.ID)))
 					targetName = parser.NewDString(d.Index.Name)
+				case *sqlbase.DescriptorMutation_Alteration:
+					mutType = "ALTERATION"
+					targetID = parser.NewDInt(parser.DInt(int64(d.Alteration.ColumnID)))
+					if col, err := table.FindColumnByID(d.Alteration.ColumnID); err == nil {
+						targetName = parser.NewDString(col.Name)
+					}
 				}
 				if err := addRow(
 					tableID,
@@ -885,9 +891,13 @@ CREATE TABLE crdb_internal.create_statements (
 				var err error
 				var typeView = parser.DString("view")
 				var typeTable = parser.DString("table")
+				var typeSequence = parser.DString("sequence")
 				if table.IsView() {
 					descType = &typeView
 					stmt, err = p.showCreateView(ctx, parser.Name(table.Name), table)
+				} else if table.IsSequence() {
+					descType = &typeSequence
+					stmt, err = p.showCreateSequence(ctx, parser.Name(table.Name), table)
 				} else {
 					descType = &typeTable
 					stmt, err = p.showCreateTable(ctx, parser.Name(table.Name), prefix, table)
@@ -17,6 +17,7 @@ package sql
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"regexp"
 	"strings"
 
@@ -139,7 +140,7 @@ func (p *planner) CreateIndex(ctx context.Context, n *parser.CreateIndex) (planN
 		return nil, err
 	}
 
-	if err := p.CheckPrivilege(tableDesc, privilege.CREATE); err != nil {
+	if err := p.CheckPrivilege(ctx, tableDesc, privilege.CREATE); err != nil {
 		return nil, err
 	}
 
@@ -162,6 +163,9 @@ func (n *createIndexNode) Start(params runParams) error {
 		Unique:           n.n.Unique,
 		StoreColumnNames: n.n.Storing.ToStrings(),
 	}
+	if n.n.Inverted {
+		indexDesc.Type = sqlbase.IndexDescriptor_INVERTED
+	}
 	if err := indexDesc.FillColumns(n.n.Columns); err != nil {
 		return err
 	}
@@ -184,6 +188,16 @@ func (n *createIndexNode) Start(params runParams) error {
 		}
 	}
 
+	if n.n.PartitionBy != nil {
+		index := n.tableDesc.Mutations[mutationIdx].GetIndex()
+		part, err := createPartitioning(
+			&params.p.evalCtx, params.p.session.SearchPath, n.tableDesc, index, n.n.PartitionBy)
+		if err != nil {
+			return err
+		}
+		index.Partitioning = part
+	}
+
 	mutationID, err := params.p.createSchemaChangeJob(params.ctx, n.tableDesc,
 		parser.AsStringWithFlags(n.n, parser.FmtSimpleQualified))
 	if err != nil {
@@ -222,8 +236,10 @@ func (*createIndexNode) Close(context.Context)        {}
 func (*createIndexNode) Values() parser.Datums        { return parser.Datums{} }
 
 type createUserNode struct {
-	n        *parser.CreateUser
-	password string
+	name        parser.Name
+	ifNotExists bool
+	isRole      bool
+	password    string
 }
 
 // CreateUser creates a user.
@@ -240,7 +256,7 @@ func (p *planner) CreateUser(ctx context.Context, n *parser.CreateUser) (planNod
 		return nil, err
 	}
 
-	if err := p.CheckPrivilege(tDesc, privilege.INSERT); err != nil {
+	if err := p.CheckPrivilege(ctx, tDesc, privilege.INSERT); err != nil {
 		return nil, err
 	}
 
@@ -252,7 +268,27 @@ func (p *planner) CreateUser(ctx context.Context, n *parser.CreateUser) (planNod
 		}
 	}
 
-	return &createUserNode{n: n, password: resolvedPassword}, nil
+	return &createUserNode{name: n.Name, password: resolvedPassword}, nil
+}
+
+// CreateRole creates a role. Roles are stored in system.users alongside the
+// users, but can't log in.
+// Privileges: INSERT on system.users.
+func (p *planner) CreateRole(ctx context.Context, n *parser.CreateRole) (planNode, error) {
+	if n.Name == "" {
+		return nil, errors.New("no role name specified")
+	}
+
+	tDesc, err := getTableDesc(ctx, p.txn, p.getVirtualTabler(), &parser.TableName{DatabaseName: "system", TableName: "users"})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.CheckPrivilege(ctx, tDesc, privilege.INSERT); err != nil {
+		return nil, err
+	}
+
+	return &createUserNode{name: n.Name, ifNotExists: n.IfNotExists, isRole: true}, nil
 }
 
 const usernameHelp = "usernames are case insensitive, must start with a letter " +
@@ -287,28 +323,38 @@ func (n *createUserNode) Start(params runParams) error {
 		}
 	}
 
-	normalizedUsername, err := NormalizeAndValidateUsername(string(n.n.Name))
+	normalizedUsername, err := NormalizeAndValidateUsername(string(n.name))
 	if err != nil {
 		return err
 	}
 
+	opName, kind := "create-user", "user"
+	if n.isRole {
+		opName, kind = "create-role", "role"
+	}
+	stmt := `INSERT INTO system.users VALUES ($1, $2, $3)`
+	if n.ifNotExists {
+		stmt += ` ON CONFLICT (username) DO NOTHING`
+	}
+
 	internalExecutor := InternalExecutor{LeaseManager: params.p.LeaseMgr()}
 	rowsAffected, err := internalExecutor.ExecuteStatementInTransaction(
 		params.ctx,
-		"create-user",
+		opName,
 		params.p.txn,
-		"INSERT INTO system.users VALUES ($1, $2);",
+		stmt,
 		normalizedUsername,
 		hashedPassword,
+		n.isRole,
 	)
 	if err != nil {
 		if sqlbase.IsUniquenessConstraintViolationError(err) {
-			err = errors.Errorf("user %s already exists", normalizedUsername)
+			err = errors.Errorf("%s %s already exists", kind, normalizedUsername)
 		}
 		return err
-	} else if rowsAffected != 1 {
+	} else if rowsAffected != 1 && !n.ifNotExists {
 		return errors.Errorf(
-			"%d rows affected by user creation; expected exactly one row affected", rowsAffected,
+			"%d rows affected by %s creation; expected exactly one row affected", rowsAffected, kind,
 		)
 	}
 
@@ -342,12 +388,16 @@ func (p *planner) CreateView(ctx context.Context, n *parser.CreateView) (planNod
 		return nil, err
 	}
 
+	if n.AsSource.With != nil {
+		return nil, pgerror.Unimplemented("view cte", "views do not currently support CTEs")
+	}
+
 	dbDesc, err := MustGetDatabaseDesc(ctx, p.txn, p.getVirtualTabler(), name.Database())
 	if err != nil {
 		return nil, err
 	}
 
-	if err := p.CheckPrivilege(dbDesc, privilege.CREATE); err != nil {
+	if err := p.CheckPrivilege(ctx, dbDesc, privilege.CREATE); err != nil {
 		return nil, err
 	}
 
@@ -500,6 +550,104 @@ func (n *createViewNode) Close(ctx context.Context) {}
 func (*createViewNode) Next(runParams) (bool, error) { return false, nil }
 func (*createViewNode) Values() parser.Datums        { return parser.Datums{} }
 
+type createSequenceNode struct {
+	n      *parser.CreateSequence
+	dbDesc *sqlbase.DatabaseDescriptor
+}
+
+// CreateSequence creates a sequence.
+// Privileges: CREATE on database.
+//   Notes: postgres requires CREATE on the schema.
+func (p *planner) CreateSequence(ctx context.Context, n *parser.CreateSequence) (planNode, error) {
+	name, err := n.Name.NormalizeWithDatabaseName(p.session.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	dbDesc, err := MustGetDatabaseDesc(ctx, p.txn, p.getVirtualTabler(), name.Database())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.CheckPrivilege(ctx, dbDesc, privilege.CREATE); err != nil {
+		return nil, err
+	}
+
+	return &createSequenceNode{
+		n:      n,
+		dbDesc: dbDesc,
+	}, nil
+}
+
+func (n *createSequenceNode) Start(params runParams) error {
+	seqName := n.n.Name.TableName().Table()
+	tKey := tableKey{parentID: n.dbDesc.ID, name: seqName}
+	key := tKey.Key()
+	if exists, err := descExists(params.ctx, params.p.txn, key); err == nil && exists {
+		if n.n.IfNotExists {
+			return nil
+		}
+		return sqlbase.NewRelationAlreadyExistsError(tKey.Name())
+	} else if err != nil {
+		return err
+	}
+
+	id, err := GenerateUniqueDescID(params.ctx, params.p.session.execCfg.DB)
+	if err != nil {
+		return err
+	}
+
+	// Inherit permissions from the database descriptor.
+	privs := n.dbDesc.GetPrivileges()
+
+	desc, err := makeSequenceTableDesc(
+		seqName, n.n.Options, n.dbDesc.ID, id, params.p.txn.OrigTimestamp(), privs,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err = desc.ValidateTable(); err != nil {
+		return err
+	}
+
+	if err = params.p.createDescriptorWithID(params.ctx, key, id, &desc); err != nil {
+		return err
+	}
+
+	// Initialize the sequence value so that the first call to nextval()
+	// returns the start value.
+	seqValueKey := keys.MakeSequenceKey(uint32(id))
+	b := &client.Batch{}
+	b.Inc(seqValueKey, desc.SequenceOpts.Start-desc.SequenceOpts.Increment)
+	if err := params.p.txn.Run(params.ctx, b); err != nil {
+		return err
+	}
+
+	if err := desc.Validate(params.ctx, params.p.txn); err != nil {
+		return err
+	}
+
+	// Log Create Sequence event. This is an auditable log event and is
+	// recorded in the same transaction as the table descriptor update.
+	return MakeEventLogger(params.p.LeaseMgr()).InsertEventRecord(
+		params.ctx,
+		params.p.txn,
+		EventLogCreateSequence,
+		int32(desc.ID),
+		int32(params.p.evalCtx.NodeID),
+		struct {
+			SequenceName string
+			Statement    string
+			User         string
+		}{n.n.Name.String(), n.n.String(), params.p.session.User},
+	)
+}
+
+func (*createSequenceNode) Next(runParams) (bool, error) { return false, nil }
+func (*createSequenceNode) Close(context.Context)        {}
+func (*createSequenceNode) Values() parser.Datums        { return parser.Datums{} }
+
 type createTableNode struct {
 	n          *parser.CreateTable
 	dbDesc     *sqlbase.DatabaseDescriptor
@@ -521,7 +669,7 @@ func (p *planner) CreateTable(ctx context.Context, n *parser.CreateTable) (planN
 		return nil, err
 	}
 
-	if err := p.CheckPrivilege(dbDesc, privilege.CREATE); err != nil {
+	if err := p.CheckPrivilege(ctx, dbDesc, privilege.CREATE); err != nil {
 		return nil, err
 	}
 
@@ -586,6 +734,7 @@ func HoistConstraints(n *parser.CreateTable) {
 					FromCols: parser.NameList{col.Name},
 					ToCols:   targetCol,
 					Name:     col.References.ConstraintName,
+					Actions:  col.References.Actions,
 				})
 				col.References.Table = parser.NormalizableTableName{}
 			}
@@ -748,6 +897,10 @@ func matchesIndex(
 	if len(cols) > len(idx.ColumnIDs) || (exact && len(cols) != len(idx.ColumnIDs)) {
 		return false
 	}
+	if idx.Type == sqlbase.IndexDescriptor_INVERTED {
+		// The values of the columns can't be looked up in an inverted index.
+		return false
+	}
 
 	for i := range cols {
 		if cols[i].ID != idx.ColumnIDs[i] {
@@ -775,6 +928,30 @@ func (p *planner) resolveFK(
 // "unvalidated", but when table is empty (e.g. during creation), no existing
 // data imples no existing violations, and thus the constraint can be created
 // without the unvalidated flag.
+// checkReferenceActions verifies that the referencing columns of a foreign key
+// can hold the values its SET NULL and SET DEFAULT actions would assign.
+func checkReferenceActions(srcCols []sqlbase.ColumnDescriptor, actions parser.ReferenceActions) error {
+	for _, action := range []parser.ReferenceAction{actions.Delete, actions.Update} {
+		for _, col := range srcCols {
+			switch action {
+			case parser.SetNull:
+				if !col.Nullable {
+					return pgerror.NewErrorf(pgerror.CodeInvalidForeignKeyError,
+						"cannot add a SET NULL action on column %q which has a NOT NULL constraint",
+						col.Name)
+				}
+			case parser.SetDefault:
+				if !col.Nullable && col.DefaultExpr == nil {
+					return pgerror.NewErrorf(pgerror.CodeInvalidForeignKeyError,
+						"cannot add a SET DEFAULT action on column %q which has a NOT NULL "+
+							"constraint and no DEFAULT expression", col.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
 func resolveFK(
 	ctx context.Context,
 	txn *client.Txn,
@@ -855,6 +1032,10 @@ func resolveFK(
 		}
 	}
 
+	if err := checkReferenceActions(srcCols, d.Actions); err != nil {
+		return err
+	}
+
 	constraintName := string(d.Name)
 	if constraintName == "" {
 		constraintName = fmt.Sprintf("fk_%s_ref_%s", string(d.FromCols[0]), target.Name)
@@ -887,6 +1068,8 @@ func resolveFK(
 		Index:           targetIdx.ID,
 		Name:            constraintName,
 		SharedPrefixLen: int32(len(srcCols)),
+		OnDelete:        sqlbase.ForeignKeyReferenceActionValue[d.Actions.Delete],
+		OnUpdate:        sqlbase.ForeignKeyReferenceActionValue[d.Actions.Update],
 	}
 	if mode == sqlbase.ConstraintValidity_Unvalidated {
 		ref.Validity = sqlbase.ConstraintValidity_Unvalidated
@@ -1171,6 +1354,80 @@ func (n *createViewNode) makeViewTableDesc(
 	return desc, desc.AllocateIDs()
 }
 
+// makeSequenceTableDesc returns the table descriptor for a new sequence.
+//
+// Sequences have no columns or indexes. Their value is stored in a single
+// key, see keys.MakeSequenceKey.
+func makeSequenceTableDesc(
+	sequenceName string,
+	sequenceOptions parser.SequenceOptions,
+	parentID sqlbase.ID,
+	id sqlbase.ID,
+	creationTime hlc.Timestamp,
+	privileges *sqlbase.PrivilegeDescriptor,
+) (sqlbase.TableDescriptor, error) {
+	desc := initTableDescriptor(id, parentID, sequenceName, creationTime, privileges)
+
+	// Fill in the default options. An ascending sequence counts up from 1 and a
+	// descending sequence counts down from -1.
+	opts := &sqlbase.TableDescriptor_SequenceOpts{
+		Increment: 1,
+	}
+	seenOptions := make(map[string]bool, len(sequenceOptions))
+	for _, option := range sequenceOptions {
+		if seenOptions[option.Name] {
+			return desc, pgerror.NewError(pgerror.CodeSyntaxError, "conflicting or redundant options")
+		}
+		seenOptions[option.Name] = true
+		if option.Name == parser.SeqOptIncrement {
+			opts.Increment = *option.IntVal
+		}
+	}
+	if opts.Increment == 0 {
+		return desc, errors.New("INCREMENT must not be zero")
+	}
+	if opts.Increment > 0 {
+		opts.MinValue = 1
+		opts.MaxValue = math.MaxInt64
+	} else {
+		opts.MinValue = math.MinInt64
+		opts.MaxValue = -1
+	}
+
+	setStart := false
+	for _, option := range sequenceOptions {
+		switch option.Name {
+		case parser.SeqOptNoCycle, parser.SeqOptIncrement:
+			// NO CYCLE is the only supported behavior; INCREMENT was handled above.
+		case parser.SeqOptMinValue:
+			// NO MINVALUE keeps the default.
+			if option.IntVal != nil {
+				opts.MinValue = *option.IntVal
+			}
+		case parser.SeqOptMaxValue:
+			// NO MAXVALUE keeps the default.
+			if option.IntVal != nil {
+				opts.MaxValue = *option.IntVal
+			}
+		case parser.SeqOptStart:
+			opts.Start = *option.IntVal
+			setStart = true
+		default:
+			return desc, pgerror.Unimplemented("sequence option", fmt.Sprintf("unsupported sequence option %q", option.Name))
+		}
+	}
+	if !setStart {
+		if opts.Increment > 0 {
+			opts.Start = opts.MinValue
+		} else {
+			opts.Start = opts.MaxValue
+		}
+	}
+	desc.SequenceOpts = opts
+
+	return desc, desc.SequenceOpts.Validate()
+}
+
 // makeTableDescIfAs is the MakeTableDesc method for when we have a table
 // that is created with the CREATE AS format.
 func makeTableDescIfAs(
@@ -1261,6 +1518,14 @@ func MakeTableDesc(
 	}
 
 	var primaryIndexColumnSet map[string]struct{}
+	// partitionedIndexes records the positions in desc.Indexes of the indexes
+	// with PARTITION BY clauses. Their partitionings are created once the index
+	// and column IDs have been allocated.
+	type partitionedIndex struct {
+		idx    int
+		partBy *parser.PartitionBy
+	}
+	var partitionedIndexes []partitionedIndex
 	for _, def := range n.Defs {
 		switch d := def.(type) {
 		case *parser.ColumnTableDef:
@@ -1271,9 +1536,15 @@ func MakeTableDesc(
 				Name:             string(d.Name),
 				StoreColumnNames: d.Storing.ToStrings(),
 			}
+			if d.Inverted {
+				idx.Type = sqlbase.IndexDescriptor_INVERTED
+			}
 			if err := idx.FillColumns(d.Columns); err != nil {
 				return desc, err
 			}
+			if d.PartitionBy != nil {
+				partitionedIndexes = append(partitionedIndexes, partitionedIndex{len(desc.Indexes), d.PartitionBy})
+			}
 			if err := desc.AddIndex(idx, false); err != nil {
 				return desc, err
 			}
@@ -1289,6 +1560,9 @@ func MakeTableDesc(
 			if err := idx.FillColumns(d.Columns); err != nil {
 				return desc, err
 			}
+			if d.PartitionBy != nil && !d.PrimaryKey {
+				partitionedIndexes = append(partitionedIndexes, partitionedIndex{len(desc.Indexes), d.PartitionBy})
+			}
 			if err := desc.AddIndex(idx, d.PrimaryKey); err != nil {
 				return desc, err
 			}
@@ -1319,6 +1593,16 @@ func MakeTableDesc(
 		}
 	}
 
+	// Computed columns are validated once all the columns they can refer to
+	// are in place.
+	for i := range desc.Columns {
+		if desc.Columns[i].IsComputed() {
+			if err := sqlbase.ValidateComputedColumn(&desc, &desc.Columns[i], searchPath); err != nil {
+				return desc, err
+			}
+		}
+	}
+
 	// Now that all columns are in place, add any explicit families (this is done
 	// here, rather than in the constraint pass below since we want to pick up
 	// explicit allocations before AllocateIDs adds implicit ones).
@@ -1342,6 +1626,21 @@ func MakeTableDesc(
 		}
 	}
 
+	if n.PartitionBy != nil {
+		part, err := createPartitioning(evalCtx, searchPath, &desc, &desc.PrimaryIndex, n.PartitionBy)
+		if err != nil {
+			return desc, err
+		}
+		desc.PrimaryIndex.Partitioning = part
+	}
+	for _, p := range partitionedIndexes {
+		part, err := createPartitioning(evalCtx, searchPath, &desc, &desc.Indexes[p.idx], p.partBy)
+		if err != nil {
+			return desc, err
+		}
+		desc.Indexes[p.idx].Partitioning = part
+	}
+
 	// With all structural elements in place and IDs allocated, we can resolve the
 	// constraints and qualifications.
 	// FKs are resolved after the descriptor is otherwise complete and IDs have
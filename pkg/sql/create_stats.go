@@ -0,0 +1,187 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/jobs"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/stats"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+)
+
+type createStatsNode struct {
+	n         *parser.CreateStats
+	tableDesc *sqlbase.TableDescriptor
+	columnIDs []sqlbase.ColumnID
+}
+
+// CreateStatistics creates a statistic on a column of a table.
+// Privileges: INSERT on table.
+func (p *planner) CreateStatistics(ctx context.Context, n *parser.CreateStats) (planNode, error) {
+	// The job records the outcome of the statement in its own transaction, so
+	// the statistics must not be written in a transaction that could still be
+	// rolled back.
+	if !p.session.TxnState.implicitTxn {
+		return nil, pgerror.NewError(pgerror.CodeActiveSQLTransactionError,
+			"CREATE STATISTICS cannot run inside a transaction block")
+	}
+
+	tn, err := n.Table.NormalizeWithDatabaseName(p.session.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	tableDesc, err := MustGetTableDesc(ctx, p.txn, p.getVirtualTabler(), tn, false /*allowAdding*/)
+	if err != nil {
+		return nil, err
+	}
+	if tableDesc.IsVirtualTable() {
+		return nil, errors.Errorf("cannot create statistics on virtual table %q", tn.Table())
+	}
+	if tableDesc.IsView() {
+		return nil, errors.Errorf("cannot create statistics on view %q", tn.Table())
+	}
+	if tableDesc.IsSequence() {
+		return nil, errors.Errorf("cannot create statistics on sequence %q", tn.Table())
+	}
+
+	if err := p.CheckPrivilege(ctx, tableDesc, privilege.INSERT); err != nil {
+		return nil, err
+	}
+
+	if len(n.ColumnNames) != 1 {
+		return nil, errors.New("multi-column statistics are not supported yet")
+	}
+
+	columnIDs := make([]sqlbase.ColumnID, len(n.ColumnNames))
+	for i, colName := range n.ColumnNames {
+		col, err := tableDesc.FindActiveColumnByName(string(colName))
+		if err != nil {
+			return nil, err
+		}
+		columnIDs[i] = col.ID
+	}
+
+	return &createStatsNode{
+		n:         n,
+		tableDesc: tableDesc,
+		columnIDs: columnIDs,
+	}, nil
+}
+
+func (n *createStatsNode) Start(params runParams) error {
+	p := params.p
+	job := p.ExecCfg().JobRegistry.NewJob(jobs.Record{
+		Description:   n.n.String(),
+		Username:      p.User(),
+		DescriptorIDs: sqlbase.IDs{n.tableDesc.ID},
+		Details: jobs.CreateStatsDetails{
+			Name:      string(n.n.Name),
+			TableID:   n.tableDesc.ID,
+			ColumnIDs: n.columnIDs,
+		},
+	})
+	if err := job.Created(params.ctx, jobs.WithoutCancel); err != nil {
+		return err
+	}
+	if err := job.Started(params.ctx); err != nil {
+		return err
+	}
+
+	err := n.createStats(params)
+	if finishErr := job.FinishedWith(params.ctx, err); finishErr != nil {
+		return finishErr
+	}
+	return err
+}
+
+// createStats runs the distributed plan that collects the statistics and
+// stores the results in system.table_statistics.
+func (n *createStatsNode) createStats(params runParams) error {
+	p := params.p
+	dsp := p.session.distSQLPlanner
+
+	planCtx := dsp.NewPlanningCtx(params.ctx, p.txn)
+	plan, err := dsp.createStatsPlan(&planCtx, n.tableDesc, n.columnIDs)
+	if err != nil {
+		return err
+	}
+
+	ci := sqlbase.ColTypeInfoFromColTypes(plan.ResultTypes)
+	rows := sqlbase.NewRowContainer(*p.evalCtx.ActiveMemAcc, ci, len(n.columnIDs))
+	defer rows.Close(params.ctx)
+	recv, err := makeDistSQLReceiver(
+		params.ctx,
+		NewRowResultWriter(parser.Rows, rows),
+		p.ExecCfg().RangeDescriptorCache,
+		p.ExecCfg().LeaseHolderCache,
+		p.txn,
+		func(ts hlc.Timestamp) {
+			_ = p.ExecCfg().Clock.Update(ts)
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if err := dsp.Run(&planCtx, p.txn, &plan, &recv, p.evalCtx); err != nil {
+		return err
+	}
+	if recv.err != nil {
+		return recv.err
+	}
+
+	if rows.Len() != len(n.columnIDs) {
+		return errors.Errorf("expected %d statistics rows, got %d", len(n.columnIDs), rows.Len())
+	}
+	ie := InternalExecutor{LeaseManager: p.LeaseMgr()}
+	for i := range n.columnIDs {
+		row := rows.At(i)
+		var histogram *stats.HistogramData
+		if row[3] != parser.DNull {
+			histogram = &stats.HistogramData{}
+			if err := histogram.Unmarshal([]byte(*row[3].(*parser.DBytes))); err != nil {
+				return err
+			}
+		}
+		if err := stats.InsertNewStat(
+			params.ctx,
+			ie,
+			p.txn,
+			n.tableDesc.ID,
+			string(n.n.Name),
+			n.columnIDs[i:i+1],
+			int64(*row[0].(*parser.DInt)),
+			int64(*row[1].(*parser.DInt)),
+			int64(*row[2].(*parser.DInt)),
+			histogram,
+		); err != nil {
+			return err
+		}
+	}
+	if statsCache := p.ExecCfg().TableStatsCache; statsCache != nil {
+		statsCache.InvalidateTableStats(params.ctx, n.tableDesc.ID)
+	}
+	return nil
+}
+
+func (*createStatsNode) Next(runParams) (bool, error) { return false, nil }
+func (*createStatsNode) Close(context.Context)        {}
+func (*createStatsNode) Values() parser.Datums        { return parser.Datums{} }
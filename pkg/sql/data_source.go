@@ -340,6 +340,15 @@ func (p *planner) getDataSource(
 ) (planDataSource, error) {
 	switch t := src.(type) {
 	case *parser.NormalizableTableName:
+		// Is this perhaps a reference to a common table expression?
+		ds, foundCTE, err := p.getCTEDataSource(t)
+		if err != nil {
+			return planDataSource{}, err
+		}
+		if foundCTE {
+			return ds, nil
+		}
+
 		// Usual case: a table.
 		tn, err := p.QualifyWithDatabase(ctx, t)
 		if err != nil {
@@ -568,6 +577,12 @@ func (p *planner) getPlanForDesc(
 				errors.Errorf("cannot specify an explicit column list when accessing a view by reference")
 		}
 		return p.getViewPlan(ctx, tn, desc)
+	} else if desc.IsSequence() {
+		if wantedColumns != nil {
+			return planDataSource{},
+				errors.Errorf("cannot specify an explicit column list when accessing a sequence by reference")
+		}
+		return p.getSequenceSource(ctx, tn, desc)
 	} else if !desc.IsTable() {
 		return planDataSource{}, errors.Errorf(
 			"unexpected table descriptor of type %s for %q", desc.TypeName(), parser.ErrString(tn))
@@ -575,7 +590,7 @@ func (p *planner) getPlanForDesc(
 
 	// This name designates a real table.
 	scan := p.Scan()
-	if err := scan.initTable(p, desc, hints, scanVisibility, wantedColumns); err != nil {
+	if err := scan.initTable(ctx, p, desc, hints, scanVisibility, wantedColumns); err != nil {
 		return planDataSource{}, err
 	}
 
@@ -606,7 +621,7 @@ func (p *planner) getViewPlan(
 	// SELECT privileges on the view, which is intended to allow for exposing
 	// some subset of a restricted table's data to less privileged users.
 	if !p.skipSelectPrivilegeChecks {
-		if err := p.CheckPrivilege(desc, privilege.SELECT); err != nil {
+		if err := p.CheckPrivilege(ctx, desc, privilege.SELECT); err != nil {
 			return planDataSource{}, err
 		}
 		p.skipSelectPrivilegeChecks = true
@@ -630,6 +645,11 @@ func (p *planner) getViewPlan(
 		p.planDeps = nil
 	}
 
+	// The names used in the view query must not resolve to the CTEs
+	// visible where the view is used.
+	defer func(prev cteNameEnvironment) { p.cteNameEnvironment = prev }(p.cteNameEnvironment)
+	p.cteNameEnvironment = nil
+
 	// TODO(a-robinson): Support ORDER BY and LIMIT in views. Is it as simple as
 	// just passing the entire select here or will inserting an ORDER BY in the
 	// middle of a query plan break things?
@@ -54,6 +54,14 @@ type deleteNode struct {
 func (p *planner) Delete(
 	ctx context.Context, n *parser.Delete, desiredTypes []parser.Type,
 ) (planNode, error) {
+	if n.With != nil {
+		return p.planWith(ctx, n.With, func() (planNode, error) {
+			stmt := *n
+			stmt.With = nil
+			return p.Delete(ctx, &stmt, desiredTypes)
+		})
+	}
+
 	if n.Where == nil && p.session.SafeUpdates {
 		return nil, pgerror.NewDangerousStatementErrorf("DELETE without WHERE clause")
 	}
@@ -75,8 +83,8 @@ func (p *planner) Delete(
 		requestedCols = en.tableDesc.Columns
 	}
 
-	fkTables := sqlbase.TablesNeededForFKs(*en.tableDesc, sqlbase.CheckDeletes)
-	if err := p.fillFKTableMap(ctx, fkTables); err != nil {
+	fkTables, err := sqlbase.TablesNeededForFKs(ctx, *en.tableDesc, sqlbase.CheckDeletes, p.lookupFKTable)
+	if err != nil {
 		return nil, err
 	}
 	rd, err := sqlbase.MakeRowDeleter(p.txn, en.tableDesc, fkTables, requestedCols,
@@ -84,7 +92,7 @@ func (p *planner) Delete(
 	if err != nil {
 		return nil, err
 	}
-	tw := tableDeleter{rd: rd, autoCommit: p.autoCommit, alloc: &p.alloc}
+	tw := tableDeleter{rd: rd, autoCommit: p.autoCommit, alloc: &p.alloc, evalCtx: &p.evalCtx}
 
 	// TODO(knz): Until we split the creation of the node from Start()
 	// for the SelectClause too, we cannot cache this. This is because
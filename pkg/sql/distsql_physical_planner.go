@@ -108,6 +108,14 @@ var planMergeJoins = settings.RegisterBoolSetting(
 	true,
 )
 
+var planLookupJoins = settings.RegisterBoolSetting(
+	"sql.distsql.lookup_joins.enabled",
+	"if set, we plan lookup joins when they are estimated to be cheaper than hash or merge joins; "+
+		"the equality columns must match a prefix of the columns of an index, and a secondary index "+
+		"that doesn't contain all the needed columns is only used for inner joins",
+	true,
+)
+
 func newDistSQLPlanner(
 	planVersion distsqlrun.DistSQLVersion,
 	st *cluster.Settings,
@@ -348,6 +356,31 @@ func (dsp *distSQLPlanner) checkSupportForNode(node planNode) (distRecommendatio
 		// Distribute aggregations if possible.
 		return rec.compose(shouldDistribute), nil
 
+	case *windowNode:
+		for _, windowFn := range n.funcs {
+			var spec distsqlrun.WindowerSpec
+			if err := setWindowerFunc(&spec, windowFn.expr); err != nil {
+				return 0, err
+			}
+			if err := dsp.checkExpr(windowFn.frameStartExpr); err != nil {
+				return 0, err
+			}
+			if err := dsp.checkExpr(windowFn.frameEndExpr); err != nil {
+				return 0, err
+			}
+		}
+		for _, e := range n.windowRender {
+			if err := dsp.checkExpr(e); err != nil {
+				return 0, err
+			}
+		}
+		rec, err := dsp.checkSupportForNode(n.plan)
+		if err != nil {
+			return 0, err
+		}
+		// Distribute window functions if possible.
+		return rec.compose(shouldDistribute), nil
+
 	case *limitNode:
 		if err := dsp.checkExpr(n.countExpr); err != nil {
 			return 0, err
@@ -1744,6 +1777,266 @@ func (dsp *distSQLPlanner) addAggregators(
 	return nil
 }
 
+// setWindowerFunc sets the function of a WindowerSpec to the enum value with
+// the same string representation as the given window function application.
+func setWindowerFunc(spec *distsqlrun.WindowerSpec, f *parser.FuncExpr) error {
+	funcStr := strings.ToUpper(f.Func.FunctionReference.String())
+	if f.GetAggregateConstructor() != nil {
+		funcIdx, ok := distsqlrun.AggregatorSpec_Func_value[funcStr]
+		if !ok {
+			return newQueryNotSupportedErrorf("aggregate %s not supported as a window function", funcStr)
+		}
+		spec.AggregateFunc = distsqlrun.AggregatorSpec_Func(funcIdx).Enum()
+		return nil
+	}
+	funcIdx, ok := distsqlrun.WindowerSpec_WindowFunc_value[funcStr]
+	if !ok {
+		return newQueryNotSupportedErrorf("window function %s not supported", funcStr)
+	}
+	spec.WindowFunc = distsqlrun.WindowerSpec_WindowFunc(funcIdx).Enum()
+	return nil
+}
+
+// makeWindowerFrameSpec converts the frame of a window function application,
+// if it has one. The offsets of the bounds are evaluated once, during planning.
+func makeWindowerFrameSpec(
+	evalCtx *parser.EvalContext, windowFn *windowFuncHolder,
+) (*distsqlrun.WindowerSpec_Frame, error) {
+	frame := windowFn.windowDef.Frame
+	if frame == nil {
+		return nil, nil
+	}
+	spec := &distsqlrun.WindowerSpec_Frame{Mode: distsqlrun.WindowerSpec_Frame_RANGE}
+	if frame.Mode == parser.RowsMode {
+		spec.Mode = distsqlrun.WindowerSpec_Frame_ROWS
+	}
+
+	convertBound := func(
+		bound *parser.WindowFrameBound, offsetExpr parser.TypedExpr, which string,
+	) (distsqlrun.WindowerSpec_Frame_Bound, error) {
+		var res distsqlrun.WindowerSpec_Frame_Bound
+		switch bound.BoundType {
+		case parser.UnboundedPreceding:
+			res.BoundType = distsqlrun.WindowerSpec_Frame_UNBOUNDED_PRECEDING
+		case parser.ValuePreceding:
+			res.BoundType = distsqlrun.WindowerSpec_Frame_OFFSET_PRECEDING
+		case parser.CurrentRow:
+			res.BoundType = distsqlrun.WindowerSpec_Frame_CURRENT_ROW
+		case parser.ValueFollowing:
+			res.BoundType = distsqlrun.WindowerSpec_Frame_OFFSET_FOLLOWING
+		case parser.UnboundedFollowing:
+			res.BoundType = distsqlrun.WindowerSpec_Frame_UNBOUNDED_FOLLOWING
+		default:
+			return res, errors.Errorf("unknown window frame bound type %d", bound.BoundType)
+		}
+		offset, err := evalWindowFrameOffset(evalCtx, offsetExpr, which)
+		if err != nil {
+			return res, err
+		}
+		if offset != nil {
+			res.Offset = distsqlplan.MakeExpression(offset, nil)
+		}
+		return res, nil
+	}
+
+	var err error
+	spec.Start, err = convertBound(frame.Bounds.StartBound, windowFn.frameStartExpr, "starting")
+	if err != nil {
+		return nil, err
+	}
+	endBound := frame.Bounds.EndBound
+	if endBound == nil {
+		endBound = &parser.WindowFrameBound{BoundType: parser.CurrentRow}
+	}
+	spec.End, err = convertBound(endBound, windowFn.frameEndExpr, "ending")
+	if err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// addWindowers adds windowers corresponding to a windowNode and updates the
+// plan to reflect the windowNode. Each window function is computed by a stage
+// of windowers which append the result of the function to their input rows.
+// The windowers require their input to be sorted on the PARTITION BY columns
+// followed by the ORDER BY columns of the window definition: if the window
+// function has a PARTITION BY clause and there are multiple streams, the rows
+// are distributed by hash on the partition columns and sorted by one sorter per
+// stream; otherwise, the rows are brought to a single sorter on this node. An
+// evaluator stage is added at the end to compute the renders of the windowNode.
+func (dsp *distSQLPlanner) addWindowers(p *physicalPlan, n *windowNode) error {
+	// windowCols holds the stream column of the result of each window function.
+	windowCols := make([]int, len(n.funcs))
+	for i, windowFn := range n.funcs {
+		var spec distsqlrun.WindowerSpec
+		if err := setWindowerFunc(&spec, windowFn.expr); err != nil {
+			return err
+		}
+		argTypes := make([]sqlbase.ColumnType, windowFn.argCount)
+		for j := range argTypes {
+			streamCol := p.planToStreamColMap[windowFn.argIdxStart+j]
+			spec.ArgIdxs = append(spec.ArgIdxs, uint32(streamCol))
+			argTypes[j] = p.ResultTypes[streamCol]
+		}
+		_, outputType, err := distsqlrun.GetWindowFunctionInfo(&spec, argTypes...)
+		if err != nil {
+			return err
+		}
+
+		// The rows are sorted on the partition columns, in any direction,
+		// followed by the ORDER BY columns.
+		var sortOrdering distsqlrun.Ordering
+		for _, idx := range windowFn.partitionIdxs {
+			streamCol := uint32(p.planToStreamColMap[idx])
+			spec.PartitionBy = append(spec.PartitionBy, streamCol)
+			sortOrdering.Columns = append(sortOrdering.Columns, distsqlrun.Ordering_Column{
+				ColIdx:    streamCol,
+				Direction: distsqlrun.Ordering_Column_ASC,
+			})
+		}
+		spec.Ordering = dsp.convertOrdering(
+			physicalProps{ordering: windowFn.columnOrdering}, p.planToStreamColMap,
+		)
+		sortOrdering.Columns = append(sortOrdering.Columns, spec.Ordering.Columns...)
+
+		spec.Frame, err = makeWindowerFrameSpec(&n.planner.evalCtx, windowFn)
+		if err != nil {
+			return err
+		}
+
+		sortCore := distsqlrun.ProcessorCoreUnion{Noop: &distsqlrun.NoopCoreSpec{}}
+		if len(sortOrdering.Columns) > 0 {
+			sortCore = distsqlrun.ProcessorCoreUnion{
+				Sorter: &distsqlrun.SorterSpec{OutputOrdering: sortOrdering},
+			}
+		}
+
+		if len(spec.PartitionBy) == 0 || len(p.ResultRouters) == 1 {
+			// No PARTITION BY, or we have a single stream. Bring the results back
+			// on this node to be sorted.
+			p.AddSingleGroupStage(
+				dsp.nodeDesc.NodeID, sortCore, distsqlrun.PostProcessSpec{}, p.ResultTypes,
+			)
+		} else {
+			// We distribute (by partition columns) to multiple processors.
+
+			// Set up the output routers from the previous stage.
+			for _, resultProc := range p.ResultRouters {
+				p.Processors[resultProc].Spec.Output[0] = distsqlrun.OutputRouterSpec{
+					Type:        distsqlrun.OutputRouterSpec_BY_HASH,
+					HashColumns: spec.PartitionBy,
+				}
+			}
+
+			stageID := p.NewStageID()
+
+			// We have one sorter for each result router, each followed by a
+			// windower below.
+			pIdxStart := distsqlplan.ProcessorIdx(len(p.Processors))
+			for _, resultProc := range p.ResultRouters {
+				proc := distsqlplan.Processor{
+					Node: p.Processors[resultProc].Node,
+					Spec: distsqlrun.ProcessorSpec{
+						Input: []distsqlrun.InputSyncSpec{{
+							// The other fields will be filled in by mergeResultStreams.
+							ColumnTypes: p.ResultTypes,
+						}},
+						Core: sortCore,
+						Output: []distsqlrun.OutputRouterSpec{{
+							Type: distsqlrun.OutputRouterSpec_PASS_THROUGH,
+						}},
+						StageID: stageID,
+					},
+				}
+				p.AddProcessor(proc)
+			}
+
+			// Connect the streams.
+			for bucket := 0; bucket < len(p.ResultRouters); bucket++ {
+				pIdx := pIdxStart + distsqlplan.ProcessorIdx(bucket)
+				p.MergeResultStreams(p.ResultRouters, bucket, distsqlrun.Ordering{}, pIdx, 0)
+			}
+
+			// Set the new result routers.
+			for i := 0; i < len(p.ResultRouters); i++ {
+				p.ResultRouters[i] = pIdxStart + distsqlplan.ProcessorIdx(i)
+			}
+		}
+
+		// The windowers append their result to the rows and preserve their
+		// ordering.
+		windowCols[i] = len(p.ResultTypes)
+		outputTypes := make([]sqlbase.ColumnType, len(p.ResultTypes)+1)
+		copy(outputTypes, p.ResultTypes)
+		outputTypes[len(p.ResultTypes)] = outputType
+		p.AddNoGroupingStage(
+			distsqlrun.ProcessorCoreUnion{Windower: &spec},
+			distsqlrun.PostProcessSpec{},
+			outputTypes,
+			sortOrdering,
+		)
+	}
+
+	// Build the renders of the windowNode. Their IndexedVars refer to the
+	// columns of the wrapped plan, followed by the results of the window
+	// functions.
+	numWrappedCols := len(p.planToStreamColMap)
+	indexVarMap := make([]int, numWrappedCols+len(n.funcs))
+	copy(indexVarMap, p.planToStreamColMap)
+	copy(indexVarMap[numWrappedCols:], windowCols)
+	indexVarTypes := make([]sqlbase.ColumnType, len(indexVarMap))
+	for i, streamCol := range indexVarMap {
+		indexVarTypes[i] = p.ResultTypes[streamCol]
+	}
+	h := distsqlplan.MakeTypeIndexedVarHelper(indexVarTypes)
+
+	renders := make([]parser.TypedExpr, len(n.windowRender))
+	curColIdx := 0
+	curFnIdx := 0
+	for i, render := range n.windowRender {
+		if render == nil {
+			// The render is passed through from the wrapped plan.
+			renders[i] = h.IndexedVar(curColIdx)
+			curColIdx++
+			continue
+		}
+		// Skip the columns of the wrapped plan that hold the arguments of the
+		// window functions of this render (see windowNode.populateValues).
+		for ; curFnIdx < len(n.funcs); curFnIdx++ {
+			windowFn := n.funcs[curFnIdx]
+			if windowFn.argIdxStart != curColIdx {
+				break
+			}
+			curColIdx += windowFn.argCount
+		}
+		// Replace the windowFuncHolders with the results of the windowers, and
+		// the IndexedVars above the windowing level (see
+		// windowNode.replaceIndexVarsAndAggFuncs) with the columns of the wrapped
+		// plan they refer to.
+		expr, err := parser.SimpleVisit(render, func(expr parser.Expr) (error, bool, parser.Expr) {
+			switch t := expr.(type) {
+			case *windowFuncHolder:
+				return nil, false, h.IndexedVar(numWrappedCols + t.funcIdx)
+			case *parser.IndexedVar:
+				colIdx, ok := n.colContainer.idxMap[t.Idx]
+				if !ok {
+					colIdx = n.aggContainer.idxMap[t.Idx]
+				}
+				return nil, false, h.IndexedVar(colIdx)
+			}
+			return nil, true, expr
+		})
+		if err != nil {
+			return err
+		}
+		renders[i] = expr.(parser.TypedExpr)
+	}
+
+	p.AddRendering(renders, indexVarMap, getTypesForPlanResult(n, nil))
+	p.planToStreamColMap = identityMap(p.planToStreamColMap, len(renders))
+	return nil
+}
+
 func (dsp *distSQLPlanner) createPlanForIndexJoin(
 	planCtx *planningCtx, n *indexJoinNode,
 ) (physicalPlan, error) {
@@ -1848,6 +2141,32 @@ func getTypesForPlanResult(node planNode, planToStreamColMap []int) []sqlbase.Co
 	return types
 }
 
+// chooseJoinAlgorithm returns the algorithm with which the join is executed:
+// the cheapest one according to the statistics among those enabled by the
+// cluster settings. Tables without statistics are assumed to contain
+// defaultTableRowCount rows. If the costs can't be estimated at all, a merge
+// join is used whenever the inputs are suitably ordered, and a hash join
+// otherwise.
+func (dsp *distSQLPlanner) chooseJoinAlgorithm(n *joinNode) joinAlgorithm {
+	enabled := func(algo joinAlgorithm) bool {
+		switch algo {
+		case mergeJoin:
+			return planMergeJoins.Get(&dsp.st.SV)
+		case lookupJoin:
+			return planLookupJoins.Get(&dsp.st.SV)
+		}
+		return true
+	}
+	costs, ok := n.estimateAlgorithmCosts(true /* useDefaults */)
+	if !ok {
+		if n.canUseMergeJoin() && enabled(mergeJoin) {
+			return mergeJoin
+		}
+		return hashJoin
+	}
+	return costs.cheapest(enabled).algo
+}
+
 func (dsp *distSQLPlanner) createPlanForJoin(
 	planCtx *planningCtx, n *joinNode,
 ) (physicalPlan, error) {
@@ -1870,6 +2189,17 @@ func (dsp *distSQLPlanner) createPlanForJoin(
 	//    joiner.
 	//
 	//  - The routers of the joiner processors are the result routers of the plan.
+	//
+	// The joiners are merge joiners instead of hash joiners if the inputs are
+	// ordered on the equality columns and a merge join is expected to be
+	// cheaper. If the right side is a table with an index on the equality
+	// columns and the left side is small, a lookup join may be cheaper still;
+	// see chooseJoinAlgorithm and createPlanForLookupJoin.
+
+	algo := dsp.chooseJoinAlgorithm(n)
+	if algo == lookupJoin {
+		return dsp.createPlanForLookupJoin(planCtx, n)
+	}
 
 	leftPlan, err := dsp.createPlanForNode(planCtx, n.left.plan)
 	if err != nil {
@@ -1945,28 +2275,24 @@ func (dsp *distSQLPlanner) createPlanForJoin(
 		for i, rightPlanCol := range n.pred.rightEqualityIndices {
 			rightEqCols[i] = uint32(rightPlan.planToStreamColMap[rightPlanCol])
 		}
-		if planMergeJoins.Get(&dsp.st.SV) && len(n.mergeJoinOrdering) > 0 &&
-			joinType == distsqlrun.JoinType_INNER {
-			// TODO(radu): we currently only use merge joins when we have an ordering on
-			// all equality columns. We should relax this by either:
-			//  - implementing a hybrid hash/merge processor which implements merge
-			//    logic on the columns we have an ordering on, and within each merge
-			//    group uses a hashmap on the remaining columns
-			//  - or: adding a sort processor to complete the order
-			if len(n.mergeJoinOrdering) == len(n.pred.leftEqualityIndices) {
-				// Excellent! We can use the merge joiner.
-				leftMergeOrd.Columns = make([]distsqlrun.Ordering_Column, len(n.mergeJoinOrdering))
-				rightMergeOrd.Columns = make([]distsqlrun.Ordering_Column, len(n.mergeJoinOrdering))
-				for i, c := range n.mergeJoinOrdering {
-					leftMergeOrd.Columns[i].ColIdx = leftEqCols[c.ColIdx]
-					rightMergeOrd.Columns[i].ColIdx = rightEqCols[c.ColIdx]
-					dir := distsqlrun.Ordering_Column_ASC
-					if c.Direction == encoding.Descending {
-						dir = distsqlrun.Ordering_Column_DESC
-					}
-					leftMergeOrd.Columns[i].Direction = dir
-					rightMergeOrd.Columns[i].Direction = dir
+		// TODO(radu): we currently only use merge joins when we have an ordering on
+		// all equality columns. We should relax this by either:
+		//  - implementing a hybrid hash/merge processor which implements merge
+		//    logic on the columns we have an ordering on, and within each merge
+		//    group uses a hashmap on the remaining columns
+		//  - or: adding a sort processor to complete the order
+		if algo == mergeJoin {
+			leftMergeOrd.Columns = make([]distsqlrun.Ordering_Column, len(n.mergeJoinOrdering))
+			rightMergeOrd.Columns = make([]distsqlrun.Ordering_Column, len(n.mergeJoinOrdering))
+			for i, c := range n.mergeJoinOrdering {
+				leftMergeOrd.Columns[i].ColIdx = leftEqCols[c.ColIdx]
+				rightMergeOrd.Columns[i].ColIdx = rightEqCols[c.ColIdx]
+				dir := distsqlrun.Ordering_Column_ASC
+				if c.Direction == encoding.Descending {
+					dir = distsqlrun.Ordering_Column_DESC
 				}
+				leftMergeOrd.Columns[i].Direction = dir
+				rightMergeOrd.Columns[i].Direction = dir
 			}
 		}
 	} else {
@@ -2201,6 +2527,18 @@ func (dsp *distSQLPlanner) createPlanForNode(
 
 		return plan, nil
 
+	case *windowNode:
+		plan, err := dsp.createPlanForNode(planCtx, n.plan)
+		if err != nil {
+			return physicalPlan{}, err
+		}
+
+		if err := dsp.addWindowers(&plan, n); err != nil {
+			return physicalPlan{}, err
+		}
+
+		return plan, nil
+
 	case *sortNode:
 		plan, err := dsp.createPlanForNode(planCtx, n.plan)
 		if err != nil {
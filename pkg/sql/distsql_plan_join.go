@@ -0,0 +1,295 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlplan"
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlrun"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util"
+)
+
+// lookupJoinTarget describes how the rows of the right side of a join can be
+// looked up for a lookup join.
+type lookupJoinTarget struct {
+	// scan is the scan of the table on the right side of the join.
+	scan *scanNode
+	// indexIdx is the position of the index used for the lookups, as expected
+	// by JoinReaderSpec.IndexIdx.
+	indexIdx uint32
+	// lookupEqCols contains, for each column of the index used for the
+	// lookups, the position of the matching equality column of the join.
+	lookupEqCols []int
+	// covering is false if the index is a secondary index that doesn't
+	// contain all the columns needed from the table; the rows found in the
+	// index are then looked up in the primary index.
+	covering bool
+}
+
+// findLookupJoinTarget determines whether the join can be executed as a
+// lookup join, in which a joinReader looks up the rows of the right side in
+// an index, for each row of the left side. This is possible if:
+//   - the join is an inner or left outer join with equality columns;
+//   - the join isn't expected to produce an ordering, as the joinReader
+//     doesn't preserve the ordering of its input;
+//   - the right side is an unconstrained scan of a table;
+//   - the table has an index whose first columns are equality columns; a
+//     secondary index that doesn't contain all the columns needed from the
+//     table is only used for inner joins (see findLookupJoinIndex).
+//
+// Whether a lookup join is actually used depends on its estimated cost; see
+// estimateAlgorithmCosts.
+func findLookupJoinTarget(n *joinNode) (lookupJoinTarget, bool) {
+	if n.joinType != joinTypeInner && n.joinType != joinTypeLeftOuter {
+		return lookupJoinTarget{}, false
+	}
+	if n.pred.numMergedEqualityColumns != 0 || len(n.pred.leftEqualityIndices) == 0 ||
+		len(n.mergeJoinOrdering) != 0 {
+		return lookupJoinTarget{}, false
+	}
+
+	scan, ok := n.right.plan.(*scanNode)
+	if !ok || scan.scanVisibility != publicColumns ||
+		len(scan.cols) != len(scan.desc.Columns) || scan.hardLimit != 0 || scan.softLimit != 0 {
+		return lookupJoinTarget{}, false
+	}
+	// The constraints of the scan may have been removed from its filter, so
+	// the scan must cover the whole index.
+	if !scan.isFullIndexScan() {
+		return lookupJoinTarget{}, false
+	}
+
+	indexIdx, lookupEqCols, covering := findLookupJoinIndex(n, scan)
+	if lookupEqCols == nil {
+		return lookupJoinTarget{}, false
+	}
+	return lookupJoinTarget{
+		scan:         scan,
+		indexIdx:     indexIdx,
+		lookupEqCols: lookupEqCols,
+		covering:     covering,
+	}, true
+}
+
+// createPlanForLookupJoin creates a plan for the join that uses a joinReader
+// to look up the rows of the right side in an index, for each row of the
+// left side. If the index doesn't contain all the needed columns, a first
+// joinReader looks up the primary keys of the rows in the index, and a second
+// one looks up the rows in the primary index. The join must be suitable for a
+// lookup join; see findLookupJoinTarget.
+func (dsp *distSQLPlanner) createPlanForLookupJoin(
+	planCtx *planningCtx, n *joinNode,
+) (physicalPlan, error) {
+	target, ok := findLookupJoinTarget(n)
+	if !ok {
+		return physicalPlan{}, errors.Errorf("join is not suitable for a lookup join")
+	}
+	scan, lookupEqCols := target.scan, target.lookupEqCols
+	joinType := distsqlrun.JoinType_INNER
+	if n.joinType == joinTypeLeftOuter {
+		joinType = distsqlrun.JoinType_LEFT_OUTER
+	}
+
+	leftPlan, err := dsp.createPlanForNode(planCtx, n.left.plan)
+	if err != nil {
+		return physicalPlan{}, err
+	}
+	numLeftStreamCols := len(leftPlan.ResultTypes)
+
+	lookupCols := make([]uint32, len(lookupEqCols))
+	usedEq := make([]bool, len(n.pred.leftEqualityIndices))
+	for i, eq := range lookupEqCols {
+		usedEq[eq] = true
+		lookupCols[i] = uint32(leftPlan.planToStreamColMap[n.pred.leftEqualityIndices[eq]])
+	}
+
+	indexIdx := target.indexIdx
+	if !target.covering {
+		// The first joinReader outputs the columns of the left stream followed
+		// by the primary key columns of the rows found in the secondary index.
+		// Only inner joins are planned this way, so all the conditions can be
+		// checked by the second joinReader.
+		indexPost := distsqlrun.PostProcessSpec{Projection: true}
+		indexTypes := make([]sqlbase.ColumnType, 0, numLeftStreamCols+len(scan.desc.PrimaryIndex.ColumnIDs))
+		for i := 0; i < numLeftStreamCols; i++ {
+			indexPost.OutputColumns = append(indexPost.OutputColumns, uint32(i))
+			indexTypes = append(indexTypes, leftPlan.ResultTypes[i])
+		}
+		pkCols := make([]uint32, len(scan.desc.PrimaryIndex.ColumnIDs))
+		for i, id := range scan.desc.PrimaryIndex.ColumnIDs {
+			colIdx := scan.colIdxMap[id]
+			pkCols[i] = uint32(len(indexPost.OutputColumns))
+			indexPost.OutputColumns = append(indexPost.OutputColumns, uint32(numLeftStreamCols+colIdx))
+			indexTypes = append(indexTypes, scan.desc.Columns[colIdx].Type)
+		}
+		indexReaderSpec := distsqlrun.JoinReaderSpec{
+			Table:         *scan.desc,
+			IndexIdx:      indexIdx,
+			LookupColumns: lookupCols,
+			Type:          distsqlrun.JoinType_INNER,
+		}
+		leftPlan.AddNoGroupingStage(
+			distsqlrun.ProcessorCoreUnion{JoinReader: &indexReaderSpec},
+			indexPost,
+			indexTypes,
+			distsqlrun.Ordering{},
+		)
+		indexIdx, lookupCols = 0, pkCols
+	}
+	numInputStreamCols := len(leftPlan.ResultTypes)
+
+	// The ON condition of the joinReader contains the ON condition of the
+	// join, the equalities that are not used for the lookup and the filter of
+	// the scan. They are all expressed in terms of the join columns.
+	onCond := n.pred.onCond
+	for i := range usedEq {
+		if !usedEq[i] {
+			onCond = mergeConj(onCond, parser.NewTypedComparisonExpr(parser.EQ,
+				n.pred.iVarHelper.IndexedVar(n.pred.leftEqualityIndices[i]),
+				n.pred.iVarHelper.IndexedVar(n.pred.numLeftCols+n.pred.rightEqualityIndices[i]),
+			))
+		}
+	}
+	if scan.filter != nil {
+		onCond = mergeConj(onCond, exprConvertVars(scan.filter,
+			func(expr parser.VariableExpr) (bool, parser.Expr) {
+				if iv, ok := expr.(*parser.IndexedVar); ok {
+					return true, n.pred.iVarHelper.IndexedVar(n.pred.numLeftCols + iv.Idx)
+				}
+				return true, expr
+			}))
+	}
+
+	// The internal columns of the joinReader are the columns of its input
+	// stream followed by the columns of the table. The columns of the left
+	// stream come first in the input stream.
+	joinColMap := make([]int, 0, len(n.columns))
+	for i := 0; i < n.pred.numLeftCols; i++ {
+		joinColMap = append(joinColMap, leftPlan.planToStreamColMap[i])
+	}
+	for i := 0; i < n.pred.numRightCols; i++ {
+		joinColMap = append(joinColMap, numInputStreamCols+i)
+	}
+
+	post := distsqlrun.PostProcessSpec{
+		Projection: true,
+	}
+	joinToStreamColMap := makePlanToStreamColMap(len(n.columns))
+	for i, col := range n.columns {
+		if !col.Omitted {
+			joinToStreamColMap[i] = len(post.OutputColumns)
+			post.OutputColumns = append(post.OutputColumns, uint32(joinColMap[i]))
+		}
+	}
+
+	joinReaderSpec := distsqlrun.JoinReaderSpec{
+		Table:         *scan.desc,
+		IndexIdx:      indexIdx,
+		LookupColumns: lookupCols,
+		OnExpr:        distsqlplan.MakeExpression(onCond, joinColMap),
+		Type:          joinType,
+	}
+
+	// Instantiate one join reader for every stream of the left side.
+	leftPlan.AddNoGroupingStage(
+		distsqlrun.ProcessorCoreUnion{JoinReader: &joinReaderSpec},
+		post,
+		getTypesForPlanResult(n, joinToStreamColMap),
+		distsqlrun.Ordering{},
+	)
+	leftPlan.planToStreamColMap = joinToStreamColMap
+	return leftPlan, nil
+}
+
+// findLookupJoinIndex looks for an index of the table scanned on the right
+// side of the join that can be used for a lookup join. It returns the
+// position of the index (as expected by JoinReaderSpec.IndexIdx), for each
+// column of the longest prefix of the index columns that are equality
+// columns, the position of the matching equality column of the join, and
+// whether the index contains all the columns needed from the table.
+//
+// The index with the longest such prefix is chosen, preferring the primary
+// index and then covering secondary indexes. A secondary index that doesn't
+// contain all the needed columns is only used for inner joins: for a left
+// outer join, the conditions that can only be checked once the rows have
+// been looked up in the primary index would determine whether a left row has
+// a match. Interleaved indexes can only be used if all their columns are
+// equality columns. If there is no suitable index, the second return value
+// is nil.
+func findLookupJoinIndex(n *joinNode, scan *scanNode) (uint32, []int, bool) {
+	// eqCols maps the IDs of the right equality columns to their position.
+	eqCols := make(map[sqlbase.ColumnID]int, len(n.pred.rightEqualityIndices))
+	for i, c := range n.pred.rightEqualityIndices {
+		if _, ok := eqCols[scan.cols[c].ID]; !ok {
+			eqCols[scan.cols[c].ID] = i
+		}
+	}
+
+	var neededCols util.FastIntSet
+	for i := range scan.cols {
+		if scan.valNeededForCol[i] || n.pred.iVarHelper.IndexedVarUsed(n.pred.numLeftCols+i) {
+			neededCols.Add(int(scan.cols[i].ID))
+		}
+	}
+	for _, c := range n.pred.rightEqualityIndices {
+		neededCols.Add(int(scan.cols[c].ID))
+	}
+
+	matchIndex := func(index *sqlbase.IndexDescriptor) []int {
+		if index.Type != sqlbase.IndexDescriptor_FORWARD {
+			return nil
+		}
+		var res []int
+		for _, id := range index.ColumnIDs {
+			eq, ok := eqCols[id]
+			if !ok {
+				break
+			}
+			res = append(res, eq)
+		}
+		if len(res) < len(index.ColumnIDs) && len(index.Interleave.Ancestors) > 0 {
+			return nil
+		}
+		return res
+	}
+
+	bestIdx, best, bestCovering := uint32(0), matchIndex(&scan.desc.PrimaryIndex), true
+	for i := range scan.desc.Indexes {
+		index := &scan.desc.Indexes[i]
+		res := matchIndex(index)
+		if len(res) == 0 || len(res) < len(best) {
+			continue
+		}
+		var indexCols util.FastIntSet
+		for _, ids := range [][]sqlbase.ColumnID{
+			index.ColumnIDs, index.ExtraColumnIDs, index.StoreColumnIDs,
+		} {
+			for _, id := range ids {
+				indexCols.Add(int(id))
+			}
+		}
+		covering := neededCols.SubsetOf(indexCols)
+		if !covering && n.joinType != joinTypeInner {
+			continue
+		}
+		if len(res) > len(best) || (covering && !bestCovering) {
+			bestIdx, best, bestCovering = uint32(i+1), res, covering
+		}
+	}
+	return bestIdx, best, bestCovering
+}
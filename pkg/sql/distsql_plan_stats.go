@@ -0,0 +1,126 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlplan"
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlrun"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/pkg/errors"
+)
+
+// histogramSamples is the number of sample rows to be collected for histogram
+// construction. For larger tables, it may be beneficial to increase this number
+// to get a more accurate distribution.
+const histogramSamples = 10000
+
+// histogramBuckets is the maximum number of buckets in a histogram.
+const histogramBuckets = 200
+
+// createStatsPlan generates a plan which collects statistics on the given
+// columns of a table. The plan consists of table readers feeding samplers on
+// each node, followed by a single sample aggregator on the gateway. The plan
+// produces one row per column with the row count, distinct count, null count
+// and encoded histogram (see SampleAggregatorSpec). The plan is finalized.
+func (dsp *distSQLPlanner) createStatsPlan(
+	planCtx *planningCtx, desc *sqlbase.TableDescriptor, columnIDs []sqlbase.ColumnID,
+) (physicalPlan, error) {
+	// Find the positions of the requested columns in the table reader output.
+	colIdxMap := make(map[sqlbase.ColumnID]int, len(desc.Columns))
+	for i, c := range desc.Columns {
+		colIdxMap[c.ID] = i
+	}
+	outCols := make([]uint32, len(columnIDs))
+	colTypes := make([]sqlbase.ColumnType, len(columnIDs))
+	sketchSpecs := make([]distsqlrun.SketchSpec, len(columnIDs))
+	for i, id := range columnIDs {
+		idx, ok := colIdxMap[id]
+		if !ok {
+			return physicalPlan{}, errors.Errorf("column %d does not exist", id)
+		}
+		outCols[i] = uint32(idx)
+		colTypes[i] = desc.Columns[idx].Type
+		sketchSpecs[i] = distsqlrun.SketchSpec{
+			SketchType:          distsqlrun.SketchType_HLL_PLUS_PLUS_V1,
+			Columns:             []uint32{uint32(i)},
+			GenerateHistogram:   true,
+			HistogramMaxBuckets: histogramBuckets,
+		}
+	}
+
+	spanPartitions, err := dsp.partitionSpans(planCtx, roachpb.Spans{desc.PrimaryIndexSpan()})
+	if err != nil {
+		return physicalPlan{}, err
+	}
+
+	var p physicalPlan
+	stageID := p.NewStageID()
+	for _, sp := range spanPartitions {
+		tr := &distsqlrun.TableReaderSpec{Table: *desc}
+		tr.Spans = make([]distsqlrun.TableReaderSpan, len(sp.spans))
+		for i := range sp.spans {
+			tr.Spans[i].Span = sp.spans[i]
+		}
+
+		proc := distsqlplan.Processor{
+			Node: sp.node,
+			Spec: distsqlrun.ProcessorSpec{
+				Core:    distsqlrun.ProcessorCoreUnion{TableReader: tr},
+				Post:    distsqlrun.PostProcessSpec{Projection: true, OutputColumns: outCols},
+				Output:  []distsqlrun.OutputRouterSpec{{Type: distsqlrun.OutputRouterSpec_PASS_THROUGH}},
+				StageID: stageID,
+			},
+		}
+		pIdx := p.AddProcessor(proc)
+		p.ResultRouters = append(p.ResultRouters, pIdx)
+	}
+	p.ResultTypes = colTypes
+
+	// Each sampler outputs the sampled rows followed by five extra columns:
+	// the rank, the sketch index, the row count, the null count and the
+	// encoded sketch.
+	intType := sqlbase.ColumnType{SemanticType: sqlbase.ColumnType_INT}
+	bytesType := sqlbase.ColumnType{SemanticType: sqlbase.ColumnType_BYTES}
+	samplerOutTypes := append([]sqlbase.ColumnType(nil), colTypes...)
+	samplerOutTypes = append(samplerOutTypes, intType, intType, intType, intType, bytesType)
+
+	p.AddNoGroupingStage(
+		distsqlrun.ProcessorCoreUnion{Sampler: &distsqlrun.SamplerSpec{
+			Sketches:   sketchSpecs,
+			SampleSize: histogramSamples,
+		}},
+		distsqlrun.PostProcessSpec{},
+		samplerOutTypes,
+		distsqlrun.Ordering{},
+	)
+
+	// The aggregator outputs the row count, distinct count, null count and
+	// histogram for each sketch.
+	aggOutTypes := []sqlbase.ColumnType{intType, intType, intType, bytesType}
+	p.AddSingleGroupStage(
+		dsp.nodeDesc.NodeID,
+		distsqlrun.ProcessorCoreUnion{SampleAggregator: &distsqlrun.SampleAggregatorSpec{
+			Sketches:   sketchSpecs,
+			SampleSize: histogramSamples,
+		}},
+		distsqlrun.PostProcessSpec{},
+		aggOutTypes,
+	)
+	p.planToStreamColMap = []int{0, 1, 2, 3}
+
+	dsp.FinalizePlan(planCtx, &p)
+	return p, nil
+}
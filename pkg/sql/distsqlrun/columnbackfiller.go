@@ -34,6 +34,10 @@ type columnBackfiller struct {
 	// updateCols is a slice of all column descriptors that are being modified.
 	updateCols  []sqlbase.ColumnDescriptor
 	updateExprs []parser.TypedExpr
+
+	// computedColsContainer holds the row the expressions of the computed
+	// columns being added are evaluated over.
+	computedColsContainer *sqlbase.RowIndexedVarContainer
 }
 
 var _ Processor = &columnBackfiller{}
@@ -90,12 +94,33 @@ func (cb *columnBackfiller) init() error {
 		return err
 	}
 
+	colIdxMap = make(map[sqlbase.ColumnID]int, len(desc.Columns))
+	for i, c := range desc.Columns {
+		colIdxMap[c.ID] = i
+	}
+
+	// The computed columns being added are computed from the values of the
+	// existing columns, which are laid out in the fetched rows like in
+	// desc.Columns.
+	cb.computedColsContainer = &sqlbase.RowIndexedVarContainer{
+		Cols:    desc.Columns,
+		Mapping: colIdxMap,
+	}
+	computeExprs, err := sqlbase.MakeComputedExprs(
+		cb.added, cb.computedColsContainer, &parser.Parser{}, &cb.flowCtx.EvalCtx,
+	)
+	if err != nil {
+		return err
+	}
+
 	cb.updateCols = append(cb.added, cb.dropped...)
-	if len(cb.dropped) > 0 || len(defaultExprs) > 0 {
-		// Populate default values.
+	if len(cb.dropped) > 0 || len(defaultExprs) > 0 || len(computeExprs) > 0 {
+		// Populate default and computed values.
 		cb.updateExprs = make([]parser.TypedExpr, len(cb.updateCols))
 		for j := range cb.added {
-			if defaultExprs == nil || defaultExprs[j] == nil {
+			if cb.added[j].IsComputed() {
+				cb.updateExprs[j] = computeExprs[j]
+			} else if defaultExprs == nil || defaultExprs[j] == nil {
 				cb.updateExprs[j] = parser.DNull
 			} else {
 				cb.updateExprs[j] = defaultExprs[j]
@@ -112,10 +137,6 @@ func (cb *columnBackfiller) init() error {
 		valNeededForCol[i] = true
 	}
 
-	colIdxMap = make(map[sqlbase.ColumnID]int, len(desc.Columns))
-	for i, c := range desc.Columns {
-		colIdxMap[c.ID] = i
-	}
 	return cb.fetcher.Init(
 		&desc, colIdxMap, &desc.PrimaryIndex, false, false, desc.Columns,
 		valNeededForCol, false, &cb.alloc,
@@ -141,21 +162,19 @@ func (cb *columnBackfiller) runChunk(
 			defer cb.flowCtx.testingKnobs.RunAfterBackfillChunk()
 		}
 
-		fkTables := sqlbase.TablesNeededForFKs(tableDesc, sqlbase.CheckUpdates)
-		for _, fkTableDesc := range cb.spec.OtherTables {
-			found, ok := fkTables[fkTableDesc.ID]
-			if !ok {
-				// We got passed an extra table for some reason - just ignore it.
-				continue
-			}
-			found.Table = &fkTableDesc
-			fkTables[fkTableDesc.ID] = found
-		}
-		for id, table := range fkTables {
-			if table.Table == nil {
+		fkTables, err := sqlbase.TablesNeededForFKs(
+			ctx, tableDesc, sqlbase.CheckUpdates,
+			func(_ context.Context, id sqlbase.ID) (sqlbase.TableLookup, error) {
+				for i := range cb.spec.OtherTables {
+					if cb.spec.OtherTables[i].ID == id {
+						return sqlbase.TableLookup{Table: &cb.spec.OtherTables[i]}, nil
+					}
+				}
 				// We weren't passed all of the tables that we need by the coordinator.
-				return errors.Errorf("table %v not sent by coordinator", id)
-			}
+				return sqlbase.TableLookup{}, errors.Errorf("table %v not sent by coordinator", id)
+			})
+		if err != nil {
+			return err
 		}
 		// TODO(dan): Tighten up the bound on the requestedCols parameter to
 		// makeRowUpdater.
@@ -206,6 +225,7 @@ func (cb *columnBackfiller) runChunk(
 			}
 			// Evaluate the new values. This must be done separately for
 			// each row so as to handle impure functions correctly.
+			cb.computedColsContainer.CurSourceRow = row
 			for j, e := range cb.updateExprs {
 				val, err := e.Eval(&cb.flowCtx.EvalCtx)
 				if err != nil {
@@ -181,6 +181,54 @@ func (d *DistinctSpec) summary() (string, []string) {
 	return "Distinct", details
 }
 
+func (w *WindowerSpec) summary() (string, []string) {
+	var buf bytes.Buffer
+	if w.AggregateFunc != nil {
+		buf.WriteString(w.AggregateFunc.String())
+	} else if w.WindowFunc != nil {
+		buf.WriteString(w.WindowFunc.String())
+	}
+	buf.WriteByte('(')
+	buf.WriteString(colListStr(w.ArgIdxs))
+	buf.WriteByte(')')
+	details := []string{buf.String()}
+	if len(w.PartitionBy) > 0 {
+		details = append(details, fmt.Sprintf("Partition by: %s", colListStr(w.PartitionBy)))
+	}
+	if len(w.Ordering.Columns) > 0 {
+		details = append(details, fmt.Sprintf("Order by: %s", w.Ordering.diagramString()))
+	}
+	if w.Frame != nil {
+		details = append(details, fmt.Sprintf("%s BETWEEN %s AND %s",
+			w.Frame.Mode, w.Frame.Start.BoundType, w.Frame.End.BoundType))
+	}
+	return "Windower", details
+}
+
+func (s *SketchSpec) summary() string {
+	str := fmt.Sprintf("%s(%s)", s.SketchType, colListStr(s.Columns))
+	if s.GenerateHistogram {
+		str += fmt.Sprintf(" histogram(%d)", s.HistogramMaxBuckets)
+	}
+	return str
+}
+
+func (s *SamplerSpec) summary() (string, []string) {
+	details := []string{fmt.Sprintf("SampleSize: %d", s.SampleSize)}
+	for i := range s.Sketches {
+		details = append(details, s.Sketches[i].summary())
+	}
+	return "Sampler", details
+}
+
+func (s *SampleAggregatorSpec) summary() (string, []string) {
+	details := []string{fmt.Sprintf("SampleSize: %d", s.SampleSize)}
+	for i := range s.Sketches {
+		details = append(details, s.Sketches[i].summary())
+	}
+	return "SampleAggregator", details
+}
+
 func (is *InputSyncSpec) summary() (string, []string) {
 	switch is.Type {
 	case InputSyncSpec_UNORDERED:
@@ -130,7 +130,7 @@ func (ib *indexBackfiller) runChunk(
 	for i, m := range mutations {
 		added[i] = *m.GetIndex()
 	}
-	secondaryIndexEntries := make([]sqlbase.IndexEntry, len(mutations))
+	secondaryIndexEntries := make([]sqlbase.IndexEntry, 0, len(mutations))
 
 	buildIndexEntries := func(ctx context.Context, txn *client.Txn) ([]sqlbase.IndexEntry, error) {
 		entries := make([]sqlbase.IndexEntry, 0, chunkSize*int64(len(added)))
@@ -164,9 +164,10 @@ func (ib *indexBackfiller) runChunk(
 			if err := sqlbase.EncDatumRowToDatums(ib.rowVals, encRow, &ib.da); err != nil {
 				return nil, err
 			}
-			if err := sqlbase.EncodeSecondaryIndexes(
+			secondaryIndexEntries, err = sqlbase.EncodeSecondaryIndexes(
 				&ib.spec.Table, added, ib.colIdxMap,
-				ib.rowVals, secondaryIndexEntries); err != nil {
+				ib.rowVals, secondaryIndexEntries[:0])
+			if err != nil {
 				return nil, err
 			}
 			entries = append(entries, secondaryIndexEntries...)
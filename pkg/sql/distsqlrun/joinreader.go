@@ -21,7 +21,9 @@ import (
 	"golang.org/x/net/context"
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 )
@@ -31,6 +33,16 @@ import (
 // nodes that "own" the respective ranges, and send out flows on those nodes.
 const joinReaderBatchSize = 100
 
+// A joinReader performs either an index join or a lookup join.
+//
+// In an index join, each input row contains the primary key of a row of the
+// table, and the output consists of the rows of the table.
+//
+// In a lookup join, the lookup columns of each input row are used as the key
+// of a lookup in an index of the table; the output consists of the input rows
+// joined with the matching rows of the table. The lookup columns can match a
+// prefix of the columns of the index, in which case each lookup scans the
+// index entries with that prefix. The lookups are batched.
 type joinReader struct {
 	processorBase
 
@@ -43,6 +55,20 @@ type joinReader struct {
 	alloc   sqlbase.DatumAlloc
 
 	input RowSource
+
+	// The fields below are only used for lookup joins.
+
+	// lookupCols contains the input columns matching the index columns (or a
+	// prefix of them).
+	lookupCols columns
+	// indexCols contains the positions in the table columns of the index
+	// columns matched by lookupCols.
+	indexCols  columns
+	joinType   joinType
+	onCond     exprHelper
+	emptyRight sqlbase.EncDatumRow
+	// renderedRow is used to build the rows of the join.
+	renderedRow sqlbase.EncDatumRow
 }
 
 var _ Processor = &joinReader{}
@@ -54,30 +80,60 @@ func newJoinReader(
 	post *PostProcessSpec,
 	output RowReceiver,
 ) (*joinReader, error) {
-	if spec.IndexIdx != 0 {
-		// TODO(radu): for now we only support joining with the primary index
+	if spec.IndexIdx != 0 && len(spec.LookupColumns) == 0 {
+		// TODO(radu): for now we only support index joins with the primary
+		// index.
 		return nil, errors.Errorf("join with index not implemented")
 	}
 
 	jr := &joinReader{
-		flowCtx: flowCtx,
-		desc:    spec.Table,
-		input:   input,
+		flowCtx:    flowCtx,
+		desc:       spec.Table,
+		input:      input,
+		lookupCols: columns(spec.LookupColumns),
+		joinType:   joinType(spec.Type),
 	}
 
-	types := make([]sqlbase.ColumnType, len(spec.Table.Columns))
-	for i := range types {
-		types[i] = spec.Table.Columns[i].Type
+	var types []sqlbase.ColumnType
+	if jr.isLookupJoin() {
+		switch jr.joinType {
+		case innerJoin, leftOuter:
+		default:
+			return nil, errors.Errorf("join type %s not supported for lookup joins", spec.Type)
+		}
+		types = append(types, input.Types()...)
+	}
+	numInputCols := len(types)
+	for i := range spec.Table.Columns {
+		types = append(types, spec.Table.Columns[i].Type)
 	}
 
+	if err := jr.onCond.init(spec.OnExpr, types, &flowCtx.EvalCtx); err != nil {
+		return nil, err
+	}
 	if err := jr.out.Init(post, types, &flowCtx.EvalCtx, output); err != nil {
 		return nil, err
 	}
 
+	// Only the columns of the table are fetched.
+	neededCols := jr.out.neededColumns()
+	if jr.onCond.expr != nil {
+		for i := range neededCols {
+			neededCols[i] = neededCols[i] || jr.onCond.vars.IndexedVarUsed(i)
+		}
+	}
+	neededCols = neededCols[numInputCols:]
+
+	if jr.isLookupJoin() {
+		if err := jr.initLookup(spec, neededCols); err != nil {
+			return nil, err
+		}
+	}
+
 	var err error
 	jr.index, _, err = initRowFetcher(
 		&jr.fetcher, &jr.desc, int(spec.IndexIdx), false, /* reverse */
-		jr.out.neededColumns(), &jr.alloc,
+		neededCols, &jr.alloc,
 	)
 	if err != nil {
 		return nil, err
@@ -86,6 +142,67 @@ func newJoinReader(
 	return jr, nil
 }
 
+// isLookupJoin returns true if the joinReader performs a lookup join (as
+// opposed to an index join).
+func (jr *joinReader) isLookupJoin() bool {
+	return len(jr.lookupCols) > 0
+}
+
+// initLookup initializes the fields used for lookup joins. The index columns
+// matched by the lookup columns are added to the needed columns, since they
+// are used to match the fetched rows with the input rows.
+func (jr *joinReader) initLookup(spec *JoinReaderSpec, neededCols []bool) error {
+	if spec.IndexIdx > uint32(len(jr.desc.Indexes)) {
+		return errors.Errorf("invalid indexIdx %d", spec.IndexIdx)
+	}
+	index := &jr.desc.PrimaryIndex
+	if spec.IndexIdx > 0 {
+		index = &jr.desc.Indexes[spec.IndexIdx-1]
+	}
+	if len(jr.lookupCols) > len(index.ColumnIDs) {
+		return errors.Errorf("%d lookup columns specified, expecting at most %d",
+			len(jr.lookupCols), len(index.ColumnIDs))
+	}
+	if len(jr.lookupCols) < len(index.ColumnIDs) && len(index.Interleave.Ancestors) > 0 {
+		return errors.Errorf("lookups on a prefix of the columns of interleaved index %q "+
+			"are not supported", index.Name)
+	}
+
+	colIdxMap := make(map[sqlbase.ColumnID]int, len(jr.desc.Columns))
+	for i := range jr.desc.Columns {
+		colIdxMap[jr.desc.Columns[i].ID] = i
+	}
+	jr.indexCols = make(columns, len(jr.lookupCols))
+	for i, id := range index.ColumnIDs[:len(jr.lookupCols)] {
+		jr.indexCols[i] = uint32(colIdxMap[id])
+		neededCols[colIdxMap[id]] = true
+	}
+
+	if spec.IndexIdx > 0 {
+		// A secondary index only contains some of the columns of the table.
+		var indexColIDs util.FastIntSet
+		for _, ids := range [][]sqlbase.ColumnID{
+			index.ColumnIDs, index.ExtraColumnIDs, index.StoreColumnIDs,
+		} {
+			for _, id := range ids {
+				indexColIDs.Add(int(id))
+			}
+		}
+		for i, needed := range neededCols {
+			if needed && !indexColIDs.Contains(int(jr.desc.Columns[i].ID)) {
+				return errors.Errorf("index %q does not contain column %q needed by the lookup join",
+					index.Name, jr.desc.Columns[i].Name)
+			}
+		}
+	}
+
+	jr.emptyRight = make(sqlbase.EncDatumRow, len(jr.desc.Columns))
+	for i := range jr.emptyRight {
+		jr.emptyRight[i] = sqlbase.DatumToEncDatum(jr.desc.Columns[i].Type, parser.DNull)
+	}
+	return nil
+}
+
 func (jr *joinReader) generateKey(
 	row sqlbase.EncDatumRow, alloc *sqlbase.DatumAlloc, primaryKeyPrefix []byte,
 ) (roachpb.Key, error) {
@@ -106,6 +223,10 @@ func (jr *joinReader) generateKey(
 // should drain and close the output. The caller should also pass the returned
 // error to the consumer.
 func (jr *joinReader) mainLoop(ctx context.Context) error {
+	if jr.isLookupJoin() {
+		return jr.lookupJoinLoop(ctx)
+	}
+
 	primaryKeyPrefix := sqlbase.MakeIndexKeyPrefix(&jr.desc, jr.index.ID)
 
 	var alloc sqlbase.DatumAlloc
@@ -192,6 +313,175 @@ func (jr *joinReader) mainLoop(ctx context.Context) error {
 	}
 }
 
+// lookupKey returns the key to look up for the given values of the index
+// columns matched by the lookup columns. If these are only a prefix of the
+// index columns, the key is a prefix of the keys of all the matching index
+// entries. The second return value is false if one of the values is NULL, in
+// which case the row can't match anything.
+func (jr *joinReader) lookupKey(
+	values sqlbase.EncDatumRow, alloc *sqlbase.DatumAlloc, keyPrefix []byte,
+) (roachpb.Key, bool, error) {
+	for i := range values {
+		if values[i].IsNull() {
+			return nil, false, nil
+		}
+	}
+	if len(values) == len(jr.index.ColumnIDs) {
+		key, err := sqlbase.MakeKeyFromEncDatums(values, &jr.desc, jr.index, keyPrefix, alloc)
+		return key, err == nil, err
+	}
+	// Prefix lookups are not supported on interleaved indexes (see
+	// initLookup), so the values directly follow the prefix of the index.
+	key := append(roachpb.Key(nil), keyPrefix...)
+	for i := range values {
+		enc := sqlbase.DatumEncoding_ASCENDING_KEY
+		if jr.index.ColumnDirections[i] == sqlbase.IndexDescriptor_DESC {
+			enc = sqlbase.DatumEncoding_DESCENDING_KEY
+		}
+		var err error
+		if key, err = values[i].Encode(alloc, enc, key); err != nil {
+			return nil, false, err
+		}
+	}
+	return key, true, nil
+}
+
+// renderRow builds a row of a lookup join from an input row and a table row
+// (or jr.emptyRight).
+func (jr *joinReader) renderRow(lrow, rrow sqlbase.EncDatumRow) sqlbase.EncDatumRow {
+	jr.renderedRow = append(jr.renderedRow[:0], lrow...)
+	jr.renderedRow = append(jr.renderedRow, rrow...)
+	return jr.renderedRow
+}
+
+// lookupJoinLoop is the mainLoop of lookup joins. The input rows are read in
+// batches; a span is generated for each distinct lookup key in the batch, and
+// the fetched rows are matched with the input rows using their index
+// columns.
+func (jr *joinReader) lookupJoinLoop(ctx context.Context) error {
+	keyPrefix := sqlbase.MakeIndexKeyPrefix(&jr.desc, jr.index.ID)
+
+	var alloc sqlbase.DatumAlloc
+	var rowAlloc sqlbase.EncDatumRowAlloc
+	spans := make(roachpb.Spans, 0, joinReaderBatchSize)
+	inputRows := make(sqlbase.EncDatumRows, 0, joinReaderBatchSize)
+	matched := make([]bool, 0, joinReaderBatchSize)
+	lookupValues := make(sqlbase.EncDatumRow, len(jr.lookupCols))
+	indexValues := make(sqlbase.EncDatumRow, len(jr.indexCols))
+
+	txn := jr.flowCtx.txn
+	if txn == nil {
+		log.Fatalf(ctx, "joinReader outside of txn")
+	}
+
+	log.VEventf(ctx, 1, "starting lookup join")
+	if log.V(1) {
+		defer log.Infof(ctx, "exiting")
+	}
+
+	for {
+		// keyToInputRows maps each lookup key of the batch to the input rows
+		// that generated it.
+		keyToInputRows := make(map[string][]int)
+		spans = spans[:0]
+		inputRows = inputRows[:0]
+		matched = matched[:0]
+		inputDone := false
+		for len(inputRows) < joinReaderBatchSize {
+			row, meta := jr.input.Next()
+			if !meta.Empty() {
+				if meta.Err != nil {
+					return meta.Err
+				}
+				if !emitHelper(ctx, &jr.out, nil /* row */, meta, jr.input) {
+					return nil
+				}
+				continue
+			}
+			if row == nil {
+				inputDone = true
+				break
+			}
+			inputRows = append(inputRows, rowAlloc.CopyRow(row))
+			matched = append(matched, false)
+
+			for i, c := range jr.lookupCols {
+				lookupValues[i] = row[c]
+			}
+			key, ok, err := jr.lookupKey(lookupValues, &alloc, keyPrefix)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			if _, ok := keyToInputRows[string(key)]; !ok {
+				spans = append(spans, roachpb.Span{Key: key, EndKey: key.PrefixEnd()})
+			}
+			keyToInputRows[string(key)] = append(keyToInputRows[string(key)], len(inputRows)-1)
+		}
+
+		if len(spans) > 0 {
+			// TODO(radu,andrei,knz): set the traceKV flag when requested by the session.
+			err := jr.fetcher.StartScan(ctx, txn, spans, false /* no batch limits */, 0, false /* traceKV */)
+			if err != nil {
+				log.Errorf(ctx, "scan error: %s", err)
+				return err
+			}
+			for {
+				fetcherRow, err := jr.fetcher.NextRow(ctx)
+				if err != nil {
+					return err
+				}
+				if fetcherRow == nil {
+					// Done with this batch.
+					break
+				}
+
+				for i, c := range jr.indexCols {
+					indexValues[i] = fetcherRow[c]
+				}
+				key, _, err := jr.lookupKey(indexValues, &alloc, keyPrefix)
+				if err != nil {
+					return err
+				}
+				for _, idx := range keyToInputRows[string(key)] {
+					renderedRow := jr.renderRow(inputRows[idx], fetcherRow)
+					pass, err := jr.onCond.evalFilter(renderedRow)
+					if err != nil {
+						return err
+					}
+					if !pass {
+						continue
+					}
+					matched[idx] = true
+					if !emitHelper(ctx, &jr.out, renderedRow, ProducerMetadata{}, jr.input) {
+						return nil
+					}
+				}
+			}
+		}
+
+		if jr.joinType == leftOuter {
+			for idx := range inputRows {
+				if matched[idx] {
+					continue
+				}
+				renderedRow := jr.renderRow(inputRows[idx], jr.emptyRight)
+				if !emitHelper(ctx, &jr.out, renderedRow, ProducerMetadata{}, jr.input) {
+					return nil
+				}
+			}
+		}
+
+		if inputDone {
+			sendTraceData(ctx, jr.out.output)
+			jr.out.Close()
+			return nil
+		}
+	}
+}
+
 // Run is part of the processor interface.
 func (jr *joinReader) Run(ctx context.Context, wg *sync.WaitGroup) {
 	if wg != nil {
@@ -152,6 +152,182 @@ func TestJoinReader(t *testing.T) {
 	}
 }
 
+// TestJoinReaderLookup tests lookup joins against the primary index, against
+// a prefix of the primary index and against a secondary index.
+func TestJoinReaderLookup(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, sqlDB, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+
+	// Create a table where each row is:
+	//
+	//  |     a    |     b    |         sum         |         s           |
+	//  |-----------------------------------------------------------------|
+	//  | rowId/10 | rowId%10 | rowId/10 + rowId%10 | IntToEnglish(rowId) |
+
+	aFn := func(row int) parser.Datum {
+		return parser.NewDInt(parser.DInt(row / 10))
+	}
+	bFn := func(row int) parser.Datum {
+		return parser.NewDInt(parser.DInt(row % 10))
+	}
+	sumFn := func(row int) parser.Datum {
+		return parser.NewDInt(parser.DInt(row/10 + row%10))
+	}
+
+	sqlutils.CreateTable(t, sqlDB, "t",
+		"a INT, b INT, sum INT, s STRING, PRIMARY KEY (a,b), INDEX bs (b,s)",
+		99,
+		sqlutils.ToRowFn(aFn, bFn, sumFn, sqlutils.RowEnglishFn))
+
+	td := sqlbase.GetTableDescriptor(kvDB, "test", "t")
+
+	intType := sqlbase.ColumnType{SemanticType: sqlbase.ColumnType_INT}
+	strType := sqlbase.ColumnType{SemanticType: sqlbase.ColumnType_STRING}
+	intDatum := func(i int) parser.Datum { return parser.NewDInt(parser.DInt(i)) }
+
+	// The rows of the primary index lookups are (x, a, b); the rows of the
+	// secondary index lookups are (b, s). The join columns are the input
+	// columns followed by the table columns a, b, sum, s.
+	primaryInput := [][]parser.Datum{
+		{intDatum(0), aFn(2), bFn(2)},
+		{intDatum(1), aFn(15), bFn(15)},
+		{intDatum(2), intDatum(20), intDatum(0)},
+		{intDatum(3), aFn(2), bFn(2)},
+		{intDatum(4), parser.DNull, bFn(2)},
+	}
+
+	testCases := []struct {
+		spec       JoinReaderSpec
+		inputTypes []sqlbase.ColumnType
+		input      [][]parser.Datum
+		post       PostProcessSpec
+		expected   string
+	}{
+		{
+			spec: JoinReaderSpec{
+				LookupColumns: []uint32{1, 2},
+				Type:          JoinType_INNER,
+			},
+			inputTypes: []sqlbase.ColumnType{intType, intType, intType},
+			input:      primaryInput,
+			post: PostProcessSpec{
+				Projection:    true,
+				OutputColumns: []uint32{0, 6},
+			},
+			expected: "[[0 'two'] [3 'two'] [1 'one-five']]",
+		},
+		{
+			spec: JoinReaderSpec{
+				LookupColumns: []uint32{1, 2},
+				OnExpr:        Expression{Expr: "@6 <= 5"}, // sum <= 5
+				Type:          JoinType_LEFT_OUTER,
+			},
+			inputTypes: []sqlbase.ColumnType{intType, intType, intType},
+			input:      primaryInput,
+			post: PostProcessSpec{
+				Projection:    true,
+				OutputColumns: []uint32{0, 6},
+			},
+			expected: "[[0 'two'] [3 'two'] [1 NULL] [2 NULL] [4 NULL]]",
+		},
+		{
+			// The rows of the input are (x, a): each lookup returns all the rows
+			// of the table with the given value of a.
+			spec: JoinReaderSpec{
+				LookupColumns: []uint32{1},
+				OnExpr:        Expression{Expr: "@4 < 2"}, // b < 2
+				Type:          JoinType_LEFT_OUTER,
+			},
+			inputTypes: []sqlbase.ColumnType{intType, intType},
+			input: [][]parser.Datum{
+				{intDatum(0), intDatum(3)},
+				{intDatum(1), intDatum(9)},
+				{intDatum(2), parser.DNull},
+			},
+			post: PostProcessSpec{
+				Projection:    true,
+				OutputColumns: []uint32{0, 5},
+			},
+			expected: "[[0 'three-zero'] [0 'three-one'] [1 'nine-zero'] [1 'nine-one'] [2 NULL]]",
+		},
+		{
+			spec: JoinReaderSpec{
+				IndexIdx:      1,
+				LookupColumns: []uint32{0, 1},
+				Type:          JoinType_INNER,
+			},
+			inputTypes: []sqlbase.ColumnType{intType, strType},
+			input: [][]parser.Datum{
+				{bFn(2), sqlutils.RowEnglishFn(2)},
+				{bFn(15), sqlutils.RowEnglishFn(15)},
+				{bFn(3), parser.NewDString("nope")},
+			},
+			post: PostProcessSpec{
+				Projection:    true,
+				OutputColumns: []uint32{1, 2},
+			},
+			expected: "[['two' 0] ['one-five' 1]]",
+		},
+	}
+	for _, c := range testCases {
+		t.Run("", func(t *testing.T) {
+			evalCtx := parser.MakeTestingEvalContext()
+			defer evalCtx.Stop(context.Background())
+			flowCtx := FlowCtx{
+				EvalCtx:  evalCtx,
+				Settings: cluster.MakeTestingClusterSettings(),
+				// Pass a DB without a TxnCoordSender.
+				txn: client.NewTxn(client.NewDB(s.DistSender(), s.Clock()), s.NodeID()),
+			}
+
+			var rows sqlbase.EncDatumRows
+			for _, row := range c.input {
+				encRow := make(sqlbase.EncDatumRow, len(row))
+				for i, d := range row {
+					encRow[i] = sqlbase.DatumToEncDatum(c.inputTypes[i], d)
+				}
+				rows = append(rows, encRow)
+			}
+			in := NewRowBuffer(c.inputTypes, rows, RowBufferArgs{})
+
+			out := &RowBuffer{}
+			spec := c.spec
+			spec.Table = *td
+			jr, err := newJoinReader(&flowCtx, &spec, in, &c.post, out)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			jr.Run(context.Background(), nil)
+
+			if !in.Done {
+				t.Fatal("joinReader didn't consume all the rows")
+			}
+			if !out.ProducerClosed {
+				t.Fatalf("output RowReceiver not closed")
+			}
+
+			var res sqlbase.EncDatumRows
+			for {
+				row, meta := out.Next()
+				if !meta.Empty() {
+					t.Fatalf("unexpected metadata: %v", meta)
+				}
+				if row == nil {
+					break
+				}
+				res = append(res, row)
+			}
+
+			if result := res.String(); result != c.expected {
+				t.Errorf("invalid results: %s, expected %s'", result, c.expected)
+			}
+		})
+	}
+}
+
 // TestJoinReaderDrain tests various scenarios in which a joinReader's consumer
 // is closed.
 func TestJoinReaderDrain(t *testing.T) {
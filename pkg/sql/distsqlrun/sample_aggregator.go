@@ -0,0 +1,250 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/stats"
+	"github.com/cockroachdb/cockroach/pkg/util/hyperloglog"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+)
+
+// sampleAggregator combines the results of multiple samplers and outputs the
+// final statistics; see SampleAggregatorSpec.
+type sampleAggregator struct {
+	processorBase
+
+	flowCtx  *FlowCtx
+	input    RowSource
+	inTypes  []sqlbase.ColumnType
+	sketches []sketchInfo
+	sr       stats.SampleReservoir
+
+	// Input column indices for the special columns.
+	rankCol      int
+	sketchIdxCol int
+	numRowsCol   int
+	numNullsCol  int
+	sketchCol    int
+
+	datumAlloc sqlbase.DatumAlloc
+}
+
+var _ Processor = &sampleAggregator{}
+
+func newSampleAggregator(
+	flowCtx *FlowCtx,
+	spec *SampleAggregatorSpec,
+	input RowSource,
+	post *PostProcessSpec,
+	output RowReceiver,
+) (*sampleAggregator, error) {
+	inTypes := input.Types()
+	if len(inTypes) < samplerOutCols {
+		return nil, errors.Errorf("sample aggregator input has too few columns")
+	}
+	// The input columns are the sampled columns followed by the sampler
+	// columns.
+	rankCol := len(inTypes) - samplerOutCols
+	sampledTypes := inTypes[:rankCol]
+	sketches, err := newSketchInfos(spec.Sketches, sampledTypes)
+	if err != nil {
+		return nil, err
+	}
+	for i := range spec.Sketches {
+		if spec.Sketches[i].GenerateHistogram && spec.Sketches[i].HistogramMaxBuckets == 0 {
+			return nil, errors.Errorf("histogram max buckets not specified")
+		}
+	}
+	if spec.SampleSize == 0 {
+		return nil, errors.Errorf("sample size must be positive")
+	}
+
+	s := &sampleAggregator{
+		flowCtx:      flowCtx,
+		input:        input,
+		inTypes:      inTypes,
+		sketches:     sketches,
+		rankCol:      rankCol,
+		sketchIdxCol: rankCol + 1,
+		numRowsCol:   rankCol + 2,
+		numNullsCol:  rankCol + 3,
+		sketchCol:    rankCol + 4,
+	}
+	s.sr.Init(int(spec.SampleSize), sampledTypes)
+
+	outTypes := []sqlbase.ColumnType{intType, intType, intType, bytesType}
+	if err := s.out.Init(post, outTypes, &flowCtx.EvalCtx, output); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Run is part of the processor interface.
+func (s *sampleAggregator) Run(ctx context.Context, wg *sync.WaitGroup) {
+	if wg != nil {
+		defer wg.Done()
+	}
+
+	ctx = log.WithLogTag(ctx, "SampleAggregator", nil)
+	ctx, span := processorSpan(ctx, "sample aggregator")
+	defer tracing.FinishSpan(span)
+
+	if log.V(2) {
+		log.Infof(ctx, "starting sample aggregator process")
+		defer log.Infof(ctx, "exiting sample aggregator")
+	}
+
+	earlyExit, err := s.mainLoop(ctx)
+	if err != nil {
+		DrainAndClose(ctx, s.out.output, err, s.input)
+	} else if !earlyExit {
+		sendTraceData(ctx, s.out.output)
+		s.input.ConsumerClosed()
+		s.out.Close()
+	}
+}
+
+func (s *sampleAggregator) mainLoop(ctx context.Context) (earlyExit bool, _ error) {
+	var tmpSketch hyperloglog.Sketch
+	for {
+		row, meta := s.input.Next()
+		if !meta.Empty() {
+			if meta.Err != nil {
+				return false, meta.Err
+			}
+			if !emitHelper(ctx, &s.out, nil /* row */, meta, s.input) {
+				// No cleanup required; emitHelper() took care of it.
+				return true, nil
+			}
+			continue
+		}
+		if row == nil {
+			break
+		}
+
+		if !row[s.rankCol].IsNull() {
+			// This is a sampled row.
+			rank, err := s.intCol(row, s.rankCol)
+			if err != nil {
+				return false, err
+			}
+			s.sr.SampleRow(row[:s.rankCol], uint64(rank))
+			continue
+		}
+		// This is a sketch row.
+		sketchIdx, err := s.intCol(row, s.sketchIdxCol)
+		if err != nil {
+			return false, err
+		}
+		if sketchIdx < 0 || sketchIdx >= int64(len(s.sketches)) {
+			return false, errors.Errorf("invalid sketch index %d", sketchIdx)
+		}
+		info := &s.sketches[sketchIdx]
+		numRows, err := s.intCol(row, s.numRowsCol)
+		if err != nil {
+			return false, err
+		}
+		info.numRows += numRows
+		numNulls, err := s.intCol(row, s.numNullsCol)
+		if err != nil {
+			return false, err
+		}
+		info.numNulls += numNulls
+		if err := row[s.sketchCol].EnsureDecoded(&s.datumAlloc); err != nil {
+			return false, err
+		}
+		data, ok := row[s.sketchCol].Datum.(*parser.DBytes)
+		if !ok {
+			return false, errors.Errorf("invalid sketch data %s", row[s.sketchCol].Datum)
+		}
+		if err := tmpSketch.UnmarshalBinary([]byte(*data)); err != nil {
+			return false, err
+		}
+		if err := info.sketch.Merge(&tmpSketch); err != nil {
+			return false, err
+		}
+	}
+
+	outRow := make(sqlbase.EncDatumRow, 4)
+	for i := range s.sketches {
+		info := &s.sketches[i]
+		// The sketch only contains the non-NULL values; make sure the estimate
+		// doesn't exceed the number of such values.
+		distinctCount := int64(info.sketch.Estimate())
+		if nonNulls := info.numRows - info.numNulls; distinctCount > nonNulls {
+			distinctCount = nonNulls
+		}
+		histogram := parser.Datum(parser.DNull)
+		if info.spec.GenerateHistogram {
+			h, err := s.generateHistogram(info)
+			if err != nil {
+				return false, err
+			}
+			data, err := h.Marshal()
+			if err != nil {
+				return false, err
+			}
+			histogram = parser.NewDBytes(parser.DBytes(data))
+		}
+		outRow[0] = sqlbase.DatumToEncDatum(intType, parser.NewDInt(parser.DInt(info.numRows)))
+		outRow[1] = sqlbase.DatumToEncDatum(intType, parser.NewDInt(parser.DInt(distinctCount)))
+		outRow[2] = sqlbase.DatumToEncDatum(intType, parser.NewDInt(parser.DInt(info.numNulls)))
+		outRow[3] = sqlbase.DatumToEncDatum(bytesType, histogram)
+		if !emitHelper(ctx, &s.out, outRow, ProducerMetadata{}, s.input) {
+			// No cleanup required; emitHelper() took care of it.
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// intCol decodes the INT column of the given row.
+func (s *sampleAggregator) intCol(row sqlbase.EncDatumRow, col int) (int64, error) {
+	if err := row[col].EnsureDecoded(&s.datumAlloc); err != nil {
+		return 0, err
+	}
+	d, ok := row[col].Datum.(*parser.DInt)
+	if !ok {
+		return 0, errors.Errorf("expected INT in column %d, got %s", col, row[col].Datum)
+	}
+	return int64(*d), nil
+}
+
+// generateHistogram builds a histogram on the first column of the sketch from
+// the sampled rows.
+func (s *sampleAggregator) generateHistogram(info *sketchInfo) (stats.HistogramData, error) {
+	col := info.spec.Columns[0]
+	var values parser.Datums
+	for _, sample := range s.sr.Get() {
+		ed := &sample.Row[col]
+		if err := ed.EnsureDecoded(&s.datumAlloc); err != nil {
+			return stats.HistogramData{}, err
+		}
+		if ed.Datum != parser.DNull {
+			values = append(values, ed.Datum)
+		}
+	}
+	return stats.EquiDepthHistogram(
+		&s.flowCtx.EvalCtx, values, info.numRows-info.numNulls, int(info.spec.HistogramMaxBuckets),
+	)
+}
@@ -0,0 +1,174 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/stats"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+// readRows returns all the rows pushed to a RowBuffer.
+func readRows(t *testing.T, out *RowBuffer) sqlbase.EncDatumRows {
+	if !out.ProducerClosed {
+		t.Fatalf("output RowReceiver not closed")
+	}
+	var res sqlbase.EncDatumRows
+	for {
+		row, meta := out.Next()
+		if !meta.Empty() {
+			t.Fatalf("unexpected metadata: %v", meta)
+		}
+		if row == nil {
+			break
+		}
+		res = append(res, row)
+	}
+	return res
+}
+
+func decodeInt(t *testing.T, ed sqlbase.EncDatum) int64 {
+	if err := ed.EnsureDecoded(&sqlbase.DatumAlloc{}); err != nil {
+		t.Fatal(err)
+	}
+	return int64(*ed.Datum.(*parser.DInt))
+}
+
+func TestSampleAggregator(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	evalCtx := parser.MakeTestingEvalContext()
+	defer evalCtx.Stop(context.Background())
+	flowCtx := FlowCtx{
+		Settings: cluster.MakeTestingClusterSettings(),
+		EvalCtx:  evalCtx,
+	}
+
+	const numRows = 1000
+	const numSamplers = 3
+	const sampleSize = 100
+
+	// The first column has 100 distinct values and no NULLs; the second column
+	// has 10 distinct values and a NULL on every fifth row.
+	rows := make([]sqlbase.EncDatumRows, numSamplers)
+	for i := 0; i < numRows; i++ {
+		b := parser.Datum(parser.NewDInt(parser.DInt(i % 10)))
+		if i%5 == 0 {
+			b = parser.DNull
+		}
+		row := sqlbase.EncDatumRow{
+			sqlbase.DatumToEncDatum(intType, parser.NewDInt(parser.DInt(i%100))),
+			sqlbase.DatumToEncDatum(intType, b),
+		}
+		rows[i%numSamplers] = append(rows[i%numSamplers], row)
+	}
+
+	sketchSpecs := []SketchSpec{
+		{
+			SketchType:          SketchType_HLL_PLUS_PLUS_V1,
+			Columns:             []uint32{0},
+			GenerateHistogram:   true,
+			HistogramMaxBuckets: 4,
+		},
+		{
+			SketchType: SketchType_HLL_PLUS_PLUS_V1,
+			Columns:    []uint32{1},
+		},
+	}
+
+	// Run the samplers and collect their outputs.
+	var samplerOutput sqlbase.EncDatumRows
+	for i := 0; i < numSamplers; i++ {
+		in := NewRowBuffer(nil /* types */, rows[i], RowBufferArgs{})
+		out := &RowBuffer{}
+		spec := &SamplerSpec{SampleSize: sampleSize, Sketches: sketchSpecs}
+		p, err := newSamplerProcessor(&flowCtx, spec, in, &PostProcessSpec{}, out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Run(context.Background(), nil)
+		res := readRows(t, out)
+		// Each sampler outputs sampleSize sampled rows and one row per sketch.
+		if len(res) != sampleSize+len(sketchSpecs) {
+			t.Fatalf("expected %d rows, got %d", sampleSize+len(sketchSpecs), len(res))
+		}
+		samplerOutput = append(samplerOutput, res...)
+	}
+
+	// Run the sample aggregator on the combined outputs.
+	in := NewRowBuffer(nil /* types */, samplerOutput, RowBufferArgs{})
+	out := &RowBuffer{}
+	spec := &SampleAggregatorSpec{SampleSize: sampleSize, Sketches: sketchSpecs}
+	agg, err := newSampleAggregator(&flowCtx, spec, in, &PostProcessSpec{}, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	agg.Run(context.Background(), nil)
+	res := readRows(t, out)
+	if len(res) != len(sketchSpecs) {
+		t.Fatalf("expected %d rows, got %d", len(sketchSpecs), len(res))
+	}
+
+	expected := []struct {
+		rowCount, distinctCount, nullCount int64
+	}{
+		{rowCount: numRows, distinctCount: 100, nullCount: 0},
+		{rowCount: numRows, distinctCount: 8, nullCount: numRows / 5},
+	}
+	for i, exp := range expected {
+		row := res[i]
+		if rowCount := decodeInt(t, row[0]); rowCount != exp.rowCount {
+			t.Errorf("%d: expected row count %d, got %d", i, exp.rowCount, rowCount)
+		}
+		// The distinct count is an estimate; allow for hash collisions.
+		if distinctCount := decodeInt(t, row[1]); distinctCount < exp.distinctCount-2 ||
+			distinctCount > exp.distinctCount {
+			t.Errorf("%d: expected distinct count %d, got %d", i, exp.distinctCount, distinctCount)
+		}
+		if nullCount := decodeInt(t, row[2]); nullCount != exp.nullCount {
+			t.Errorf("%d: expected null count %d, got %d", i, exp.nullCount, nullCount)
+		}
+	}
+
+	// Only the first sketch generates a histogram.
+	if !res[1][3].IsNull() {
+		t.Errorf("expected no histogram, got %s", res[1][3])
+	}
+	if err := res[0][3].EnsureDecoded(&sqlbase.DatumAlloc{}); err != nil {
+		t.Fatal(err)
+	}
+	var h stats.HistogramData
+	if err := h.Unmarshal([]byte(*res[0][3].Datum.(*parser.DBytes))); err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Buckets) != 4 {
+		t.Fatalf("expected 4 buckets, got %d", len(h.Buckets))
+	}
+	var total int64
+	for _, b := range h.Buckets {
+		total += b.NumEq + b.NumRange
+	}
+	// The bucket counts are scaled from the sample, so they can be off by
+	// rounding.
+	if total < numRows-int64(len(h.Buckets)*2) || total > numRows {
+		t.Errorf("expected the buckets to add up to %d, got %d", numRows, total)
+	}
+}
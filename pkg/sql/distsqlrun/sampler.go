@@ -0,0 +1,246 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/stats"
+	"github.com/cockroachdb/cockroach/pkg/util/hyperloglog"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+)
+
+// sketchInfo contains the specification and run-time state for each sketch.
+type sketchInfo struct {
+	spec     SketchSpec
+	sketch   *hyperloglog.Sketch
+	numNulls int64
+	numRows  int64
+}
+
+// newSketchInfos creates the run-time state for the given sketch specs.
+func newSketchInfos(specs []SketchSpec, inTypes []sqlbase.ColumnType) ([]sketchInfo, error) {
+	sketches := make([]sketchInfo, len(specs))
+	for i := range specs {
+		if specs[i].SketchType != SketchType_HLL_PLUS_PLUS_V1 {
+			return nil, errors.Errorf("unsupported sketch type %s", specs[i].SketchType)
+		}
+		if len(specs[i].Columns) != 1 {
+			return nil, errors.Errorf("multi-column sketches not supported yet")
+		}
+		if col := specs[i].Columns[0]; col >= uint32(len(inTypes)) {
+			return nil, errors.Errorf("sketch column %d is not a valid column", col)
+		}
+		sketch, err := hyperloglog.New(hyperloglog.DefaultPrecision)
+		if err != nil {
+			return nil, err
+		}
+		sketches[i] = sketchInfo{spec: specs[i], sketch: sketch}
+	}
+	return sketches, nil
+}
+
+// samplerProcessor computes reservoir samples and cardinality sketches on the
+// input rows; see SamplerSpec for the output schema.
+type samplerProcessor struct {
+	processorBase
+
+	flowCtx  *FlowCtx
+	input    RowSource
+	sr       stats.SampleReservoir
+	sketches []sketchInfo
+	outTypes []sqlbase.ColumnType
+
+	// Output column indices for the special columns.
+	rankCol      int
+	sketchIdxCol int
+	numRowsCol   int
+	numNullsCol  int
+	sketchCol    int
+
+	datumAlloc sqlbase.DatumAlloc
+}
+
+var _ Processor = &samplerProcessor{}
+
+// samplerOutCols is the number of columns the sampler appends to the input
+// columns (see SamplerSpec).
+const samplerOutCols = 5
+
+var intType = sqlbase.ColumnType{SemanticType: sqlbase.ColumnType_INT}
+var bytesType = sqlbase.ColumnType{SemanticType: sqlbase.ColumnType_BYTES}
+
+func newSamplerProcessor(
+	flowCtx *FlowCtx, spec *SamplerSpec, input RowSource, post *PostProcessSpec, output RowReceiver,
+) (*samplerProcessor, error) {
+	inTypes := input.Types()
+	sketches, err := newSketchInfos(spec.Sketches, inTypes)
+	if err != nil {
+		return nil, err
+	}
+	if spec.SampleSize == 0 {
+		return nil, errors.Errorf("sample size must be positive")
+	}
+
+	s := &samplerProcessor{
+		flowCtx:  flowCtx,
+		input:    input,
+		sketches: sketches,
+	}
+	s.sr.Init(int(spec.SampleSize), inTypes)
+
+	outTypes := make([]sqlbase.ColumnType, 0, len(inTypes)+samplerOutCols)
+	// First columns are the same as the input.
+	outTypes = append(outTypes, inTypes...)
+	// An INT column for the rank of each row.
+	s.rankCol = len(outTypes)
+	outTypes = append(outTypes, intType)
+	// An INT column indicating the sketch index.
+	s.sketchIdxCol = len(outTypes)
+	outTypes = append(outTypes, intType)
+	// An INT column indicating the number of rows processed.
+	s.numRowsCol = len(outTypes)
+	outTypes = append(outTypes, intType)
+	// An INT column indicating the number of rows that have a NULL in the
+	// first sketch column.
+	s.numNullsCol = len(outTypes)
+	outTypes = append(outTypes, intType)
+	// A BYTES column with the sketch data.
+	s.sketchCol = len(outTypes)
+	outTypes = append(outTypes, bytesType)
+	s.outTypes = outTypes
+
+	if err := s.out.Init(post, outTypes, &flowCtx.EvalCtx, output); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Run is part of the processor interface.
+func (s *samplerProcessor) Run(ctx context.Context, wg *sync.WaitGroup) {
+	if wg != nil {
+		defer wg.Done()
+	}
+
+	ctx = log.WithLogTag(ctx, "Sampler", nil)
+	ctx, span := processorSpan(ctx, "sampler")
+	defer tracing.FinishSpan(span)
+
+	if log.V(2) {
+		log.Infof(ctx, "starting sampler process")
+		defer log.Infof(ctx, "exiting sampler")
+	}
+
+	earlyExit, err := s.mainLoop(ctx)
+	if err != nil {
+		DrainAndClose(ctx, s.out.output, err, s.input)
+	} else if !earlyExit {
+		sendTraceData(ctx, s.out.output)
+		s.input.ConsumerClosed()
+		s.out.Close()
+	}
+}
+
+func (s *samplerProcessor) mainLoop(ctx context.Context) (earlyExit bool, _ error) {
+	rng, _ := randutil.NewPseudoRand()
+	var buf []byte
+	for {
+		row, meta := s.input.Next()
+		if !meta.Empty() {
+			if meta.Err != nil {
+				return false, meta.Err
+			}
+			if !emitHelper(ctx, &s.out, nil /* row */, meta, s.input) {
+				// No cleanup required; emitHelper() took care of it.
+				return true, nil
+			}
+			continue
+		}
+		if row == nil {
+			break
+		}
+
+		for i := range s.sketches {
+			info := &s.sketches[i]
+			info.numRows++
+			col := info.spec.Columns[0]
+			if row[col].IsNull() {
+				info.numNulls++
+				continue
+			}
+			// We need to use a stable encoding for the value, so that equal
+			// values generate the same hash regardless of where they came from.
+			enc := sqlbase.DatumEncoding_ASCENDING_KEY
+			if sqlbase.MustBeValueEncoded(s.outTypes[col].SemanticType) {
+				enc = sqlbase.DatumEncoding_VALUE
+			}
+			var err error
+			buf, err = row[col].Encode(&s.datumAlloc, enc, buf[:0])
+			if err != nil {
+				return false, err
+			}
+			info.sketch.Insert(buf)
+		}
+
+		// Use Int63 so we don't have headaches converting to DInt.
+		s.sr.SampleRow(row, uint64(rng.Int63()))
+	}
+
+	outRow := make(sqlbase.EncDatumRow, len(s.outTypes))
+	for i := range outRow {
+		outRow[i] = sqlbase.DatumToEncDatum(s.outTypes[i], parser.DNull)
+	}
+	// Emit the sampled rows.
+	for _, sample := range s.sr.Get() {
+		copy(outRow, sample.Row)
+		outRow[s.rankCol] = sqlbase.DatumToEncDatum(
+			intType, parser.NewDInt(parser.DInt(sample.Rank)),
+		)
+		if !emitHelper(ctx, &s.out, outRow, ProducerMetadata{}, s.input) {
+			// No cleanup required; emitHelper() took care of it.
+			return true, nil
+		}
+	}
+	// Emit the sketch rows.
+	for i := range outRow {
+		outRow[i] = sqlbase.DatumToEncDatum(s.outTypes[i], parser.DNull)
+	}
+	for i, info := range s.sketches {
+		data, err := info.sketch.MarshalBinary()
+		if err != nil {
+			return false, err
+		}
+		outRow[s.sketchIdxCol] = sqlbase.DatumToEncDatum(intType, parser.NewDInt(parser.DInt(i)))
+		outRow[s.numRowsCol] = sqlbase.DatumToEncDatum(
+			intType, parser.NewDInt(parser.DInt(info.numRows)),
+		)
+		outRow[s.numNullsCol] = sqlbase.DatumToEncDatum(
+			intType, parser.NewDInt(parser.DInt(info.numNulls)),
+		)
+		outRow[s.sketchCol] = sqlbase.DatumToEncDatum(bytesType, parser.NewDBytes(parser.DBytes(data)))
+		if !emitHelper(ctx, &s.out, outRow, ProducerMetadata{}, s.input) {
+			// No cleanup required; emitHelper() took care of it.
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,389 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"strings"
+	"sync"
+	"unsafe"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/mon"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+)
+
+// GetWindowFunctionInfo returns the window function constructor and the
+// return type for the function of the given spec when applied to the given
+// input types.
+func GetWindowFunctionInfo(
+	spec *WindowerSpec, inputTypes ...sqlbase.ColumnType,
+) (
+	windowConstructor func(*parser.EvalContext) parser.WindowFunc,
+	returnType sqlbase.ColumnType,
+	err error,
+) {
+	var name string
+	var builtins []parser.Builtin
+	switch {
+	case spec.AggregateFunc != nil:
+		name = strings.ToLower(spec.AggregateFunc.String())
+		builtins = parser.Aggregates[name]
+	case spec.WindowFunc != nil:
+		name = strings.ToLower(spec.WindowFunc.String())
+		builtins = parser.Builtins[name]
+	default:
+		return nil, sqlbase.ColumnType{}, errors.Errorf("window function not specified")
+	}
+
+	datumTypes := make([]parser.Type, len(inputTypes))
+	for i := range inputTypes {
+		datumTypes[i] = inputTypes[i].ToDatumType()
+	}
+
+	for _, b := range builtins {
+		if b.WindowFunc == nil {
+			continue
+		}
+		types := b.Types.Types()
+		if len(types) != len(inputTypes) {
+			continue
+		}
+		match := true
+		for i, t := range types {
+			if !datumTypes[i].Equivalent(t) {
+				match = false
+				break
+			}
+		}
+		if match {
+			// Found!
+			constructWindow := func(evalCtx *parser.EvalContext) parser.WindowFunc {
+				return b.WindowFunc(datumTypes, evalCtx)
+			}
+
+			colTyp, err := sqlbase.DatumTypeToColumnType(b.FixedReturnType())
+			if err != nil {
+				return nil, sqlbase.ColumnType{}, err
+			}
+			return constructWindow, colTyp, nil
+		}
+	}
+	return nil, sqlbase.ColumnType{}, errors.Errorf(
+		"no builtin window function for %s on %v", name, inputTypes,
+	)
+}
+
+// windower is the processor core type that computes a window function. Its
+// input is sorted on the PARTITION BY columns followed by the ORDER BY columns
+// of the window definition, so the windower only needs to buffer the rows of
+// one partition at a time. Each input row is emitted with the result of the
+// window function appended to it.
+type windower struct {
+	processorBase
+
+	flowCtx *FlowCtx
+	input   RowSource
+
+	windowConstructor func(*parser.EvalContext) parser.WindowFunc
+	outputType        sqlbase.ColumnType
+	argIdxs           []uint32
+	partitionBy       []uint32
+	ordering          sqlbase.ColumnOrdering
+	frame             *parser.WindowFrame
+	startBoundOffset  parser.Datum
+	endBoundOffset    parser.Datum
+
+	// rows buffers the rows of the current partition.
+	rows memRowContainer
+	// memAcc accounts for the memory used to compute the window function over
+	// the current partition.
+	memAcc     mon.BoundAccount
+	outputRow  sqlbase.EncDatumRow
+	datumAlloc sqlbase.DatumAlloc
+}
+
+var _ Processor = &windower{}
+
+func newWindower(
+	flowCtx *FlowCtx, spec *WindowerSpec, input RowSource, post *PostProcessSpec, output RowReceiver,
+) (*windower, error) {
+	w := &windower{
+		flowCtx:     flowCtx,
+		input:       input,
+		argIdxs:     spec.ArgIdxs,
+		partitionBy: spec.PartitionBy,
+		ordering:    convertToColumnOrdering(spec.Ordering),
+		memAcc:      flowCtx.EvalCtx.Mon.MakeBoundAccount(),
+	}
+
+	inputTypes := input.Types()
+	argTypes := make([]sqlbase.ColumnType, len(spec.ArgIdxs))
+	for i, idx := range spec.ArgIdxs {
+		if idx >= uint32(len(inputTypes)) {
+			return nil, errors.Errorf("ArgIdxs[%d]=%d is not a valid column", i, idx)
+		}
+		argTypes[i] = inputTypes[idx]
+	}
+	var err error
+	w.windowConstructor, w.outputType, err = GetWindowFunctionInfo(spec, argTypes...)
+	if err != nil {
+		return nil, err
+	}
+
+	if spec.Frame != nil {
+		if err := w.initFrame(spec.Frame); err != nil {
+			return nil, err
+		}
+	}
+	if w.frame != nil && w.frame.Mode == parser.RangeMode && len(w.ordering) != 1 &&
+		(w.frame.Bounds.StartBound.OffsetExpr != nil || w.frame.Bounds.EndBound.OffsetExpr != nil) {
+		return nil, errors.Errorf("RANGE frame with an offset requires exactly one ordering column")
+	}
+
+	w.rows.init(w.ordering, inputTypes, &flowCtx.EvalCtx)
+
+	outputTypes := make([]sqlbase.ColumnType, len(inputTypes)+1)
+	copy(outputTypes, inputTypes)
+	outputTypes[len(inputTypes)] = w.outputType
+	w.outputRow = make(sqlbase.EncDatumRow, len(outputTypes))
+
+	if err := w.out.Init(post, outputTypes, &flowCtx.EvalCtx, output); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// initFrame converts the frame of the spec to a parser.WindowFrame and
+// evaluates the offsets of its bounds.
+func (w *windower) initFrame(spec *WindowerSpec_Frame) error {
+	w.frame = &parser.WindowFrame{}
+	switch spec.Mode {
+	case WindowerSpec_Frame_RANGE:
+		w.frame.Mode = parser.RangeMode
+	case WindowerSpec_Frame_ROWS:
+		w.frame.Mode = parser.RowsMode
+	default:
+		return errors.Errorf("unsupported window frame mode %s", spec.Mode)
+	}
+	var err error
+	w.frame.Bounds.StartBound, w.startBoundOffset, err = w.convertFrameBound(spec.Start)
+	if err != nil {
+		return err
+	}
+	w.frame.Bounds.EndBound, w.endBoundOffset, err = w.convertFrameBound(spec.End)
+	return err
+}
+
+func (w *windower) convertFrameBound(
+	spec WindowerSpec_Frame_Bound,
+) (*parser.WindowFrameBound, parser.Datum, error) {
+	bound := &parser.WindowFrameBound{}
+	switch spec.BoundType {
+	case WindowerSpec_Frame_UNBOUNDED_PRECEDING:
+		bound.BoundType = parser.UnboundedPreceding
+	case WindowerSpec_Frame_OFFSET_PRECEDING:
+		bound.BoundType = parser.ValuePreceding
+	case WindowerSpec_Frame_CURRENT_ROW:
+		bound.BoundType = parser.CurrentRow
+	case WindowerSpec_Frame_OFFSET_FOLLOWING:
+		bound.BoundType = parser.ValueFollowing
+	case WindowerSpec_Frame_UNBOUNDED_FOLLOWING:
+		bound.BoundType = parser.UnboundedFollowing
+	default:
+		return nil, nil, errors.Errorf("unsupported window frame bound type %s", spec.BoundType)
+	}
+	if bound.BoundType != parser.ValuePreceding && bound.BoundType != parser.ValueFollowing {
+		return bound, nil, nil
+	}
+	var helper exprHelper
+	if err := helper.init(spec.Offset, nil /* types */, &w.flowCtx.EvalCtx); err != nil {
+		return nil, nil, err
+	}
+	if helper.expr == nil {
+		return nil, nil, errors.Errorf("window frame bound %s requires an offset", spec.BoundType)
+	}
+	offset, err := helper.eval(nil /* row */)
+	if err != nil {
+		return nil, nil, err
+	}
+	bound.OffsetExpr = offset
+	return bound, offset, nil
+}
+
+// Run is part of the processor interface.
+func (w *windower) Run(ctx context.Context, wg *sync.WaitGroup) {
+	if wg != nil {
+		defer wg.Done()
+	}
+	defer w.memAcc.Close(ctx)
+	defer w.rows.Close(ctx)
+
+	ctx = log.WithLogTag(ctx, "Windower", nil)
+	ctx, span := processorSpan(ctx, "windower")
+	defer tracing.FinishSpan(span)
+
+	if log.V(2) {
+		log.Infof(ctx, "starting windower process")
+		defer log.Infof(ctx, "exiting windower")
+	}
+
+	earlyExit, err := w.mainLoop(ctx)
+	if err != nil {
+		DrainAndClose(ctx, w.out.output, err, w.input)
+	} else if !earlyExit {
+		sendTraceData(ctx, w.out.output)
+		w.input.ConsumerClosed()
+		w.out.Close()
+	}
+}
+
+func (w *windower) mainLoop(ctx context.Context) (earlyExit bool, _ error) {
+	for {
+		row, meta := w.input.Next()
+		if !meta.Empty() {
+			if meta.Err != nil {
+				return false, meta.Err
+			}
+			if !emitHelper(ctx, &w.out, nil /* row */, meta, w.input) {
+				// No cleanup required; emitHelper() took care of it.
+				return true, nil
+			}
+			continue
+		}
+		if row == nil {
+			break
+		}
+
+		if w.rows.Len() > 0 {
+			matched, err := w.matchLastPartition(row)
+			if err != nil {
+				return false, err
+			}
+			if !matched {
+				if earlyExit, err := w.emitPartition(ctx); earlyExit || err != nil {
+					return earlyExit, err
+				}
+			}
+		}
+		if err := w.rows.AddRow(ctx, row); err != nil {
+			return false, err
+		}
+	}
+
+	if w.rows.Len() > 0 {
+		return w.emitPartition(ctx)
+	}
+	return false, nil
+}
+
+// matchLastPartition returns whether the row belongs to the partition of the
+// buffered rows.
+func (w *windower) matchLastPartition(row sqlbase.EncDatumRow) (bool, error) {
+	last := w.rows.At(w.rows.Len() - 1)
+	for _, colIdx := range w.partitionBy {
+		if err := row[colIdx].EnsureDecoded(&w.datumAlloc); err != nil {
+			return false, err
+		}
+		if row[colIdx].Datum.Compare(&w.flowCtx.EvalCtx, last[colIdx]) != 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// emitPartition computes the window function over the buffered partition and
+// emits its rows, which are then cleared.
+func (w *windower) emitPartition(ctx context.Context) (earlyExit bool, _ error) {
+	evalCtx := &w.flowCtx.EvalCtx
+	rowCount := w.rows.Len()
+
+	// The window function accesses its arguments, followed by the ORDER BY
+	// column used to find the bounds of RANGE frames, through a contiguous
+	// slice of each row.
+	argCount := len(w.argIdxs)
+	width := argCount + 1
+	sz := uintptr(rowCount) * (uintptr(width)*unsafe.Sizeof(parser.Datum(nil)) +
+		unsafe.Sizeof(parser.IndexedRow{}))
+	if err := w.memAcc.Grow(ctx, int64(sz)); err != nil {
+		return false, err
+	}
+	datumAlloc := make(parser.Datums, rowCount*width)
+	partition := make([]parser.IndexedRow, rowCount)
+	for i := range partition {
+		src := w.rows.At(i)
+		row := datumAlloc[i*width : (i+1)*width]
+		for j, idx := range w.argIdxs {
+			row[j] = src[idx]
+		}
+		if len(w.ordering) > 0 {
+			row[argCount] = src[w.ordering[0].ColIdx]
+		}
+		partition[i] = parser.IndexedRow{Idx: i, Row: row}
+	}
+
+	builtin := w.windowConstructor(evalCtx)
+	defer builtin.Close(ctx, evalCtx)
+
+	frame := &parser.WindowFrameRun{
+		Rows:             partition,
+		ArgIdxStart:      0,
+		ArgCount:         argCount,
+		Frame:            w.frame,
+		StartBoundOffset: w.startBoundOffset,
+		EndBoundOffset:   w.endBoundOffset,
+		OrdColIdx:        argCount,
+		RowIdx:           0,
+	}
+	if len(w.ordering) > 0 {
+		frame.OrdDescending = w.ordering[0].Direction == encoding.Descending
+	}
+	for frame.RowIdx < rowCount {
+		// Compute the size of the current peer group. Without an ordering, all
+		// the rows of the partition are peers.
+		frame.FirstPeerIdx = frame.RowIdx
+		frame.PeerRowCount = 1
+		for ; frame.FirstPeerIdx+frame.PeerRowCount < rowCount; frame.PeerRowCount++ {
+			cur := frame.FirstPeerIdx + frame.PeerRowCount
+			if sqlbase.CompareDatums(w.ordering, evalCtx, w.rows.At(cur-1), w.rows.At(cur)) != 0 {
+				break
+			}
+		}
+
+		for ; frame.RowIdx < frame.FirstPeerIdx+frame.PeerRowCount; frame.RowIdx++ {
+			res, err := builtin.Compute(ctx, evalCtx, frame)
+			if err != nil {
+				return false, err
+			}
+			row := w.rows.EncRow(frame.RowIdx)
+			copy(w.outputRow, row)
+			w.outputRow[len(row)] = sqlbase.DatumToEncDatum(w.outputType, res)
+			if !emitHelper(ctx, &w.out, w.outputRow, ProducerMetadata{}, w.input) {
+				// No cleanup required; emitHelper() took care of it.
+				return true, nil
+			}
+		}
+	}
+
+	w.rows.Clear(ctx)
+	w.memAcc.Clear(ctx)
+	return false, nil
+}
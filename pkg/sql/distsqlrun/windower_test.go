@@ -0,0 +1,165 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+
+	"golang.org/x/net/context"
+)
+
+func TestWindower(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	v := [15]sqlbase.EncDatum{}
+	for i := range v {
+		v[i] = sqlbase.DatumToEncDatum(sqlbase.ColumnType{SemanticType: sqlbase.ColumnType_INT},
+			parser.NewDInt(parser.DInt(i)))
+	}
+
+	// The input is sorted on the partition column @1 followed by the ordering
+	// column @2.
+	input := sqlbase.EncDatumRows{
+		{v[1], v[1], v[1]},
+		{v[1], v[2], v[2]},
+		{v[1], v[2], v[3]},
+		{v[2], v[1], v[4]},
+		{v[2], v[3], v[5]},
+	}
+	ordering := Ordering{Columns: []Ordering_Column{{ColIdx: 1, Direction: Ordering_Column_ASC}}}
+
+	testCases := []struct {
+		spec     WindowerSpec
+		expected sqlbase.EncDatumRows
+	}{
+		{
+			// SELECT row_number() OVER (PARTITION BY @1 ORDER BY @2)
+			spec: WindowerSpec{
+				WindowFunc:  WindowerSpec_ROW_NUMBER.Enum(),
+				PartitionBy: []uint32{0},
+				Ordering:    ordering,
+			},
+			expected: sqlbase.EncDatumRows{
+				{v[1], v[1], v[1], v[1]},
+				{v[1], v[2], v[2], v[2]},
+				{v[1], v[2], v[3], v[3]},
+				{v[2], v[1], v[4], v[1]},
+				{v[2], v[3], v[5], v[2]},
+			},
+		},
+		{
+			// SELECT dense_rank() OVER (PARTITION BY @1 ORDER BY @2)
+			spec: WindowerSpec{
+				WindowFunc:  WindowerSpec_DENSE_RANK.Enum(),
+				PartitionBy: []uint32{0},
+				Ordering:    ordering,
+			},
+			expected: sqlbase.EncDatumRows{
+				{v[1], v[1], v[1], v[1]},
+				{v[1], v[2], v[2], v[2]},
+				{v[1], v[2], v[3], v[2]},
+				{v[2], v[1], v[4], v[1]},
+				{v[2], v[3], v[5], v[2]},
+			},
+		},
+		{
+			// SELECT sum_int(@3) OVER (PARTITION BY @1 ORDER BY @2)
+			spec: WindowerSpec{
+				AggregateFunc: AggregatorSpec_SUM_INT.Enum(),
+				ArgIdxs:       []uint32{2},
+				PartitionBy:   []uint32{0},
+				Ordering:      ordering,
+			},
+			expected: sqlbase.EncDatumRows{
+				{v[1], v[1], v[1], v[1]},
+				{v[1], v[2], v[2], v[6]},
+				{v[1], v[2], v[3], v[6]},
+				{v[2], v[1], v[4], v[4]},
+				{v[2], v[3], v[5], v[9]},
+			},
+		},
+		{
+			// SELECT sum_int(@3) OVER (ROWS BETWEEN 1 PRECEDING AND 1 FOLLOWING)
+			spec: WindowerSpec{
+				AggregateFunc: AggregatorSpec_SUM_INT.Enum(),
+				ArgIdxs:       []uint32{2},
+				Frame: &WindowerSpec_Frame{
+					Mode: WindowerSpec_Frame_ROWS,
+					Start: WindowerSpec_Frame_Bound{
+						BoundType: WindowerSpec_Frame_OFFSET_PRECEDING,
+						Offset:    Expression{Expr: "1"},
+					},
+					End: WindowerSpec_Frame_Bound{
+						BoundType: WindowerSpec_Frame_OFFSET_FOLLOWING,
+						Offset:    Expression{Expr: "1"},
+					},
+				},
+			},
+			expected: sqlbase.EncDatumRows{
+				{v[1], v[1], v[1], v[3]},
+				{v[1], v[2], v[2], v[6]},
+				{v[1], v[2], v[3], v[9]},
+				{v[2], v[1], v[4], v[12]},
+				{v[2], v[3], v[5], v[9]},
+			},
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run("", func(t *testing.T) {
+			ws := c.spec
+
+			in := NewRowBuffer(nil /* types */, input, RowBufferArgs{})
+			out := &RowBuffer{}
+
+			evalCtx := parser.MakeTestingEvalContext()
+			defer evalCtx.Stop(context.Background())
+			flowCtx := FlowCtx{
+				Settings: cluster.MakeTestingClusterSettings(),
+				EvalCtx:  evalCtx,
+			}
+
+			w, err := newWindower(&flowCtx, &ws, in, &PostProcessSpec{}, out)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w.Run(context.Background(), nil)
+			if !out.ProducerClosed {
+				t.Fatalf("output RowReceiver not closed")
+			}
+			var res sqlbase.EncDatumRows
+			for {
+				row, meta := out.Next()
+				if !meta.Empty() {
+					t.Fatalf("unexpected metadata: %v", meta)
+				}
+				if row == nil {
+					break
+				}
+				res = append(res, row)
+			}
+
+			if result := res.String(); result != c.expected.String() {
+				t.Errorf("invalid results: %s, expected %s'", result, c.expected.String())
+			}
+		})
+	}
+}
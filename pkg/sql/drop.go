@@ -62,7 +62,7 @@ func (p *planner) DropDatabase(ctx context.Context, n *parser.DropDatabase) (pla
 		return nil, sqlbase.NewUndefinedDatabaseError(string(n.Name))
 	}
 
-	if err := p.CheckPrivilege(dbDesc, privilege.DROP); err != nil {
+	if err := p.CheckPrivilege(ctx, dbDesc, privilege.DROP); err != nil {
 		return nil, err
 	}
 
@@ -247,7 +247,7 @@ func (p *planner) DropIndex(ctx context.Context, n *parser.DropIndex) (planNode,
 			return nil, err
 		}
 
-		if err := p.CheckPrivilege(tableDesc, privilege.CREATE); err != nil {
+		if err := p.CheckPrivilege(ctx, tableDesc, privilege.CREATE); err != nil {
 			return nil, err
 		}
 
@@ -356,6 +356,10 @@ func (p *planner) dropIndexByName(
 			droppedViews = append(droppedViews, cascadedViews...)
 		}
 	}
+	if err := removeIndexZoneConfigs(ctx, p.txn, tableDesc, idx.ID); err != nil {
+		return err
+	}
+
 	found := false
 	for i := range tableDesc.Indexes {
 		if tableDesc.Indexes[i].ID == idx.ID {
@@ -516,6 +520,82 @@ func (*dropViewNode) Next(runParams) (bool, error) { return false, nil }
 func (*dropViewNode) Close(context.Context)        {}
 func (*dropViewNode) Values() parser.Datums        { return parser.Datums{} }
 
+type dropSequenceNode struct {
+	n  *parser.DropSequence
+	td []*sqlbase.TableDescriptor
+}
+
+// DropSequence drops a sequence.
+// Privileges: DROP on sequence.
+//   Notes: postgres allows only the sequence owner to DROP a sequence.
+func (p *planner) DropSequence(ctx context.Context, n *parser.DropSequence) (planNode, error) {
+	td := make([]*sqlbase.TableDescriptor, 0, len(n.Names))
+	for _, name := range n.Names {
+		tn, err := name.NormalizeTableName()
+		if err != nil {
+			return nil, err
+		}
+		if err := tn.QualifyWithDatabase(p.session.Database); err != nil {
+			return nil, err
+		}
+
+		droppedDesc, err := p.dropTableOrViewPrepare(ctx, tn)
+		if err != nil {
+			return nil, err
+		}
+		if droppedDesc == nil {
+			if n.IfExists {
+				continue
+			}
+			// Sequence does not exist, but we want it to: error out.
+			return nil, sqlbase.NewUndefinedRelationError(tn)
+		}
+		if !droppedDesc.IsSequence() {
+			return nil, sqlbase.NewWrongObjectTypeError(tn, "sequence")
+		}
+
+		td = append(td, droppedDesc)
+	}
+
+	if len(td) == 0 {
+		return &zeroNode{}, nil
+	}
+	return &dropSequenceNode{n: n, td: td}, nil
+}
+
+func (n *dropSequenceNode) Start(params runParams) error {
+	ctx := params.ctx
+	for _, droppedDesc := range n.td {
+		// Sequences have no indexes or dependent views, so dropping one only
+		// marks it as dropped; the schema changer then deletes its value.
+		if _, err := params.p.dropTableImpl(ctx, droppedDesc); err != nil {
+			return err
+		}
+		// Log a Drop Sequence event for this sequence. This is an auditable log
+		// event and is recorded in the same transaction as the table descriptor
+		// update.
+		if err := MakeEventLogger(params.p.LeaseMgr()).InsertEventRecord(
+			ctx,
+			params.p.txn,
+			EventLogDropSequence,
+			int32(droppedDesc.ID),
+			int32(params.p.evalCtx.NodeID),
+			struct {
+				SequenceName string
+				Statement    string
+				User         string
+			}{droppedDesc.Name, n.n.String(), params.p.session.User},
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (*dropSequenceNode) Next(runParams) (bool, error) { return false, nil }
+func (*dropSequenceNode) Close(context.Context)        {}
+func (*dropSequenceNode) Values() parser.Datums        { return parser.Datums{} }
+
 type dropTableNode struct {
 	n  *parser.DropTable
 	td []*sqlbase.TableDescriptor
@@ -600,7 +680,7 @@ func (p *planner) canRemoveFK(
 	if behavior != parser.DropCascade {
 		return nil, fmt.Errorf("%q is referenced by foreign key from table %q", from, table.Name)
 	}
-	if err := p.CheckPrivilege(table, privilege.CREATE); err != nil {
+	if err := p.CheckPrivilege(ctx, table, privilege.CREATE); err != nil {
 		return nil, err
 	}
 	return table, nil
@@ -623,7 +703,7 @@ func (p *planner) canRemoveInterleave(
 		return pgerror.UnimplementedWithIssueErrorf(
 			8036, "%q is interleaved by table %q", from, table.Name)
 	}
-	if err := p.CheckPrivilege(table, privilege.CREATE); err != nil {
+	if err := p.CheckPrivilege(ctx, table, privilege.CREATE); err != nil {
 		return err
 	}
 	return nil
@@ -650,7 +730,7 @@ func (p *planner) canRemoveDependentViewGeneric(
 	if err != nil {
 		return err
 	}
-	if err := p.CheckPrivilege(viewDesc, privilege.DROP); err != nil {
+	if err := p.CheckPrivilege(ctx, viewDesc, privilege.DROP); err != nil {
 		return err
 	}
 	// If this view is depended on by other views, we have to check them as well.
@@ -773,7 +853,7 @@ func (p *planner) dropTableOrViewPrepare(
 		return nil, err
 	}
 
-	if err := p.CheckPrivilege(tableDesc, privilege.DROP); err != nil {
+	if err := p.CheckPrivilege(ctx, tableDesc, privilege.DROP); err != nil {
 		return nil, err
 	}
 	return tableDesc, nil
@@ -1037,14 +1117,21 @@ func (p *planner) getViewDescForCascade(
 }
 
 type dropUserNode struct {
-	n *parser.DropUser
+	names    parser.NameList
+	ifExists bool
+	isRole   bool
 	// The number of users deleted.
 	numDeleted int
 }
 
 func (n *dropUserNode) Start(params runParams) error {
+	opName, kind := "drop-user", "user"
+	if n.isRole {
+		opName, kind = "drop-role", "role"
+	}
+
 	numDeleted := 0
-	for _, name := range n.n.Names {
+	for _, name := range n.names {
 		normalizedUsername, err := NormalizeAndValidateUsername(string(name))
 		if err != nil {
 			return err
@@ -1059,18 +1146,35 @@ func (n *dropUserNode) Start(params runParams) error {
 		internalExecutor := InternalExecutor{LeaseManager: params.p.LeaseMgr()}
 		rowsAffected, err := internalExecutor.ExecuteStatementInTransaction(
 			params.ctx,
-			"drop-user",
+			opName,
 			params.p.txn,
-			"DELETE FROM system.users WHERE username=$1",
+			`DELETE FROM system.users WHERE username=$1 AND "isRole" = $2`,
 			normalizedUsername,
+			n.isRole,
 		)
 		if err != nil {
 			return err
 		}
 
-		if rowsAffected == 0 && !n.n.IfExists {
-			return errors.Errorf("user %s does not exist", normalizedUsername)
+		if rowsAffected == 0 {
+			if !n.ifExists {
+				return errors.Errorf("%s %s does not exist", kind, normalizedUsername)
+			}
+			continue
+		}
+
+		// Remove the memberships of the user or role, and the members of the
+		// role.
+		if _, err := internalExecutor.ExecuteStatementInTransaction(
+			params.ctx,
+			opName,
+			params.p.txn,
+			`DELETE FROM system.role_members WHERE role=$1 OR member=$1`,
+			normalizedUsername,
+		); err != nil {
+			return err
 		}
+		params.p.memberOf = nil
 
 		numDeleted += rowsAffected
 	}
@@ -1093,9 +1197,24 @@ func (p *planner) DropUser(ctx context.Context, n *parser.DropUser) (planNode, e
 		return nil, err
 	}
 
-	if err := p.CheckPrivilege(tDesc, privilege.DELETE); err != nil {
+	if err := p.CheckPrivilege(ctx, tDesc, privilege.DELETE); err != nil {
+		return nil, err
+	}
+
+	return &dropUserNode{names: n.Names, ifExists: n.IfExists}, nil
+}
+
+// DropRole drops a list of roles.
+// Privileges: DELETE on system.users.
+func (p *planner) DropRole(ctx context.Context, n *parser.DropRole) (planNode, error) {
+	tDesc, err := getTableDesc(ctx, p.txn, p.getVirtualTabler(), &parser.TableName{DatabaseName: "system", TableName: "users"})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.CheckPrivilege(ctx, tDesc, privilege.DELETE); err != nil {
 		return nil, err
 	}
 
-	return &dropUserNode{n: n}, nil
+	return &dropUserNode{names: n.Names, ifExists: n.IfExists, isRole: true}, nil
 }
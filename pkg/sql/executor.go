@@ -44,6 +44,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/stats"
 	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
@@ -285,6 +286,10 @@ type ExecutorConfig struct {
 	// Caches updated by DistSQL.
 	RangeDescriptorCache *kv.RangeDescriptorCache
 	LeaseHolderCache     *kv.LeaseHolderCache
+
+	// TableStatsCache holds the table statistics used by the planner. If nil,
+	// plans are built without statistics.
+	TableStatsCache *stats.TableStatisticsCache
 }
 
 // Organization returns the value of cluster.organization.
@@ -2353,6 +2358,7 @@ func checkResultType(typ parser.Type) error {
 	case parser.TypeInterval:
 	case parser.TypeUUID:
 	case parser.TypeINet:
+	case parser.TypeJSON:
 	case parser.TypeNameArray:
 	case parser.TypeOid:
 	case parser.TypeRegClass:
@@ -112,22 +112,7 @@ func doExpandPlan(
 		n.source.plan, err = doExpandPlan(ctx, p, params, n.source.plan)
 
 	case *joinNode:
-		n.left.plan, err = doExpandPlan(ctx, p, noParams, n.left.plan)
-		if err != nil {
-			return plan, err
-		}
-		n.right.plan, err = doExpandPlan(ctx, p, noParams, n.right.plan)
-		if err != nil {
-			return plan, err
-		}
-
-		n.mergeJoinOrdering = computeMergeJoinOrdering(
-			planPhysicalProps(n.left.plan),
-			planPhysicalProps(n.right.plan),
-			n.pred.leftEqualityIndices,
-			n.pred.rightEqualityIndices,
-		)
-		n.props = n.joinOrdering()
+		plan, err = p.expandJoin(ctx, n)
 
 	case *ordinalityNode:
 		// There may be too many columns in the required ordering. Filter them.
@@ -249,7 +234,24 @@ func doExpandPlan(
 	case *testingRelocateNode:
 		n.rows, err = doExpandPlan(ctx, p, noParams, n.rows)
 
+	case *withNode:
+		for _, cte := range n.ctes {
+			cte.plan, err = doExpandPlan(ctx, p, noParams, cte.plan)
+			if err != nil {
+				return plan, err
+			}
+		}
+		n.plan, err = doExpandPlan(ctx, p, params, n.plan)
+
+	case *recursiveCTENode:
+		n.initial, err = doExpandPlan(ctx, p, noParams, n.initial)
+		if err != nil {
+			return plan, err
+		}
+		n.recursive, err = doExpandPlan(ctx, p, noParams, n.recursive)
+
 	case *valuesNode:
+	case *cteScanNode:
 	case *alterTableNode:
 	case *cancelQueryNode:
 	case *controlJobNode:
@@ -258,10 +260,14 @@ func doExpandPlan(
 	case *createIndexNode:
 	case *createUserNode:
 	case *createViewNode:
+	case *createSequenceNode:
+	case *createStatsNode:
+	case *createChangefeedNode:
 	case *dropDatabaseNode:
 	case *dropIndexNode:
 	case *dropTableNode:
 	case *dropViewNode:
+	case *dropSequenceNode:
 	case *dropUserNode:
 	case *zeroNode:
 	case *unaryNode:
@@ -269,6 +275,7 @@ func doExpandPlan(
 	case *valueGenerator:
 	case *setNode:
 	case *setClusterSettingNode:
+	case *setZoneConfigNode:
 	case *showRangesNode:
 	case *showFingerprintsNode:
 	case *scatterNode:
@@ -570,7 +577,18 @@ func (p *planner) simplifyOrderings(plan planNode, usefulOrdering sqlbase.Column
 	case *testingRelocateNode:
 		n.rows = p.simplifyOrderings(n.rows, nil)
 
+	case *withNode:
+		for _, cte := range n.ctes {
+			cte.plan = p.simplifyOrderings(cte.plan, nil)
+		}
+		n.plan = p.simplifyOrderings(n.plan, usefulOrdering)
+
+	case *recursiveCTENode:
+		n.initial = p.simplifyOrderings(n.initial, nil)
+		n.recursive = p.simplifyOrderings(n.recursive, nil)
+
 	case *valuesNode:
+	case *cteScanNode:
 	case *alterTableNode:
 	case *cancelQueryNode:
 	case *controlJobNode:
@@ -579,10 +597,14 @@ func (p *planner) simplifyOrderings(plan planNode, usefulOrdering sqlbase.Column
 	case *createIndexNode:
 	case *createUserNode:
 	case *createViewNode:
+	case *createSequenceNode:
+	case *createStatsNode:
+	case *createChangefeedNode:
 	case *dropDatabaseNode:
 	case *dropIndexNode:
 	case *dropTableNode:
 	case *dropViewNode:
+	case *dropSequenceNode:
 	case *dropUserNode:
 	case *zeroNode:
 	case *unaryNode:
@@ -590,6 +612,7 @@ func (p *planner) simplifyOrderings(plan planNode, usefulOrdering sqlbase.Column
 	case *valueGenerator:
 	case *setNode:
 	case *setClusterSettingNode:
+	case *setZoneConfigNode:
 	case *showRangesNode:
 	case *showFingerprintsNode:
 	case *scatterNode:
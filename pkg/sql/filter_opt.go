@@ -321,6 +321,24 @@ func (p *planner) propagateFilters(
 			return plan, extraFilter, err
 		}
 
+	case *withNode:
+		for _, cte := range n.ctes {
+			if cte.plan, err = p.triggerFilterPropagation(ctx, cte.plan); err != nil {
+				return plan, extraFilter, err
+			}
+		}
+		if n.plan, err = p.triggerFilterPropagation(ctx, n.plan); err != nil {
+			return plan, extraFilter, err
+		}
+
+	case *recursiveCTENode:
+		if n.initial, err = p.triggerFilterPropagation(ctx, n.initial); err != nil {
+			return plan, extraFilter, err
+		}
+		if n.recursive, err = p.triggerFilterPropagation(ctx, n.recursive); err != nil {
+			return plan, extraFilter, err
+		}
+
 	case *alterTableNode:
 	case *cancelQueryNode:
 	case *controlJobNode:
@@ -329,16 +347,22 @@ func (p *planner) propagateFilters(
 	case *createIndexNode:
 	case *createUserNode:
 	case *createViewNode:
+	case *createSequenceNode:
+	case *createStatsNode:
+	case *createChangefeedNode:
 	case *dropDatabaseNode:
 	case *dropIndexNode:
 	case *dropTableNode:
 	case *dropViewNode:
+	case *dropSequenceNode:
 	case *dropUserNode:
 	case *hookFnNode:
 	case *valueGenerator:
 	case *valuesNode:
+	case *cteScanNode:
 	case *setNode:
 	case *setClusterSettingNode:
+	case *setZoneConfigNode:
 	case *showRangesNode:
 	case *showFingerprintsNode:
 	case *scatterNode:
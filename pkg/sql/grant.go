@@ -15,8 +15,11 @@
 package sql
 
 import (
+	"fmt"
+
 	"golang.org/x/net/context"
 
+	"github.com/cockroachdb/cockroach/pkg/security"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
@@ -34,7 +37,7 @@ func (p *planner) changePrivileges(
 	}
 
 	for _, descriptor := range descriptors {
-		if err := p.CheckPrivilege(descriptor, privilege.GRANT); err != nil {
+		if err := p.CheckPrivilege(ctx, descriptor, privilege.GRANT); err != nil {
 			return nil, err
 		}
 		privileges := descriptor.GetPrivileges()
@@ -99,3 +102,133 @@ func (p *planner) Revoke(ctx context.Context, n *parser.Revoke) (planNode, error
 		privDesc.Revoke(grantee, n.Privileges)
 	})
 }
+
+// GrantRole adds users or roles to roles.
+// Privileges: admin option on the roles, or super user.
+//   Notes: postgres allows granting roles to roles as long as no membership
+//          cycle is created. We do as well.
+func (p *planner) GrantRole(ctx context.Context, n *parser.GrantRole) (planNode, error) {
+	roles, members, err := p.checkRoleMembershipChange(ctx, n.Roles, n.Members)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, role := range roles {
+		// Granting the role to a member the role is a member of would create a
+		// membership cycle.
+		memberOf, err := p.MemberOfWithAdminOption(ctx, role)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			if _, ok := memberOf[member]; ok || role == member {
+				return nil, fmt.Errorf(
+					"making %s a member of %s would create a cycle", member, role)
+			}
+		}
+	}
+
+	// Granting a role again without the admin option doesn't remove the admin
+	// option from the membership.
+	stmt := `INSERT INTO system.role_members VALUES ($1, $2, $3) ON CONFLICT (role, member) DO NOTHING`
+	if n.AdminOption {
+		stmt = `UPSERT INTO system.role_members VALUES ($1, $2, $3)`
+	}
+	internalExecutor := InternalExecutor{LeaseManager: p.LeaseMgr()}
+	for _, role := range roles {
+		for _, member := range members {
+			if _, err := internalExecutor.ExecuteStatementInTransaction(
+				ctx, "grant-role", p.txn, stmt, role, member, n.AdminOption,
+			); err != nil {
+				return nil, err
+			}
+		}
+	}
+	p.memberOf = nil
+	return &zeroNode{}, nil
+}
+
+// RevokeRole removes users or roles from roles, or only their admin option on
+// the roles.
+// Privileges: admin option on the roles, or super user.
+func (p *planner) RevokeRole(ctx context.Context, n *parser.RevokeRole) (planNode, error) {
+	roles, members, err := p.checkRoleMembershipChange(ctx, n.Roles, n.Members)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := `DELETE FROM system.role_members WHERE role = $1 AND member = $2`
+	if n.AdminOption {
+		stmt = `UPDATE system.role_members SET "isAdmin" = false WHERE role = $1 AND member = $2`
+	}
+	internalExecutor := InternalExecutor{LeaseManager: p.LeaseMgr()}
+	for _, role := range roles {
+		for _, member := range members {
+			if _, err := internalExecutor.ExecuteStatementInTransaction(
+				ctx, "revoke-role", p.txn, stmt, role, member,
+			); err != nil {
+				return nil, err
+			}
+		}
+	}
+	p.memberOf = nil
+	return &zeroNode{}, nil
+}
+
+// checkRoleMembershipChange verifies that the session user may change the
+// membership of the given roles, and that the roles and members exist. It
+// returns the normalized names of the roles and members.
+func (p *planner) checkRoleMembershipChange(
+	ctx context.Context, roleNames, memberNames parser.NameList,
+) (roles, members []string, err error) {
+	if roles, err = normalizeUsernames(roleNames); err != nil {
+		return nil, nil, err
+	}
+	if members, err = normalizeUsernames(memberNames); err != nil {
+		return nil, nil, err
+	}
+
+	if user := p.session.User; user != security.RootUser && user != security.NodeUser {
+		memberOf, err := p.MemberOfWithAdminOption(ctx, user)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, role := range roles {
+			if !memberOf[role] {
+				return nil, nil, fmt.Errorf("%s must have admin option on role %q", user, role)
+			}
+		}
+	}
+
+	for _, role := range roles {
+		exists, isRole, err := p.lookupUserOrRole(ctx, role)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !exists || !isRole {
+			return nil, nil, fmt.Errorf("role %s does not exist", role)
+		}
+	}
+	for _, member := range members {
+		exists, _, err := p.lookupUserOrRole(ctx, member)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !exists {
+			return nil, nil, fmt.Errorf("user or role %s does not exist", member)
+		}
+	}
+	return roles, members, nil
+}
+
+func normalizeUsernames(names parser.NameList) ([]string, error) {
+	ret := make([]string, len(names))
+	for i, name := range names {
+		normalized, err := NormalizeAndValidateUsername(string(name))
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = normalized
+	}
+	return ret, nil
+}
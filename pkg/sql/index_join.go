@@ -156,13 +156,18 @@ func (p *planner) makeIndexJoin(
 	// Then, in case the index-specific part, post-split, actually
 	// refers to any additional column, we also need to prepare the
 	// mapping for these columns in colIDtoRowIndex.
-	for _, colID := range indexScan.index.ColumnIDs {
-		idx, ok := indexScan.colIdxMap[colID]
-		if !ok {
-			panic(fmt.Sprintf("Unknown column %d in index!", colID))
+	//
+	// The key of an inverted index doesn't contain the values of the indexed
+	// column, so it provides none of them.
+	if indexScan.index.Type != sqlbase.IndexDescriptor_INVERTED {
+		for _, colID := range indexScan.index.ColumnIDs {
+			idx, ok := indexScan.colIdxMap[colID]
+			if !ok {
+				panic(fmt.Sprintf("Unknown column %d in index!", colID))
+			}
+			valProvidedIndex[idx] = true
+			colIDtoRowIndex[colID] = idx
 		}
-		valProvidedIndex[idx] = true
-		colIDtoRowIndex[colID] = idx
 	}
 
 	if origScan.filter != nil {
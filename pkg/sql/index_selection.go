@@ -17,6 +17,7 @@ package sql
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"sort"
 
 	"github.com/pkg/errors"
@@ -88,9 +89,15 @@ func (p *planner) selectIndex(
 		return s, nil
 	}
 
+	s.stats = p.getTableStats(ctx, s.desc)
+
 	if s.filter == nil && analyzeOrdering == nil && s.specifiedIndex == nil {
 		// No where-clause, no ordering, and no specified index.
 		s.initOrdering(0)
+		if s.stats != nil {
+			s.estimatedRowCount = s.stats.rowCount
+			s.estimatedCost = s.stats.rowCount
+		}
 		var err error
 		s.spans, err = makeSpans(nil, s.desc, s.index)
 		if err != nil {
@@ -167,6 +174,25 @@ func (p *planner) selectIndex(
 		}
 	}
 
+	// Eliminate the inverted indexes that can't be used to restrict the keys
+	// scanned: unlike forward indexes they don't contain every row, so they
+	// can't be used to simply scan the table.
+	for i := 0; i < len(candidates); {
+		if candidates[i].index.Type == sqlbase.IndexDescriptor_INVERTED &&
+			candidates[i].invertedSpans == nil {
+			candidates[i] = candidates[len(candidates)-1]
+			candidates = candidates[:len(candidates)-1]
+		} else {
+			i++
+		}
+	}
+	if len(candidates) == 0 {
+		// The primary index is always usable. So the only way this can happen is
+		// if we had a specified index.
+		return nil, fmt.Errorf("index \"%s\" is inverted and cannot be used for this query",
+			s.specifiedIndex.Name)
+	}
+
 	if s.noIndexJoin {
 		// Eliminate non-covering indexes. We do this after the check above for
 		// constant false filter.
@@ -189,6 +215,15 @@ func (p *planner) selectIndex(
 		}
 	}
 
+	if s.stats != nil {
+		// Weigh the cost of each index by the number of rows it is expected to
+		// scan. This replaces the heuristic used in analyzeExprs.
+		for _, c := range candidates {
+			c.estimatedRowCount = c.estimateRowCount()
+			c.cost *= c.estimatedRowCount
+		}
+	}
+
 	for _, c := range candidates {
 		// Compute the prefix of the index for which we have exact constraints. This
 		// prefix is inconsequential for ordering because the values are identical.
@@ -202,8 +237,8 @@ func (p *planner) selectIndex(
 
 	if log.V(2) {
 		for i, c := range candidates {
-			log.Infof(ctx, "%d: selectIndex(%s): cost=%v constraints=%s reverse=%t",
-				i, c.index.Name, c.cost, c.constraints, c.reverse)
+			log.Infof(ctx, "%d: selectIndex(%s): cost=%v rows=%v constraints=%s reverse=%t",
+				i, c.index.Name, c.cost, c.estimatedRowCount, c.constraints, c.reverse)
 		}
 	}
 
@@ -213,18 +248,24 @@ func (p *planner) selectIndex(
 	s.index = c.index
 	s.specifiedIndex = nil
 	s.isSecondaryIndex = (c.index != &s.desc.PrimaryIndex)
-	var err error
-	s.spans, err = makeSpans(c.constraints, c.desc, c.index)
-	if err != nil {
-		return nil, errors.Wrapf(err, "constraints = %v, table ID = %d, index ID = %d",
-			c.constraints, s.desc.ID, s.index.ID)
-	}
-	if len(s.spans) == 0 {
-		// There are no spans to scan.
-		return &zeroNode{}, nil
-	}
+	if c.index.Type == sqlbase.IndexDescriptor_INVERTED {
+		// The spans of an inverted index only narrow down the candidate rows, so
+		// the filter is kept as is.
+		s.spans = c.invertedSpans
+	} else {
+		var err error
+		s.spans, err = makeSpans(c.constraints, c.desc, c.index)
+		if err != nil {
+			return nil, errors.Wrapf(err, "constraints = %v, table ID = %d, index ID = %d",
+				c.constraints, s.desc.ID, s.index.ID)
+		}
+		if len(s.spans) == 0 {
+			// There are no spans to scan.
+			return &zeroNode{}, nil
+		}
 
-	s.filter = applyIndexConstraints(&p.evalCtx, s.filter, c.constraints)
+		s.filter = applyIndexConstraints(&p.evalCtx, s.filter, c.constraints)
+	}
 	if s.filter != nil {
 		// Constraint propagation may have produced new constant sub-expressions.
 		// Propagate them and check if s.filter can be applied prematurely.
@@ -243,6 +284,15 @@ func (p *planner) selectIndex(
 	s.filterVars.Rebind(s.filter, true, false)
 
 	s.reverse = c.reverse
+	if s.stats != nil {
+		// All the rows in the spans are read, including those that are then
+		// filtered out.
+		s.estimatedCost = c.estimatedRowCount
+		s.estimatedRowCount = c.estimatedRowCount
+		if s.filter != nil {
+			s.estimatedRowCount *= defaultRangeSelectivity
+		}
+	}
 
 	var plan planNode
 	if c.covering {
@@ -344,10 +394,19 @@ type indexInfo struct {
 	covering    bool // Does the index cover the required IndexedVars?
 	reverse     bool
 	exactPrefix int
+	// invertedSpans are the spans to scan when index is an inverted index;
+	// such an index is only usable if they are set.
+	invertedSpans roachpb.Spans
+	// stats contains the statistics of the table, if there are any. If set,
+	// the cost of the index is weighed by estimatedRowCount, the number of
+	// rows scanned given the constraints.
+	stats             *tableStats
+	estimatedRowCount float64
 }
 
 func (v *indexInfo) init(s *scanNode) {
 	v.covering = v.isCoveringIndex(s)
+	v.stats = s.stats
 
 	// The base cost is the number of keys per row.
 	if v.index == &v.desc.PrimaryIndex {
@@ -368,10 +427,23 @@ func (v *indexInfo) init(s *scanNode) {
 // analyzeExprs examines the range map to determine the cost of using the
 // index.
 func (v *indexInfo) analyzeExprs(exprs []parser.TypedExprs) {
+	if v.index.Type == sqlbase.IndexDescriptor_INVERTED {
+		if err := v.makeInvertedSpans(exprs); err != nil {
+			panic(err)
+		}
+		return
+	}
+
 	if err := v.makeOrConstraints(exprs); err != nil {
 		panic(err)
 	}
 
+	if v.stats != nil {
+		// The cost is computed from the statistics instead; see
+		// estimateRowCount.
+		return
+	}
+
 	// Count the number of elements used to limit the start and end keys. We then
 	// boost the cost by what fraction of the index keys are being used. The
 	// higher the fraction, the lower the cost.
@@ -402,6 +474,91 @@ func (v *indexInfo) analyzeExprs(exprs []parser.TypedExprs) {
 	}
 }
 
+// estimateRowCount returns the number of rows of the table that are expected
+// to be scanned using the index and its constraints.
+func (v *indexInfo) estimateRowCount() float64 {
+	sel := 1.0
+	if v.index.Type == sqlbase.IndexDescriptor_INVERTED {
+		sel = defaultRangeSelectivity
+	} else if len(v.constraints) > 0 {
+		// The disjunctions may overlap; summing their selectivities gives an
+		// upper bound.
+		sel = 0
+		for _, c := range v.constraints {
+			sel += v.constraintsSelectivity(c)
+		}
+		sel = math.Min(sel, 1)
+	}
+	return math.Max(1, sel*v.stats.rowCount)
+}
+
+// constraintsSelectivity returns the fraction of the rows of the table that
+// satisfy the given constraints. Equality constraints use the number of
+// distinct values of the constrained columns; other constraints use a default
+// selectivity.
+func (v *indexInfo) constraintsSelectivity(constraints indexConstraints) float64 {
+	sel := 1.0
+	colIdx := 0
+	for _, c := range constraints {
+		numCols := c.numColumns()
+		if colIdx+numCols > len(v.index.ColumnIDs) {
+			break
+		}
+		if c.start == nil || c.start != c.end ||
+			(c.start.Operator != parser.EQ && c.start.Operator != parser.In) {
+			sel *= defaultRangeSelectivity
+			colIdx += numCols
+			continue
+		}
+		for _, colID := range v.index.ColumnIDs[colIdx : colIdx+numCols] {
+			sel *= v.stats.equalitySelectivity(colID)
+		}
+		if c.start.Operator == parser.In {
+			if t, ok := c.start.Right.(*parser.DTuple); ok {
+				sel = math.Min(1, sel*float64(len(t.D)))
+			}
+		}
+		colIdx += numCols
+	}
+	return sel
+}
+
+// makeInvertedSpans populates the indexInfo.invertedSpans field for an
+// inverted index based on the analyzed expressions. The index can only be used
+// if there is a single disjunction containing a containment constraint ("a @>
+// x") on the indexed column; the resulting span contains the index entries of
+// all the rows that may satisfy that constraint.
+func (v *indexInfo) makeInvertedSpans(orExprs []parser.TypedExprs) error {
+	if len(orExprs) != 1 {
+		return nil
+	}
+	colID := v.index.ColumnIDs[0]
+	for _, e := range orExprs[0] {
+		c, ok := e.(*parser.ComparisonExpr)
+		if !ok || c.Operator != parser.Contains {
+			continue
+		}
+		if ok, colIdx := getColVarIdx(c.Left); !ok || v.desc.Columns[colIdx].ID != colID {
+			continue
+		}
+		datum, ok := c.Right.(parser.Datum)
+		if !ok {
+			continue
+		}
+		key, ok, err := sqlbase.EncodeInvertedIndexContainmentKey(
+			datum, sqlbase.MakeIndexKeyPrefix(v.desc, v.index.ID))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		v.invertedSpans = roachpb.Spans{{Key: key, EndKey: roachpb.Key(key).PrefixEnd()}}
+		return nil
+	}
+	return nil
+}
+
 // analyzeOrdering analyzes the ordering provided by the index and determines
 // if it matches the ordering requested by the query. Non-matching orderings
 // increase the cost of using the index.
@@ -793,6 +950,11 @@ func (v *indexInfo) isCoveringIndex(scan *scanNode) bool {
 		// The primary key index always covers all of the columns.
 		return true
 	}
+	if v.index.Type == sqlbase.IndexDescriptor_INVERTED {
+		// The key of an inverted index doesn't contain the value of the indexed
+		// column, so the rows always have to be looked up in the primary index.
+		return false
+	}
 
 	for i, needed := range scan.valNeededForCol {
 		if needed {
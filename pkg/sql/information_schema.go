@@ -506,6 +506,7 @@ var (
 	tableTypeSystemView = parser.NewDString("SYSTEM VIEW")
 	tableTypeBaseTable  = parser.NewDString("BASE TABLE")
 	tableTypeView       = parser.NewDString("VIEW")
+	tableTypeSequence   = parser.NewDString("SEQUENCE")
 )
 
 var informationSchemaTablesTable = virtualSchemaTable{
@@ -524,6 +525,8 @@ CREATE TABLE information_schema.tables (
 				tableType = tableTypeSystemView
 			} else if table.IsView() {
 				tableType = tableTypeView
+			} else if table.IsSequence() {
+				tableType = tableTypeSequence
 			}
 			return addRow(
 				defString,                     // table_catalog
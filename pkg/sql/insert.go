@@ -49,9 +49,14 @@ type insertNode struct {
 	// The following fields are populated during makePlan.
 	editNodeBase
 	defaultExprs []parser.TypedExpr
+	computeExprs []parser.TypedExpr
 	n            *parser.Insert
 	checkHelper  checkHelper
 
+	// computedColsContainer holds the row the expressions of the computed
+	// columns are evaluated over.
+	computedColsContainer *sqlbase.RowIndexedVarContainer
+
 	insertCols            []sqlbase.ColumnDescriptor
 	insertColIDtoRowIndex map[sqlbase.ColumnID]int
 	tw                    tableWriter
@@ -77,6 +82,14 @@ type insertNode struct {
 func (p *planner) Insert(
 	ctx context.Context, n *parser.Insert, desiredTypes []parser.Type,
 ) (planNode, error) {
+	if n.With != nil {
+		return p.planWith(ctx, n.With, func() (planNode, error) {
+			stmt := *n
+			stmt.With = nil
+			return p.Insert(ctx, &stmt, desiredTypes)
+		})
+	}
+
 	tn, err := p.getAliasedTableName(n.Table)
 	if err != nil {
 		return nil, err
@@ -89,7 +102,7 @@ func (p *planner) Insert(
 	isUpsertReturning := false
 	if n.OnConflict != nil {
 		if !n.OnConflict.DoNothing {
-			if err := p.CheckPrivilege(en.tableDesc, privilege.UPDATE); err != nil {
+			if err := p.CheckPrivilege(ctx, en.tableDesc, privilege.UPDATE); err != nil {
 				return nil, err
 			}
 		}
@@ -101,7 +114,7 @@ func (p *planner) Insert(
 	var cols []sqlbase.ColumnDescriptor
 	// Determine which columns we're inserting into.
 	if n.DefaultValues() {
-		cols = en.tableDesc.Columns
+		cols = writableColumns(en.tableDesc.Columns)
 	} else {
 		var err error
 		if cols, err = p.processColumns(en.tableDesc, n.Columns); err != nil {
@@ -117,6 +130,9 @@ func (p *planner) Insert(
 	if err != nil {
 		return nil, err
 	}
+	// The computed columns are written after the columns whose values come
+	// from the data source or default expressions.
+	insertCols, computedCols := sqlbase.ProcessComputedColumns(cols, en.tableDesc)
 
 	var insertRows parser.SelectStatement
 	if n.DefaultValues() {
@@ -166,16 +182,27 @@ func (p *planner) Insert(
 		}
 	}
 
-	fkTables := sqlbase.TablesNeededForFKs(*en.tableDesc, sqlbase.CheckInserts)
-	if err := p.fillFKTableMap(ctx, fkTables); err != nil {
+	fkTables, err := sqlbase.TablesNeededForFKs(ctx, *en.tableDesc, sqlbase.CheckInserts, p.lookupFKTable)
+	if err != nil {
 		return nil, err
 	}
-	ri, err := sqlbase.MakeRowInserter(p.txn, en.tableDesc, fkTables, cols,
+	ri, err := sqlbase.MakeRowInserter(p.txn, en.tableDesc, fkTables, insertCols,
 		sqlbase.CheckFKs, &p.alloc)
 	if err != nil {
 		return nil, err
 	}
 
+	computedColsContainer := &sqlbase.RowIndexedVarContainer{
+		Cols:    en.tableDesc.Columns,
+		Mapping: ri.InsertColIDtoRowIndex,
+	}
+	computeExprs, err := sqlbase.MakeComputedExprs(
+		computedCols, computedColsContainer, &p.parser, &p.evalCtx,
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	var tw tableWriter
 	if n.OnConflict == nil {
 		ti := tableInserterPool.Get().(*tableInserter)
@@ -219,6 +246,9 @@ func (p *planner) Insert(
 				if err != nil {
 					return nil, err
 				}
+				if col.IsComputed() {
+					return nil, sqlbase.CannotWriteToComputedColError(col)
+				}
 				updateCols[i] = col
 			}
 
@@ -229,10 +259,21 @@ func (p *planner) Insert(
 				return nil, err
 			}
 
-			fkTables := sqlbase.TablesNeededForFKs(*en.tableDesc, sqlbase.CheckUpdates)
-			if err := p.fillFKTableMap(ctx, fkTables); err != nil {
+			fkTables, err := sqlbase.TablesNeededForFKs(ctx, *en.tableDesc, sqlbase.CheckUpdates, p.lookupFKTable)
+			if err != nil {
+				return nil, err
+			}
+
+			// The computed columns are recomputed from the updated rows.
+			updateCols, updateComputedCols := sqlbase.ProcessComputedColumns(updateCols, en.tableDesc)
+			updateComputedColsContainer := &sqlbase.RowIndexedVarContainer{Cols: en.tableDesc.Columns}
+			updateComputeExprs, err := sqlbase.MakeComputedExprs(
+				updateComputedCols, updateComputedColsContainer, &p.parser, &p.evalCtx,
+			)
+			if err != nil {
 				return nil, err
 			}
+
 			tu := tableUpserterPool.Get().(*tableUpserter)
 			*tu = tableUpserter{
 				ri:            ri,
@@ -240,11 +281,15 @@ func (p *planner) Insert(
 				alloc:         &p.alloc,
 				mon:           &p.session.TxnState.mon,
 				collectRows:   isUpsertReturning,
+				evalCtx:       &p.evalCtx,
 				fkTables:      fkTables,
 				updateCols:    updateCols,
 				conflictIndex: *conflictIndex,
 				evaler:        helper,
 				isUpsertAlias: n.OnConflict.IsUpsertAlias(),
+
+				computeExprs:          updateComputeExprs,
+				computedColsContainer: updateComputedColsContainer,
 			}
 			tw = tu
 		}
@@ -255,6 +300,8 @@ func (p *planner) Insert(
 		n:                     n,
 		editNodeBase:          en,
 		defaultExprs:          defaultExprs,
+		computeExprs:          computeExprs,
+		computedColsContainer: computedColsContainer,
 		insertCols:            ri.InsertCols,
 		insertColIDtoRowIndex: ri.InsertColIDtoRowIndex,
 		isUpsertReturning:     isUpsertReturning,
@@ -381,7 +428,10 @@ func (n *insertNode) internalNext(params runParams) (bool, error) {
 		return false, err
 	}
 
-	rowVals, err := GenerateInsertRow(n.defaultExprs, n.insertColIDtoRowIndex, n.insertCols, params.p.evalCtx, n.tableDesc, n.run.rows.Values())
+	rowVals, err := GenerateInsertRow(
+		n.defaultExprs, n.computeExprs, n.insertColIDtoRowIndex, n.insertCols,
+		params.p.evalCtx, n.tableDesc, n.run.rows.Values(), n.computedColsContainer,
+	)
 	if err != nil {
 		return false, err
 	}
@@ -417,15 +467,22 @@ func (n *insertNode) internalNext(params runParams) (bool, error) {
 }
 
 // GenerateInsertRow prepares a row tuple for insertion. It fills in default
-// expressions, verifies non-nullable columns, and checks column widths.
+// expressions, computes the values of computed columns, verifies
+// non-nullable columns, and checks column widths. The computed columns, if
+// any, are the last len(computeExprs) columns of insertCols, and their
+// expressions are bound to computedColsContainer.
 func GenerateInsertRow(
 	defaultExprs []parser.TypedExpr,
+	computeExprs []parser.TypedExpr,
 	insertColIDtoRowIndex map[sqlbase.ColumnID]int,
 	insertCols []sqlbase.ColumnDescriptor,
 	evalCtx parser.EvalContext,
 	tableDesc *sqlbase.TableDescriptor,
 	rowVals parser.Datums,
+	computedColsContainer *sqlbase.RowIndexedVarContainer,
 ) (parser.Datums, error) {
+	numNonComputedCols := len(insertCols) - len(computeExprs)
+
 	// The values for the row may be shorter than the number of columns being
 	// inserted into. Generate default values for those columns using the
 	// default expressions. This will not happen if the row tuple was produced
@@ -437,7 +494,7 @@ func GenerateInsertRow(
 		rowVals = make(parser.Datums, len(insertCols))
 		copy(rowVals, oldVals)
 
-		for i := len(oldVals); i < len(insertCols); i++ {
+		for i := len(oldVals); i < numNonComputedCols; i++ {
 			if defaultExprs == nil {
 				rowVals[i] = parser.DNull
 				continue
@@ -450,9 +507,22 @@ func GenerateInsertRow(
 		}
 	}
 
-	// Check to see if NULL is being inserted into any non-nullable column.
+	// Compute the values of the computed columns from the rest of the row.
+	if len(computeExprs) > 0 {
+		computedColsContainer.CurSourceRow = rowVals
+		for i, expr := range computeExprs {
+			d, err := expr.Eval(&evalCtx)
+			if err != nil {
+				return nil, err
+			}
+			rowVals[numNonComputedCols+i] = d
+		}
+	}
+
+	// Check to see if NULL is being inserted into any non-nullable column,
+	// including columns in the middle of being made NOT NULL.
 	for _, col := range tableDesc.Columns {
-		if !col.Nullable {
+		if !col.Nullable || tableDesc.HasPendingNotNull(col.ID) {
 			if i, ok := insertColIDtoRowIndex[col.ID]; !ok || rowVals[i] == parser.DNull {
 				return nil, sqlbase.NewNonNullViolationError(col.Name)
 			}
@@ -475,8 +545,8 @@ func (p *planner) processColumns(
 		// VisibleColumns is used here to prevent INSERT INTO <table> VALUES (...)
 		// (as opposed to INSERT INTO <table> (...) VALUES (...)) from writing
 		// hidden columns. At present, the only hidden column is the implicit rowid
-		// primary key column.
-		return tableDesc.VisibleColumns(), nil
+		// primary key column. Computed columns are never written directly.
+		return writableColumns(tableDesc.VisibleColumns()), nil
 	}
 
 	cols := make([]sqlbase.ColumnDescriptor, len(node))
@@ -495,6 +565,9 @@ func (p *planner) processColumns(
 		if err != nil {
 			return nil, err
 		}
+		if col.IsComputed() {
+			return nil, sqlbase.CannotWriteToComputedColError(col)
+		}
 
 		if _, ok := colIDSet[col.ID]; ok {
 			return nil, fmt.Errorf("multiple assignments to the same column %q", n)
@@ -506,6 +579,22 @@ func (p *planner) processColumns(
 	return cols, nil
 }
 
+// writableColumns returns the columns of cols that are not computed.
+func writableColumns(cols []sqlbase.ColumnDescriptor) []sqlbase.ColumnDescriptor {
+	for i := range cols {
+		if cols[i].IsComputed() {
+			writable := make([]sqlbase.ColumnDescriptor, 0, len(cols)-1)
+			for _, col := range cols {
+				if !col.IsComputed() {
+					writable = append(writable, col)
+				}
+			}
+			return writable
+		}
+	}
+	return cols
+}
+
 // extractInsertSource removes the parentheses around the data source of an INSERT statement.
 // If the data source is a VALUES clause not further qualified with LIMIT/OFFSET and ORDER BY,
 // the 2nd return value is a pre-casted pointer to the VALUES clause.
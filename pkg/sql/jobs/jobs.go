@@ -66,6 +66,8 @@ type Details interface{}
 var _ Details = BackupDetails{}
 var _ Details = RestoreDetails{}
 var _ Details = SchemaChangeDetails{}
+var _ Details = CreateStatsDetails{}
+var _ Details = ChangefeedDetails{}
 
 // Record stores the job fields that are not automatically managed by Job.
 type Record struct {
@@ -129,7 +131,8 @@ var WithoutCancel func()
 // remembers the assigned ID of the job in the Job. The job information is read
 // from the Record field at the time Created is called. If cancelFn is not nil,
 // the Registry will automatically acquire a lease for this job and invoke
-// cancelFn if the lease expires.
+// cancelFn if the lease expires; if the job is created in a transaction (see
+// WithTxn), the Registry only starts tracking it once the transaction commits.
 func (j *Job) Created(ctx context.Context, cancelFn func()) error {
 	payload := &Payload{
 		Description:   j.Record.Description,
@@ -140,11 +143,20 @@ func (j *Job) Created(ctx context.Context, cancelFn func()) error {
 	if cancelFn != nil {
 		payload.Lease = j.registry.newLease()
 	}
+	txn := j.txn
 	if err := j.insert(ctx, payload); err != nil {
 		return err
 	}
 	if cancelFn != nil {
 		j.cancelFn = cancelFn
+		if txn != nil {
+			txn.AddCommitTrigger(func() {
+				if err := j.registry.register(*j.id, j); err != nil {
+					log.Errorf(ctx, "job %d: %s", *j.id, err)
+				}
+			})
+			return nil
+		}
 		if err := j.registry.register(*j.id, j); err != nil {
 			return err
 		}
@@ -211,6 +223,7 @@ func isControllable(p *Payload, op string) error {
 			18139, "import jobs do not support %s", op)
 	case TypeBackup:
 	case TypeRestore:
+	case TypeChangefeed:
 	default:
 		return fmt.Errorf("%s jobs do not support %s", strings.ToLower(typ.String()), op)
 	}
@@ -407,6 +420,12 @@ func (j *Job) DB() *client.DB {
 	return j.registry.db
 }
 
+// ExecutorConfig returns the SQL executor configuration the job's registry was
+// given with SetExecutorConfig, or nil.
+func (j *Job) ExecutorConfig() interface{} {
+	return j.registry.execCfg
+}
+
 // Gossip returns the *gossip.Gossip associated with this job.
 func (j *Job) Gossip() *gossip.Gossip {
 	return j.registry.gossip
@@ -588,6 +607,10 @@ func (p *Payload) Type() Type {
 		return TypeSchemaChange
 	case *Payload_Import:
 		return TypeImport
+	case *Payload_CreateStats:
+		return TypeCreateStats
+	case *Payload_Changefeed:
+		return TypeChangefeed
 	default:
 		panic("Payload.Type called on a payload with an unknown details type")
 	}
@@ -610,6 +633,10 @@ func WrapPayloadDetails(details Details) interface {
 		return &Payload_SchemaChange{SchemaChange: &d}
 	case ImportDetails:
 		return &Payload_Import{Import: &d}
+	case CreateStatsDetails:
+		return &Payload_CreateStats{CreateStats: &d}
+	case ChangefeedDetails:
+		return &Payload_Changefeed{Changefeed: &d}
 	default:
 		panic(fmt.Sprintf("jobs.WrapPayloadDetails: unknown details type %T", d))
 	}
@@ -631,6 +658,10 @@ func (p *Payload) UnwrapDetails() (Details, error) {
 		return *d.SchemaChange, nil
 	case *Payload_Import:
 		return *d.Import, nil
+	case *Payload_CreateStats:
+		return *d.CreateStats, nil
+	case *Payload_Changefeed:
+		return *d.Changefeed, nil
 	default:
 		return nil, errors.Errorf("jobs.Payload: unsupported details type %T", d)
 	}
@@ -403,6 +403,7 @@ func TestJobLifecycle(t *testing.T) {
 		}{
 			{jobs.TypeSchemaChange, jobs.SchemaChangeDetails{}, "schema change"},
 			{jobs.TypeImport, jobs.ImportDetails{}, "import"},
+			{jobs.TypeCreateStats, jobs.CreateStatsDetails{}, "create stats"},
 		}
 		for _, tc := range testCases {
 			job, _ := createJob(tc.typ, jobs.WithoutCancel, jobs.Record{
@@ -51,6 +51,11 @@ type Registry struct {
 	nodeID    *base.NodeIDContainer
 	clusterID func() uuid.UUID
 
+	// execCfg is the configuration of the SQL executor of the node. It is
+	// opaque to this package, as the executor depends on the registry; see
+	// SetExecutorConfig.
+	execCfg interface{}
+
 	mu struct {
 		syncutil.Mutex
 		epoch int64
@@ -73,6 +78,13 @@ func MakeRegistry(
 	return r
 }
 
+// SetExecutorConfig makes the configuration of the SQL executor of the node
+// available to resumed jobs through Job.ExecutorConfig. It must be called
+// before Start.
+func (r *Registry) SetExecutorConfig(execCfg interface{}) {
+	r.execCfg = execCfg
+}
+
 // NewJob creates a new Job.
 func (r *Registry) NewJob(record Record) *Job {
 	return &Job{
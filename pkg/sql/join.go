@@ -264,14 +264,25 @@ func (p *planner) makeJoin(
 		pred:     pred,
 		columns:  info.sourceColumns,
 	}
+	n.bucketsMemAcc = p.session.TxnState.OpenAccount()
+	n.initRowContainers()
+
+	return planDataSource{
+		info: info,
+		plan: n,
+	}, nil
+}
 
+// initRowContainers creates the containers for the result rows and for the
+// rows of the right side. They depend on the columns of the join, so they
+// must be recreated if the operands of the join change.
+func (n *joinNode) initRowContainers() {
+	p := n.planner
 	n.buffer = &RowBuffer{
 		RowContainer: sqlbase.NewRowContainer(
 			p.session.TxnState.makeBoundAccount(), sqlbase.ColTypeInfoFromResCols(planColumns(n)), 0,
 		),
 	}
-
-	n.bucketsMemAcc = p.session.TxnState.OpenAccount()
 	n.buckets = buckets{
 		buckets: make(map[string]*bucket),
 		rowContainer: sqlbase.NewRowContainer(
@@ -280,11 +291,6 @@ func (p *planner) makeJoin(
 			0,
 		),
 	}
-
-	return planDataSource{
-		info: info,
-		plan: n,
-	}, nil
 }
 
 // Start implements the planNode interface.
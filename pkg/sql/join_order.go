@@ -0,0 +1,429 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+)
+
+// joinTree describes a tree of inner joins whose operands can be joined in
+// any order. The columns of the tree are numbered as in the result of the
+// root join: the columns of the leaves, from left to right.
+type joinTree struct {
+	// joins contains the join nodes of the tree in pre-order; joins[0] is the
+	// root.
+	joins []*joinNode
+	// leaves contains the operands of the tree, ordered by column offset.
+	leaves []joinLeaf
+	// equalities contains the pairs of columns that are constrained to be
+	// equal by the equality columns of the joins.
+	equalities [][2]int
+	// conds contains the conjuncts of the ON conditions of the joins.
+	conds []joinCond
+}
+
+// joinLeaf is an operand of a joinTree.
+type joinLeaf struct {
+	// side is the operand of the join node that references the leaf.
+	side *planDataSource
+	// offset is the position of the first column of the leaf in the columns
+	// of the tree.
+	offset int
+	// rows is the estimated number of rows produced by the leaf.
+	rows float64
+}
+
+// joinCond is a conjunct of the ON condition of one of the joins of a
+// joinTree.
+type joinCond struct {
+	expr parser.TypedExpr
+	// offset is the position of the first column of the join that contained
+	// the condition in the columns of the tree.
+	offset int
+	// leaves contains the indexes of the leaves referenced by the condition.
+	leaves []int
+}
+
+// isReorderable returns true if the join can be part of a joinTree.
+func (n *joinNode) isReorderable() bool {
+	return n.joinType == joinTypeInner && n.pred.numMergedEqualityColumns == 0
+}
+
+// collect adds the given join and its reorderable descendants to the tree.
+// offset is the position of the first column of the join in the tree.
+func (t *joinTree) collect(p *planner, n *joinNode, offset int) {
+	t.joins = append(t.joins, n)
+	rightOffset := offset + n.pred.numLeftCols
+	for _, s := range []struct {
+		side   *planDataSource
+		offset int
+	}{{&n.left, offset}, {&n.right, rightOffset}} {
+		if j, ok := s.side.plan.(*joinNode); ok && j.isReorderable() {
+			t.collect(p, j, s.offset)
+		} else {
+			t.leaves = append(t.leaves, joinLeaf{side: s.side, offset: s.offset})
+		}
+	}
+	for i := range n.pred.leftEqualityIndices {
+		t.equalities = append(t.equalities, [2]int{
+			offset + n.pred.leftEqualityIndices[i],
+			rightOffset + n.pred.rightEqualityIndices[i],
+		})
+	}
+	for _, e := range splitAndExpr(&p.evalCtx, n.pred.onCond, nil) {
+		if e == nil || e == parser.DBoolTrue {
+			continue
+		}
+		t.conds = append(t.conds, joinCond{expr: e, offset: offset})
+	}
+}
+
+// leafOf returns the index of the leaf that produces the given column.
+func (t *joinTree) leafOf(col int) int {
+	for i := len(t.leaves) - 1; i > 0; i-- {
+		if t.leaves[i].offset <= col {
+			return i
+		}
+	}
+	return 0
+}
+
+// initConds computes the leaves referenced by each ON condition.
+func (t *joinTree) initConds() {
+	for i := range t.conds {
+		c := &t.conds[i]
+		seen := make(map[int]bool)
+		exprCheckVars(c.expr, func(expr parser.VariableExpr) (bool, parser.Expr) {
+			if iv, ok := expr.(*parser.IndexedVar); ok {
+				if l := t.leafOf(c.offset + iv.Idx); !seen[l] {
+					seen[l] = true
+					c.leaves = append(c.leaves, l)
+				}
+			}
+			return true, expr
+		})
+	}
+}
+
+// distinctCount returns the estimated number of distinct values in the given
+// column of the tree, before any join.
+func (t *joinTree) distinctCount(col int) float64 {
+	leaf := &t.leaves[t.leafOf(col)]
+	if d, ok := planColumnDistinctCount(leaf.side.plan, col-leaf.offset); ok && d < leaf.rows {
+		return d
+	}
+	return leaf.rows
+}
+
+// estimateJoin returns the estimated number of rows of the join between a
+// set of leaves that produces the given number of rows and another leaf. The
+// second return value is true if the join has a condition that connects the
+// leaf to the set.
+func (t *joinTree) estimateJoin(inSet []bool, setRows float64, leaf int) (float64, bool) {
+	sel := 1.0
+	connected := false
+	for _, e := range t.equalities {
+		setCol, leafCol := e[0], e[1]
+		if t.leafOf(leafCol) != leaf {
+			setCol, leafCol = leafCol, setCol
+		}
+		if t.leafOf(leafCol) != leaf || !inSet[t.leafOf(setCol)] {
+			continue
+		}
+		setDistinct := t.distinctCount(setCol)
+		if setDistinct > setRows {
+			setDistinct = setRows
+		}
+		if s := joinEqualitySelectivity(setDistinct, t.distinctCount(leafCol)); s < sel {
+			sel = s
+		}
+		connected = true
+	}
+	for _, c := range t.conds {
+		if t.condApplies(c, inSet, leaf) {
+			sel *= defaultRangeSelectivity
+			connected = true
+		}
+	}
+	return setRows * t.leaves[leaf].rows * sel, connected
+}
+
+// condApplies returns true if the condition references the given leaf and
+// otherwise only leaves in the set.
+func (t *joinTree) condApplies(c joinCond, inSet []bool, leaf int) bool {
+	found := false
+	for _, l := range c.leaves {
+		if l == leaf {
+			found = true
+		} else if !inSet[l] {
+			return false
+		}
+	}
+	return found
+}
+
+// condCovered returns true if the condition only references the given leaf
+// and leaves in the set. Unlike condApplies, this is also true of conditions
+// that reference no leaf at all, or only leaves in the set.
+func (t *joinTree) condCovered(c joinCond, inSet []bool, leaf int) bool {
+	for _, l := range c.leaves {
+		if l != leaf && !inSet[l] {
+			return false
+		}
+	}
+	return true
+}
+
+// orderCost returns the estimated cost of joining the leaves in the given
+// order with a left-deep tree, which is the sum of the sizes of the
+// intermediate results.
+func (t *joinTree) orderCost(order []int) float64 {
+	inSet := make([]bool, len(t.leaves))
+	inSet[order[0]] = true
+	rows := t.leaves[order[0]].rows
+	cost := 0.0
+	for _, l := range order[1:] {
+		rows, _ = t.estimateJoin(inSet, rows, l)
+		inSet[l] = true
+		cost += rows
+	}
+	return cost
+}
+
+// chooseOrder returns the order in which the leaves should be joined, or nil
+// if the original order should be kept. The order is chosen greedily: the
+// smallest leaf first, then at each step the connected leaf that yields the
+// smallest intermediate result. Cross products are only used when no leaf is
+// connected.
+func (t *joinTree) chooseOrder() []int {
+	first := 0
+	for i := range t.leaves {
+		if t.leaves[i].rows < t.leaves[first].rows {
+			first = i
+		}
+	}
+	order := []int{first}
+	inSet := make([]bool, len(t.leaves))
+	inSet[first] = true
+	rows := t.leaves[first].rows
+	for len(order) < len(t.leaves) {
+		best, bestRows, bestConnected := -1, 0.0, false
+		for l := range t.leaves {
+			if inSet[l] {
+				continue
+			}
+			r, connected := t.estimateJoin(inSet, rows, l)
+			if best == -1 || (connected && !bestConnected) ||
+				(connected == bestConnected && r < bestRows) {
+				best, bestRows, bestConnected = l, r, connected
+			}
+		}
+		order = append(order, best)
+		inSet[best] = true
+		rows = bestRows
+	}
+
+	original := make([]int, len(t.leaves))
+	reordered := false
+	for i := range original {
+		original[i] = i
+		reordered = reordered || order[i] != i
+	}
+	if !reordered || t.orderCost(order) >= t.orderCost(original) {
+		return nil
+	}
+	return order
+}
+
+// expandJoin expands the operands of a join. Trees of inner joins are
+// reordered if the statistics of the tables show that another order
+// produces smaller intermediate results.
+func (p *planner) expandJoin(ctx context.Context, n *joinNode) (planNode, error) {
+	var t joinTree
+	if n.isReorderable() {
+		t.collect(p, n, 0)
+	} else {
+		t.joins = []*joinNode{n}
+		t.leaves = []joinLeaf{{side: &n.left}, {side: &n.right}}
+	}
+
+	canReorder := n.isReorderable()
+	for i := range t.leaves {
+		leaf := &t.leaves[i]
+		var err error
+		leaf.side.plan, err = doExpandPlan(ctx, p, noParams, leaf.side.plan)
+		if err != nil {
+			return n, err
+		}
+		var ok bool
+		leaf.rows, ok = planRowCount(leaf.side.plan, false /* useDefaults */)
+		canReorder = canReorder && ok
+	}
+
+	if canReorder {
+		t.initConds()
+		if order := t.chooseOrder(); order != nil {
+			plan, err := p.reorderJoins(ctx, &t, order)
+			if err != nil {
+				return n, err
+			}
+			return plan, nil
+		}
+	}
+
+	// Keep the original order. The joins are finalized bottom-up.
+	for i := len(t.joins) - 1; i >= 0; i-- {
+		t.joins[i].finalizeOrdering()
+	}
+	return n, nil
+}
+
+// finalizeOrdering computes the orderings of the join once its operands have
+// been expanded.
+func (n *joinNode) finalizeOrdering() {
+	n.mergeJoinOrdering = computeMergeJoinOrdering(
+		planPhysicalProps(n.left.plan),
+		planPhysicalProps(n.right.plan),
+		n.pred.leftEqualityIndices,
+		n.pred.rightEqualityIndices,
+	)
+	n.props = n.joinOrdering()
+}
+
+// reorderJoins rebuilds the tree so that the leaves are joined in the given
+// order: each join combines the result of the previous one with the next
+// leaf. The right side of a join is the one that is buffered, so the smaller
+// of the two operands is placed on the right. Each ON condition is applied by
+// the first join whose operands produce all the columns it references. The
+// join nodes of the tree are reused. A renderNode is added on top to restore
+// the original order of the columns.
+func (p *planner) reorderJoins(
+	ctx context.Context, t *joinTree, order []int,
+) (planNode, error) {
+	root := t.joins[0]
+	origColumns := root.columns
+
+	sources := make([]planDataSource, len(t.leaves))
+	for i := range t.leaves {
+		sources[i] = *t.leaves[i].side
+	}
+
+	// newPos maps the columns of the tree to the columns of the current
+	// intermediate result.
+	numCols := len(origColumns)
+	newPos := make([]int, numCols)
+	setPositions := func(leaf, base int) {
+		l := &t.leaves[leaf]
+		for i := range sources[leaf].info.sourceColumns {
+			newPos[l.offset+i] = base + i
+		}
+	}
+
+	inSet := make([]bool, len(t.leaves))
+	condUsed := make([]bool, len(t.conds))
+	cur := sources[order[0]]
+	curRows := t.leaves[order[0]].rows
+	inSet[order[0]] = true
+	setPositions(order[0], 0)
+	for k, leaf := range order[1:] {
+		left, right := cur, sources[leaf]
+		if t.leaves[leaf].rows > curRows {
+			// The new leaf is larger than the intermediate result; shift the
+			// columns of the intermediate result to the right.
+			left, right = right, left
+			for i := range newPos {
+				if inSet[t.leafOf(i)] {
+					newPos[i] += len(left.info.sourceColumns)
+				}
+			}
+			setPositions(leaf, 0)
+		} else {
+			setPositions(leaf, len(left.info.sourceColumns))
+		}
+		pred, info, err := makeCrossPredicate(left.info, right.info)
+		if err != nil {
+			return nil, err
+		}
+
+		var onCond parser.TypedExpr
+		for _, e := range t.equalities {
+			a, b := t.leafOf(e[0]), t.leafOf(e[1])
+			if !(inSet[a] && b == leaf) && !(inSet[b] && a == leaf) {
+				continue
+			}
+			eq := parser.NewTypedComparisonExpr(parser.EQ,
+				pred.iVarHelper.IndexedVar(newPos[e[0]]),
+				pred.iVarHelper.IndexedVar(newPos[e[1]]),
+			)
+			if !pred.tryAddEqualityFilter(eq, left.info, right.info) {
+				onCond = mergeConj(onCond, eq)
+			}
+		}
+		for i, c := range t.conds {
+			if condUsed[i] || !t.condCovered(c, inSet, leaf) {
+				continue
+			}
+			condUsed[i] = true
+			offset := c.offset
+			onCond = mergeConj(onCond, exprConvertVars(c.expr,
+				func(expr parser.VariableExpr) (bool, parser.Expr) {
+					if iv, ok := expr.(*parser.IndexedVar); ok {
+						return true, pred.iVarHelper.IndexedVar(newPos[offset+iv.Idx])
+					}
+					return true, expr
+				}))
+		}
+		pred.onCond = pred.iVarHelper.Rebind(onCond, true, false)
+		curRows, _ = t.estimateJoin(inSet, curRows, leaf)
+		inSet[leaf] = true
+
+		// The root join is reused for the last join of the new tree.
+		j := t.joins[len(order)-2-k]
+		j.buffer.Close(ctx)
+		j.buckets.Close(ctx)
+		j.left = left
+		j.right = right
+		j.pred = pred
+		j.columns = info.sourceColumns
+		j.initRowContainers()
+		j.finalizeOrdering()
+		cur = planDataSource{info: info, plan: j}
+	}
+	for i, used := range condUsed {
+		if !used {
+			return nil, pgerror.NewErrorf(pgerror.CodeInternalError,
+				"join condition %s was not applied", t.conds[i].expr)
+		}
+	}
+
+	r := &renderNode{
+		planner: p,
+		source: planDataSource{
+			info: newSourceInfoForSingleTable(anonymousTable, planColumns(root)),
+			plan: root,
+		},
+	}
+	r.sourceInfo = multiSourceInfo{r.source.info}
+	r.ivarHelper = parser.MakeIndexedVarHelper(r, numCols)
+	for i := range origColumns {
+		expr := r.ivarHelper.IndexedVar(newPos[i])
+		r.addRenderColumn(expr, symbolicExprStr(expr), origColumns[i])
+	}
+	r.computePhysicalProps(planPhysicalProps(root))
+	return r, nil
+}
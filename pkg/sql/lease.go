@@ -279,13 +279,13 @@ var errDidntUpdateDescriptor = errors.New("didn't update the table descriptor")
 // The update closure is called after the wait, and it provides the new version
 // of the descriptor to be written. In a multi-step schema operation, this
 // update should perform a single step.
-// The closure may be called multiple times if retries occur; make sure it does
-// not have side effects.
+// The closure may be called multiple times if retries occur; make sure its
+// only side effects are writes in the transaction it is passed.
 // Returns the updated version of the descriptor.
 func (s LeaseStore) Publish(
 	ctx context.Context,
 	tableID sqlbase.ID,
-	update func(*sqlbase.TableDescriptor) error,
+	update func(*client.Txn, *sqlbase.TableDescriptor) error,
 	logEvent func(*client.Txn) error,
 ) (*sqlbase.Descriptor, error) {
 	errLeaseVersionChanged := errors.New("lease version changed")
@@ -322,9 +322,15 @@ func (s LeaseStore) Publish(
 				return errLeaseVersionChanged
 			}
 
+			// The trigger anchors the transaction to the system config range,
+			// which must happen before the update closure writes in it.
+			if err := txn.SetSystemConfigTrigger(); err != nil {
+				return err
+			}
+
 			// Run the update closure.
 			version := tableDesc.Version
-			if err := update(tableDesc); err != nil {
+			if err := update(txn, tableDesc); err != nil {
 				return err
 			}
 			if version != tableDesc.Version {
@@ -345,9 +351,6 @@ func (s LeaseStore) Publish(
 			}
 
 			// Write the updated descriptor.
-			if err := txn.SetSystemConfigTrigger(); err != nil {
-				return err
-			}
 			b := txn.NewBatch()
 			b.Put(descKey, desc)
 			if logEvent != nil {
@@ -162,7 +162,24 @@ func applyLimit(plan planNode, numRows int64, soft bool) {
 	case *testingRelocateNode:
 		setUnlimited(n.rows)
 
+	case *withNode:
+		for _, cte := range n.ctes {
+			if cte.plan != nil {
+				setUnlimited(cte.plan)
+			}
+		}
+		applyLimit(n.plan, numRows, soft)
+
+	case *recursiveCTENode:
+		if n.initial != nil {
+			setUnlimited(n.initial)
+		}
+		if n.recursive != nil {
+			setUnlimited(n.recursive)
+		}
+
 	case *valuesNode:
+	case *cteScanNode:
 	case *alterTableNode:
 	case *cancelQueryNode:
 	case *controlJobNode:
@@ -171,10 +188,14 @@ func applyLimit(plan planNode, numRows int64, soft bool) {
 	case *createIndexNode:
 	case *createUserNode:
 	case *createViewNode:
+	case *createSequenceNode:
+	case *createStatsNode:
+	case *createChangefeedNode:
 	case *dropDatabaseNode:
 	case *dropIndexNode:
 	case *dropTableNode:
 	case *dropViewNode:
+	case *dropSequenceNode:
 	case *dropUserNode:
 	case *zeroNode:
 	case *unaryNode:
@@ -182,6 +203,7 @@ func applyLimit(plan planNode, numRows int64, soft bool) {
 	case *valueGenerator:
 	case *setNode:
 	case *setClusterSettingNode:
+	case *setZoneConfigNode:
 	case *showRangesNode:
 	case *showFingerprintsNode:
 	case *scatterNode:
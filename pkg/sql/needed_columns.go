@@ -183,6 +183,22 @@ func setNeededColumns(plan planNode, needed []bool) {
 	case *testingRelocateNode:
 		setNeededColumns(n.rows, allColumns(n.rows))
 
+	case *withNode:
+		// The results of each CTE are shared by all its references, so
+		// all the columns are needed.
+		for _, cte := range n.ctes {
+			setNeededColumns(cte.plan, allColumns(cte.plan))
+		}
+		setNeededColumns(n.plan, needed)
+
+	case *cteScanNode:
+		markOmitted(n.columns, needed)
+
+	case *recursiveCTENode:
+		// The working table needs all the columns.
+		setNeededColumns(n.initial, allColumns(n.initial))
+		setNeededColumns(n.recursive, allColumns(n.recursive))
+
 	case *alterTableNode:
 	case *cancelQueryNode:
 	case *controlJobNode:
@@ -191,10 +207,14 @@ func setNeededColumns(plan planNode, needed []bool) {
 	case *createIndexNode:
 	case *createUserNode:
 	case *createViewNode:
+	case *createSequenceNode:
+	case *createStatsNode:
+	case *createChangefeedNode:
 	case *dropDatabaseNode:
 	case *dropIndexNode:
 	case *dropTableNode:
 	case *dropViewNode:
+	case *dropSequenceNode:
 	case *dropUserNode:
 	case *zeroNode:
 	case *unaryNode:
@@ -202,6 +222,7 @@ func setNeededColumns(plan planNode, needed []bool) {
 	case *valueGenerator:
 	case *setNode:
 	case *setClusterSettingNode:
+	case *setZoneConfigNode:
 	case *showRangesNode:
 	case *showFingerprintsNode:
 	case *scatterNode:
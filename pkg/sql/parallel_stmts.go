@@ -367,13 +367,15 @@ func IsStmtParallelized(stmt Statement) bool {
 		_, ok := ret.(*parser.ReturningNothing)
 		return ok
 	}
+	// Statements with a WITH clause are never parallelized, since their
+	// CTEs may read or write arbitrary data.
 	switch s := stmt.AST.(type) {
 	case *parser.Delete:
-		return parallelizedRetClause(s.Returning)
+		return s.With == nil && parallelizedRetClause(s.Returning)
 	case *parser.Insert:
-		return parallelizedRetClause(s.Returning)
+		return s.With == nil && parallelizedRetClause(s.Returning)
 	case *parser.Update:
-		return parallelizedRetClause(s.Returning)
+		return s.With == nil && parallelizedRetClause(s.Returning)
 	}
 	return false
 }
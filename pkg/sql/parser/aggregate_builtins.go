@@ -81,6 +81,16 @@ type AggregateFunc interface {
 	Close(context.Context)
 }
 
+// removableAggregateFunc is implemented by aggregate functions which can undo
+// the accumulation of a datum. Window functions use it to slide their frame
+// over a partition without recomputing the aggregation from scratch.
+type removableAggregateFunc interface {
+	AggregateFunc
+
+	// Remove undoes a previous call to Add with the same datum.
+	Remove(_ context.Context, datum Datum) error
+}
+
 // Aggregates are a special class of builtin functions that are wrapped
 // at execution in a bucketing layer to combine (aggregate) the result
 // of the function being run over many rows.
@@ -151,7 +161,7 @@ var Aggregates = map[string][]Builtin{
 			ReturnType:    fixedReturnType(TypeInt),
 			AggregateFunc: newCountRowsAggregate,
 			WindowFunc: func(params []Type, evalCtx *EvalContext) WindowFunc {
-				return newAggregateWindow(newCountRowsAggregate(params, evalCtx))
+				return newAggregateWindow(newCountRowsAggregate, params, evalCtx)
 			},
 			Info: "Calculates the number of rows.",
 		},
@@ -261,7 +271,7 @@ func makeAggBuiltinWithReturnType(
 		ReturnType:    retType,
 		AggregateFunc: f,
 		WindowFunc: func(params []Type, evalCtx *EvalContext) WindowFunc {
-			return newAggregateWindow(f(params, evalCtx))
+			return newAggregateWindow(f, params, evalCtx)
 		},
 		Info: info,
 	}
@@ -289,6 +299,12 @@ var _ AggregateFunc = &concatAggregate{}
 var _ AggregateFunc = &bytesXorAggregate{}
 var _ AggregateFunc = &intXorAggregate{}
 
+var _ removableAggregateFunc = &intAvgAggregate{}
+var _ removableAggregateFunc = &countAggregate{}
+var _ removableAggregateFunc = &countRowsAggregate{}
+var _ removableAggregateFunc = &smallIntSumAggregate{}
+var _ removableAggregateFunc = &intSumAggregate{}
+
 // In order to render the unaggregated (i.e. grouped) fields, during aggregation,
 // the values for those fields have to be stored for each bucket.
 // The `identAggregate` provides an "aggregate" function that actually
@@ -376,7 +392,7 @@ type avgAggregate struct {
 }
 
 func newIntAvgAggregate(params []Type, evalCtx *EvalContext) AggregateFunc {
-	return &avgAggregate{agg: newIntSumAggregate(params, evalCtx)}
+	return &intAvgAggregate{avgAggregate{agg: newIntSumAggregate(params, evalCtx)}}
 }
 func newFloatAvgAggregate(params []Type, evalCtx *EvalContext) AggregateFunc {
 	return &avgAggregate{agg: newFloatSumAggregate(params, evalCtx)}
@@ -421,6 +437,24 @@ func (a *avgAggregate) Result() (Datum, error) {
 // Close is part of the AggregateFunc interface.
 func (a *avgAggregate) Close(context.Context) {}
 
+// intAvgAggregate is the avgAggregate over integers, whose underlying sum can
+// remove values.
+type intAvgAggregate struct {
+	avgAggregate
+}
+
+// Remove is part of the removableAggregateFunc interface.
+func (a *intAvgAggregate) Remove(ctx context.Context, datum Datum) error {
+	if datum == DNull {
+		return nil
+	}
+	if err := a.agg.(removableAggregateFunc).Remove(ctx, datum); err != nil {
+		return err
+	}
+	a.count--
+	return nil
+}
+
 type concatAggregate struct {
 	forBytes   bool
 	sawNonNull bool
@@ -549,6 +583,15 @@ func (a *countAggregate) Add(_ context.Context, datum Datum, _ ...Datum) error {
 	return nil
 }
 
+// Remove is part of the removableAggregateFunc interface.
+func (a *countAggregate) Remove(_ context.Context, datum Datum) error {
+	if datum == DNull {
+		return nil
+	}
+	a.count--
+	return nil
+}
+
 func (a *countAggregate) Result() (Datum, error) {
 	return NewDInt(DInt(a.count)), nil
 }
@@ -569,6 +612,12 @@ func (a *countRowsAggregate) Add(_ context.Context, _ Datum, _ ...Datum) error {
 	return nil
 }
 
+// Remove is part of the removableAggregateFunc interface.
+func (a *countRowsAggregate) Remove(context.Context, Datum) error {
+	a.count--
+	return nil
+}
+
 func (a *countRowsAggregate) Result() (Datum, error) {
 	return NewDInt(DInt(a.count)), nil
 }
@@ -651,8 +700,8 @@ func (a *MinAggregate) Result() (Datum, error) {
 func (a *MinAggregate) Close(context.Context) {}
 
 type smallIntSumAggregate struct {
-	sum         int64
-	seenNonNull bool
+	sum          int64
+	nonNullCount int
 }
 
 func newSmallIntSumAggregate(_ []Type, _ *EvalContext) AggregateFunc {
@@ -666,13 +715,24 @@ func (a *smallIntSumAggregate) Add(_ context.Context, datum Datum, _ ...Datum) e
 	}
 
 	a.sum += int64(MustBeDInt(datum))
-	a.seenNonNull = true
+	a.nonNullCount++
+	return nil
+}
+
+// Remove subtracts the value of the passed datum from the sum.
+func (a *smallIntSumAggregate) Remove(_ context.Context, datum Datum) error {
+	if datum == DNull {
+		return nil
+	}
+
+	a.sum -= int64(MustBeDInt(datum))
+	a.nonNullCount--
 	return nil
 }
 
 // Result returns the sum.
 func (a *smallIntSumAggregate) Result() (Datum, error) {
-	if !a.seenNonNull {
+	if a.nonNullCount == 0 {
 		return DNull, nil
 	}
 	return NewDInt(DInt(a.sum)), nil
@@ -685,11 +745,11 @@ type intSumAggregate struct {
 	// Either the `intSum` and `decSum` fields contains the
 	// result. Which one is used is determined by the `large` field
 	// below.
-	intSum      int64
-	decSum      DDecimal
-	tmpDec      apd.Decimal
-	large       bool
-	seenNonNull bool
+	intSum       int64
+	decSum       DDecimal
+	tmpDec       apd.Decimal
+	large        bool
+	nonNullCount int
 }
 
 func newIntSumAggregate(_ []Type, _ *EvalContext) AggregateFunc {
@@ -728,13 +788,44 @@ func (a *intSumAggregate) Add(_ context.Context, datum Datum, _ ...Datum) error
 			}
 		}
 	}
-	a.seenNonNull = true
+	a.nonNullCount++
+	return nil
+}
+
+// Remove subtracts the value of the passed datum from the sum.
+func (a *intSumAggregate) Remove(_ context.Context, datum Datum) error {
+	if datum == DNull {
+		return nil
+	}
+
+	t := int64(MustBeDInt(datum))
+	if t != 0 {
+		// As in Add, stay with a single int64 as long as the subtraction
+		// does not overflow. Negating math.MinInt64 overflows by itself.
+		if !a.large {
+			if r, ok := addWithOverflow(a.intSum, -t); ok && t != math.MinInt64 {
+				a.intSum = r
+			} else {
+				a.large = true
+				a.decSum.SetCoefficient(a.intSum)
+			}
+		}
+
+		if a.large {
+			a.tmpDec.SetCoefficient(t)
+			_, err := ExactCtx.Sub(&a.decSum.Decimal, &a.decSum.Decimal, &a.tmpDec)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	a.nonNullCount--
 	return nil
 }
 
 // Result returns the sum.
 func (a *intSumAggregate) Result() (Datum, error) {
-	if !a.seenNonNull {
+	if a.nonNullCount == 0 {
 		return DNull, nil
 	}
 	dd := &DDecimal{}
@@ -64,6 +64,8 @@ func (*AlterTableDropColumn) alterTableCmd()         {}
 func (*AlterTableDropConstraint) alterTableCmd()     {}
 func (*AlterTableDropNotNull) alterTableCmd()        {}
 func (*AlterTableSetDefault) alterTableCmd()         {}
+func (*AlterTableSetNotNull) alterTableCmd()         {}
+func (*AlterTableSetType) alterTableCmd()            {}
 func (*AlterTableValidateConstraint) alterTableCmd() {}
 
 var _ AlterTableCmd = &AlterTableAddColumn{}
@@ -72,6 +74,8 @@ var _ AlterTableCmd = &AlterTableDropColumn{}
 var _ AlterTableCmd = &AlterTableDropConstraint{}
 var _ AlterTableCmd = &AlterTableDropNotNull{}
 var _ AlterTableCmd = &AlterTableSetDefault{}
+var _ AlterTableCmd = &AlterTableSetNotNull{}
+var _ AlterTableCmd = &AlterTableSetType{}
 var _ AlterTableCmd = &AlterTableValidateConstraint{}
 
 // ColumnMutationCmd is the subset of AlterTableCmds that modify an
@@ -227,3 +231,60 @@ func (node *AlterTableDropNotNull) Format(buf *bytes.Buffer, f FmtFlags) {
 	FormatNode(buf, f, node.Column)
 	buf.WriteString(" DROP NOT NULL")
 }
+
+// AlterTableSetNotNull represents an ALTER COLUMN SET NOT NULL
+// command.
+type AlterTableSetNotNull struct {
+	columnKeyword bool
+	Column        Name
+}
+
+// GetColumn implements the ColumnMutationCmd interface.
+func (node *AlterTableSetNotNull) GetColumn() Name {
+	return node.Column
+}
+
+// Format implements the NodeFormatter interface.
+func (node *AlterTableSetNotNull) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("ALTER ")
+	if node.columnKeyword {
+		buf.WriteString("COLUMN ")
+	}
+	FormatNode(buf, f, node.Column)
+	buf.WriteString(" SET NOT NULL")
+}
+
+// AlterTableSetType represents an ALTER COLUMN [SET DATA] TYPE
+// command.
+type AlterTableSetType struct {
+	columnKeyword bool
+	setData       bool
+	Column        Name
+	ToType        ColumnType
+	// Using is the expression used to convert the existing values of the
+	// column to ToType, or nil if they are simply cast.
+	Using Expr
+}
+
+// GetColumn implements the ColumnMutationCmd interface.
+func (node *AlterTableSetType) GetColumn() Name {
+	return node.Column
+}
+
+// Format implements the NodeFormatter interface.
+func (node *AlterTableSetType) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("ALTER ")
+	if node.columnKeyword {
+		buf.WriteString("COLUMN ")
+	}
+	FormatNode(buf, f, node.Column)
+	if node.setData {
+		buf.WriteString(" SET DATA")
+	}
+	buf.WriteString(" TYPE ")
+	FormatNode(buf, f, node.ToType)
+	if node.Using != nil {
+		buf.WriteString(" USING ")
+		FormatNode(buf, f, node.Using)
+	}
+}
@@ -44,6 +44,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/ipaddr"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
@@ -87,6 +88,8 @@ const (
 	categoryMath          = "Math and Numeric"
 	categoryString        = "String and Byte"
 	categoryArray         = "Array"
+	categoryJSON          = "JSONB"
+	categorySequences     = "Sequence"
 	categorySystemInfo    = "System Info"
 )
 
@@ -308,7 +311,7 @@ var Builtins = map[string][]Builtin{
 	// NULL arguments are ignored.
 	"concat": {
 		Builtin{
-			Types:        VariadicType{TypeString},
+			Types:        VariadicType{Typ: TypeString},
 			ReturnType:   fixedReturnType(TypeString),
 			nullableArgs: true,
 			fn: func(evalCtx *EvalContext, args Datums) (Datum, error) {
@@ -331,7 +334,7 @@ var Builtins = map[string][]Builtin{
 
 	"concat_ws": {
 		Builtin{
-			Types:        VariadicType{TypeString},
+			Types:        VariadicType{Typ: TypeString},
 			ReturnType:   fixedReturnType(TypeString),
 			nullableArgs: true,
 			fn: func(evalCtx *EvalContext, args Datums) (Datum, error) {
@@ -1088,6 +1091,119 @@ CockroachDB supports the following flags:
 		},
 	},
 
+	// Sequence functions.
+
+	"nextval": {
+		Builtin{
+			Types:            ArgTypes{{"sequence_name", TypeString}},
+			ReturnType:       fixedReturnType(TypeInt),
+			category:         categorySequences,
+			impure:           true,
+			distsqlBlacklist: true,
+			fn: func(evalCtx *EvalContext, args Datums) (Datum, error) {
+				qualifiedName, err := evalSequenceName(evalCtx, args[0])
+				if err != nil {
+					return nil, err
+				}
+				res, err := evalCtx.Planner.IncrementSequence(evalCtx.Ctx(), qualifiedName)
+				if err != nil {
+					return nil, err
+				}
+				return NewDInt(DInt(res)), nil
+			},
+			Info: "Advances the given sequence and returns its new value.",
+		},
+	},
+
+	"currval": {
+		Builtin{
+			Types:            ArgTypes{{"sequence_name", TypeString}},
+			ReturnType:       fixedReturnType(TypeInt),
+			category:         categorySequences,
+			impure:           true,
+			distsqlBlacklist: true,
+			fn: func(evalCtx *EvalContext, args Datums) (Datum, error) {
+				qualifiedName, err := evalSequenceName(evalCtx, args[0])
+				if err != nil {
+					return nil, err
+				}
+				res, err := evalCtx.Planner.GetLatestValueInSessionForSequence(evalCtx.Ctx(), qualifiedName)
+				if err != nil {
+					return nil, err
+				}
+				return NewDInt(DInt(res)), nil
+			},
+			Info: "Returns the latest value obtained with nextval for this sequence in this session.",
+		},
+	},
+
+	"lastval": {
+		Builtin{
+			Types:            ArgTypes{},
+			ReturnType:       fixedReturnType(TypeInt),
+			category:         categorySequences,
+			impure:           true,
+			distsqlBlacklist: true,
+			fn: func(evalCtx *EvalContext, args Datums) (Datum, error) {
+				res, err := evalCtx.Planner.GetLastSequenceValue(evalCtx.Ctx())
+				if err != nil {
+					return nil, err
+				}
+				return NewDInt(DInt(res)), nil
+			},
+			Info: "Returns the value most recently obtained with nextval in this session.",
+		},
+	},
+
+	"setval": {
+		Builtin{
+			Types:            ArgTypes{{"sequence_name", TypeString}, {"value", TypeInt}},
+			ReturnType:       fixedReturnType(TypeInt),
+			category:         categorySequences,
+			impure:           true,
+			distsqlBlacklist: true,
+			fn: func(evalCtx *EvalContext, args Datums) (Datum, error) {
+				qualifiedName, err := evalSequenceName(evalCtx, args[0])
+				if err != nil {
+					return nil, err
+				}
+				newVal := MustBeDInt(args[1])
+				if err := evalCtx.Planner.SetSequenceValue(
+					evalCtx.Ctx(), qualifiedName, int64(newVal), true /* isCalled */); err != nil {
+					return nil, err
+				}
+				return args[1], nil
+			},
+			Info: "Sets the given sequence's current value. The next call to nextval will return " +
+				"`value` plus the sequence's increment.",
+		},
+		Builtin{
+			Types: ArgTypes{
+				{"sequence_name", TypeString}, {"value", TypeInt}, {"is_called", TypeBool},
+			},
+			ReturnType:       fixedReturnType(TypeInt),
+			category:         categorySequences,
+			impure:           true,
+			distsqlBlacklist: true,
+			fn: func(evalCtx *EvalContext, args Datums) (Datum, error) {
+				qualifiedName, err := evalSequenceName(evalCtx, args[0])
+				if err != nil {
+					return nil, err
+				}
+				isCalled := bool(*args[2].(*DBool))
+				newVal := MustBeDInt(args[1])
+				if err := evalCtx.Planner.SetSequenceValue(
+					evalCtx.Ctx(), qualifiedName, int64(newVal), isCalled); err != nil {
+					return nil, err
+				}
+				return args[1], nil
+			},
+			Info: "Sets the given sequence's current value. If `is_called` is false, the next call " +
+				"to nextval will return `value`; otherwise it will return `value` plus the " +
+				"sequence's increment.",
+		},
+	},
+
 	"experimental_uuid_v4": {uuidV4Impl},
 	"uuid_v4":              {uuidV4Impl},
 
@@ -1919,6 +2035,49 @@ CockroachDB supports the following flags:
 		}
 	}),
 
+	// JSONB functions. Every function is available under both its json_ and
+	// jsonb_ name, since there is a single JSON type.
+
+	"json_typeof":  jsonTypeOfImpl,
+	"jsonb_typeof": jsonTypeOfImpl,
+
+	"json_array_length":  jsonArrayLengthImpl,
+	"jsonb_array_length": jsonArrayLengthImpl,
+
+	"to_json":  toJSONImpl,
+	"to_jsonb": toJSONImpl,
+
+	"json_build_array":  jsonBuildArrayImpl,
+	"jsonb_build_array": jsonBuildArrayImpl,
+
+	"json_build_object":  jsonBuildObjectImpl,
+	"jsonb_build_object": jsonBuildObjectImpl,
+
+	"json_extract_path":  jsonExtractPathImpl,
+	"jsonb_extract_path": jsonExtractPathImpl,
+
+	"json_extract_path_text":  jsonExtractPathTextImpl,
+	"jsonb_extract_path_text": jsonExtractPathTextImpl,
+
+	"json_strip_nulls":  jsonStripNullsImpl,
+	"jsonb_strip_nulls": jsonStripNullsImpl,
+
+	"jsonb_pretty": {
+		Builtin{
+			Types:      ArgTypes{{"val", TypeJSON}},
+			ReturnType: fixedReturnType(TypeString),
+			fn: func(_ *EvalContext, args Datums) (Datum, error) {
+				s, err := json.Pretty(MustBeDJSON(args[0]).JSON)
+				if err != nil {
+					return nil, err
+				}
+				return NewDString(s), nil
+			},
+			category: categoryJSON,
+			Info:     "Returns the given JSON value as a STRING indented and with newlines.",
+		},
+	},
+
 	// Metadata functions.
 
 	"version": {
@@ -2248,6 +2407,17 @@ var substringImpls = []Builtin{
 	},
 }
 
+// evalSequenceName parses the name of a sequence passed as a string argument
+// to a sequence builtin and qualifies it with the current database if needed.
+func evalSequenceName(evalCtx *EvalContext, arg Datum) (*TableName, error) {
+	tn, err := ParseTableName(string(MustBeDString(arg)))
+	if err != nil {
+		return nil, err
+	}
+	return evalCtx.Planner.QualifyWithDatabase(
+		evalCtx.Ctx(), &NormalizableTableName{TableNameReference: tn})
+}
+
 var uuidV4Impl = Builtin{
 	Types:      ArgTypes{},
 	ReturnType: fixedReturnType(TypeBytes),
@@ -2314,6 +2484,266 @@ var powImpls = []Builtin{
 	},
 }
 
+var jsonTypeOfImpl = []Builtin{
+	{
+		Types:      ArgTypes{{"val", TypeJSON}},
+		ReturnType: fixedReturnType(TypeString),
+		fn: func(_ *EvalContext, args Datums) (Datum, error) {
+			return NewDString(MustBeDJSON(args[0]).Type().String()), nil
+		},
+		category: categoryJSON,
+		Info: "Returns the type of the outermost JSON value as a text string: one of " +
+			"`object`, `array`, `string`, `number`, `boolean` or `null`.",
+	},
+}
+
+var errJSONArrayLengthOfNonArray = pgerror.NewError(pgerror.CodeInvalidParameterValueError,
+	"cannot get array length of a non-array")
+
+var jsonArrayLengthImpl = []Builtin{
+	{
+		Types:      ArgTypes{{"json", TypeJSON}},
+		ReturnType: fixedReturnType(TypeInt),
+		fn: func(_ *EvalContext, args Datums) (Datum, error) {
+			elems, ok := json.AsArray(MustBeDJSON(args[0]).JSON)
+			if !ok {
+				return nil, errJSONArrayLengthOfNonArray
+			}
+			return NewDInt(DInt(len(elems))), nil
+		},
+		category: categoryJSON,
+		Info:     "Returns the number of elements in the outermost JSON array.",
+	},
+}
+
+var toJSONImpl = []Builtin{
+	{
+		Types:      ArgTypes{{"val", TypeAny}},
+		ReturnType: fixedReturnType(TypeJSON),
+		fn: func(_ *EvalContext, args Datums) (Datum, error) {
+			j, err := AsJSON(args[0])
+			if err != nil {
+				return nil, err
+			}
+			return NewDJSON(j), nil
+		},
+		category: categoryJSON,
+		Info:     "Returns the value as JSON.",
+	},
+}
+
+var jsonBuildArrayImpl = []Builtin{
+	{
+		Types:        VariadicType{Typ: TypeAny},
+		ReturnType:   fixedReturnType(TypeJSON),
+		nullableArgs: true,
+		fn: func(_ *EvalContext, args Datums) (Datum, error) {
+			elems := make([]json.JSON, len(args))
+			for i, arg := range args {
+				var err error
+				if elems[i], err = AsJSON(arg); err != nil {
+					return nil, err
+				}
+			}
+			return NewDJSON(json.FromArray(elems)), nil
+		},
+		category: categoryJSON,
+		Info:     "Builds a possibly-heterogeneously-typed JSON array out of a variadic argument list.",
+	},
+}
+
+var (
+	errJSONBuildObjectOddArgs = pgerror.NewError(pgerror.CodeInvalidParameterValueError,
+		"argument list must have even number of elements")
+	errJSONBuildObjectNullKey = pgerror.NewError(pgerror.CodeInvalidParameterValueError,
+		"argument must not be null")
+)
+
+var jsonBuildObjectImpl = []Builtin{
+	{
+		Types:        VariadicType{Typ: TypeAny},
+		ReturnType:   fixedReturnType(TypeJSON),
+		nullableArgs: true,
+		fn: func(_ *EvalContext, args Datums) (Datum, error) {
+			if len(args)%2 != 0 {
+				return nil, errJSONBuildObjectOddArgs
+			}
+			builder := json.NewObjectBuilder(len(args) / 2)
+			for i := 0; i < len(args); i += 2 {
+				if args[i] == DNull {
+					return nil, errJSONBuildObjectNullKey
+				}
+				key, err := asJSONObjectKey(args[i])
+				if err != nil {
+					return nil, err
+				}
+				val, err := AsJSON(args[i+1])
+				if err != nil {
+					return nil, err
+				}
+				builder.Add(key, val)
+			}
+			return NewDJSON(builder.Build()), nil
+		},
+		category: categoryJSON,
+		Info: "Builds a JSON object out of a variadic argument list. By convention, the " +
+			"argument list consists of alternating keys and values.",
+	},
+}
+
+var jsonExtractPathImpl = []Builtin{
+	{
+		Types:      VariadicType{FixedTypes: []Type{TypeJSON}, Typ: TypeString},
+		ReturnType: fixedReturnType(TypeJSON),
+		fn: func(_ *EvalContext, args Datums) (Datum, error) {
+			j := jsonExtractPath(args)
+			if j == nil {
+				return DNull, nil
+			}
+			return NewDJSON(j), nil
+		},
+		category: categoryJSON,
+		Info:     "Returns the JSON value pointed to by the variadic arguments.",
+	},
+}
+
+var jsonExtractPathTextImpl = []Builtin{
+	{
+		Types:      VariadicType{FixedTypes: []Type{TypeJSON}, Typ: TypeString},
+		ReturnType: fixedReturnType(TypeString),
+		fn: func(_ *EvalContext, args Datums) (Datum, error) {
+			return jsonAsText(jsonExtractPath(args)), nil
+		},
+		category: categoryJSON,
+		Info:     "Returns the JSON value as text pointed to by the variadic arguments.",
+	},
+}
+
+var jsonStripNullsImpl = []Builtin{
+	{
+		Types:      ArgTypes{{"from_json", TypeJSON}},
+		ReturnType: fixedReturnType(TypeJSON),
+		fn: func(_ *EvalContext, args Datums) (Datum, error) {
+			return NewDJSON(MustBeDJSON(args[0]).StripNulls()), nil
+		},
+		category: categoryJSON,
+		Info:     "Returns from_json with all object fields that have null values omitted. Other null values are untouched.",
+	},
+}
+
+// jsonExtractPath follows the path given by args[1:] through the JSON value
+// args[0], returning nil if the path does not exist. Path elements address
+// keys in objects and, if they are integers, elements in arrays.
+func jsonExtractPath(args Datums) json.JSON {
+	j := MustBeDJSON(args[0]).JSON
+	for _, arg := range args[1:] {
+		key := string(MustBeDString(arg))
+		switch j.Type() {
+		case json.ObjectJSONType:
+			j = j.FetchValKey(key)
+		case json.ArrayJSONType:
+			idx, err := strconv.Atoi(key)
+			if err != nil {
+				return nil
+			}
+			j = j.FetchValIdx(idx)
+		default:
+			return nil
+		}
+		if j == nil {
+			return nil
+		}
+	}
+	return j
+}
+
+// AsJSON converts a datum into a JSON value, the way to_json does. Booleans,
+// numbers, strings and arrays map to their JSON equivalent, tuples map to
+// objects with keys f1, f2, etc. and any other value is converted to a JSON
+// string containing its textual representation.
+func AsJSON(d Datum) (json.JSON, error) {
+	switch t := d.(type) {
+	case dNull:
+		return json.NullJSONValue, nil
+	case *DBool:
+		return json.FromBool(bool(*t)), nil
+	case *DInt:
+		var dec apd.Decimal
+		dec.SetInt64(int64(*t))
+		return json.FromDecimal(dec), nil
+	case *DFloat:
+		f := float64(*t)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			// JSON has no representation for non-finite numbers.
+			return json.FromString(t.String()), nil
+		}
+		var dec apd.Decimal
+		if _, err := dec.SetFloat64(f); err != nil {
+			return nil, err
+		}
+		return json.FromDecimal(dec), nil
+	case *DDecimal:
+		if t.Form != apd.Finite {
+			return json.FromString(t.String()), nil
+		}
+		return json.FromDecimal(t.Decimal), nil
+	case *DString:
+		return json.FromString(string(*t)), nil
+	case *DCollatedString:
+		return json.FromString(t.Contents), nil
+	case *DJSON:
+		return t.JSON, nil
+	case *DArray:
+		elems := make([]json.JSON, len(t.Array))
+		for i, e := range t.Array {
+			var err error
+			if elems[i], err = AsJSON(e); err != nil {
+				return nil, err
+			}
+		}
+		return json.FromArray(elems), nil
+	case *DTuple:
+		builder := json.NewObjectBuilder(len(t.D))
+		for i, e := range t.D {
+			j, err := AsJSON(e)
+			if err != nil {
+				return nil, err
+			}
+			builder.Add(fmt.Sprintf("f%d", i+1), j)
+		}
+		return builder.Build(), nil
+	case *DOidWrapper:
+		return AsJSON(t.Wrapped)
+	case *DInterval:
+		return json.FromString(t.ValueAsString()), nil
+	case *DUuid:
+		return json.FromString(t.UUID.String()), nil
+	case *DIPAddr:
+		return json.FromString(t.IPAddr.String()), nil
+	default:
+		return json.FromString(AsStringWithFlags(d, FmtBareStrings)), nil
+	}
+}
+
+// asJSONObjectKey returns the text used for d when it is used as the key of
+// a JSON object.
+func asJSONObjectKey(d Datum) (string, error) {
+	switch t := d.(type) {
+	case *DString:
+		return string(*t), nil
+	case *DCollatedString:
+		return t.Contents, nil
+	case *DArray, *DTuple, *DJSON:
+		return "", pgerror.NewErrorf(pgerror.CodeInvalidParameterValueError,
+			"key value must be scalar, not array, tuple or json")
+	}
+	j, err := AsJSON(d)
+	if err != nil {
+		return "", err
+	}
+	return *j.AsText(), nil
+}
+
 func arrayBuiltin(impl func(Type) Builtin) []Builtin {
 	result := make([]Builtin, len(TypesAnyNonArray))
 	for i, typ := range TypesAnyNonArray {
@@ -2465,7 +2895,7 @@ func feedHash(h hash.Hash, args Datums) {
 func hashBuiltin(newHash func() hash.Hash, info string) []Builtin {
 	return []Builtin{
 		{
-			Types:        VariadicType{TypeString},
+			Types:        VariadicType{Typ: TypeString},
 			ReturnType:   fixedReturnType(TypeString),
 			nullableArgs: true,
 			fn: func(_ *EvalContext, args Datums) (Datum, error) {
@@ -2476,7 +2906,7 @@ func hashBuiltin(newHash func() hash.Hash, info string) []Builtin {
 			Info: info,
 		},
 		{
-			Types:        VariadicType{TypeBytes},
+			Types:        VariadicType{Typ: TypeBytes},
 			ReturnType:   fixedReturnType(TypeString),
 			nullableArgs: true,
 			fn: func(_ *EvalContext, args Datums) (Datum, error) {
@@ -2492,7 +2922,7 @@ func hashBuiltin(newHash func() hash.Hash, info string) []Builtin {
 func hash32Builtin(newHash func() hash.Hash32, info string) []Builtin {
 	return []Builtin{
 		{
-			Types:        VariadicType{TypeString},
+			Types:        VariadicType{Typ: TypeString},
 			ReturnType:   fixedReturnType(TypeInt),
 			nullableArgs: true,
 			fn: func(_ *EvalContext, args Datums) (Datum, error) {
@@ -2503,7 +2933,7 @@ func hash32Builtin(newHash func() hash.Hash32, info string) []Builtin {
 			Info: info,
 		},
 		{
-			Types:        VariadicType{TypeBytes},
+			Types:        VariadicType{Typ: TypeBytes},
 			ReturnType:   fixedReturnType(TypeInt),
 			nullableArgs: true,
 			fn: func(_ *EvalContext, args Datums) (Datum, error) {
@@ -2518,7 +2948,7 @@ func hash32Builtin(newHash func() hash.Hash32, info string) []Builtin {
 func hash64Builtin(newHash func() hash.Hash64, info string) []Builtin {
 	return []Builtin{
 		{
-			Types:        VariadicType{TypeString},
+			Types:        VariadicType{Typ: TypeString},
 			ReturnType:   fixedReturnType(TypeInt),
 			nullableArgs: true,
 			fn: func(_ *EvalContext, args Datums) (Datum, error) {
@@ -2529,7 +2959,7 @@ func hash64Builtin(newHash func() hash.Hash64, info string) []Builtin {
 			Info: info,
 		},
 		{
-			Types:        VariadicType{TypeBytes},
+			Types:        VariadicType{Typ: TypeBytes},
 			ReturnType:   fixedReturnType(TypeInt),
 			nullableArgs: true,
 			fn: func(_ *EvalContext, args Datums) (Datum, error) {
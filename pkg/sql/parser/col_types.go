@@ -48,6 +48,7 @@ func (*TimestampTZColType) columnType()    {}
 func (*IntervalColType) columnType()       {}
 func (*UUIDColType) columnType()           {}
 func (*IPAddrColType) columnType()         {}
+func (*JSONColType) columnType()           {}
 func (*StringColType) columnType()         {}
 func (*NameColType) columnType()           {}
 func (*BytesColType) columnType()          {}
@@ -67,6 +68,7 @@ func (*TimestampTZColType) castTargetType()    {}
 func (*IntervalColType) castTargetType()       {}
 func (*UUIDColType) castTargetType()           {}
 func (*IPAddrColType) castTargetType()         {}
+func (*JSONColType) castTargetType()           {}
 func (*StringColType) castTargetType()         {}
 func (*NameColType) castTargetType()           {}
 func (*BytesColType) castTargetType()          {}
@@ -314,6 +316,23 @@ func (node *IPAddrColType) Format(buf *bytes.Buffer, f FmtFlags) {
 	buf.WriteString(node.Name)
 }
 
+// Pre-allocated immutable JSON column types.
+var (
+	jsonColTypeJSON  = &JSONColType{Name: "JSON"}
+	jsonColTypeJSONB = &JSONColType{Name: "JSONB"}
+)
+
+// JSONColType represents the JSON column type. JSON and JSONB are
+// synonyms; both use the binary representation.
+type JSONColType struct {
+	Name string
+}
+
+// Format implements the NodeFormatter interface.
+func (node *JSONColType) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString(node.Name)
+}
+
 // Pre-allocated immutable string column types.
 var (
 	stringColTypeChar    = &StringColType{Name: "CHAR"}
@@ -496,6 +515,7 @@ func (node *TimestampTZColType) String() string    { return AsString(node) }
 func (node *IntervalColType) String() string       { return AsString(node) }
 func (node *UUIDColType) String() string           { return AsString(node) }
 func (node *IPAddrColType) String() string         { return AsString(node) }
+func (node *JSONColType) String() string           { return AsString(node) }
 func (node *StringColType) String() string         { return AsString(node) }
 func (node *NameColType) String() string           { return AsString(node) }
 func (node *BytesColType) String() string          { return AsString(node) }
@@ -527,6 +547,8 @@ func DatumTypeToColumnType(t Type) (ColumnType, error) {
 		return uuidColTypeUUID, nil
 	case TypeINet:
 		return ipnetColTypeINet, nil
+	case TypeJSON:
+		return jsonColTypeJSONB, nil
 	case TypeDate:
 		return dateColTypeDate, nil
 	case TypeString:
@@ -591,6 +613,8 @@ func CastTargetToDatumType(t CastTargetType) Type {
 		return TypeUUID
 	case *IPAddrColType:
 		return TypeINet
+	case *JSONColType:
+		return TypeJSON
 	case *CollatedStringColType:
 		return TCollatedString{Locale: ct.Locale}
 	case *ArrayColType:
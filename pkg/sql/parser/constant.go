@@ -351,6 +351,7 @@ var (
 		TypeInterval,
 		TypeUUID,
 		TypeINet,
+		TypeJSON,
 	}
 	strValAvailBytesString = []Type{TypeBytes, TypeString, TypeUUID, TypeINet}
 	strValAvailBytes       = []Type{TypeBytes, TypeUUID}
@@ -427,6 +428,8 @@ func (expr *StrVal) ResolveAsType(ctx *SemaContext, typ Type) (Datum, error) {
 		return ParseDDate(expr.s, ctx.getLocation())
 	case TypeINet:
 		return ParseDIPAddrFromINetString(expr.s)
+	case TypeJSON:
+		return ParseDJSON(expr.s)
 	case TypeTimestamp:
 		return ParseDTimestamp(expr.s, time.Microsecond)
 	case TypeTimestampTZ:
@@ -100,12 +100,14 @@ type CreateIndex struct {
 	Name        Name
 	Table       NormalizableTableName
 	Unique      bool
+	Inverted    bool
 	IfNotExists bool
 	Columns     IndexElemList
 	// Extra columns to be stored together with the indexed ones as an optimization
 	// for improved reading performance.
-	Storing    NameList
-	Interleave *InterleaveDef
+	Storing     NameList
+	Interleave  *InterleaveDef
+	PartitionBy *PartitionBy
 }
 
 // Format implements the NodeFormatter interface.
@@ -114,6 +116,9 @@ func (node *CreateIndex) Format(buf *bytes.Buffer, f FmtFlags) {
 	if node.Unique {
 		buf.WriteString("UNIQUE ")
 	}
+	if node.Inverted {
+		buf.WriteString("INVERTED ")
+	}
 	buf.WriteString("INDEX ")
 	if node.IfNotExists {
 		buf.WriteString("IF NOT EXISTS ")
@@ -135,6 +140,9 @@ func (node *CreateIndex) Format(buf *bytes.Buffer, f FmtFlags) {
 	if node.Interleave != nil {
 		FormatNode(buf, f, node.Interleave)
 	}
+	if node.PartitionBy != nil {
+		FormatNode(buf, f, node.PartitionBy)
+	}
 }
 
 // TableDef represents a column, index or constraint definition within a CREATE
@@ -196,12 +204,17 @@ type ColumnTableDef struct {
 		Table          NormalizableTableName
 		Col            Name
 		ConstraintName Name
+		Actions        ReferenceActions
 	}
 	Family struct {
 		Name        Name
 		Create      bool
 		IfNotExists bool
 	}
+	Computed struct {
+		Computed bool
+		Expr     Expr
+	}
 }
 
 // ColumnTableDefCheckExpr represents a check constraint on a column definition
@@ -292,6 +305,7 @@ func newColumnTableDef(
 			d.References.Table = t.Table
 			d.References.Col = t.Col
 			d.References.ConstraintName = c.Name
+			d.References.Actions = t.Actions
 		case *ColumnFamilyConstraint:
 			if d.HasColumnFamily() {
 				return nil, pgerror.NewErrorf(pgerror.CodeInvalidTableDefinitionError,
@@ -300,6 +314,9 @@ func newColumnTableDef(
 			d.Family.Name = t.Family
 			d.Family.Create = t.Create
 			d.Family.IfNotExists = t.IfNotExists
+		case *ColumnComputedDef:
+			d.Computed.Computed = true
+			d.Computed.Expr = t.Expr
 		default:
 			panic(fmt.Sprintf("unexpected column qualification: %T", c))
 		}
@@ -326,6 +343,11 @@ func (node *ColumnTableDef) HasColumnFamily() bool {
 	return node.Family.Name != "" || node.Family.Create
 }
 
+// IsComputed returns if the ColumnTableDef is a computed column.
+func (node *ColumnTableDef) IsComputed() bool {
+	return node.Computed.Computed
+}
+
 // Format implements the NodeFormatter interface.
 func (node *ColumnTableDef) Format(buf *bytes.Buffer, f FmtFlags) {
 	FormatNode(buf, f, node.Name)
@@ -375,6 +397,7 @@ func (node *ColumnTableDef) Format(buf *bytes.Buffer, f FmtFlags) {
 			FormatNode(buf, f, node.References.Col)
 			buf.WriteByte(')')
 		}
+		FormatNode(buf, f, node.References.Actions)
 	}
 	if node.HasColumnFamily() {
 		if node.Family.Create {
@@ -389,6 +412,11 @@ func (node *ColumnTableDef) Format(buf *bytes.Buffer, f FmtFlags) {
 			FormatNode(buf, f, node.Family.Name)
 		}
 	}
+	if node.IsComputed() {
+		buf.WriteString(" AS (")
+		FormatNode(buf, f, node.Computed.Expr)
+		buf.WriteString(") STORED")
+	}
 }
 
 // NamedColumnQualification wraps a NamedColumnQualification with a name.
@@ -411,6 +439,7 @@ func (UniqueConstraint) columnQualification()        {}
 func (*ColumnCheckConstraint) columnQualification()  {}
 func (*ColumnFKConstraint) columnQualification()     {}
 func (*ColumnFamilyConstraint) columnQualification() {}
+func (*ColumnComputedDef) columnQualification()      {}
 
 // ColumnCollation represents a COLLATE clause for a column.
 type ColumnCollation string
@@ -439,8 +468,9 @@ type ColumnCheckConstraint struct {
 
 // ColumnFKConstraint represents a FK-constaint on a column.
 type ColumnFKConstraint struct {
-	Table NormalizableTableName
-	Col   Name // empty-string means use PK
+	Table   NormalizableTableName
+	Col     Name // empty-string means use PK
+	Actions ReferenceActions
 }
 
 // ColumnFamilyConstraint represents FAMILY on a column.
@@ -450,13 +480,20 @@ type ColumnFamilyConstraint struct {
 	IfNotExists bool
 }
 
+// ColumnComputedDef represents the description of a computed column.
+type ColumnComputedDef struct {
+	Expr Expr
+}
+
 // IndexTableDef represents an index definition within a CREATE TABLE
 // statement.
 type IndexTableDef struct {
-	Name       Name
-	Columns    IndexElemList
-	Storing    NameList
-	Interleave *InterleaveDef
+	Name        Name
+	Columns     IndexElemList
+	Storing     NameList
+	Interleave  *InterleaveDef
+	Inverted    bool
+	PartitionBy *PartitionBy
 }
 
 func (node *IndexTableDef) setName(name Name) {
@@ -465,6 +502,9 @@ func (node *IndexTableDef) setName(name Name) {
 
 // Format implements the NodeFormatter interface.
 func (node *IndexTableDef) Format(buf *bytes.Buffer, f FmtFlags) {
+	if node.Inverted {
+		buf.WriteString("INVERTED ")
+	}
 	buf.WriteString("INDEX ")
 	if node.Name != "" {
 		FormatNode(buf, f, node.Name)
@@ -481,6 +521,9 @@ func (node *IndexTableDef) Format(buf *bytes.Buffer, f FmtFlags) {
 	if node.Interleave != nil {
 		FormatNode(buf, f, node.Interleave)
 	}
+	if node.PartitionBy != nil {
+		FormatNode(buf, f, node.PartitionBy)
+	}
 }
 
 // ConstraintTableDef represents a constraint definition within a CREATE TABLE
@@ -524,6 +567,9 @@ func (node *UniqueConstraintTableDef) Format(buf *bytes.Buffer, f FmtFlags) {
 	if node.Interleave != nil {
 		FormatNode(buf, f, node.Interleave)
 	}
+	if node.PartitionBy != nil {
+		FormatNode(buf, f, node.PartitionBy)
+	}
 }
 
 // ForeignKeyConstraintTableDef represents a FOREIGN KEY constraint in the AST.
@@ -532,6 +578,7 @@ type ForeignKeyConstraintTableDef struct {
 	Table    NormalizableTableName
 	FromCols NameList
 	ToCols   NameList
+	Actions  ReferenceActions
 }
 
 // Format implements the NodeFormatter interface.
@@ -552,6 +599,7 @@ func (node *ForeignKeyConstraintTableDef) Format(buf *bytes.Buffer, f FmtFlags)
 		FormatNode(buf, f, node.ToCols)
 		buf.WriteByte(')')
 	}
+	FormatNode(buf, f, node.Actions)
 }
 
 func (node *ForeignKeyConstraintTableDef) setName(name Name) {
@@ -561,6 +609,50 @@ func (node *ForeignKeyConstraintTableDef) setName(name Name) {
 func (*ForeignKeyConstraintTableDef) tableDef()           {}
 func (*ForeignKeyConstraintTableDef) constraintTableDef() {}
 
+// ReferenceAction is the action taken to maintain referential integrity when
+// a row referenced by a foreign key is deleted or updated.
+type ReferenceAction int
+
+// The values for ReferenceAction.
+const (
+	NoAction ReferenceAction = iota
+	Restrict
+	SetNull
+	SetDefault
+	Cascade
+)
+
+var referenceActionName = [...]string{
+	NoAction:   "NO ACTION",
+	Restrict:   "RESTRICT",
+	SetNull:    "SET NULL",
+	SetDefault: "SET DEFAULT",
+	Cascade:    "CASCADE",
+}
+
+func (ra ReferenceAction) String() string {
+	return referenceActionName[ra]
+}
+
+// ReferenceActions contains the actions of a foreign key for deletes and
+// updates of the referenced rows.
+type ReferenceActions struct {
+	Delete ReferenceAction
+	Update ReferenceAction
+}
+
+// Format implements the NodeFormatter interface.
+func (node ReferenceActions) Format(buf *bytes.Buffer, f FmtFlags) {
+	if node.Delete != NoAction {
+		buf.WriteString(" ON DELETE ")
+		buf.WriteString(node.Delete.String())
+	}
+	if node.Update != NoAction {
+		buf.WriteString(" ON UPDATE ")
+		buf.WriteString(node.Update.String())
+	}
+}
+
 func (*CheckConstraintTableDef) tableDef()           {}
 func (*CheckConstraintTableDef) constraintTableDef() {}
 
@@ -636,11 +728,82 @@ func (node *InterleaveDef) Format(buf *bytes.Buffer, f FmtFlags) {
 	}
 }
 
+// PartitionBy represents a PARTITION BY definition within a CREATE TABLE or
+// CREATE INDEX statement. Exactly one of List and Range is set.
+type PartitionBy struct {
+	Fields NameList
+	List   []ListPartition
+	Range  []RangePartition
+}
+
+// Format implements the NodeFormatter interface.
+func (node *PartitionBy) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString(" PARTITION BY ")
+	if node.List != nil {
+		buf.WriteString("LIST")
+	} else {
+		buf.WriteString("RANGE")
+	}
+	buf.WriteString(" (")
+	FormatNode(buf, f, node.Fields)
+	buf.WriteString(") (")
+	for i, p := range node.List {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		FormatNode(buf, f, p)
+	}
+	for i, p := range node.Range {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		FormatNode(buf, f, p)
+	}
+	buf.WriteByte(')')
+}
+
+// ListPartition represents a PARTITION definition within a PARTITION BY LIST.
+type ListPartition struct {
+	Name  Name
+	Exprs Exprs
+}
+
+// Format implements the NodeFormatter interface.
+func (node ListPartition) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("PARTITION ")
+	FormatNode(buf, f, node.Name)
+	buf.WriteString(" VALUES IN (")
+	FormatNode(buf, f, node.Exprs)
+	buf.WriteByte(')')
+}
+
+// RangePartition represents a PARTITION definition within a PARTITION BY
+// RANGE. A nil Exprs represents MAXVALUE.
+type RangePartition struct {
+	Name  Name
+	Exprs Exprs
+}
+
+// Format implements the NodeFormatter interface.
+func (node RangePartition) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("PARTITION ")
+	FormatNode(buf, f, node.Name)
+	buf.WriteString(" VALUES < ")
+	if node.Exprs == nil {
+		buf.WriteString("MAXVALUE")
+		return
+	}
+	buf.WriteByte('(')
+	FormatNode(buf, f, node.Exprs)
+	buf.WriteByte(')')
+}
+
 // CreateTable represents a CREATE TABLE statement.
 type CreateTable struct {
 	IfNotExists   bool
 	Table         NormalizableTableName
 	Interleave    *InterleaveDef
+	PartitionBy   *PartitionBy
 	Defs          TableDefs
 	AsSource      *Select
 	AsColumnNames NameList // Only to be used in conjunction with AsSource
@@ -674,6 +837,9 @@ func (node *CreateTable) Format(buf *bytes.Buffer, f FmtFlags) {
 		if node.Interleave != nil {
 			FormatNode(buf, f, node.Interleave)
 		}
+		if node.PartitionBy != nil {
+			FormatNode(buf, f, node.PartitionBy)
+		}
 	}
 }
 
@@ -702,6 +868,21 @@ func (node *CreateUser) Format(buf *bytes.Buffer, f FmtFlags) {
 	}
 }
 
+// CreateRole represents a CREATE ROLE statement.
+type CreateRole struct {
+	Name        Name
+	IfNotExists bool
+}
+
+// Format implements the NodeFormatter interface.
+func (node *CreateRole) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("CREATE ROLE ")
+	if node.IfNotExists {
+		buf.WriteString("IF NOT EXISTS ")
+	}
+	FormatNode(buf, f, node.Name)
+}
+
 // CreateView represents a CREATE VIEW statement.
 type CreateView struct {
 	Name        NormalizableTableName
@@ -724,3 +905,108 @@ func (node *CreateView) Format(buf *bytes.Buffer, f FmtFlags) {
 	buf.WriteString(" AS ")
 	FormatNode(buf, f, node.AsSource)
 }
+
+// CreateSequence represents a CREATE SEQUENCE statement.
+type CreateSequence struct {
+	IfNotExists bool
+	Name        NormalizableTableName
+	Options     SequenceOptions
+}
+
+// Format implements the NodeFormatter interface.
+func (node *CreateSequence) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("CREATE SEQUENCE ")
+	if node.IfNotExists {
+		buf.WriteString("IF NOT EXISTS ")
+	}
+	FormatNode(buf, f, &node.Name)
+	FormatNode(buf, f, node.Options)
+}
+
+// CreateStats represents a CREATE STATISTICS statement.
+type CreateStats struct {
+	Name        Name
+	ColumnNames NameList
+	Table       NormalizableTableName
+}
+
+// Format implements the NodeFormatter interface.
+func (node *CreateStats) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("CREATE STATISTICS ")
+	FormatNode(buf, f, node.Name)
+	buf.WriteString(" ON ")
+	FormatNode(buf, f, node.ColumnNames)
+	buf.WriteString(" FROM ")
+	FormatNode(buf, f, &node.Table)
+}
+
+// CreateChangefeed represents a CREATE CHANGEFEED statement.
+type CreateChangefeed struct {
+	Targets TargetList
+	SinkURI Expr
+}
+
+// Format implements the NodeFormatter interface.
+func (node *CreateChangefeed) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("CREATE CHANGEFEED FOR ")
+	if node.Targets.Databases == nil {
+		buf.WriteString("TABLE ")
+	}
+	FormatNode(buf, f, node.Targets)
+	buf.WriteString(" INTO ")
+	FormatNode(buf, f, node.SinkURI)
+}
+
+// SequenceOptions represents a list of sequence options.
+type SequenceOptions []SequenceOption
+
+// Format implements the NodeFormatter interface.
+func (node SequenceOptions) Format(buf *bytes.Buffer, f FmtFlags) {
+	for _, option := range node {
+		buf.WriteByte(' ')
+		switch option.Name {
+		case SeqOptNoCycle:
+			buf.WriteString(option.Name)
+		case SeqOptIncrement, SeqOptMinValue, SeqOptMaxValue, SeqOptStart:
+			if option.IntVal == nil {
+				// NO MINVALUE and NO MAXVALUE.
+				buf.WriteString("NO ")
+				buf.WriteString(option.Name)
+				continue
+			}
+			buf.WriteString(option.Name)
+			buf.WriteByte(' ')
+			if option.OptionalWord {
+				if option.Name == SeqOptIncrement {
+					buf.WriteString("BY ")
+				} else {
+					buf.WriteString("WITH ")
+				}
+			}
+			fmt.Fprintf(buf, "%d", *option.IntVal)
+		default:
+			panic(fmt.Sprintf("unexpected SequenceOption: %v", option))
+		}
+	}
+}
+
+// SequenceOption represents an option on a CREATE SEQUENCE statement.
+type SequenceOption struct {
+	Name string
+
+	// IntVal is nil for NO MINVALUE and NO MAXVALUE.
+	IntVal *int64
+
+	// OptionalWord is set if the optional BY (for INCREMENT) or WITH (for
+	// START) keyword was specified.
+	OptionalWord bool
+}
+
+// Names of options on CREATE SEQUENCE.
+const (
+	SeqOptNoCycle   = "NO CYCLE"
+	SeqOptIncrement = "INCREMENT"
+	SeqOptMinValue  = "MINVALUE"
+	SeqOptMaxValue  = "MAXVALUE"
+	SeqOptStart     = "START"
+)
@@ -38,6 +38,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/ipaddr"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/uint128"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
@@ -1271,6 +1272,145 @@ func (d *DIPAddr) Size() uintptr {
 	return unsafe.Sizeof(*d)
 }
 
+// DJSON is the JSON Datum.
+type DJSON struct {
+	json.JSON
+}
+
+// NewDJSON is a helper routine to create a *DJSON initialized from its
+// argument.
+func NewDJSON(j json.JSON) *DJSON {
+	return &DJSON{j}
+}
+
+// ParseDJSON parses and returns the *DJSON Datum value represented by the
+// provided string, or an error.
+func ParseDJSON(s string) (*DJSON, error) {
+	j, err := json.ParseJSON(s)
+	if err != nil {
+		return nil, makeParseError(s, TypeJSON, err)
+	}
+	return NewDJSON(j), nil
+}
+
+// AsDJSON attempts to retrieve a *DJSON from an Expr, returning a *DJSON and
+// a flag signifying whether the assertion was successful.
+func AsDJSON(e Expr) (*DJSON, bool) {
+	switch t := e.(type) {
+	case *DJSON:
+		return t, true
+	case *DOidWrapper:
+		return AsDJSON(t.Wrapped)
+	}
+	return nil, false
+}
+
+// MustBeDJSON attempts to retrieve a DJSON from an Expr, panicking if the
+// assertion fails.
+func MustBeDJSON(e Expr) DJSON {
+	i, ok := AsDJSON(e)
+	if !ok {
+		panic(pgerror.NewErrorf(pgerror.CodeInternalError, "expected *DJSON, found %T", e))
+	}
+	return *i
+}
+
+// ResolvedType implements the TypedExpr interface.
+func (*DJSON) ResolvedType() Type {
+	return TypeJSON
+}
+
+// Compare implements the Datum interface.
+func (d *DJSON) Compare(ctx *EvalContext, other Datum) int {
+	if other == DNull {
+		// NULL is less than any non-NULL value.
+		return 1
+	}
+	v, ok := other.(*DJSON)
+	if !ok {
+		panic(makeUnsupportedComparisonMessage(d, other))
+	}
+	return d.JSON.Compare(v.JSON)
+}
+
+// Prev implements the Datum interface.
+func (d *DJSON) Prev() (Datum, bool) {
+	return nil, false
+}
+
+// Next implements the Datum interface.
+func (d *DJSON) Next() (Datum, bool) {
+	return nil, false
+}
+
+// IsMax implements the Datum interface.
+func (d *DJSON) IsMax() bool {
+	return false
+}
+
+// IsMin implements the Datum interface.
+func (d *DJSON) IsMin() bool {
+	return d.JSON == json.NullJSONValue
+}
+
+// max implements the Datum interface.
+func (d *DJSON) max() (Datum, bool) {
+	return nil, false
+}
+
+// min implements the Datum interface.
+func (d *DJSON) min() (Datum, bool) {
+	return &DJSON{json.NullJSONValue}, true
+}
+
+// AmbiguousFormat implements the Datum interface.
+func (*DJSON) AmbiguousFormat() bool {
+	return true
+}
+
+// Format implements the NodeFormatter interface.
+func (d *DJSON) Format(buf *bytes.Buffer, f FmtFlags) {
+	if f.withinArray {
+		encodeSQLStringInsideArray(buf, d.JSON.String())
+	} else {
+		encodeSQLStringWithFlags(buf, d.JSON.String(), f)
+	}
+}
+
+// IsComposite implements the CompositeDatum interface. A JSON value is
+// composite if any of the numbers it contains is.
+func (d *DJSON) IsComposite() bool {
+	return jsonIsComposite(d.JSON)
+}
+
+func jsonIsComposite(j json.JSON) bool {
+	switch j.Type() {
+	case json.NumberJSONType:
+		dec, _ := json.AsDecimal(j)
+		return (&DDecimal{Decimal: *dec}).IsComposite()
+	case json.ArrayJSONType:
+		elems, _ := json.AsArray(j)
+		for _, e := range elems {
+			if jsonIsComposite(e) {
+				return true
+			}
+		}
+	case json.ObjectJSONType:
+		keys, _ := json.ObjectKeys(j)
+		for _, k := range keys {
+			if jsonIsComposite(j.FetchValKey(k)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Size implements the Datum interface.
+func (d *DJSON) Size() uintptr {
+	return unsafe.Sizeof(*d) + d.JSON.Size()
+}
+
 // DDate is the date Datum represented as the number of days after
 // the Unix epoch.
 type DDate int64
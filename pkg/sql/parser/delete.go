@@ -24,6 +24,7 @@ import "bytes"
 
 // Delete represents a DELETE statement.
 type Delete struct {
+	With      *With
 	Table     TableExpr
 	Where     *Where
 	Limit     *Limit
@@ -32,6 +33,7 @@ type Delete struct {
 
 // Format implements the NodeFormatter interface.
 func (node *Delete) Format(buf *bytes.Buffer, f FmtFlags) {
+	FormatNode(buf, f, node.With)
 	buf.WriteString("DELETE FROM ")
 	FormatNode(buf, f, node.Table)
 	FormatNode(buf, f, node.Where)
@@ -34,6 +34,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 )
@@ -1068,6 +1069,67 @@ var BinOps = map[BinaryOperator]binOpOverload{
 			},
 		},
 	},
+
+	JSONFetchVal: {
+		BinOp{
+			LeftType:   TypeJSON,
+			RightType:  TypeString,
+			ReturnType: TypeJSON,
+			fn: func(_ *EvalContext, left Datum, right Datum) (Datum, error) {
+				j := MustBeDJSON(left).FetchValKey(string(MustBeDString(right)))
+				if j == nil {
+					return DNull, nil
+				}
+				return NewDJSON(j), nil
+			},
+		},
+		BinOp{
+			LeftType:   TypeJSON,
+			RightType:  TypeInt,
+			ReturnType: TypeJSON,
+			fn: func(_ *EvalContext, left Datum, right Datum) (Datum, error) {
+				j := MustBeDJSON(left).FetchValIdx(int(MustBeDInt(right)))
+				if j == nil {
+					return DNull, nil
+				}
+				return NewDJSON(j), nil
+			},
+		},
+	},
+
+	JSONFetchText: {
+		BinOp{
+			LeftType:   TypeJSON,
+			RightType:  TypeString,
+			ReturnType: TypeString,
+			fn: func(_ *EvalContext, left Datum, right Datum) (Datum, error) {
+				j := MustBeDJSON(left).FetchValKey(string(MustBeDString(right)))
+				return jsonAsText(j), nil
+			},
+		},
+		BinOp{
+			LeftType:   TypeJSON,
+			RightType:  TypeInt,
+			ReturnType: TypeString,
+			fn: func(_ *EvalContext, left Datum, right Datum) (Datum, error) {
+				j := MustBeDJSON(left).FetchValIdx(int(MustBeDInt(right)))
+				return jsonAsText(j), nil
+			},
+		},
+	},
+}
+
+// jsonAsText returns the textual value of j as returned by the ->> operator,
+// or NULL if j is nil or a JSON null.
+func jsonAsText(j json.JSON) Datum {
+	if j == nil {
+		return DNull
+	}
+	s := j.AsText()
+	if s == nil {
+		return DNull
+	}
+	return NewDString(*s)
 }
 
 var timestampMinusBinOp BinOp
@@ -1112,6 +1174,11 @@ func init() {
 			RightType: TArray{t},
 			fn:        cmpOpScalarEQFn,
 		})
+		CmpOps[Contains] = append(CmpOps[Contains], CmpOp{
+			LeftType:  TArray{t},
+			RightType: TArray{t},
+			fn:        cmpOpArrayContainsFn,
+		})
 	}
 }
 
@@ -1266,6 +1333,11 @@ var CmpOps = map[ComparisonOperator]cmpOpOverload{
 			RightType: TypeINet,
 			fn:        cmpOpScalarEQFn,
 		},
+		CmpOp{
+			LeftType:  TypeJSON,
+			RightType: TypeJSON,
+			fn:        cmpOpScalarEQFn,
+		},
 		CmpOp{
 			LeftType:  TypeOid,
 			RightType: TypeOid,
@@ -1406,6 +1478,11 @@ var CmpOps = map[ComparisonOperator]cmpOpOverload{
 			RightType: TypeINet,
 			fn:        cmpOpScalarLTFn,
 		},
+		CmpOp{
+			LeftType:  TypeJSON,
+			RightType: TypeJSON,
+			fn:        cmpOpScalarLTFn,
+		},
 		CmpOp{
 			LeftType:  TypeTuple,
 			RightType: TypeTuple,
@@ -1541,6 +1618,11 @@ var CmpOps = map[ComparisonOperator]cmpOpOverload{
 			RightType: TypeINet,
 			fn:        cmpOpScalarLEFn,
 		},
+		CmpOp{
+			LeftType:  TypeJSON,
+			RightType: TypeJSON,
+			fn:        cmpOpScalarLEFn,
+		},
 		CmpOp{
 			LeftType:  TypeTuple,
 			RightType: TypeTuple,
@@ -1564,6 +1646,7 @@ var CmpOps = map[ComparisonOperator]cmpOpOverload{
 		makeEvalTupleIn(TypeInterval),
 		makeEvalTupleIn(TypeUUID),
 		makeEvalTupleIn(TypeINet),
+		makeEvalTupleIn(TypeJSON),
 		makeEvalTupleIn(TypeTuple),
 		makeEvalTupleIn(TypeOid),
 	},
@@ -1620,6 +1703,80 @@ var CmpOps = map[ComparisonOperator]cmpOpOverload{
 			},
 		},
 	},
+
+	Contains: {
+		CmpOp{
+			LeftType:  TypeJSON,
+			RightType: TypeJSON,
+			fn: func(_ *EvalContext, left Datum, right Datum) (Datum, error) {
+				return MakeDBool(DBool(MustBeDJSON(left).Contains(MustBeDJSON(right).JSON))), nil
+			},
+		},
+	},
+
+	JSONExists: {
+		CmpOp{
+			LeftType:  TypeJSON,
+			RightType: TypeString,
+			fn: func(_ *EvalContext, left Datum, right Datum) (Datum, error) {
+				return MakeDBool(DBool(MustBeDJSON(left).Exists(string(MustBeDString(right))))), nil
+			},
+		},
+	},
+
+	JSONSomeExists: {
+		CmpOp{
+			LeftType:  TypeJSON,
+			RightType: TArray{TypeString},
+			fn: func(_ *EvalContext, left Datum, right Datum) (Datum, error) {
+				return jsonExistsAny(MustBeDJSON(left), MustBeDArray(right), false), nil
+			},
+		},
+	},
+
+	JSONAllExists: {
+		CmpOp{
+			LeftType:  TypeJSON,
+			RightType: TArray{TypeString},
+			fn: func(_ *EvalContext, left Datum, right Datum) (Datum, error) {
+				return jsonExistsAny(MustBeDJSON(left), MustBeDArray(right), true), nil
+			},
+		},
+	},
+}
+
+// jsonExistsAny implements the ?| and ?& operators: it reports whether any
+// (or, if all is set, every) non-NULL string in keys exists in j.
+func jsonExistsAny(j DJSON, keys *DArray, all bool) Datum {
+	for _, k := range keys.Array {
+		if k == DNull {
+			continue
+		}
+		if j.Exists(string(MustBeDString(k))) != all {
+			return MakeDBool(DBool(!all))
+		}
+	}
+	return MakeDBool(DBool(all))
+}
+
+// cmpOpArrayContainsFn implements the @> operator for arrays: it reports
+// whether every element of right is equal to some element of left. As NULL is
+// not equal to anything, an array containing NULL is never contained.
+func cmpOpArrayContainsFn(ctx *EvalContext, left, right Datum) (Datum, error) {
+	haystack := MustBeDArray(left)
+	for _, needle := range MustBeDArray(right).Array {
+		found := false
+		for _, elem := range haystack.Array {
+			if cmpOpScalarFn(ctx, elem, needle, EQ) == DBoolTrue {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return DBoolFalse, nil
+		}
+	}
+	return DBoolTrue, nil
 }
 
 func isNaN(d Datum) bool {
@@ -1839,6 +1996,25 @@ type EvalPlanner interface {
 	// QualifyWithDatabase resolves a possibly unqualified table name into a
 	// normalized table name that is qualified by database.
 	QualifyWithDatabase(ctx context.Context, t *NormalizableTableName) (*TableName, error)
+
+	// IncrementSequence increments the given sequence and returns the result.
+	// It returns an error if the given name is not a sequence.
+	// The caller must ensure that seqName is fully qualified already.
+	IncrementSequence(ctx context.Context, seqName *TableName) (int64, error)
+
+	// GetLatestValueInSessionForSequence returns the value most recently obtained by
+	// nextval() for the given sequence in this session.
+	GetLatestValueInSessionForSequence(ctx context.Context, seqName *TableName) (int64, error)
+
+	// GetLastSequenceValue returns the value most recently obtained by nextval()
+	// for any sequence in this session.
+	GetLastSequenceValue(ctx context.Context) (int64, error)
+
+	// SetSequenceValue sets the sequence's value.
+	// If isCalled is false, the sequence is set such that the next time nextval() is called,
+	// `newVal` is returned. Otherwise, the next call to nextval will return
+	// `newVal + seqOpts.Increment`.
+	SetSequenceValue(ctx context.Context, seqName *TableName, newVal int64, isCalled bool) error
 }
 
 // CtxProvider is anything that can return a Context.
@@ -2435,6 +2611,8 @@ func performCast(ctx *EvalContext, d Datum, t CastTargetType) (Datum, error) {
 			s = t.UUID.String()
 		case *DIPAddr:
 			s = t.String()
+		case *DJSON:
+			s = t.JSON.String()
 		case *DString:
 			s = string(*t)
 		case *DCollatedString:
@@ -2501,6 +2679,14 @@ func performCast(ctx *EvalContext, d Datum, t CastTargetType) (Datum, error) {
 			return d, nil
 		}
 
+	case *JSONColType:
+		switch t := d.(type) {
+		case *DString:
+			return ParseDJSON(string(*t))
+		case *DJSON:
+			return d, nil
+		}
+
 	case *DateColType:
 		switch d := d.(type) {
 		case *DString:
@@ -3068,6 +3254,11 @@ func (t *DIPAddr) Eval(_ *EvalContext) (Datum, error) {
 	return t, nil
 }
 
+// Eval implements the TypedExpr interface.
+func (t *DJSON) Eval(_ *EvalContext) (Datum, error) {
+	return t, nil
+}
+
 // Eval implements the TypedExpr interface.
 func (t *DDate) Eval(_ *EvalContext) (Datum, error) {
 	return t, nil
@@ -3197,6 +3388,9 @@ func foldComparisonExpr(
 	case NotRegIMatch:
 		// NotRegIMatch(left, right) is implemented as !RegIMatch(left, right)
 		return RegIMatch, left, right, false, true
+	case ContainedBy:
+		// ContainedBy(left, right) is implemented as Contains(right, left)
+		return Contains, right, left, true, false
 	case IsDistinctFrom:
 		// IsDistinctFrom(left, right) is implemented as !EQ(left, right)
 		//
@@ -293,6 +293,11 @@ const (
 	IsNotDistinctFrom
 	Is
 	IsNot
+	Contains
+	ContainedBy
+	JSONExists
+	JSONSomeExists
+	JSONAllExists
 
 	// The following operators will always be used with an associated SubOperator.
 	// If Go had algebraic data types they would be defined in a self-contained
@@ -335,6 +340,11 @@ var comparisonOpName = [...]string{
 	IsNotDistinctFrom: "IS NOT DISTINCT FROM",
 	Is:                "IS",
 	IsNot:             "IS NOT",
+	Contains:          "@>",
+	ContainedBy:       "<@",
+	JSONExists:        "?",
+	JSONSomeExists:    "?|",
+	JSONAllExists:     "?&",
 	Any:               "ANY",
 	Some:              "SOME",
 	All:               "ALL",
@@ -748,22 +758,26 @@ const (
 	Concat
 	LShift
 	RShift
+	JSONFetchVal
+	JSONFetchText
 )
 
 var binaryOpName = [...]string{
-	Bitand:   "&",
-	Bitor:    "|",
-	Bitxor:   "#",
-	Plus:     "+",
-	Minus:    "-",
-	Mult:     "*",
-	Div:      "/",
-	FloorDiv: "//",
-	Mod:      "%",
-	Pow:      "^",
-	Concat:   "||",
-	LShift:   "<<",
-	RShift:   ">>",
+	Bitand:        "&",
+	Bitor:         "|",
+	Bitxor:        "#",
+	Plus:          "+",
+	Minus:         "-",
+	Mult:          "*",
+	Div:           "/",
+	FloorDiv:      "//",
+	Mod:           "%",
+	Pow:           "^",
+	Concat:        "||",
+	LShift:        "<<",
+	RShift:        ">>",
+	JSONFetchVal:  "->",
+	JSONFetchText: "->>",
 }
 
 func (i BinaryOperator) String() string {
@@ -1078,7 +1092,7 @@ var (
 	decimalCastTypes = []Type{TypeNull, TypeBool, TypeInt, TypeFloat, TypeDecimal, TypeString, TypeCollatedString,
 		TypeTimestamp, TypeTimestampTZ, TypeDate, TypeInterval}
 	stringCastTypes = []Type{TypeNull, TypeBool, TypeInt, TypeFloat, TypeDecimal, TypeString, TypeCollatedString,
-		TypeBytes, TypeTimestamp, TypeTimestampTZ, TypeInterval, TypeUUID, TypeDate, TypeOid, TypeINet}
+		TypeBytes, TypeTimestamp, TypeTimestampTZ, TypeInterval, TypeUUID, TypeDate, TypeOid, TypeINet, TypeJSON}
 	bytesCastTypes     = []Type{TypeNull, TypeString, TypeCollatedString, TypeBytes, TypeUUID}
 	dateCastTypes      = []Type{TypeNull, TypeString, TypeCollatedString, TypeDate, TypeTimestamp, TypeTimestampTZ, TypeInt}
 	timestampCastTypes = []Type{TypeNull, TypeString, TypeCollatedString, TypeDate, TypeTimestamp, TypeTimestampTZ, TypeInt}
@@ -1086,6 +1100,7 @@ var (
 	oidCastTypes       = []Type{TypeNull, TypeString, TypeCollatedString, TypeInt, TypeOid}
 	uuidCastTypes      = []Type{TypeNull, TypeString, TypeCollatedString, TypeBytes, TypeUUID}
 	inetCastTypes      = []Type{TypeNull, TypeString, TypeCollatedString, TypeINet}
+	jsonCastTypes      = []Type{TypeNull, TypeString, TypeJSON}
 	arrayCastTypes     = []Type{TypeNull, TypeString}
 )
 
@@ -1114,6 +1129,8 @@ func validCastTypes(t Type) []Type {
 		return uuidCastTypes
 	case TypeINet:
 		return inetCastTypes
+	case TypeJSON:
+		return jsonCastTypes
 	case TypeOid, TypeRegClass, TypeRegNamespace, TypeRegProc, TypeRegProcedure, TypeRegType:
 		return oidCastTypes
 	default:
@@ -1229,6 +1246,7 @@ func (node *DInt) String() string             { return AsString(node) }
 func (node *DInterval) String() string        { return AsString(node) }
 func (node *DUuid) String() string            { return AsString(node) }
 func (node *DIPAddr) String() string          { return AsString(node) }
+func (node *DJSON) String() string            { return AsString(node) }
 func (node *DString) String() string          { return AsString(node) }
 func (node *DCollatedString) String() string  { return AsString(node) }
 func (node *DTimestamp) String() string       { return AsString(node) }
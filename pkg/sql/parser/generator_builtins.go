@@ -18,6 +18,7 @@ import (
 	"fmt"
 
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
 )
 
 // Table generators, also called "set-generating functions", are
@@ -79,6 +80,8 @@ type ValueGenerator interface {
 
 var _ ValueGenerator = &seriesValueGenerator{}
 var _ ValueGenerator = &arrayValueGenerator{}
+var _ ValueGenerator = &jsonArrayGenerator{}
+var _ ValueGenerator = &jsonObjectKeysGenerator{}
 
 func initGeneratorBuiltins() {
 	// Add all windows to the Builtins map after a few sanity checks.
@@ -126,6 +129,12 @@ var Generators = map[string][]Builtin{
 			"Returns the input array as a set of rows",
 		),
 	},
+	"json_array_elements":       {jsonArrayElementsImpl},
+	"jsonb_array_elements":      {jsonArrayElementsImpl},
+	"json_array_elements_text":  {jsonArrayElementsTextImpl},
+	"jsonb_array_elements_text": {jsonArrayElementsTextImpl},
+	"json_object_keys":          {jsonObjectKeysImpl},
+	"jsonb_object_keys":         {jsonObjectKeysImpl},
 }
 
 func makeGeneratorBuiltin(in ArgTypes, ret TTuple, g generatorFactory, info string) Builtin {
@@ -247,3 +256,124 @@ func (s *arrayValueGenerator) Next() (bool, error) {
 func (s *arrayValueGenerator) Values() Datums {
 	return Datums{s.array.Array[s.nextIndex]}
 }
+
+var jsonArrayElementsImpl = makeGeneratorBuiltin(
+	ArgTypes{{"input", TypeJSON}},
+	TTuple{TypeJSON},
+	makeJSONArrayAsJSONGenerator,
+	"Expands a JSON array to a set of JSON values.",
+)
+
+var jsonArrayElementsTextImpl = makeGeneratorBuiltin(
+	ArgTypes{{"input", TypeJSON}},
+	TTuple{TypeString},
+	makeJSONArrayAsTextGenerator,
+	"Expands a JSON array to a set of text values.",
+)
+
+var errJSONArrayElementsOfNonArray = pgerror.NewError(pgerror.CodeInvalidParameterValueError,
+	"cannot be called on a non-array")
+
+func makeJSONArrayAsJSONGenerator(_ *EvalContext, args Datums) (ValueGenerator, error) {
+	return makeJSONArrayGenerator(args, false)
+}
+
+func makeJSONArrayAsTextGenerator(_ *EvalContext, args Datums) (ValueGenerator, error) {
+	return makeJSONArrayGenerator(args, true)
+}
+
+func makeJSONArrayGenerator(args Datums, asText bool) (ValueGenerator, error) {
+	elems, ok := json.AsArray(MustBeDJSON(args[0]).JSON)
+	if !ok {
+		return nil, errJSONArrayElementsOfNonArray
+	}
+	return &jsonArrayGenerator{elems: elems, asText: asText}, nil
+}
+
+// jsonArrayGenerator is a value generator that returns each element of a
+// JSON array, either as JSON or as text.
+type jsonArrayGenerator struct {
+	elems     []json.JSON
+	asText    bool
+	nextIndex int
+}
+
+// ColumnTypes implements the ValueGenerator interface.
+func (g *jsonArrayGenerator) ColumnTypes() TTuple {
+	if g.asText {
+		return TTuple{TypeString}
+	}
+	return TTuple{TypeJSON}
+}
+
+// Start implements the ValueGenerator interface.
+func (g *jsonArrayGenerator) Start() error {
+	g.nextIndex = -1
+	return nil
+}
+
+// Close implements the ValueGenerator interface.
+func (g *jsonArrayGenerator) Close() {}
+
+// Next implements the ValueGenerator interface.
+func (g *jsonArrayGenerator) Next() (bool, error) {
+	g.nextIndex++
+	return g.nextIndex < len(g.elems), nil
+}
+
+// Values implements the ValueGenerator interface.
+func (g *jsonArrayGenerator) Values() Datums {
+	e := g.elems[g.nextIndex]
+	if g.asText {
+		return Datums{jsonAsText(e)}
+	}
+	return Datums{NewDJSON(e)}
+}
+
+var jsonObjectKeysImpl = makeGeneratorBuiltin(
+	ArgTypes{{"input", TypeJSON}},
+	TTuple{TypeString},
+	makeJSONObjectKeysGenerator,
+	"Returns sorted set of keys in the outermost JSON object.",
+)
+
+var errJSONObjectKeysOfNonObject = pgerror.NewError(pgerror.CodeInvalidParameterValueError,
+	"cannot call json_object_keys on a non-object")
+
+func makeJSONObjectKeysGenerator(_ *EvalContext, args Datums) (ValueGenerator, error) {
+	keys, ok := json.ObjectKeys(MustBeDJSON(args[0]).JSON)
+	if !ok {
+		return nil, errJSONObjectKeysOfNonObject
+	}
+	return &jsonObjectKeysGenerator{keys: keys}, nil
+}
+
+// jsonObjectKeysGenerator is a value generator that returns the keys of a
+// JSON object.
+type jsonObjectKeysGenerator struct {
+	keys      []string
+	nextIndex int
+}
+
+// ColumnTypes implements the ValueGenerator interface.
+func (g *jsonObjectKeysGenerator) ColumnTypes() TTuple { return TTuple{TypeString} }
+
+// Start implements the ValueGenerator interface.
+func (g *jsonObjectKeysGenerator) Start() error {
+	g.nextIndex = -1
+	return nil
+}
+
+// Close implements the ValueGenerator interface.
+func (g *jsonObjectKeysGenerator) Close() {}
+
+// Next implements the ValueGenerator interface.
+func (g *jsonObjectKeysGenerator) Next() (bool, error) {
+	g.nextIndex++
+	return g.nextIndex < len(g.keys), nil
+}
+
+// Values implements the ValueGenerator interface.
+func (g *jsonObjectKeysGenerator) Values() Datums {
+	return Datums{NewDString(g.keys[g.nextIndex])}
+}
@@ -8,6 +8,7 @@ var expectedHelpStrings = []string{
 	"<SOURCE>",
 	"ALTER DATABASE",
 	"ALTER INDEX",
+	"ALTER PARTITION",
 	"ALTER TABLE",
 	"ALTER VIEW",
 	"ALTER",
@@ -17,8 +18,12 @@ var expectedHelpStrings = []string{
 	"CANCEL QUERY",
 	"CANCEL",
 	"COMMIT",
+	"CREATE CHANGEFEED",
 	"CREATE DATABASE",
 	"CREATE INDEX",
+	"CREATE ROLE",
+	"CREATE SEQUENCE",
+	"CREATE STATISTICS",
 	"CREATE TABLE",
 	"CREATE USER",
 	"CREATE VIEW",
@@ -28,6 +33,8 @@ var expectedHelpStrings = []string{
 	"DISCARD",
 	"DROP DATABASE",
 	"DROP INDEX",
+	"DROP ROLE",
+	"DROP SEQUENCE",
 	"DROP TABLE",
 	"DROP USER",
 	"DROP VIEW",
@@ -55,6 +62,7 @@ var expectedHelpStrings = []string{
 	"SHOW CLUSTER SETTING",
 	"SHOW COLUMNS",
 	"SHOW CONSTRAINTS",
+	"SHOW CREATE SEQUENCE",
 	"SHOW CREATE TABLE",
 	"SHOW CREATE VIEW",
 	"SHOW DATABASES",
@@ -62,8 +70,10 @@ var expectedHelpStrings = []string{
 	"SHOW INDEXES",
 	"SHOW JOBS",
 	"SHOW QUERIES",
+	"SHOW ROLES",
 	"SHOW SESSION",
 	"SHOW SESSIONS",
+	"SHOW STATISTICS",
 	"SHOW TABLES",
 	"SHOW TRACE",
 	"SHOW TRANSACTION",
@@ -6,6 +6,7 @@ package parser
 var keywords = map[string]int{
 	"ACTION":                    ACTION,
 	"ADD":                       ADD,
+	"ADMIN":                     ADMIN,
 	"ALL":                       ALL,
 	"ALTER":                     ALTER,
 	"ANALYSE":                   ANALYSE,
@@ -35,6 +36,7 @@ var keywords = map[string]int{
 	"CASCADE":                   CASCADE,
 	"CASE":                      CASE,
 	"CAST":                      CAST,
+	"CHANGEFEED":                CHANGEFEED,
 	"CHAR":                      CHAR,
 	"CHARACTER":                 CHARACTER,
 	"CHARACTERISTICS":           CHARACTERISTICS,
@@ -47,6 +49,7 @@ var keywords = map[string]int{
 	"COLUMNS":                   COLUMNS,
 	"COMMIT":                    COMMIT,
 	"COMMITTED":                 COMMITTED,
+	"CONFIGURE":                 CONFIGURE,
 	"CONFLICT":                  CONFLICT,
 	"CONSTRAINT":                CONSTRAINT,
 	"CONSTRAINTS":               CONSTRAINTS,
@@ -88,6 +91,7 @@ var keywords = map[string]int{
 	"EXCEPT":                    EXCEPT,
 	"EXECUTE":                   EXECUTE,
 	"EXISTS":                    EXISTS,
+	"EXPERIMENTAL":              EXPERIMENTAL,
 	"EXPERIMENTAL_FINGERPRINTS": EXPERIMENTAL_FINGERPRINTS,
 	"EXPLAIN":                   EXPLAIN,
 	"EXTRACT":                   EXTRACT,
@@ -119,6 +123,7 @@ var keywords = map[string]int{
 	"ILIKE":                     ILIKE,
 	"IMPORT":                    IMPORT,
 	"IN":                        IN,
+	"INCREMENT":                 INCREMENT,
 	"INCREMENTAL":               INCREMENTAL,
 	"INDEX":                     INDEX,
 	"INDEXES":                   INDEXES,
@@ -137,11 +142,14 @@ var keywords = map[string]int{
 	"INTERSECT":                 INTERSECT,
 	"INTERVAL":                  INTERVAL,
 	"INTO":                      INTO,
+	"INVERTED":                  INVERTED,
 	"IS":                        IS,
 	"ISOLATION":                 ISOLATION,
 	"JOB":                       JOB,
 	"JOBS":                      JOBS,
 	"JOIN":                      JOIN,
+	"JSON":                      JSON,
+	"JSONB":                     JSONB,
 	"KEY":                       KEY,
 	"KEYS":                      KEYS,
 	"KV":                        KV,
@@ -154,12 +162,15 @@ var keywords = map[string]int{
 	"LEVEL":                     LEVEL,
 	"LIKE":                      LIKE,
 	"LIMIT":                     LIMIT,
+	"LIST":                      LIST,
 	"LOCAL":                     LOCAL,
 	"LOCALTIME":                 LOCALTIME,
 	"LOCALTIMESTAMP":            LOCALTIMESTAMP,
 	"LOW":                       LOW,
 	"MATCH":                     MATCH,
+	"MAXVALUE":                  MAXVALUE,
 	"MINUTE":                    MINUTE,
+	"MINVALUE":                  MINVALUE,
 	"MONTH":                     MONTH,
 	"NAME":                      NAME,
 	"NAMES":                     NAMES,
@@ -181,6 +192,7 @@ var keywords = map[string]int{
 	"OID":                       OID,
 	"ON":                        ON,
 	"ONLY":                      ONLY,
+	"OPTION":                    OPTION,
 	"OPTIONS":                   OPTIONS,
 	"OR":                        OR,
 	"ORDER":                     ORDER,
@@ -226,6 +238,8 @@ var keywords = map[string]int{
 	"RETURNING":                 RETURNING,
 	"REVOKE":                    REVOKE,
 	"RIGHT":                     RIGHT,
+	"ROLE":                      ROLE,
+	"ROLES":                     ROLES,
 	"ROLLBACK":                  ROLLBACK,
 	"ROLLUP":                    ROLLUP,
 	"ROW":                       ROW,
@@ -235,6 +249,7 @@ var keywords = map[string]int{
 	"SEARCH":                    SEARCH,
 	"SECOND":                    SECOND,
 	"SELECT":                    SELECT,
+	"SEQUENCE":                  SEQUENCE,
 	"SEQUENCES":                 SEQUENCES,
 	"SERIAL":                    SERIAL,
 	"SERIALIZABLE":              SERIALIZABLE,
@@ -254,9 +269,11 @@ var keywords = map[string]int{
 	"SPLIT":                     SPLIT,
 	"SQL":                       SQL,
 	"START":                     START,
+	"STATISTICS":                STATISTICS,
 	"STATUS":                    STATUS,
 	"STDIN":                     STDIN,
 	"STORE":                     STORE,
+	"STORED":                    STORED,
 	"STORING":                   STORING,
 	"STRICT":                    STRICT,
 	"STRING":                    STRING,
@@ -477,6 +477,8 @@ func (expr *ComparisonExpr) normalize(v *normalizeVisitor) TypedExpr {
 		SimilarTo, NotSimilarTo,
 		RegMatch, NotRegMatch,
 		RegIMatch, NotRegIMatch,
+		Contains, ContainedBy,
+		JSONExists, JSONSomeExists, JSONAllExists,
 		Any, Some, All:
 		if expr.TypedLeft() == DNull || expr.TypedRight() == DNull {
 			return DNull
@@ -160,11 +160,13 @@ func (HomogeneousType) String() string {
 	return "anyelement..."
 }
 
-// VariadicType is a typeList implementation which accepts any number of
-// arguments and matches when each argument is either NULL or of the type
-// typ.
+// VariadicType is a typeList implementation which accepts a fixed number of
+// arguments at the beginning and an arbitrary number of homogeneous arguments
+// at the end. Each variadic argument matches when it is either NULL or of the
+// type typ.
 type VariadicType struct {
-	Typ Type
+	FixedTypes []Type
+	Typ        Type
 }
 
 func (v VariadicType) match(types []Type) bool {
@@ -177,29 +179,44 @@ func (v VariadicType) match(types []Type) bool {
 }
 
 func (v VariadicType) matchAt(typ Type, i int) bool {
+	if i < len(v.FixedTypes) {
+		return typ == TypeNull || v.FixedTypes[i].Equivalent(typ)
+	}
 	return typ == TypeNull || v.Typ.Equivalent(typ)
 }
 
 func (v VariadicType) matchLen(l int) bool {
-	return true
+	return l >= len(v.FixedTypes)
 }
 
 func (v VariadicType) getAt(i int) Type {
+	if i < len(v.FixedTypes) {
+		return v.FixedTypes[i]
+	}
 	return v.Typ
 }
 
 // Length implements the typeList interface.
 func (v VariadicType) Length() int {
-	return 1
+	return len(v.FixedTypes) + 1
 }
 
 // Types implements the typeList interface.
 func (v VariadicType) Types() []Type {
-	return []Type{v.Typ}
+	result := make([]Type, len(v.FixedTypes)+1)
+	copy(result, v.FixedTypes)
+	result[len(result)-1] = v.Typ
+	return result
 }
 
 func (v VariadicType) String() string {
-	return fmt.Sprintf("%s...", v.Typ)
+	var buf bytes.Buffer
+	for _, t := range v.FixedTypes {
+		buf.WriteString(t.String())
+		buf.WriteString(", ")
+	}
+	fmt.Fprintf(&buf, "%s...", v.Typ)
+	return buf.String()
 }
 
 // unknownReturnType is returned from returnTypers when the arguments provided are
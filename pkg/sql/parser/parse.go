@@ -161,6 +161,20 @@ func ParseTableName(sql string) (*TableName, error) {
 	return rename.Name.Normalize()
 }
 
+// ParseTableNameWithIndex parses a table name qualified with an index name,
+// e.g. "db.t@idx". The table name is not normalized.
+func ParseTableNameWithIndex(sql string) (*TableNameWithIndex, error) {
+	stmt, err := ParseOne(fmt.Sprintf("ALTER INDEX %s RENAME TO x", sql))
+	if err != nil {
+		return nil, err
+	}
+	rename, ok := stmt.(*RenameIndex)
+	if !ok {
+		return nil, pgerror.NewErrorf(pgerror.CodeInternalError, "expected an ALTER INDEX statement, but found %T", stmt)
+	}
+	return rename.Index, nil
+}
+
 // parseExprs parses one or more sql expressions.
 func parseExprs(exprs []string) (Exprs, error) {
 	stmt, err := ParseOne(fmt.Sprintf("SET ROW (%s)", strings.Join(exprs, ",")))
@@ -238,6 +252,8 @@ func ParseStringAs(t Type, s string, evalCtx *EvalContext) (Datum, error) {
 		d, err = ParseDUuidFromString(s)
 	case TypeINet:
 		d, err = ParseDIPAddrFromINetString(s)
+	case TypeJSON:
+		d, err = ParseDJSON(s)
 	default:
 		if a, ok := t.(TArray); ok {
 			typ, err := DatumTypeToColumnType(a.Typ)
@@ -72,6 +72,9 @@ func TestParse(t *testing.T) {
 		{`CREATE DATABASE IF NOT EXISTS a LC_CTYPE = 'INVALID'`},
 		{`CREATE DATABASE IF NOT EXISTS a TEMPLATE = 'template0' ENCODING = 'UTF8' LC_COLLATE = 'C.UTF-8' LC_CTYPE = 'INVALID'`},
 
+		{`CREATE ROLE a`},
+		{`CREATE ROLE IF NOT EXISTS a`},
+
 		{`CREATE INDEX a ON b (c)`},
 		{`CREATE INDEX a ON b.c (d)`},
 		{`CREATE INDEX ON a (b)`},
@@ -83,7 +86,12 @@ func TestParse(t *testing.T) {
 		{`CREATE UNIQUE INDEX a ON b (c) STORING (d)`},
 		{`CREATE UNIQUE INDEX a ON b (c) INTERLEAVE IN PARENT d (e, f)`},
 		{`CREATE UNIQUE INDEX a ON b (c) INTERLEAVE IN PARENT d.e (f, g)`},
+		{`CREATE INDEX ON a (b) PARTITION BY LIST (b) (PARTITION p1 VALUES IN (1, DEFAULT))`},
+		{`CREATE INDEX ON a (b) INTERLEAVE IN PARENT c (d) PARTITION BY RANGE (b) (PARTITION p1 VALUES < (1), PARTITION p2 VALUES < MAXVALUE)`},
 		{`CREATE UNIQUE INDEX a ON b.c (d)`},
+		{`CREATE INVERTED INDEX a ON b (c)`},
+		{`CREATE INVERTED INDEX a ON b.c (d)`},
+		{`CREATE INVERTED INDEX IF NOT EXISTS a ON b (c)`},
 
 		{`CREATE TABLE a ()`},
 		{`CREATE TABLE a (b INT)`},
@@ -100,6 +108,8 @@ func TestParse(t *testing.T) {
 		{`CREATE TABLE a (b BIGSERIAL)`},
 		{`CREATE TABLE a (b UUID)`},
 		{`CREATE TABLE a (b INET)`},
+		{`CREATE TABLE a (b JSON)`},
+		{`CREATE TABLE a (b JSONB)`},
 		{`CREATE TABLE a (b INT NULL)`},
 		{`CREATE TABLE a (b INT CONSTRAINT maybe NULL)`},
 		{`CREATE TABLE a (b INT NOT NULL)`},
@@ -110,6 +120,8 @@ func TestParse(t *testing.T) {
 		{`CREATE TABLE a (b INT DEFAULT 1)`},
 		{`CREATE TABLE a (b INT CONSTRAINT one DEFAULT 1)`},
 		{`CREATE TABLE a (b INT DEFAULT now())`},
+		{`CREATE TABLE a (b INT, c INT AS (b + 1) STORED)`},
+		{`CREATE TABLE a (b STRING, c STRING AS (lower(b)) STORED, INDEX (c))`},
 		{`CREATE TABLE a (a INT CHECK (a > 0))`},
 		{`CREATE TABLE a (a INT CONSTRAINT positive CHECK (a > 0))`},
 		{`CREATE TABLE a (a INT DEFAULT 1 CHECK (a > 0))`},
@@ -123,8 +135,13 @@ func TestParse(t *testing.T) {
 		{`CREATE TABLE a (b INT, c TEXT, FOREIGN KEY (b, c) REFERENCES other)`},
 		{`CREATE TABLE a (b INT, c TEXT, FOREIGN KEY (b, c) REFERENCES other (x, y))`},
 		{`CREATE TABLE a (b INT, c TEXT, CONSTRAINT s FOREIGN KEY (b, c) REFERENCES other (x, y))`},
+		{`CREATE TABLE a (b INT, FOREIGN KEY (b) REFERENCES other ON DELETE CASCADE)`},
+		{`CREATE TABLE a (b INT, FOREIGN KEY (b) REFERENCES other ON UPDATE SET NULL)`},
+		{`CREATE TABLE a (b INT, FOREIGN KEY (b) REFERENCES other (x) ON DELETE SET DEFAULT ON UPDATE RESTRICT)`},
 		{`CREATE TABLE a (b INT, c TEXT, INDEX (b, c))`},
 		{`CREATE TABLE a (b INT, c TEXT, INDEX d (b, c))`},
+		{`CREATE TABLE a (b INT, c JSONB, INVERTED INDEX (c))`},
+		{`CREATE TABLE a (b INT, c JSONB, INVERTED INDEX d (c))`},
 		{`CREATE TABLE a (b INT, c TEXT, CONSTRAINT d UNIQUE (b, c))`},
 		{`CREATE TABLE a (b INT, c TEXT, CONSTRAINT d UNIQUE (b, c) INTERLEAVE IN PARENT d (e, f))`},
 		{`CREATE TABLE a (b INT, UNIQUE (b))`},
@@ -133,6 +150,8 @@ func TestParse(t *testing.T) {
 		{`CREATE TABLE a (b INT, c INT REFERENCES foo)`},
 		{`CREATE TABLE a (b INT, c INT CONSTRAINT ref REFERENCES foo)`},
 		{`CREATE TABLE a (b INT, c INT REFERENCES foo (bar))`},
+		{`CREATE TABLE a (b INT, c INT REFERENCES foo ON DELETE CASCADE ON UPDATE CASCADE)`},
+		{`CREATE TABLE a (b INT, c INT REFERENCES foo (bar) ON DELETE SET NULL)`},
 		{`CREATE TABLE a (b INT, INDEX (b) STORING (c))`},
 		{`CREATE TABLE a (b INT, c TEXT, INDEX (b ASC, c DESC) STORING (c))`},
 		{`CREATE TABLE a (b INT, INDEX (b) INTERLEAVE IN PARENT c (d, e))`},
@@ -140,6 +159,11 @@ func TestParse(t *testing.T) {
 		{`CREATE TABLE a (b INT, c STRING, FAMILY foo (b), FAMILY (c))`},
 		{`CREATE TABLE a (b INT) INTERLEAVE IN PARENT foo (c, d)`},
 		{`CREATE TABLE a (b INT) INTERLEAVE IN PARENT foo (c) CASCADE`},
+		{`CREATE TABLE a (b INT, c STRING, PRIMARY KEY (b, c)) PARTITION BY LIST (b) (PARTITION p1 VALUES IN (1, 2), PARTITION p2 VALUES IN (DEFAULT))`},
+		{`CREATE TABLE a (b INT, c STRING, PRIMARY KEY (b, c)) PARTITION BY LIST (b, c) (PARTITION p1 VALUES IN ((1, 'a'), (2, 'b')))`},
+		{`CREATE TABLE a (b INT PRIMARY KEY) PARTITION BY RANGE (b) (PARTITION p1 VALUES < (1), PARTITION p2 VALUES < MAXVALUE)`},
+		{`CREATE TABLE a (b INT, INDEX (b) PARTITION BY LIST (b) (PARTITION p1 VALUES IN (1)))`},
+		{`CREATE TABLE a (b INT, UNIQUE (b) PARTITION BY RANGE (b) (PARTITION p1 VALUES < (1)))`},
 		{`CREATE TABLE a.b (b INT)`},
 		{`CREATE TABLE IF NOT EXISTS a (b INT)`},
 
@@ -168,6 +192,18 @@ func TestParse(t *testing.T) {
 		{`CREATE VIEW a (x, y) AS VALUES (1, 'one'), (2, 'two')`},
 		{`CREATE VIEW a AS TABLE b`},
 
+		{`CREATE SEQUENCE a`},
+		{`CREATE SEQUENCE IF NOT EXISTS a`},
+		{`CREATE SEQUENCE a.b INCREMENT 5 MINVALUE -10 MAXVALUE 10 START 0`},
+		{`CREATE SEQUENCE a INCREMENT BY -1 NO MINVALUE NO MAXVALUE START WITH 10 NO CYCLE`},
+
+		{`CREATE STATISTICS a ON col1 FROM t`},
+		{`CREATE STATISTICS a ON col1 FROM d.t`},
+		{`CREATE STATISTICS a ON col1, col2 FROM t`},
+
+		{`CREATE CHANGEFEED FOR TABLE foo INTO 'sink'`},
+		{`CREATE CHANGEFEED FOR TABLE foo, db.bar INTO $1`},
+
 		{`DELETE FROM a`},
 		{`DELETE FROM a.b`},
 		{`DELETE FROM a WHERE a = b`},
@@ -212,9 +248,17 @@ func TestParse(t *testing.T) {
 		{`DROP VIEW a.b CASCADE`},
 		{`DROP VIEW a, b CASCADE`},
 
+		{`DROP SEQUENCE a`},
+		{`DROP SEQUENCE a.b`},
+		{`DROP SEQUENCE IF EXISTS a, b CASCADE`},
+
 		{`DROP USER a`},
 		{`DROP USER a, b`},
 
+		{`DROP ROLE a`},
+		{`DROP ROLE a, b`},
+		{`DROP ROLE IF EXISTS a`},
+
 		{`CANCEL JOB a`},
 		{`CANCEL QUERY a`},
 		{`RESUME JOB a`},
@@ -237,6 +281,10 @@ func TestParse(t *testing.T) {
 		{`SHOW TABLES`},
 		{`SHOW TABLES FROM a`},
 		{`SHOW COLUMNS FROM a`},
+		{`SHOW CREATE SEQUENCE a.b`},
+
+		{`SHOW STATISTICS FOR TABLE t`},
+		{`SHOW STATISTICS FOR TABLE d.t`},
 		{`SHOW COLUMNS FROM a.b.c`},
 		{`SHOW INDEXES FROM a`},
 		{`SHOW INDEXES FROM a.b.c`},
@@ -244,6 +292,7 @@ func TestParse(t *testing.T) {
 		{`SHOW CONSTRAINTS FROM a.b.c`},
 		{`SHOW TABLES FROM a; SHOW COLUMNS FROM b`},
 		{`SHOW USERS`},
+		{`SHOW ROLES`},
 		{`SHOW JOBS`},
 		{`SHOW CLUSTER QUERIES`},
 		{`SHOW LOCAL QUERIES`},
@@ -318,6 +367,10 @@ func TestParse(t *testing.T) {
 		{`GRANT SELECT, INSERT ON DATABASE db1, db2 TO foo, bar, baz`},
 		{`GRANT SELECT, INSERT ON DATABASE db1, db2 TO "test-user"`},
 
+		{`GRANT rolea TO foo`},
+		{`GRANT rolea, roleb TO foo, bar`},
+		{`GRANT rolea TO foo WITH ADMIN OPTION`},
+
 		// Tables are the default, but can also be specified with
 		// REVOKE x ON TABLE y. However, the stringer does not output TABLE.
 		{`REVOKE SELECT ON foo FROM root`},
@@ -327,6 +380,10 @@ func TestParse(t *testing.T) {
 		{`REVOKE SELECT, INSERT ON DATABASE bar FROM foo, bar, baz`},
 		{`REVOKE SELECT, INSERT ON DATABASE db1, db2 FROM foo, bar, baz`},
 
+		{`REVOKE rolea FROM foo`},
+		{`REVOKE rolea, roleb FROM foo, bar`},
+		{`REVOKE ADMIN OPTION FOR rolea FROM foo`},
+
 		{`INSERT INTO a VALUES (1)`},
 		{`INSERT INTO a.b VALUES (1)`},
 		{`INSERT INTO a VALUES (1, 2)`},
@@ -438,6 +495,8 @@ func TestParse(t *testing.T) {
 
 		{`SELECT '192.168.0.1':::INET`},
 		{`SELECT '192.168.0.1'::INET`},
+		{`SELECT '{"a": 1}':::JSONB`},
+		{`SELECT '{"a": 1}'::JSONB`},
 
 		{`SELECT 'a' AS "12345"`},
 		{`SELECT 'a' AS clnm`},
@@ -499,6 +558,13 @@ func TestParse(t *testing.T) {
 		{`SELECT a FROM t WHERE a NOT LIKE b`},
 		{`SELECT a FROM t WHERE a ILIKE b`},
 		{`SELECT a FROM t WHERE a NOT ILIKE b`},
+		{`SELECT a FROM t WHERE a @> b`},
+		{`SELECT a FROM t WHERE a <@ b`},
+		{`SELECT a FROM t WHERE a ? b`},
+		{`SELECT a FROM t WHERE a ?| b`},
+		{`SELECT a FROM t WHERE a ?& b`},
+		{`SELECT a -> b FROM t`},
+		{`SELECT a ->> b FROM t`},
 		{`SELECT a FROM t WHERE a SIMILAR TO b`},
 		{`SELECT a FROM t WHERE a NOT SIMILAR TO b`},
 		{`SELECT a FROM t WHERE a ~ b`},
@@ -567,6 +633,15 @@ func TestParse(t *testing.T) {
 		{`SELECT avg(1) OVER (ORDER BY c) FROM t`},
 		{`SELECT avg(1) OVER (PARTITION BY b ORDER BY c) FROM t`},
 		{`SELECT avg(1) OVER (w PARTITION BY b ORDER BY c) FROM t`},
+		{`SELECT avg(1) OVER (ROWS UNBOUNDED PRECEDING) FROM t`},
+		{`SELECT avg(1) OVER (ROWS 1 PRECEDING) FROM t`},
+		{`SELECT avg(1) OVER (ROWS CURRENT ROW) FROM t`},
+		{`SELECT avg(1) OVER (ROWS BETWEEN 1 PRECEDING AND 1 FOLLOWING) FROM t`},
+		{`SELECT avg(1) OVER (ROWS BETWEEN CURRENT ROW AND UNBOUNDED FOLLOWING) FROM t`},
+		{`SELECT avg(1) OVER (ORDER BY c RANGE BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) FROM t`},
+		{`SELECT avg(1) OVER (PARTITION BY b ORDER BY c RANGE BETWEEN 1 + 2 PRECEDING AND $1 FOLLOWING) FROM t`},
+		{`SELECT avg(1) OVER (w ROWS BETWEEN 2 FOLLOWING AND UNBOUNDED FOLLOWING) FROM t`},
+		{`SELECT a FROM t WINDOW w AS (ORDER BY c ROWS BETWEEN 2 PRECEDING AND CURRENT ROW)`},
 
 		{`SELECT a FROM t UNION SELECT 1 FROM t`},
 		{`SELECT a FROM t UNION SELECT 1 FROM t UNION SELECT 1 FROM t`},
@@ -673,6 +748,7 @@ func TestParse(t *testing.T) {
 		{`ALTER TABLE a ADD b INT CREATE FAMILY`},
 		{`ALTER TABLE a ADD b INT CREATE FAMILY fam_b`},
 		{`ALTER TABLE a ADD b INT CREATE IF NOT EXISTS FAMILY fam_b`},
+		{`ALTER TABLE a ADD COLUMN c INT AS (b * 2) STORED`},
 
 		{`ALTER TABLE a DROP b, DROP CONSTRAINT a_idx`},
 		{`ALTER TABLE a DROP IF EXISTS b, DROP CONSTRAINT a_idx`},
@@ -693,6 +769,12 @@ func TestParse(t *testing.T) {
 		{`ALTER TABLE a ALTER COLUMN b DROP DEFAULT`},
 		{`ALTER TABLE a ALTER COLUMN b DROP NOT NULL`},
 		{`ALTER TABLE a ALTER b DROP NOT NULL`},
+		{`ALTER TABLE a ALTER COLUMN b SET NOT NULL`},
+		{`ALTER TABLE a ALTER b SET NOT NULL`},
+		{`ALTER TABLE a ALTER COLUMN b TYPE DECIMAL`},
+		{`ALTER TABLE a ALTER COLUMN b SET DATA TYPE DECIMAL`},
+		{`ALTER TABLE a ALTER b TYPE STRING USING b * 2`},
+		{`ALTER TABLE a ALTER COLUMN b SET DATA TYPE INT USING length(b)`},
 
 		{`COPY t FROM STDIN`},
 		{`COPY t (a, b, c) FROM STDIN`},
@@ -715,6 +797,9 @@ func TestParse(t *testing.T) {
 		{`ALTER TABLE d.a SCATTER`},
 		{`ALTER INDEX d.i SCATTER FROM (1) TO (2)`},
 
+		{`ALTER PARTITION p OF TABLE a EXPERIMENTAL CONFIGURE ZONE 'foo'`},
+		{`ALTER PARTITION p OF TABLE d.a EXPERIMENTAL CONFIGURE ZONE NULL`},
+
 		{`BACKUP foo TO 'bar'`},
 		{`BACKUP foo.foo, baz.baz TO 'bar'`},
 		{`SHOW BACKUP 'bar'`},
@@ -738,6 +823,17 @@ func TestParse(t *testing.T) {
 		{`IMPORT TABLE foo (id INT, email STRING, age INT) CSV DATA ('path/to/some/file', $1) WITH comma = ',', "nullif" = 'n/a', temp = $2`},
 		{`SET ROW (1, true, NULL)`},
 
+		{`WITH a AS (SELECT 1) SELECT * FROM a`},
+		{`WITH a (x, y) AS (SELECT 1, 2), b AS (SELECT * FROM a) SELECT * FROM b ORDER BY x LIMIT 1`},
+		{`WITH RECURSIVE t (n) AS (VALUES (1) UNION ALL SELECT n + 1 FROM t WHERE n < 10) SELECT sum(n) FROM t`},
+		{`WITH a AS (INSERT INTO t VALUES (1) RETURNING k) SELECT * FROM a`},
+		{`WITH a AS (DELETE FROM t WHERE k = 1 RETURNING NOTHING) SELECT 1`},
+		{`WITH a AS (SELECT 1) INSERT INTO t SELECT * FROM a`},
+		{`WITH a AS (SELECT 1) UPSERT INTO t SELECT * FROM a`},
+		{`WITH a AS (SELECT 1) UPDATE t SET v = 2 WHERE k IN (SELECT * FROM a)`},
+		{`WITH a AS (SELECT 1) DELETE FROM t WHERE k IN (SELECT * FROM a)`},
+		{`SELECT * FROM (WITH a AS (SELECT 1) SELECT * FROM a)`},
+
 		// Regression for #15926
 		{`SELECT * FROM ((t1 NATURAL JOIN t2 WITH ORDINALITY AS o1)) WITH ORDINALITY AS o2`},
 	}
@@ -769,6 +865,10 @@ func TestParse2(t *testing.T) {
 			`CREATE DATABASE a TEMPLATE = 'invalid'`},
 		{`CREATE TABLE a (b INT, UNIQUE INDEX foo (b))`,
 			`CREATE TABLE a (b INT, CONSTRAINT foo UNIQUE (b))`},
+		{`CREATE TABLE a (b INT REFERENCES other ON UPDATE CASCADE ON DELETE NO ACTION)`,
+			`CREATE TABLE a (b INT REFERENCES other ON UPDATE CASCADE)`},
+		{`CREATE TABLE a (b INT, FOREIGN KEY (b) REFERENCES other ON UPDATE RESTRICT ON DELETE CASCADE)`,
+			`CREATE TABLE a (b INT, FOREIGN KEY (b) REFERENCES other ON DELETE CASCADE ON UPDATE RESTRICT)`},
 		{`CREATE TABLE a (b INT, UNIQUE INDEX foo (b) INTERLEAVE IN PARENT c (d))`,
 			`CREATE TABLE a (b INT, CONSTRAINT foo UNIQUE (b) INTERLEAVE IN PARENT c (d))`},
 		{`CREATE INDEX ON a (b) COVERING (c)`, `CREATE INDEX ON a (b) STORING (c)`},
@@ -803,6 +903,8 @@ func TestParse2(t *testing.T) {
 		{`SELECT a FROM t WHERE a = b / c`, `SELECT a FROM t WHERE a = (b / c)`},
 		{`SELECT a FROM t WHERE a = b % c`, `SELECT a FROM t WHERE a = (b % c)`},
 		{`SELECT a FROM t WHERE a = b || c`, `SELECT a FROM t WHERE a = (b || c)`},
+		{`SELECT a->'b'->>'c' FROM t`, `SELECT (a -> 'b') ->> 'c' FROM t`},
+		{`SELECT a FROM t WHERE a->'b' @> c`, `SELECT a FROM t WHERE (a -> 'b') @> c`},
 		{`SELECT a FROM t WHERE a = + b`, `SELECT a FROM t WHERE a = (+b)`},
 		{`SELECT a FROM t WHERE a = - b`, `SELECT a FROM t WHERE a = (-b)`},
 		{`SELECT a FROM t WHERE a = ~ b`, `SELECT a FROM t WHERE a = (~b)`},
@@ -1335,6 +1437,13 @@ SELECT ANNOTATE_TYPE(1.2+2.3, notatype)
 CREATE USER foo WITH PASSWORD
                              ^
 HINT: try \h CREATE USER`,
+		},
+		{
+			`GRANT foo ON bar TO baz`,
+			`not a valid privilege: "foo" at or near "on"
+GRANT foo ON bar TO baz
+          ^
+`,
 		},
 		{
 			`ALTER TABLE t RENAME TO t[TRUE]`,
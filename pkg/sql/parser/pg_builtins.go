@@ -37,6 +37,7 @@ var typeBuiltinsHaveUnderscore = map[oid.Oid]struct{}{
 	TypeDate.Oid():        {},
 	TypeDecimal.Oid():     {},
 	TypeInterval.Oid():    {},
+	TypeJSON.Oid():        {},
 	TypeUUID.Oid():        {},
 	TypeTimestamp.Oid():   {},
 	TypeTimestampTZ.Oid(): {},
@@ -321,7 +321,44 @@ func (s *Scanner) scan(lval *sqlSymType) {
 		return
 
 	case '?':
-		lval.id = HELPTOKEN
+		switch s.peek() {
+		case '?': // ??
+			s.pos++
+			lval.id = HELPTOKEN
+			return
+		case '|': // ?|
+			s.pos++
+			lval.id = JSON_SOME_EXISTS
+			return
+		case '&': // ?&
+			s.pos++
+			lval.id = JSON_ALL_EXISTS
+			return
+		}
+		return
+
+	case '-':
+		switch s.peek() {
+		case '>': // ->
+			if s.peekN(1) == '>' {
+				// ->>
+				s.pos += 2
+				lval.id = FETCHTEXT
+				return
+			}
+			s.pos++
+			lval.id = FETCHVAL
+			return
+		}
+		return
+
+	case '@':
+		switch s.peek() {
+		case '>': // @>
+			s.pos++
+			lval.id = CONTAINS
+			return
+		}
 		return
 
 	case '<':
@@ -338,6 +375,10 @@ func (s *Scanner) scan(lval *sqlSymType) {
 			s.pos++
 			lval.id = LESS_EQUALS
 			return
+		case '@': // <@
+			s.pos++
+			lval.id = CONTAINED_BY
+			return
 		}
 		return
 
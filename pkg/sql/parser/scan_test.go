@@ -64,6 +64,14 @@ func TestScanner(t *testing.T) {
 		{`&`, []int{'&'}},
 		{`|`, []int{'|'}},
 		{`||`, []int{CONCAT}},
+		{`->`, []int{FETCHVAL}},
+		{`->>`, []int{FETCHTEXT}},
+		{`@>`, []int{CONTAINS}},
+		{`<@`, []int{CONTAINED_BY}},
+		{`?`, []int{'?'}},
+		{`?|`, []int{JSON_SOME_EXISTS}},
+		{`?&`, []int{JSON_ALL_EXISTS}},
+		{`??`, []int{HELPTOKEN}},
 		{`#`, []int{'#'}},
 		{`~`, []int{'~'}},
 		{`!~`, []int{NOT_REGMATCH}},
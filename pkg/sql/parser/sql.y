@@ -265,9 +265,6 @@ func (u *sqlSymUnion) targetList() TargetList {
 func (u *sqlSymUnion) targetListPtr() *TargetList {
     return u.val.(*TargetList)
 }
-func (u *sqlSymUnion) privilegeType() privilege.Kind {
-    return u.val.(privilege.Kind)
-}
 func (u *sqlSymUnion) privilegeList() privilege.List {
     return u.val.(privilege.List)
 }
@@ -319,9 +316,33 @@ func (u *sqlSymUnion) validationBehavior() ValidationBehavior {
 func (u *sqlSymUnion) interleave() *InterleaveDef {
     return u.val.(*InterleaveDef)
 }
+func (u *sqlSymUnion) partitionBy() *PartitionBy {
+    return u.val.(*PartitionBy)
+}
+func (u *sqlSymUnion) listPartition() ListPartition {
+    return u.val.(ListPartition)
+}
+func (u *sqlSymUnion) listPartitions() []ListPartition {
+    return u.val.([]ListPartition)
+}
+func (u *sqlSymUnion) rangePartition() RangePartition {
+    return u.val.(RangePartition)
+}
+func (u *sqlSymUnion) rangePartitions() []RangePartition {
+    return u.val.([]RangePartition)
+}
 func (u *sqlSymUnion) windowDef() *WindowDef {
     return u.val.(*WindowDef)
 }
+func (u *sqlSymUnion) windowFrame() *WindowFrame {
+    return u.val.(*WindowFrame)
+}
+func (u *sqlSymUnion) windowFrameBounds() WindowFrameBounds {
+    return u.val.(WindowFrameBounds)
+}
+func (u *sqlSymUnion) windowFrameBound() *WindowFrameBound {
+    return u.val.(*WindowFrameBound)
+}
 func (u *sqlSymUnion) window() Window {
     return u.val.(Window)
 }
@@ -346,6 +367,36 @@ func (u *sqlSymUnion) kvOptions() []KVOption {
 func (u *sqlSymUnion) transactionModes() TransactionModes {
     return u.val.(TransactionModes)
 }
+func (u *sqlSymUnion) with() *With {
+    if with, ok := u.val.(*With); ok {
+        return with
+    }
+    return nil
+}
+func (u *sqlSymUnion) cte() *CTE {
+    if cte, ok := u.val.(*CTE); ok {
+        return cte
+    }
+    return nil
+}
+func (u *sqlSymUnion) ctes() []*CTE {
+    return u.val.([]*CTE)
+}
+func (u *sqlSymUnion) int64() int64 {
+    return u.val.(int64)
+}
+func (u *sqlSymUnion) seqOpt() SequenceOption {
+    return u.val.(SequenceOption)
+}
+func (u *sqlSymUnion) seqOpts() []SequenceOption {
+    return u.val.([]SequenceOption)
+}
+func (u *sqlSymUnion) referenceAction() ReferenceAction {
+    return u.val.(ReferenceAction)
+}
+func (u *sqlSymUnion) referenceActions() ReferenceActions {
+    return u.val.(ReferenceActions)
+}
 
 %}
 
@@ -359,6 +410,7 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 %token <str>   TYPECAST TYPEANNOTATE DOT_DOT
 %token <str>   LESS_EQUALS GREATER_EQUALS NOT_EQUALS
 %token <str>   NOT_REGMATCH REGIMATCH NOT_REGIMATCH
+%token <str>   FETCHVAL FETCHTEXT CONTAINS CONTAINED_BY JSON_SOME_EXISTS JSON_ALL_EXISTS
 %token <str>   ERROR
 
 // If you want to make any keyword changes, add the new keyword here as well as
@@ -366,17 +418,17 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 // below; search this file for "Keyword category lists".
 
 // Ordinary key words in alphabetical order.
-%token <str>   ACTION ADD
+%token <str>   ACTION ADD ADMIN
 %token <str>   ALL ALTER ANALYSE ANALYZE AND ANY ANNOTATE_TYPE ARRAY AS ASC
 %token <str>   ASYMMETRIC AT
 
 %token <str>   BACKUP BEGIN BETWEEN BIGINT BIGSERIAL BIT
 %token <str>   BLOB BOOL BOOLEAN BOTH BY BYTEA BYTES
 
-%token <str>   CANCEL CASCADE CASE CAST CHAR
+%token <str>   CANCEL CASCADE CASE CAST CHANGEFEED CHAR
 %token <str>   CHARACTER CHARACTERISTICS CHECK
 %token <str>   CLUSTER COALESCE COLLATE COLLATION COLUMN COLUMNS COMMIT
-%token <str>   COMMITTED CONCAT CONFLICT CONSTRAINT CONSTRAINTS
+%token <str>   COMMITTED CONCAT CONFIGURE CONFLICT CONSTRAINT CONSTRAINTS
 %token <str>   COPY COVERING CREATE
 %token <str>   CROSS CSV CUBE CURRENT CURRENT_CATALOG CURRENT_DATE CURRENT_SCHEMA
 %token <str>   CURRENT_ROLE CURRENT_TIME CURRENT_TIMESTAMP
@@ -387,7 +439,8 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 %token <str>   DISCARD DISTINCT DO DOUBLE DROP
 
 %token <str>   ELSE ENCODING END ESCAPE EXCEPT
-%token <str>   EXISTS EXECUTE EXPERIMENTAL_FINGERPRINTS EXPLAIN EXTRACT EXTRACT_DURATION
+%token <str>   EXISTS EXECUTE EXPERIMENTAL EXPERIMENTAL_FINGERPRINTS EXPLAIN EXTRACT
+%token <str>   EXTRACT_DURATION
 
 %token <str>   FALSE FAMILY FETCH FILTER FIRST FLOAT FLOAT4 FLOAT8 FLOORDIV FOLLOWING FOR
 %token <str>   FORCE_INDEX FOREIGN FROM FULL
@@ -396,26 +449,26 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 
 %token <str>   HAVING HELP HIGH HOUR
 
-%token <str>   IMPORT INCREMENTAL IF IFNULL ILIKE IN INET INTERLEAVE
+%token <str>   IMPORT INCREMENT INCREMENTAL IF IFNULL ILIKE IN INET INTERLEAVE INVERTED
 %token <str>   INDEX INDEXES INITIALLY
 %token <str>   INNER INSERT INT INT2VECTOR INT2 INT4 INT8 INT64 INTEGER
 %token <str>   INTERSECT INTERVAL INTO IS ISOLATION
 
-%token <str>   JOB JOBS JOIN
+%token <str>   JOB JOBS JOIN JSON JSONB
 
 %token <str>   KEY KEYS KV
 
 %token <str>   LATERAL LC_CTYPE LC_COLLATE
-%token <str>   LEADING LEAST LEFT LEVEL LIKE LIMIT LOCAL
+%token <str>   LEADING LEAST LEFT LEVEL LIKE LIMIT LIST LOCAL
 %token <str>   LOCALTIME LOCALTIMESTAMP LOW LSHIFT
 
-%token <str>   MATCH MINUTE MONTH
+%token <str>   MATCH MAXVALUE MINUTE MINVALUE MONTH
 
 %token <str>   NAN NAME NAMES NATURAL NEXT NO NO_INDEX_JOIN NORMAL
 %token <str>   NOT NOTHING NULL NULLIF
 %token <str>   NULLS NUMERIC
 
-%token <str>   OF OFF OFFSET OID ON ONLY OPTIONS OR
+%token <str>   OF OFF OFFSET OID ON ONLY OPTION OPTIONS OR
 %token <str>   ORDER ORDINALITY OUT OUTER OVER OVERLAPS OVERLAY
 
 %token <str>   PARENT PARTIAL PARTITION PASSWORD PAUSE PLACING PLANS POSITION
@@ -427,12 +480,12 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 %token <str>   REGCLASS REGPROC REGPROCEDURE REGNAMESPACE REGTYPE
 %token <str>   RENAME REPEATABLE
 %token <str>   RELEASE RESET RESTORE RESTRICT RESUME RETURNING REVOKE RIGHT
-%token <str>   ROLLBACK ROLLUP ROW ROWS RSHIFT
+%token <str>   ROLE ROLES ROLLBACK ROLLUP ROW ROWS RSHIFT
 
-%token <str>   SAVEPOINT SCATTER SEARCH SECOND SELECT SEQUENCES
+%token <str>   SAVEPOINT SCATTER SEARCH SECOND SELECT SEQUENCE SEQUENCES
 %token <str>   SERIAL SERIALIZABLE SESSION SESSIONS SESSION_USER SET SETTING SETTINGS
 %token <str>   SHOW SIMILAR SIMPLE SMALLINT SMALLSERIAL SNAPSHOT SOME SPLIT SQL
-%token <str>   START STATUS STDIN STRICT STRING STORE STORING SUBSTRING
+%token <str>   START STATISTICS STATUS STDIN STRICT STRING STORE STORED STORING SUBSTRING
 %token <str>   SYMMETRIC SYSTEM
 
 %token <str>   TABLE TABLES TEMP TEMPLATE TEMPORARY TESTING_RANGES TESTING_RELOCATE TEXT THEN
@@ -477,6 +530,7 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 %type <Statement> alter_index_stmt
 %type <Statement> alter_view_stmt
 %type <Statement> alter_database_stmt
+%type <Statement> alter_partition_stmt
 
 // ALTER TABLE
 %type <Statement> alter_onetable_stmt
@@ -497,6 +551,9 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 // ALTER VIEW
 %type <Statement> alter_rename_view_stmt
 
+// ALTER PARTITION
+%type <Statement> alter_zone_partition_stmt
+
 %type <Statement> backup_stmt
 %type <Statement> begin_stmt
 
@@ -513,7 +570,11 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 %type <Statement> create_table_stmt
 %type <Statement> create_table_as_stmt
 %type <Statement> create_user_stmt
+%type <Statement> create_role_stmt
 %type <Statement> create_view_stmt
+%type <Statement> create_sequence_stmt
+%type <Statement> create_stats_stmt
+%type <Statement> create_changefeed_stmt
 %type <Statement> delete_stmt
 %type <Statement> discard_stmt
 
@@ -522,7 +583,9 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 %type <Statement> drop_index_stmt
 %type <Statement> drop_table_stmt
 %type <Statement> drop_user_stmt
+%type <Statement> drop_role_stmt
 %type <Statement> drop_view_stmt
+%type <Statement> drop_sequence_stmt
 
 %type <Statement> explain_stmt
 %type <Statement> prepare_stmt
@@ -558,6 +621,8 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 %type <Statement> show_constraints_stmt
 %type <Statement> show_create_table_stmt
 %type <Statement> show_create_view_stmt
+%type <Statement> show_create_sequence_stmt
+%type <Statement> show_stats_stmt
 %type <Statement> show_csettings_stmt
 %type <Statement> show_databases_stmt
 %type <Statement> show_grants_stmt
@@ -571,6 +636,7 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 %type <Statement> show_trace_stmt
 %type <Statement> show_transaction_stmt
 %type <Statement> show_users_stmt
+%type <Statement> show_roles_stmt
 
 %type <str> session_var
 
@@ -583,13 +649,16 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 %type <[]string> opt_incremental
 %type <KVOption> kv_option
 %type <[]KVOption> kv_option_list opt_with_options
+%type <SequenceOption> sequence_option_elem
+%type <[]SequenceOption> sequence_option_list opt_sequence_option_list
+%type <int64> signed_iconst64
 %type <str> import_data_format
 
 %type <*Select> select_no_parens
 %type <SelectStatement> select_clause select_with_parens simple_select values_clause table_clause simple_select_clause
 %type <SelectStatement> set_operation
 
-%type <empty> alter_using
+%type <Expr> alter_using
 %type <Expr> alter_column_default
 %type <Direction> opt_asc_desc
 
@@ -631,6 +700,12 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 
 %type <TableDefs> opt_table_elem_list table_elem_list
 %type <*InterleaveDef> opt_interleave
+%type <*PartitionBy> opt_partition_by partition_by
+%type <ListPartition> list_partition
+%type <[]ListPartition> list_partitions
+%type <RangePartition> range_partition
+%type <[]RangePartition> range_partitions
+%type <Expr> set_zone_config
 %type <empty> opt_all_clause
 %type <bool> distinct_clause
 %type <NameList> opt_column_list
@@ -675,7 +750,7 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 
 %type <bool> opt_unique opt_column
 
-%type <empty> opt_set_data
+%type <bool> opt_set_data
 
 %type <*Limit> limit_clause offset_clause opt_limit_clause
 %type <Expr>  select_limit_value
@@ -764,13 +839,16 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 %type <[]NamedColumnQualification> col_qual_list
 %type <NamedColumnQualification> col_qualification
 %type <ColumnQualification> col_qualification_elem
-%type <empty> key_actions key_delete key_match key_update key_action
+%type <empty> key_match
+%type <ReferenceActions> reference_actions
+%type <ReferenceAction> reference_action reference_on_delete reference_on_update
 
 %type <Expr>  func_application func_expr_common_subexpr
 %type <Expr>  func_expr func_expr_windowless
-%type <empty> common_table_expr
-%type <empty> with_clause opt_with opt_with_clause
-%type <empty> cte_list
+%type <*CTE> common_table_expr
+%type <*With> with_clause opt_with_clause
+%type <[]*CTE> cte_list
+%type <empty> opt_with
 
 %type <empty> within_group_clause
 %type <Expr> filter_clause
@@ -778,15 +856,18 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 %type <Window> window_clause window_definition_list
 %type <*WindowDef> window_definition over_clause window_specification
 %type <str> opt_existing_window_name
-%type <empty> opt_frame_clause frame_extent frame_bound
+%type <*WindowFrame> opt_frame_clause
+%type <WindowFrameBounds> frame_extent
+%type <*WindowFrameBound> frame_bound
 
 %type <[]ColumnID> opt_tableref_col_list tableref_col_list
 
 %type <TargetList>    targets
 %type <*TargetList> on_privilege_target_clause
 %type <NameList>       grantee_list for_grantee_clause
-%type <privilege.List> privileges privilege_list
-%type <privilege.Kind> privilege
+%type <privilege.List> privileges
+%type <NameList>       privilege_list
+%type <str>            privilege
 
 // Precedence: lowest to highest
 %nonassoc  VALUES              // see value_clause
@@ -797,7 +878,7 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 %left      AND
 %right     NOT
 %nonassoc  IS                  // IS sets precedence for IS NULL, etc
-%nonassoc  '<' '>' '=' LESS_EQUALS GREATER_EQUALS NOT_EQUALS
+%nonassoc  '<' '>' '=' LESS_EQUALS GREATER_EQUALS NOT_EQUALS CONTAINS CONTAINED_BY '?' JSON_SOME_EXISTS JSON_ALL_EXISTS
 %nonassoc  '~' BETWEEN IN LIKE ILIKE SIMILAR NOT_REGMATCH REGIMATCH NOT_REGIMATCH NOT_LA
 %nonassoc  ESCAPE              // ESCAPE must be just above LIKE/ILIKE/SIMILAR
 %nonassoc  OVERLAPS
@@ -827,7 +908,7 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 // funny behavior of UNBOUNDED on the SQL standard, though.
 %nonassoc  UNBOUNDED         // ideally should have same precedence as IDENT
 %nonassoc  IDENT NULL PARTITION RANGE ROWS PRECEDING FOLLOWING CUBE ROLLUP
-%left      CONCAT       // multi-character ops
+%left      CONCAT FETCHVAL FETCHTEXT // multi-character ops
 %left      '|'
 %left      '#'
 %left      '&'
@@ -917,13 +998,14 @@ stmt:
 
 // %Help: ALTER
 // %Category: Group
-// %Text: ALTER TABLE, ALTER INDEX, ALTER VIEW, ALTER DATABASE
+// %Text: ALTER TABLE, ALTER INDEX, ALTER VIEW, ALTER DATABASE, ALTER PARTITION
 alter_stmt:
-  alter_table_stmt    // EXTEND WITH HELP: ALTER TABLE
-| alter_index_stmt    // EXTEND WITH HELP: ALTER INDEX
-| alter_view_stmt     // EXTEND WITH HELP: ALTER VIEW
-| alter_database_stmt // EXTEND WITH HELP: ALTER DATABASE
-| ALTER error         // SHOW HELP: ALTER
+  alter_table_stmt     // EXTEND WITH HELP: ALTER TABLE
+| alter_index_stmt     // EXTEND WITH HELP: ALTER INDEX
+| alter_view_stmt      // EXTEND WITH HELP: ALTER VIEW
+| alter_database_stmt  // EXTEND WITH HELP: ALTER DATABASE
+| alter_partition_stmt // EXTEND WITH HELP: ALTER PARTITION
+| ALTER error          // SHOW HELP: ALTER
 
 // %Help: ALTER TABLE - change the definition of a table
 // %Category: DDL
@@ -936,7 +1018,8 @@ alter_stmt:
 //   ALTER TABLE ... DROP [COLUMN] [IF EXISTS] <colname> [RESTRICT | CASCADE]
 //   ALTER TABLE ... DROP CONSTRAINT [IF EXISTS] <constraintname> [RESTRICT | CASCADE]
 //   ALTER TABLE ... ALTER [COLUMN] <colname> {SET DEFAULT <expr> | DROP DEFAULT}
-//   ALTER TABLE ... ALTER [COLUMN] <colname> DROP NOT NULL
+//   ALTER TABLE ... ALTER [COLUMN] <colname> {SET NOT NULL | DROP NOT NULL}
+//   ALTER TABLE ... ALTER [COLUMN] <colname> [SET DATA] TYPE <type> [USING <expr>]
 //   ALTER TABLE ... RENAME TO <newname>
 //   ALTER TABLE ... RENAME [COLUMN] <colname> TO <newname>
 //   ALTER TABLE ... VALIDATE CONSTRAINT <constraintname>
@@ -1002,6 +1085,38 @@ alter_index_stmt:
 // prefix is spread over multiple non-terminals.
 | ALTER INDEX error // SHOW HELP: ALTER INDEX
 
+// %Help: ALTER PARTITION - change the zone config of a partition
+// %Category: DDL
+// %Text:
+// ALTER PARTITION <name> OF TABLE <tablename> EXPERIMENTAL CONFIGURE ZONE <yaml>
+//
+// The zone config is merged with the zone config inherited by the
+// partition. Use NULL instead of a YAML string to remove it.
+// %SeeAlso: CREATE TABLE, CREATE INDEX
+alter_partition_stmt:
+  alter_zone_partition_stmt
+// ALTER PARTITION has its error help token here because the ALTER PARTITION
+// prefix is spread over multiple non-terminals.
+| ALTER PARTITION error // SHOW HELP: ALTER PARTITION
+
+alter_zone_partition_stmt:
+  ALTER PARTITION name OF TABLE qualified_name set_zone_config
+  {
+    $$.val = &SetZoneConfig{
+      ZoneSpecifier: ZoneSpecifier{
+        Table: $6.normalizableTableName(),
+        Partition: Name($3),
+      },
+      YAMLConfig: $7.expr(),
+    }
+  }
+
+set_zone_config:
+  EXPERIMENTAL CONFIGURE ZONE a_expr
+  {
+    $$.val = $4.expr()
+  }
+
 alter_onetable_stmt:
   ALTER TABLE relation_expr alter_table_cmds
   {
@@ -1100,7 +1215,10 @@ alter_table_cmd:
     $$.val = &AlterTableDropNotNull{columnKeyword: $2.bool(), Column: Name($3)}
   }
   // ALTER TABLE <name> ALTER [COLUMN] <colname> SET NOT NULL
-| ALTER opt_column name SET NOT NULL { return unimplemented(sqllex, "alter set non null") }
+| ALTER opt_column name SET NOT NULL
+  {
+    $$.val = &AlterTableSetNotNull{columnKeyword: $2.bool(), Column: Name($3)}
+  }
   // ALTER TABLE <name> DROP [COLUMN] IF EXISTS <colname> [RESTRICT|CASCADE]
 | DROP opt_column IF EXISTS name opt_drop_behavior
   {
@@ -1123,7 +1241,16 @@ alter_table_cmd:
   }
   // ALTER TABLE <name> ALTER [COLUMN] <colname> [SET DATA] TYPE <typename>
   //     [ USING <expression> ]
-| ALTER opt_column name opt_set_data TYPE typename opt_collate_clause alter_using { return unimplemented(sqllex, "alter set type") }
+| ALTER opt_column name opt_set_data TYPE typename opt_collate_clause alter_using
+  {
+    $$.val = &AlterTableSetType{
+      columnKeyword: $2.bool(),
+      setData: $4.bool(),
+      Column: Name($3),
+      ToType: $6.colType(),
+      Using: $8.expr(),
+    }
+  }
   // ALTER TABLE <name> ADD CONSTRAINT ...
 | ADD table_constraint opt_validate_behavior
   {
@@ -1199,8 +1326,14 @@ opt_collate_clause:
 | /* EMPTY */ {}
 
 alter_using:
-  USING a_expr { return unimplemented(sqllex, "alter using") }
-| /* EMPTY */ {}
+  USING a_expr
+  {
+    $$.val = $2.expr()
+  }
+| /* EMPTY */
+  {
+    $$.val = nil
+  }
 
 // %Help: BACKUP - back up data to external storage
 // %Category: CCL
@@ -1410,7 +1543,8 @@ cancel_query_stmt:
 // %Category: Group
 // %Text:
 // CREATE DATABASE, CREATE TABLE, CREATE INDEX, CREATE TABLE AS,
-// CREATE USER, CREATE VIEW
+// CREATE USER, CREATE VIEW, CREATE SEQUENCE, CREATE STATISTICS,
+// CREATE CHANGEFEED
 create_stmt:
   create_database_stmt // EXTEND WITH HELP: CREATE DATABASE
 | create_index_stmt    // EXTEND WITH HELP: CREATE INDEX
@@ -1419,7 +1553,11 @@ create_stmt:
 // Error case for both CREATE TABLE and CREATE TABLE ... AS in one
 | CREATE TABLE error   // SHOW HELP: CREATE TABLE
 | create_user_stmt     // EXTEND WITH HELP: CREATE USER
+| create_role_stmt     // EXTEND WITH HELP: CREATE ROLE
 | create_view_stmt     // EXTEND WITH HELP: CREATE VIEW
+| create_sequence_stmt // EXTEND WITH HELP: CREATE SEQUENCE
+| create_stats_stmt    // EXTEND WITH HELP: CREATE STATISTICS
+| create_changefeed_stmt // EXTEND WITH HELP: CREATE CHANGEFEED
 | CREATE error         // SHOW HELP: CREATE
 
 // %Help: DELETE - delete rows from a table
@@ -1432,6 +1570,7 @@ delete_stmt:
   opt_with_clause DELETE FROM relation_expr_opt_alias where_clause opt_limit_clause returning_clause
   {
     $$.val = &Delete{
+      With: $1.with(),
       Table: $4.tblExpr(),
       Where: newWhere(astWhere, $5.expr()),
       Limit: $6.limit(),
@@ -1456,13 +1595,15 @@ discard_stmt:
 
 // %Help: DROP
 // %Category: Group
-// %Text: DROP DATABASE, DROP INDEX, DROP TABLE, DROP VIEW, DROP USER
+// %Text: DROP DATABASE, DROP INDEX, DROP TABLE, DROP VIEW, DROP SEQUENCE, DROP USER
 drop_stmt:
   drop_database_stmt // EXTEND WITH HELP: DROP DATABASE
 | drop_index_stmt    // EXTEND WITH HELP: DROP INDEX
 | drop_table_stmt    // EXTEND WITH HELP: DROP TABLE
 | drop_view_stmt     // EXTEND WITH HELP: DROP VIEW
+| drop_sequence_stmt // EXTEND WITH HELP: DROP SEQUENCE
 | drop_user_stmt     // EXTEND WITH HELP: DROP USER
+| drop_role_stmt     // EXTEND WITH HELP: DROP ROLE
 | DROP error         // SHOW HELP: DROP
 
 // %Help: DROP VIEW - remove a view
@@ -1480,6 +1621,21 @@ drop_view_stmt:
   }
 | DROP VIEW error // SHOW HELP: DROP VIEW
 
+// %Help: DROP SEQUENCE - remove a sequence
+// %Category: DDL
+// %Text: DROP SEQUENCE [IF EXISTS] <sequenceName> [, ...] [CASCADE | RESTRICT]
+// %SeeAlso: DROP
+drop_sequence_stmt:
+  DROP SEQUENCE table_name_list opt_drop_behavior
+  {
+    $$.val = &DropSequence{Names: $3.tableNameReferences(), IfExists: false, DropBehavior: $4.dropBehavior()}
+  }
+| DROP SEQUENCE IF EXISTS table_name_list opt_drop_behavior
+  {
+    $$.val = &DropSequence{Names: $5.tableNameReferences(), IfExists: true, DropBehavior: $6.dropBehavior()}
+  }
+| DROP SEQUENCE error // SHOW HELP: DROP SEQUENCE
+
 // %Help: DROP TABLE - remove a table
 // %Category: DDL
 // %Text: DROP TABLE [IF EXISTS] <tablename> [, ...] [CASCADE | RESTRICT]
@@ -1556,6 +1712,21 @@ drop_user_stmt:
   }
 | DROP USER error // SHOW HELP: DROP USER
 
+// %Help: DROP ROLE - remove a role
+// %Category: Priv
+// %Text: DROP ROLE [IF EXISTS] <role> [, ...]
+// %SeeAlso: CREATE ROLE, SHOW ROLES
+drop_role_stmt:
+  DROP ROLE name_list
+  {
+    $$.val = &DropRole{Names: $3.nameList(), IfExists: false}
+  }
+| DROP ROLE IF EXISTS name_list
+  {
+    $$.val = &DropRole{Names: $5.nameList(), IfExists: true}
+  }
+| DROP ROLE error // SHOW HELP: DROP ROLE
+
 table_name_list:
   any_name
   {
@@ -1731,10 +1902,13 @@ deallocate_stmt:
   }
 | DEALLOCATE error // SHOW HELP: DEALLOCATE
 
-// %Help: GRANT - define access privileges
+// %Help: GRANT - define access privileges and roles
 // %Category: Priv
 // %Text:
-// GRANT {ALL | <privileges...> } ON <targets...> TO <grantees...>
+// Grant privileges:
+//   GRANT {ALL | <privileges...> } ON <targets...> TO <grantees...>
+// Grant role membership:
+//   GRANT <roles...> TO <grantees...> [WITH ADMIN OPTION]
 //
 // Privileges:
 //   CREATE, DROP, GRANT, SELECT, INSERT, DELETE, UPDATE
@@ -1749,12 +1923,23 @@ grant_stmt:
   {
     $$.val = &Grant{Privileges: $2.privilegeList(), Grantees: $6.nameList(), Targets: $4.targetList()}
   }
+| GRANT privilege_list TO grantee_list
+  {
+    $$.val = &GrantRole{Roles: $2.nameList(), Members: $4.nameList(), AdminOption: false}
+  }
+| GRANT privilege_list TO grantee_list WITH ADMIN OPTION
+  {
+    $$.val = &GrantRole{Roles: $2.nameList(), Members: $4.nameList(), AdminOption: true}
+  }
 | GRANT error // SHOW HELP: GRANT
 
-// %Help: REVOKE - remove access privileges
+// %Help: REVOKE - remove access privileges and role memberships
 // %Category: Priv
 // %Text:
-// REVOKE {ALL | <privileges...> } ON <targets...> FROM <grantees...>
+// Revoke privileges:
+//   REVOKE {ALL | <privileges...> } ON <targets...> FROM <grantees...>
+// Revoke role membership:
+//   REVOKE [ADMIN OPTION FOR] <roles...> FROM <grantees...>
 //
 // Privileges:
 //   CREATE, DROP, GRANT, SELECT, INSERT, DELETE, UPDATE
@@ -1769,6 +1954,14 @@ revoke_stmt:
   {
     $$.val = &Revoke{Privileges: $2.privilegeList(), Grantees: $6.nameList(), Targets: $4.targetList()}
   }
+| REVOKE privilege_list FROM grantee_list
+  {
+    $$.val = &RevokeRole{Roles: $2.nameList(), Members: $4.nameList(), AdminOption: false}
+  }
+| REVOKE ADMIN OPTION FOR privilege_list FROM grantee_list
+  {
+    $$.val = &RevokeRole{Roles: $5.nameList(), Members: $7.nameList(), AdminOption: true}
+  }
 | REVOKE error // SHOW HELP: REVOKE
 
 targets:
@@ -1791,48 +1984,36 @@ privileges:
   {
     $$.val = privilege.List{privilege.ALL}
   }
-  | privilege_list { }
+  | privilege_list
+  {
+    privList, err := privilege.ListFromStrings($1.nameList().ToStrings())
+    if err != nil {
+      sqllex.Error(err.Error())
+      return 1
+    }
+    $$.val = privList
+  }
 
+// privilege_list is either a list of privileges or a list of roles, as the
+// two can only be distinguished by what follows them in GRANT and REVOKE.
 privilege_list:
   privilege
   {
-    $$.val = privilege.List{$1.privilegeType()}
+    $$.val = NameList{Name($1)}
   }
   | privilege_list ',' privilege
   {
-    $$.val = append($1.privilegeList(), $3.privilegeType())
+    $$.val = append($1.nameList(), Name($3))
   }
 
-// This list must match the list of privileges in sql/privilege/privilege.go.
+// Privileges are parsed as names and checked against the list of privileges
+// in sql/privilege/privilege.go. The reserved keywords naming a privilege
+// are listed explicitly.
 privilege:
-  CREATE
-  {
-    $$.val = privilege.CREATE
-  }
-| DROP
-  {
-    $$.val = privilege.DROP
-  }
+  name
+| CREATE
 | GRANT
-  {
-    $$.val = privilege.GRANT
-  }
 | SELECT
-  {
-    $$.val = privilege.SELECT
-  }
-| INSERT
-  {
-    $$.val = privilege.INSERT
-  }
-| DELETE
-  {
-    $$.val = privilege.DELETE
-  }
-| UPDATE
-  {
-    $$.val = privilege.UPDATE
-  }
 
 // TODO(marc): this should not be 'name', but should instead be a
 // type just for usernames.
@@ -2094,14 +2275,16 @@ non_reserved_word_or_sconst:
 // %Category: Group
 // %Text:
 // SHOW SESSION, SHOW CLUSTER SETTING, SHOW DATABASES, SHOW TABLES, SHOW COLUMNS, SHOW INDEXES,
-// SHOW CONSTRAINTS, SHOW CREATE TABLE, SHOW CREATE VIEW, SHOW USERS, SHOW TRANSACTION, SHOW BACKUP,
-// SHOW JOBS, SHOW QUERIES, SHOW SESSIONS, SHOW TRACE
+// SHOW CONSTRAINTS, SHOW CREATE TABLE, SHOW CREATE VIEW, SHOW CREATE SEQUENCE, SHOW USERS,
+// SHOW TRANSACTION, SHOW BACKUP, SHOW JOBS, SHOW QUERIES, SHOW SESSIONS, SHOW TRACE,
+// SHOW STATISTICS
 show_stmt:
   show_backup_stmt       // EXTEND WITH HELP: SHOW BACKUP
 | show_columns_stmt      // EXTEND WITH HELP: SHOW COLUMNS
 | show_constraints_stmt  // EXTEND WITH HELP: SHOW CONSTRAINTS
 | show_create_table_stmt // EXTEND WITH HELP: SHOW CREATE TABLE
 | show_create_view_stmt  // EXTEND WITH HELP: SHOW CREATE VIEW
+| show_create_sequence_stmt // EXTEND WITH HELP: SHOW CREATE SEQUENCE
 | show_csettings_stmt    // EXTEND WITH HELP: SHOW CLUSTER SETTING
 | show_databases_stmt    // EXTEND WITH HELP: SHOW DATABASES
 | show_grants_stmt       // EXTEND WITH HELP: SHOW GRANTS
@@ -2110,11 +2293,13 @@ show_stmt:
 | show_queries_stmt      // EXTEND WITH HELP: SHOW QUERIES
 | show_session_stmt      // EXTEND WITH HELP: SHOW SESSION
 | show_sessions_stmt     // EXTEND WITH HELP: SHOW SESSIONS
+| show_stats_stmt        // EXTEND WITH HELP: SHOW STATISTICS
 | show_tables_stmt       // EXTEND WITH HELP: SHOW TABLES
 | show_testing_stmt
 | show_trace_stmt        // EXTEND WITH HELP: SHOW TRACE
 | show_transaction_stmt  // EXTEND WITH HELP: SHOW TRANSACTION
 | show_users_stmt        // EXTEND WITH HELP: SHOW USERS
+| show_roles_stmt        // EXTEND WITH HELP: SHOW ROLES
 | SHOW error             // SHOW HELP: SHOW
 
 // %Help: SHOW SESSION - display session variables
@@ -2377,6 +2562,27 @@ show_create_view_stmt:
   }
 | SHOW CREATE VIEW error // SHOW HELP: SHOW CREATE VIEW
 
+// %Help: SHOW CREATE SEQUENCE - display the CREATE SEQUENCE statement for a sequence
+// %Category: Misc
+// %Text: SHOW CREATE SEQUENCE <seqname>
+show_create_sequence_stmt:
+  SHOW CREATE SEQUENCE var_name
+  {
+    $$.val = &ShowCreateSequence{Sequence: $4.normalizableTableName()}
+  }
+| SHOW CREATE SEQUENCE error // SHOW HELP: SHOW CREATE SEQUENCE
+
+// %Help: SHOW STATISTICS - display table statistics
+// %Category: Misc
+// %Text: SHOW STATISTICS FOR TABLE <table_name>
+// %SeeAlso: CREATE STATISTICS
+show_stats_stmt:
+  SHOW STATISTICS FOR TABLE var_name
+  {
+    $$.val = &ShowTableStats{Table: $5.normalizableTableName()}
+  }
+| SHOW STATISTICS error // SHOW HELP: SHOW STATISTICS
+
 // %Help: SHOW USERS - list defined users
 // %Category: Priv
 // %Text: SHOW USERS
@@ -2388,6 +2594,17 @@ show_users_stmt:
   }
 | SHOW USERS error // SHOW HELP: SHOW USERS
 
+// %Help: SHOW ROLES - list defined roles
+// %Category: Priv
+// %Text: SHOW ROLES
+// %SeeAlso: CREATE ROLE, DROP ROLE
+show_roles_stmt:
+  SHOW ROLES
+  {
+    $$.val = &ShowRoles{}
+  }
+| SHOW ROLES error // SHOW HELP: SHOW ROLES
+
 show_testing_stmt:
   SHOW TESTING_RANGES FROM TABLE qualified_name
   {
@@ -2440,42 +2657,52 @@ pause_stmt:
 // %Help: CREATE TABLE - create a new table
 // %Category: DDL
 // %Text:
-// CREATE TABLE [IF NOT EXISTS] <tablename> ( <elements...> ) [<interleave>]
+// CREATE TABLE [IF NOT EXISTS] <tablename> ( <elements...> ) [<interleave>] [<partition by>]
 // CREATE TABLE [IF NOT EXISTS] <tablename> [( <colnames...> )] AS <source>
 //
 // Table elements:
 //    <name> <type> [<qualifiers...>]
 //    [UNIQUE] INDEX [<name>] ( <colname> [ASC | DESC] [, ...] )
-//                            [STORING ( <colnames...> )] [<interleave>]
+//                            [STORING ( <colnames...> )] [<interleave>] [<partition by>]
+//    INVERTED INDEX [<name>] ( <colname> )
 //    FAMILY [<name>] ( <colnames...> )
 //    [CONSTRAINT <name>] <constraint>
 //
 // Table constraints:
 //    PRIMARY KEY ( <colnames...> )
-//    FOREIGN KEY ( <colnames...> ) REFERENCES <tablename> [( <colnames...> )]
-//    UNIQUE ( <colnames... ) [STORING ( <colnames...> )] [<interleave>]
+//    FOREIGN KEY ( <colnames...> ) REFERENCES <tablename> [( <colnames...> )] [<reference actions>]
+//    UNIQUE ( <colnames... ) [STORING ( <colnames...> )] [<interleave>] [<partition by>]
 //    CHECK ( <expr> )
 //
 // Column qualifiers:
 //   [CONSTRAINT <constraintname>] {NULL | NOT NULL | UNIQUE | PRIMARY KEY | CHECK (<expr>) | DEFAULT <expr>}
 //   FAMILY <familyname>, CREATE [IF NOT EXISTS] FAMILY [<familyname>]
-//   REFERENCES <tablename> [( <colnames...> )]
+//   REFERENCES <tablename> [( <colnames...> )] [<reference actions>]
 //   COLLATE <collationname>
+//   AS ( <expr> ) STORED
+//
+// Reference actions:
+//   [ON DELETE <action>] [ON UPDATE <action>]
+//   where <action> is one of NO ACTION, RESTRICT, CASCADE, SET NULL or SET DEFAULT
 //
 // Interleave clause:
 //    INTERLEAVE IN PARENT <tablename> ( <colnames...> ) [CASCADE | RESTRICT]
 //
+// Partition by clause:
+//    PARTITION BY LIST ( <colnames...> ) ( PARTITION <name> VALUES IN ( <exprs...> ) [, ...] )
+//    PARTITION BY RANGE ( <colnames...> ) ( PARTITION <name> VALUES < {( <exprs...> ) | MAXVALUE} [, ...] )
+//
 // %SeeAlso: SHOW TABLES, CREATE VIEW, SHOW CREATE TABLE,
 // WEBDOCS/create-table.html
 // WEBDOCS/create-table-as.html
 create_table_stmt:
-  CREATE TABLE any_name '(' opt_table_elem_list ')' opt_interleave
+  CREATE TABLE any_name '(' opt_table_elem_list ')' opt_interleave opt_partition_by
   {
-    $$.val = &CreateTable{Table: $3.normalizableTableName(), IfNotExists: false, Interleave: $7.interleave(), Defs: $5.tblDefs(), AsSource: nil, AsColumnNames: nil}
+    $$.val = &CreateTable{Table: $3.normalizableTableName(), IfNotExists: false, Interleave: $7.interleave(), PartitionBy: $8.partitionBy(), Defs: $5.tblDefs(), AsSource: nil, AsColumnNames: nil}
   }
-| CREATE TABLE IF NOT EXISTS any_name '(' opt_table_elem_list ')' opt_interleave
+| CREATE TABLE IF NOT EXISTS any_name '(' opt_table_elem_list ')' opt_interleave opt_partition_by
   {
-    $$.val = &CreateTable{Table: $6.normalizableTableName(), IfNotExists: true, Interleave: $10.interleave(), Defs: $8.tblDefs(), AsSource: nil, AsColumnNames: nil}
+    $$.val = &CreateTable{Table: $6.normalizableTableName(), IfNotExists: true, Interleave: $10.interleave(), PartitionBy: $11.partitionBy(), Defs: $8.tblDefs(), AsSource: nil, AsColumnNames: nil}
   }
 
 create_table_as_stmt:
@@ -2531,6 +2758,73 @@ opt_interleave:
     $$.val = (*InterleaveDef)(nil)
   }
 
+opt_partition_by:
+  partition_by
+| /* EMPTY */
+  {
+    $$.val = (*PartitionBy)(nil)
+  }
+
+partition_by:
+  PARTITION BY LIST '(' name_list ')' '(' list_partitions ')'
+  {
+    $$.val = &PartitionBy{
+      Fields: $5.nameList(),
+      List: $8.listPartitions(),
+    }
+  }
+| PARTITION BY RANGE '(' name_list ')' '(' range_partitions ')'
+  {
+    $$.val = &PartitionBy{
+      Fields: $5.nameList(),
+      Range: $8.rangePartitions(),
+    }
+  }
+
+list_partitions:
+  list_partition
+  {
+    $$.val = []ListPartition{$1.listPartition()}
+  }
+| list_partitions ',' list_partition
+  {
+    $$.val = append($1.listPartitions(), $3.listPartition())
+  }
+
+list_partition:
+  PARTITION name VALUES IN '(' ctext_expr_list ')'
+  {
+    $$.val = ListPartition{
+      Name: Name($2),
+      Exprs: $6.exprs(),
+    }
+  }
+
+range_partitions:
+  range_partition
+  {
+    $$.val = []RangePartition{$1.rangePartition()}
+  }
+| range_partitions ',' range_partition
+  {
+    $$.val = append($1.rangePartitions(), $3.rangePartition())
+  }
+
+range_partition:
+  PARTITION name VALUES '<' '(' expr_list ')'
+  {
+    $$.val = RangePartition{
+      Name: Name($2),
+      Exprs: $6.exprs(),
+    }
+  }
+| PARTITION name VALUES '<' MAXVALUE
+  {
+    $$.val = RangePartition{
+      Name: Name($2),
+    }
+  }
+
 // TODO(dan): This can be removed in favor of opt_drop_behavior when #7854 is fixed.
 opt_interleave_drop_behavior:
   CASCADE
@@ -2632,25 +2926,31 @@ col_qualification_elem:
   {
     $$.val = &ColumnDefault{Expr: $2.expr()}
   }
-| REFERENCES qualified_name opt_name_parens key_match key_actions
+| REFERENCES qualified_name opt_name_parens key_match reference_actions
  {
     $$.val = &ColumnFKConstraint{
       Table: $2.normalizableTableName(),
       Col: Name($3),
+      Actions: $5.referenceActions(),
     }
  }
+| AS '(' a_expr ')' STORED
+  {
+    $$.val = &ColumnComputedDef{Expr: $3.expr()}
+  }
 
 index_def:
-  INDEX opt_name '(' index_params ')' opt_storing opt_interleave
+  INDEX opt_name '(' index_params ')' opt_storing opt_interleave opt_partition_by
   {
     $$.val = &IndexTableDef{
       Name:    Name($2),
       Columns: $4.idxElems(),
       Storing: $6.nameList(),
       Interleave: $7.interleave(),
+      PartitionBy: $8.partitionBy(),
     }
   }
-| UNIQUE INDEX opt_name '(' index_params ')' opt_storing opt_interleave
+| UNIQUE INDEX opt_name '(' index_params ')' opt_storing opt_interleave opt_partition_by
   {
     $$.val = &UniqueConstraintTableDef{
       IndexTableDef: IndexTableDef {
@@ -2658,9 +2958,18 @@ index_def:
         Columns: $5.idxElems(),
         Storing: $7.nameList(),
         Interleave: $8.interleave(),
+        PartitionBy: $9.partitionBy(),
       },
     }
   }
+| INVERTED INDEX opt_name '(' index_params ')'
+  {
+    $$.val = &IndexTableDef{
+      Name:     Name($3),
+      Columns:  $5.idxElems(),
+      Inverted: true,
+    }
+  }
 
 family_def:
   FAMILY opt_name '(' name_list ')'
@@ -2692,13 +3001,14 @@ constraint_elem:
       Expr: $3.expr(),
     }
   }
-| UNIQUE '(' index_params ')' opt_storing opt_interleave
+| UNIQUE '(' index_params ')' opt_storing opt_interleave opt_partition_by
   {
     $$.val = &UniqueConstraintTableDef{
       IndexTableDef: IndexTableDef{
         Columns: $3.idxElems(),
         Storing: $5.nameList(),
         Interleave: $6.interleave(),
+        PartitionBy: $7.partitionBy(),
       },
     }
   }
@@ -2712,12 +3022,13 @@ constraint_elem:
     }
   }
 | FOREIGN KEY '(' name_list ')' REFERENCES qualified_name
-    opt_column_list key_match key_actions
+    opt_column_list key_match reference_actions
   {
     $$.val = &ForeignKeyConstraintTableDef{
       Table: $7.normalizableTableName(),
       FromCols: $4.nameList(),
       ToCols: $8.nameList(),
+      Actions: $10.referenceActions(),
     }
   }
 
@@ -2763,27 +3074,62 @@ key_match:
 // We combine the update and delete actions into one value temporarily for
 // simplicity of parsing, and then break them down again in the calling
 // production.
-key_actions:
-  key_update {}
-| key_delete {}
-| key_update key_delete {}
-| key_delete key_update {}
-| /* EMPTY */ {}
+reference_actions:
+  reference_on_update
+  {
+     $$.val = ReferenceActions{Update: $1.referenceAction()}
+  }
+| reference_on_delete
+  {
+     $$.val = ReferenceActions{Delete: $1.referenceAction()}
+  }
+| reference_on_update reference_on_delete
+  {
+    $$.val = ReferenceActions{Delete: $2.referenceAction(), Update: $1.referenceAction()}
+  }
+| reference_on_delete reference_on_update
+  {
+    $$.val = ReferenceActions{Delete: $1.referenceAction(), Update: $2.referenceAction()}
+  }
+| /* EMPTY */
+  {
+    $$.val = ReferenceActions{}
+  }
 
-key_update:
-  ON UPDATE key_action {}
+reference_on_update:
+  ON UPDATE reference_action
+  {
+    $$.val = $3.referenceAction()
+  }
 
-key_delete:
-  ON DELETE key_action {}
+reference_on_delete:
+  ON DELETE reference_action
+  {
+    $$.val = $3.referenceAction()
+  }
 
-key_action:
-  NO ACTION { return unimplemented(sqllex, "no action") }
-// RESTRICT is currently the default and only supported ON DELETE/UPDATE
-// behavior and thus needs no special handling.
-| RESTRICT {}
-| CASCADE { return unimplemented(sqllex, "action cascade") }
-| SET NULL { return unimplemented(sqllex, "action set null") }
-| SET DEFAULT { return unimplemented(sqllex, "action set default") }
+reference_action:
+// NO ACTION is the default.
+  NO ACTION
+  {
+    $$.val = NoAction
+  }
+| RESTRICT
+  {
+    $$.val = Restrict
+  }
+| CASCADE
+  {
+    $$.val = Cascade
+  }
+| SET NULL
+  {
+    $$.val = SetNull
+  }
+| SET DEFAULT
+  {
+    $$.val = SetDefault
+  }
 
 numeric_only:
   FCONST
@@ -2821,6 +3167,21 @@ create_user_stmt:
   }
 | CREATE USER error // SHOW HELP: CREATE USER
 
+// %Help: CREATE ROLE - define a new role
+// %Category: Priv
+// %Text: CREATE ROLE [IF NOT EXISTS] <name>
+// %SeeAlso: DROP ROLE, SHOW ROLES, GRANT
+create_role_stmt:
+  CREATE ROLE name
+  {
+    $$.val = &CreateRole{Name: Name($3), IfNotExists: false}
+  }
+| CREATE ROLE IF NOT EXISTS name
+  {
+    $$.val = &CreateRole{Name: Name($6), IfNotExists: true}
+  }
+| CREATE ROLE error // SHOW HELP: CREATE ROLE
+
 opt_password:
   opt_with PASSWORD SCONST
   {
@@ -2848,20 +3209,123 @@ create_view_stmt:
 
 // TODO(a-robinson): CREATE OR REPLACE VIEW support (#2971).
 
+// %Help: CREATE SEQUENCE - create a new sequence
+// %Category: DDL
+// %Text:
+// CREATE SEQUENCE [IF NOT EXISTS] <seqname>
+//   [INCREMENT [BY] <increment>]
+//   [MINVALUE <minvalue> | NO MINVALUE]
+//   [MAXVALUE <maxvalue> | NO MAXVALUE]
+//   [START [WITH] <start>]
+//   [NO CYCLE]
+// %SeeAlso: CREATE TABLE, SHOW CREATE SEQUENCE, DROP SEQUENCE
+create_sequence_stmt:
+  CREATE SEQUENCE any_name opt_sequence_option_list
+  {
+    $$.val = &CreateSequence{
+      Name: $3.normalizableTableName(),
+      Options: $4.seqOpts(),
+    }
+  }
+| CREATE SEQUENCE IF NOT EXISTS any_name opt_sequence_option_list
+  {
+    $$.val = &CreateSequence{
+      IfNotExists: true,
+      Name: $6.normalizableTableName(),
+      Options: $7.seqOpts(),
+    }
+  }
+| CREATE SEQUENCE error // SHOW HELP: CREATE SEQUENCE
+
+// %Help: CREATE STATISTICS - create a new table statistic
+// %Category: Misc
+// %Text:
+// CREATE STATISTICS <statisticname>
+//   ON <colname> [, ...]
+//   FROM <tablename>
+// %SeeAlso: SHOW STATISTICS
+create_stats_stmt:
+  CREATE STATISTICS name ON name_list FROM qualified_name
+  {
+    $$.val = &CreateStats{
+      Name: Name($3),
+      ColumnNames: $5.nameList(),
+      Table: $7.normalizableTableName(),
+    }
+  }
+| CREATE STATISTICS error // SHOW HELP: CREATE STATISTICS
+
+// %Help: CREATE CHANGEFEED - emit the changes to tables to a sink
+// %Category: Misc
+// %Text:
+// CREATE CHANGEFEED FOR TABLE <tablename> [, ...] INTO '<sink>'
+//
+// Sinks:
+//    'file:///path/to/file'    append newline-delimited JSON messages to a file
+//    'http://host/path'        POST newline-delimited JSON messages to a URL
+// %SeeAlso: SHOW JOBS, CANCEL JOB
+create_changefeed_stmt:
+  CREATE CHANGEFEED FOR targets INTO string_or_placeholder
+  {
+    $$.val = &CreateChangefeed{
+      Targets: $4.targetList(),
+      SinkURI: $6.expr(),
+    }
+  }
+| CREATE CHANGEFEED error // SHOW HELP: CREATE CHANGEFEED
+
+opt_sequence_option_list:
+  sequence_option_list
+| /* EMPTY */ { $$.val = []SequenceOption(nil) }
+
+sequence_option_list:
+  sequence_option_elem
+  {
+    $$.val = []SequenceOption{$1.seqOpt()}
+  }
+| sequence_option_list sequence_option_elem
+  {
+    $$.val = append($1.seqOpts(), $2.seqOpt())
+  }
+
+sequence_option_elem:
+  NO CYCLE                     { $$.val = SequenceOption{Name: SeqOptNoCycle} }
+| CYCLE                        { return unimplemented(sqllex, "create sequence cycle") }
+| INCREMENT signed_iconst64    { x := $2.int64()
+                                 $$.val = SequenceOption{Name: SeqOptIncrement, IntVal: &x} }
+| INCREMENT BY signed_iconst64 { x := $3.int64()
+                                 $$.val = SequenceOption{Name: SeqOptIncrement, IntVal: &x, OptionalWord: true} }
+| MINVALUE signed_iconst64     { x := $2.int64()
+                                 $$.val = SequenceOption{Name: SeqOptMinValue, IntVal: &x} }
+| NO MINVALUE                  { $$.val = SequenceOption{Name: SeqOptMinValue} }
+| MAXVALUE signed_iconst64     { x := $2.int64()
+                                 $$.val = SequenceOption{Name: SeqOptMaxValue, IntVal: &x} }
+| NO MAXVALUE                  { $$.val = SequenceOption{Name: SeqOptMaxValue} }
+| START signed_iconst64        { x := $2.int64()
+                                 $$.val = SequenceOption{Name: SeqOptStart, IntVal: &x} }
+| START WITH signed_iconst64   { x := $3.int64()
+                                 $$.val = SequenceOption{Name: SeqOptStart, IntVal: &x, OptionalWord: true} }
+
 // %Help: CREATE INDEX - create a new index
 // %Category: DDL
 // %Text:
 // CREATE [UNIQUE] INDEX [IF NOT EXISTS] [<idxname>]
 //        ON <tablename> ( <colname> [ASC | DESC] [, ...] )
-//        [STORING ( <colnames...> )] [<interleave>]
+//        [STORING ( <colnames...> )] [<interleave>] [<partition by>]
+// CREATE INVERTED INDEX [IF NOT EXISTS] [<idxname>]
+//        ON <tablename> ( <colname> )
 //
 // Interleave clause:
 //    INTERLEAVE IN PARENT <tablename> ( <colnames...> ) [CASCADE | RESTRICT]
 //
+// Partition by clause:
+//    PARTITION BY LIST ( <colnames...> ) ( PARTITION <name> VALUES IN ( <exprs...> ) [, ...] )
+//    PARTITION BY RANGE ( <colnames...> ) ( PARTITION <name> VALUES < {( <exprs...> ) | MAXVALUE} [, ...] )
+//
 // %SeeAlso: CREATE TABLE, SHOW INDEXES, SHOW CREATE INDEX,
 // WEBDOCS/create-index.html
 create_index_stmt:
-  CREATE opt_unique INDEX opt_name ON qualified_name '(' index_params ')' opt_storing opt_interleave
+  CREATE opt_unique INDEX opt_name ON qualified_name '(' index_params ')' opt_storing opt_interleave opt_partition_by
   {
     $$.val = &CreateIndex{
       Name:    Name($4),
@@ -2870,9 +3334,10 @@ create_index_stmt:
       Columns: $8.idxElems(),
       Storing: $10.nameList(),
       Interleave: $11.interleave(),
+      PartitionBy: $12.partitionBy(),
     }
   }
-| CREATE opt_unique INDEX IF NOT EXISTS name ON qualified_name '(' index_params ')' opt_storing opt_interleave
+| CREATE opt_unique INDEX IF NOT EXISTS name ON qualified_name '(' index_params ')' opt_storing opt_interleave opt_partition_by
   {
     $$.val = &CreateIndex{
       Name:        Name($7),
@@ -2882,9 +3347,30 @@ create_index_stmt:
       Columns:     $11.idxElems(),
       Storing:     $13.nameList(),
       Interleave: $14.interleave(),
+      PartitionBy: $15.partitionBy(),
+    }
+  }
+| CREATE INVERTED INDEX opt_name ON qualified_name '(' index_params ')'
+  {
+    $$.val = &CreateIndex{
+      Name:     Name($4),
+      Table:    $6.normalizableTableName(),
+      Inverted: true,
+      Columns:  $8.idxElems(),
+    }
+  }
+| CREATE INVERTED INDEX IF NOT EXISTS name ON qualified_name '(' index_params ')'
+  {
+    $$.val = &CreateIndex{
+      Name:        Name($7),
+      Table:       $9.normalizableTableName(),
+      Inverted:    true,
+      IfNotExists: true,
+      Columns:     $11.idxElems(),
     }
   }
 | CREATE opt_unique INDEX error // SHOW HELP: CREATE INDEX
+| CREATE INVERTED INDEX error // SHOW HELP: CREATE INDEX
 
 opt_unique:
   UNIQUE
@@ -2994,8 +3480,14 @@ opt_column:
   }
 
 opt_set_data:
-  SET DATA {}
-| /* EMPTY */ {}
+  SET DATA
+  {
+    $$.val = true
+  }
+| /* EMPTY */
+  {
+    $$.val = false
+  }
 
 // %Help: RELEASE - complete a retryable block
 // %Category: Txn
@@ -3277,12 +3769,14 @@ insert_stmt:
   opt_with_clause INSERT INTO insert_target insert_rest returning_clause
   {
     $$.val = $5.stmt()
+    $$.val.(*Insert).With = $1.with()
     $$.val.(*Insert).Table = $4.tblExpr()
     $$.val.(*Insert).Returning = $6.retClause()
   }
 | opt_with_clause INSERT INTO insert_target insert_rest on_conflict returning_clause
   {
     $$.val = $5.stmt()
+    $$.val.(*Insert).With = $1.with()
     $$.val.(*Insert).Table = $4.tblExpr()
     $$.val.(*Insert).OnConflict = $6.onConflict()
     $$.val.(*Insert).Returning = $7.retClause()
@@ -3300,6 +3794,7 @@ upsert_stmt:
   opt_with_clause UPSERT INTO insert_target insert_rest returning_clause
   {
     $$.val = $5.stmt()
+    $$.val.(*Insert).With = $1.with()
     $$.val.(*Insert).Table = $4.tblExpr()
     $$.val.(*Insert).OnConflict = &OnConflict{}
     $$.val.(*Insert).Returning = $6.retClause()
@@ -3379,7 +3874,7 @@ update_stmt:
   opt_with_clause UPDATE relation_expr_opt_alias
     SET set_clause_list update_from_clause where_clause returning_clause
   {
-    $$.val = &Update{Table: $3.tblExpr(), Exprs: $5.updateExprs(), Where: newWhere(astWhere, $7.expr()), Returning: $8.retClause()}
+    $$.val = &Update{With: $1.with(), Table: $3.tblExpr(), Exprs: $5.updateExprs(), Where: newWhere(astWhere, $7.expr()), Returning: $8.retClause()}
   }
 | opt_with_clause UPDATE error // SHOW HELP: UPDATE
 
@@ -3501,15 +3996,15 @@ select_no_parens:
   }
 | with_clause select_clause
   {
-    $$.val = &Select{Select: $2.selectStmt()}
+    $$.val = &Select{With: $1.with(), Select: $2.selectStmt()}
   }
 | with_clause select_clause sort_clause
   {
-    $$.val = &Select{Select: $2.selectStmt(), OrderBy: $3.orderBy()}
+    $$.val = &Select{With: $1.with(), Select: $2.selectStmt(), OrderBy: $3.orderBy()}
   }
 | with_clause select_clause opt_sort_clause select_limit
   {
-    $$.val = &Select{Select: $2.selectStmt(), OrderBy: $3.orderBy(), Limit: $4.limit()}
+    $$.val = &Select{With: $1.with(), Select: $2.selectStmt(), OrderBy: $3.orderBy(), Limit: $4.limit()}
   }
 
 select_clause:
@@ -3653,24 +4148,51 @@ table_clause:
 //
 // Recognizing WITH_LA here allows a CTE to be named TIME or ORDINALITY.
 with_clause:
-  WITH cte_list { return unimplemented(sqllex, "with cte_list") }
-| WITH_LA cte_list { return unimplemented(sqllex, "with cte_list") }
-| WITH RECURSIVE cte_list { return unimplemented(sqllex, "with cte_list") }
+  WITH cte_list
+  {
+    $$.val = &With{CTEList: $2.ctes()}
+  }
+| WITH_LA cte_list
+  {
+    $$.val = &With{CTEList: $2.ctes()}
+  }
+| WITH RECURSIVE cte_list
+  {
+    $$.val = &With{Recursive: true, CTEList: $3.ctes()}
+  }
 
 cte_list:
-  common_table_expr { return unimplemented(sqllex, "cte_list") }
-| cte_list ',' common_table_expr { return unimplemented(sqllex, "cte_list") }
+  common_table_expr
+  {
+    $$.val = []*CTE{$1.cte()}
+  }
+| cte_list ',' common_table_expr
+  {
+    $$.val = append($1.ctes(), $3.cte())
+  }
 
 common_table_expr:
-  name opt_name_list AS '(' preparable_stmt ')' { return unimplemented(sqllex, "cte") }
+  name opt_name_list AS '(' preparable_stmt ')'
+  {
+    $$.val = &CTE{
+      Name: AliasClause{Alias: Name($1), Cols: $2.nameList()},
+      Stmt: $5.stmt(),
+    }
+  }
 
 opt_with:
   WITH {}
 | /* EMPTY */ {}
 
 opt_with_clause:
-  with_clause { return unimplemented(sqllex, "with_clause") }
-| /* EMPTY */ {}
+  with_clause
+  {
+    $$.val = $1.with()
+  }
+| /* EMPTY */
+  {
+    $$.val = nil
+  }
 
 opt_table:
   TABLE {}
@@ -4373,6 +4895,14 @@ simple_typename:
   {
     $$.val = ipnetColTypeINet
   }
+| JSON
+  {
+    $$.val = jsonColTypeJSON
+  }
+| JSONB
+  {
+    $$.val = jsonColTypeJSONB
+  }
 | BIGSERIAL
   {
     $$.val = intColTypeBigSerial
@@ -4941,6 +5471,34 @@ a_expr:
   {
     $$.val = &ComparisonExpr{Operator: NotRegIMatch, Left: $1.expr(), Right: $3.expr()}
   }
+| a_expr FETCHVAL a_expr
+  {
+    $$.val = &BinaryExpr{Operator: JSONFetchVal, Left: $1.expr(), Right: $3.expr()}
+  }
+| a_expr FETCHTEXT a_expr
+  {
+    $$.val = &BinaryExpr{Operator: JSONFetchText, Left: $1.expr(), Right: $3.expr()}
+  }
+| a_expr CONTAINS a_expr
+  {
+    $$.val = &ComparisonExpr{Operator: Contains, Left: $1.expr(), Right: $3.expr()}
+  }
+| a_expr CONTAINED_BY a_expr
+  {
+    $$.val = &ComparisonExpr{Operator: ContainedBy, Left: $1.expr(), Right: $3.expr()}
+  }
+| a_expr '?' a_expr
+  {
+    $$.val = &ComparisonExpr{Operator: JSONExists, Left: $1.expr(), Right: $3.expr()}
+  }
+| a_expr JSON_SOME_EXISTS a_expr
+  {
+    $$.val = &ComparisonExpr{Operator: JSONSomeExists, Left: $1.expr(), Right: $3.expr()}
+  }
+| a_expr JSON_ALL_EXISTS a_expr
+  {
+    $$.val = &ComparisonExpr{Operator: JSONAllExists, Left: $1.expr(), Right: $3.expr()}
+  }
 | a_expr IS NAN %prec IS
   {
     $$.val = &FuncExpr{Func: wrapFunction("ISNAN"), Exprs: Exprs{$1.expr()}}
@@ -5483,6 +6041,7 @@ window_specification:
       RefName: Name($2),
       Partitions: $3.exprs(),
       OrderBy: $4.orderBy(),
+      Frame: $5.windowFrame(),
     }
   }
 
@@ -5511,29 +6070,96 @@ opt_partition_clause:
     $$.val = Exprs(nil)
   }
 
-// For frame clauses, we return a WindowDef, but only some fields are used:
-// frameOptions, startOffset, and endOffset.
-//
 // This is only a subset of the full SQL:2008 frame_clause grammar. We don't
 // support <window frame exclusion> yet.
 opt_frame_clause:
-  RANGE frame_extent { return unimplemented(sqllex, "frame range") }
-| ROWS frame_extent { return unimplemented(sqllex, "frame rows") }
-| /* EMPTY */ {}
+  RANGE frame_extent
+  {
+    $$.val = &WindowFrame{
+      Mode: RangeMode,
+      Bounds: $2.windowFrameBounds(),
+    }
+  }
+| ROWS frame_extent
+  {
+    $$.val = &WindowFrame{
+      Mode: RowsMode,
+      Bounds: $2.windowFrameBounds(),
+    }
+  }
+| /* EMPTY */
+  {
+    $$.val = (*WindowFrame)(nil)
+  }
 
 frame_extent:
-  frame_bound { return unimplemented(sqllex, "frame_extent") }
-| BETWEEN frame_bound AND frame_bound { return unimplemented(sqllex, "frame_extent") }
+  frame_bound
+  {
+    startBound := $1.windowFrameBound()
+    switch startBound.BoundType {
+    case UnboundedFollowing:
+      sqllex.Error("frame start cannot be UNBOUNDED FOLLOWING")
+      return 1
+    case ValueFollowing:
+      sqllex.Error("frame starting from following row cannot end with current row")
+      return 1
+    }
+    $$.val = WindowFrameBounds{StartBound: startBound}
+  }
+| BETWEEN frame_bound AND frame_bound
+  {
+    startBound := $2.windowFrameBound()
+    endBound := $4.windowFrameBound()
+    switch {
+    case startBound.BoundType == UnboundedFollowing:
+      sqllex.Error("frame start cannot be UNBOUNDED FOLLOWING")
+      return 1
+    case endBound.BoundType == UnboundedPreceding:
+      sqllex.Error("frame end cannot be UNBOUNDED PRECEDING")
+      return 1
+    case startBound.BoundType == CurrentRow && endBound.BoundType == ValuePreceding:
+      sqllex.Error("frame starting from current row cannot have preceding rows")
+      return 1
+    case startBound.BoundType == ValueFollowing && endBound.BoundType == ValuePreceding:
+      sqllex.Error("frame starting from following row cannot have preceding rows")
+      return 1
+    case startBound.BoundType == ValueFollowing && endBound.BoundType == CurrentRow:
+      sqllex.Error("frame starting from following row cannot have preceding rows")
+      return 1
+    }
+    $$.val = WindowFrameBounds{StartBound: startBound, EndBound: endBound}
+  }
 
 // This is used for both frame start and frame end, with output set up on the
 // assumption it's frame start; the frame_extent productions must reject
 // invalid cases.
 frame_bound:
-  UNBOUNDED PRECEDING { return unimplemented(sqllex, "frame_bound") }
-| UNBOUNDED FOLLOWING { return unimplemented(sqllex, "frame_bound") }
-| CURRENT ROW { return unimplemented(sqllex, "frame_bound") }
-| a_expr PRECEDING { return unimplemented(sqllex, "frame_bound") }
-| a_expr FOLLOWING { return unimplemented(sqllex, "frame_bound") }
+  UNBOUNDED PRECEDING
+  {
+    $$.val = &WindowFrameBound{BoundType: UnboundedPreceding}
+  }
+| UNBOUNDED FOLLOWING
+  {
+    $$.val = &WindowFrameBound{BoundType: UnboundedFollowing}
+  }
+| CURRENT ROW
+  {
+    $$.val = &WindowFrameBound{BoundType: CurrentRow}
+  }
+| a_expr PRECEDING
+  {
+    $$.val = &WindowFrameBound{
+      OffsetExpr: $1.expr(),
+      BoundType: ValuePreceding,
+    }
+  }
+| a_expr FOLLOWING
+  {
+    $$.val = &WindowFrameBound{
+      OffsetExpr: $1.expr(),
+      BoundType: ValueFollowing,
+    }
+  }
 
 // Supporting nonterminals for expressions.
 
@@ -6130,6 +6756,16 @@ signed_iconst:
     $$.val = &NumVal{Value: constant.UnaryOp(token.SUB, $2.numVal().Value, 0)}
   }
 
+// signed_iconst64 is a variant of signed_iconst which only accepts (signed)
+// integer literals that fit in an int64; larger values are a syntax error.
+signed_iconst64:
+  signed_iconst
+  {
+    val, err := $1.numVal().AsInt64()
+    if err != nil { sqllex.Error(err.Error()); return 1 }
+    $$.val = val
+  }
+
 interval:
   const_interval SCONST opt_interval
   {
@@ -6214,6 +6850,7 @@ unrestricted_name:
 unreserved_keyword:
   ACTION
 | ADD
+| ADMIN
 | ALTER
 | AT
 | BACKUP
@@ -6222,10 +6859,12 @@ unreserved_keyword:
 | BY
 | CANCEL
 | CASCADE
+| CHANGEFEED
 | CLUSTER
 | COLUMNS
 | COMMIT
 | COMMITTED
+| CONFIGURE
 | CONFLICT
 | CONSTRAINTS
 | COPY
@@ -6245,6 +6884,7 @@ unreserved_keyword:
 | DROP
 | ENCODING
 | EXECUTE
+| EXPERIMENTAL
 | EXPERIMENTAL_FINGERPRINTS
 | EXPLAIN
 | FILTER
@@ -6255,24 +6895,31 @@ unreserved_keyword:
 | HIGH
 | HOUR
 | IMPORT
+| INCREMENT
 | INCREMENTAL
 | INDEXES
 | INSERT
 | INT2VECTOR
 | INTERLEAVE
+| INVERTED
 | ISOLATION
 | JOB
 | JOBS
+| JSON
+| JSONB
 | KEY
 | KEYS
 | KV
 | LC_COLLATE
 | LC_CTYPE
 | LEVEL
+| LIST
 | LOCAL
 | LOW
 | MATCH
+| MAXVALUE
 | MINUTE
+| MINVALUE
 | MONTH
 | NAMES
 | NAN
@@ -6284,6 +6931,7 @@ unreserved_keyword:
 | OF
 | OFF
 | OID
+| OPTION
 | OPTIONS
 | ORDINALITY
 | OVER
@@ -6315,6 +6963,8 @@ unreserved_keyword:
 | RESTRICT
 | RESUME
 | REVOKE
+| ROLE
+| ROLES
 | ROLLBACK
 | ROLLUP
 | ROWS
@@ -6326,6 +6976,7 @@ unreserved_keyword:
 | SEARCH
 | SECOND
 | SERIALIZABLE
+| SEQUENCE
 | SEQUENCES
 | SESSION
 | SESSIONS
@@ -6335,8 +6986,10 @@ unreserved_keyword:
 | SNAPSHOT
 | SQL
 | START
+| STATISTICS
 | STDIN
 | STORE
+| STORED
 | STORING
 | STRICT
 | SPLIT
@@ -93,6 +93,8 @@ var (
 	TypeUUID Type = tUUID{}
 	// TypeINet is the type of a DIPAddr. Can be compared with ==.
 	TypeINet Type = tINet{}
+	// TypeJSON is the type of a DJSON. Can be compared with ==.
+	TypeJSON Type = tJSON{}
 	// TypeTuple is the type family of a DTuple. CANNOT be compared with ==.
 	TypeTuple Type = TTuple(nil)
 	// TypeArray is the type family of a DArray. CANNOT be compared with ==.
@@ -145,6 +147,7 @@ var (
 		TypeInterval,
 		TypeUUID,
 		TypeINet,
+		TypeJSON,
 		TypeOid,
 	}
 )
@@ -190,6 +193,7 @@ var OidToType = map[oid.Oid]Type{
 	oid.T_timestamptz:  TypeTimestampTZ,
 	oid.T_uuid:         TypeUUID,
 	oid.T_inet:         TypeINet,
+	oid.T_jsonb:        TypeJSON,
 	oid.T_varchar:      typeVarChar,
 }
 
@@ -407,6 +411,16 @@ func (tINet) Oid() oid.Oid                { return oid.T_inet }
 func (tINet) SQLName() string             { return "inet" }
 func (tINet) IsAmbiguous() bool           { return false }
 
+type tJSON struct{}
+
+func (tJSON) String() string              { return "jsonb" }
+func (tJSON) Equivalent(other Type) bool  { return UnwrapType(other) == TypeJSON || other == TypeAny }
+func (tJSON) FamilyEqual(other Type) bool { return UnwrapType(other) == TypeJSON }
+func (tJSON) Size() (uintptr, bool)       { return unsafe.Sizeof(DJSON{}), variableSize }
+func (tJSON) Oid() oid.Oid                { return oid.T_jsonb }
+func (tJSON) SQLName() string             { return "jsonb" }
+func (tJSON) IsAmbiguous() bool           { return false }
+
 // TTuple is the type of a DTuple.
 type TTuple []Type
 
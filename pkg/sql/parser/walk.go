@@ -249,6 +249,8 @@ func (expr *FuncExpr) Walk(v Visitor) Expr {
 				ret.WindowDef.OrderBy[i].Expr = e
 			}
 		}
+		// The offsets of the window frame are not walked: like LIMIT and
+		// OFFSET, they are analyzed separately by the window planner.
 	}
 	if expr.Filter != nil {
 		e, changed := WalkExpr(v, expr.Filter)
@@ -537,6 +539,9 @@ func (expr *DUuid) Walk(_ Visitor) Expr { return expr }
 // Walk implements the Expr interface.
 func (expr *DIPAddr) Walk(_ Visitor) Expr { return expr }
 
+// Walk implements the Expr interface.
+func (expr *DJSON) Walk(_ Visitor) Expr { return expr }
+
 // Walk implements the Expr interface.
 func (expr dNull) Walk(_ Visitor) Expr { return expr }
 
@@ -1048,6 +1053,25 @@ func (stmt *SetClusterSetting) WalkStmt(v Visitor) Statement {
 	return ret
 }
 
+// CopyNode makes a copy of this Statement without recursing in any child Statements.
+func (stmt *SetZoneConfig) CopyNode() *SetZoneConfig {
+	stmtCopy := *stmt
+	return &stmtCopy
+}
+
+// WalkStmt is part of the WalkableStmt interface.
+func (stmt *SetZoneConfig) WalkStmt(v Visitor) Statement {
+	ret := stmt
+	if stmt.YAMLConfig != nil {
+		e, changed := WalkExpr(v, stmt.YAMLConfig)
+		if changed {
+			ret = stmt.CopyNode()
+			ret.YAMLConfig = e
+		}
+	}
+	return ret
+}
+
 // CopyNode makes a copy of this Statement without recursing in any child Statements.
 func (stmt *Update) CopyNode() *Update {
 	stmtCopy := *stmt
@@ -1122,6 +1146,7 @@ var _ WalkableStmt = &Select{}
 var _ WalkableStmt = &SelectClause{}
 var _ WalkableStmt = &SetClusterSetting{}
 var _ WalkableStmt = &SetVar{}
+var _ WalkableStmt = &SetZoneConfig{}
 var _ WalkableStmt = &Update{}
 var _ WalkableStmt = &ValuesClause{}
 
@@ -16,6 +16,7 @@ package parser
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 
@@ -48,49 +49,201 @@ type IndexedRow struct {
 	Row Datums
 }
 
-// WindowFrame is a view into a subset of data over which calculations are made.
-type WindowFrame struct {
+// WindowFrameRun contains the runtime state of a window frame during
+// calculations.
+type WindowFrameRun struct {
 	// constant for all calls to WindowFunc.Add
 	Rows        []IndexedRow
 	ArgIdxStart int // the index which arguments to the window function begin
 	ArgCount    int // the number of window function arguments
 
+	// Frame is the frame clause of the window definition, or nil if the
+	// default frame is used.
+	Frame *WindowFrame
+	// StartBoundOffset and EndBoundOffset are the evaluated offsets of the
+	// bounds of Frame, if they are of type ValuePreceding or ValueFollowing.
+	StartBoundOffset Datum
+	EndBoundOffset   Datum
+	// OrdColIdx is the index in Rows of the single ORDER BY column, which is
+	// used to find the bounds of RANGE frames with offsets.
+	OrdColIdx     int
+	OrdDescending bool
+
 	// changes for each row (each call to WindowFunc.Add)
 	RowIdx int // the current row index
 
 	// changes for each peer group
 	FirstPeerIdx int // the first index in the current peer group
 	PeerRowCount int // the number of rows in the current peer group
+
+	// plusOp and minusOp are used to compute the boundary values of RANGE
+	// frames with offsets. They are looked up on first use.
+	plusOp, minusOp BinOp
 }
 
-func (wf WindowFrame) rank() int {
+func (wf *WindowFrameRun) rank() int {
 	return wf.RowIdx + 1
 }
 
-func (wf WindowFrame) rowCount() int {
+func (wf *WindowFrameRun) rowCount() int {
 	return len(wf.Rows)
 }
 
-// TODO(nvanbenschoten): This definition only holds while we don't support
-// frame specification (RANGE or ROWS) in the OVER clause.
-func (wf WindowFrame) frameSize() int {
+// defaultFrameSize returns the size of the default frame, which contains the
+// rows from the start of the partition through the last peer of the current
+// row.
+func (wf *WindowFrameRun) defaultFrameSize() int {
 	return wf.FirstPeerIdx + wf.PeerRowCount
 }
 
 // firstInPeerGroup returns if the current row is the first in its peer group.
-func (wf WindowFrame) firstInPeerGroup() bool {
+func (wf *WindowFrameRun) firstInPeerGroup() bool {
 	return wf.RowIdx == wf.FirstPeerIdx
 }
 
-func (wf WindowFrame) args() Datums {
+func (wf *WindowFrameRun) args() Datums {
 	return wf.argsWithRowOffset(0)
 }
 
-func (wf WindowFrame) argsWithRowOffset(offset int) Datums {
-	return wf.Rows[wf.RowIdx+offset].Row[wf.ArgIdxStart : wf.ArgIdxStart+wf.ArgCount]
+func (wf *WindowFrameRun) argsWithRowOffset(offset int) Datums {
+	return wf.argsForRow(wf.RowIdx + offset)
+}
+
+func (wf *WindowFrameRun) argsForRow(idx int) Datums {
+	return wf.Rows[idx].Row[wf.ArgIdxStart : wf.ArgIdxStart+wf.ArgCount]
+}
+
+// frameBounds returns the indexes of the first row of the current row's window
+// frame and of the row following its last row. The frame is empty if both are
+// equal. Both indexes never decrease as the current row moves forward.
+func (wf *WindowFrameRun) frameBounds(evalCtx *EvalContext) (start, end int, err error) {
+	if wf.Frame == nil {
+		return 0, wf.defaultFrameSize(), nil
+	}
+	start, err = wf.boundIdx(evalCtx, wf.Frame.Bounds.StartBound, wf.StartBoundOffset, true)
+	if err != nil {
+		return 0, 0, err
+	}
+	if wf.Frame.Bounds.EndBound == nil {
+		end, err = wf.boundIdx(evalCtx, &WindowFrameBound{BoundType: CurrentRow}, nil, false)
+	} else {
+		end, err = wf.boundIdx(evalCtx, wf.Frame.Bounds.EndBound, wf.EndBoundOffset, false)
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	if end < start {
+		end = start
+	}
+	return start, end, nil
+}
+
+// boundIdx returns the index of the first row of the frame if isStart is set,
+// or the index of the row following its last row otherwise.
+func (wf *WindowFrameRun) boundIdx(
+	evalCtx *EvalContext, bound *WindowFrameBound, offset Datum, isStart bool,
+) (int, error) {
+	switch bound.BoundType {
+	case UnboundedPreceding:
+		return 0, nil
+	case UnboundedFollowing:
+		return wf.rowCount(), nil
+	case CurrentRow:
+		switch {
+		case wf.Frame.Mode == RowsMode && isStart:
+			return wf.RowIdx, nil
+		case wf.Frame.Mode == RowsMode:
+			return wf.RowIdx + 1, nil
+		case isStart:
+			return wf.FirstPeerIdx, nil
+		default:
+			return wf.FirstPeerIdx + wf.PeerRowCount, nil
+		}
+	case ValuePreceding, ValueFollowing:
+		preceding := bound.BoundType == ValuePreceding
+		if wf.Frame.Mode == RowsMode {
+			return wf.rowsOffsetIdx(int64(MustBeDInt(offset)), preceding, isStart), nil
+		}
+		return wf.rangeOffsetIdx(evalCtx, offset, preceding, isStart)
+	default:
+		panic(fmt.Sprintf("unhandled WindowFrameBoundType in boundIdx: %d", bound.BoundType))
+	}
+}
+
+// rowsOffsetIdx returns the index of the row the given number of rows before
+// or after the current row, clamped to the partition.
+func (wf *WindowFrameRun) rowsOffsetIdx(offset int64, preceding, isStart bool) int {
+	idx := int64(wf.RowIdx)
+	if !isStart {
+		idx++
+	}
+	// Clamp the offset first so the computation cannot overflow.
+	if offset > int64(wf.rowCount()) {
+		offset = int64(wf.rowCount())
+	}
+	if preceding {
+		idx -= offset
+	} else {
+		idx += offset
+	}
+	if idx < 0 {
+		return 0
+	}
+	if idx > int64(wf.rowCount()) {
+		return wf.rowCount()
+	}
+	return int(idx)
+}
+
+// rangeOffsetIdx returns the index of the first row, or of the row following
+// the last row, whose ORDER BY value is within the given offset before or after
+// the value of the current row. A current row with a NULL value has only its
+// peers in its frame.
+func (wf *WindowFrameRun) rangeOffsetIdx(
+	evalCtx *EvalContext, offset Datum, preceding, isStart bool,
+) (int, error) {
+	cur := wf.Rows[wf.RowIdx].Row[wf.OrdColIdx]
+	if cur == DNull {
+		if isStart {
+			return wf.FirstPeerIdx, nil
+		}
+		return wf.FirstPeerIdx + wf.PeerRowCount, nil
+	}
+	if wf.plusOp.fn == nil {
+		var ok bool
+		if wf.plusOp, ok = BinOps[Plus].lookupImpl(cur.ResolvedType(), offset.ResolvedType()); !ok {
+			return 0, pgerror.NewErrorf(pgerror.CodeInternalError,
+				"no operator %s + %s", cur.ResolvedType(), offset.ResolvedType())
+		}
+		if wf.minusOp, ok = BinOps[Minus].lookupImpl(cur.ResolvedType(), offset.ResolvedType()); !ok {
+			return 0, pgerror.NewErrorf(pgerror.CodeInternalError,
+				"no operator %s - %s", cur.ResolvedType(), offset.ResolvedType())
+		}
+	}
+	// In a descending ordering, the preceding rows have larger values.
+	op := wf.plusOp
+	if preceding != wf.OrdDescending {
+		op = wf.minusOp
+	}
+	target, err := op.fn(evalCtx, cur, offset)
+	if err != nil {
+		return 0, err
+	}
+	// The rows of the partition are sorted on the ORDER BY column, so the rows
+	// past the bound form a suffix of the partition.
+	return sort.Search(wf.rowCount(), func(i int) bool {
+		cmp := wf.Rows[i].Row[wf.OrdColIdx].Compare(evalCtx, target)
+		if wf.OrdDescending {
+			cmp = -cmp
+		}
+		if isStart {
+			return cmp >= 0
+		}
+		return cmp > 0
+	}), nil
 }
 
-// WindowFunc performs a computation on each row using data from a provided WindowFrame.
+// WindowFunc performs a computation on each row using data from a provided WindowFrameRun.
 type WindowFunc interface {
 	// Compute computes the window function for the provided window frame, given the
 	// current state of WindowFunc. The method should be called sequentially for every
@@ -98,7 +251,7 @@ type WindowFunc interface {
 	// because there is an implicit carried dependency between each row and all those
 	// that have come before it (like in an AggregateFunc). As such, this approach does
 	// not present any exploitable associativity/commutativity for optimization.
-	Compute(context.Context, *EvalContext, WindowFrame) (Datum, error)
+	Compute(context.Context, *EvalContext, *WindowFrameRun) (Datum, error)
 
 	// Close allows the window function to free any memory it requested during execution,
 	// such as during the execution of an aggregation like CONCAT_AGG or ARRAY_AGG.
@@ -203,49 +356,83 @@ var _ WindowFunc = &lastValueWindow{}
 var _ WindowFunc = &nthValueWindow{}
 
 // aggregateWindowFunc aggregates over the the current row's window frame, using
-// the internal AggregateFunc to perform the aggregation.
+// the internal AggregateFunc to perform the aggregation. As the frame moves
+// forward, rows entering it are added to the aggregation and rows leaving it
+// are removed when the AggregateFunc permits it. Otherwise, the aggregation is
+// restarted over the new frame.
 type aggregateWindowFunc struct {
-	agg     AggregateFunc
-	peerRes Datum
+	newAgg  func([]Type, *EvalContext) AggregateFunc
+	params  []Type
+	evalCtx *EvalContext
+
+	agg AggregateFunc
+	// aggStart and aggEnd are the bounds of the rows accumulated into agg, with
+	// the same meaning as the results of WindowFrameRun.frameBounds.
+	aggStart, aggEnd int
+	res              Datum
 }
 
-func newAggregateWindow(agg AggregateFunc) WindowFunc {
-	return &aggregateWindowFunc{agg: agg}
+func newAggregateWindow(
+	newAgg func([]Type, *EvalContext) AggregateFunc, params []Type, evalCtx *EvalContext,
+) WindowFunc {
+	return &aggregateWindowFunc{newAgg: newAgg, params: params, evalCtx: evalCtx}
 }
 
 func (w *aggregateWindowFunc) Compute(
-	ctx context.Context, evalCtx *EvalContext, wf WindowFrame,
+	ctx context.Context, evalCtx *EvalContext, wf *WindowFrameRun,
 ) (Datum, error) {
-	if !wf.firstInPeerGroup() {
-		return w.peerRes, nil
-	}
-
-	// Accumulate all values in the peer group at the same time, as these
-	// must return the same value.
-	for i := 0; i < wf.PeerRowCount; i++ {
-		args := wf.argsWithRowOffset(i)
-		var value Datum
-		// COUNT_ROWS takes no arguments.
-		if len(args) > 0 {
-			value = args[0]
+	start, end, err := wf.frameBounds(evalCtx)
+	if err != nil {
+		return nil, err
+	}
+	if w.agg != nil && start == w.aggStart && end == w.aggEnd {
+		// The frame did not change, which is always the case for peers when
+		// using the default frame.
+		return w.res, nil
+	}
+
+	removable, canRemove := w.agg.(removableAggregateFunc)
+	if w.agg == nil || start >= w.aggEnd || (start > w.aggStart && !canRemove) {
+		if w.agg != nil {
+			w.agg.Close(ctx)
 		}
-		if err := w.agg.Add(ctx, value); err != nil {
+		w.agg = w.newAgg(w.params, w.evalCtx)
+		w.aggStart, w.aggEnd = start, start
+	}
+
+	for ; w.aggStart < start; w.aggStart++ {
+		if err := removable.Remove(ctx, w.aggValue(wf, w.aggStart)); err != nil {
+			return nil, err
+		}
+	}
+	for ; w.aggEnd < end; w.aggEnd++ {
+		if err := w.agg.Add(ctx, w.aggValue(wf, w.aggEnd)); err != nil {
 			return nil, err
 		}
 	}
 
-	// Retrieve the value for the entire peer group, save it, and return it.
-
-	peerRes, err := w.agg.Result()
+	// Retrieve the value for the entire frame, save it, and return it.
+	res, err := w.agg.Result()
 	if err != nil {
 		return nil, err
 	}
-	w.peerRes = peerRes
-	return w.peerRes, nil
+	w.res = res
+	return w.res, nil
+}
+
+// aggValue returns the argument of the aggregation for the given row.
+func (w *aggregateWindowFunc) aggValue(wf *WindowFrameRun, idx int) Datum {
+	// COUNT_ROWS takes no arguments.
+	if args := wf.argsForRow(idx); len(args) > 0 {
+		return args[0]
+	}
+	return nil
 }
 
 func (w *aggregateWindowFunc) Close(ctx context.Context, evalCtx *EvalContext) {
-	w.agg.Close(ctx)
+	if w.agg != nil {
+		w.agg.Close(ctx)
+	}
 }
 
 // rowNumberWindow computes the number of the current row within its partition,
@@ -256,7 +443,7 @@ func newRowNumberWindow([]Type, *EvalContext) WindowFunc {
 	return &rowNumberWindow{}
 }
 
-func (rowNumberWindow) Compute(_ context.Context, _ *EvalContext, wf WindowFrame) (Datum, error) {
+func (rowNumberWindow) Compute(_ context.Context, _ *EvalContext, wf *WindowFrameRun) (Datum, error) {
 	return NewDInt(DInt(wf.RowIdx + 1 /* one-indexed */)), nil
 }
 
@@ -271,7 +458,7 @@ func newRankWindow([]Type, *EvalContext) WindowFunc {
 	return &rankWindow{}
 }
 
-func (w *rankWindow) Compute(_ context.Context, _ *EvalContext, wf WindowFrame) (Datum, error) {
+func (w *rankWindow) Compute(_ context.Context, _ *EvalContext, wf *WindowFrameRun) (Datum, error) {
 	if wf.firstInPeerGroup() {
 		w.peerRes = NewDInt(DInt(wf.rank()))
 	}
@@ -291,7 +478,7 @@ func newDenseRankWindow([]Type, *EvalContext) WindowFunc {
 }
 
 func (w *denseRankWindow) Compute(
-	_ context.Context, _ *EvalContext, wf WindowFrame,
+	_ context.Context, _ *EvalContext, wf *WindowFrameRun,
 ) (Datum, error) {
 	if wf.firstInPeerGroup() {
 		w.denseRank++
@@ -315,7 +502,7 @@ func newPercentRankWindow([]Type, *EvalContext) WindowFunc {
 var dfloatZero = NewDFloat(0)
 
 func (w *percentRankWindow) Compute(
-	_ context.Context, _ *EvalContext, wf WindowFrame,
+	_ context.Context, _ *EvalContext, wf *WindowFrameRun,
 ) (Datum, error) {
 	// Return zero if there's only one row, per spec.
 	if wf.rowCount() <= 1 {
@@ -342,11 +529,11 @@ func newCumulativeDistWindow([]Type, *EvalContext) WindowFunc {
 }
 
 func (w *cumulativeDistWindow) Compute(
-	_ context.Context, _ *EvalContext, wf WindowFrame,
+	_ context.Context, _ *EvalContext, wf *WindowFrameRun,
 ) (Datum, error) {
 	if wf.firstInPeerGroup() {
 		// (number of rows preceding or peer with current row) / (total rows)
-		w.peerRes = NewDFloat(DFloat(wf.frameSize()) / DFloat(wf.rowCount()))
+		w.peerRes = NewDFloat(DFloat(wf.defaultFrameSize()) / DFloat(wf.rowCount()))
 	}
 	return w.peerRes, nil
 }
@@ -368,7 +555,7 @@ func newNtileWindow([]Type, *EvalContext) WindowFunc {
 
 var errInvalidArgumentForNtile = pgerror.NewErrorf(pgerror.CodeInvalidParameterValueError, "argument of ntile() must be greater than zero")
 
-func (w *ntileWindow) Compute(_ context.Context, _ *EvalContext, wf WindowFrame) (Datum, error) {
+func (w *ntileWindow) Compute(_ context.Context, _ *EvalContext, wf *WindowFrameRun) (Datum, error) {
 	if w.ntile == nil {
 		// If this is the first call to ntileWindow.Compute, set up the buckets.
 		total := wf.rowCount()
@@ -436,7 +623,7 @@ func makeLeadLagWindowConstructor(
 	}
 }
 
-func (w *leadLagWindow) Compute(_ context.Context, _ *EvalContext, wf WindowFrame) (Datum, error) {
+func (w *leadLagWindow) Compute(_ context.Context, _ *EvalContext, wf *WindowFrameRun) (Datum, error) {
 	offset := 1
 	if w.withOffset {
 		offsetArg := wf.args()[1]
@@ -470,8 +657,17 @@ func newFirstValueWindow([]Type, *EvalContext) WindowFunc {
 	return &firstValueWindow{}
 }
 
-func (firstValueWindow) Compute(_ context.Context, _ *EvalContext, wf WindowFrame) (Datum, error) {
-	return wf.Rows[0].Row[wf.ArgIdxStart], nil
+func (firstValueWindow) Compute(
+	_ context.Context, evalCtx *EvalContext, wf *WindowFrameRun,
+) (Datum, error) {
+	start, end, err := wf.frameBounds(evalCtx)
+	if err != nil {
+		return nil, err
+	}
+	if start == end {
+		return DNull, nil
+	}
+	return wf.Rows[start].Row[wf.ArgIdxStart], nil
 }
 
 func (firstValueWindow) Close(context.Context, *EvalContext) {}
@@ -483,8 +679,17 @@ func newLastValueWindow([]Type, *EvalContext) WindowFunc {
 	return &lastValueWindow{}
 }
 
-func (lastValueWindow) Compute(_ context.Context, _ *EvalContext, wf WindowFrame) (Datum, error) {
-	return wf.Rows[wf.frameSize()-1].Row[wf.ArgIdxStart], nil
+func (lastValueWindow) Compute(
+	_ context.Context, evalCtx *EvalContext, wf *WindowFrameRun,
+) (Datum, error) {
+	start, end, err := wf.frameBounds(evalCtx)
+	if err != nil {
+		return nil, err
+	}
+	if start == end {
+		return DNull, nil
+	}
+	return wf.Rows[end-1].Row[wf.ArgIdxStart], nil
 }
 
 func (lastValueWindow) Close(context.Context, *EvalContext) {}
@@ -499,7 +704,9 @@ func newNthValueWindow([]Type, *EvalContext) WindowFunc {
 
 var errInvalidArgumentForNthValue = pgerror.NewErrorf(pgerror.CodeInvalidParameterValueError, "argument of nth_value() must be greater than zero")
 
-func (nthValueWindow) Compute(_ context.Context, _ *EvalContext, wf WindowFrame) (Datum, error) {
+func (nthValueWindow) Compute(
+	_ context.Context, evalCtx *EvalContext, wf *WindowFrameRun,
+) (Datum, error) {
 	arg := wf.args()[1]
 	if arg == DNull {
 		return DNull, nil
@@ -512,10 +719,14 @@ func (nthValueWindow) Compute(_ context.Context, _ *EvalContext, wf WindowFrame)
 
 	// per spec: Only consider the rows within the "window frame", which by default contains
 	// the rows from the start of the partition through the last peer of the current row.
-	if nth > wf.frameSize() {
+	start, end, err := wf.frameBounds(evalCtx)
+	if err != nil {
+		return nil, err
+	}
+	if nth > end-start {
 		return DNull, nil
 	}
-	return wf.Rows[nth-1].Row[wf.ArgIdxStart], nil
+	return wf.Rows[start+nth-1].Row[wf.ArgIdxStart], nil
 }
 
 func (nthValueWindow) Close(context.Context, *EvalContext) {}
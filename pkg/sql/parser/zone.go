@@ -0,0 +1,47 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package parser
+
+import "bytes"
+
+// ZoneSpecifier references a partition of a table for which a zone config
+// can be set.
+type ZoneSpecifier struct {
+	Table     NormalizableTableName
+	Partition Name
+}
+
+// Format implements the NodeFormatter interface.
+func (node *ZoneSpecifier) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("PARTITION ")
+	FormatNode(buf, f, node.Partition)
+	buf.WriteString(" OF TABLE ")
+	FormatNode(buf, f, &node.Table)
+}
+
+// SetZoneConfig represents an ALTER PARTITION ... EXPERIMENTAL CONFIGURE ZONE
+// statement. A NULL YAMLConfig removes the zone config.
+type SetZoneConfig struct {
+	ZoneSpecifier
+	YAMLConfig Expr
+}
+
+// Format implements the NodeFormatter interface.
+func (node *SetZoneConfig) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("ALTER ")
+	FormatNode(buf, f, &node.ZoneSpecifier)
+	buf.WriteString(" EXPERIMENTAL CONFIGURE ZONE ")
+	FormatNode(buf, f, node.YAMLConfig)
+}
@@ -0,0 +1,423 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/config"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/pkg/errors"
+)
+
+// createPartitioning constructs the partitioning descriptor for an index from
+// its PARTITION BY clause. The partitioning columns must be a prefix of the
+// columns of the index, and the values of the partitions must be constants.
+//
+// The values of each partition are stored as tuples of value-encoded datums.
+// An empty tuple represents DEFAULT for list partitions and MAXVALUE for range
+// partitions.
+func createPartitioning(
+	evalCtx *parser.EvalContext,
+	searchPath parser.SearchPath,
+	tableDesc *sqlbase.TableDescriptor,
+	indexDesc *sqlbase.IndexDescriptor,
+	partBy *parser.PartitionBy,
+) (sqlbase.PartitioningDescriptor, error) {
+	var part sqlbase.PartitioningDescriptor
+	if len(partBy.Fields) > len(indexDesc.ColumnNames) {
+		return part, pgerror.NewErrorf(pgerror.CodeSyntaxError,
+			"declared partition columns (%s) exceed the number of columns in index being partitioned (%s)",
+			partitionColumnsString(partBy.Fields.ToStrings()), partitionColumnsString(indexDesc.ColumnNames))
+	}
+	colTypes := make([]parser.Type, len(partBy.Fields))
+	for i, field := range partBy.Fields {
+		if string(field) != indexDesc.ColumnNames[i] {
+			n := len(partBy.Fields)
+			return part, pgerror.NewErrorf(pgerror.CodeSyntaxError,
+				"declared partition columns (%s) do not match first %d columns in index being partitioned (%s)",
+				partitionColumnsString(partBy.Fields.ToStrings()), n,
+				partitionColumnsString(indexDesc.ColumnNames[:n]))
+		}
+		col, err := tableDesc.FindActiveColumnByName(string(field))
+		if err != nil {
+			return part, err
+		}
+		colTypes[i] = col.Type.ToDatumType()
+	}
+	part.NumColumns = uint32(len(partBy.Fields))
+
+	// encodeTuple type checks and value-encodes the given tuple of expressions,
+	// one per partitioning column.
+	encodeTuple := func(partName parser.Name, exprs parser.Exprs) ([]byte, error) {
+		if len(exprs) != len(colTypes) {
+			return nil, pgerror.NewErrorf(pgerror.CodeSyntaxError,
+				"partition %s: number of values (%d) does not match number of partition columns (%d)",
+				partName, len(exprs), len(colTypes))
+		}
+		var value []byte
+		for i, expr := range exprs {
+			if _, ok := expr.(parser.DefaultVal); ok {
+				return nil, pgerror.NewErrorf(pgerror.CodeSyntaxError,
+					"partition %s: DEFAULT must be the only value in its tuple", partName)
+			}
+			typedExpr, err := sqlbase.SanitizeVarFreeExpr(expr, colTypes[i], "partition", searchPath)
+			if err != nil {
+				return nil, errors.Wrapf(err, "partition %s", partName)
+			}
+			datum, err := typedExpr.Eval(evalCtx)
+			if err != nil {
+				return nil, errors.Wrapf(err, "partition %s", partName)
+			}
+			value, err = sqlbase.EncodeTableValue(value, sqlbase.ColumnID(encoding.NoColumnID), datum, nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return value, nil
+	}
+
+	if partBy.List != nil {
+		seen := make(map[string]parser.Name)
+		for _, l := range partBy.List {
+			p := sqlbase.PartitioningDescriptor_List{Name: string(l.Name)}
+			for _, expr := range l.Exprs {
+				var value []byte
+				if _, ok := expr.(parser.DefaultVal); !ok {
+					exprs := parser.Exprs{expr}
+					if len(colTypes) > 1 {
+						tuple, ok := expr.(*parser.Tuple)
+						if !ok {
+							return part, pgerror.NewErrorf(pgerror.CodeSyntaxError,
+								"partition %s: values must be tuples of %d values", l.Name, len(colTypes))
+						}
+						exprs = tuple.Exprs
+					}
+					var err error
+					if value, err = encodeTuple(l.Name, exprs); err != nil {
+						return part, err
+					}
+				}
+				if other, ok := seen[string(value)]; ok {
+					return part, pgerror.NewErrorf(pgerror.CodeSyntaxError,
+						"partition %s: %s is also contained in partition %s", l.Name, expr, other)
+				}
+				seen[string(value)] = l.Name
+				p.Values = append(p.Values, value)
+			}
+			part.List = append(part.List, p)
+		}
+	} else {
+		for i, r := range partBy.Range {
+			p := sqlbase.PartitioningDescriptor_Range{Name: string(r.Name)}
+			if r.Exprs == nil {
+				if i != len(partBy.Range)-1 {
+					return part, pgerror.NewErrorf(pgerror.CodeSyntaxError,
+						"partition %s: MAXVALUE must be the upper bound of the last partition", r.Name)
+				}
+			} else {
+				var err error
+				if p.UpperBound, err = encodeTuple(r.Name, r.Exprs); err != nil {
+					return part, err
+				}
+			}
+			part.Range = append(part.Range, p)
+		}
+	}
+
+	// Ensure the values are valid by computing the spans of the partitions. This
+	// also catches range partitions whose upper bounds aren't increasing.
+	for _, name := range partitionNames(&part) {
+		if _, err := partitionSpans(tableDesc, indexDesc, &part, name); err != nil {
+			return part, err
+		}
+	}
+	return part, nil
+}
+
+func partitionColumnsString(names []string) string {
+	return strings.Join(names, ", ")
+}
+
+// partitionNames returns the names of the partitions of a partitioning.
+func partitionNames(part *sqlbase.PartitioningDescriptor) []string {
+	var names []string
+	for _, l := range part.List {
+		names = append(names, l.Name)
+	}
+	for _, r := range part.Range {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+// decodePartitionTuple decodes a value-encoded tuple of a partition into the
+// key-encoded prefix of the index it represents, including the index prefix.
+func decodePartitionTuple(
+	a *sqlbase.DatumAlloc,
+	tableDesc *sqlbase.TableDescriptor,
+	indexDesc *sqlbase.IndexDescriptor,
+	part *sqlbase.PartitioningDescriptor,
+	value []byte,
+) (roachpb.Key, error) {
+	key := roachpb.Key(sqlbase.MakeIndexKeyPrefix(tableDesc, indexDesc.ID))
+	for i := 0; i < int(part.NumColumns); i++ {
+		col, err := tableDesc.FindColumnByID(indexDesc.ColumnIDs[i])
+		if err != nil {
+			return nil, err
+		}
+		var datum parser.Datum
+		datum, value, err = sqlbase.DecodeTableValue(a, col.Type.ToDatumType(), value)
+		if err != nil {
+			return nil, err
+		}
+		dir, err := indexDesc.ColumnDirections[i].ToEncodingDirection()
+		if err != nil {
+			return nil, err
+		}
+		if key, err = sqlbase.EncodeTableKey(key, datum, dir); err != nil {
+			return nil, err
+		}
+	}
+	if len(value) > 0 {
+		return nil, errors.Errorf("superfluous data in encoded partition value: %x", value)
+	}
+	return key, nil
+}
+
+// decodePartitionDatums decodes a value-encoded tuple of a partition into an
+// expression: a single datum if the index is partitioned by one column and a
+// tuple of datums otherwise.
+func decodePartitionDatums(
+	a *sqlbase.DatumAlloc,
+	tableDesc *sqlbase.TableDescriptor,
+	indexDesc *sqlbase.IndexDescriptor,
+	part *sqlbase.PartitioningDescriptor,
+	value []byte,
+) (parser.Exprs, error) {
+	exprs := make(parser.Exprs, part.NumColumns)
+	for i := range exprs {
+		col, err := tableDesc.FindColumnByID(indexDesc.ColumnIDs[i])
+		if err != nil {
+			return nil, err
+		}
+		if exprs[i], value, err = sqlbase.DecodeTableValue(a, col.Type.ToDatumType(), value); err != nil {
+			return nil, err
+		}
+	}
+	return exprs, nil
+}
+
+// showCreatePartitioning returns a PARTITION BY clause for the specified index,
+// if applicable.
+func showCreatePartitioning(
+	tableDesc *sqlbase.TableDescriptor, indexDesc *sqlbase.IndexDescriptor, buf *bytes.Buffer,
+) error {
+	part := &indexDesc.Partitioning
+	if part.NumColumns == 0 {
+		return nil
+	}
+	var a sqlbase.DatumAlloc
+	partBy := parser.PartitionBy{}
+	for _, name := range indexDesc.ColumnNames[:part.NumColumns] {
+		partBy.Fields = append(partBy.Fields, parser.Name(name))
+	}
+	for _, l := range part.List {
+		p := parser.ListPartition{Name: parser.Name(l.Name)}
+		for _, value := range l.Values {
+			if len(value) == 0 {
+				p.Exprs = append(p.Exprs, parser.DefaultVal{})
+				continue
+			}
+			exprs, err := decodePartitionDatums(&a, tableDesc, indexDesc, part, value)
+			if err != nil {
+				return err
+			}
+			if len(exprs) == 1 {
+				p.Exprs = append(p.Exprs, exprs[0])
+			} else {
+				p.Exprs = append(p.Exprs, &parser.Tuple{Exprs: exprs})
+			}
+		}
+		partBy.List = append(partBy.List, p)
+	}
+	for _, r := range part.Range {
+		p := parser.RangePartition{Name: parser.Name(r.Name)}
+		if len(r.UpperBound) > 0 {
+			exprs, err := decodePartitionDatums(&a, tableDesc, indexDesc, part, r.UpperBound)
+			if err != nil {
+				return err
+			}
+			p.Exprs = exprs
+		}
+		partBy.Range = append(partBy.Range, p)
+	}
+	parser.FormatNode(buf, parser.FmtSimple, &partBy)
+	return nil
+}
+
+// partitionSpans returns the sorted, non-overlapping key spans of the index
+// covered by the named partition.
+func partitionSpans(
+	tableDesc *sqlbase.TableDescriptor,
+	indexDesc *sqlbase.IndexDescriptor,
+	part *sqlbase.PartitioningDescriptor,
+	name string,
+) (roachpb.Spans, error) {
+	if len(indexDesc.Interleave.Ancestors) > 0 {
+		return nil, pgerror.NewErrorf(pgerror.CodeFeatureNotSupportedError,
+			"interleaved index %q cannot be partitioned", indexDesc.Name)
+	}
+	var a sqlbase.DatumAlloc
+	indexSpan := tableDesc.IndexSpan(indexDesc.ID)
+
+	if len(part.List) > 0 {
+		// Compute the spans of every value so that the DEFAULT partition, if any,
+		// can cover the gaps between them.
+		var spans, valueSpans roachpb.Spans
+		var isDefault bool
+		for _, l := range part.List {
+			for _, value := range l.Values {
+				if len(value) == 0 {
+					isDefault = isDefault || l.Name == name
+					continue
+				}
+				key, err := decodePartitionTuple(&a, tableDesc, indexDesc, part, value)
+				if err != nil {
+					return nil, err
+				}
+				span := roachpb.Span{Key: key, EndKey: key.PrefixEnd()}
+				valueSpans = append(valueSpans, span)
+				if l.Name == name {
+					spans = append(spans, span)
+				}
+			}
+		}
+		if isDefault {
+			sort.Sort(valueSpans)
+			spans = append(spans, spanGaps(indexSpan, valueSpans)...)
+		}
+		sort.Sort(spans)
+		return spans, nil
+	}
+
+	startKey := indexSpan.Key
+	for _, r := range part.Range {
+		endKey := indexSpan.EndKey
+		if len(r.UpperBound) > 0 {
+			var err error
+			if endKey, err = decodePartitionTuple(&a, tableDesc, indexDesc, part, r.UpperBound); err != nil {
+				return nil, err
+			}
+		}
+		if startKey.Compare(endKey) >= 0 {
+			return nil, pgerror.NewErrorf(pgerror.CodeSyntaxError,
+				"partition %s: upper bound must be greater than the upper bound of the previous partition", r.Name)
+		}
+		if r.Name == name {
+			return roachpb.Spans{{Key: startKey, EndKey: endKey}}, nil
+		}
+		startKey = endKey
+	}
+	return nil, errors.Errorf("partition %q does not exist in index %q", name, indexDesc.Name)
+}
+
+// spanGaps returns the parts of span not covered by the given sorted,
+// non-overlapping spans.
+func spanGaps(span roachpb.Span, covered roachpb.Spans) roachpb.Spans {
+	var gaps roachpb.Spans
+	key := span.Key
+	for _, c := range covered {
+		if key.Compare(c.Key) < 0 {
+			gaps = append(gaps, roachpb.Span{Key: key, EndKey: c.Key})
+		}
+		key = c.EndKey
+	}
+	if key.Compare(span.EndKey) < 0 {
+		gaps = append(gaps, roachpb.Span{Key: key, EndKey: span.EndKey})
+	}
+	return gaps
+}
+
+// GenerateSubzoneSpans computes the key spans of a table to which each of the
+// given subzones applies. A subzone with an empty partition name applies to a
+// whole index, except for the partitions of the index that have subzones of
+// their own. The returned spans are sorted and their keys don't include the
+// table prefix.
+func GenerateSubzoneSpans(
+	tableDesc *sqlbase.TableDescriptor, subzones []config.Subzone,
+) ([]config.SubzoneSpan, error) {
+	tablePrefix := keys.MakeTablePrefix(uint32(tableDesc.ID))
+	spansBySubzone := make([]roachpb.Spans, len(subzones))
+	partitionSpansByIndex := make(map[uint32]roachpb.Spans)
+	for i, subzone := range subzones {
+		if subzone.PartitionName == "" {
+			continue
+		}
+		indexDesc, err := tableDesc.FindIndexByID(sqlbase.IndexID(subzone.IndexID))
+		if err != nil {
+			return nil, err
+		}
+		spans, err := partitionSpans(tableDesc, indexDesc, &indexDesc.Partitioning, subzone.PartitionName)
+		if err != nil {
+			return nil, err
+		}
+		spansBySubzone[i] = spans
+		partitionSpansByIndex[subzone.IndexID] = append(partitionSpansByIndex[subzone.IndexID], spans...)
+	}
+	for i, subzone := range subzones {
+		if subzone.PartitionName != "" {
+			continue
+		}
+		indexDesc, err := tableDesc.FindIndexByID(sqlbase.IndexID(subzone.IndexID))
+		if err != nil {
+			return nil, err
+		}
+		if len(indexDesc.Interleave.Ancestors) > 0 {
+			return nil, pgerror.NewErrorf(pgerror.CodeFeatureNotSupportedError,
+				"interleaved index %q cannot have a zone config", indexDesc.Name)
+		}
+		// The zone configs of the index's partitions take precedence over the
+		// zone config of the index.
+		covered := partitionSpansByIndex[subzone.IndexID]
+		sort.Sort(covered)
+		spansBySubzone[i] = spanGaps(tableDesc.IndexSpan(indexDesc.ID), covered)
+	}
+
+	var subzoneSpans []config.SubzoneSpan
+	for i, spans := range spansBySubzone {
+		for _, span := range spans {
+			subzoneSpan := config.SubzoneSpan{
+				Key:          bytes.TrimPrefix(span.Key, tablePrefix),
+				SubzoneIndex: int32(i),
+			}
+			if !span.Key.PrefixEnd().Equal(span.EndKey) {
+				subzoneSpan.EndKey = bytes.TrimPrefix(span.EndKey, tablePrefix)
+			}
+			subzoneSpans = append(subzoneSpans, subzoneSpan)
+		}
+	}
+	sort.Slice(subzoneSpans, func(i, j int) bool {
+		return bytes.Compare(subzoneSpans[i].Key, subzoneSpans[j].Key) < 0
+	})
+	return subzoneSpans, nil
+}
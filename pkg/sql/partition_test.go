@@ -0,0 +1,106 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/config"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestGenerateSubzoneSpans(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const tableID = 100
+	desc, err := CreateTestTableDescriptor(context.TODO(), keys.MaxReservedDescID+1, tableID,
+		`CREATE TABLE t (
+			a INT PRIMARY KEY,
+			b INT,
+			INDEX b_idx (b)
+		) PARTITION BY LIST (a) (PARTITION p1 VALUES IN (1), PARTITION p2 VALUES IN (3))`,
+		sqlbase.NewDefaultPrivilegeDescriptor())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		subzones []config.Subzone
+		expected []string
+	}{
+		{
+			subzones: nil,
+			expected: nil,
+		},
+		{
+			subzones: []config.Subzone{{IndexID: 2}},
+			expected: []string{"0: /Table/100/2-/Table/100/3"},
+		},
+		{
+			subzones: []config.Subzone{{IndexID: 1, PartitionName: "p2"}},
+			expected: []string{"0: /Table/100/1/3-/Table/100/1/4"},
+		},
+		{
+			// The zone configs of partitions take precedence over the zone config
+			// of their index.
+			subzones: []config.Subzone{
+				{IndexID: 1},
+				{IndexID: 1, PartitionName: "p1"},
+				{IndexID: 2},
+			},
+			expected: []string{
+				"0: /Table/100/1-/Table/100/1/1",
+				"1: /Table/100/1/1-/Table/100/1/2",
+				"0: /Table/100/1/2-/Table/100/2",
+				"2: /Table/100/2-/Table/100/3",
+			},
+		},
+	}
+
+	tablePrefix := keys.MakeTablePrefix(tableID)
+	for i, tc := range testCases {
+		spans, err := GenerateSubzoneSpans(&desc, tc.subzones)
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		var actual []string
+		for _, span := range spans {
+			key := append(roachpb.Key(nil), tablePrefix...)
+			key = append(key, span.Key...)
+			endKey := key.PrefixEnd()
+			if len(span.EndKey) > 0 {
+				endKey = append(roachpb.Key(nil), tablePrefix...)
+				endKey = append(endKey, span.EndKey...)
+			}
+			actual = append(actual, fmt.Sprintf("%d: %s-%s", span.SubzoneIndex, key, endKey))
+		}
+		if !reflect.DeepEqual(tc.expected, actual) {
+			t.Errorf("%d: expected %v, got %v", i, tc.expected, actual)
+		}
+	}
+
+	missing := []config.Subzone{{IndexID: 1, PartitionName: "p3"}}
+	if _, err := GenerateSubzoneSpans(&desc, missing); !testutils.IsError(err, `partition "p3" does not exist`) {
+		t.Errorf("expected missing partition error, got %v", err)
+	}
+}
@@ -222,6 +222,7 @@ var (
 	relKindTable = parser.NewDString("r")
 	relKindIndex = parser.NewDString("i")
 	relKindView  = parser.NewDString("v")
+	relKindSeq   = parser.NewDString("S")
 
 	relPersistencePermanent = parser.NewDString("p")
 )
@@ -267,6 +268,8 @@ CREATE TABLE pg_catalog.pg_class (
 			if table.IsView() {
 				// The only difference between tables and views is the relkind column.
 				relKind = relKindView
+			} else if table.IsSequence() {
+				relKind = relKindSeq
 			}
 			if err := addRow(
 				h.TableOid(db, table),       // oid
@@ -389,11 +392,13 @@ var (
 	fkActionSetNull    = parser.NewDString("n")
 	fkActionSetDefault = parser.NewDString("d")
 
-	// Avoid unused warning for constants.
-	_ = fkActionRestrict
-	_ = fkActionCascade
-	_ = fkActionSetNull
-	_ = fkActionSetDefault
+	fkActionMap = map[sqlbase.ForeignKeyReference_Action]parser.Datum{
+		sqlbase.ForeignKeyReference_NO_ACTION:   fkActionNone,
+		sqlbase.ForeignKeyReference_RESTRICT:    fkActionRestrict,
+		sqlbase.ForeignKeyReference_CASCADE:     fkActionCascade,
+		sqlbase.ForeignKeyReference_SET_NULL:    fkActionSetNull,
+		sqlbase.ForeignKeyReference_SET_DEFAULT: fkActionSetDefault,
+	}
 
 	fkMatchTypeFull    = parser.NewDString("f")
 	fkMatchTypePartial = parser.NewDString("p")
@@ -482,8 +487,8 @@ CREATE TABLE pg_catalog.pg_constraint (
 					contype = conTypeFK
 					conindid = h.IndexOid(referencedDB, c.ReferencedTable, c.ReferencedIndex)
 					confrelid = h.TableOid(referencedDB, c.ReferencedTable)
-					confupdtype = fkActionNone
-					confdeltype = fkActionNone
+					confupdtype = fkActionMap[c.FK.OnUpdate]
+					confdeltype = fkActionMap[c.FK.OnDelete]
 					confmatchtype = fkMatchTypeSimple
 					var err error
 					conkey, err = colIDArrayToDatum(c.Index.ColumnIDs)
@@ -1126,7 +1131,7 @@ CREATE TABLE pg_catalog.pg_proc (
 				switch argTypes.(type) {
 				case parser.VariadicType:
 					argmodes = proArgModeVariadic
-					argType := argTypes.Types()[0]
+					argType := argTypes.(parser.VariadicType).Typ
 					oid := argType.Oid()
 					variadicType = parser.NewDOid(parser.DInt(oid))
 				case parser.HomogeneousType:
@@ -1559,6 +1564,7 @@ var datumToTypeCategory = map[reflect.Type]*parser.DString{
 	reflect.TypeOf(parser.TypeOid):         typCategoryNumeric,
 	reflect.TypeOf(parser.TypeUUID):        typCategoryUserDefined,
 	reflect.TypeOf(parser.TypeINet):        typCategoryNetworkAddr,
+	reflect.TypeOf(parser.TypeJSON):        typCategoryUserDefined,
 }
 
 func typCategory(typ parser.Type) parser.Datum {
@@ -673,7 +673,8 @@ func TestPGPreparedQuery(t *testing.T) {
 		"SHOW COLUMNS FROM system.users": {
 			baseTest.
 				Results("username", "STRING", false, gosql.NullBool{}, "{\"primary\"}").
-				Results("hashedPassword", "BYTES", true, gosql.NullBool{}, "{}"),
+				Results("hashedPassword", "BYTES", true, gosql.NullBool{}, "{}").
+				Results("isRole", "BOOL", false, "false", "{}"),
 		},
 		"SHOW DATABASES": {
 			baseTest.Results("crdb_internal").Results("d").Results("information_schema").Results("pg_catalog").Results("system"),
@@ -689,7 +690,7 @@ func TestPGPreparedQuery(t *testing.T) {
 			baseTest.Results("users", "primary", true, 1, "username", "ASC", false, false),
 		},
 		"SHOW TABLES FROM system": {
-			baseTest.Results("descriptor").Others(10),
+			baseTest.Results("descriptor").Others(12),
 		},
 		"SHOW CONSTRAINTS FROM system.users": {
 			baseTest.Results("users", "primary", "PRIMARY KEY", "username", gosql.NullString{}),
@@ -145,6 +145,9 @@ func (b *writeBuffer) writeTextDatum(
 	case *parser.DIPAddr:
 		b.writeLengthPrefixedString(v.IPAddr.String())
 
+	case *parser.DJSON:
+		b.writeLengthPrefixedString(v.JSON.String())
+
 	case *parser.DString:
 		b.writeLengthPrefixedString(string(*v))
 
@@ -353,6 +356,14 @@ func (b *writeBuffer) writeBinaryDatum(
 			b.setError(errors.Errorf("error encoding inet to pgBinary: %v", v.IPAddr))
 		}
 
+	case *parser.DJSON:
+		// The binary format of JSONB is a version byte followed by the text
+		// representation of the value.
+		s := v.JSON.String()
+		b.putInt32(int32(len(s) + 1))
+		b.writeByte(pgBinaryJSONBVersion)
+		b.writeString(s)
+
 	case *parser.DString:
 		b.writeLengthPrefixedString(string(*v))
 
@@ -482,6 +493,10 @@ const (
 	pgBinaryIPv6family byte = 3
 )
 
+// pgBinaryJSONBVersion is the version byte that prefixes the binary format
+// of JSONB. Postgres only defines version 1.
+const pgBinaryJSONBVersion byte = 1
+
 // pgBinaryToIPAddr takes an IPAddr and interprets it as the Postgres binary
 // format. See https://github.com/postgres/postgres/blob/81c5e46c490e2426db243eada186995da5bb0ba7/src/backend/utils/adt/network.c#L144
 // for the binary spec.
@@ -609,6 +624,12 @@ func decodeOidDatum(id oid.Oid, code formatCode, b []byte) (parser.Datum, error)
 				return nil, errors.Errorf("could not parse string %q as inet", b)
 			}
 			return d, nil
+		case oid.T_jsonb:
+			d, err := parser.ParseDJSON(string(b))
+			if err != nil {
+				return nil, errors.Errorf("could not parse string %q as jsonb", b)
+			}
+			return d, nil
 		case oid.T__int2, oid.T__int4, oid.T__int8:
 			var arr pq.Int64Array
 			if err := (&arr).Scan(b); err != nil {
@@ -795,6 +816,18 @@ func decodeOidDatum(id oid.Oid, code formatCode, b []byte) (parser.Datum, error)
 				return nil, err
 			}
 			return parser.NewDIPAddr(parser.DIPAddr{IPAddr: ipAddr}), nil
+		case oid.T_jsonb:
+			if len(b) < 1 {
+				return nil, errors.Errorf("jsonb requires at least 1 byte for binary format")
+			}
+			if b[0] != pgBinaryJSONBVersion {
+				return nil, errors.Errorf("unsupported jsonb binary format version %d", b[0])
+			}
+			d, err := parser.ParseDJSON(string(b[1:]))
+			if err != nil {
+				return nil, errors.Errorf("could not parse string %q as jsonb", b[1:])
+			}
+			return d, nil
 		case oid.T__int2, oid.T__int4, oid.T__int8, oid.T__text, oid.T__name:
 			return decodeBinaryArray(b, code)
 		}
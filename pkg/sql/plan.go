@@ -143,6 +143,9 @@ var _ planNode = &createDatabaseNode{}
 var _ planNode = &createIndexNode{}
 var _ planNode = &createTableNode{}
 var _ planNode = &createViewNode{}
+var _ planNode = &createSequenceNode{}
+var _ planNode = &createStatsNode{}
+var _ planNode = &createChangefeedNode{}
 var _ planNode = &delayedNode{}
 var _ planNode = &deleteNode{}
 var _ planNode = &distinctNode{}
@@ -150,6 +153,7 @@ var _ planNode = &dropDatabaseNode{}
 var _ planNode = &dropIndexNode{}
 var _ planNode = &dropTableNode{}
 var _ planNode = &dropViewNode{}
+var _ planNode = &dropSequenceNode{}
 var _ planNode = &zeroNode{}
 var _ planNode = &unaryNode{}
 var _ planNode = &explainDistSQLNode{}
@@ -167,6 +171,7 @@ var _ planNode = &testingRelocateNode{}
 var _ planNode = &renderNode{}
 var _ planNode = &scanNode{}
 var _ planNode = &scatterNode{}
+var _ planNode = &setZoneConfigNode{}
 var _ planNode = &showRangesNode{}
 var _ planNode = &showFingerprintsNode{}
 var _ planNode = &sortNode{}
@@ -178,9 +183,13 @@ var _ planNode = &valuesNode{}
 var _ planNode = &windowNode{}
 var _ planNode = &createUserNode{}
 var _ planNode = &dropUserNode{}
+var _ planNode = &withNode{}
+var _ planNode = &cteScanNode{}
+var _ planNode = &recursiveCTENode{}
 
 var _ planNodeFastPath = &deleteNode{}
 var _ planNodeFastPath = &dropUserNode{}
+var _ planNodeFastPath = &withNode{}
 
 // makePlan implements the Planner interface.
 func (p *planner) makePlan(ctx context.Context, stmt Statement) (planNode, error) {
@@ -340,8 +349,16 @@ func (p *planner) newPlan(
 		return p.CreateDatabase(n)
 	case *parser.CreateIndex:
 		return p.CreateIndex(ctx, n)
+	case *parser.CreateSequence:
+		return p.CreateSequence(ctx, n)
+	case *parser.CreateStats:
+		return p.CreateStatistics(ctx, n)
+	case *parser.CreateChangefeed:
+		return p.CreateChangefeed(ctx, n)
 	case *parser.CreateTable:
 		return p.CreateTable(ctx, n)
+	case *parser.CreateRole:
+		return p.CreateRole(ctx, n)
 	case *parser.CreateUser:
 		return p.CreateUser(ctx, n)
 	case *parser.CreateView:
@@ -356,10 +373,14 @@ func (p *planner) newPlan(
 		return p.DropDatabase(ctx, n)
 	case *parser.DropIndex:
 		return p.DropIndex(ctx, n)
+	case *parser.DropSequence:
+		return p.DropSequence(ctx, n)
 	case *parser.DropTable:
 		return p.DropTable(ctx, n)
 	case *parser.DropView:
 		return p.DropView(ctx, n)
+	case *parser.DropRole:
+		return p.DropRole(ctx, n)
 	case *parser.DropUser:
 		return p.DropUser(ctx, n)
 	case *parser.Execute:
@@ -368,6 +389,8 @@ func (p *planner) newPlan(
 		return p.Explain(ctx, n)
 	case *parser.Grant:
 		return p.Grant(ctx, n)
+	case *parser.GrantRole:
+		return p.GrantRole(ctx, n)
 	case *parser.Insert:
 		return p.Insert(ctx, n, desiredTypes)
 	case *parser.ParenSelect:
@@ -388,6 +411,8 @@ func (p *planner) newPlan(
 		return p.ResumeJob(ctx, n)
 	case *parser.Revoke:
 		return p.Revoke(ctx, n)
+	case *parser.RevokeRole:
+		return p.RevokeRole(ctx, n)
 	case *parser.Scatter:
 		return p.Scatter(ctx, n)
 	case *parser.Select:
@@ -402,6 +427,8 @@ func (p *planner) newPlan(
 		return p.SetTransaction(n)
 	case *parser.SetDefaultIsolation:
 		return p.SetDefaultIsolation(n)
+	case *parser.SetZoneConfig:
+		return p.SetZoneConfig(ctx, n)
 	case *parser.ShowClusterSetting:
 		return p.ShowClusterSetting(ctx, n)
 	case *parser.ShowVar:
@@ -414,6 +441,8 @@ func (p *planner) newPlan(
 		return p.ShowCreateTable(ctx, n)
 	case *parser.ShowCreateView:
 		return p.ShowCreateView(ctx, n)
+	case *parser.ShowCreateSequence:
+		return p.ShowCreateSequence(ctx, n)
 	case *parser.ShowDatabases:
 		return p.ShowDatabases(ctx, n)
 	case *parser.ShowGrants:
@@ -426,12 +455,16 @@ func (p *planner) newPlan(
 		return p.ShowJobs(ctx, n)
 	case *parser.ShowSessions:
 		return p.ShowSessions(ctx, n)
+	case *parser.ShowTableStats:
+		return p.ShowTableStats(ctx, n)
 	case *parser.ShowTables:
 		return p.ShowTables(ctx, n)
 	case *parser.ShowTrace:
 		return p.ShowTrace(ctx, n)
 	case *parser.ShowTransactionStatus:
 		return p.ShowTransactionStatus(ctx)
+	case *parser.ShowRoles:
+		return p.ShowRoles(ctx, n)
 	case *parser.ShowUsers:
 		return p.ShowUsers(ctx, n)
 	case *parser.ShowRanges:
@@ -470,6 +503,8 @@ func (p *planner) prepare(ctx context.Context, stmt parser.Statement) (planNode,
 		return p.CancelQuery(ctx, n)
 	case *parser.CancelJob:
 		return p.CancelJob(ctx, n)
+	case *parser.CreateChangefeed:
+		return p.CreateChangefeed(ctx, n)
 	case *parser.Delete:
 		return p.Delete(ctx, n, nil)
 	case *parser.Explain:
@@ -496,6 +531,8 @@ func (p *planner) prepare(ctx context.Context, stmt parser.Statement) (planNode,
 		return p.ShowCreateTable(ctx, n)
 	case *parser.ShowCreateView:
 		return p.ShowCreateView(ctx, n)
+	case *parser.ShowCreateSequence:
+		return p.ShowCreateSequence(ctx, n)
 	case *parser.ShowColumns:
 		return p.ShowColumns(ctx, n)
 	case *parser.ShowDatabases:
@@ -512,10 +549,14 @@ func (p *planner) prepare(ctx context.Context, stmt parser.Statement) (planNode,
 		return p.ShowJobs(ctx, n)
 	case *parser.ShowSessions:
 		return p.ShowSessions(ctx, n)
+	case *parser.ShowTableStats:
+		return p.ShowTableStats(ctx, n)
 	case *parser.ShowTables:
 		return p.ShowTables(ctx, n)
 	case *parser.ShowTrace:
 		return p.ShowTrace(ctx, n)
+	case *parser.ShowRoles:
+		return p.ShowRoles(ctx, n)
 	case *parser.ShowUsers:
 		return p.ShowUsers(ctx, n)
 	case *parser.ShowTransactionStatus:
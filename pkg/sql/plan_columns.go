@@ -53,6 +53,10 @@ func getPlanColumns(plan planNode, mut bool) sqlbase.ResultColumns {
 		return n.resultColumns
 	case *delayedNode:
 		return n.columns
+	case *cteScanNode:
+		return n.columns
+	case *recursiveCTENode:
+		return n.columns
 	case *groupNode:
 		return n.columns
 	case *hookFnNode:
@@ -91,6 +95,8 @@ func getPlanColumns(plan planNode, mut bool) sqlbase.ResultColumns {
 		return n.getColumns(mut, showFingerprintsColumns)
 	case *splitNode:
 		return n.getColumns(mut, splitNodeColumns)
+	case *createChangefeedNode:
+		return n.getColumns(mut, createChangefeedColumns)
 
 		// Nodes using the RETURNING helper.
 	case *deleteNode:
@@ -110,6 +116,8 @@ func getPlanColumns(plan planNode, mut bool) sqlbase.ResultColumns {
 		return getPlanColumns(n.table, mut)
 	case *limitNode:
 		return getPlanColumns(n.plan, mut)
+	case *withNode:
+		return getPlanColumns(n.plan, mut)
 	case *unionNode:
 		if n.inverted {
 			return getPlanColumns(n.right, mut)
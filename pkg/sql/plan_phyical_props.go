@@ -32,6 +32,8 @@ func planPhysicalProps(plan planNode) physicalProps {
 		return planPhysicalProps(n.plan)
 	case *limitNode:
 		return planPhysicalProps(n.plan)
+	case *withNode:
+		return planPhysicalProps(n.plan)
 	case *indexJoinNode:
 		return planPhysicalProps(n.index)
 
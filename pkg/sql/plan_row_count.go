@@ -0,0 +1,430 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// The selectivities below are used for predicates on columns for which there
+// are no statistics, or for which the statistics don't say anything useful.
+const (
+	// defaultEqualitySelectivity is the fraction of rows assumed to satisfy an
+	// equality predicate.
+	defaultEqualitySelectivity = 0.1
+	// defaultRangeSelectivity is the fraction of rows assumed to satisfy any
+	// other predicate.
+	defaultRangeSelectivity = 1.0 / 3
+)
+
+// defaultTableRowCount is the number of rows assumed to be in a table without
+// statistics, when estimates are requested for such tables (see
+// planRowCount).
+const defaultTableRowCount = 1000
+
+// tableStats contains the statistics of a table used during planning.
+type tableStats struct {
+	// rowCount is the number of rows in the table.
+	rowCount float64
+	// distinctCount maps the ID of a column to the number of distinct values
+	// in that column. Columns without single-column statistics are absent.
+	distinctCount map[sqlbase.ColumnID]float64
+}
+
+// getTableStats returns the statistics of the given table, or nil if there are
+// none (or if they can't be retrieved).
+func (p *planner) getTableStats(ctx context.Context, desc *sqlbase.TableDescriptor) *tableStats {
+	// The statistics are themselves stored in a system table; we don't use
+	// statistics for system tables to avoid recursing while reading them.
+	if desc.IsVirtualTable() || desc.ParentID == keys.SystemDatabaseID {
+		return nil
+	}
+	execCfg := p.ExecCfg()
+	if execCfg == nil || execCfg.TableStatsCache == nil {
+		return nil
+	}
+	statsCache := execCfg.TableStatsCache
+	stats, err := statsCache.GetTableStats(ctx, desc.ID)
+	if err != nil {
+		log.Warningf(ctx, "unable to retrieve statistics for table %d: %v", desc.ID, err)
+		return nil
+	}
+	if len(stats) == 0 {
+		return nil
+	}
+	// The statistics are ordered with the most recent first.
+	res := &tableStats{
+		rowCount:      float64(stats[0].RowCount),
+		distinctCount: make(map[sqlbase.ColumnID]float64),
+	}
+	for _, s := range stats {
+		if len(s.ColumnIDs) != 1 {
+			continue
+		}
+		if _, ok := res.distinctCount[s.ColumnIDs[0]]; !ok {
+			res.distinctCount[s.ColumnIDs[0]] = float64(s.DistinctCount)
+		}
+	}
+	return res
+}
+
+// equalitySelectivity returns the fraction of the rows of the table with a
+// given value in the given column.
+func (ts *tableStats) equalitySelectivity(colID sqlbase.ColumnID) float64 {
+	if d, ok := ts.distinctCount[colID]; ok && d >= 1 {
+		return 1 / d
+	}
+	return defaultEqualitySelectivity
+}
+
+// columnDistinctCount returns the number of distinct values in the given
+// column, out of at most maxRows rows.
+func (ts *tableStats) columnDistinctCount(
+	colID sqlbase.ColumnID, maxRows float64,
+) (float64, bool) {
+	d, ok := ts.distinctCount[colID]
+	if !ok {
+		return 0, false
+	}
+	return math.Min(d, maxRows), true
+}
+
+// planRowCount returns the estimated number of rows produced by the plan. The
+// second return value is false if no estimate is available, which is the case
+// when the plan reads from tables without statistics, unless useDefaults is
+// set: such tables are then assumed to contain defaultTableRowCount rows.
+func planRowCount(plan planNode, useDefaults bool) (float64, bool) {
+	switch n := plan.(type) {
+	case *scanNode:
+		if n.stats == nil {
+			if !useDefaults {
+				return 0, false
+			}
+			rows, _ := n.defaultEstimates()
+			return rows, true
+		}
+		return n.estimatedRowCount, true
+	case *indexJoinNode:
+		return planRowCount(n.index, useDefaults)
+	case *filterNode:
+		rows, ok := planRowCount(n.source.plan, useDefaults)
+		return rows * defaultRangeSelectivity, ok
+	case *renderNode:
+		return planRowCount(n.source.plan, useDefaults)
+	case *sortNode:
+		return planRowCount(n.plan, useDefaults)
+	case *distinctNode:
+		return planRowCount(n.plan, useDefaults)
+	case *limitNode:
+		rows, ok := planRowCount(n.plan, useDefaults)
+		if ok && n.evaluated && n.count < math.MaxInt64 {
+			rows = math.Min(rows, float64(n.count))
+		}
+		return rows, ok
+	case *joinNode:
+		return n.estimateRowCount(useDefaults)
+	case *zeroNode:
+		return 0, true
+	}
+	return 0, false
+}
+
+// defaultEstimates returns the number of rows the scan is expected to produce
+// and its cost, assuming the table contains defaultTableRowCount rows. It is
+// used for tables without statistics.
+func (n *scanNode) defaultEstimates() (rows, cost float64) {
+	rows = defaultTableRowCount
+	if !n.isFullIndexScan() {
+		// The scan is constrained; it is assumed to look up a single value if
+		// some of the index columns are constrained to a constant.
+		if n.props.constantCols.Empty() {
+			rows *= defaultRangeSelectivity
+		} else {
+			rows *= defaultEqualitySelectivity
+		}
+	}
+	// All the rows in the spans are read, including those that are then
+	// filtered out.
+	cost = rows
+	if n.filter != nil {
+		rows *= defaultRangeSelectivity
+	}
+	return rows, cost
+}
+
+// planColumnDistinctCount returns the estimated number of distinct values in
+// the given result column of the plan. The second return value is false if no
+// estimate is available.
+func planColumnDistinctCount(plan planNode, col int) (float64, bool) {
+	switch n := plan.(type) {
+	case *scanNode:
+		if n.stats == nil {
+			return 0, false
+		}
+		return n.stats.columnDistinctCount(n.cols[col].ID, n.estimatedRowCount)
+	case *indexJoinNode:
+		if n.index.stats == nil {
+			return 0, false
+		}
+		return n.index.stats.columnDistinctCount(n.table.cols[col].ID, n.index.estimatedRowCount)
+	case *filterNode:
+		return planColumnDistinctCount(n.source.plan, col)
+	case *renderNode:
+		if iv, ok := n.render[col].(*parser.IndexedVar); ok {
+			return planColumnDistinctCount(n.source.plan, iv.Idx)
+		}
+	case *joinNode:
+		col -= n.pred.numMergedEqualityColumns
+		if col < 0 {
+			return 0, false
+		}
+		if col < n.pred.numLeftCols {
+			return planColumnDistinctCount(n.left.plan, col)
+		}
+		return planColumnDistinctCount(n.right.plan, col-n.pred.numLeftCols)
+	}
+	return 0, false
+}
+
+// joinEqualitySelectivity returns the fraction of the rows of a cross product
+// that satisfy an equality between two columns with the given numbers of
+// distinct values. This assumes that the values of the side with fewer
+// distinct values are all present on the other side.
+func joinEqualitySelectivity(leftDistinct, rightDistinct float64) float64 {
+	return 1 / math.Max(1, math.Max(leftDistinct, rightDistinct))
+}
+
+// estimateRowCount returns the estimated number of rows produced by the join.
+// See planRowCount for useDefaults.
+func (n *joinNode) estimateRowCount(useDefaults bool) (float64, bool) {
+	leftRows, ok := planRowCount(n.left.plan, useDefaults)
+	if !ok {
+		return 0, false
+	}
+	rightRows, ok := planRowCount(n.right.plan, useDefaults)
+	if !ok {
+		return 0, false
+	}
+
+	// Only the most selective of the equality columns is taken into account:
+	// the columns of multi-column equalities are often correlated.
+	sel := 1.0
+	for i := range n.pred.leftEqualityIndices {
+		leftDistinct, ok := planColumnDistinctCount(n.left.plan, n.pred.leftEqualityIndices[i])
+		if !ok {
+			leftDistinct = leftRows
+		}
+		rightDistinct, ok := planColumnDistinctCount(n.right.plan, n.pred.rightEqualityIndices[i])
+		if !ok {
+			rightDistinct = rightRows
+		}
+		sel = math.Min(sel, joinEqualitySelectivity(leftDistinct, rightDistinct))
+	}
+	if n.pred.onCond != nil {
+		sel *= defaultRangeSelectivity
+	}
+
+	rows := leftRows * rightRows * sel
+	switch n.joinType {
+	case joinTypeLeftOuter:
+		rows = math.Max(rows, leftRows)
+	case joinTypeRightOuter:
+		rows = math.Max(rows, rightRows)
+	case joinTypeFullOuter:
+		rows = math.Max(rows, leftRows+rightRows)
+	}
+	return rows, true
+}
+
+// The costs of plans are expressed in units of the cost of reading a row
+// sequentially from a table.
+const (
+	// hashJoinBuildCost is the cost of adding a row of the right side of a
+	// hash join to its hash table.
+	hashJoinBuildCost = 2
+	// lookupRowCost is the cost of looking up rows with a point lookup in an
+	// index, as done for each input row by index joins and lookup joins.
+	lookupRowCost = 10
+)
+
+// planCost returns the estimated cost of executing the plan. The second
+// return value is false if no estimate is available; see planRowCount for
+// useDefaults.
+func planCost(plan planNode, useDefaults bool) (float64, bool) {
+	switch n := plan.(type) {
+	case *scanNode:
+		if n.stats == nil {
+			if !useDefaults {
+				return 0, false
+			}
+			_, cost := n.defaultEstimates()
+			return cost, true
+		}
+		return n.estimatedCost, true
+	case *indexJoinNode:
+		cost, ok := planCost(n.index, useDefaults)
+		if !ok {
+			return 0, false
+		}
+		rows, _ := planRowCount(n.index, useDefaults)
+		return cost + rows*lookupRowCost, true
+	case *filterNode:
+		return planCost(n.source.plan, useDefaults)
+	case *renderNode:
+		return planCost(n.source.plan, useDefaults)
+	case *sortNode:
+		return planCost(n.plan, useDefaults)
+	case *distinctNode:
+		return planCost(n.plan, useDefaults)
+	case *limitNode:
+		return planCost(n.plan, useDefaults)
+	case *joinNode:
+		costs, ok := n.estimateAlgorithmCosts(useDefaults)
+		if !ok {
+			return 0, false
+		}
+		return costs.cheapest(nil).cost, true
+	case *zeroNode:
+		return 0, true
+	}
+	return 0, false
+}
+
+// joinAlgorithm is an algorithm with which DistSQL can execute a join.
+type joinAlgorithm int
+
+const (
+	hashJoin joinAlgorithm = iota
+	mergeJoin
+	lookupJoin
+)
+
+var joinAlgorithmNames = [...]string{
+	hashJoin:   "hash",
+	mergeJoin:  "merge",
+	lookupJoin: "lookup",
+}
+
+func (a joinAlgorithm) String() string {
+	return joinAlgorithmNames[a]
+}
+
+// joinAlgorithmCost is the estimated cost of executing a join, including the
+// cost of its inputs, with a given algorithm.
+type joinAlgorithmCost struct {
+	algo joinAlgorithm
+	cost float64
+}
+
+// joinAlgorithmCosts contains the costs of the algorithms that can be used
+// for a join. The hash join, which can always be used, comes first.
+type joinAlgorithmCosts []joinAlgorithmCost
+
+// cheapest returns the cheapest of the algorithms for which enabled returns
+// true, or of all the algorithms if enabled is nil. The hash join is
+// considered even if it isn't enabled.
+func (c joinAlgorithmCosts) cheapest(enabled func(joinAlgorithm) bool) joinAlgorithmCost {
+	best := c[0]
+	for _, a := range c[1:] {
+		if (enabled == nil || enabled(a.algo)) && a.cost < best.cost {
+			best = a
+		}
+	}
+	return best
+}
+
+// String formats the costs for EXPLAIN.
+func (c joinAlgorithmCosts) String() string {
+	var buf bytes.Buffer
+	for i, a := range c {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		fmt.Fprintf(&buf, "%s %.0f", a.algo, a.cost)
+	}
+	return buf.String()
+}
+
+// canUseMergeJoin returns true if the join can be executed with a merge join,
+// which requires the inputs to be ordered on all the equality columns.
+func (n *joinNode) canUseMergeJoin() bool {
+	return n.joinType == joinTypeInner && len(n.pred.leftEqualityIndices) > 0 &&
+		len(n.mergeJoinOrdering) == len(n.pred.leftEqualityIndices)
+}
+
+// estimateAlgorithmCosts returns the estimated costs of executing the join
+// with each of the algorithms that can be used for it. The second return
+// value is false if no estimate is available; see planRowCount for
+// useDefaults.
+//
+// All the algorithms read the rows of the left side and produce the rows of
+// the join. In addition:
+//   - a hash join reads the rows of the right side and adds them to a hash
+//     table;
+//   - a merge join reads the rows of the right side;
+//   - a lookup join doesn't read the right side at all, but looks up the
+//     matching rows in an index of the right table for each row of the left
+//     side; if the index doesn't contain all the needed columns, each of the
+//     matching rows is then looked up in the primary index.
+func (n *joinNode) estimateAlgorithmCosts(useDefaults bool) (joinAlgorithmCosts, bool) {
+	leftRows, ok := planRowCount(n.left.plan, useDefaults)
+	if !ok {
+		return nil, false
+	}
+	leftCost, ok := planCost(n.left.plan, useDefaults)
+	if !ok {
+		return nil, false
+	}
+	rightRows, ok := planRowCount(n.right.plan, useDefaults)
+	if !ok {
+		return nil, false
+	}
+	rightCost, ok := planCost(n.right.plan, useDefaults)
+	if !ok {
+		return nil, false
+	}
+	rows, ok := n.estimateRowCount(useDefaults)
+	if !ok {
+		return nil, false
+	}
+
+	costs := joinAlgorithmCosts{{
+		algo: hashJoin,
+		cost: leftCost + rightCost + leftRows + hashJoinBuildCost*rightRows + rows,
+	}}
+	if n.canUseMergeJoin() {
+		costs = append(costs, joinAlgorithmCost{
+			algo: mergeJoin,
+			cost: leftCost + rightCost + leftRows + rightRows + rows,
+		})
+	}
+	if target, ok := findLookupJoinTarget(n); ok {
+		cost := leftCost + lookupRowCost*leftRows + rows
+		if !target.covering {
+			cost += lookupRowCost * rows
+		}
+		costs = append(costs, joinAlgorithmCost{algo: lookupJoin, cost: cost})
+	}
+	return costs, true
+}
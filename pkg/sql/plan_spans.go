@@ -35,6 +35,7 @@ func collectSpans(params runParams, plan planNode) (reads, writes roachpb.Spans,
 	case
 		*valueGenerator,
 		*valuesNode,
+		*cteScanNode,
 		*zeroNode,
 		*unaryNode:
 		return nil, nil, nil
@@ -87,6 +88,10 @@ func collectSpans(params runParams, plan planNode) (reads, writes roachpb.Spans,
 		return concatSpans(params, n.left.plan, n.right.plan)
 	case *unionNode:
 		return concatSpans(params, n.left, n.right)
+	case *recursiveCTENode:
+		return concatSpans(params, n.initial, n.recursive)
+	case *withNode:
+		return withNodeSpans(params, n)
 	}
 
 	panic(fmt.Sprintf("don't know how to collect spans for node %T", plan))
@@ -125,7 +130,8 @@ func tableWriterSpans(params runParams, tw tableWriter) (reads, writes roachpb.S
 	// conservative and assume anything in the table might change.
 	tableSpans := tw.tableDesc().AllIndexSpans()
 	fkReads := tw.fkSpanCollector().CollectSpans()
-	return fkReads, tableSpans
+	cascadeWrites := tw.fkSpanCollector().CollectCascadeSpans()
+	return fkReads, append(tableSpans, cascadeWrites...)
 }
 
 // insertNodeWithValuesSpans is a special case of editNodeSpans. It tightens the
@@ -219,3 +225,21 @@ func concatSpans(params runParams, left, right planNode) (reads, writes roachpb.
 	}
 	return append(leftReads, rightReads...), append(leftWrites, rightWrites...), nil
 }
+
+func withNodeSpans(params runParams, n *withNode) (reads, writes roachpb.Spans, err error) {
+	// The spans touched by a CTE are attributed to the withNode that
+	// defines it, as opposed to the cteScanNodes that refer to it.
+	for _, cte := range n.ctes {
+		cteReads, cteWrites, err := collectSpans(params, cte.plan)
+		if err != nil {
+			return nil, nil, err
+		}
+		reads = append(reads, cteReads...)
+		writes = append(writes, cteWrites...)
+	}
+	planReads, planWrites, err := collectSpans(params, n.plan)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(reads, planReads...), append(writes, planWrites...), nil
+}
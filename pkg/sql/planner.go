@@ -98,6 +98,14 @@ type planner struct {
 	// occurred during logical plan construction.
 	hasSubqueries bool
 
+	// cteNameEnvironment collects the common table expressions visible
+	// to the statement being planned. See with.go.
+	cteNameEnvironment cteNameEnvironment
+
+	// memberOf caches the results of MemberOfWithAdminOption, keyed by
+	// member. It is cleared whenever the planner changes role memberships.
+	memberOf map[string]map[string]bool
+
 	// Avoid allocations by embedding commonly used objects and visitors.
 	parser                parser.Parser
 	subqueryVisitor       subqueryVisitor
@@ -298,19 +306,17 @@ func (p *planner) exec(ctx context.Context, sql string, args ...interface{}) (in
 	return countRowsAffected(params, plan)
 }
 
-func (p *planner) fillFKTableMap(ctx context.Context, m sqlbase.TableLookupsByID) error {
-	for tableID := range m {
-		table, err := p.session.tables.getTableVersionByID(ctx, p.txn, tableID)
-		if err == errTableAdding {
-			m[tableID] = sqlbase.TableLookup{IsAdding: true}
-			continue
-		}
-		if err != nil {
-			return err
-		}
-		m[tableID] = sqlbase.TableLookup{Table: table}
+func (p *planner) lookupFKTable(
+	ctx context.Context, tableID sqlbase.ID,
+) (sqlbase.TableLookup, error) {
+	table, err := p.session.tables.getTableVersionByID(ctx, p.txn, tableID)
+	if err == errTableAdding {
+		return sqlbase.TableLookup{IsAdding: true}, nil
+	}
+	if err != nil {
+		return sqlbase.TableLookup{}, err
 	}
-	return nil
+	return sqlbase.TableLookup{Table: table}, nil
 }
 
 // isDatabaseVisible returns true if the given database is visible
@@ -15,9 +15,11 @@
 package privilege_test
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 )
 
@@ -60,3 +62,19 @@ func TestPrivilegeDecode(t *testing.T) {
 		}
 	}
 }
+
+func TestListFromStrings(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	pl, err := privilege.ListFromStrings([]string{"select", "INSERT", "Drop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (privilege.List{privilege.SELECT, privilege.INSERT, privilege.DROP}); !reflect.DeepEqual(pl, expected) {
+		t.Errorf("expected %v, got %v", expected, pl)
+	}
+
+	if _, err := privilege.ListFromStrings([]string{"select", "foo"}); !testutils.IsError(err, `not a valid privilege: "foo"`) {
+		t.Errorf("expected invalid privilege error, got %v", err)
+	}
+}
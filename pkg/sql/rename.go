@@ -52,7 +52,7 @@ func (p *planner) RenameDatabase(ctx context.Context, n *parser.RenameDatabase)
 		return nil, err
 	}
 
-	if err := p.CheckPrivilege(dbDesc, privilege.DROP); err != nil {
+	if err := p.CheckPrivilege(ctx, dbDesc, privilege.DROP); err != nil {
 		return nil, err
 	}
 
@@ -165,7 +165,7 @@ func (p *planner) RenameTable(ctx context.Context, n *parser.RenameTable) (planN
 		}
 	}
 
-	if err := p.CheckPrivilege(tableDesc, privilege.DROP); err != nil {
+	if err := p.CheckPrivilege(ctx, tableDesc, privilege.DROP); err != nil {
 		return nil, err
 	}
 
@@ -184,7 +184,7 @@ func (p *planner) RenameTable(ctx context.Context, n *parser.RenameTable) (planN
 		return nil, err
 	}
 
-	if err := p.CheckPrivilege(targetDbDesc, privilege.CREATE); err != nil {
+	if err := p.CheckPrivilege(ctx, targetDbDesc, privilege.CREATE); err != nil {
 		return nil, err
 	}
 
@@ -275,7 +275,7 @@ func (p *planner) RenameIndex(ctx context.Context, n *parser.RenameIndex) (planN
 		return nil, err
 	}
 
-	if err := p.CheckPrivilege(tableDesc, privilege.CREATE); err != nil {
+	if err := p.CheckPrivilege(ctx, tableDesc, privilege.CREATE); err != nil {
 		return nil, err
 	}
 
@@ -339,7 +339,7 @@ func (p *planner) RenameColumn(ctx context.Context, n *parser.RenameColumn) (pla
 		return nil, fmt.Errorf("table %q does not exist", tn.Table())
 	}
 
-	if err := p.CheckPrivilege(tableDesc, privilege.CREATE); err != nil {
+	if err := p.CheckPrivilege(ctx, tableDesc, privilege.CREATE); err != nil {
 		return nil, err
 	}
 
@@ -409,6 +409,36 @@ func (p *planner) RenameColumn(ctx context.Context, n *parser.RenameColumn) (pla
 			tableDesc.Checks[i].Expr = after
 		}
 	}
+
+	// Rename the column in the expressions of computed columns.
+	renameInComputeExpr := func(c *sqlbase.ColumnDescriptor) error {
+		if !c.IsComputed() {
+			return nil
+		}
+		expr, err := parser.ParseExpr(*c.ComputeExpr)
+		if err != nil {
+			return err
+		}
+		expr, err = parser.SimpleVisit(expr, preFn)
+		if err != nil {
+			return err
+		}
+		s := expr.String()
+		c.ComputeExpr = &s
+		return nil
+	}
+	for i := range tableDesc.Columns {
+		if err := renameInComputeExpr(&tableDesc.Columns[i]); err != nil {
+			return nil, err
+		}
+	}
+	for i := range tableDesc.Mutations {
+		if c := tableDesc.Mutations[i].GetColumn(); c != nil {
+			if err := renameInComputeExpr(c); err != nil {
+				return nil, err
+			}
+		}
+	}
 	// Rename the column in the indexes.
 	tableDesc.RenameColumnDescriptor(col, string(n.NewName))
 
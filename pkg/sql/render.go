@@ -136,11 +136,24 @@ func (r *renderNode) IndexedVarFormat(buf *bytes.Buffer, f parser.FmtFlags, idx
 func (p *planner) Select(
 	ctx context.Context, n *parser.Select, desiredTypes []parser.Type,
 ) (planNode, error) {
+	if n.With != nil {
+		return p.planWith(ctx, n.With, func() (planNode, error) {
+			sel := *n
+			sel.With = nil
+			return p.Select(ctx, &sel, desiredTypes)
+		})
+	}
+
 	wrapped := n.Select
 	limit := n.Limit
 	orderBy := n.OrderBy
 
 	for s, ok := wrapped.(*parser.ParenSelect); ok; s, ok = wrapped.(*parser.ParenSelect) {
+		if s.Select.With != nil {
+			// The WITH clause scopes over the parenthesized select, which
+			// must thus be planned on its own.
+			break
+		}
 		wrapped = s.Select.Select
 		if s.Select.OrderBy != nil {
 			if orderBy != nil {
@@ -183,6 +183,9 @@ func TestRandomSyntaxFunctions(t *testing.T) {
 				args = append(args, r.GenerateRandomArg(typ))
 			}
 		case parser.VariadicType:
+			for _, typ := range ft.FixedTypes {
+				args = append(args, r.GenerateRandomArg(typ))
+			}
 			for i := r.Intn(5); i > 0; i-- {
 				args = append(args, r.GenerateRandomArg(ft.Typ))
 			}
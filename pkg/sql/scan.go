@@ -95,6 +95,13 @@ type scanNode struct {
 
 	disableBatchLimits bool
 
+	// stats contains the statistics of the table, if there are any. If set,
+	// estimatedRowCount is the number of rows the scan is expected to produce
+	// and estimatedCost the cost of the scan (see planCost).
+	stats             *tableStats
+	estimatedRowCount float64
+	estimatedCost     float64
+
 	scanVisibility scanVisibility
 	// This struct must be allocated on the heap and its location stay
 	// stable after construction because it implements
@@ -189,6 +196,7 @@ func (n *scanNode) Next(params runParams) (bool, error) {
 
 // Initializes a scanNode with a table descriptor.
 func (n *scanNode) initTable(
+	ctx context.Context,
 	p *planner,
 	desc *sqlbase.TableDescriptor,
 	indexHints *parser.IndexHints,
@@ -198,7 +206,7 @@ func (n *scanNode) initTable(
 	n.desc = desc
 
 	if !p.skipSelectPrivilegeChecks {
-		if err := p.CheckPrivilege(n.desc, privilege.SELECT); err != nil {
+		if err := p.CheckPrivilege(ctx, n.desc, privilege.SELECT); err != nil {
 			return err
 		}
 	}
@@ -367,6 +375,11 @@ func (n *scanNode) initDescDefaults(
 	return nil
 }
 
+// isFullIndexScan returns true if the scan reads the whole index.
+func (n *scanNode) isFullIndexScan() bool {
+	return len(n.spans) == 1 && n.spans[0].EqualValue(n.desc.IndexSpan(n.index.ID))
+}
+
 // initOrdering initializes the ordering info using the selected index. This
 // must be called after index selection is performed.
 func (n *scanNode) initOrdering(exactPrefix int) {
@@ -386,6 +399,12 @@ func (n *scanNode) computePhysicalProps(
 ) physicalProps {
 	var ordering physicalProps
 
+	if index.Type == sqlbase.IndexDescriptor_INVERTED {
+		// The rows of an inverted index scan aren't ordered by any column.
+		ordering.applyExpr(&n.p.evalCtx, n.filter)
+		return ordering
+	}
+
 	columnIDs, dirs := index.FullColumnIDs()
 
 	var keySet util.FastIntSet
@@ -302,7 +302,7 @@ func (sc *SchemaChanger) maybeAddDropRename(
 		if _, err := sc.leaseMgr.Publish(
 			ctx,
 			table.ID,
-			func(tbl *sqlbase.TableDescriptor) error {
+			func(_ *client.Txn, tbl *sqlbase.TableDescriptor) error {
 				tbl.State = sqlbase.TableDescriptor_PUBLIC
 				return nil
 			},
@@ -342,7 +342,7 @@ func (sc *SchemaChanger) maybeAddDropRename(
 		}
 
 		// Clean up - clear the descriptor's state.
-		if _, err := sc.leaseMgr.Publish(ctx, sc.tableID, func(desc *sqlbase.TableDescriptor) error {
+		if _, err := sc.leaseMgr.Publish(ctx, sc.tableID, func(_ *client.Txn, desc *sqlbase.TableDescriptor) error {
 			desc.Renames = nil
 			return nil
 		}, nil); err != nil {
@@ -488,7 +488,7 @@ func (sc *SchemaChanger) rollbackSchemaChange(
 // the current (pre-increment) version of the descriptor.
 // Returns the (potentially updated) descriptor.
 func (sc *SchemaChanger) MaybeIncrementVersion(ctx context.Context) (*sqlbase.Descriptor, error) {
-	return sc.leaseMgr.Publish(ctx, sc.tableID, func(desc *sqlbase.TableDescriptor) error {
+	return sc.leaseMgr.Publish(ctx, sc.tableID, func(_ *client.Txn, desc *sqlbase.TableDescriptor) error {
 		if !desc.UpVersion {
 			// Return error so that Publish() doesn't increment the version.
 			return errDidntUpdateDescriptor
@@ -503,7 +503,7 @@ func (sc *SchemaChanger) MaybeIncrementVersion(ctx context.Context) (*sqlbase.De
 // and wait to ensure that all nodes are seeing the latest version
 // of the table.
 func (sc *SchemaChanger) RunStateMachineBeforeBackfill(ctx context.Context) error {
-	if _, err := sc.leaseMgr.Publish(ctx, sc.tableID, func(desc *sqlbase.TableDescriptor) error {
+	if _, err := sc.leaseMgr.Publish(ctx, sc.tableID, func(_ *client.Txn, desc *sqlbase.TableDescriptor) error {
 		var modified bool
 		// Apply mutations belonging to the same version.
 		for i, mutation := range desc.Mutations {
@@ -575,9 +575,14 @@ func (sc *SchemaChanger) waitToUpdateLeases(ctx context.Context, tableID sqlbase
 // done finalizes the mutations (adds new cols/indexes to the table).
 // It ensures that all nodes are on the current (pre-update) version of the
 // schema.
-// Returns the updated of the descriptor.
-func (sc *SchemaChanger) done(ctx context.Context) (*sqlbase.Descriptor, error) {
-	return sc.leaseMgr.Publish(ctx, sc.tableID, func(desc *sqlbase.TableDescriptor) error {
+// Returns the updated of the descriptor, and the ID of the cleanup mutations
+// queued up by the completed mutations, if any.
+func (sc *SchemaChanger) done(
+	ctx context.Context,
+) (*sqlbase.Descriptor, sqlbase.MutationID, error) {
+	cleanupMutationID := sqlbase.InvalidMutationID
+	desc, err := sc.leaseMgr.Publish(ctx, sc.tableID, func(txn *client.Txn, desc *sqlbase.TableDescriptor) error {
+		cleanupMutationID = sqlbase.InvalidMutationID
 		i := 0
 		for _, mutation := range desc.Mutations {
 			if mutation.MutationID != sc.mutationID {
@@ -603,7 +608,12 @@ func (sc *SchemaChanger) done(ctx context.Context) (*sqlbase.Descriptor, error)
 				break
 			}
 		}
-		return nil
+
+		// The cleanup job is created in the transaction publishing the
+		// descriptor, so that a retried publication doesn't leave it behind.
+		var err error
+		cleanupMutationID, err = sc.createCleanupJob(ctx, txn, desc)
+		return err
 	}, func(txn *client.Txn) error {
 		if err := sc.job.WithTxn(txn).Succeeded(ctx); err != nil {
 			log.Warningf(ctx, "schema change ignoring error while marking job %d as successful: %+v",
@@ -623,6 +633,41 @@ func (sc *SchemaChanger) done(ctx context.Context) (*sqlbase.Descriptor, error)
 			}{uint32(sc.mutationID)},
 		)
 	})
+	return desc, cleanupMutationID, err
+}
+
+// createCleanupJob finalizes the mutations added to desc by the completion of
+// the schema change, such as the mutation dropping a column replaced by its
+// shadow column, and creates a job tracking them in txn. The job is recorded
+// in desc, which the caller must write in txn. It returns the mutation ID of
+// the cleanup mutations, or InvalidMutationID if there are none.
+func (sc *SchemaChanger) createCleanupJob(
+	ctx context.Context, txn *client.Txn, desc *sqlbase.TableDescriptor,
+) (sqlbase.MutationID, error) {
+	var spanList []jobs.ResumeSpanList
+	for _, mutation := range desc.Mutations {
+		if mutation.MutationID == desc.NextMutationID {
+			spanList = append(spanList, jobs.ResumeSpanList{
+				ResumeSpans: []roachpb.Span{desc.PrimaryIndexSpan()},
+			})
+		}
+	}
+	if len(spanList) == 0 {
+		return sqlbase.InvalidMutationID, nil
+	}
+	mutationID := desc.NextMutationID
+	desc.NextMutationID++
+
+	record := sc.job.Record
+	record.Description = "CLEAN UP " + record.Description
+	record.Details = jobs.SchemaChangeDetails{ResumeSpanList: spanList}
+	job := sc.jobRegistry.NewJob(record)
+	if err := job.WithTxn(txn).Created(ctx, jobs.WithoutCancel); err != nil {
+		return sqlbase.InvalidMutationID, err
+	}
+	desc.MutationJobs = append(desc.MutationJobs, sqlbase.TableDescriptor_MutationJob{
+		MutationID: mutationID, JobID: *job.ID()})
+	return mutationID, nil
 }
 
 // notFirstInLine returns true whenever the schema change has been queued
@@ -670,8 +715,34 @@ func (sc *SchemaChanger) runStateMachineAndBackfill(
 	}
 
 	// Mark the mutations as completed.
-	_, err := sc.done(ctx)
-	return err
+	desc, cleanupMutationID, err := sc.done(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Completing the mutations might have queued up cleanup mutations. Run
+	// them right away if nothing else is queued up before them, so that they
+	// don't hold up the next schema change until the SchemaChangeManager gets
+	// to them.
+	tableDesc := desc.GetTable()
+	if cleanupMutationID == sqlbase.InvalidMutationID ||
+		tableDesc.Mutations[0].MutationID != cleanupMutationID {
+		return nil
+	}
+	sc.mutationID = cleanupMutationID
+	jobID, err := sc.getJobIDForMutationWithDescriptor(ctx, tableDesc, sc.mutationID)
+	if err != nil {
+		return err
+	}
+	if sc.job, err = sc.jobRegistry.LoadJob(ctx, jobID); err != nil {
+		return err
+	}
+	if err := sc.job.Started(ctx); err != nil {
+		if log.V(2) {
+			log.Infof(ctx, "Failed to mark job %d as started: %v", *sc.job.ID(), err)
+		}
+	}
+	return sc.runStateMachineAndBackfill(ctx, lease, evalCtx)
 }
 
 // reverseMutations reverses the direction of all the mutations with the
@@ -680,7 +751,7 @@ func (sc *SchemaChanger) runStateMachineAndBackfill(
 // all new indexes referencing the column will also be dropped.
 func (sc *SchemaChanger) reverseMutations(ctx context.Context, causingError error) error {
 	// Reverse the flow of the state machine.
-	_, err := sc.leaseMgr.Publish(ctx, sc.tableID, func(desc *sqlbase.TableDescriptor) error {
+	_, err := sc.leaseMgr.Publish(ctx, sc.tableID, func(_ *client.Txn, desc *sqlbase.TableDescriptor) error {
 		// Keep track of the column mutations being reversed so that indexes
 		// referencing them can be dropped.
 		columns := make(map[string]struct{})
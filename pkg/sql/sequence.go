@@ -0,0 +1,259 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// sequenceState stores the values most recently obtained from sequences in a
+// session, for use by currval() and lastval().
+type sequenceState struct {
+	mu syncutil.Mutex
+	// latestValues stores the last value obtained by nextval() (or set by
+	// setval() with is_called = true) in this session, by sequence ID.
+	latestValues map[sqlbase.ID]int64
+	// lastSequenceIncremented is the ID of the last sequence on which
+	// nextval() was called in this session, or 0 if there is none.
+	lastSequenceIncremented sqlbase.ID
+}
+
+func (ss *sequenceState) recordValue(seqID sqlbase.ID, val int64) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.lastSequenceIncremented = seqID
+	ss.setLatestValueLocked(seqID, val)
+}
+
+func (ss *sequenceState) setLatestValueLocked(seqID sqlbase.ID, val int64) {
+	if ss.latestValues == nil {
+		ss.latestValues = make(map[sqlbase.ID]int64)
+	}
+	ss.latestValues[seqID] = val
+}
+
+func (ss *sequenceState) getLatestValue(seqID sqlbase.ID) (int64, bool) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	val, ok := ss.latestValues[seqID]
+	return val, ok
+}
+
+func (ss *sequenceState) getLastValue() (sqlbase.ID, int64, bool) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if ss.lastSequenceIncremented == 0 {
+		return 0, 0, false
+	}
+	return ss.lastSequenceIncremented, ss.latestValues[ss.lastSequenceIncremented], true
+}
+
+func (ss *sequenceState) reset() {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.latestValues = nil
+	ss.lastSequenceIncremented = 0
+}
+
+// getSequenceDesc returns the descriptor of the named sequence, or an error if
+// the name does not refer to a sequence.
+func (p *planner) getSequenceDesc(
+	ctx context.Context, seqName *parser.TableName,
+) (*sqlbase.TableDescriptor, error) {
+	desc, err := p.getTableDesc(ctx, seqName)
+	if err != nil {
+		return nil, err
+	}
+	if !desc.IsSequence() {
+		return nil, sqlbase.NewWrongObjectTypeError(seqName, "sequence")
+	}
+	return desc, nil
+}
+
+// IncrementSequence implements the parser.EvalPlanner interface.
+//
+// The increment is not transactional: the value obtained is not rolled back
+// if the enclosing transaction aborts, so concurrent sessions never obtain
+// the same value.
+func (p *planner) IncrementSequence(ctx context.Context, seqName *parser.TableName) (int64, error) {
+	descriptor, err := p.getSequenceDesc(ctx, seqName)
+	if err != nil {
+		return 0, err
+	}
+	if err := p.CheckPrivilege(ctx, descriptor, privilege.UPDATE); err != nil {
+		return 0, err
+	}
+
+	seqOpts := descriptor.SequenceOpts
+	seqValueKey := keys.MakeSequenceKey(uint32(descriptor.ID))
+	val, err := client.IncrementValRetryable(
+		ctx, p.session.execCfg.DB, seqValueKey, seqOpts.Increment)
+	if err != nil {
+		return 0, err
+	}
+
+	if val > seqOpts.MaxValue {
+		return 0, pgerror.NewErrorf(pgerror.CodeSequenceGeneratorLimitExceeded,
+			"reached maximum value of sequence %q (%d)", descriptor.Name, seqOpts.MaxValue)
+	}
+	if val < seqOpts.MinValue {
+		return 0, pgerror.NewErrorf(pgerror.CodeSequenceGeneratorLimitExceeded,
+			"reached minimum value of sequence %q (%d)", descriptor.Name, seqOpts.MinValue)
+	}
+
+	p.session.sequenceState.recordValue(descriptor.ID, val)
+	return val, nil
+}
+
+// GetLatestValueInSessionForSequence implements the parser.EvalPlanner
+// interface.
+func (p *planner) GetLatestValueInSessionForSequence(
+	ctx context.Context, seqName *parser.TableName,
+) (int64, error) {
+	descriptor, err := p.getSequenceDesc(ctx, seqName)
+	if err != nil {
+		return 0, err
+	}
+	if err := p.CheckPrivilege(ctx, descriptor, privilege.SELECT); err != nil {
+		return 0, err
+	}
+
+	val, ok := p.session.sequenceState.getLatestValue(descriptor.ID)
+	if !ok {
+		return 0, pgerror.NewErrorf(pgerror.CodeObjectNotInPrerequisiteStateError,
+			"currval of sequence %q is not yet defined in this session", descriptor.Name)
+	}
+	return val, nil
+}
+
+// GetLastSequenceValue implements the parser.EvalPlanner interface.
+func (p *planner) GetLastSequenceValue(ctx context.Context) (int64, error) {
+	seqID, val, ok := p.session.sequenceState.getLastValue()
+	if !ok {
+		return 0, pgerror.NewError(pgerror.CodeObjectNotInPrerequisiteStateError,
+			"lastval is not yet defined in this session")
+	}
+	descriptor, err := p.getTableDescByID(ctx, seqID)
+	if err != nil {
+		return 0, err
+	}
+	if err := p.CheckPrivilege(ctx, descriptor, privilege.SELECT); err != nil {
+		return 0, err
+	}
+	return val, nil
+}
+
+// SetSequenceValue implements the parser.EvalPlanner interface.
+//
+// If isCalled is false, the next call to nextval() returns newVal; otherwise
+// it returns newVal plus the sequence's increment.
+func (p *planner) SetSequenceValue(
+	ctx context.Context, seqName *parser.TableName, newVal int64, isCalled bool,
+) error {
+	descriptor, err := p.getSequenceDesc(ctx, seqName)
+	if err != nil {
+		return err
+	}
+	if err := p.CheckPrivilege(ctx, descriptor, privilege.UPDATE); err != nil {
+		return err
+	}
+
+	seqOpts := descriptor.SequenceOpts
+	if newVal > seqOpts.MaxValue || newVal < seqOpts.MinValue {
+		return pgerror.NewErrorf(pgerror.CodeNumericValueOutOfRangeError,
+			"value %d is out of bounds for sequence %q (%d..%d)",
+			newVal, descriptor.Name, seqOpts.MinValue, seqOpts.MaxValue)
+	}
+
+	// The stored value is always the last value handed out, so back it up by
+	// one increment if the next call to nextval() should return newVal itself.
+	storedVal := newVal
+	if !isCalled {
+		storedVal = newVal - seqOpts.Increment
+	}
+
+	seqValueKey := keys.MakeSequenceKey(uint32(descriptor.ID))
+	// Like nextval(), setval() is not transactional.
+	if err := p.session.execCfg.DB.Put(ctx, seqValueKey, storedVal); err != nil {
+		return err
+	}
+
+	if isCalled {
+		ss := &p.session.sequenceState
+		ss.mu.Lock()
+		ss.setLatestValueLocked(descriptor.ID, newVal)
+		ss.mu.Unlock()
+	}
+	return nil
+}
+
+// getSequenceSource builds a planDataSource which reads the current state of
+// a sequence, in the same format as PostgreSQL's SELECT * FROM <sequence>.
+func (p *planner) getSequenceSource(
+	ctx context.Context, tn *parser.TableName, desc *sqlbase.TableDescriptor,
+) (planDataSource, error) {
+	if err := p.CheckPrivilege(ctx, desc, privilege.SELECT); err != nil {
+		return planDataSource{}, err
+	}
+
+	columns := sqlbase.ResultColumns{
+		{Name: "last_value", Typ: parser.TypeInt},
+		{Name: "log_cnt", Typ: parser.TypeInt},
+		{Name: "is_called", Typ: parser.TypeBool},
+	}
+
+	return planDataSource{
+		info: newSourceInfoForSingleTable(*tn, columns),
+		plan: &delayedNode{
+			name:    tn.String(),
+			columns: columns,
+			constructor: func(ctx context.Context, p *planner) (planNode, error) {
+				seqOpts := desc.SequenceOpts
+				val, err := p.txn.Get(ctx, keys.MakeSequenceKey(uint32(desc.ID)))
+				if err != nil {
+					return nil, err
+				}
+				lastValue := val.ValueInt()
+
+				// A sequence on which nextval() has never been called stores the
+				// value just before its start value.
+				isCalled := true
+				if lastValue == seqOpts.Start-seqOpts.Increment {
+					lastValue = seqOpts.Start
+					isCalled = false
+				}
+
+				v := p.newContainerValuesNode(columns, 1)
+				if _, err := v.rows.AddRow(ctx, parser.Datums{
+					parser.NewDInt(parser.DInt(lastValue)),
+					parser.NewDInt(0),
+					parser.MakeDBool(parser.DBool(isCalled)),
+				}); err != nil {
+					v.Close(ctx)
+					return nil, err
+				}
+				return v, nil
+			},
+		},
+	}, nil
+}
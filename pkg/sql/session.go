@@ -233,6 +233,9 @@ type Session struct {
 	// that have been prepared via pgwire.
 	PreparedStatements PreparedStatements
 	PreparedPortals    PreparedPortals
+	// sequenceState stores state related to calls to sequence builtins
+	// (nextval, currval, lastval and setval) in this session.
+	sequenceState sequenceState
 	// virtualSchemas aliases Executor.virtualSchemas.
 	// It is duplicated in Session to provide easier access to
 	// the various methods that need this reference.
@@ -623,6 +626,7 @@ func (s *Session) resetPlanner(p *planner, e *Executor, txn *client.Txn) {
 	// phaseTimes is an array, not a slice, so this performs a copy-by-value.
 	p.phaseTimes = s.phaseTimes
 	p.stmt = nil
+	p.memberOf = nil
 	p.cancelChecker = sqlbase.NewCancelChecker(s.Ctx())
 
 	p.semaCtx = parser.MakeSemaContext(s.User == security.RootUser)
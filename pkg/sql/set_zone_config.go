@@ -0,0 +1,185 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/cockroachdb/cockroach/pkg/config"
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+type setZoneConfigNode struct {
+	tableDesc     *sqlbase.TableDescriptor
+	indexID       sqlbase.IndexID
+	partitionName string
+	// If yamlConfig is nil, the zone config should be removed.
+	yamlConfig parser.TypedExpr
+}
+
+// SetZoneConfig sets the zone config of a partition of a table.
+// Privileges: super user.
+func (p *planner) SetZoneConfig(ctx context.Context, n *parser.SetZoneConfig) (planNode, error) {
+	if err := p.RequireSuperUser("CONFIGURE ZONE"); err != nil {
+		return nil, err
+	}
+
+	tn, err := n.Table.NormalizeWithDatabaseName(p.session.Database)
+	if err != nil {
+		return nil, err
+	}
+	tableDesc, err := MustGetTableDesc(ctx, p.txn, p.getVirtualTabler(), tn, false /*allowAdding*/)
+	if err != nil {
+		return nil, err
+	}
+	index, err := tableDesc.FindPartitionByName(string(n.Partition))
+	if err != nil {
+		return nil, err
+	}
+
+	var yamlConfig parser.TypedExpr
+	if n.YAMLConfig != parser.DNull {
+		var dummyHelper parser.IndexedVarHelper
+		yamlConfig, err = p.analyzeExpr(
+			ctx, n.YAMLConfig, nil, dummyHelper, parser.TypeString, false /*requireType*/, "configure zone")
+		if err != nil {
+			return nil, err
+		}
+		if typ := yamlConfig.ResolvedType(); typ != parser.TypeString && typ != parser.TypeNull {
+			return nil, fmt.Errorf("zone config must be of type string or null, not %s", typ)
+		}
+	}
+
+	return &setZoneConfigNode{
+		tableDesc:     tableDesc,
+		indexID:       index.ID,
+		partitionName: string(n.Partition),
+		yamlConfig:    yamlConfig,
+	}, nil
+}
+
+func (n *setZoneConfigNode) Start(params runParams) error {
+	var yamlConfig *string
+	if n.yamlConfig != nil {
+		datum, err := n.yamlConfig.Eval(&params.p.evalCtx)
+		if err != nil {
+			return err
+		}
+		if datum != parser.DNull {
+			s := string(parser.MustBeDString(datum))
+			yamlConfig = &s
+		}
+	}
+
+	zone, err := getTableZoneConfig(params.ctx, params.p.txn, n.tableDesc.ID)
+	if err != nil {
+		return err
+	}
+
+	indexID := uint32(n.indexID)
+	if yamlConfig == nil {
+		if !zone.DeleteSubzone(indexID, n.partitionName) {
+			return nil
+		}
+	} else {
+		// The new zone config is merged with the zone config currently
+		// applying to the partition.
+		var partitionZone config.ZoneConfig
+		if subzone := zone.GetSubzone(indexID, n.partitionName); subzone != nil {
+			partitionZone = subzone.Config
+		} else {
+			partitionZone, _, err = GetZoneConfigInTxn(params.ctx, params.p.txn, uint32(n.tableDesc.ID))
+			if err != nil {
+				return err
+			}
+			partitionZone.Subzones = nil
+			partitionZone.SubzoneSpans = nil
+		}
+		if err := yaml.Unmarshal([]byte(*yamlConfig), &partitionZone); err != nil {
+			return fmt.Errorf("could not parse zone config: %s", err)
+		}
+		if err := partitionZone.Validate(); err != nil {
+			return fmt.Errorf("could not validate zone config: %s", err)
+		}
+		zone.SetSubzone(config.Subzone{
+			IndexID:       indexID,
+			PartitionName: n.partitionName,
+			Config:        partitionZone,
+		})
+	}
+
+	return putTableSubzones(params.ctx, params.p.txn, n.tableDesc, &zone)
+}
+
+func (*setZoneConfigNode) Next(runParams) (bool, error) { return false, nil }
+func (*setZoneConfigNode) Values() parser.Datums        { return nil }
+func (*setZoneConfigNode) Close(context.Context)        {}
+
+// getTableZoneConfig reads the zone config stored for the table itself, if
+// any, which holds the subzones of the table. Unlike GetZoneConfigInTxn, it
+// doesn't fall back to the zone config of the table's database.
+func getTableZoneConfig(
+	ctx context.Context, txn *client.Txn, id sqlbase.ID,
+) (config.ZoneConfig, error) {
+	kv, err := txn.Get(ctx, sqlbase.MakeZoneKey(id))
+	if err != nil || kv.Value == nil {
+		return config.ZoneConfig{}, err
+	}
+	return config.MigrateZoneConfig(kv.Value)
+}
+
+// putTableSubzones regenerates the subzone spans of the table's zone config
+// and writes it. A placeholder zone config left without subzones is removed.
+func putTableSubzones(
+	ctx context.Context, txn *client.Txn, tableDesc *sqlbase.TableDescriptor, zone *config.ZoneConfig,
+) error {
+	var err error
+	if zone.SubzoneSpans, err = GenerateSubzoneSpans(tableDesc, zone.Subzones); err != nil {
+		return err
+	}
+	zoneKey := sqlbase.MakeZoneKey(tableDesc.ID)
+	if zone.NumReplicas == 0 && len(zone.Subzones) == 0 {
+		// The table only had a placeholder zone config to store its subzones.
+		return txn.Del(ctx, zoneKey)
+	}
+	return txn.Put(ctx, zoneKey, zone)
+}
+
+// removeIndexZoneConfigs removes the zone configs of the given index and its
+// partitions from the table's zone config.
+func removeIndexZoneConfigs(
+	ctx context.Context, txn *client.Txn, tableDesc *sqlbase.TableDescriptor, indexID sqlbase.IndexID,
+) error {
+	zone, err := getTableZoneConfig(ctx, txn, tableDesc.ID)
+	if err != nil {
+		return err
+	}
+	subzones := zone.Subzones[:0]
+	for _, s := range zone.Subzones {
+		if s.IndexID != uint32(indexID) {
+			subzones = append(subzones, s)
+		}
+	}
+	if len(subzones) == len(zone.Subzones) {
+		return nil
+	}
+	zone.Subzones = subzones
+	return putTableSubzones(ctx, txn, tableDesc, &zone)
+}
@@ -224,7 +224,7 @@ func (p *planner) showTableDetails(
 		if err != nil {
 			return err
 		}
-		return p.anyPrivilege(desc)
+		return p.anyPrivilege(ctx, desc)
 	}
 
 	return p.delegateQuery(ctx, showType,
@@ -278,6 +278,29 @@ func (p *planner) ShowCreateView(ctx context.Context, n *parser.ShowCreateView)
 	return p.showTableDetails(ctx, "SHOW CREATE VIEW", n.View, showCreateViewQuery)
 }
 
+// ShowCreateSequence returns a CREATE SEQUENCE statement for the specified
+// sequence.
+// Privileges: Any privilege on sequence.
+func (p *planner) ShowCreateSequence(
+	ctx context.Context, n *parser.ShowCreateSequence,
+) (planNode, error) {
+	// We make the check whether the name points to a sequence or not in
+	// SQL, so as to avoid a double lookup (a first one to check if the
+	// descriptor is of the right type, another to populate the
+	// create_statements vtable).
+	const showCreateSequenceQuery = `
+     SELECT %[3]s AS "Sequence",
+            IFNULL(create_statement,
+                   crdb_internal.force_error('` + pgerror.CodeUndefinedTableError + `',
+                                             %[1]s || '.' || %[2]s || ' is not a sequence')::string
+            ) AS "CreateSequence"
+       FROM (SELECT create_statement FROM %[4]s.crdb_internal.create_statements
+              WHERE database_name = %[1]s AND descriptor_name = %[2]s AND descriptor_type = 'sequence'
+              UNION ALL VALUES (NULL) ORDER BY 1 DESC) LIMIT 1
+  `
+	return p.showTableDetails(ctx, "SHOW CREATE SEQUENCE", n.Sequence, showCreateSequenceQuery)
+}
+
 // ShowTrace shows the current stored session trace.
 // Privileges: None.
 func (p *planner) ShowTrace(ctx context.Context, n *parser.ShowTrace) (planNode, error) {
@@ -533,7 +556,7 @@ func (p *planner) ShowConstraints(
 	if err != nil {
 		return nil, sqlbase.NewUndefinedRelationError(tn)
 	}
-	if err := p.anyPrivilege(desc); err != nil {
+	if err := p.anyPrivilege(ctx, desc); err != nil {
 		return nil, err
 	}
 
@@ -595,6 +618,72 @@ func (p *planner) ShowConstraints(
 	}, nil
 }
 
+// ShowTableStats returns the statistics collected for a table.
+// Privileges: Any privilege on table.
+func (p *planner) ShowTableStats(ctx context.Context, n *parser.ShowTableStats) (planNode, error) {
+	tn, err := n.Table.NormalizeWithDatabaseName(p.session.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	desc, err := MustGetTableDesc(ctx, p.txn, p.getVirtualTabler(), tn, false /*allowAdding*/)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.anyPrivilege(ctx, desc); err != nil {
+		return nil, err
+	}
+
+	columns := sqlbase.ResultColumns{
+		{Name: "Name", Typ: parser.TypeString},
+		{Name: "Columns", Typ: parser.TypeString},
+		{Name: "Created", Typ: parser.TypeTimestamp},
+		{Name: "Row Count", Typ: parser.TypeInt},
+		{Name: "Distinct Count", Typ: parser.TypeInt},
+		{Name: "Null Count", Typ: parser.TypeInt},
+	}
+
+	return &delayedNode{
+		name:    "SHOW STATISTICS FOR TABLE " + tn.String(),
+		columns: columns,
+		constructor: func(ctx context.Context, p *planner) (planNode, error) {
+			rows, err := p.queryRows(ctx,
+				`SELECT name, "columnIDs", "createdAt", "rowCount", "distinctCount", "nullCount"
+				FROM system.table_statistics
+				WHERE "tableID" = $1
+				ORDER BY "createdAt"`,
+				desc.ID,
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			v := p.newContainerValuesNode(columns, 0)
+			for _, r := range rows {
+				// Replace the column IDs with column names. Columns that have
+				// since been dropped are shown by ID.
+				colIDs := r[1].(*parser.DArray).Array
+				colNames := make([]string, len(colIDs))
+				for i, d := range colIDs {
+					id := sqlbase.ColumnID(*d.(*parser.DInt))
+					colNames[i] = fmt.Sprintf("[%d]", id)
+					if col, err := desc.FindColumnByID(id); err == nil {
+						colNames[i] = col.Name
+					}
+				}
+				newRow := parser.Datums{
+					r[0], parser.NewDString(strings.Join(colNames, ", ")), r[2], r[3], r[4], r[5],
+				}
+				if _, err := v.rows.AddRow(ctx, newRow); err != nil {
+					v.Close(ctx)
+					return nil, err
+				}
+			}
+			return v, nil
+		},
+	}, nil
+}
+
 func (p *planner) ShowQueries(ctx context.Context, n *parser.ShowQueries) (planNode, error) {
 	query := `TABLE crdb_internal.node_queries`
 	if n.Cluster {
@@ -659,5 +748,12 @@ func (p *planner) ShowTransactionStatus(ctx context.Context) (planNode, error) {
 // Privileges: SELECT on system.users.
 func (p *planner) ShowUsers(ctx context.Context, n *parser.ShowUsers) (planNode, error) {
 	return p.delegateQuery(ctx, "SHOW USERS",
-		`SELECT username FROM system.users ORDER BY 1`, nil, nil)
+		`SELECT username FROM system.users WHERE "isRole" = false ORDER BY 1`, nil, nil)
+}
+
+// ShowRoles returns all the roles.
+// Privileges: SELECT on system.users.
+func (p *planner) ShowRoles(ctx context.Context, n *parser.ShowRoles) (planNode, error) {
+	return p.delegateQuery(ctx, "SHOW ROLES",
+		`SELECT username AS role FROM system.users WHERE "isRole" = true ORDER BY 1`, nil, nil)
 }
@@ -43,6 +43,20 @@ func (p *planner) showCreateView(
 	return buf.String(), nil
 }
 
+// showCreateSequence returns a valid SQL representation of the
+// CREATE SEQUENCE statement used to create the given sequence.
+func (p *planner) showCreateSequence(
+	ctx context.Context, tn parser.Name, desc *sqlbase.TableDescriptor,
+) (string, error) {
+	var buf bytes.Buffer
+	buf.WriteString("CREATE SEQUENCE ")
+	tn.Format(&buf, parser.FmtSimple)
+	opts := desc.SequenceOpts
+	fmt.Fprintf(&buf, " MINVALUE %d MAXVALUE %d INCREMENT %d START %d",
+		opts.MinValue, opts.MaxValue, opts.Increment, opts.Start)
+	return buf.String(), nil
+}
+
 // showCreateTable returns a valid SQL representation of the CREATE
 // TABLE statement used to create the given table.
 //
@@ -97,6 +111,10 @@ func (p *planner) showCreateTable(
 				&fkTableName,
 				quoteNames(fkIdx.ColumnNames...),
 			)
+			parser.FormatNode(&buf, parser.FmtSimple, parser.ReferenceActions{
+				Delete: fk.OnDelete.ReferenceAction(),
+				Update: fk.OnUpdate.ReferenceAction(),
+			})
 		}
 		if idx.ID != desc.PrimaryIndex.ID {
 			// Showing the primary index is handled above.
@@ -105,6 +123,9 @@ func (p *planner) showCreateTable(
 			if err := p.showCreateInterleave(ctx, &idx, &buf, dbPrefix); err != nil {
 				return "", err
 			}
+			if err := showCreatePartitioning(desc, &idx, &buf); err != nil {
+				return "", err
+			}
 		}
 	}
 
@@ -134,6 +155,9 @@ func (p *planner) showCreateTable(
 	if err := p.showCreateInterleave(ctx, &desc.PrimaryIndex, &buf, dbPrefix); err != nil {
 		return "", err
 	}
+	if err := showCreatePartitioning(desc, &desc.PrimaryIndex, &buf); err != nil {
+		return "", err
+	}
 
 	return buf.String(), nil
 }
@@ -307,7 +307,7 @@ func (n *testingRelocateNode) Next(params runParams) (bool, error) {
 	}
 	n.lastRangeStartKey = rangeDesc.StartKey.AsRawKey()
 
-	if err := storage.TestingRelocateRange(params.ctx, params.p.ExecCfg().DB, rangeDesc, targets); err != nil {
+	if err := storage.RelocateRange(params.ctx, params.p.ExecCfg().DB, rangeDesc, targets); err != nil {
 		return false, err
 	}
 
@@ -0,0 +1,378 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sqlbase
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+)
+
+// maxCascadeDepth is the maximum number of foreign key references through
+// which a single delete or update may cascade. Cascades through cyclic
+// references normally stop once no more rows reference the changed values;
+// the limit protects against those that never do.
+const maxCascadeDepth = 32
+
+// cascadeRef is an inbound foreign key reference whose ON DELETE or ON
+// UPDATE action modifies the referencing rows instead of rejecting the change
+// to the referenced row.
+type cascadeRef struct {
+	action ForeignKeyReference_Action
+	// table and index are the referencing table and its index which holds the
+	// foreign key.
+	table *TableDescriptor
+	index *IndexDescriptor
+	// prefixLen is the number of columns of index used by the foreign key.
+	prefixLen int
+	// ids maps the IDs of the foreign key columns of index to the positions
+	// of the referenced values in the rows of the referenced table.
+	ids map[ColumnID]int
+}
+
+func makeCascadeRef(
+	otherTables TableLookupsByID,
+	refIdx IndexDescriptor,
+	ref ForeignKeyReference,
+	action ForeignKeyReference_Action,
+	colMap map[ColumnID]int,
+) (cascadeRef, error) {
+	c := cascadeRef{action: action, table: otherTables[ref.Table].Table}
+	if c.table == nil {
+		return c, errors.Errorf("referencing table %d not in provided table map %+v", ref.Table, otherTables)
+	}
+	index, err := c.table.FindIndexByID(ref.Index)
+	if err != nil {
+		return c, err
+	}
+	c.index = index
+	c.prefixLen = len(refIdx.ColumnIDs)
+	if len(index.ColumnIDs) < c.prefixLen {
+		c.prefixLen = len(index.ColumnIDs)
+	}
+
+	c.ids = make(map[ColumnID]int, c.prefixLen)
+	nulls := true
+	for i, refColID := range refIdx.ColumnIDs[:c.prefixLen] {
+		if found, ok := colMap[refColID]; ok {
+			c.ids[index.ColumnIDs[i]] = found
+			nulls = false
+		} else if !nulls {
+			return c, errors.Errorf("missing value for column %q in multi-part foreign key", refIdx.ColumnNames[i])
+		}
+	}
+	if nulls {
+		return c, errSkipUnusedFK
+	}
+	return c, nil
+}
+
+// pendingCascade is a change to a referenced row whose effect on the rows
+// referencing it has not been applied yet.
+type pendingCascade struct {
+	ref   *cascadeRef
+	depth int
+	// oldValues are the values of the referenced row before the change, and
+	// newValues the values after it, or nil if the row was deleted.
+	oldValues parser.Datums
+	newValues parser.Datums
+}
+
+// cascader performs the ON DELETE and ON UPDATE actions of foreign keys.
+//
+// Row writers queue the changes of referenced rows as they write them. Once
+// the statement's own writes have been run, the queued changes are applied to
+// the referencing rows one at a time, within the same transaction, by row
+// writers which share the cascader and queue any further cascades. Applying
+// the actions after the statement's writes, as PostgreSQL does, ensures the
+// referencing rows are read in their latest state.
+type cascader struct {
+	txn    *client.Txn
+	tables TableLookupsByID
+	alloc  *DatumAlloc
+
+	pending []pendingCascade
+	// depth is the depth of the cascade being applied, or 0 while the
+	// statement's own writes are being queued.
+	depth int
+
+	deleters map[ID]*RowDeleter
+	updaters map[cascadeUpdaterKey]*RowUpdater
+}
+
+type cascadeUpdaterKey struct {
+	table ID
+	index IndexID
+}
+
+func makeCascader(txn *client.Txn, tables TableLookupsByID, alloc *DatumAlloc) *cascader {
+	return &cascader{
+		txn:      txn,
+		tables:   tables,
+		alloc:    alloc,
+		deleters: make(map[ID]*RowDeleter),
+		updaters: make(map[cascadeUpdaterKey]*RowUpdater),
+	}
+}
+
+// queue records that the referenced row with the given values was deleted
+// (if newValues is nil) or updated, so that the action of ref is applied to
+// the rows referencing it.
+func (c *cascader) queue(ref *cascadeRef, oldValues, newValues parser.Datums) error {
+	for _, colID := range ref.index.ColumnIDs[:ref.prefixLen] {
+		if oldValues[ref.ids[colID]] == parser.DNull {
+			// A NULL value is never referenced.
+			return nil
+		}
+	}
+	depth := c.depth + 1
+	if depth > maxCascadeDepth {
+		return pgerror.NewErrorf(pgerror.CodeProgramLimitExceededError,
+			"foreign key cascade on table %q exceeds the maximum depth of %d",
+			ref.table.Name, maxCascadeDepth)
+	}
+	p := pendingCascade{
+		ref:       ref,
+		depth:     depth,
+		oldValues: append(parser.Datums(nil), oldValues...),
+	}
+	if newValues != nil {
+		p.newValues = append(parser.Datums(nil), newValues...)
+	}
+	c.pending = append(c.pending, p)
+	return nil
+}
+
+// run applies all queued cascades, including those queued while doing so.
+func (c *cascader) run(ctx context.Context, evalCtx *parser.EvalContext, traceKV bool) error {
+	defer func() { c.depth = 0 }()
+	for len(c.pending) > 0 {
+		p := c.pending[0]
+		c.pending = c.pending[1:]
+		c.depth = p.depth
+		if err := c.apply(ctx, evalCtx, p, traceKV); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply applies the action of a single queued cascade to the rows referencing
+// the changed row.
+func (c *cascader) apply(
+	ctx context.Context, evalCtx *parser.EvalContext, p pendingCascade, traceKV bool,
+) error {
+	ref := p.ref
+	b := c.txn.NewBatch()
+	if p.newValues == nil && ref.action == ForeignKeyReference_CASCADE {
+		rd, err := c.rowDeleter(ref.table)
+		if err != nil {
+			return err
+		}
+		rows, err := c.fetchReferencingRows(ctx, ref, p.oldValues, rd.FetchCols, rd.FetchColIDtoRowIndex, traceKV)
+		if err != nil || len(rows) == 0 {
+			return err
+		}
+		for _, row := range rows {
+			if err := rd.DeleteRow(ctx, b, row, traceKV); err != nil {
+				return err
+			}
+		}
+	} else {
+		ru, err := c.rowUpdater(ref)
+		if err != nil {
+			return err
+		}
+		rows, err := c.fetchReferencingRows(ctx, ref, p.oldValues, ru.FetchCols, ru.FetchColIDtoRowIndex, traceKV)
+		if err != nil || len(rows) == 0 {
+			return err
+		}
+		var defaultExprs []parser.TypedExpr
+		if ref.action == ForeignKeyReference_SET_DEFAULT {
+			if defaultExprs, err = MakeDefaultExprs(ru.UpdateCols, &parser.Parser{}, evalCtx); err != nil {
+				return err
+			}
+		}
+		updateValues := make(parser.Datums, len(ru.UpdateCols))
+		for _, row := range rows {
+			for i, col := range ru.UpdateCols {
+				switch ref.action {
+				case ForeignKeyReference_CASCADE:
+					updateValues[i] = p.newValues[ref.ids[col.ID]]
+				case ForeignKeyReference_SET_DEFAULT:
+					if defaultExprs == nil {
+						updateValues[i] = parser.DNull
+						continue
+					}
+					if updateValues[i], err = defaultExprs[i].Eval(evalCtx); err != nil {
+						return err
+					}
+				default:
+					updateValues[i] = parser.DNull
+				}
+			}
+			if _, err := ru.UpdateRow(ctx, b, row, updateValues, traceKV); err != nil {
+				return err
+			}
+		}
+	}
+	if err := c.txn.Run(ctx, b); err != nil {
+		return ConvertBatchError(ctx, ref.table, b)
+	}
+	return nil
+}
+
+// rowDeleter returns the RowDeleter used to delete referencing rows from the
+// given table.
+func (c *cascader) rowDeleter(table *TableDescriptor) (*RowDeleter, error) {
+	if rd, ok := c.deleters[table.ID]; ok {
+		return rd, nil
+	}
+	rd, err := makeRowDeleter(c.txn, table, c.tables, nil /* requestedCols */, CheckFKs, c.alloc, c)
+	if err != nil {
+		return nil, err
+	}
+	c.deleters[table.ID] = &rd
+	return &rd, nil
+}
+
+// rowUpdater returns the RowUpdater used to update the foreign key columns of
+// the rows referencing through ref.
+func (c *cascader) rowUpdater(ref *cascadeRef) (*RowUpdater, error) {
+	key := cascadeUpdaterKey{table: ref.table.ID, index: ref.index.ID}
+	if ru, ok := c.updaters[key]; ok {
+		return ru, nil
+	}
+	updateCols := make([]ColumnDescriptor, ref.prefixLen)
+	for i, colID := range ref.index.ColumnIDs[:ref.prefixLen] {
+		col, err := ref.table.FindColumnByID(colID)
+		if err != nil {
+			return nil, err
+		}
+		updateCols[i] = *col
+	}
+	ru, err := makeRowUpdater(c.txn, ref.table, c.tables, updateCols, nil, /* requestedCols */
+		RowUpdaterDefault, c.alloc, c)
+	if err != nil {
+		return nil, err
+	}
+	c.updaters[key] = &ru
+	return &ru, nil
+}
+
+// fetchReferencingRows returns the current values of the given columns of the
+// rows which reference the given values of a referenced row through ref.
+func (c *cascader) fetchReferencingRows(
+	ctx context.Context,
+	ref *cascadeRef,
+	values parser.Datums,
+	cols []ColumnDescriptor,
+	colIDtoRowIndex map[ColumnID]int,
+	traceKV bool,
+) ([]parser.Datums, error) {
+	table, index := ref.table, ref.index
+	key, _, err := EncodePartialIndexKey(
+		table, index, ref.prefixLen, ref.ids, values, MakeIndexKeyPrefix(table, index.ID))
+	if err != nil {
+		return nil, err
+	}
+	spans := roachpb.Spans{{Key: key, EndKey: roachpb.Key(key).PrefixEnd()}}
+
+	if index.ID != table.PrimaryIndex.ID {
+		// The foreign key is on a secondary index, which only provides the
+		// primary keys of the referencing rows.
+		if spans, err = c.primaryKeySpans(ctx, table, index, spans, cols, colIDtoRowIndex, traceKV); err != nil {
+			return nil, err
+		}
+		if len(spans) == 0 {
+			return nil, nil
+		}
+	}
+
+	valNeededForCol := make([]bool, len(cols))
+	for i := range valNeededForCol {
+		valNeededForCol[i] = true
+	}
+	var rf RowFetcher
+	if err := rf.Init(
+		table, colIDtoRowIndex, &table.PrimaryIndex, false /* reverse */, false, /* isSecondaryIndex */
+		cols, valNeededForCol, false /* returnRangeInfo */, c.alloc,
+	); err != nil {
+		return nil, err
+	}
+	if err := rf.StartScan(ctx, c.txn, spans, false /* limitBatches */, 0, traceKV); err != nil {
+		return nil, err
+	}
+	var rows []parser.Datums
+	for {
+		row, err := rf.NextRowDecoded(ctx, traceKV)
+		if err != nil {
+			return nil, err
+		}
+		if row == nil {
+			return rows, nil
+		}
+		// The rows returned by RowFetcher are invalidated after the call to
+		// NextRow, so we have to copy them to save them.
+		rows = append(rows, append(parser.Datums(nil), row...))
+	}
+}
+
+// primaryKeySpans scans the given spans of a secondary index and returns the
+// spans of the primary index rows they point to.
+func (c *cascader) primaryKeySpans(
+	ctx context.Context,
+	table *TableDescriptor,
+	index *IndexDescriptor,
+	indexSpans roachpb.Spans,
+	cols []ColumnDescriptor,
+	colIDtoRowIndex map[ColumnID]int,
+	traceKV bool,
+) (roachpb.Spans, error) {
+	valNeededForCol := make([]bool, len(cols))
+	for _, colID := range table.PrimaryIndex.ColumnIDs {
+		valNeededForCol[colIDtoRowIndex[colID]] = true
+	}
+	var rf RowFetcher
+	if err := rf.Init(
+		table, colIDtoRowIndex, index, false /* reverse */, true, /* isSecondaryIndex */
+		cols, valNeededForCol, false /* returnRangeInfo */, c.alloc,
+	); err != nil {
+		return nil, err
+	}
+	if err := rf.StartScan(ctx, c.txn, indexSpans, false /* limitBatches */, 0, traceKV); err != nil {
+		return nil, err
+	}
+	pkPrefix := MakeIndexKeyPrefix(table, table.PrimaryIndex.ID)
+	var spans roachpb.Spans
+	for {
+		row, err := rf.NextRowDecoded(ctx, traceKV)
+		if err != nil {
+			return nil, err
+		}
+		if row == nil {
+			return spans, nil
+		}
+		key, _, err := EncodeIndexKey(table, &table.PrimaryIndex, colIDtoRowIndex, row, pkPrefix)
+		if err != nil {
+			return nil, err
+		}
+		spans = append(spans, roachpb.Span{Key: key, EndKey: roachpb.Key(key).PrefixEnd()})
+	}
+}
@@ -0,0 +1,314 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sqlbase
+
+import (
+	"bytes"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+)
+
+// CannotWriteToComputedColError constructs a write error for a computed column.
+func CannotWriteToComputedColError(col ColumnDescriptor) error {
+	return pgerror.NewErrorf(pgerror.CodeObjectNotInPrerequisiteStateError,
+		"cannot write directly to computed column %q", col.Name)
+}
+
+// RowIndexedVarContainer is used to evaluate computed column expressions over
+// rows. The IndexedVars of the expressions refer to Cols, which are not
+// necessarily laid out like the row being evaluated, so Mapping maps the IDs
+// of the columns to their position in CurSourceRow.
+type RowIndexedVarContainer struct {
+	CurSourceRow parser.Datums
+	Cols         []ColumnDescriptor
+	Mapping      map[ColumnID]int
+}
+
+var _ parser.IndexedVarContainer = &RowIndexedVarContainer{}
+
+// IndexedVarEval implements the parser.IndexedVarContainer interface.
+func (r *RowIndexedVarContainer) IndexedVarEval(
+	idx int, ctx *parser.EvalContext,
+) (parser.Datum, error) {
+	rowIdx, ok := r.Mapping[r.Cols[idx].ID]
+	if !ok {
+		return parser.DNull, nil
+	}
+	return r.CurSourceRow[rowIdx], nil
+}
+
+// IndexedVarResolvedType implements the parser.IndexedVarContainer interface.
+func (r *RowIndexedVarContainer) IndexedVarResolvedType(idx int) parser.Type {
+	return r.Cols[idx].Type.ToDatumType()
+}
+
+// IndexedVarFormat implements the parser.IndexedVarContainer interface.
+func (r *RowIndexedVarContainer) IndexedVarFormat(buf *bytes.Buffer, f parser.FmtFlags, idx int) {
+	parser.FormatNode(buf, f, parser.Name(r.Cols[idx].Name))
+}
+
+// computedColumnResolver replaces the column references in a computed column
+// expression with IndexedVars bound to a RowIndexedVarContainer.
+type computedColumnResolver struct {
+	iv         *RowIndexedVarContainer
+	ivarHelper *parser.IndexedVarHelper
+	err        error
+}
+
+var _ parser.Visitor = &computedColumnResolver{}
+
+// VisitPre implements the parser.Visitor interface.
+func (v *computedColumnResolver) VisitPre(expr parser.Expr) (recurse bool, newExpr parser.Expr) {
+	if v.err != nil {
+		return false, expr
+	}
+	switch t := expr.(type) {
+	case parser.UnresolvedName:
+		vn, err := t.NormalizeVarName()
+		if err != nil {
+			v.err = err
+			return false, expr
+		}
+		return v.VisitPre(vn)
+
+	case *parser.ColumnItem:
+		if len(t.Selector) > 0 {
+			v.err = pgerror.UnimplementedWithIssueErrorf(8318, "compound types not supported yet: %q", t)
+			return false, expr
+		}
+		for i := range v.iv.Cols {
+			if v.iv.Cols[i].Name == string(t.ColumnName) {
+				return false, v.ivarHelper.IndexedVar(i)
+			}
+		}
+		v.err = pgerror.NewErrorf(pgerror.CodeUndefinedColumnError,
+			"column %q does not exist", parser.ErrString(t))
+		return false, expr
+
+	case parser.VarName:
+		v.err = pgerror.NewErrorf(pgerror.CodeInvalidColumnReferenceError,
+			"%q is not allowed in computed column expressions", parser.ErrString(t))
+		return false, expr
+
+	case *parser.Subquery:
+		v.err = pgerror.NewErrorf(pgerror.CodeInvalidTableDefinitionError,
+			"subqueries are not allowed in computed column expressions")
+		return false, expr
+	}
+	return true, expr
+}
+
+// VisitPost implements the parser.Visitor interface.
+func (*computedColumnResolver) VisitPost(expr parser.Expr) parser.Expr { return expr }
+
+// typeCheckComputedExpr resolves the column references of the expression of
+// the computed column col against the columns of iv and type checks it.
+func typeCheckComputedExpr(
+	expr parser.Expr,
+	col *ColumnDescriptor,
+	iv *RowIndexedVarContainer,
+	ivarHelper *parser.IndexedVarHelper,
+	searchPath parser.SearchPath,
+) (parser.TypedExpr, error) {
+	resolver := computedColumnResolver{iv: iv, ivarHelper: ivarHelper}
+	expr, _ = parser.WalkExpr(&resolver, expr)
+	if resolver.err != nil {
+		return nil, resolver.err
+	}
+	colType := col.Type.ToDatumType()
+	ctx := parser.SemaContext{SearchPath: searchPath}
+	typedExpr, err := parser.TypeCheck(expr, &ctx, colType)
+	if err != nil {
+		return nil, err
+	}
+	if typ := typedExpr.ResolvedType(); !colType.Equivalent(typ) && typedExpr != parser.DNull {
+		return nil, incompatibleExprTypeError("computed column", colType, typ)
+	}
+	return typedExpr, nil
+}
+
+// ValidateComputedColumn checks that the expression of the computed column col
+// is a valid expression over the public columns of tableDesc: it must have
+// the type of col, must not refer to other computed columns and must not
+// contain aggregate, window or impure functions.
+func ValidateComputedColumn(
+	tableDesc *TableDescriptor, col *ColumnDescriptor, searchPath parser.SearchPath,
+) error {
+	expr, err := parser.ParseExpr(*col.ComputeExpr)
+	if err != nil {
+		return err
+	}
+	var p parser.Parser
+	if err := p.AssertNoAggregationOrWindowing(
+		expr, "computed column expressions", searchPath,
+	); err != nil {
+		return err
+	}
+
+	iv := &RowIndexedVarContainer{Cols: tableDesc.Columns}
+	ivarHelper := parser.MakeIndexedVarHelper(iv, len(iv.Cols))
+	typedExpr, err := typeCheckComputedExpr(expr, col, iv, &ivarHelper, searchPath)
+	if err != nil {
+		return err
+	}
+
+	for i := range iv.Cols {
+		if ivarHelper.IndexedVarUsed(i) && iv.Cols[i].IsComputed() {
+			return pgerror.NewErrorf(pgerror.CodeInvalidTableDefinitionError,
+				"computed column %q cannot reference computed column %q", col.Name, iv.Cols[i].Name)
+		}
+	}
+
+	var impure bool
+	parser.WalkExprConst(impureFuncVisitor{impure: &impure}, typedExpr)
+	if impure {
+		return pgerror.NewErrorf(pgerror.CodeInvalidTableDefinitionError,
+			"impure functions are not allowed in computed column expressions")
+	}
+	return nil
+}
+
+// impureFuncVisitor records whether an expression contains an impure
+// function application.
+type impureFuncVisitor struct {
+	impure *bool
+}
+
+// VisitPre implements the parser.Visitor interface.
+func (v impureFuncVisitor) VisitPre(expr parser.Expr) (recurse bool, newExpr parser.Expr) {
+	if f, ok := expr.(*parser.FuncExpr); ok && f.IsImpure() {
+		*v.impure = true
+		return false, expr
+	}
+	return !*v.impure, expr
+}
+
+// VisitPost implements the parser.Visitor interface.
+func (impureFuncVisitor) VisitPost(expr parser.Expr) parser.Expr { return expr }
+
+// ComputedColumnReferences returns the names of the columns referenced by the
+// expression of the computed column col.
+func ComputedColumnReferences(col *ColumnDescriptor) ([]string, error) {
+	return ColumnReferences(*col.ComputeExpr)
+}
+
+// ColumnReferences returns the names of the columns referenced by the
+// serialized expression exprStr.
+func ColumnReferences(exprStr string) ([]string, error) {
+	expr, err := parser.ParseExpr(exprStr)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	_, err = parser.SimpleVisit(expr, func(expr parser.Expr) (error, bool, parser.Expr) {
+		if vBase, ok := expr.(parser.VarName); ok {
+			v, err := vBase.NormalizeVarName()
+			if err != nil {
+				return err, false, nil
+			}
+			if c, ok := v.(*parser.ColumnItem); ok {
+				names = append(names, string(c.ColumnName))
+			}
+			return nil, false, expr
+		}
+		return nil, true, expr
+	})
+	return names, err
+}
+
+// MakeComputedExprs returns a slice of the computed expressions for the slice
+// of input column descriptors, or nil if none of the input column descriptors
+// are computed. The column references of the expressions are bound to iv, in
+// which the caller loads the rows over which the expressions are evaluated.
+func MakeComputedExprs(
+	cols []ColumnDescriptor,
+	iv *RowIndexedVarContainer,
+	parse *parser.Parser,
+	evalCtx *parser.EvalContext,
+) ([]parser.TypedExpr, error) {
+	// Check to see if any of the columns are computed. If there are none, we
+	// don't bother with constructing the expressions.
+	haveComputed := false
+	for _, col := range cols {
+		if col.IsComputed() {
+			haveComputed = true
+			break
+		}
+	}
+	if !haveComputed {
+		return nil, nil
+	}
+
+	computedExprs := make([]parser.TypedExpr, 0, len(cols))
+	exprStrings := make([]string, 0, len(cols))
+	for _, col := range cols {
+		if col.IsComputed() {
+			exprStrings = append(exprStrings, *col.ComputeExpr)
+		}
+	}
+	exprs, err := parser.ParseExprs(exprStrings)
+	if err != nil {
+		return nil, err
+	}
+
+	ivarHelper := parser.MakeIndexedVarHelper(iv, len(iv.Cols))
+	compExprIdx := 0
+	for i := range cols {
+		if !cols[i].IsComputed() {
+			computedExprs = append(computedExprs, parser.DNull)
+			continue
+		}
+		typedExpr, err := typeCheckComputedExpr(
+			exprs[compExprIdx], &cols[i], iv, &ivarHelper, evalCtx.SearchPath,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if typedExpr, err = parse.NormalizeExpr(evalCtx, typedExpr); err != nil {
+			return nil, err
+		}
+		computedExprs = append(computedExprs, typedExpr)
+		compExprIdx++
+	}
+	return computedExprs, nil
+}
+
+// ProcessComputedColumns appends the computed columns of tableDesc to a copy
+// of cols, which must not contain any of them, and returns it along with the
+// computed columns. Columns being added by a mutation that is
+// DELETE_AND_WRITE_ONLY are included, as their values must be written.
+func ProcessComputedColumns(
+	cols []ColumnDescriptor, tableDesc *TableDescriptor,
+) ([]ColumnDescriptor, []ColumnDescriptor) {
+	var computedCols []ColumnDescriptor
+	for _, col := range tableDesc.Columns {
+		if col.IsComputed() {
+			computedCols = append(computedCols, col)
+		}
+	}
+	for _, m := range tableDesc.Mutations {
+		if col := m.GetColumn(); col != nil && col.IsComputed() &&
+			m.State == DescriptorMutation_DELETE_AND_WRITE_ONLY {
+			computedCols = append(computedCols, *col)
+		}
+	}
+	if len(computedCols) == 0 {
+		return cols, nil
+	}
+	allCols := make([]ColumnDescriptor, 0, len(cols)+len(computedCols))
+	allCols = append(allCols, cols...)
+	return append(allCols, computedCols...), computedCols
+}
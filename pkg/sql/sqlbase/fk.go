@@ -51,54 +51,112 @@ const (
 	CheckUpdates
 )
 
-// TablesNeededForFKs calculates the IDs of the additional TableDescriptors that
-// will be needed for FK checking delete and/or insert operations on `table`.
+// TableLookupFunction is the function type used by TablesNeededForFKs to look
+// up the descriptors of the tables it finds.
+type TableLookupFunction func(context.Context, ID) (TableLookup, error)
+
+// TablesNeededForFKs looks up the additional TableDescriptors that will be
+// needed for FK checking delete and/or insert operations on `table`.
 //
-// NB: the returned map's values are *not* set -- higher level calling code, eg
-// in planner, should fill the map's values by acquiring leases. This function
-// is essentially just returning a slice of IDs, but the empty map can be filled
-// in place and reused, avoiding a second allocation.
-func TablesNeededForFKs(table TableDescriptor, usage FKCheck) TableLookupsByID {
+// When rows of `table` are deleted or updated, the tables referencing it
+// through foreign keys with cascading actions are modified as well, so the
+// tables needed for those modifications are looked up too, recursively.
+func TablesNeededForFKs(
+	ctx context.Context, table TableDescriptor, usage FKCheck, lookup TableLookupFunction,
+) (TableLookupsByID, error) {
+	type tableUsage struct {
+		id    ID
+		usage FKCheck
+	}
 	var ret TableLookupsByID
-	for _, idx := range table.AllNonDropIndexes() {
-		if usage != CheckDeletes && idx.ForeignKey.IsSet() {
-			if ret == nil {
-				ret = make(TableLookupsByID)
-			}
-			ret[idx.ForeignKey.Table] = TableLookup{}
+	visited := make(map[tableUsage]struct{})
+	add := func(id ID) (TableLookup, error) {
+		if ret == nil {
+			ret = make(TableLookupsByID)
+		}
+		if t, ok := ret[id]; ok {
+			return t, nil
 		}
-		if usage != CheckInserts {
-			for _, idx := range table.AllNonDropIndexes() {
-				for _, ref := range idx.ReferencedBy {
-					if ret == nil {
-						ret = make(TableLookupsByID)
-					}
-					ret[ref.Table] = TableLookup{}
+		t, err := lookup(ctx, id)
+		if err != nil {
+			return t, err
+		}
+		ret[id] = t
+		return t, nil
+	}
+
+	var walk func(table *TableDescriptor, usage FKCheck) error
+	walk = func(table *TableDescriptor, usage FKCheck) error {
+		key := tableUsage{id: table.ID, usage: usage}
+		if _, ok := visited[key]; ok {
+			return nil
+		}
+		visited[key] = struct{}{}
+		for _, idx := range table.AllNonDropIndexes() {
+			if usage != CheckDeletes && idx.ForeignKey.IsSet() {
+				if _, err := add(idx.ForeignKey.Table); err != nil {
+					return err
+				}
+			}
+			if usage == CheckInserts {
+				continue
+			}
+			for _, ref := range idx.ReferencedBy {
+				other, err := add(ref.Table)
+				if err != nil {
+					return err
+				}
+				if other.IsAdding || other.Table == nil {
+					continue
+				}
+				otherIdx, err := other.Table.FindIndexByID(ref.Index)
+				if err != nil {
+					return err
+				}
+				action := otherIdx.ForeignKey.OnUpdate
+				if usage == CheckDeletes {
+					action = otherIdx.ForeignKey.OnDelete
+				}
+				if !action.IsCascading() {
+					continue
+				}
+				// Deleting the referenced row deletes the referencing rows for
+				// ON DELETE CASCADE; all other cascading actions update them.
+				otherUsage := CheckUpdates
+				if usage == CheckDeletes && action == ForeignKeyReference_CASCADE {
+					otherUsage = CheckDeletes
+				}
+				if err := walk(other.Table, otherUsage); err != nil {
+					return err
 				}
 			}
 		}
+		return nil
 	}
-	return ret
+	if err := walk(&table, usage); err != nil {
+		return nil, err
+	}
+	return ret, nil
 }
 
-// spanKVFetcher is an kvFetcher that returns a set slice of kvs.
-type spanKVFetcher struct {
-	kvs []roachpb.KeyValue
+// SpanKVFetcher is an kvFetcher that returns a set slice of kvs.
+type SpanKVFetcher struct {
+	KVs []roachpb.KeyValue
 }
 
 // nextKV implements the kvFetcher interface.
-func (f *spanKVFetcher) nextKV(ctx context.Context) (bool, roachpb.KeyValue, error) {
-	if len(f.kvs) == 0 {
+func (f *SpanKVFetcher) nextKV(ctx context.Context) (bool, roachpb.KeyValue, error) {
+	if len(f.KVs) == 0 {
 		return false, roachpb.KeyValue{}, nil
 	}
-	kv := f.kvs[0]
-	f.kvs = f.kvs[1:]
+	kv := f.KVs[0]
+	f.KVs = f.KVs[1:]
 	return true, kv, nil
 }
 
 // getRangesInfo implements the kvFetcher interface.
-func (f *spanKVFetcher) getRangesInfo() []roachpb.RangeInfo {
-	panic("getRangesInfo() called on spanKVFetcher")
+func (f *SpanKVFetcher) getRangesInfo() []roachpb.RangeInfo {
+	panic("getRangesInfo() called on SpanKVFetcher")
 }
 
 // fkBatchChecker accumulates foreign key checks and sends them out as a single
@@ -150,11 +208,11 @@ func (f *fkBatchChecker) runCheck(
 		return err.GoError()
 	}
 
-	fetcher := spanKVFetcher{}
+	fetcher := SpanKVFetcher{}
 	for i, resp := range br.Responses {
 		fk := f.batchIdxToFk[i]
 
-		fetcher.kvs = resp.GetInner().(*roachpb.ScanResponse).Rows
+		fetcher.KVs = resp.GetInner().(*roachpb.ScanResponse).Rows
 		if err := fk.rf.StartScanFrom(ctx, &fetcher); err != nil {
 			return err
 		}
@@ -276,6 +334,11 @@ func (h fkInsertHelper) CollectSpans() roachpb.Spans {
 	return collectSpansWithFKMap(h.fks)
 }
 
+// CollectCascadeSpans implements the FkSpanCollector interface.
+func (h fkInsertHelper) CollectCascadeSpans() roachpb.Spans {
+	return nil
+}
+
 // CollectSpansForValues implements the FkSpanCollector interface.
 func (h fkInsertHelper) CollectSpansForValues(values parser.Datums) (roachpb.Spans, error) {
 	return collectSpansForValuesWithFKMap(h.fks, values)
@@ -284,15 +347,27 @@ func (h fkInsertHelper) CollectSpansForValues(values parser.Datums) (roachpb.Spa
 type fkDeleteHelper struct {
 	fks map[IndexID][]baseFKHelper
 
+	// cascades maps index id to the inbound foreign keys of each index whose
+	// ON DELETE (or, for updates, ON UPDATE) action modifies the referencing
+	// rows. These are queued on cascader instead of being checked.
+	cascades map[IndexID][]cascadeRef
+	cascader *cascader
+
 	checker *fkBatchChecker
 }
 
+// makeFKDeleteHelper creates the helper checking the inbound foreign keys of
+// table, and queuing their cascading actions, when rows are deleted (if usage
+// is CheckDeletes) or updated (if usage is CheckUpdates). The cascades are
+// queued on c if it is non-nil, or on a new cascader otherwise.
 func makeFKDeleteHelper(
 	txn *client.Txn,
 	table TableDescriptor,
 	otherTables TableLookupsByID,
 	colMap map[ColumnID]int,
 	alloc *DatumAlloc,
+	usage FKCheck,
+	c *cascader,
 ) (fkDeleteHelper, error) {
 	h := fkDeleteHelper{
 		checker: &fkBatchChecker{
@@ -306,6 +381,28 @@ func makeFKDeleteHelper(
 				// and thus does not need to be checked for FK violations.
 				continue
 			}
+			action, err := referenceAction(otherTables, ref, usage)
+			if err != nil {
+				return h, err
+			}
+			if action.IsCascading() {
+				cascade, err := makeCascadeRef(otherTables, idx, ref, action, colMap)
+				if err == errSkipUnusedFK {
+					continue
+				}
+				if err != nil {
+					return h, err
+				}
+				if c == nil {
+					c = makeCascader(txn, otherTables, alloc)
+				}
+				h.cascader = c
+				if h.cascades == nil {
+					h.cascades = make(map[IndexID][]cascadeRef)
+				}
+				h.cascades[idx.ID] = append(h.cascades[idx.ID], cascade)
+				continue
+			}
 			fk, err := makeBaseFKHelper(txn, otherTables, idx, ref, colMap, alloc, CheckDeletes)
 			if err == errSkipUnusedFK {
 				continue
@@ -322,8 +419,28 @@ func makeFKDeleteHelper(
 	return h, nil
 }
 
+// referenceAction returns the action of the inbound foreign key reference ref
+// which applies to deletes (if usage is CheckDeletes) or updates of the
+// referenced rows.
+func referenceAction(
+	otherTables TableLookupsByID, ref ForeignKeyReference, usage FKCheck,
+) (ForeignKeyReference_Action, error) {
+	other := otherTables[ref.Table].Table
+	if other == nil {
+		return 0, errors.Errorf("referencing table %d not in provided table map %+v", ref.Table, otherTables)
+	}
+	idx, err := other.FindIndexByID(ref.Index)
+	if err != nil {
+		return 0, err
+	}
+	if usage == CheckDeletes {
+		return idx.ForeignKey.OnDelete, nil
+	}
+	return idx.ForeignKey.OnUpdate, nil
+}
+
 func (h fkDeleteHelper) checkAll(ctx context.Context, row parser.Datums, traceKV bool) error {
-	if len(h.fks) == 0 {
+	if len(h.fks) == 0 && len(h.cascades) == 0 {
 		return nil
 	}
 	for idx := range h.fks {
@@ -331,7 +448,27 @@ func (h fkDeleteHelper) checkAll(ctx context.Context, row parser.Datums, traceKV
 			return err
 		}
 	}
-	return h.checker.runCheck(ctx, row, nil)
+	if err := h.checker.runCheck(ctx, row, nil); err != nil {
+		return err
+	}
+	for idx := range h.cascades {
+		if err := h.queueCascades(idx, row, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queueCascades queues the cascading actions of the inbound foreign keys of
+// the given index for a referenced row which is deleted (if newValues is nil)
+// or updated.
+func (h fkDeleteHelper) queueCascades(idx IndexID, oldValues, newValues parser.Datums) error {
+	for i := range h.cascades[idx] {
+		if err := h.cascader.queue(&h.cascades[idx][i], oldValues, newValues); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (h fkDeleteHelper) checkIdx(
@@ -350,6 +487,21 @@ func (h fkDeleteHelper) CollectSpans() roachpb.Spans {
 	return collectSpansWithFKMap(h.fks)
 }
 
+// CollectCascadeSpans implements the FkSpanCollector interface.
+func (h fkDeleteHelper) CollectCascadeSpans() roachpb.Spans {
+	if h.cascader == nil {
+		return nil
+	}
+	// Cascades may modify any of the tables needed by the writer.
+	var writes roachpb.Spans
+	for _, t := range h.cascader.tables {
+		if t.Table != nil {
+			writes = append(writes, t.Table.AllIndexSpans()...)
+		}
+	}
+	return writes
+}
+
 // CollectSpansForValues implements the FkSpanCollector interface.
 func (h fkDeleteHelper) CollectSpansForValues(values parser.Datums) (roachpb.Spans, error) {
 	return collectSpansForValuesWithFKMap(h.fks, values)
@@ -368,10 +520,13 @@ func makeFKUpdateHelper(
 	otherTables TableLookupsByID,
 	colMap map[ColumnID]int,
 	alloc *DatumAlloc,
+	c *cascader,
 ) (fkUpdateHelper, error) {
 	ret := fkUpdateHelper{}
 	var err error
-	if ret.inbound, err = makeFKDeleteHelper(txn, table, otherTables, colMap, alloc); err != nil {
+	if ret.inbound, err = makeFKDeleteHelper(
+		txn, table, otherTables, colMap, alloc, CheckUpdates, c,
+	); err != nil {
 		return ret, err
 	}
 	ret.outbound, err = makeFKInsertHelper(txn, table, otherTables, colMap, alloc)
@@ -386,6 +541,9 @@ func (fks fkUpdateHelper) checkIdx(
 	if err := fks.inbound.checkIdx(ctx, idx, oldValues, traceKV); err != nil {
 		return err
 	}
+	if err := fks.inbound.queueCascades(idx, oldValues, newValues); err != nil {
+		return err
+	}
 	return fks.outbound.checkIdx(ctx, idx, newValues, traceKV)
 }
 
@@ -396,6 +554,11 @@ func (fks fkUpdateHelper) CollectSpans() roachpb.Spans {
 	return append(inboundReads, outboundReads...)
 }
 
+// CollectCascadeSpans implements the FkSpanCollector interface.
+func (fks fkUpdateHelper) CollectCascadeSpans() roachpb.Spans {
+	return fks.inbound.CollectCascadeSpans()
+}
+
 // CollectSpansForValues implements the FkSpanCollector interface.
 func (fks fkUpdateHelper) CollectSpansForValues(values parser.Datums) (roachpb.Spans, error) {
 	inboundReads, err := fks.inbound.CollectSpansForValues(values)
@@ -493,6 +656,9 @@ func (f baseFKHelper) span() roachpb.Span {
 type FkSpanCollector interface {
 	CollectSpans() roachpb.Spans
 	CollectSpansForValues(values parser.Datums) (roachpb.Spans, error)
+	// CollectCascadeSpans returns the spans that the cascading actions of
+	// foreign keys may write to.
+	CollectCascadeSpans() roachpb.Spans
 }
 
 var _ FkSpanCollector = fkInsertHelper{}
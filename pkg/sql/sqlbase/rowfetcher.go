@@ -171,10 +171,20 @@ func (rf *RowFetcher) Init(
 	for i, id := range indexColumnIDs {
 		rf.indexColIdx[i] = rf.colIdxMap[id]
 	}
+	if index.Type == IndexDescriptor_INVERTED {
+		// The key of an inverted index only contains a piece of the indexed
+		// value, so the column can't be filled in from the key.
+		rf.indexColIdx[0] = -1
+	}
 
 	if isSecondaryIndex {
 		for i := range rf.cols {
-			if rf.neededCols.Contains(int(rf.cols[i].ID)) && !index.ContainsColumnID(rf.cols[i].ID) {
+			id := rf.cols[i].ID
+			if !rf.neededCols.Contains(int(id)) {
+				continue
+			}
+			if !index.ContainsColumnID(id) ||
+				(index.Type == IndexDescriptor_INVERTED && id == index.ColumnIDs[0]) {
 				return fmt.Errorf("requested column %s not in index", rf.cols[i].Name)
 			}
 		}
@@ -186,6 +196,15 @@ func (rf *RowFetcher) Init(
 	if err != nil {
 		return err
 	}
+	if index.Type == IndexDescriptor_INVERTED {
+		// Decode the inverted key as what it really is: a JSON path (which is
+		// encoded as bytes) or an array element.
+		if rf.keyVals[0].Type.SemanticType == ColumnType_ARRAY {
+			rf.keyVals[0].Type = ColumnType{SemanticType: *rf.keyVals[0].Type.ArrayContents}
+		} else {
+			rf.keyVals[0].Type = ColumnType{SemanticType: ColumnType_BYTES}
+		}
+	}
 
 	if isSecondaryIndex && index.Unique {
 		// Unique secondary indexes have a value that is the primary index
@@ -344,7 +363,9 @@ func (rf *RowFetcher) processKV(
 
 		// Fill in the column values that are part of the index key.
 		for i, v := range rf.keyVals {
-			rf.row[rf.indexColIdx[i]] = v
+			if idx := rf.indexColIdx[i]; idx != -1 {
+				rf.row[idx] = v
+			}
 		}
 	}
 
@@ -45,6 +45,7 @@ type rowHelper struct {
 
 	// Computed and cached.
 	primaryIndexKeyPrefix []byte
+	indexKeyPrefixes      [][]byte
 	primaryIndexCols      map[ColumnID]struct{}
 	sortedColumnFamilies  map[FamilyID][]ColumnID
 }
@@ -77,17 +78,39 @@ func (rh *rowHelper) encodeIndexes(
 func (rh *rowHelper) encodeSecondaryIndexes(
 	colIDtoRowIndex map[ColumnID]int, values []parser.Datum,
 ) (secondaryIndexEntries []IndexEntry, err error) {
-	if len(rh.indexEntries) != len(rh.Indexes) {
-		rh.indexEntries = make([]IndexEntry, len(rh.Indexes))
-	}
-	err = EncodeSecondaryIndexes(
-		rh.TableDesc, rh.Indexes, colIDtoRowIndex, values, rh.indexEntries)
+	rh.indexEntries, err = EncodeSecondaryIndexes(
+		rh.TableDesc, rh.Indexes, colIDtoRowIndex, values, rh.indexEntries[:0])
 	if err != nil {
 		return nil, err
 	}
 	return rh.indexEntries, nil
 }
 
+// splitIndexEntries splits off the leading entries of secondaryIndexEntries
+// that belong to rh.Indexes[idx], where secondaryIndexEntries holds the entries
+// (as returned by encodeSecondaryIndexes) of rh.Indexes[idx:]. Forward indexes
+// always have exactly one entry, while inverted indexes have a variable
+// number of them, all sharing the index's key prefix.
+func (rh *rowHelper) splitIndexEntries(
+	idx int, secondaryIndexEntries []IndexEntry,
+) (indexEntries, rest []IndexEntry) {
+	if rh.Indexes[idx].Type != IndexDescriptor_INVERTED {
+		return secondaryIndexEntries[:1], secondaryIndexEntries[1:]
+	}
+	if rh.indexKeyPrefixes == nil {
+		rh.indexKeyPrefixes = make([][]byte, len(rh.Indexes))
+	}
+	if rh.indexKeyPrefixes[idx] == nil {
+		rh.indexKeyPrefixes[idx] = MakeIndexKeyPrefix(rh.TableDesc, rh.Indexes[idx].ID)
+	}
+	n := 0
+	for n < len(secondaryIndexEntries) &&
+		bytes.HasPrefix(secondaryIndexEntries[n].Key, rh.indexKeyPrefixes[idx]) {
+		n++
+	}
+	return secondaryIndexEntries[:n], secondaryIndexEntries[n:]
+}
+
 // skipColumnInPK returns true if the value at column colID does not need
 // to be encoded because it is already part of the primary key. Composite
 // datums are considered too, so a composite datum in a PK will return false.
@@ -407,6 +430,21 @@ func MakeRowUpdater(
 	requestedCols []ColumnDescriptor,
 	updateType rowUpdaterType,
 	alloc *DatumAlloc,
+) (RowUpdater, error) {
+	return makeRowUpdater(txn, tableDesc, fkTables, updateCols, requestedCols, updateType, alloc, nil)
+}
+
+// makeRowUpdater creates a RowUpdater which queues the cascading actions of
+// foreign keys on the given cascader, or on a new one if it is nil.
+func makeRowUpdater(
+	txn *client.Txn,
+	tableDesc *TableDescriptor,
+	fkTables TableLookupsByID,
+	updateCols []ColumnDescriptor,
+	requestedCols []ColumnDescriptor,
+	updateType rowUpdaterType,
+	alloc *DatumAlloc,
+	c *cascader,
 ) (RowUpdater, error) {
 	updateColIDtoRowIndex := ColIDtoRowIndexFromCols(updateCols)
 
@@ -547,12 +585,31 @@ func MakeRowUpdater(
 
 	var err error
 	if ru.Fks, err = makeFKUpdateHelper(txn, *tableDesc, fkTables,
-		ru.FetchColIDtoRowIndex, alloc); err != nil {
+		ru.FetchColIDtoRowIndex, alloc, c); err != nil {
 		return RowUpdater{}, err
 	}
 	return ru, nil
 }
 
+// HasPendingCascades returns true if the rows updated so far require the
+// cascading actions of foreign keys to be run with RunCascades.
+func (ru *RowUpdater) HasPendingCascades() bool {
+	c := ru.Fks.inbound.cascader
+	return c != nil && len(c.pending) > 0
+}
+
+// RunCascades applies the cascading actions of foreign keys to the rows
+// referencing the rows updated so far. It must be called once the batches
+// containing the updates have been run.
+func (ru *RowUpdater) RunCascades(
+	ctx context.Context, evalCtx *parser.EvalContext, traceKV bool,
+) error {
+	if c := ru.Fks.inbound.cascader; c != nil {
+		return c.run(ctx, evalCtx, traceKV)
+	}
+	return nil
+}
+
 // UpdateRow adds to the batch the kv operations necessary to update a table row
 // with the given values.
 //
@@ -622,8 +679,16 @@ func (ru *RowUpdater) UpdateRow(
 		if err := ru.Fks.checkIdx(ctx, ru.Helper.TableDesc.PrimaryIndex.ID, oldValues, ru.newValues, traceKV); err != nil {
 			return nil, err
 		}
-		for i := range newSecondaryIndexEntries {
-			if !bytes.Equal(newSecondaryIndexEntries[i].Key, secondaryIndexEntries[i].Key) {
+		oldEntries, newEntries := secondaryIndexEntries, newSecondaryIndexEntries
+		for i := range ru.Helper.Indexes {
+			var oldIndexEntries, newIndexEntries []IndexEntry
+			oldIndexEntries, oldEntries = ru.Helper.splitIndexEntries(i, oldEntries)
+			newIndexEntries, newEntries = ru.Helper.splitIndexEntries(i, newEntries)
+			if ru.Helper.Indexes[i].Type == IndexDescriptor_INVERTED {
+				// Inverted indexes cannot be referenced by foreign keys.
+				continue
+			}
+			if !bytes.Equal(newIndexEntries[0].Key, oldIndexEntries[0].Key) {
 				if err := ru.Fks.checkIdx(ctx, ru.Helper.Indexes[i].ID, oldValues, ru.newValues, traceKV); err != nil {
 					return nil, err
 				}
@@ -740,8 +805,17 @@ func (ru *RowUpdater) UpdateRow(
 	}
 
 	// Update secondary indexes.
-	for i, newSecondaryIndexEntry := range newSecondaryIndexEntries {
-		secondaryIndexEntry := secondaryIndexEntries[i]
+	oldEntries, newEntries := secondaryIndexEntries, newSecondaryIndexEntries
+	for i := range ru.Helper.Indexes {
+		var oldIndexEntries, newIndexEntries []IndexEntry
+		oldIndexEntries, oldEntries = ru.Helper.splitIndexEntries(i, oldEntries)
+		newIndexEntries, newEntries = ru.Helper.splitIndexEntries(i, newEntries)
+		if ru.Helper.Indexes[i].Type == IndexDescriptor_INVERTED {
+			ru.updateInvertedIndex(ctx, b, i, oldIndexEntries, newIndexEntries, traceKV)
+			continue
+		}
+
+		secondaryIndexEntry, newSecondaryIndexEntry := oldIndexEntries[0], newIndexEntries[0]
 		var expValue interface{}
 		if !bytes.Equal(newSecondaryIndexEntry.Key, secondaryIndexEntry.Key) {
 			if err := ru.Fks.checkIdx(ctx, ru.Helper.Indexes[i].ID, oldValues, ru.newValues, traceKV); err != nil {
@@ -772,6 +846,50 @@ func (ru *RowUpdater) UpdateRow(
 	return ru.newValues, nil
 }
 
+// updateInvertedIndex adds to the batch the kv operations necessary to replace
+// the entries of the inverted index ru.Helper.Indexes[idx] for a row whose
+// primary key is unchanged. Both oldEntries and newEntries are sorted by key,
+// so only the entries that differ between them are deleted or added.
+func (ru *RowUpdater) updateInvertedIndex(
+	ctx context.Context,
+	b *client.Batch,
+	idx int,
+	oldEntries, newEntries []IndexEntry,
+	traceKV bool,
+) {
+	// Do not update Indexes in the DELETE_ONLY state.
+	_, deleteOnly := ru.deleteOnlyIndex[idx]
+	for len(oldEntries) > 0 || len(newEntries) > 0 {
+		var c int
+		switch {
+		case len(oldEntries) == 0:
+			c = 1
+		case len(newEntries) == 0:
+			c = -1
+		default:
+			c = bytes.Compare(oldEntries[0].Key, newEntries[0].Key)
+		}
+		switch {
+		case c < 0:
+			if traceKV {
+				log.VEventf(ctx, 2, "Del %s", oldEntries[0].Key)
+			}
+			b.Del(oldEntries[0].Key)
+			oldEntries = oldEntries[1:]
+		case c > 0:
+			if !deleteOnly {
+				if traceKV {
+					log.VEventf(ctx, 2, "Put %s -> %v", newEntries[0].Key, newEntries[0].Value.PrettyPrint())
+				}
+				b.Put(newEntries[0].Key, &newEntries[0].Value)
+			}
+			newEntries = newEntries[1:]
+		default:
+			oldEntries, newEntries = oldEntries[1:], newEntries[1:]
+		}
+	}
+}
+
 // IsColumnOnlyUpdate returns true if this RowUpdater is only updating column
 // data (in contrast to updating the primary key or other indexes).
 func (ru *RowUpdater) IsColumnOnlyUpdate() bool {
@@ -807,6 +925,20 @@ func MakeRowDeleter(
 	requestedCols []ColumnDescriptor,
 	checkFKs bool,
 	alloc *DatumAlloc,
+) (RowDeleter, error) {
+	return makeRowDeleter(txn, tableDesc, fkTables, requestedCols, checkFKs, alloc, nil)
+}
+
+// makeRowDeleter creates a RowDeleter which queues the cascading actions of
+// foreign keys on the given cascader, or on a new one if it is nil.
+func makeRowDeleter(
+	txn *client.Txn,
+	tableDesc *TableDescriptor,
+	fkTables TableLookupsByID,
+	requestedCols []ColumnDescriptor,
+	checkFKs bool,
+	alloc *DatumAlloc,
+	c *cascader,
 ) (RowDeleter, error) {
 	indexes := tableDesc.Indexes
 	for _, m := range tableDesc.Mutations {
@@ -856,7 +988,7 @@ func MakeRowDeleter(
 	if checkFKs {
 		var err error
 		if rd.Fks, err = makeFKDeleteHelper(txn, *tableDesc, fkTables,
-			fetchColIDtoRowIndex, alloc); err != nil {
+			fetchColIDtoRowIndex, alloc, CheckDeletes, c); err != nil {
 			return RowDeleter{}, err
 		}
 	}
@@ -864,6 +996,24 @@ func MakeRowDeleter(
 	return rd, nil
 }
 
+// HasPendingCascades returns true if the rows deleted so far require the
+// cascading actions of foreign keys to be run with RunCascades.
+func (rd *RowDeleter) HasPendingCascades() bool {
+	return rd.Fks.cascader != nil && len(rd.Fks.cascader.pending) > 0
+}
+
+// RunCascades applies the cascading actions of foreign keys to the rows
+// referencing the rows deleted so far. It must be called once the batches
+// containing the deletions have been run.
+func (rd *RowDeleter) RunCascades(
+	ctx context.Context, evalCtx *parser.EvalContext, traceKV bool,
+) error {
+	if rd.Fks.cascader != nil {
+		return rd.Fks.cascader.run(ctx, evalCtx, traceKV)
+	}
+	return nil
+}
+
 // DeleteRow adds to the batch the kv operations necessary to delete a table row
 // with the given values.
 func (rd *RowDeleter) DeleteRow(
@@ -905,15 +1055,17 @@ func (rd *RowDeleter) DeleteIndexRow(
 	if err := rd.Fks.checkAll(ctx, values, traceKV); err != nil {
 		return err
 	}
-	secondaryIndexEntry, err := EncodeSecondaryIndex(
+	secondaryIndexEntries, err := EncodeSecondaryIndex(
 		rd.Helper.TableDesc, idx, rd.FetchColIDtoRowIndex, values)
 	if err != nil {
 		return err
 	}
-	if traceKV {
-		log.VEventf(ctx, 2, "Del %s", secondaryIndexEntry.Key)
+	for _, entry := range secondaryIndexEntries {
+		if traceKV {
+			log.VEventf(ctx, 2, "Del %s", entry.Key)
+		}
+		b.Del(entry.Key)
 	}
-	b.Del(secondaryIndexEntry.Key)
 	return nil
 }
 
@@ -287,6 +287,8 @@ func (desc *IndexDescriptor) ColNamesString() string {
 
 var isUnique = map[bool]string{true: "UNIQUE "}
 
+var indexTypePrefix = map[IndexDescriptor_Type]string{IndexDescriptor_INVERTED: "INVERTED "}
+
 // SQLString returns the SQL string describing this index. If non-empty,
 // "ON tableName" is included in the output in the correct place.
 func (desc *IndexDescriptor) SQLString(tableName string) string {
@@ -302,11 +304,17 @@ func (desc *IndexDescriptor) SQLString(tableName string) string {
 	if tableName != "" {
 		onTable = fmt.Sprintf("ON %s ", tableName)
 	}
-	return fmt.Sprintf("%sINDEX %s%s (%s)%s",
+	colNames := desc.ColNamesString()
+	if desc.Type == IndexDescriptor_INVERTED {
+		// Inverted indexes have no column direction.
+		colNames = parser.AsString(parser.Name(desc.ColumnNames[0]))
+	}
+	return fmt.Sprintf("%s%sINDEX %s%s (%s)%s",
 		isUnique[desc.Unique],
+		indexTypePrefix[desc.Type],
 		onTable,
 		parser.AsString(parser.Name(desc.Name)),
-		desc.ColNamesString(),
+		colNames,
 		storing,
 	)
 }
@@ -333,9 +341,10 @@ func (desc *TableDescriptor) IsEmpty() bool {
 }
 
 // IsTable returns true if the TableDescriptor actually describes a
-// Table resource, as opposed to a different resource (like a View).
+// Table resource, as opposed to a different resource (like a View or a
+// Sequence).
 func (desc *TableDescriptor) IsTable() bool {
-	return !desc.IsView()
+	return !desc.IsView() && !desc.IsSequence()
 }
 
 // IsView returns true if the TableDescriptor actually describes a
@@ -344,6 +353,12 @@ func (desc *TableDescriptor) IsView() bool {
 	return desc.ViewQuery != ""
 }
 
+// IsSequence returns true if the TableDescriptor actually describes a
+// Sequence resource rather than a Table.
+func (desc *TableDescriptor) IsSequence() bool {
+	return desc.SequenceOpts != nil
+}
+
 // IsVirtualTable returns true if the TableDescriptor describes a
 // virtual Table (like the information_schema tables) and thus doesn't
 // need to be physically stored.
@@ -587,7 +602,8 @@ func HasCompositeKeyEncoding(semanticType ColumnType_SemanticType) bool {
 	switch semanticType {
 	case ColumnType_COLLATEDSTRING,
 		ColumnType_FLOAT,
-		ColumnType_DECIMAL:
+		ColumnType_DECIMAL,
+		ColumnType_JSON:
 		return true
 	}
 	return false
@@ -691,9 +707,13 @@ func (desc *TableDescriptor) allocateIndexIDs(columnNames map[string]ColumnID) e
 		}
 
 		index.CompositeColumnIDs = nil
-		for _, colID := range index.ColumnIDs {
-			if _, ok := isCompositeColumn[colID]; ok {
-				index.CompositeColumnIDs = append(index.CompositeColumnIDs, colID)
+		// The key of an inverted index doesn't contain the values of its
+		// columns, so they can't be composite.
+		if index.Type != IndexDescriptor_INVERTED {
+			for _, colID := range index.ColumnIDs {
+				if _, ok := isCompositeColumn[colID]; ok {
+					index.CompositeColumnIDs = append(index.CompositeColumnIDs, colID)
+				}
 			}
 		}
 		for _, colID := range index.ExtraColumnIDs {
@@ -1003,6 +1023,14 @@ func (desc *TableDescriptor) ValidateTable() error {
 			desc.Name, desc.GetFormatVersion(), FamilyFormatVersion, InterleavedFormatVersion)
 	}
 
+	// Sequences have no columns or indexes; their value lives in a single key.
+	if desc.IsSequence() {
+		if err := desc.SequenceOpts.Validate(); err != nil {
+			return err
+		}
+		return desc.Privileges.Validate(desc.GetID())
+	}
+
 	if len(desc.Columns) == 0 {
 		return ErrMissingColumns
 	}
@@ -1057,6 +1085,11 @@ func (desc *TableDescriptor) ValidateTable() error {
 				idx := desc.Index
 				return errors.Errorf("mutation in state %s, direction %s, index %s, id %v", m.State, m.Direction, idx.Name, idx.ID)
 			}
+		case *DescriptorMutation_Alteration:
+			if unSetEnums {
+				return errors.Errorf("mutation in state %s, direction %s, alteration of column %v",
+					m.State, m.Direction, desc.Alteration.ColumnID)
+			}
 		default:
 			return errors.Errorf("mutation in state %s, direction %s, and no column/index descriptor", m.State, m.Direction)
 		}
@@ -1080,6 +1113,24 @@ func (desc *TableDescriptor) ValidateTable() error {
 	return desc.Privileges.Validate(desc.GetID())
 }
 
+// Validate checks that the sequence options are consistent.
+func (opts *TableDescriptor_SequenceOpts) Validate() error {
+	if opts.Increment == 0 {
+		return errors.New("INCREMENT must not be zero")
+	}
+	if opts.MinValue > opts.MaxValue {
+		return errors.Errorf(
+			"MINVALUE (%d) must be less than MAXVALUE (%d)", opts.MinValue, opts.MaxValue)
+	}
+	if opts.Start < opts.MinValue {
+		return errors.Errorf("START value (%d) cannot be less than MINVALUE (%d)", opts.Start, opts.MinValue)
+	}
+	if opts.Start > opts.MaxValue {
+		return errors.Errorf("START value (%d) cannot be greater than MAXVALUE (%d)", opts.Start, opts.MaxValue)
+	}
+	return nil
+}
+
 func (desc *TableDescriptor) validateColumnFamilies(
 	columnIDs map[ColumnID]string,
 ) (map[ColumnID]FamilyID, error) {
@@ -1156,6 +1207,7 @@ func (desc *TableDescriptor) validateTableIndexes(
 
 	indexNames := map[string]struct{}{}
 	indexIDs := map[IndexID]string{}
+	partitionNames := map[string]string{}
 	for _, index := range desc.AllNonDropIndexes() {
 		if err := validateName(index.Name, "index"); err != nil {
 			return err
@@ -1208,6 +1260,10 @@ func (desc *TableDescriptor) validateTableIndexes(
 					index.Name, name, colID, index.ColumnIDs[i])
 			}
 		}
+
+		if err := index.validatePartitioning(partitionNames); err != nil {
+			return err
+		}
 	}
 
 	for _, colID := range desc.PrimaryIndex.ColumnIDs {
@@ -1220,6 +1276,78 @@ func (desc *TableDescriptor) validateTableIndexes(
 	return nil
 }
 
+// validatePartitioning checks that the partitioning of the index is
+// consistent. partitionNames maps the names of the partitions of the table
+// seen so far to the name of their index; partition names must be unique
+// within a table.
+func (desc *IndexDescriptor) validatePartitioning(partitionNames map[string]string) error {
+	part := &desc.Partitioning
+	if part.NumColumns == 0 {
+		if len(part.List) > 0 || len(part.Range) > 0 {
+			return fmt.Errorf("index %q has partitions but no partitioning columns", desc.Name)
+		}
+		return nil
+	}
+	if int(part.NumColumns) > len(desc.ColumnIDs) {
+		return fmt.Errorf("index %q is partitioned by %d columns but only has %d columns",
+			desc.Name, part.NumColumns, len(desc.ColumnIDs))
+	}
+	if len(part.List) > 0 && len(part.Range) > 0 {
+		return fmt.Errorf("index %q has both list and range partitions", desc.Name)
+	}
+	if len(part.List) == 0 && len(part.Range) == 0 {
+		return fmt.Errorf("index %q has partitioning columns but no partitions", desc.Name)
+	}
+	checkName := func(name string) error {
+		if err := validateName(name, "partition"); err != nil {
+			return err
+		}
+		if other, ok := partitionNames[name]; ok {
+			return fmt.Errorf("partition %q in index %q duplicates partition in index %q",
+				name, desc.Name, other)
+		}
+		partitionNames[name] = desc.Name
+		return nil
+	}
+	for _, p := range part.List {
+		if err := checkName(p.Name); err != nil {
+			return err
+		}
+		if len(p.Values) == 0 {
+			return fmt.Errorf("partition %q must contain values", p.Name)
+		}
+	}
+	for _, p := range part.Range {
+		if err := checkName(p.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindPartitionByName searches the partitions of the table's indexes for
+// one named name, returning the index containing it.
+func (desc *TableDescriptor) FindPartitionByName(name string) (*IndexDescriptor, error) {
+	var found *IndexDescriptor
+	_ = desc.ForeachNonDropIndex(func(index *IndexDescriptor) error {
+		for _, p := range index.Partitioning.List {
+			if p.Name == name {
+				found = index
+			}
+		}
+		for _, p := range index.Partitioning.Range {
+			if p.Name == name {
+				found = index
+			}
+		}
+		return nil
+	})
+	if found == nil {
+		return nil, fmt.Errorf("partition %q does not exist", name)
+	}
+	return found, nil
+}
+
 // FamilyHeuristicTargetBytes is the target total byte size of columns that the
 // current heuristic will assign to a family.
 const FamilyHeuristicTargetBytes = 256
@@ -1239,7 +1367,8 @@ func upperBoundColumnValueEncodedSize(col ColumnDescriptor) (int, bool) {
 		typ = encoding.Float
 	case ColumnType_INTERVAL:
 		typ = encoding.Duration
-	case ColumnType_STRING, ColumnType_BYTES, ColumnType_COLLATEDSTRING, ColumnType_NAME, ColumnType_UUID, ColumnType_INET:
+	case ColumnType_STRING, ColumnType_BYTES, ColumnType_COLLATEDSTRING, ColumnType_NAME, ColumnType_UUID, ColumnType_INET,
+		ColumnType_JSON:
 		// STRINGs are counted as runes, so this isn't totally correct, but this
 		// seems better than always assuming the maximum rune width.
 		typ, size = encoding.Bytes, int(col.Type.Width)
@@ -1332,6 +1461,40 @@ func checkColumnsValidForIndex(tableDesc *TableDescriptor, indexColNames []strin
 	return nil
 }
 
+// checkValidInvertedIndex verifies that idx can be used as an inverted index:
+// it must be a non-unique index without stored columns on a single JSONB or
+// ARRAY column.
+func checkValidInvertedIndex(tableDesc *TableDescriptor, idx *IndexDescriptor) error {
+	if len(idx.ColumnNames) != 1 {
+		return errors.New("inverted indexes don't support multi-column indexes")
+	}
+	if idx.Unique {
+		return errors.New("inverted indexes can't be unique")
+	}
+	if len(idx.StoreColumnNames) > 0 {
+		return errors.New("inverted indexes don't support stored columns")
+	}
+	for _, col := range tableDesc.Columns {
+		if col.Name == idx.ColumnNames[0] {
+			switch col.Type.SemanticType {
+			case ColumnType_JSON, ColumnType_ARRAY:
+				return nil
+			}
+			return fmt.Errorf("column %s of type %s is not allowed in an inverted index",
+				col.Name, col.Type.SemanticType)
+		}
+	}
+	return nil
+}
+
+// checkIndexValid verifies that the columns of idx can be indexed by it.
+func checkIndexValid(tableDesc *TableDescriptor, idx *IndexDescriptor) error {
+	if idx.Type == IndexDescriptor_INVERTED {
+		return checkValidInvertedIndex(tableDesc, idx)
+	}
+	return checkColumnsValidForIndex(tableDesc, idx.ColumnNames)
+}
+
 // AddColumn adds a column to the table.
 func (desc *TableDescriptor) AddColumn(col ColumnDescriptor) {
 	desc.Columns = append(desc.Columns, col)
@@ -1344,10 +1507,13 @@ func (desc *TableDescriptor) AddFamily(fam ColumnFamilyDescriptor) {
 
 // AddIndex adds an index to the table.
 func (desc *TableDescriptor) AddIndex(idx IndexDescriptor, primary bool) error {
-	if err := checkColumnsValidForIndex(desc, idx.ColumnNames); err != nil {
+	if err := checkIndexValid(desc, &idx); err != nil {
 		return err
 	}
 	if primary {
+		if idx.Type == IndexDescriptor_INVERTED {
+			return errors.New("a primary key can't be an inverted index")
+		}
 		// PrimaryIndex is unset.
 		if desc.PrimaryIndex.Name == "" {
 			if idx.Name == "" {
@@ -1646,6 +1812,9 @@ func (desc *TableDescriptor) MakeMutationComplete(m DescriptorMutation) {
 			if err := desc.AddIndex(*t.Index, false); err != nil {
 				panic(err)
 			}
+
+		case *DescriptorMutation_Alteration:
+			desc.completeColumnAlteration(*t.Alteration)
 		}
 
 	case DescriptorMutation_DROP:
@@ -1654,8 +1823,83 @@ func (desc *TableDescriptor) MakeMutationComplete(m DescriptorMutation) {
 			desc.RemoveColumnFromFamily(t.Column.ID)
 		}
 		// Nothing else to be done. The column/index was already removed from the
-		// set of column/index descriptors at mutation creation time.
+		// set of column/index descriptors at mutation creation time, and a
+		// column alteration being dropped leaves its column untouched.
+	}
+}
+
+// completeColumnAlteration applies a column alteration once it has been
+// validated and its shadow column, if any, backfilled. Replacing a column by
+// its shadow column adds a mutation dropping the replaced column, which uses
+// the next mutation ID of the table.
+func (desc *TableDescriptor) completeColumnAlteration(a ColumnAlteration) {
+	colIdx, shadowIdx := -1, -1
+	for i := range desc.Columns {
+		switch desc.Columns[i].ID {
+		case a.ColumnID:
+			colIdx = i
+		case a.ShadowColumnID:
+			shadowIdx = i
+		}
+	}
+	if colIdx == -1 {
+		panic(fmt.Sprintf("column-id \"%d\" does not exist", a.ColumnID))
+	}
+	if a.SetNotNull {
+		desc.Columns[colIdx].Nullable = false
+	}
+	if a.ShadowColumnID == 0 {
+		return
+	}
+	if shadowIdx == -1 {
+		panic(fmt.Sprintf("shadow column-id \"%d\" does not exist", a.ShadowColumnID))
+	}
+
+	// The shadow column takes over the name, position and attributes of the
+	// column, which is renamed to the name of the shadow column.
+	col, shadow := desc.Columns[colIdx], desc.Columns[shadowIdx]
+	desc.RenameColumnDescriptor(col, shadow.Name)
+	desc.RenameColumnDescriptor(shadow, col.Name)
+	col, shadow = desc.Columns[colIdx], desc.Columns[shadowIdx]
+	shadow.Nullable = col.Nullable
+	shadow.Hidden = col.Hidden
+	shadow.DefaultExpr = col.DefaultExpr
+	shadow.ComputeExpr = nil
+	col.Hidden = true
+	desc.Columns[colIdx] = shadow
+	desc.Columns = append(desc.Columns[:shadowIdx], desc.Columns[shadowIdx+1:]...)
+	for i := range desc.Families {
+		family := &desc.Families[i]
+		colPos, shadowPos := -1, -1
+		for j, id := range family.ColumnIDs {
+			switch id {
+			case col.ID:
+				colPos = j
+			case shadow.ID:
+				shadowPos = j
+			}
+		}
+		if colPos != -1 && shadowPos != -1 {
+			family.ColumnIDs[colPos], family.ColumnIDs[shadowPos] =
+				family.ColumnIDs[shadowPos], family.ColumnIDs[colPos]
+			family.ColumnNames[colPos], family.ColumnNames[shadowPos] =
+				family.ColumnNames[shadowPos], family.ColumnNames[colPos]
+		}
 	}
+	desc.AddColumnMutation(col, DescriptorMutation_DROP)
+}
+
+// HasPendingNotNull returns whether a column alteration is making the column
+// with the given ID NOT NULL. NULL values must not be written to such a
+// column, as they could go unnoticed by the validation of the alteration.
+func (desc *TableDescriptor) HasPendingNotNull(id ColumnID) bool {
+	for _, m := range desc.Mutations {
+		if a := m.GetAlteration(); a != nil && a.ColumnID == id && a.SetNotNull &&
+			m.Direction == DescriptorMutation_ADD {
+			return true
+		}
+	}
+	return false
 }
 
 // AddColumnMutation adds a column mutation to desc.Mutations.
@@ -1666,11 +1910,20 @@ func (desc *TableDescriptor) AddColumnMutation(
 	desc.addMutation(m)
 }
 
+// AddColumnAlterationMutation adds a column alteration mutation to
+// desc.Mutations.
+func (desc *TableDescriptor) AddColumnAlterationMutation(
+	a ColumnAlteration, direction DescriptorMutation_Direction,
+) {
+	m := DescriptorMutation{Descriptor_: &DescriptorMutation_Alteration{Alteration: &a}, Direction: direction}
+	desc.addMutation(m)
+}
+
 // AddIndexMutation adds an index mutation to desc.Mutations.
 func (desc *TableDescriptor) AddIndexMutation(
 	idx IndexDescriptor, direction DescriptorMutation_Direction,
 ) error {
-	if err := checkColumnsValidForIndex(desc, idx.ColumnNames); err != nil {
+	if err := checkIndexValid(desc, &idx); err != nil {
 		return err
 	}
 	m := DescriptorMutation{Descriptor_: &DescriptorMutation_Index{Index: &idx}, Direction: direction}
@@ -1791,6 +2044,8 @@ func (c *ColumnType) SQLString() string {
 		return fmt.Sprintf("%s COLLATE %s", ColumnType_STRING.String(), *c.Locale)
 	case ColumnType_ARRAY:
 		return c.ArrayContents.String() + "[]"
+	case ColumnType_JSON:
+		return "JSONB"
 	}
 	if c.VisibleType != ColumnType_NONE {
 		return c.VisibleType.String()
@@ -1888,6 +2143,8 @@ func DatumTypeToColumnSemanticType(ptyp parser.Type) (ColumnType_SemanticType, e
 		return ColumnType_UUID, nil
 	case parser.TypeINet:
 		return ColumnType_INET, nil
+	case parser.TypeJSON:
+		return ColumnType_JSON, nil
 	case parser.TypeOid:
 		return ColumnType_OID, nil
 	case parser.TypeNull:
@@ -1956,6 +2213,8 @@ func columnSemanticTypeToDatumType(c *ColumnType, k ColumnType_SemanticType) par
 		return parser.TypeUUID
 	case ColumnType_INET:
 		return parser.TypeINet
+	case ColumnType_JSON:
+		return parser.TypeJSON
 	case ColumnType_COLLATEDSTRING:
 		if c.Locale == nil {
 			panic("locale is required for COLLATEDSTRING")
@@ -2042,6 +2301,44 @@ func (f ForeignKeyReference) IsSet() bool {
 	return f.Table != 0
 }
 
+// ForeignKeyReferenceActionValue maps parser.ReferenceAction values to
+// ForeignKeyReference_Action values.
+var ForeignKeyReferenceActionValue = [...]ForeignKeyReference_Action{
+	parser.NoAction:   ForeignKeyReference_NO_ACTION,
+	parser.Restrict:   ForeignKeyReference_RESTRICT,
+	parser.SetNull:    ForeignKeyReference_SET_NULL,
+	parser.SetDefault: ForeignKeyReference_SET_DEFAULT,
+	parser.Cascade:    ForeignKeyReference_CASCADE,
+}
+
+// ReferenceAction returns the parser.ReferenceAction corresponding to the
+// action.
+func (a ForeignKeyReference_Action) ReferenceAction() parser.ReferenceAction {
+	switch a {
+	case ForeignKeyReference_RESTRICT:
+		return parser.Restrict
+	case ForeignKeyReference_SET_NULL:
+		return parser.SetNull
+	case ForeignKeyReference_SET_DEFAULT:
+		return parser.SetDefault
+	case ForeignKeyReference_CASCADE:
+		return parser.Cascade
+	default:
+		return parser.NoAction
+	}
+}
+
+// IsCascading returns whether the action modifies the referencing rows
+// instead of rejecting the change to the referenced rows.
+func (a ForeignKeyReference_Action) IsCascading() bool {
+	switch a {
+	case ForeignKeyReference_CASCADE, ForeignKeyReference_SET_NULL, ForeignKeyReference_SET_DEFAULT:
+		return true
+	default:
+		return false
+	}
+}
+
 // InvalidateFKConstraints sets all FK constraints to un-validated.
 func (desc *TableDescriptor) InvalidateFKConstraints() {
 	// We don't use GetConstraintInfo because we want to edit the passed desc.
@@ -2110,5 +2407,13 @@ func (desc *ColumnDescriptor) SQLString() string {
 	if desc.DefaultExpr != nil {
 		fmt.Fprintf(&buf, " DEFAULT %s", *desc.DefaultExpr)
 	}
+	if desc.IsComputed() {
+		fmt.Fprintf(&buf, " AS (%s) STORED", *desc.ComputeExpr)
+	}
 	return buf.String()
 }
+
+// IsComputed returns whether this is a computed column.
+func (desc *ColumnDescriptor) IsComputed() bool {
+	return desc.ComputeExpr != nil
+}
@@ -15,6 +15,7 @@
 package sqlbase
 
 import (
+	"bytes"
 	"fmt"
 	"sort"
 	"time"
@@ -31,6 +32,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/encoding"
 	"github.com/cockroachdb/cockroach/pkg/util/ipaddr"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 )
 
@@ -143,6 +145,7 @@ func MakeColumnDefDescs(
 	case *parser.IntervalColType:
 	case *parser.UUIDColType:
 	case *parser.IPAddrColType:
+	case *parser.JSONColType:
 	case *parser.StringColType:
 		col.Type.Width = int32(t.N)
 	case *parser.NameColType:
@@ -216,6 +219,16 @@ func MakeColumnDefDescs(
 		col.DefaultExpr = &s
 	}
 
+	if d.IsComputed() {
+		if col.DefaultExpr != nil {
+			return nil, nil, fmt.Errorf("computed column %q cannot have a default value", col.Name)
+		}
+		// The expression can only be checked against the other columns of the
+		// table, see ValidateComputedColumn.
+		s := parser.Serialize(d.Computed.Expr)
+		col.ComputeExpr = &s
+	}
+
 	var idx *IndexDescriptor
 	if d.PrimaryKey || d.Unique {
 		idx = &IndexDescriptor{
@@ -552,6 +565,11 @@ func EncodeTableKey(b []byte, val parser.Datum, dir encoding.Direction) ([]byte,
 			return encoding.EncodeBytesAscending(b, data), nil
 		}
 		return encoding.EncodeBytesDescending(b, data), nil
+	case *parser.DJSON:
+		if dir == encoding.Ascending {
+			return encoding.EncodeJSONAscending(b, t.JSON), nil
+		}
+		return encoding.EncodeJSONDescending(b, t.JSON), nil
 	case *parser.DTuple:
 		for _, datum := range t.D {
 			var err error
@@ -620,6 +638,8 @@ func EncodeTableValue(
 		return encoding.EncodeUUIDValue(appendTo, uint32(colID), t.UUID), nil
 	case *parser.DIPAddr:
 		return encoding.EncodeIPAddrValue(appendTo, uint32(colID), t.IPAddr), nil
+	case *parser.DJSON:
+		return encoding.EncodeJSONValue(appendTo, uint32(colID), t.JSON), nil
 	case *parser.DArray:
 		a, err := encodeArray(t, scratch)
 		if err != nil {
@@ -928,6 +948,7 @@ type DatumAlloc struct {
 	dintervalAlloc    []parser.DInterval
 	duuidAlloc        []parser.DUuid
 	dipnetAlloc       []parser.DIPAddr
+	djsonAlloc        []parser.DJSON
 	doidAlloc         []parser.DOid
 	scratch           []byte
 	env               parser.CollationEnvironment
@@ -1070,6 +1091,18 @@ func (a *DatumAlloc) NewDIPAddr(v parser.DIPAddr) *parser.DIPAddr {
 	return r
 }
 
+// NewDJSON allocates a DJSON.
+func (a *DatumAlloc) NewDJSON(v parser.DJSON) *parser.DJSON {
+	buf := &a.djsonAlloc
+	if len(*buf) == 0 {
+		*buf = make([]parser.DJSON, datumAllocSize)
+	}
+	r := &(*buf)[0]
+	*r = v
+	*buf = (*buf)[1:]
+	return r
+}
+
 // NewDOid allocates a DOid.
 func (a *DatumAlloc) NewDOid(v parser.DOid) parser.Datum {
 	buf := &a.doidAlloc
@@ -1212,6 +1245,17 @@ func DecodeTableKey(
 		var ipAddr ipaddr.IPAddr
 		_, err := ipAddr.FromBuffer(r)
 		return a.NewDIPAddr(parser.DIPAddr{IPAddr: ipAddr}), rkey, err
+	case parser.TypeJSON:
+		var j json.JSON
+		if dir == encoding.Ascending {
+			rkey, j, err = encoding.DecodeJSONAscending(key)
+		} else {
+			rkey, j, err = encoding.DecodeJSONDescending(key)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return a.NewDJSON(parser.DJSON{JSON: j}), rkey, nil
 	case parser.TypeOid:
 		var i int64
 		if dir == encoding.Ascending {
@@ -1399,6 +1443,12 @@ func decodeUntaggedDatum(a *DatumAlloc, t parser.Type, buf []byte) (parser.Datum
 	case parser.TypeINet:
 		b, data, err := encoding.DecodeUntaggedIPAddrValue(buf)
 		return a.NewDIPAddr(parser.DIPAddr{IPAddr: data}), b, err
+	case parser.TypeJSON:
+		b, data, err := encoding.DecodeUntaggedJSONValue(buf)
+		if err != nil {
+			return nil, b, err
+		}
+		return a.NewDJSON(parser.DJSON{JSON: data}), b, nil
 	case parser.TypeOid:
 		b, data, err := encoding.DecodeUntaggedIntValue(buf)
 		return a.NewDOid(parser.MakeDOid(parser.DInt(data))), b, err
@@ -1435,19 +1485,115 @@ func (a byID) Len() int           { return len(a) }
 func (a byID) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a byID) Less(i, j int) bool { return a[i].id < a[j].id }
 
+// EncodeInvertedIndexKeys creates a list of inverted index keys by
+// concatenating keyPrefix with the encodings of the elements (or paths) of the
+// indexed column's value. An inverted index has a single column; a NULL value
+// results in no keys.
+func EncodeInvertedIndexKeys(
+	tableDesc *TableDescriptor,
+	index *IndexDescriptor,
+	colMap map[ColumnID]int,
+	values []parser.Datum,
+	keyPrefix []byte,
+) (key [][]byte, err error) {
+	if len(index.ColumnIDs) != 1 {
+		return nil, errors.Errorf("trying to apply inverted index to more than one column")
+	}
+
+	var val parser.Datum
+	if i, ok := colMap[index.ColumnIDs[0]]; ok {
+		val = values[i]
+	} else {
+		val = parser.DNull
+	}
+	return EncodeInvertedIndexTableKeys(val, keyPrefix)
+}
+
+// EncodeInvertedIndexTableKeys encodes the inverted index keys of val, each
+// prefixed by inKey. ARRAY values produce one key per distinct non-NULL
+// element and JSONB values one key per distinct path. The keys are returned
+// in sorted order.
+func EncodeInvertedIndexTableKeys(val parser.Datum, inKey []byte) (key [][]byte, err error) {
+	if val == parser.DNull {
+		return nil, nil
+	}
+	switch t := parser.UnwrapDatum(val).(type) {
+	case *parser.DJSON:
+		return encoding.EncodeJSONInvertedIndexKeys(inKey, t.JSON), nil
+	case *parser.DArray:
+		keys := make([][]byte, 0, len(t.Array))
+		for _, d := range t.Array {
+			if d == parser.DNull {
+				continue
+			}
+			k, err := EncodeTableKey(append([]byte(nil), inKey...), d, encoding.Ascending)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+		out := keys[:0]
+		for i := range keys {
+			if i == 0 || !bytes.Equal(keys[i], keys[i-1]) {
+				out = append(out, keys[i])
+			}
+		}
+		return out, nil
+	}
+	return nil, errors.Errorf("value type %s cannot be indexed by an inverted index", val.ResolvedType())
+}
+
+// EncodeInvertedIndexContainmentKey returns an inverted index key, prefixed by
+// inKey, that is among the keys of every value containing val (that is, every
+// value v for which `v @> val` holds). It returns false if there is no such
+// key, in which case the inverted index cannot be used to find those values.
+func EncodeInvertedIndexContainmentKey(val parser.Datum, inKey []byte) ([]byte, bool, error) {
+	switch t := parser.UnwrapDatum(val).(type) {
+	case *parser.DJSON:
+		key, ok := encoding.EncodeJSONContainmentKey(inKey, t.JSON)
+		return key, ok, nil
+	case *parser.DArray:
+		// An array containing NULL is not contained in any array, and the empty
+		// array is contained in all of them.
+		if len(t.Array) == 0 || t.Array[0] == parser.DNull {
+			return nil, false, nil
+		}
+		key, err := EncodeTableKey(append([]byte(nil), inKey...), t.Array[0], encoding.Ascending)
+		return key, err == nil, err
+	}
+	return nil, false, nil
+}
+
 // EncodeSecondaryIndex encodes key/values for a secondary index. colMap maps
-// ColumnIDs to indices in `values`.
+// ColumnIDs to indices in `values`. Forward indexes produce exactly one entry;
+// inverted indexes produce one entry per key returned by
+// EncodeInvertedIndexKeys.
 func EncodeSecondaryIndex(
 	tableDesc *TableDescriptor,
 	secondaryIndex *IndexDescriptor,
 	colMap map[ColumnID]int,
 	values []parser.Datum,
-) (IndexEntry, error) {
+) ([]IndexEntry, error) {
 	secondaryIndexKeyPrefix := MakeIndexKeyPrefix(tableDesc, secondaryIndex.ID)
-	secondaryIndexKey, containsNull, err := EncodeIndexKey(
-		tableDesc, secondaryIndex, colMap, values, secondaryIndexKeyPrefix)
-	if err != nil {
-		return IndexEntry{}, err
+
+	var secondaryKeys [][]byte
+	var containsNull bool
+	if secondaryIndex.Type == IndexDescriptor_INVERTED {
+		var err error
+		secondaryKeys, err = EncodeInvertedIndexKeys(
+			tableDesc, secondaryIndex, colMap, values, secondaryIndexKeyPrefix)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		secondaryIndexKey, hasNull, err := EncodeIndexKey(
+			tableDesc, secondaryIndex, colMap, values, secondaryIndexKeyPrefix)
+		if err != nil {
+			return nil, err
+		}
+		secondaryKeys = [][]byte{secondaryIndexKey}
+		containsNull = hasNull
 	}
 
 	// Add the extra columns - they are encoded ascendingly which is done by
@@ -1455,21 +1601,9 @@ func EncodeSecondaryIndex(
 	extraKey, _, err := EncodeColumns(secondaryIndex.ExtraColumnIDs, nil,
 		colMap, values, nil)
 	if err != nil {
-		return IndexEntry{}, err
-	}
-
-	entry := IndexEntry{Key: secondaryIndexKey}
-
-	if !secondaryIndex.Unique || containsNull {
-		// If the index is not unique or it contains a NULL value, append
-		// extraKey to the key in order to make it unique.
-		entry.Key = append(entry.Key, extraKey...)
+		return nil, err
 	}
 
-	// Index keys are considered "sentinel" keys in that they do not have a
-	// column ID suffix.
-	entry.Key = keys.MakeFamilyKey(entry.Key, 0)
-
 	var entryValue []byte
 	if secondaryIndex.Unique {
 		// Note that a unique secondary index that contains a NULL column value
@@ -1507,33 +1641,51 @@ func EncodeSecondaryIndex(
 		lastColID = col.id
 		entryValue, err = EncodeTableValue(entryValue, colIDDiff, val, nil)
 		if err != nil {
-			return IndexEntry{}, err
+			return nil, err
 		}
 	}
-	entry.Value.SetBytes(entryValue)
 
-	return entry, nil
+	entries := make([]IndexEntry, len(secondaryKeys))
+	for i, key := range secondaryKeys {
+		entry := IndexEntry{Key: key}
+
+		if !secondaryIndex.Unique || containsNull {
+			// If the index is not unique or it contains a NULL value, append
+			// extraKey to the key in order to make it unique.
+			entry.Key = append(entry.Key, extraKey...)
+		}
+
+		// Index keys are considered "sentinel" keys in that they do not have a
+		// column ID suffix.
+		entry.Key = keys.MakeFamilyKey(entry.Key, 0)
+		entry.Value.SetBytes(entryValue)
+		entries[i] = entry
+	}
+
+	return entries, nil
 }
 
 // EncodeSecondaryIndexes encodes key/values for the secondary indexes. colMap
-// maps ColumnIDs to indices in `values`. secondaryIndexEntries is the return
-// value (passed as a parameter so the caller can reuse between rows) and is
-// expected to be the same length as indexes.
+// maps ColumnIDs to indices in `values`. The entries are appended to
+// secondaryIndexEntries (passed as a parameter so the caller can reuse it
+// between rows) and the resulting slice is returned. Since inverted indexes
+// can produce any number of entries, the result does not necessarily parallel
+// indexes.
 func EncodeSecondaryIndexes(
 	tableDesc *TableDescriptor,
 	indexes []IndexDescriptor,
 	colMap map[ColumnID]int,
 	values []parser.Datum,
 	secondaryIndexEntries []IndexEntry,
-) error {
+) ([]IndexEntry, error) {
 	for i := range indexes {
-		var err error
-		secondaryIndexEntries[i], err = EncodeSecondaryIndex(tableDesc, &indexes[i], colMap, values)
+		entries, err := EncodeSecondaryIndex(tableDesc, &indexes[i], colMap, values)
 		if err != nil {
-			return err
+			return secondaryIndexEntries, err
 		}
+		secondaryIndexEntries = append(secondaryIndexEntries, entries...)
 	}
-	return nil
+	return secondaryIndexEntries, nil
 }
 
 // CheckColumnType verifies that a given value is compatible
@@ -1650,6 +1802,11 @@ func MarshalColumnValue(col ColumnDescriptor, val parser.Datum) (roachpb.Value,
 			r.SetBytes(data)
 			return r, nil
 		}
+	case ColumnType_JSON:
+		if v, ok := val.(*parser.DJSON); ok {
+			r.SetString(v.JSON.String())
+			return r, nil
+		}
 	case ColumnType_ARRAY:
 		if v, ok := val.(*parser.DArray); ok {
 			if err := checkElementType(v.ParamTyp, col.Type); err != nil {
@@ -1922,6 +2079,16 @@ func UnmarshalColumnValue(
 			return nil, err
 		}
 		return a.NewDIPAddr(parser.DIPAddr{IPAddr: ipAddr}), nil
+	case ColumnType_JSON:
+		v, err := value.GetBytes()
+		if err != nil {
+			return nil, err
+		}
+		j, err := json.ParseJSON(string(v))
+		if err != nil {
+			return nil, err
+		}
+		return a.NewDJSON(parser.DJSON{JSON: j}), nil
 	case ColumnType_NAME:
 		v, err := value.GetBytes()
 		if err != nil {
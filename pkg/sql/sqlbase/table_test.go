@@ -200,11 +200,15 @@ func TestIndexKey(t *testing.T) {
 		primaryValue := roachpb.MakeValueFromBytes(nil)
 		primaryIndexKV := client.KeyValue{Key: primaryKey, Value: &primaryValue}
 
-		secondaryIndexEntry, err := EncodeSecondaryIndex(
+		secondaryIndexEntries, err := EncodeSecondaryIndex(
 			&tableDesc, &tableDesc.Indexes[0], colMap, testValues)
 		if err != nil {
 			t.Fatal(err)
 		}
+		if len(secondaryIndexEntries) != 1 {
+			t.Fatalf("expected 1 index entry, got %d", len(secondaryIndexEntries))
+		}
+		secondaryIndexEntry := secondaryIndexEntries[0]
 		secondaryIndexKV := client.KeyValue{
 			Key:   secondaryIndexEntry.Key,
 			Value: &secondaryIndexEntry.Value,
@@ -367,6 +371,55 @@ func BenchmarkArrayEncoding(b *testing.B) {
 	}
 }
 
+func TestEncodeInvertedIndexArrayKeys(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	prefix := []byte{1, 2}
+	intKey := func(i int64) []byte {
+		return encoding.EncodeVarintAscending(append([]byte(nil), prefix...), i)
+	}
+	array := func(elems ...parser.Datum) *parser.DArray {
+		return &parser.DArray{ParamTyp: parser.TypeInt, Array: elems, HasNulls: true}
+	}
+	one, two, three := parser.NewDInt(1), parser.NewDInt(2), parser.NewDInt(3)
+
+	tests := []struct {
+		datum    parser.Datum
+		expected [][]byte
+	}{
+		{parser.DNull, nil},
+		{array(), nil},
+		{array(parser.DNull), nil},
+		{array(one), [][]byte{intKey(1)}},
+		// Keys are sorted and deduplicated.
+		{array(three, one, parser.DNull, three, two), [][]byte{intKey(1), intKey(2), intKey(3)}},
+	}
+	for _, test := range tests {
+		keys, err := EncodeInvertedIndexTableKeys(test.datum, prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != len(test.expected) {
+			t.Fatalf("%s: expected %d keys, got %d", test.datum, len(test.expected), len(keys))
+		}
+		for i := range keys {
+			if !bytes.Equal(keys[i], test.expected[i]) {
+				t.Errorf("%s: expected key %d to be %v, got %v", test.datum, i, test.expected[i], keys[i])
+			}
+		}
+	}
+
+	key, ok, err := EncodeInvertedIndexContainmentKey(array(two, one), prefix)
+	if err != nil || !ok || !bytes.Equal(key, intKey(2)) {
+		t.Errorf("expected containment key %v, got %v (ok=%t, err=%v)", intKey(2), key, ok, err)
+	}
+	for _, d := range []parser.Datum{array(), array(parser.DNull, one)} {
+		if _, ok, err := EncodeInvertedIndexContainmentKey(d, prefix); err != nil || ok {
+			t.Errorf("%s: expected no containment key, got ok=%t, err=%v", d, ok, err)
+		}
+	}
+}
+
 func TestMarshalColumnValue(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
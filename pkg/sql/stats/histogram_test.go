@@ -0,0 +1,140 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stats
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+type expBucket struct {
+	upper    int
+	numEq    int64
+	numRange int64
+}
+
+func TestEquiDepthHistogram(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testCases := []struct {
+		samples    []int
+		numRows    int64
+		maxBuckets int
+		buckets    []expBucket
+	}{
+		{
+			samples:    []int{1, 2, 4, 5, 5, 9},
+			numRows:    6,
+			maxBuckets: 2,
+			buckets: []expBucket{
+				{upper: 4, numEq: 1, numRange: 2},
+				{upper: 9, numEq: 1, numRange: 2},
+			},
+		},
+		{
+			// Same as above, but the values are scaled up to the row count.
+			samples:    []int{1, 2, 4, 5, 5, 9},
+			numRows:    600,
+			maxBuckets: 2,
+			buckets: []expBucket{
+				{upper: 4, numEq: 100, numRange: 200},
+				{upper: 9, numEq: 100, numRange: 200},
+			},
+		},
+		{
+			// Buckets are extended to cover all the samples equal to the upper
+			// bound.
+			samples:    []int{5, 2, 3, 3, 1, 5, 3, 5, 4, 2},
+			numRows:    100,
+			maxBuckets: 3,
+			buckets: []expBucket{
+				{upper: 2, numEq: 20, numRange: 10},
+				{upper: 3, numEq: 30, numRange: 0},
+				{upper: 5, numEq: 30, numRange: 10},
+			},
+		},
+		{
+			// More buckets than samples.
+			samples:    []int{3, 1, 2},
+			numRows:    3,
+			maxBuckets: 10,
+			buckets: []expBucket{
+				{upper: 1, numEq: 1, numRange: 0},
+				{upper: 2, numEq: 1, numRange: 0},
+				{upper: 3, numEq: 1, numRange: 0},
+			},
+		},
+		{
+			samples:    []int{},
+			numRows:    0,
+			maxBuckets: 10,
+			buckets:    []expBucket{},
+		},
+	}
+
+	evalCtx := parser.MakeTestingEvalContext()
+	defer evalCtx.Stop(context.Background())
+
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			samples := make(parser.Datums, len(tc.samples))
+			for i := range samples {
+				samples[i] = parser.NewDInt(parser.DInt(tc.samples[i]))
+			}
+			h, err := EquiDepthHistogram(&evalCtx, samples, tc.numRows, tc.maxBuckets)
+			if err != nil {
+				t.Fatal(err)
+			}
+			buckets := make([]expBucket, len(h.Buckets))
+			for i, b := range h.Buckets {
+				upper, _, err := sqlbase.DecodeTableKey(
+					&sqlbase.DatumAlloc{}, parser.TypeInt, b.UpperBound, encoding.Ascending,
+				)
+				if err != nil {
+					t.Fatal(err)
+				}
+				buckets[i] = expBucket{
+					upper:    int(*upper.(*parser.DInt)),
+					numEq:    b.NumEq,
+					numRange: b.NumRange,
+				}
+			}
+			if !reflect.DeepEqual(buckets, tc.buckets) {
+				t.Errorf("expected buckets %v, got %v", tc.buckets, buckets)
+			}
+		})
+	}
+
+	t.Run("invalid-numRows", func(t *testing.T) {
+		samples := parser.Datums{parser.NewDInt(1), parser.NewDInt(2), parser.NewDInt(3)}
+		if _, err := EquiDepthHistogram(&evalCtx, samples, 2, 10); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+
+	t.Run("null", func(t *testing.T) {
+		samples := parser.Datums{parser.NewDInt(1), parser.DNull}
+		if _, err := EquiDepthHistogram(&evalCtx, samples, 2, 10); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
@@ -0,0 +1,78 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stats
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+// runSampleTest feeds the values of ranks (in order) as row values and ranks
+// to a reservoir of size numSamples and checks that the rows with the smallest
+// ranks are retained.
+func runSampleTest(t *testing.T, numSamples int, ranks []int) {
+	typeInt := sqlbase.ColumnType{SemanticType: sqlbase.ColumnType_INT}
+	var sr SampleReservoir
+	sr.Init(numSamples, []sqlbase.ColumnType{typeInt})
+	row := make(sqlbase.EncDatumRow, 1)
+	for _, r := range ranks {
+		// The row is reused for every value, the reservoir must copy it.
+		row[0] = sqlbase.DatumToEncDatum(typeInt, parser.NewDInt(parser.DInt(r)))
+		sr.SampleRow(row, uint64(r))
+	}
+	samples := sr.Get()
+	sampledValues := make([]int, len(samples))
+	for i, s := range samples {
+		if int(s.Rank) != int(*s.Row[0].Datum.(*parser.DInt)) {
+			t.Fatalf("sample row %s has rank %d", s.Row, s.Rank)
+		}
+		sampledValues[i] = int(s.Rank)
+	}
+	sort.Ints(sampledValues)
+
+	expected := append([]int(nil), ranks...)
+	sort.Ints(expected)
+	if len(expected) > numSamples {
+		expected = expected[:numSamples]
+	}
+
+	if len(sampledValues) != len(expected) {
+		t.Fatalf("expected %d samples, got %d", len(expected), len(sampledValues))
+	}
+	for i := range expected {
+		if sampledValues[i] != expected[i] {
+			t.Fatalf("expected samples %v, got %v", expected, sampledValues)
+		}
+	}
+}
+
+func TestSampleReservoir(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	for _, n := range []int{10, 100, 1000, 10000} {
+		rng := rand.New(rand.NewSource(int64(n)))
+		ranks := rng.Perm(n)
+		for _, k := range []int{1, 5, 10, 100} {
+			t.Run("", func(t *testing.T) {
+				runSampleTest(t, k, ranks)
+			})
+		}
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stats
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlutil"
+	"github.com/cockroachdb/cockroach/pkg/util/cache"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/pkg/errors"
+)
+
+// TableStatistic contains the statistic for a table or a set of columns of a
+// table, as stored in system.table_statistics.
+type TableStatistic struct {
+	// TableID is the ID of the table.
+	TableID sqlbase.ID
+	// StatisticID is an ID that uniquely identifies each statistic created for
+	// the table.
+	StatisticID uint64
+	// Name is the optional user-defined name of the statistic.
+	Name string
+	// ColumnIDs is the list of column IDs which the statistic is computed on.
+	ColumnIDs []sqlbase.ColumnID
+	// CreatedAt is the time at which the statistic was created.
+	CreatedAt time.Time
+	// RowCount is the total number of rows in the table.
+	RowCount uint64
+	// DistinctCount is the estimated number of distinct values of the columns
+	// in ColumnIDs.
+	DistinctCount uint64
+	// NullCount is the number of rows that have a NULL in any of the columns
+	// in ColumnIDs.
+	NullCount uint64
+	// Histogram is the histogram for the first column in ColumnIDs, if one
+	// was collected.
+	Histogram *HistogramData
+}
+
+// tableStatsRefreshInterval is the maximum amount of time the statistics of a
+// table are served from the cache before being read again. Statistics created
+// on the local node invalidate the cache immediately.
+const tableStatsRefreshInterval = time.Minute
+
+// cacheEntry is the value stored in the cache for each table.
+type cacheEntry struct {
+	stats     []*TableStatistic
+	fetchedAt time.Time
+}
+
+// A TableStatisticsCache contains the statistics for a bounded number of
+// tables, with the most recently created statistics first.
+type TableStatisticsCache struct {
+	// NB: This can't be a RWMutex for lookup because UnorderedCache.Get
+	// manipulates an internal LRU list.
+	mu struct {
+		syncutil.Mutex
+		cache *cache.UnorderedCache
+	}
+	db       *client.DB
+	executor sqlutil.InternalExecutor
+}
+
+// NewTableStatisticsCache creates a new TableStatisticsCache that can hold
+// statistics for up to cacheSize tables.
+func NewTableStatisticsCache(
+	cacheSize int, db *client.DB, executor sqlutil.InternalExecutor,
+) *TableStatisticsCache {
+	sc := &TableStatisticsCache{
+		db:       db,
+		executor: executor,
+	}
+	sc.mu.cache = cache.NewUnorderedCache(cache.Config{
+		Policy: cache.CacheLRU,
+		ShouldEvict: func(s int, key, value interface{}) bool {
+			return s > cacheSize
+		},
+	})
+	return sc
+}
+
+// GetTableStats looks up the statistics for the requested table ID in the
+// cache, and if they are missing or too old, retrieves them from the system
+// table. The statistics are ordered by creation time, most recent first.
+func (sc *TableStatisticsCache) GetTableStats(
+	ctx context.Context, tableID sqlbase.ID,
+) ([]*TableStatistic, error) {
+	sc.mu.Lock()
+	if v, ok := sc.mu.cache.Get(tableID); ok {
+		e := v.(*cacheEntry)
+		if timeutil.Since(e.fetchedAt) < tableStatsRefreshInterval {
+			sc.mu.Unlock()
+			return e.stats, nil
+		}
+	}
+	sc.mu.Unlock()
+
+	// Two concurrent lookups of the same table may both read the system table;
+	// this is harmless since they find the same statistics.
+	stats, err := sc.getTableStatsFromDB(ctx, tableID)
+	if err != nil {
+		return nil, err
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.mu.cache.Add(tableID, &cacheEntry{stats: stats, fetchedAt: timeutil.Now()})
+	return stats, nil
+}
+
+// InvalidateTableStats invalidates the cached statistics for the given table
+// ID.
+func (sc *TableStatisticsCache) InvalidateTableStats(ctx context.Context, tableID sqlbase.ID) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.mu.cache.Del(tableID)
+}
+
+const (
+	tableIDIndex = iota
+	statisticsIDIndex
+	nameIndex
+	columnIDsIndex
+	createdAtIndex
+	rowCountIndex
+	distinctCountIndex
+	nullCountIndex
+	histogramIndex
+	statsLen
+)
+
+// parseStats converts the given datums to a TableStatistic object.
+func parseStats(datums parser.Datums) (*TableStatistic, error) {
+	if datums.Len() != statsLen {
+		return nil, errors.Errorf("%d values returned from table statistics lookup. Expected %d",
+			datums.Len(), statsLen)
+	}
+
+	res := &TableStatistic{
+		TableID:       sqlbase.ID(*datums[tableIDIndex].(*parser.DInt)),
+		StatisticID:   uint64(*datums[statisticsIDIndex].(*parser.DInt)),
+		CreatedAt:     datums[createdAtIndex].(*parser.DTimestamp).Time,
+		RowCount:      uint64(*datums[rowCountIndex].(*parser.DInt)),
+		DistinctCount: uint64(*datums[distinctCountIndex].(*parser.DInt)),
+		NullCount:     uint64(*datums[nullCountIndex].(*parser.DInt)),
+	}
+	columnIDs := datums[columnIDsIndex].(*parser.DArray)
+	res.ColumnIDs = make([]sqlbase.ColumnID, len(columnIDs.Array))
+	for i, d := range columnIDs.Array {
+		res.ColumnIDs[i] = sqlbase.ColumnID(*d.(*parser.DInt))
+	}
+	if datums[nameIndex] != parser.DNull {
+		res.Name = string(*datums[nameIndex].(*parser.DString))
+	}
+	if datums[histogramIndex] != parser.DNull {
+		res.Histogram = &HistogramData{}
+		if err := res.Histogram.Unmarshal([]byte(*datums[histogramIndex].(*parser.DBytes))); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// getTableStatsFromDB retrieves the statistics in system.table_statistics
+// for the given table ID, most recent first.
+func (sc *TableStatisticsCache) getTableStatsFromDB(
+	ctx context.Context, tableID sqlbase.ID,
+) ([]*TableStatistic, error) {
+	const getTableStatisticsStmt = `
+SELECT "tableID", "statisticID", name, "columnIDs", "createdAt", "rowCount", "distinctCount", "nullCount", histogram
+FROM system.table_statistics
+WHERE "tableID" = $1
+ORDER BY "createdAt" DESC
+`
+	var rows []parser.Datums
+	if err := sc.db.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		var err error
+		rows, err = sc.executor.QueryRowsInTransaction(
+			ctx, "get-table-statistics", txn, getTableStatisticsStmt, tableID,
+		)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	var statsList []*TableStatistic
+	for _, row := range rows {
+		stat, err := parseStats(row)
+		if err != nil {
+			return nil, err
+		}
+		statsList = append(statsList, stat)
+	}
+
+	return statsList, nil
+}
@@ -143,6 +143,7 @@ func (ti *tableInserter) fkSpanCollector() sqlbase.FkSpanCollector {
 type tableUpdater struct {
 	ru         sqlbase.RowUpdater
 	autoCommit bool
+	evalCtx    *parser.EvalContext
 
 	// Set by init.
 	txn *client.Txn
@@ -167,9 +168,10 @@ func (tu *tableUpdater) row(
 	return tu.ru.UpdateRow(ctx, tu.b, oldValues, updateValues, traceKV)
 }
 
-func (tu *tableUpdater) finalize(ctx context.Context, _ bool) (*sqlbase.RowContainer, error) {
+func (tu *tableUpdater) finalize(ctx context.Context, traceKV bool) (*sqlbase.RowContainer, error) {
 	var err error
-	if tu.autoCommit {
+	cascades := tu.ru.HasPendingCascades()
+	if tu.autoCommit && !cascades {
 		// An auto-txn can commit the transaction with the batch. This is an
 		// optimization to avoid an extra round-trip to the transaction
 		// coordinator.
@@ -181,6 +183,11 @@ func (tu *tableUpdater) finalize(ctx context.Context, _ bool) (*sqlbase.RowConta
 	if err != nil {
 		return nil, sqlbase.ConvertBatchError(ctx, tu.ru.Helper.TableDesc, tu.b)
 	}
+	if cascades {
+		// The referencing rows are modified once the updates are visible; an
+		// auto-txn is committed by the executor afterwards.
+		return nil, tu.ru.RunCascades(ctx, tu.evalCtx, traceKV)
+	}
 	return nil, nil
 }
 
@@ -194,6 +201,43 @@ func (tu *tableUpdater) fkSpanCollector() sqlbase.FkSpanCollector {
 
 func (tu *tableUpdater) close(_ context.Context) {}
 
+// updatedRowColIDtoRowIndex returns a mapping from column IDs to positions in
+// the row formed by the values fetched by ru followed by the values it
+// updates, so that the updated columns map to their new values.
+func updatedRowColIDtoRowIndex(ru *sqlbase.RowUpdater) map[sqlbase.ColumnID]int {
+	m := make(map[sqlbase.ColumnID]int, len(ru.FetchColIDtoRowIndex))
+	for id, i := range ru.FetchColIDtoRowIndex {
+		m[id] = i
+	}
+	for i, col := range ru.UpdateCols {
+		m[col.ID] = len(ru.FetchCols) + i
+	}
+	return m
+}
+
+// evalComputedCols recomputes the computed columns of an updated row. The
+// computed columns are the last len(computeExprs) columns being updated, and
+// their values are stored at the end of updateValues. The expressions are
+// evaluated over the row formed by oldValues followed by updateValues, using
+// the mapping returned by updatedRowColIDtoRowIndex.
+func evalComputedCols(
+	evalCtx *parser.EvalContext,
+	computeExprs []parser.TypedExpr,
+	container *sqlbase.RowIndexedVarContainer,
+	oldValues, updateValues parser.Datums,
+) error {
+	container.CurSourceRow = append(append(container.CurSourceRow[:0], oldValues...), updateValues...)
+	numNonComputedCols := len(updateValues) - len(computeExprs)
+	for i, expr := range computeExprs {
+		d, err := expr.Eval(evalCtx)
+		if err != nil {
+			return err
+		}
+		updateValues[numNonComputedCols+i] = d
+	}
+	return nil
+}
+
 type tableUpsertEvaler interface {
 	expressionCarrier
 
@@ -236,11 +280,18 @@ type tableUpserter struct {
 	alloc         *sqlbase.DatumAlloc
 	mon           *mon.BytesMonitor
 	collectRows   bool
+	evalCtx       *parser.EvalContext
 
 	// These are set for ON CONFLICT DO UPDATE, but not for DO NOTHING
 	updateCols []sqlbase.ColumnDescriptor
 	evaler     tableUpsertEvaler
 
+	// computeExprs are the expressions of the computed columns, which are the
+	// last len(computeExprs) columns of updateCols. They are bound to
+	// computedColsContainer.
+	computeExprs          []parser.TypedExpr
+	computedColsContainer *sqlbase.RowIndexedVarContainer
+
 	// Set by init.
 	txn                   *client.Txn
 	fkTables              sqlbase.TableLookupsByID // for fk checks in update case
@@ -328,6 +379,9 @@ func (tu *tableUpserter) init(txn *client.Txn) error {
 		for i, updateCol := range tu.ru.UpdateCols {
 			tu.updateColIDtoRowIndex[updateCol.ID] = i
 		}
+		if len(tu.computeExprs) > 0 {
+			tu.computedColsContainer.Mapping = updatedRowColIDtoRowIndex(&tu.ru)
+		}
 	}
 
 	tu.insertRows.Init(
@@ -454,6 +508,14 @@ func (tu *tableUpserter) flush(
 				if err != nil {
 					return nil, err
 				}
+				if len(tu.computeExprs) > 0 {
+					updateValues = append(updateValues, make(parser.Datums, len(tu.computeExprs))...)
+					if err := evalComputedCols(
+						tu.evalCtx, tu.computeExprs, tu.computedColsContainer, existingValues, updateValues,
+					); err != nil {
+						return nil, err
+					}
+				}
 				updatedRow, err := tu.ru.UpdateRow(ctx, b, existingValues, updateValues, traceKV)
 				if err != nil {
 					return nil, err
@@ -468,7 +530,8 @@ func (tu *tableUpserter) flush(
 		}
 	}
 
-	if finalize && tu.autoCommit {
+	cascades := len(tu.updateCols) > 0 && tu.ru.HasPendingCascades()
+	if finalize && tu.autoCommit && !cascades {
 		// An auto-txn can commit the transaction with the batch. This is an
 		// optimization to avoid an extra round-trip to the transaction
 		// coordinator.
@@ -479,6 +542,11 @@ func (tu *tableUpserter) flush(
 	if err != nil {
 		return nil, sqlbase.ConvertBatchError(ctx, tableDesc, b)
 	}
+	if cascades {
+		if err := tu.ru.RunCascades(ctx, tu.evalCtx, traceKV); err != nil {
+			return nil, err
+		}
+	}
 	return tu.rowsUpserted, nil
 }
 
@@ -511,11 +579,14 @@ func (tu *tableUpserter) upsertRowPKs(ctx context.Context, traceKV bool) ([]roac
 	b := tu.txn.NewBatch()
 	for i := 0; i < tu.insertRows.Len(); i++ {
 		insertRow := tu.insertRows.At(i)
-		entry, err := sqlbase.EncodeSecondaryIndex(
+		// The conflict index is unique and thus a forward index, so it always
+		// produces exactly one entry.
+		entries, err := sqlbase.EncodeSecondaryIndex(
 			tableDesc, &tu.conflictIndex, tu.ri.InsertColIDtoRowIndex, insertRow)
 		if err != nil {
 			return nil, err
 		}
+		entry := entries[0]
 		if traceKV {
 			log.VEventf(ctx, 2, "Get %s", entry.Key)
 		}
@@ -653,6 +724,7 @@ type tableDeleter struct {
 	rd         sqlbase.RowDeleter
 	autoCommit bool
 	alloc      *sqlbase.DatumAlloc
+	evalCtx    *parser.EvalContext
 
 	// Set by init.
 	txn *client.Txn
@@ -673,14 +745,23 @@ func (td *tableDeleter) row(
 	return nil, td.rd.DeleteRow(ctx, td.b, values, traceKV)
 }
 
-func (td *tableDeleter) finalize(ctx context.Context, _ bool) (*sqlbase.RowContainer, error) {
-	if td.autoCommit {
+func (td *tableDeleter) finalize(ctx context.Context, traceKV bool) (*sqlbase.RowContainer, error) {
+	cascades := td.rd.HasPendingCascades()
+	if td.autoCommit && !cascades {
 		// An auto-txn can commit the transaction with the batch. This is an
 		// optimization to avoid an extra round-trip to the transaction
 		// coordinator.
 		return nil, td.txn.CommitInBatch(ctx, td.b)
 	}
-	return nil, td.txn.Run(ctx, td.b)
+	if err := td.txn.Run(ctx, td.b); err != nil {
+		return nil, err
+	}
+	if cascades {
+		// The referencing rows are modified once the deletions are visible; an
+		// auto-txn is committed by the executor afterwards.
+		return nil, td.rd.RunCascades(ctx, td.evalCtx, traceKV)
+	}
+	return nil, nil
 }
 
 // fastPathAvailable returns true if the fastDelete optimization can be used.
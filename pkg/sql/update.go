@@ -52,13 +52,17 @@ func (p *planner) makeEditNode(
 	if err != nil {
 		return editNodeBase{}, err
 	}
-	// We don't support update on views, only real tables.
+	// We don't support update on views or sequences, only real tables.
+	if tableDesc.IsSequence() {
+		return editNodeBase{},
+			errors.Errorf("cannot run %s on sequence %q - sequences are not updateable", priv, tn)
+	}
 	if !tableDesc.IsTable() {
 		return editNodeBase{},
 			errors.Errorf("cannot run %s on view %q - views are not updateable", priv, tn)
 	}
 
-	if err := p.CheckPrivilege(tableDesc, priv); err != nil {
+	if err := p.CheckPrivilege(ctx, tableDesc, priv); err != nil {
 		return editNodeBase{}, err
 	}
 
@@ -118,6 +122,12 @@ type updateNode struct {
 	checkHelper   checkHelper
 	sourceSlots   []sourceSlot
 
+	// computeExprs are the expressions of the computed columns, which are the
+	// last len(computeExprs) columns of updateCols. They are bound to
+	// computedColsContainer.
+	computeExprs          []parser.TypedExpr
+	computedColsContainer *sqlbase.RowIndexedVarContainer
+
 	run struct {
 		// The following fields are populated during Start().
 		editNodeRun
@@ -205,6 +215,14 @@ func addOrMergeExpr(
 func (p *planner) Update(
 	ctx context.Context, n *parser.Update, desiredTypes []parser.Type,
 ) (planNode, error) {
+	if n.With != nil {
+		return p.planWith(ctx, n.With, func() (planNode, error) {
+			stmt := *n
+			stmt.With = nil
+			return p.Update(ctx, &stmt, desiredTypes)
+		})
+	}
+
 	if n.Where == nil && p.session.SafeUpdates {
 		return nil, pgerror.NewDangerousStatementErrorf("UPDATE without WHERE clause")
 	}
@@ -247,15 +265,20 @@ func (p *planner) Update(
 		return nil, err
 	}
 
+	// The computed columns are recomputed from the updated rows, which
+	// requires fetching all the columns they can refer to.
+	updateCols, computedCols := sqlbase.ProcessComputedColumns(updateCols, en.tableDesc)
+
 	var requestedCols []sqlbase.ColumnDescriptor
-	if _, retExprs := n.Returning.(*parser.ReturningExprs); retExprs || len(en.tableDesc.Checks) > 0 {
+	if _, retExprs := n.Returning.(*parser.ReturningExprs); retExprs || len(en.tableDesc.Checks) > 0 ||
+		len(computedCols) > 0 {
 		// TODO(dan): This could be made tighter, just the rows needed for RETURNING
 		// exprs.
 		requestedCols = en.tableDesc.Columns
 	}
 
-	fkTables := sqlbase.TablesNeededForFKs(*en.tableDesc, sqlbase.CheckUpdates)
-	if err := p.fillFKTableMap(ctx, fkTables); err != nil {
+	fkTables, err := sqlbase.TablesNeededForFKs(ctx, *en.tableDesc, sqlbase.CheckUpdates, p.lookupFKTable)
+	if err != nil {
 		return nil, err
 	}
 	ru, err := sqlbase.MakeRowUpdater(p.txn, en.tableDesc, fkTables, updateCols,
@@ -263,7 +286,18 @@ func (p *planner) Update(
 	if err != nil {
 		return nil, err
 	}
-	tw := tableUpdater{ru: ru, autoCommit: p.autoCommit}
+	tw := tableUpdater{ru: ru, autoCommit: p.autoCommit, evalCtx: &p.evalCtx}
+
+	computedColsContainer := &sqlbase.RowIndexedVarContainer{
+		Cols:    en.tableDesc.Columns,
+		Mapping: updatedRowColIDtoRowIndex(&ru),
+	}
+	computeExprs, err := sqlbase.MakeComputedExprs(
+		computedCols, computedColsContainer, &p.parser, &p.evalCtx,
+	)
+	if err != nil {
+		return nil, err
+	}
 
 	tracing.AnnotateTrace()
 
@@ -369,6 +403,9 @@ func (p *planner) Update(
 		updateColsIdx: updateColsIdx,
 		tw:            tw,
 		sourceSlots:   sourceSlots,
+
+		computeExprs:          computeExprs,
+		computedColsContainer: computedColsContainer,
 	}
 	if err := un.checkHelper.init(ctx, p, tn, en.tableDesc); err != nil {
 		return nil, err
@@ -425,6 +462,14 @@ func (u *updateNode) Next(params runParams) (bool, error) {
 		}
 	}
 
+	if len(u.computeExprs) > 0 {
+		if err := evalComputedCols(
+			&params.p.evalCtx, u.computeExprs, u.computedColsContainer, oldValues, updateValues,
+		); err != nil {
+			return false, err
+		}
+	}
+
 	if err := u.checkHelper.loadRow(u.tw.ru.FetchColIDtoRowIndex, oldValues, false); err != nil {
 		return false, err
 	}
@@ -444,7 +489,7 @@ func (u *updateNode) Next(params runParams) (bool, error) {
 
 	for i, col := range u.tw.ru.UpdateCols {
 		val := updateValues[i]
-		if !col.Nullable && val == parser.DNull {
+		if (!col.Nullable || u.tableDesc.HasPendingNotNull(col.ID)) && val == parser.DNull {
 			return false, sqlbase.NewNonNullViolationError(col.Name)
 		}
 	}
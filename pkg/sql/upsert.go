@@ -215,6 +215,11 @@ func upsertExprsAndIndex(
 		}
 		updateExprs := make(parser.UpdateExprs, 0, len(insertCols))
 		for _, c := range insertCols {
+			// Computed columns are recomputed from the updated row rather than
+			// assigned.
+			if c.IsComputed() {
+				continue
+			}
 			if _, ok := indexColSet[c.ID]; !ok {
 				names := parser.UnresolvedNames{
 					parser.UnresolvedName{parser.Name(c.Name)},
@@ -40,7 +40,7 @@ func GetUserHashedPassword(
 		p := makeInternalPlanner("get-pwd", txn, security.RootUser, metrics)
 		defer finishInternalPlanner(p)
 		const getHashedPassword = `SELECT "hashedPassword" FROM system.users ` +
-			`WHERE username=$1`
+			`WHERE username=$1 AND "isRole" = false`
 		values, err := p.QueryRow(ctx, getHashedPassword, normalizedUsername)
 		if err != nil {
 			return errors.Errorf("error looking up user %s", normalizedUsername)
@@ -55,3 +55,22 @@ func GetUserHashedPassword(
 
 	return exists, hashedPassword, err
 }
+
+// lookupUserOrRole returns whether the given normalized name is a user or a
+// role in system.users, and whether it is a role. The root user is not in
+// system.users but always exists.
+func (p *planner) lookupUserOrRole(
+	ctx context.Context, normalizedName string,
+) (exists bool, isRole bool, err error) {
+	if normalizedName == security.RootUser {
+		return true, false, nil
+	}
+	internalExecutor := InternalExecutor{LeaseManager: p.LeaseMgr()}
+	values, err := internalExecutor.QueryRowInTransaction(
+		ctx, "lookup-user", p.txn,
+		`SELECT "isRole" FROM system.users WHERE username = $1`, normalizedName)
+	if err != nil || values == nil {
+		return false, false, err
+	}
+	return true, bool(*values[0].(*parser.DBool)), nil
+}
@@ -150,6 +150,10 @@ func (v *planVisitor) visit(plan planNode) {
 			if n.hardLimit > 0 && isFilterTrue(n.filter) {
 				v.observer.attr(name, "limit", fmt.Sprintf("%d", n.hardLimit))
 			}
+			if n.stats != nil {
+				v.observer.attr(name, "estimated rows", fmt.Sprintf("%.0f", n.estimatedRowCount))
+				v.observer.attr(name, "estimated cost", fmt.Sprintf("%.0f", n.estimatedCost))
+			}
 		}
 		subplans := v.expr(name, "filter", -1, n.filter, nil)
 		v.subqueries(name, subplans)
@@ -210,6 +214,13 @@ func (v *planVisitor) visit(plan planNode) {
 				}
 				v.observer.attr(name, "mergeJoinOrder", order.AsString(eqCols))
 			}
+			if rows, ok := n.estimateRowCount(false /* useDefaults */); ok {
+				v.observer.attr(name, "estimated rows", fmt.Sprintf("%.0f", rows))
+			}
+			// The costs of the algorithms that can be used for the join.
+			if costs, ok := n.estimateAlgorithmCosts(false /* useDefaults */); ok {
+				v.observer.attr(name, "estimated cost", costs.String())
+			}
 		}
 		subplans := v.expr(name, "pred", -1, n.pred.onCond, nil)
 		v.subqueries(name, subplans)
@@ -287,6 +298,30 @@ func (v *planVisitor) visit(plan planNode) {
 		v.visit(n.left)
 		v.visit(n.right)
 
+	case *withNode:
+		for _, cte := range n.ctes {
+			if v.observer.attr != nil {
+				v.observer.attr(name, "cte", string(cte.name))
+			}
+			if cte.plan != nil {
+				v.visit(cte.plan)
+			}
+		}
+		v.visit(n.plan)
+
+	case *cteScanNode:
+		if v.observer.attr != nil {
+			v.observer.attr(name, "source", string(n.source.name))
+		}
+
+	case *recursiveCTENode:
+		if n.initial != nil {
+			v.visit(n.initial)
+		}
+		if n.recursive != nil {
+			v.visit(n.recursive)
+		}
+
 	case *splitNode:
 		v.visit(n.rows)
 
@@ -524,14 +559,19 @@ var planNodeNames = map[reflect.Type]string{
 	reflect.TypeOf(&copyNode{}):              "copy",
 	reflect.TypeOf(&createDatabaseNode{}):    "create database",
 	reflect.TypeOf(&createIndexNode{}):       "create index",
+	reflect.TypeOf(&createSequenceNode{}):    "create sequence",
+	reflect.TypeOf(&createStatsNode{}):       "create statistics",
+	reflect.TypeOf(&createChangefeedNode{}):  "create changefeed",
 	reflect.TypeOf(&createTableNode{}):       "create table",
 	reflect.TypeOf(&createUserNode{}):        "create user",
 	reflect.TypeOf(&createViewNode{}):        "create view",
+	reflect.TypeOf(&cteScanNode{}):           "cte scan",
 	reflect.TypeOf(&delayedNode{}):           "virtual table",
 	reflect.TypeOf(&deleteNode{}):            "delete",
 	reflect.TypeOf(&distinctNode{}):          "distinct",
 	reflect.TypeOf(&dropDatabaseNode{}):      "drop database",
 	reflect.TypeOf(&dropIndexNode{}):         "drop index",
+	reflect.TypeOf(&dropSequenceNode{}):      "drop sequence",
 	reflect.TypeOf(&dropTableNode{}):         "drop table",
 	reflect.TypeOf(&dropViewNode{}):          "drop view",
 	reflect.TypeOf(&dropUserNode{}):          "drop user",
@@ -547,12 +587,14 @@ var planNodeNames = map[reflect.Type]string{
 	reflect.TypeOf(&joinNode{}):              "join",
 	reflect.TypeOf(&limitNode{}):             "limit",
 	reflect.TypeOf(&ordinalityNode{}):        "ordinality",
+	reflect.TypeOf(&recursiveCTENode{}):      "recursive cte",
 	reflect.TypeOf(&testingRelocateNode{}):   "testingRelocate",
 	reflect.TypeOf(&renderNode{}):            "render",
 	reflect.TypeOf(&scanNode{}):              "scan",
 	reflect.TypeOf(&scatterNode{}):           "scatter",
 	reflect.TypeOf(&setNode{}):               "set",
 	reflect.TypeOf(&setClusterSettingNode{}): "set cluster setting",
+	reflect.TypeOf(&setZoneConfigNode{}):     "configure zone",
 	reflect.TypeOf(&showRangesNode{}):        "showRanges",
 	reflect.TypeOf(&showFingerprintsNode{}):  "showFingerprints",
 	reflect.TypeOf(&sortNode{}):              "sort",
@@ -562,5 +604,6 @@ var planNodeNames = map[reflect.Type]string{
 	reflect.TypeOf(&valueGenerator{}):        "generator",
 	reflect.TypeOf(&valuesNode{}):            "values",
 	reflect.TypeOf(&windowNode{}):            "window",
+	reflect.TypeOf(&withNode{}):              "with",
 	reflect.TypeOf(&zeroNode{}):              "norows",
 }
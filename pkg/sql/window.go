@@ -24,7 +24,9 @@ import (
 	"golang.org/x/net/context"
 
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/encoding"
 )
 
@@ -32,7 +34,8 @@ import (
 // adjust the render targets in the renderNode as necessary. The use of window functions
 // will run with a space complexity of O(NW) (N = number of rows, W = number of windows)
 // and a time complexity of O(NW) (no ordering), O(W*NlogN) (with ordering), and
-// O(W*N^2) (with constant or variable sized window-frames, which are not yet supported).
+// O(W*N^2) (with window frames whose start moves forward, when the aggregation
+// cannot remove rows leaving the frame).
 //
 // This code uses the following terminology throughout:
 // - window:
@@ -185,6 +188,7 @@ func (n *windowNode) constructWindowDefinitions(
 		}
 
 		// Validate ORDER BY clause.
+		var orderByTypes []parser.Type
 		for _, orderBy := range windowDef.OrderBy {
 			cols, exprs, _, err := s.planner.computeRenderAllowingStars(ctx,
 				parser.SelectExpr{Expr: orderBy.Expr}, parser.TypeAny, s.sourceInfo, s.ivarHelper,
@@ -198,6 +202,9 @@ func (n *windowNode) constructWindowDefinitions(
 				direction = encoding.Descending
 			}
 
+			for _, expr := range exprs {
+				orderByTypes = append(orderByTypes, expr.ResolvedType())
+			}
 			colIdxs := s.addOrReuseRenders(cols, exprs, true)
 			for _, idx := range colIdxs {
 				ordering := sqlbase.ColumnOrderInfo{
@@ -208,6 +215,20 @@ func (n *windowNode) constructWindowDefinitions(
 			}
 		}
 
+		// Validate frame clause.
+		if frame := windowDef.Frame; frame != nil {
+			windowFn.frameStartExpr, err = s.planner.analyzeWindowFrameOffset(
+				ctx, frame, frame.Bounds.StartBound, orderByTypes)
+			if err != nil {
+				return err
+			}
+			windowFn.frameEndExpr, err = s.planner.analyzeWindowFrameOffset(
+				ctx, frame, frame.Bounds.EndBound, orderByTypes)
+			if err != nil {
+				return err
+			}
+		}
+
 		windowFn.windowDef = windowDef
 	}
 	return nil
@@ -259,9 +280,92 @@ func constructWindowDef(
 		}
 		def.OrderBy = referencedSpec.OrderBy
 	}
+
+	// referencedSpec.Frame cannot be copied.
+	if referencedSpec.Frame != nil {
+		return def, errors.Errorf("cannot copy window %q because it has a frame clause", refName)
+	}
 	return def, nil
 }
 
+// analyzeWindowFrameOffset analyzes the offset of a bound of a window frame, if
+// the bound has one. Like LIMIT and OFFSET, the offset is evaluated once and
+// cannot refer to the rows of the window. The offsets of a ROWS frame are
+// numbers of rows, while those of a RANGE frame are added to or subtracted from
+// the value of its single ORDER BY column, whose types are given.
+func (p *planner) analyzeWindowFrameOffset(
+	ctx context.Context,
+	frame *parser.WindowFrame,
+	bound *parser.WindowFrameBound,
+	orderByTypes []parser.Type,
+) (parser.TypedExpr, error) {
+	if bound == nil || bound.OffsetExpr == nil {
+		return nil, nil
+	}
+
+	required := parser.Type(parser.TypeInt)
+	if frame.Mode == parser.RangeMode {
+		if len(orderByTypes) != 1 {
+			return nil, pgerror.NewError(pgerror.CodeWindowingError,
+				"RANGE with offset PRECEDING/FOLLOWING requires exactly one ORDER BY column")
+		}
+		switch typ := orderByTypes[0]; {
+		case typ.Equivalent(parser.TypeInt), typ.Equivalent(parser.TypeFloat),
+			typ.Equivalent(parser.TypeDecimal):
+			required = typ
+		case typ.Equivalent(parser.TypeTimestamp), typ.Equivalent(parser.TypeTimestampTZ),
+			typ.Equivalent(parser.TypeInterval):
+			required = parser.TypeInterval
+		default:
+			return nil, pgerror.NewErrorf(pgerror.CodeWindowingError,
+				"RANGE with offset PRECEDING/FOLLOWING is not supported for column type %s", typ)
+		}
+	}
+
+	name := fmt.Sprintf("window %s", frame.Mode)
+	if err := p.parser.AssertNoAggregationOrWindowing(
+		bound.OffsetExpr, name, p.session.SearchPath,
+	); err != nil {
+		return nil, err
+	}
+	return p.analyzeExpr(ctx, bound.OffsetExpr, nil, parser.IndexedVarHelper{},
+		required, true, fmt.Sprintf("argument of %s", frame.Mode))
+}
+
+// evalWindowFrameOffset evaluates the offset of a bound of a window frame, if
+// the bound has one.
+func evalWindowFrameOffset(
+	evalCtx *parser.EvalContext, expr parser.TypedExpr, which string,
+) (parser.Datum, error) {
+	if expr == nil {
+		return nil, nil
+	}
+	offset, err := expr.Eval(evalCtx)
+	if err != nil {
+		return nil, err
+	}
+	negative := false
+	switch t := offset.(type) {
+	case *parser.DInt:
+		negative = *t < 0
+	case *parser.DFloat:
+		negative = *t < 0
+	case *parser.DDecimal:
+		negative = t.Sign() < 0
+	case *parser.DInterval:
+		negative = t.Duration.Compare(duration.Duration{}) < 0
+	}
+	switch {
+	case offset == parser.DNull:
+		return nil, pgerror.NewErrorf(pgerror.CodeNullValueNotAllowedError,
+			"frame %s offset must not be null", which)
+	case negative:
+		return nil, pgerror.NewErrorf(pgerror.CodeInvalidParameterValueError,
+			"frame %s offset must not be negative", which)
+	}
+	return offset, nil
+}
+
 // Once the extractWindowFunctions has been run over each render, the remaining
 // render expressions will either be nil or contain an expression. If one is nil,
 // that means the render will not be touched by windowNode, and will be passed on
@@ -370,8 +474,11 @@ func (n *windowNode) replaceIndexVarsAndAggFuncs(s *renderNode) {
 						return nil, false, iVar
 					}
 
-					// Create a new IndexedVar with the next available index.
-					idx := len(n.aggContainer.idxMap)
+					// Create a new IndexedVar with the next available index. The
+					// indexes follow those of the column IndexedVars, so that the
+					// IndexedVars of both containers can be told apart (see
+					// distSQLPlanner.addWindowers).
+					idx := ivarHelper.NumVars() + len(n.aggContainer.idxMap)
 					aggIVar := parser.NewIndexedVar(idx)
 					aggIVars[colIdx] = aggIVar
 					n.aggContainer.idxMap[idx] = colIdx
@@ -394,7 +501,7 @@ func (n *windowNode) replaceIndexVarsAndAggFuncs(s *renderNode) {
 		// Now that we know how many aggregate functions there were, we can create
 		// an IndexedVarHelper and bind each of the corresponding IndexedVars to
 		// the helper.
-		aggHelper := parser.MakeIndexedVarHelper(&n.aggContainer, len(aggIVars))
+		aggHelper := parser.MakeIndexedVarHelper(&n.aggContainer, ivarHelper.NumVars()+len(aggIVars))
 		for _, ivar := range aggIVars {
 			// The ivars above have been created with a nil container, and
 			// therefore they are guaranteed to be modified in-place by
@@ -578,6 +685,17 @@ func (n *windowNode) computeWindows(ctx context.Context) error {
 	for windowIdx, windowFn := range n.funcs {
 		partitions := make(map[string][]parser.IndexedRow)
 
+		startBoundOffset, err := evalWindowFrameOffset(
+			&n.planner.evalCtx, windowFn.frameStartExpr, "starting")
+		if err != nil {
+			return err
+		}
+		endBoundOffset, err := evalWindowFrameOffset(
+			&n.planner.evalCtx, windowFn.frameEndExpr, "ending")
+		if err != nil {
+			return err
+		}
+
 		if len(windowFn.partitionIdxs) == 0 {
 			// If no partition indexes are included for the window function, all
 			// rows are added to the same partition, which need to be pre-allocated.
@@ -606,8 +724,10 @@ func (n *windowNode) computeWindows(ctx context.Context) error {
 		// See Cao et al. [http://vldb.org/pvldb/vol5/p1244_yucao_vldb2012.pdf]
 		for rowI := 0; rowI < rowCount; rowI++ {
 			row := n.wrappedRenderVals.At(rowI)
-			sourceVals := row[:n.sourceCols]
-			entry := parser.IndexedRow{Idx: rowI, Row: sourceVals}
+			// The entry includes the PARTITION BY and ORDER BY columns following
+			// the source columns, so that window frames can access the ordering
+			// values.
+			entry := parser.IndexedRow{Idx: rowI, Row: row}
 			if len(windowFn.partitionIdxs) == 0 {
 				// If no partition indexes are included for the window function, all
 				// rows are added to the same partition.
@@ -644,18 +764,17 @@ func (n *windowNode) computeWindows(ctx context.Context) error {
 		//   * Segment Tree
 		// See Leis et al. [http://www.vldb.org/pvldb/vol8/p1058-leis.pdf]
 		for _, partition := range partitions {
-			// TODO(nvanbenschoten): Handle framing here. Right now we only handle the default
-			// framing option of RANGE UNBOUNDED PRECEDING. With ORDER BY, this sets the frame
-			// to be all rows from the partition start up through the current row's last ORDER BY
-			// peer. Without ORDER BY, all rows of the partition are included in the window frame,
-			// since all rows become peers of the current row. Once we add better framing support,
-			// we should flesh this logic out more.
+			// Without a frame clause, the default framing option of RANGE UNBOUNDED
+			// PRECEDING is used. With ORDER BY, this sets the frame to be all rows from
+			// the partition start up through the current row's last ORDER BY peer.
+			// Without ORDER BY, all rows of the partition are included in the window
+			// frame, since all rows become peers of the current row. Other frames are
+			// computed by the window function from the peer groups and the offsets.
 			builtin := windowFn.expr.GetWindowConstructor()(&n.planner.evalCtx)
 			defer builtin.Close(ctx, &n.planner.evalCtx)
 
-			// Since we only support two types of window frames (see TODO above), we only
-			// need two possible types of peerGroupChecker's to help determine peer groups
-			// for given tuples.
+			// We only need two possible types of peerGroupChecker's to help determine
+			// peer groups for given tuples.
 			var peerGrouper peerGroupChecker
 			if windowFn.columnOrdering != nil {
 				// If an ORDER BY clause is provided, order the partition and use the
@@ -680,11 +799,18 @@ func (n *windowNode) computeWindows(ctx context.Context) error {
 			}
 
 			// Iterate over peer groups within partition using a window frame.
-			frame := parser.WindowFrame{
-				Rows:        partition,
-				ArgIdxStart: windowFn.argIdxStart,
-				ArgCount:    windowFn.argCount,
-				RowIdx:      0,
+			frame := &parser.WindowFrameRun{
+				Rows:             partition,
+				ArgIdxStart:      windowFn.argIdxStart,
+				ArgCount:         windowFn.argCount,
+				Frame:            windowFn.windowDef.Frame,
+				StartBoundOffset: startBoundOffset,
+				EndBoundOffset:   endBoundOffset,
+				RowIdx:           0,
+			}
+			if len(windowFn.columnOrdering) > 0 {
+				frame.OrdColIdx = windowFn.columnOrdering[0].ColIdx
+				frame.OrdDescending = windowFn.columnOrdering[0].Direction == encoding.Descending
 			}
 			for frame.RowIdx < len(partition) {
 				// Compute the size of the current peer group.
@@ -903,6 +1029,11 @@ type windowFuncHolder struct {
 	windowDef      parser.WindowDef
 	partitionIdxs  []int
 	columnOrdering sqlbase.ColumnOrdering
+
+	// frameStartExpr and frameEndExpr are the offsets of the bounds of the
+	// window frame, if any.
+	frameStartExpr parser.TypedExpr
+	frameEndExpr   parser.TypedExpr
 }
 
 func (*windowFuncHolder) Variable() {}
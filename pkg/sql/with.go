@@ -0,0 +1,653 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+// This file implements common table expressions (CTEs), that is the
+// WITH clause that can prefix SELECT, INSERT, UPSERT, UPDATE and
+// DELETE statements:
+//
+//   WITH [RECURSIVE] name [(col, ...)] AS (stmt), ... statement
+//
+// The CTEs are planned in order in a new scope of the planner's
+// cteNameEnvironment, so that each of them can be referred to by name
+// in the CTEs that follow and in the statement itself. The resulting
+// withNode evaluates every CTE exactly once, before the statement,
+// regardless of how many times it is referenced (possibly zero: this
+// ensures that data-modifying CTEs always run to completion). The
+// results are stored in memory and served to every reference by a
+// cteScanNode.
+//
+// A recursive CTE must have the form:
+//
+//   non-recursive-term UNION [ALL] recursive-term
+//
+// where only the recursive term refers to the CTE itself. It is
+// evaluated by a recursiveCTENode, which re-plans and runs the
+// recursive term against the rows produced by the previous iteration
+// (the "working table") until no new rows are produced.
+
+// cteSource holds the plan and the materialized results of a single
+// CTE.
+type cteSource struct {
+	name    parser.Name
+	columns sqlbase.ResultColumns
+
+	// plan computes the rows of the CTE. It is nil for the working table
+	// of a recursive CTE, whose rows are provided by its
+	// recursiveCTENode.
+	plan planNode
+
+	// rows holds the results of plan once materialized.
+	rows         *sqlbase.RowContainer
+	materialized bool
+
+	// recursive is set for the working table of a recursive CTE.
+	// referenced indicates whether the working table is used by the
+	// recursive term.
+	recursive  bool
+	referenced bool
+
+	// invalidRefErr, if set, is reported when the source is referenced.
+	// This is used to reject self-references in the parts of a recursive
+	// CTE where they are not allowed.
+	invalidRefErr error
+}
+
+// materialize runs the CTE's plan to completion and stores the
+// results. It is idempotent, so that it can be invoked both when the
+// CTE is first referenced (possibly during the evaluation of a
+// sub-query) and by the withNode that owns it.
+func (s *cteSource) materialize(params runParams) error {
+	if s.materialized {
+		return nil
+	}
+	s.materialized = true
+
+	if err := s.plan.Start(params); err != nil {
+		return err
+	}
+	s.rows = sqlbase.NewRowContainer(
+		params.p.session.TxnState.makeBoundAccount(), sqlbase.ColTypeInfoFromResCols(s.columns), 0,
+	)
+	if a, ok := s.plan.(planNodeFastPath); ok {
+		if _, res := a.FastPathResults(); res {
+			return nil
+		}
+	}
+	next, err := s.plan.Next(params)
+	for ; next; next, err = s.plan.Next(params) {
+		if len(s.columns) == 0 {
+			// Data-modifying statement without RETURNING clause: there
+			// is nothing to store, but all the rows must be processed.
+			continue
+		}
+		if _, err := s.rows.AddRow(params.ctx, s.plan.Values()); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+func (s *cteSource) close(ctx context.Context) {
+	if s.plan != nil {
+		s.plan.Close(ctx)
+		s.plan = nil
+	}
+	if s.rows != nil {
+		s.rows.Close(ctx)
+		s.rows = nil
+	}
+}
+
+// cteNameScope maps the names defined by a single WITH clause to
+// their sources.
+type cteNameScope map[parser.Name]*cteSource
+
+// cteNameEnvironment is the stack of WITH scopes visible to the
+// statement being planned. The innermost scope comes last.
+type cteNameEnvironment []cteNameScope
+
+// push returns a new environment extended with the given scope. The
+// receiver is not modified, so that it can be captured safely.
+func (e cteNameEnvironment) push(scope cteNameScope) cteNameEnvironment {
+	res := make(cteNameEnvironment, len(e), len(e)+1)
+	copy(res, e)
+	return append(res, scope)
+}
+
+// lookup returns the innermost source with the given name, or nil if
+// there is none.
+func (e cteNameEnvironment) lookup(name parser.Name) *cteSource {
+	for i := len(e) - 1; i >= 0; i-- {
+		if src, ok := e[i][name]; ok {
+			return src
+		}
+	}
+	return nil
+}
+
+// planWith plans a statement prefixed by a WITH clause. planStmt is
+// invoked to plan the statement itself, once all the CTEs are visible.
+func (p *planner) planWith(
+	ctx context.Context, with *parser.With, planStmt func() (planNode, error),
+) (planNode, error) {
+	if p.planDeps != nil {
+		// The CTE names would be mistaken for table names when the view
+		// query is qualified and stored.
+		return nil, pgerror.Unimplemented("view cte", "views do not currently support CTEs")
+	}
+
+	// A data-modifying CTE must not commit the transaction ahead of the
+	// other parts of the statement.
+	defer func(prev bool) { p.autoCommit = prev }(p.autoCommit)
+	p.autoCommit = false
+
+	scope := make(cteNameScope, len(with.CTEList))
+	defer func(prev cteNameEnvironment) { p.cteNameEnvironment = prev }(p.cteNameEnvironment)
+	p.cteNameEnvironment = p.cteNameEnvironment.push(scope)
+
+	n := &withNode{}
+	for _, cte := range with.CTEList {
+		name := cte.Name.Alias
+		if _, ok := scope[name]; ok {
+			n.Close(ctx)
+			return nil, pgerror.NewErrorf(pgerror.CodeDuplicateAliasError,
+				"WITH query name %q specified more than once", parser.ErrString(name))
+		}
+		src, err := p.planCTE(ctx, scope, cte, with.Recursive)
+		if err != nil {
+			n.Close(ctx)
+			return nil, err
+		}
+		scope[name] = src
+		n.ctes = append(n.ctes, src)
+	}
+
+	plan, err := planStmt()
+	if err != nil {
+		n.Close(ctx)
+		return nil, err
+	}
+	n.plan = plan
+	return n, nil
+}
+
+// planCTE plans a single CTE. scope is the innermost scope of the
+// name environment, which receives the CTE once planned.
+func (p *planner) planCTE(
+	ctx context.Context, scope cteNameScope, cte *parser.CTE, recursive bool,
+) (*cteSource, error) {
+	switch cte.Stmt.(type) {
+	case *parser.Select, *parser.Insert, *parser.Update, *parser.Delete:
+	default:
+		return nil, pgerror.NewErrorf(pgerror.CodeFeatureNotSupportedError,
+			"%s is not supported inside a WITH clause", cte.Stmt.StatementTag())
+	}
+
+	name := cte.Name.Alias
+	if recursive {
+		if union, ok := getRecursiveUnion(cte.Stmt); ok {
+			return p.planRecursiveCTE(ctx, scope, cte, union)
+		}
+		scope[name] = &cteSource{
+			name: name,
+			invalidRefErr: pgerror.NewErrorf(pgerror.CodeInvalidRecursionError,
+				"recursive query %q does not have the form non-recursive-term UNION [ALL] recursive-term",
+				parser.ErrString(name)),
+		}
+		defer delete(scope, name)
+	}
+
+	plan, err := p.newPlan(ctx, cte.Stmt, nil)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := getCTEColumns(cte.Name, planColumns(plan))
+	if err != nil {
+		plan.Close(ctx)
+		return nil, err
+	}
+	return &cteSource{name: name, columns: columns, plan: plan}, nil
+}
+
+// getRecursiveUnion returns the UNION clause of a CTE statement if it
+// has the form of a recursive CTE.
+func getRecursiveUnion(stmt parser.Statement) (*parser.UnionClause, bool) {
+	sel, ok := stmt.(*parser.Select)
+	if !ok || sel.With != nil || sel.OrderBy != nil || sel.Limit != nil {
+		return nil, false
+	}
+	union, ok := sel.Select.(*parser.UnionClause)
+	if !ok || union.Type != parser.UnionOp {
+		return nil, false
+	}
+	return union, true
+}
+
+// planRecursiveCTE plans a CTE of the form:
+//
+//	non-recursive-term UNION [ALL] recursive-term
+func (p *planner) planRecursiveCTE(
+	ctx context.Context, scope cteNameScope, cte *parser.CTE, union *parser.UnionClause,
+) (*cteSource, error) {
+	name := cte.Name.Alias
+
+	// Plan the non-recursive term, which may not refer to the CTE.
+	scope[name] = &cteSource{
+		name: name,
+		invalidRefErr: pgerror.NewErrorf(pgerror.CodeInvalidRecursionError,
+			"recursive reference to query %q must not appear within its non-recursive term",
+			parser.ErrString(name)),
+	}
+	initial, err := p.newPlan(ctx, union.Left, nil)
+	delete(scope, name)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := getCTEColumns(cte.Name, planColumns(initial))
+	if err != nil {
+		initial.Close(ctx)
+		return nil, err
+	}
+
+	// Plan the recursive term with the CTE name bound to the working
+	// table. This plan is used for the first iteration; the following
+	// iterations re-plan the recursive term from scratch.
+	working := &cteSource{name: name, columns: columns, recursive: true}
+	desiredTypes := make([]parser.Type, len(columns))
+	for i := range columns {
+		desiredTypes[i] = columns[i].Typ
+	}
+	scope[name] = working
+	recursive, err := p.newPlan(ctx, union.Right, desiredTypes)
+	delete(scope, name)
+	if err != nil {
+		initial.Close(ctx)
+		return nil, err
+	}
+
+	if !working.referenced {
+		// The CTE is not actually recursive; plan it as a regular UNION.
+		initial.Close(ctx)
+		recursive.Close(ctx)
+		plan, err := p.newPlan(ctx, cte.Stmt, nil)
+		if err != nil {
+			return nil, err
+		}
+		columns, err := getCTEColumns(cte.Name, planColumns(plan))
+		if err != nil {
+			plan.Close(ctx)
+			return nil, err
+		}
+		return &cteSource{name: name, columns: columns, plan: plan}, nil
+	}
+
+	recursiveColumns := planColumns(recursive)
+	if len(recursiveColumns) != len(columns) {
+		initial.Close(ctx)
+		recursive.Close(ctx)
+		return nil, fmt.Errorf("each %v query must have the same number of columns: %d vs %d",
+			union.Type, len(columns), len(recursiveColumns))
+	}
+	for i := range columns {
+		l, r := columns[i].Typ, recursiveColumns[i].Typ
+		if !(l.Equivalent(r) || r == parser.TypeNull) {
+			initial.Close(ctx)
+			recursive.Close(ctx)
+			return nil, pgerror.NewErrorf(pgerror.CodeDatatypeMismatchError,
+				"recursive query %q column %d has type %s in non-recursive term but type %s overall",
+				parser.ErrString(name), i+1, l, r)
+		}
+	}
+
+	n := &recursiveCTENode{
+		name:          name,
+		columns:       columns,
+		initial:       initial,
+		recursive:     recursive,
+		recursiveStmt: union.Right,
+		desiredTypes:  desiredTypes,
+		env:           p.cteNameEnvironment,
+		working:       working,
+		unionAll:      union.All,
+	}
+	return &cteSource{name: name, columns: columns, plan: n}, nil
+}
+
+// getCTEColumns computes the result columns of a CTE from those of its
+// plan, applying the column aliases if any.
+func getCTEColumns(as parser.AliasClause, planCols sqlbase.ResultColumns) (sqlbase.ResultColumns, error) {
+	columns := append(sqlbase.ResultColumns(nil), planCols...)
+	for colIdx, aliasIdx := 0, 0; aliasIdx < len(as.Cols); colIdx++ {
+		if colIdx >= len(columns) {
+			return nil, pgerror.NewErrorf(pgerror.CodeInvalidColumnReferenceError,
+				"WITH query %q has %d columns available but %d columns specified",
+				parser.ErrString(as.Alias), aliasIdx, len(as.Cols))
+		}
+		if columns[colIdx].Hidden {
+			continue
+		}
+		columns[colIdx].Name = string(as.Cols[aliasIdx])
+		aliasIdx++
+	}
+	return columns, nil
+}
+
+// getCTEDataSource resolves a table name to a CTE, if there is one
+// visible by that name. Only unqualified names can refer to CTEs.
+func (p *planner) getCTEDataSource(
+	t *parser.NormalizableTableName,
+) (planDataSource, bool, error) {
+	if len(p.cteNameEnvironment) == 0 {
+		return planDataSource{}, false, nil
+	}
+	tn, err := t.Normalize()
+	if err != nil {
+		return planDataSource{}, false, err
+	}
+	if tn.DatabaseName != "" {
+		return planDataSource{}, false, nil
+	}
+	src := p.cteNameEnvironment.lookup(tn.TableName)
+	if src == nil {
+		return planDataSource{}, false, nil
+	}
+	if src.invalidRefErr != nil {
+		return planDataSource{}, false, src.invalidRefErr
+	}
+	if len(src.columns) == 0 {
+		return planDataSource{}, false, pgerror.NewErrorf(pgerror.CodeFeatureNotSupportedError,
+			"WITH query %q does not have a RETURNING clause", parser.ErrString(src.name))
+	}
+	if src.recursive {
+		src.referenced = true
+	}
+
+	n := &cteScanNode{
+		source:  src,
+		columns: append(sqlbase.ResultColumns(nil), src.columns...),
+	}
+	return planDataSource{
+		info: newSourceInfoForSingleTable(parser.TableName{TableName: src.name}, n.columns),
+		plan: n,
+	}, true, nil
+}
+
+// withNode runs the CTEs of a WITH clause, then the statement the
+// clause applies to.
+type withNode struct {
+	ctes []*cteSource
+	plan planNode
+}
+
+func (n *withNode) Start(params runParams) error {
+	for _, cte := range n.ctes {
+		if err := cte.materialize(params); err != nil {
+			return err
+		}
+	}
+	return n.plan.Start(params)
+}
+
+func (n *withNode) Next(params runParams) (bool, error) { return n.plan.Next(params) }
+func (n *withNode) Values() parser.Datums               { return n.plan.Values() }
+
+// FastPathResults implements the planNodeFastPath interface.
+func (n *withNode) FastPathResults() (int, bool) {
+	if a, ok := n.plan.(planNodeFastPath); ok {
+		return a.FastPathResults()
+	}
+	return 0, false
+}
+
+func (n *withNode) Close(ctx context.Context) {
+	if n.plan != nil {
+		n.plan.Close(ctx)
+		n.plan = nil
+	}
+	for _, cte := range n.ctes {
+		cte.close(ctx)
+	}
+	n.ctes = nil
+}
+
+// cteScanNode produces the rows of a CTE for one of its references.
+type cteScanNode struct {
+	source  *cteSource
+	columns sqlbase.ResultColumns
+
+	rows    *sqlbase.RowContainer
+	nextRow int
+}
+
+func (n *cteScanNode) Start(params runParams) error {
+	if n.source.recursive {
+		if n.source.rows == nil {
+			// The working table is only populated while the recursive term
+			// runs; sub-queries are evaluated beforehand.
+			return pgerror.NewErrorf(pgerror.CodeInvalidRecursionError,
+				"recursive reference to query %q must not appear within a subquery",
+				parser.ErrString(n.source.name))
+		}
+	} else if err := n.source.materialize(params); err != nil {
+		return err
+	}
+	n.rows = n.source.rows
+	return nil
+}
+
+func (n *cteScanNode) Next(params runParams) (bool, error) {
+	if n.nextRow >= n.rows.Len() {
+		return false, nil
+	}
+	n.nextRow++
+	return true, nil
+}
+
+func (n *cteScanNode) Values() parser.Datums {
+	return n.rows.At(n.nextRow - 1)
+}
+
+func (n *cteScanNode) Close(context.Context) {
+	// The rows are owned by the cteSource.
+	n.rows = nil
+}
+
+// recursiveCTENode computes the rows of a recursive CTE.
+type recursiveCTENode struct {
+	name    parser.Name
+	columns sqlbase.ResultColumns
+
+	// initial is the plan for the non-recursive term.
+	initial planNode
+	// recursive is the plan for the first iteration of the recursive
+	// term. The following iterations use a new plan for recursiveStmt,
+	// planned in env with the CTE name bound to the working table.
+	recursive     planNode
+	recursiveStmt *parser.Select
+	desiredTypes  []parser.Type
+	env           cteNameEnvironment
+	working       *cteSource
+
+	unionAll bool
+
+	run struct {
+		// source is the plan currently producing rows.
+		source planNode
+		// iteration accumulates the rows produced by the current iteration,
+		// which become the working table of the next iteration.
+		iteration *sqlbase.RowContainer
+		// seen contains the encoding of every row produced so far, for
+		// UNION (as opposed to UNION ALL).
+		seen    map[string]struct{}
+		scratch []byte
+		row     parser.Datums
+	}
+}
+
+func (n *recursiveCTENode) Start(params runParams) error {
+	if err := n.initial.Start(params); err != nil {
+		return err
+	}
+	n.run.source = n.initial
+	n.run.iteration = n.newRowContainer(params)
+	if !n.unionAll {
+		n.run.seen = make(map[string]struct{})
+	}
+	return nil
+}
+
+func (n *recursiveCTENode) newRowContainer(params runParams) *sqlbase.RowContainer {
+	return sqlbase.NewRowContainer(
+		params.p.session.TxnState.makeBoundAccount(), sqlbase.ColTypeInfoFromResCols(n.columns), 0,
+	)
+}
+
+func (n *recursiveCTENode) Next(params runParams) (bool, error) {
+	for n.run.source != nil {
+		if err := params.p.cancelChecker.Check(); err != nil {
+			return false, err
+		}
+		next, err := n.run.source.Next(params)
+		if err != nil {
+			return false, err
+		}
+		if !next {
+			if err := n.nextIteration(params); err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		row := n.run.source.Values()
+		if n.run.seen != nil {
+			n.run.scratch, err = sqlbase.EncodeDatums(n.run.scratch[:0], row)
+			if err != nil {
+				return false, err
+			}
+			if _, ok := n.run.seen[string(n.run.scratch)]; ok {
+				continue
+			}
+			n.run.seen[string(n.run.scratch)] = struct{}{}
+		}
+		n.run.row, err = n.run.iteration.AddRow(params.ctx, row)
+		if err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// nextIteration closes the exhausted source and starts the next
+// iteration of the recursive term, unless the previous iteration did
+// not produce any rows.
+func (n *recursiveCTENode) nextIteration(params runParams) error {
+	n.run.source.Close(params.ctx)
+	switch n.run.source {
+	case n.initial:
+		n.initial = nil
+	case n.recursive:
+		n.recursive = nil
+	}
+	n.run.source = nil
+	if n.working.rows != nil {
+		n.working.rows.Close(params.ctx)
+		n.working.rows = nil
+	}
+
+	if n.run.iteration.Len() == 0 {
+		return nil
+	}
+	working := n.run.iteration
+	n.run.iteration = n.newRowContainer(params)
+
+	plan := n.recursive
+	if plan == nil {
+		var err error
+		if plan, err = n.planIteration(params); err != nil {
+			working.Close(params.ctx)
+			return err
+		}
+	}
+	n.run.source = plan
+
+	// The sub-queries are evaluated before the working table is made
+	// available; see cteScanNode.Start.
+	if err := params.p.startSubqueryPlans(params.ctx, plan); err != nil {
+		working.Close(params.ctx)
+		return err
+	}
+	n.working.rows = working
+	if err := plan.Start(params); err != nil {
+		return err
+	}
+	setUnlimited(plan)
+	return nil
+}
+
+func (n *recursiveCTENode) planIteration(params runParams) (planNode, error) {
+	p := params.p
+	defer func(prev cteNameEnvironment) { p.cteNameEnvironment = prev }(p.cteNameEnvironment)
+	p.cteNameEnvironment = n.env.push(cteNameScope{n.name: n.working})
+
+	plan, err := p.newPlan(params.ctx, n.recursiveStmt, n.desiredTypes)
+	if err != nil {
+		return nil, err
+	}
+	plan, err = p.optimizePlan(params.ctx, plan, allColumns(plan))
+	if err != nil {
+		plan.Close(params.ctx)
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (n *recursiveCTENode) Values() parser.Datums {
+	return n.run.row
+}
+
+func (n *recursiveCTENode) Close(ctx context.Context) {
+	if n.run.source != nil && n.run.source != n.initial && n.run.source != n.recursive {
+		n.run.source.Close(ctx)
+	}
+	n.run.source = nil
+	if n.initial != nil {
+		n.initial.Close(ctx)
+		n.initial = nil
+	}
+	if n.recursive != nil {
+		n.recursive.Close(ctx)
+		n.recursive = nil
+	}
+	if n.working.rows != nil {
+		n.working.rows.Close(ctx)
+		n.working.rows = nil
+	}
+	if n.run.iteration != nil {
+		n.run.iteration.Close(ctx)
+		n.run.iteration = nil
+	}
+}
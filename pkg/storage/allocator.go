@@ -278,7 +278,7 @@ func (a *Allocator) ComputeAction(
 			// we'll up-replicate to, just an indication that such a target exists.
 			if _, _, err := a.AllocateTarget(
 				ctx,
-				zone.Constraints,
+				zone,
 				liveReplicas,
 				rangeInfo,
 				true, /* relaxConstraints */
@@ -334,7 +334,7 @@ type decisionDetails struct {
 // allocate a target.
 func (a *Allocator) AllocateTarget(
 	ctx context.Context,
-	constraints config.Constraints,
+	zone config.ZoneConfig,
 	existing []roachpb.ReplicaDescriptor,
 	rangeInfo RangeInfo,
 	relaxConstraints bool,
@@ -344,7 +344,7 @@ func (a *Allocator) AllocateTarget(
 	candidates := allocateCandidates(
 		a.storePool.st,
 		sl,
-		constraints,
+		analyzeConstraints(a.storePool.getStoreDescriptor, existing, zone),
 		existing,
 		rangeInfo,
 		a.storePool.getLocalities(existing),
@@ -370,7 +370,7 @@ func (a *Allocator) AllocateTarget(
 		return nil, "", errors.Errorf("%d matching stores are currently throttled", throttledStoreCount)
 	}
 	return nil, "", &allocatorError{
-		required: constraints.Constraints,
+		required: zone.Constraints.Constraints,
 	}
 }
 
@@ -381,7 +381,7 @@ func (a *Allocator) AllocateTarget(
 // replicas.
 func (a Allocator) RemoveTarget(
 	ctx context.Context,
-	constraints config.Constraints,
+	zone config.ZoneConfig,
 	candidates []roachpb.ReplicaDescriptor,
 	rangeInfo RangeInfo,
 ) (roachpb.ReplicaDescriptor, string, error) {
@@ -399,7 +399,7 @@ func (a Allocator) RemoveTarget(
 	rankedCandidates := removeCandidates(
 		a.storePool.st,
 		sl,
-		constraints,
+		analyzeConstraints(a.storePool.getStoreDescriptor, rangeInfo.Desc.Replicas, zone),
 		rangeInfo,
 		a.storePool.getLocalities(rangeInfo.Desc.Replicas),
 		a.storePool.deterministic,
@@ -431,8 +431,8 @@ func (a Allocator) RemoveTarget(
 // criteria. Namely, if chosen, it must further the goal of balancing the
 // cluster.
 //
-// The supplied parameters are the required attributes for the range and
-// information about the range being considered for rebalancing.
+// The supplied parameters are the zone config for the range and information
+// about the range being considered for rebalancing.
 //
 // The existing replicas modulo any store with dead replicas are candidates for
 // rebalancing. Note that rebalancing is accomplished by first adding a new
@@ -444,7 +444,7 @@ func (a Allocator) RemoveTarget(
 // rebalance. This helps prevent a stampeding herd targeting an abnormally
 // under-utilized store.
 func (a Allocator) RebalanceTarget(
-	ctx context.Context, constraints config.Constraints, rangeInfo RangeInfo, filter storeFilter,
+	ctx context.Context, zone config.ZoneConfig, rangeInfo RangeInfo, filter storeFilter,
 ) (*roachpb.StoreDescriptor, string) {
 	sl, _, _ := a.storePool.getStoreList(rangeInfo.Desc.RangeID, filter)
 
@@ -452,7 +452,7 @@ func (a Allocator) RebalanceTarget(
 		ctx,
 		a.storePool.st,
 		sl,
-		constraints,
+		analyzeConstraints(a.storePool.getStoreDescriptor, rangeInfo.Desc.Replicas, zone),
 		rangeInfo.Desc.Replicas,
 		rangeInfo,
 		a.storePool.getLocalities(rangeInfo.Desc.Replicas),
@@ -506,9 +506,11 @@ func (a Allocator) RebalanceTarget(
 // TransferLeaseTarget returns a suitable replica to transfer the range lease
 // to from the provided list. It excludes the current lease holder replica
 // unless asked to do otherwise by the checkTransferLeaseSource parameter.
+// Replicas matching the zone's earliest satisfiable lease preference are
+// chosen over all others.
 func (a *Allocator) TransferLeaseTarget(
 	ctx context.Context,
-	constraints config.Constraints,
+	zone config.ZoneConfig,
 	existing []roachpb.ReplicaDescriptor,
 	leaseStoreID roachpb.StoreID,
 	rangeID roachpb.RangeID,
@@ -518,7 +520,23 @@ func (a *Allocator) TransferLeaseTarget(
 	alwaysAllowDecisionWithoutStats bool,
 ) roachpb.ReplicaDescriptor {
 	sl, _, _ := a.storePool.getStoreList(rangeID, storeFilterNone)
-	sl = sl.filter(constraints)
+	sl = sl.filter(zone.Constraints)
+
+	// Only consider the replicas matching the first lease preference which any
+	// replica matches. If the leaseholder doesn't match it, the lease should be
+	// moved regardless of the load on the leaseholder's store.
+	preferred := a.preferredLeaseholders(zone, existing)
+	if len(preferred) == 1 {
+		if preferred[0].StoreID == leaseStoreID {
+			return roachpb.ReplicaDescriptor{}
+		}
+		return preferred[0]
+	} else if len(preferred) > 1 {
+		existing = preferred
+		if !storeHasReplica(leaseStoreID, preferred) {
+			checkTransferLeaseSource = false
+		}
+	}
 
 	// Filter stores that are on nodes containing existing replicas, but leave
 	// the stores containing the existing replicas in place. This excludes stores
@@ -598,12 +616,13 @@ func (a *Allocator) TransferLeaseTarget(
 	return candidates[a.randGen.Intn(len(candidates))]
 }
 
-// ShouldTransferLease returns true if the specified store is overfull in terms
-// of leases with respect to the other stores matching the specified
-// attributes.
+// ShouldTransferLease returns true if the specified store doesn't match the
+// zone's lease preferences while another replica's store does, or if it is
+// overfull in terms of leases with respect to the other stores matching the
+// zone's constraints.
 func (a *Allocator) ShouldTransferLease(
 	ctx context.Context,
-	constraints config.Constraints,
+	zone config.ZoneConfig,
 	existing []roachpb.ReplicaDescriptor,
 	leaseStoreID roachpb.StoreID,
 	rangeID roachpb.RangeID,
@@ -613,8 +632,22 @@ func (a *Allocator) ShouldTransferLease(
 	if !ok {
 		return false
 	}
+
+	preferred := a.preferredLeaseholders(zone, existing)
+	if len(preferred) > 0 {
+		if !storeHasReplica(leaseStoreID, preferred) {
+			log.VEventf(ctx, 3, "ShouldTransferLease (lease-holder=%d): not a preferred leaseholder",
+				leaseStoreID)
+			return true
+		}
+		if len(preferred) == 1 {
+			return false
+		}
+		existing = preferred
+	}
+
 	sl, _, _ := a.storePool.getStoreList(rangeID, storeFilterNone)
-	sl = sl.filter(constraints)
+	sl = sl.filter(zone.Constraints)
 	log.VEventf(ctx, 3, "ShouldTransferLease (lease-holder=%d):\n%s", leaseStoreID, sl)
 
 	transferDec, _ := a.shouldTransferLeaseUsingStats(ctx, sl, source, existing, stats)
@@ -838,6 +871,44 @@ func (a Allocator) shouldTransferLeaseWithoutStats(
 	return false
 }
 
+// preferredLeaseholders returns the replicas whose stores match the first of
+// the zone's lease preferences which is matched by any of the existing
+// replicas. It returns nil if the zone has no lease preferences or none of
+// them are matched.
+func (a Allocator) preferredLeaseholders(
+	zone config.ZoneConfig, existing []roachpb.ReplicaDescriptor,
+) []roachpb.ReplicaDescriptor {
+	// Lease preferences are ordered by priority, so as soon as one of them is
+	// matched the later ones don't matter.
+	for _, preference := range zone.LeasePreferences {
+		constraints := config.Constraints{Constraints: preference.Constraints}
+		var preferred []roachpb.ReplicaDescriptor
+		for _, repl := range existing {
+			storeDesc, ok := a.storePool.getStoreDescriptor(repl.StoreID)
+			if !ok {
+				continue
+			}
+			if ok, _ := constraintCheck(storeDesc, constraints); ok {
+				preferred = append(preferred, repl)
+			}
+		}
+		if len(preferred) > 0 {
+			return preferred
+		}
+	}
+	return nil
+}
+
+// storeHasReplica returns whether one of the given replicas is on the store.
+func storeHasReplica(storeID roachpb.StoreID, existing []roachpb.ReplicaDescriptor) bool {
+	for _, r := range existing {
+		if r.StoreID == storeID {
+			return true
+		}
+	}
+	return false
+}
+
 // computeQuorum computes the quorum value for the given number of nodes.
 func computeQuorum(nodes int) int {
 	return (nodes / 2) + 1
@@ -100,6 +100,7 @@ func (bd balanceDimensions) String() string {
 type candidate struct {
 	store           roachpb.StoreDescriptor
 	valid           bool
+	necessary       bool
 	constraintScore float64
 	convergesScore  int
 	balanceScore    balanceDimensions
@@ -108,9 +109,9 @@ type candidate struct {
 }
 
 func (c candidate) String() string {
-	return fmt.Sprintf("s%d, valid:%t, constraint:%.2f, converges:%d, balance:%s, rangeCount:%d, "+
-		"logicalBytes:%s, writesPerSecond:%.2f, details:(%s)",
-		c.store.StoreID, c.valid, c.constraintScore, c.convergesScore, c.balanceScore, c.rangeCount,
+	return fmt.Sprintf("s%d, valid:%t, necessary:%t, constraint:%.2f, converges:%d, balance:%s, "+
+		"rangeCount:%d, logicalBytes:%s, writesPerSecond:%.2f, details:(%s)",
+		c.store.StoreID, c.valid, c.necessary, c.constraintScore, c.convergesScore, c.balanceScore, c.rangeCount,
 		humanizeutil.IBytes(c.store.Capacity.LogicalBytes), c.store.Capacity.WritesPerSecond, c.details)
 }
 
@@ -122,6 +123,9 @@ func (c candidate) less(o candidate) bool {
 	if !c.valid {
 		return true
 	}
+	if c.necessary != o.necessary {
+		return o.necessary
+	}
 	if c.constraintScore != o.constraintScore {
 		return c.constraintScore < o.constraintScore
 	}
@@ -170,6 +174,7 @@ func (c byScoreAndID) Less(i, j int) bool {
 		c[i].convergesScore == c[j].convergesScore &&
 		c[i].balanceScore.totalScore() == c[j].balanceScore.totalScore() &&
 		c[i].rangeCount == c[j].rangeCount &&
+		c[i].necessary == c[j].necessary &&
 		c[i].valid == c[j].valid {
 		return c[i].store.StoreID < c[j].store.StoreID
 	}
@@ -196,7 +201,8 @@ func (cl candidateList) best() candidateList {
 		return cl
 	}
 	for i := 1; i < len(cl); i++ {
-		if cl[i].constraintScore < cl[0].constraintScore ||
+		if cl[i].necessary != cl[0].necessary ||
+			cl[i].constraintScore < cl[0].constraintScore ||
 			(cl[i].constraintScore == cl[len(cl)-1].constraintScore &&
 				cl[i].convergesScore < cl[len(cl)-1].convergesScore) {
 			return cl[:i]
@@ -221,7 +227,8 @@ func (cl candidateList) worst() candidateList {
 	}
 	// Find the worst constraint values.
 	for i := len(cl) - 2; i >= 0; i-- {
-		if cl[i].constraintScore > cl[len(cl)-1].constraintScore ||
+		if cl[i].necessary != cl[len(cl)-1].necessary ||
+			cl[i].constraintScore > cl[len(cl)-1].constraintScore ||
 			(cl[i].constraintScore == cl[len(cl)-1].constraintScore &&
 				cl[i].convergesScore > cl[len(cl)-1].convergesScore) {
 			return cl[i+1:]
@@ -291,7 +298,7 @@ func (cl candidateList) selectBad(randGen allocatorRand) *candidate {
 func allocateCandidates(
 	st *cluster.Settings,
 	sl StoreList,
-	constraints config.Constraints,
+	constraints analyzedConstraints,
 	existing []roachpb.ReplicaDescriptor,
 	rangeInfo RangeInfo,
 	existingNodeLocalities map[roachpb.NodeID]roachpb.Locality,
@@ -302,10 +309,14 @@ func allocateCandidates(
 		if !preexistingReplicaCheck(s.Node.NodeID, existing) {
 			continue
 		}
-		constraintsOk, preferredMatched := constraintCheck(s, constraints)
+		constraintsOk, preferredMatched := constraintCheck(s, constraints.constraints)
 		if !constraintsOk {
 			continue
 		}
+		replicaConstraintsOk, necessary := allocateConstraintsCheck(s, constraints)
+		if !replicaConstraintsOk {
+			continue
+		}
 		if !maxCapacityCheck(s) {
 			continue
 		}
@@ -314,6 +325,7 @@ func allocateCandidates(
 		candidates = append(candidates, candidate{
 			store:           s,
 			valid:           true,
+			necessary:       necessary,
 			constraintScore: diversityScore + float64(preferredMatched),
 			balanceScore:    balanceScore,
 			rangeCount:      int(s.Capacity.RangeCount),
@@ -334,14 +346,14 @@ func allocateCandidates(
 func removeCandidates(
 	st *cluster.Settings,
 	sl StoreList,
-	constraints config.Constraints,
+	constraints analyzedConstraints,
 	rangeInfo RangeInfo,
 	existingNodeLocalities map[roachpb.NodeID]roachpb.Locality,
 	deterministic bool,
 ) candidateList {
 	var candidates candidateList
 	for _, s := range sl.stores {
-		constraintsOk, preferredMatched := constraintCheck(s, constraints)
+		constraintsOk, preferredMatched := constraintCheck(s, constraints.constraints)
 		if !constraintsOk {
 			candidates = append(candidates, candidate{
 				store:   s,
@@ -350,6 +362,15 @@ func removeCandidates(
 			})
 			continue
 		}
+		replicaConstraintsOk, necessary := removeConstraintsCheck(s, constraints)
+		if !replicaConstraintsOk {
+			candidates = append(candidates, candidate{
+				store:   s,
+				valid:   false,
+				details: "replica constraint check fail",
+			})
+			continue
+		}
 		if !maxCapacityCheck(s) {
 			candidates = append(candidates, candidate{
 				store:   s,
@@ -372,6 +393,7 @@ func removeCandidates(
 		candidates = append(candidates, candidate{
 			store:           s,
 			valid:           true,
+			necessary:       necessary,
 			constraintScore: diversityScore + float64(preferredMatched),
 			convergesScore:  convergesScore,
 			balanceScore:    balanceScore,
@@ -395,7 +417,7 @@ func rebalanceCandidates(
 	ctx context.Context,
 	st *cluster.Settings,
 	sl StoreList,
-	constraints config.Constraints,
+	constraints analyzedConstraints,
 	existing []roachpb.ReplicaDescriptor,
 	rangeInfo RangeInfo,
 	existingNodeLocalities map[roachpb.NodeID]roachpb.Locality,
@@ -413,15 +435,30 @@ func rebalanceCandidates(
 	var constraintsOkStoreDescriptors []roachpb.StoreDescriptor
 
 	type constraintInfo struct {
-		ok      bool
-		matched int
+		ok        bool
+		necessary bool
+		matched   int
 	}
 	storeInfos := make(map[roachpb.StoreID]constraintInfo)
 	var rebalanceConstraintsCheck bool
 	for _, s := range sl.stores {
-		constraintsOk, preferredMatched := constraintCheck(s, constraints)
-		storeInfos[s.StoreID] = constraintInfo{ok: constraintsOk, matched: preferredMatched}
+		constraintsOk, preferredMatched := constraintCheck(s, constraints.constraints)
 		_, exists := existingStoreIDs[s.StoreID]
+		var necessary bool
+		if constraintsOk {
+			// Existing replicas are checked as if they were being removed, while
+			// other stores are checked as if they were replacing one of them.
+			if exists {
+				constraintsOk, necessary = removeConstraintsCheck(s, constraints)
+			} else {
+				constraintsOk, necessary = rebalanceToConstraintsCheck(s, constraints)
+			}
+		}
+		storeInfos[s.StoreID] = constraintInfo{
+			ok:        constraintsOk,
+			necessary: necessary,
+			matched:   preferredMatched,
+		}
 		if constraintsOk {
 			constraintsOkStoreDescriptors = append(constraintsOkStoreDescriptors, s)
 		} else if exists {
@@ -429,6 +466,13 @@ func rebalanceCandidates(
 			log.VEventf(ctx, 2, "must rebalance from s%d due to constraint check", s.StoreID)
 		}
 	}
+	for i, satisfiedBy := range constraints.satisfiedBy {
+		if len(satisfiedBy) < int(constraints.replicaConstraints[i].NumReplicas) {
+			rebalanceConstraintsCheck = true
+			log.VEventf(ctx, 2, "must rebalance due to unsatisfied replica constraints %s",
+				constraints.replicaConstraints[i].Constraints)
+		}
+	}
 
 	constraintsOkStoreList := makeStoreList(constraintsOkStoreDescriptors)
 	var shouldRebalanceCheck bool
@@ -485,6 +529,7 @@ func rebalanceCandidates(
 			existingCandidates = append(existingCandidates, candidate{
 				store:           s,
 				valid:           true,
+				necessary:       storeInfo.necessary,
 				constraintScore: diversityScore + float64(storeInfo.matched),
 				convergesScore:  convergesScore,
 				balanceScore:    balanceScore,
@@ -513,6 +558,7 @@ func rebalanceCandidates(
 			candidates = append(candidates, candidate{
 				store:           s,
 				valid:           true,
+				necessary:       storeInfo.necessary,
 				constraintScore: diversityScore + float64(storeInfo.matched),
 				convergesScore:  convergesScore,
 				balanceScore:    balanceScore,
@@ -687,6 +733,125 @@ func constraintCheck(store roachpb.StoreDescriptor, constraints config.Constrain
 	return true, positive
 }
 
+// analyzedConstraints holds a zone's constraints along with its per-replica
+// constraints and the existing replicas that satisfy each of them.
+type analyzedConstraints struct {
+	// constraints apply to every replica of the range.
+	constraints config.Constraints
+	// replicaConstraints each apply to NumReplicas replicas of the range.
+	replicaConstraints []config.Constraints
+	// unconstrainedReplicas is true if the replicaConstraints apply to fewer
+	// than all of the zone's replicas, so that some replicas may be placed on
+	// stores that satisfy none of them.
+	unconstrainedReplicas bool
+	// satisfiedBy contains, for each of the replicaConstraints, the stores of
+	// the existing replicas that satisfy it.
+	satisfiedBy [][]roachpb.StoreID
+	// satisfies maps the stores of the existing replicas to the indexes of the
+	// replicaConstraints they satisfy.
+	satisfies map[roachpb.StoreID][]int
+}
+
+// analyzeConstraints determines which of the zone's per-replica constraints
+// are satisfied by each of the existing replicas. A store satisfying several
+// per-replica constraints counts toward all of them.
+func analyzeConstraints(
+	getStoreDescFn func(roachpb.StoreID) (roachpb.StoreDescriptor, bool),
+	existing []roachpb.ReplicaDescriptor,
+	zone config.ZoneConfig,
+) analyzedConstraints {
+	result := analyzedConstraints{
+		constraints:        zone.Constraints,
+		replicaConstraints: zone.ReplicaConstraints,
+	}
+	if len(zone.ReplicaConstraints) == 0 {
+		return result
+	}
+	var constrained int32
+	for _, constraints := range zone.ReplicaConstraints {
+		constrained += constraints.NumReplicas
+	}
+	result.unconstrainedReplicas = constrained < zone.NumReplicas
+	result.satisfiedBy = make([][]roachpb.StoreID, len(zone.ReplicaConstraints))
+	result.satisfies = make(map[roachpb.StoreID][]int)
+	for _, repl := range existing {
+		store, ok := getStoreDescFn(repl.StoreID)
+		if !ok {
+			continue
+		}
+		for i, constraints := range zone.ReplicaConstraints {
+			if ok, _ := constraintCheck(store, constraints); ok {
+				result.satisfiedBy[i] = append(result.satisfiedBy[i], store.StoreID)
+				result.satisfies[store.StoreID] = append(result.satisfies[store.StoreID], i)
+			}
+		}
+	}
+	return result
+}
+
+// allocateConstraintsCheck returns whether a new replica may be added to the
+// store given the zone's per-replica constraints, and whether the store is
+// necessary to satisfy a per-replica constraint that is satisfied by too few
+// of the existing replicas.
+func allocateConstraintsCheck(
+	store roachpb.StoreDescriptor, constraints analyzedConstraints,
+) (valid bool, necessary bool) {
+	if len(constraints.replicaConstraints) == 0 {
+		return true, false
+	}
+	for i, replicaConstraints := range constraints.replicaConstraints {
+		if ok, _ := constraintCheck(store, replicaConstraints); ok {
+			valid = true
+			if len(constraints.satisfiedBy[i]) < int(replicaConstraints.NumReplicas) {
+				return true, true
+			}
+		}
+	}
+	return valid || constraints.unconstrainedReplicas, false
+}
+
+// removeConstraintsCheck returns whether the existing replica on the store is
+// allowed by the zone's per-replica constraints, and whether removing it would
+// leave one of the per-replica constraints satisfied by too few replicas.
+func removeConstraintsCheck(
+	store roachpb.StoreDescriptor, constraints analyzedConstraints,
+) (valid bool, necessary bool) {
+	if len(constraints.replicaConstraints) == 0 {
+		return true, false
+	}
+	satisfies := constraints.satisfies[store.StoreID]
+	if len(satisfies) == 0 {
+		return constraints.unconstrainedReplicas, false
+	}
+	for _, i := range satisfies {
+		if len(constraints.satisfiedBy[i]) <= int(constraints.replicaConstraints[i].NumReplicas) {
+			return true, true
+		}
+	}
+	return true, false
+}
+
+// rebalanceToConstraintsCheck returns whether the store may receive a replica
+// in place of one of the existing replicas given the zone's per-replica
+// constraints, and whether it could replace an existing replica that is
+// necessary to satisfy one of the per-replica constraints.
+func rebalanceToConstraintsCheck(
+	store roachpb.StoreDescriptor, constraints analyzedConstraints,
+) (valid bool, necessary bool) {
+	if len(constraints.replicaConstraints) == 0 {
+		return true, false
+	}
+	for i, replicaConstraints := range constraints.replicaConstraints {
+		if ok, _ := constraintCheck(store, replicaConstraints); ok {
+			valid = true
+			if len(constraints.satisfiedBy[i]) <= int(replicaConstraints.NumReplicas) {
+				return true, true
+			}
+		}
+	}
+	return valid || constraints.unconstrainedReplicas, false
+}
+
 // diversityScore returns a score between 1 and 0 where higher scores are stores
 // with the fewest locality tiers in common with already existing replicas.
 func diversityScore(
@@ -265,7 +265,7 @@ func TestAllocatorSimpleRetrieval(t *testing.T) {
 	gossiputil.NewStoreGossiper(g).GossipStores(singleStore, t)
 	result, _, err := a.AllocateTarget(
 		context.Background(),
-		simpleZoneConfig.Constraints,
+		simpleZoneConfig,
 		[]roachpb.ReplicaDescriptor{},
 		firstRangeInfo,
 		false,
@@ -299,7 +299,7 @@ func TestAllocatorCorruptReplica(t *testing.T) {
 
 	result, _, err := a.AllocateTarget(
 		context.Background(),
-		simpleZoneConfig.Constraints,
+		simpleZoneConfig,
 		[]roachpb.ReplicaDescriptor{},
 		firstRangeInfo,
 		true,
@@ -319,7 +319,7 @@ func TestAllocatorNoAvailableDisks(t *testing.T) {
 	defer stopper.Stop(context.Background())
 	result, _, err := a.AllocateTarget(
 		context.Background(),
-		simpleZoneConfig.Constraints,
+		simpleZoneConfig,
 		[]roachpb.ReplicaDescriptor{},
 		firstRangeInfo,
 		false,
@@ -341,7 +341,7 @@ func TestAllocatorTwoDatacenters(t *testing.T) {
 	ctx := context.Background()
 	result1, _, err := a.AllocateTarget(
 		ctx,
-		multiDCConfig.Constraints,
+		multiDCConfig,
 		[]roachpb.ReplicaDescriptor{},
 		firstRangeInfo,
 		false,
@@ -351,7 +351,7 @@ func TestAllocatorTwoDatacenters(t *testing.T) {
 	}
 	result2, _, err := a.AllocateTarget(
 		ctx,
-		multiDCConfig.Constraints,
+		multiDCConfig,
 		[]roachpb.ReplicaDescriptor{{
 			NodeID:  result1.Node.NodeID,
 			StoreID: result1.StoreID,
@@ -370,7 +370,7 @@ func TestAllocatorTwoDatacenters(t *testing.T) {
 	// Verify that no result is forthcoming if we already have a replica.
 	result3, _, err := a.AllocateTarget(
 		ctx,
-		multiDCConfig.Constraints,
+		multiDCConfig,
 		[]roachpb.ReplicaDescriptor{
 			{
 				NodeID:  result1.Node.NodeID,
@@ -397,10 +397,12 @@ func TestAllocatorExistingReplica(t *testing.T) {
 	gossiputil.NewStoreGossiper(g).GossipStores(sameDCStores, t)
 	result, _, err := a.AllocateTarget(
 		context.Background(),
-		config.Constraints{
-			Constraints: []config.Constraint{
-				{Value: "a"},
-				{Value: "hdd"},
+		config.ZoneConfig{
+			Constraints: config.Constraints{
+				Constraints: []config.Constraint{
+					{Value: "a"},
+					{Value: "hdd"},
+				},
 			},
 		},
 		[]roachpb.ReplicaDescriptor{
@@ -581,7 +583,7 @@ func TestAllocatorRelaxConstraints(t *testing.T) {
 			}
 			result, _, err := a.AllocateTarget(
 				context.Background(),
-				config.Constraints{Constraints: test.constraints},
+				config.ZoneConfig{Constraints: config.Constraints{Constraints: test.constraints}},
 				existing,
 				firstRangeInfo,
 				false,
@@ -656,7 +658,7 @@ func TestAllocatorRebalance(t *testing.T) {
 	for i := 0; i < 10; i++ {
 		result, _ := a.RebalanceTarget(
 			ctx,
-			config.Constraints{},
+			config.ZoneConfig{},
 			testRangeInfo([]roachpb.ReplicaDescriptor{{StoreID: 3}}, firstRange),
 			storeFilterThrottled,
 		)
@@ -754,7 +756,7 @@ func TestAllocatorRebalanceDeadNodes(t *testing.T) {
 	for _, c := range testCases {
 		t.Run("", func(t *testing.T) {
 			result, _ := a.RebalanceTarget(
-				ctx, config.Constraints{}, testRangeInfo(c.existing, firstRange), storeFilterThrottled)
+				ctx, config.ZoneConfig{}, testRangeInfo(c.existing, firstRange), storeFilterThrottled)
 			if c.expected > 0 {
 				if result == nil {
 					t.Fatalf("expected %d, but found nil", c.expected)
@@ -948,7 +950,7 @@ func TestAllocatorRebalanceByCount(t *testing.T) {
 	for i := 0; i < 10; i++ {
 		result, _ := a.RebalanceTarget(
 			ctx,
-			config.Constraints{},
+			config.ZoneConfig{},
 			testRangeInfo([]roachpb.ReplicaDescriptor{{StoreID: stores[0].StoreID}}, firstRange),
 			storeFilterThrottled,
 		)
@@ -1018,7 +1020,7 @@ func TestAllocatorTransferLeaseTarget(t *testing.T) {
 		t.Run("", func(t *testing.T) {
 			target := a.TransferLeaseTarget(
 				context.Background(),
-				config.Constraints{},
+				config.ZoneConfig{},
 				c.existing,
 				c.leaseholder,
 				0,
@@ -1072,7 +1074,7 @@ func TestAllocatorTransferLeaseTargetMultiStore(t *testing.T) {
 		t.Run("", func(t *testing.T) {
 			target := a.TransferLeaseTarget(
 				context.Background(),
-				config.Constraints{},
+				config.ZoneConfig{},
 				existing,
 				c.leaseholder,
 				0,
@@ -1135,7 +1137,7 @@ func TestAllocatorShouldTransferLease(t *testing.T) {
 		t.Run("", func(t *testing.T) {
 			result := a.ShouldTransferLease(
 				context.Background(),
-				config.Constraints{},
+				config.ZoneConfig{},
 				c.existing,
 				c.leaseholder,
 				0,
@@ -1148,6 +1150,232 @@ func TestAllocatorShouldTransferLease(t *testing.T) {
 	}
 }
 
+func TestAllocatorLeasePreferences(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	stopper, g, _, a, _ := createTestAllocator( /* deterministic */ true)
+	defer stopper.Stop(context.Background())
+
+	// 5 stores with equal lease counts in three regions.
+	regions := []string{"us", "us", "eu", "eu", "ap"}
+	var stores []*roachpb.StoreDescriptor
+	for i, region := range regions {
+		stores = append(stores, &roachpb.StoreDescriptor{
+			StoreID: roachpb.StoreID(i + 1),
+			Node: roachpb.NodeDescriptor{
+				NodeID: roachpb.NodeID(i + 1),
+				Locality: roachpb.Locality{
+					Tiers: []roachpb.Tier{{Key: "region", Value: region}},
+				},
+			},
+			Capacity: roachpb.StoreCapacity{LeaseCount: 10},
+		})
+	}
+	sg := gossiputil.NewStoreGossiper(g)
+	sg.GossipStores(stores, t)
+
+	replicas := func(storeIDs ...roachpb.StoreID) []roachpb.ReplicaDescriptor {
+		var r []roachpb.ReplicaDescriptor
+		for _, storeID := range storeIDs {
+			r = append(r, roachpb.ReplicaDescriptor{
+				NodeID:  roachpb.NodeID(storeID),
+				StoreID: storeID,
+			})
+		}
+		return r
+	}
+	preference := func(region string) config.LeasePreference {
+		return config.LeasePreference{Constraints: []config.Constraint{
+			{Type: config.Constraint_REQUIRED, Key: "region", Value: region},
+		}}
+	}
+	zone := config.ZoneConfig{
+		LeasePreferences: []config.LeasePreference{preference("eu"), preference("us")},
+	}
+
+	testCases := []struct {
+		leaseholder    roachpb.StoreID
+		existing       []roachpb.ReplicaDescriptor
+		expectTransfer bool
+		// expectedTargets are the acceptable lease transfer targets; a nil
+		// slice means that no target should be found.
+		expectedTargets []roachpb.StoreID
+	}{
+		{leaseholder: 1, existing: replicas(1, 2, 3), expectTransfer: true, expectedTargets: []roachpb.StoreID{3}},
+		{leaseholder: 3, existing: replicas(1, 2, 3), expectTransfer: false, expectedTargets: nil},
+		{leaseholder: 1, existing: replicas(1, 3, 4), expectTransfer: true, expectedTargets: []roachpb.StoreID{3, 4}},
+		{leaseholder: 5, existing: replicas(1, 2, 5), expectTransfer: true, expectedTargets: []roachpb.StoreID{1, 2}},
+		{leaseholder: 1, existing: replicas(1, 2, 5), expectTransfer: false, expectedTargets: nil},
+		{leaseholder: 5, existing: replicas(5), expectTransfer: false, expectedTargets: nil},
+	}
+	for _, c := range testCases {
+		t.Run("", func(t *testing.T) {
+			result := a.ShouldTransferLease(
+				context.Background(),
+				zone,
+				c.existing,
+				c.leaseholder,
+				0,
+				nil, /* replicaStats */
+			)
+			if c.expectTransfer != result {
+				t.Errorf("expected ShouldTransferLease %v, but found %v", c.expectTransfer, result)
+			}
+			target := a.TransferLeaseTarget(
+				context.Background(),
+				zone,
+				c.existing,
+				c.leaseholder,
+				0,
+				nil,   /* replicaStats */
+				true,  /* checkTransferLeaseSource */
+				false, /* checkCandidateFullness */
+				false, /* !alwaysAllowDecisionWithoutStats */
+			)
+			if c.expectedTargets == nil {
+				if target != (roachpb.ReplicaDescriptor{}) {
+					t.Errorf("expected no lease transfer target, but found s%d", target.StoreID)
+				}
+				return
+			}
+			if !storeHasReplica(target.StoreID, replicas(c.expectedTargets...)) {
+				t.Errorf("expected lease transfer target in %v, but found s%d", c.expectedTargets, target.StoreID)
+			}
+		})
+	}
+}
+
+func TestAllocatorReplicaConstraints(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	stopper, g, _, a, _ := createTestAllocator( /* deterministic */ true)
+	defer stopper.Stop(context.Background())
+
+	// 6 stores with equal range counts in three regions.
+	regions := []string{"us", "us", "us", "eu", "eu", "ap"}
+	var stores []*roachpb.StoreDescriptor
+	for i, region := range regions {
+		stores = append(stores, &roachpb.StoreDescriptor{
+			StoreID: roachpb.StoreID(i + 1),
+			Node: roachpb.NodeDescriptor{
+				NodeID: roachpb.NodeID(i + 1),
+				Locality: roachpb.Locality{
+					Tiers: []roachpb.Tier{{Key: "region", Value: region}},
+				},
+			},
+			Capacity: roachpb.StoreCapacity{Capacity: 100, Available: 100, RangeCount: 10},
+		})
+	}
+	sg := gossiputil.NewStoreGossiper(g)
+	sg.GossipStores(stores, t)
+
+	replicas := func(storeIDs ...roachpb.StoreID) []roachpb.ReplicaDescriptor {
+		var r []roachpb.ReplicaDescriptor
+		for _, storeID := range storeIDs {
+			r = append(r, roachpb.ReplicaDescriptor{
+				NodeID:  roachpb.NodeID(storeID),
+				StoreID: storeID,
+			})
+		}
+		return r
+	}
+	region := func(numReplicas int32, region string) config.Constraints {
+		return config.Constraints{
+			NumReplicas: numReplicas,
+			Constraints: []config.Constraint{
+				{Type: config.Constraint_REQUIRED, Key: "region", Value: region},
+			},
+		}
+	}
+	// Two replicas in us and one in eu, leaving none for ap.
+	zone := config.ZoneConfig{
+		NumReplicas:        3,
+		ReplicaConstraints: []config.Constraints{region(2, "us"), region(1, "eu")},
+	}
+	ctx := context.Background()
+
+	t.Run("allocate", func(t *testing.T) {
+		testCases := []struct {
+			existing []roachpb.ReplicaDescriptor
+			expected []roachpb.StoreID
+		}{
+			{existing: nil, expected: []roachpb.StoreID{1, 2, 3, 4, 5}},
+			{existing: replicas(1), expected: []roachpb.StoreID{4, 5}},
+			{existing: replicas(1, 2), expected: []roachpb.StoreID{4, 5}},
+			{existing: replicas(1, 4), expected: []roachpb.StoreID{2, 3}},
+			{existing: replicas(4), expected: []roachpb.StoreID{1, 2, 3}},
+		}
+		for _, c := range testCases {
+			t.Run("", func(t *testing.T) {
+				result, _, err := a.AllocateTarget(
+					ctx,
+					zone,
+					c.existing,
+					testRangeInfo(c.existing, firstRange),
+					false, /* relaxConstraints */
+				)
+				if err != nil {
+					t.Fatalf("unable to perform allocation: %v", err)
+				}
+				if !storeHasReplica(result.StoreID, replicas(c.expected...)) {
+					t.Errorf("expected allocate target in %v, but found s%d", c.expected, result.StoreID)
+				}
+			})
+		}
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		testCases := []struct {
+			existing []roachpb.ReplicaDescriptor
+			expected []roachpb.StoreID
+		}{
+			{existing: replicas(1, 2, 3, 4), expected: []roachpb.StoreID{1, 2, 3}},
+			{existing: replicas(1, 2, 4, 5), expected: []roachpb.StoreID{4, 5}},
+			{existing: replicas(1, 2, 4, 6), expected: []roachpb.StoreID{6}},
+		}
+		for _, c := range testCases {
+			t.Run("", func(t *testing.T) {
+				result, _, err := a.RemoveTarget(
+					ctx,
+					zone,
+					c.existing,
+					testRangeInfo(c.existing, firstRange),
+				)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !storeHasReplica(result.StoreID, replicas(c.expected...)) {
+					t.Errorf("expected remove target in %v, but found s%d", c.expected, result.StoreID)
+				}
+			})
+		}
+	})
+
+	t.Run("rebalance", func(t *testing.T) {
+		testCases := []struct {
+			existing []roachpb.ReplicaDescriptor
+			expected []roachpb.StoreID
+		}{
+			{existing: replicas(1, 4, 5), expected: []roachpb.StoreID{2, 3}},
+			{existing: replicas(1, 2, 6), expected: []roachpb.StoreID{4, 5}},
+		}
+		for _, c := range testCases {
+			t.Run("", func(t *testing.T) {
+				result, _ := a.RebalanceTarget(
+					ctx,
+					zone,
+					testRangeInfo(c.existing, firstRange),
+					storeFilterThrottled,
+				)
+				if result == nil {
+					t.Fatalf("expected rebalance target in %v, but found none", c.expected)
+				}
+				if !storeHasReplica(result.StoreID, replicas(c.expected...)) {
+					t.Errorf("expected rebalance target in %v, but found s%d", c.expected, result.StoreID)
+				}
+			})
+		}
+	})
+}
+
 // Test out the load-based lease transfer algorithm against a variety of
 // request distributions and inter-node latencies.
 func TestAllocatorTransferLeaseTargetLoadBased(t *testing.T) {
@@ -1301,7 +1529,7 @@ func TestAllocatorTransferLeaseTargetLoadBased(t *testing.T) {
 			})
 			target := a.TransferLeaseTarget(
 				context.Background(),
-				config.Constraints{},
+				config.ZoneConfig{},
 				existing,
 				c.leaseholder,
 				0,
@@ -1502,7 +1730,7 @@ func TestAllocatorRemoveTarget(t *testing.T) {
 	for i := 0; i < 10; i++ {
 		targetRepl, _, err := a.RemoveTarget(
 			ctx,
-			config.Constraints{},
+			config.ZoneConfig{},
 			replicas,
 			testRangeInfo(replicas, firstRange),
 		)
@@ -2248,7 +2476,7 @@ func TestAllocatorThrottled(t *testing.T) {
 	// First test to make sure we would send the replica to purgatory.
 	_, _, err := a.AllocateTarget(
 		ctx,
-		simpleZoneConfig.Constraints,
+		simpleZoneConfig,
 		[]roachpb.ReplicaDescriptor{},
 		firstRangeInfo,
 		false,
@@ -2261,7 +2489,7 @@ func TestAllocatorThrottled(t *testing.T) {
 	gossiputil.NewStoreGossiper(g).GossipStores(singleStore, t)
 	result, _, err := a.AllocateTarget(
 		ctx,
-		simpleZoneConfig.Constraints,
+		simpleZoneConfig,
 		[]roachpb.ReplicaDescriptor{},
 		firstRangeInfo,
 		false,
@@ -2284,7 +2512,7 @@ func TestAllocatorThrottled(t *testing.T) {
 	a.storePool.detailsMu.Unlock()
 	_, _, err = a.AllocateTarget(
 		ctx,
-		simpleZoneConfig.Constraints,
+		simpleZoneConfig,
 		[]roachpb.ReplicaDescriptor{},
 		firstRangeInfo,
 		false,
@@ -2543,15 +2771,17 @@ func TestAllocatorRebalanceAway(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.constraint.String(), func(t *testing.T) {
-			constraints := config.Constraints{
-				Constraints: []config.Constraint{
-					tc.constraint,
+			zone := config.ZoneConfig{
+				Constraints: config.Constraints{
+					Constraints: []config.Constraint{
+						tc.constraint,
+					},
 				},
 			}
 
 			actual, _ := a.RebalanceTarget(
 				ctx,
-				constraints,
+				zone,
 				testRangeInfo(existingReplicas, firstRange),
 				storeFilterThrottled,
 			)
@@ -2671,7 +2901,7 @@ func Example_rebalancing() {
 			ts := &testStores[j]
 			target, _ := alloc.RebalanceTarget(
 				context.Background(),
-				config.Constraints{},
+				config.ZoneConfig{},
 				testRangeInfo([]roachpb.ReplicaDescriptor{{NodeID: ts.Node.NodeID, StoreID: ts.StoreID}}, firstRange),
 				storeFilterThrottled,
 			)
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -29,6 +30,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/storage"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
@@ -367,6 +369,143 @@ func TestStoreRangeMergeNonCollocated(t *testing.T) {
 	}
 }
 
+// TestStoreRangeMergeRacingWithLeaseExpiration verifies that writes to the
+// right hand side of a merge are not lost when they race with the merge and
+// with the expiration of the right hand side's lease: either the merge fails
+// because the lease was lost, or all the replicas of the merged range see
+// the writes applied before it.
+func TestStoreRangeMergeRacingWithLeaseExpiration(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+
+	mergeBlocked := make(chan struct{})
+	releaseMerge := make(chan struct{})
+	var blockOnce sync.Once
+	storeCfg := storage.TestStoreConfig(nil)
+	storeCfg.TestingKnobs.DisableSplitQueue = true
+	storeCfg.TestingKnobs.DisableMergeQueue = true
+	storeCfg.TestingKnobs.TestingEvalFilter = func(args storagebase.FilterArgs) *roachpb.Error {
+		et, ok := args.Req.(*roachpb.EndTransactionRequest)
+		if !ok || et.InternalCommitTrigger.GetMergeTrigger() == nil {
+			return nil
+		}
+		blockOnce.Do(func() {
+			close(mergeBlocked)
+			<-releaseMerge
+		})
+		return nil
+	}
+	mtc := &multiTestContext{storeConfig: &storeCfg}
+	defer mtc.Stop()
+	mtc.Start(t, 3)
+	store := mtc.stores[0]
+
+	leftDesc, rightDesc, pErr := createSplitRanges(store)
+	if pErr != nil {
+		t.Fatal(pErr)
+	}
+	mtc.replicateRange(leftDesc.RangeID, 1, 2)
+	mtc.replicateRange(rightDesc.RangeID, 1, 2)
+
+	key := roachpb.Key("c")
+	if _, err := mtc.dbs[0].Inc(ctx, key, 1); err != nil {
+		t.Fatal(err)
+	}
+	mtc.waitForValues(key, []int64{1, 1, 1})
+
+	mergeErr := make(chan *roachpb.Error, 1)
+	go func() {
+		_, pErr := client.SendWrapped(ctx, rg1(store), adminMergeArgs(roachpb.KeyMin))
+		mergeErr <- pErr
+	}()
+	<-mergeBlocked
+
+	// The right hand side is frozen now. Let its lease expire and write to it
+	// through another node while the merge is about to commit.
+	mtc.advanceClock(ctx)
+	writeErr := make(chan error, 1)
+	go func() {
+		_, err := mtc.dbs[1].Inc(ctx, key, 1)
+		writeErr <- err
+	}()
+	close(releaseMerge)
+
+	if pErr := <-mergeErr; pErr != nil && !testutils.IsPError(pErr, "lost during merge") {
+		t.Fatal(pErr)
+	}
+	if err := <-writeErr; err != nil {
+		t.Fatal(err)
+	}
+	mtc.waitForValues(key, []int64{2, 2, 2})
+}
+
+// TestStoreRangeMergeWaitForApplication verifies that the replicas of a range
+// report when they have applied its Raft log up to a given index, as a merge
+// requires of the replicas of its right hand side before it is proposed.
+func TestStoreRangeMergeWaitForApplication(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+
+	storeCfg := storage.TestStoreConfig(nil)
+	storeCfg.TestingKnobs.DisableSplitQueue = true
+	storeCfg.TestingKnobs.DisableMergeQueue = true
+	mtc := &multiTestContext{storeConfig: &storeCfg}
+	defer mtc.Stop()
+	mtc.Start(t, 3)
+	store := mtc.stores[0]
+
+	_, rightDesc, pErr := createSplitRanges(store)
+	if pErr != nil {
+		t.Fatal(pErr)
+	}
+	mtc.replicateRange(rightDesc.RangeID, 1, 2)
+
+	key := roachpb.Key("c")
+	if _, err := mtc.dbs[0].Inc(ctx, key, 1); err != nil {
+		t.Fatal(err)
+	}
+	appliedIndex := store.LookupReplica(roachpb.RKey(key), nil).State().RaftAppliedIndex
+
+	waitForApplication := func(
+		ctx context.Context, replica roachpb.ReplicaDescriptor, index uint64,
+	) error {
+		addr, err := mtc.getNodeIDAddress(replica.NodeID)
+		if err != nil {
+			return err
+		}
+		conn, err := mtc.rpcContext.GRPCDial(addr.String())
+		if err != nil {
+			return err
+		}
+		_, err = storage.NewConsistencyClient(conn).WaitForApplication(ctx,
+			&storage.WaitForApplicationRequest{
+				StoreRequestHeader: storage.StoreRequestHeader{
+					NodeID: replica.NodeID, StoreID: replica.StoreID,
+				},
+				RangeID:      rightDesc.RangeID,
+				AppliedIndex: index,
+			})
+		return err
+	}
+
+	desc := store.LookupReplica(roachpb.RKey(key), nil).Desc()
+	for _, replica := range desc.Replicas {
+		if err := waitForApplication(ctx, replica, appliedIndex); err != nil {
+			t.Fatalf("%s: %s", replica, err)
+		}
+	}
+	mtc.waitForValues(key, []int64{1, 1, 1})
+
+	// No replica reaches an index that is never written.
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := waitForApplication(timeoutCtx, desc.Replicas[1], appliedIndex+1000); !testutils.IsError(
+		err, "deadline exceeded",
+	) {
+		t.Fatalf("expected a deadline error, got %v", err)
+	}
+}
+
 // TestStoreRangeMergeStats starts by splitting a range, then writing random data
 // to both sides of the split. It then merges the ranges and verifies the merged
 // range has stats consistent with recomputations.
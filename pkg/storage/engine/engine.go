@@ -227,6 +227,15 @@ type Engine interface {
 	// IngestExternalFile links a file into the RocksDB log-structured
 	// merge-tree.
 	IngestExternalFile(ctx context.Context, path string, move bool) error
+	// WriteFile writes data to a file in the engine's env. Files written
+	// this way are encrypted along with the engine's own files.
+	WriteFile(filename string, data []byte) error
+	// ReadFile reads the contents of a file in the engine's env.
+	ReadFile(filename string) ([]byte, error)
+	// DeleteFile deletes a file in the engine's env.
+	DeleteFile(filename string) error
+	// RenameFile renames a file or directory in the engine's env.
+	RenameFile(oldname, newname string) error
 }
 
 // Batch is the interface for batch specific operations.
@@ -306,6 +306,10 @@ type RocksDBConfig struct {
 	WarnLargeBatchThreshold time.Duration
 	// Settings instance for cluster-wide knobs.
 	Settings *cluster.Settings
+	// ExtraOptions is an opaque blob set by Go CCL code and passed through
+	// to the C CCL code opening the database, where it configures
+	// encryption at rest.
+	ExtraOptions []byte
 }
 
 // RocksDB is a wrapper around a RocksDB database instance.
@@ -445,6 +449,7 @@ func (r *RocksDB) open() error {
 			logging_enabled: C.bool(log.V(3)),
 			num_cpu:         C.int(runtime.NumCPU()),
 			max_open_files:  C.int(maxOpenFiles),
+			extra_options:   goToCSlice(r.cfg.ExtraOptions),
 		})
 	if err := statusToError(status); err != nil {
 		return errors.Errorf("could not open rocksdb instance: %s", err)
@@ -2159,6 +2164,25 @@ func (r *RocksDB) WriteFile(filename string, data []byte) error {
 	return statusToError(C.DBEnvWriteFile(r.rdb, goToCSlice([]byte(filename)), goToCSlice(data)))
 }
 
+// ReadFile reads the contents of a file in this RocksDB's env.
+func (r *RocksDB) ReadFile(filename string) ([]byte, error) {
+	var data C.DBString
+	if err := statusToError(C.DBEnvReadFile(r.rdb, goToCSlice([]byte(filename)), &data)); err != nil {
+		return nil, err
+	}
+	return cStringToGoBytes(data), nil
+}
+
+// DeleteFile deletes a file in this RocksDB's env.
+func (r *RocksDB) DeleteFile(filename string) error {
+	return statusToError(C.DBEnvDeleteFile(r.rdb, goToCSlice([]byte(filename))))
+}
+
+// RenameFile renames a file or directory in this RocksDB's env.
+func (r *RocksDB) RenameFile(oldname, newname string) error {
+	return statusToError(C.DBEnvRenameFile(r.rdb, goToCSlice([]byte(oldname)), goToCSlice([]byte(newname))))
+}
+
 // IsValidSplitKey returns whether the key is a valid split key. Certain key
 // ranges cannot be split (the meta1 span and the system DB span); split keys
 // chosen within any of these ranges are considered invalid. And a split key
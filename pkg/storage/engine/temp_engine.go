@@ -57,6 +57,7 @@ func NewTempEngine(ctx context.Context, storeCfg base.StoreSpec) (Engine, error)
 		// MaxSizeBytes doesn't matter for temp stores - it's not enforced in any way.
 		MaxSizeBytes: 0,
 		MaxOpenFiles: 128, // TODO(arjun): Revisit this.
+		ExtraOptions: storeCfg.ExtraOptions,
 	}
 	rocksDBCache := NewRocksDBCache(0)
 	rocksdb, err := NewRocksDB(rocksDBCfg, rocksDBCache)
@@ -388,7 +388,7 @@ func processLocalKeyRange(
 
 		// The transaction record should be considered for removal.
 		switch txn.Status {
-		case roachpb.PENDING:
+		case roachpb.PENDING, roachpb.STAGING:
 			// Marked as running, so we need to push it to abort it but won't
 			// try to GC it in this cycle (for convenience).
 			// TODO(tschottdorf): refactor so that we can GC PENDING entries
@@ -862,7 +862,7 @@ func RunGC(
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, gcTaskLimit)
 	for _, txn := range txnMap {
-		if txn.Status != roachpb.PENDING {
+		if txn.Status.IsFinalized() {
 			continue
 		}
 		wg.Add(1)
@@ -891,7 +891,7 @@ func RunGC(
 	log.Eventf(ctx, "resolving up to %d intents", len(txnMap))
 	var intents []roachpb.Intent
 	for txnID, txn := range txnMap {
-		if txn.Status != roachpb.PENDING {
+		if txn.Status.IsFinalized() {
 			for _, intent := range intentSpanMap[txnID] {
 				intents = append(intents, roachpb.Intent{Span: intent, Status: txn.Status, Txn: txn.TxnMeta})
 			}
@@ -946,6 +946,16 @@ func pushTxn(
 		return
 	}
 	br := b.RawResponse()
+	pushee := br.Responses[0].GetInner().(*roachpb.PushTxnResponse).PusheeTxn
+	if pushee.Status == roachpb.STAGING {
+		// The txn may be implicitly committed; find out by recovering it.
+		recovered, err := recoverStagingTxn(ctx, db, pushee)
+		if err != nil {
+			log.Warningf(ctx, "recovery of txn %s failed: %s", txn, err)
+			return
+		}
+		pushee = recovered
+	}
 	// Update the supplied txn on successful push.
-	*txn = br.Responses[0].GetInner().(*roachpb.PushTxnResponse).PusheeTxn
+	*txn = pushee
 }
@@ -236,6 +236,16 @@ func (ir *intentResolver) maybePushTransactions(
 		if _, ok := pushedTxns[txn.ID]; ok {
 			panic(fmt.Sprintf("have two PushTxn responses for %s", txn.ID))
 		}
+		// A STAGING pushee which is already above the timestamp we tried to
+		// push it to doesn't conflict with us. Otherwise, it can't be pushed
+		// and has to be recovered.
+		if txn.Status == roachpb.STAGING &&
+			!(pushType == roachpb.PUSH_TIMESTAMP && h.Timestamp.Less(txn.Timestamp)) {
+			var err error
+			if txn, err = recoverStagingTxn(ctx, ir.store.db, txn); err != nil {
+				return nil, roachpb.NewError(err)
+			}
+		}
 		pushedTxns[txn.ID] = txn
 		log.Eventf(ctx, "%s is now %s", txn.ID, txn.Status)
 	}
@@ -0,0 +1,206 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/config"
+	"github.com/cockroachdb/cockroach/pkg/gossip"
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+const (
+	// mergeQueueTimerDuration is the duration between merges of queued ranges.
+	mergeQueueTimerDuration = time.Second
+)
+
+var mergeQueueEnabled = settings.RegisterBoolSetting(
+	"kv.range_merge.queue_enabled",
+	"whether the automatic merge queue is enabled",
+	false,
+)
+
+// mergeQueue manages a queue of ranges slated to be merged with their right
+// neighbor because they are smaller than the minimum range size of their
+// zone.
+//
+// A range is merged with its right neighbor if both ranges are in the same
+// zone and the merged range would not need to be split again. Before merging,
+// the replicas and the lease of the right neighbor are relocated to the stores
+// of the range, as AdminMerge requires.
+type mergeQueue struct {
+	*baseQueue
+	db *client.DB
+}
+
+// newMergeQueue returns a new instance of mergeQueue.
+func newMergeQueue(store *Store, db *client.DB, gossip *gossip.Gossip) *mergeQueue {
+	mq := &mergeQueue{
+		db: db,
+	}
+	mq.baseQueue = newBaseQueue(
+		"merge", mq, store, gossip,
+		queueConfig{
+			maxSize:              defaultQueueMaxSize,
+			needsLease:           true,
+			needsSystemConfig:    true,
+			acceptsUnsplitRanges: false,
+			successes:            store.metrics.MergeQueueSuccesses,
+			failures:             store.metrics.MergeQueueFailures,
+			pending:              store.metrics.MergeQueuePending,
+			processingNanos:      store.metrics.MergeQueueProcessingNanos,
+		},
+	)
+	return mq
+}
+
+// shouldQueue determines whether a range should be queued for merging. This
+// is true if the range is smaller than the minimum size for its zone. The
+// priority is higher for smaller ranges.
+func (mq *mergeQueue) shouldQueue(
+	ctx context.Context, now hlc.Timestamp, repl *Replica, sysCfg config.SystemConfig,
+) (bool, float64) {
+	if !mergeQueueEnabled.Get(&repl.store.ClusterSettings().SV) {
+		return false, 0
+	}
+	desc := repl.Desc()
+	if !mergeableRange(desc) {
+		return false, 0
+	}
+	if repl.exceedsLoadSplitThreshold() {
+		// The range would be split again because of its load.
+		return false, 0
+	}
+	zone, err := sysCfg.GetZoneConfigForKey(desc.StartKey)
+	if err != nil {
+		log.ErrEventf(ctx, "could not find zone config: %s", err)
+		return false, 0
+	}
+	if zone.RangeMinBytes <= 0 {
+		return false, 0
+	}
+	size := repl.GetMVCCStats().Total()
+	if size >= zone.RangeMinBytes {
+		return false, 0
+	}
+	return true, 1 - float64(size)/float64(zone.RangeMinBytes)
+}
+
+// mergeableRange returns whether the range can be merged with its right
+// neighbor. The last range has no right neighbor, and the system ranges
+// (including the meta ranges) are never merged.
+func mergeableRange(desc *roachpb.RangeDescriptor) bool {
+	return !desc.EndKey.Equal(roachpb.RKeyMax) &&
+		!desc.StartKey.Less(roachpb.RKey(keys.SystemMax))
+}
+
+// process merges the range with its right neighbor, after relocating the
+// right neighbor to the stores of the range if necessary.
+func (mq *mergeQueue) process(ctx context.Context, r *Replica, sysCfg config.SystemConfig) error {
+	desc := r.Desc()
+	if !mergeableRange(desc) {
+		return nil
+	}
+
+	var rightDesc roachpb.RangeDescriptor
+	if err := mq.db.GetProto(ctx, keys.RangeDescriptorKey(desc.EndKey), &rightDesc); err != nil {
+		return err
+	}
+	if rightDesc.RangeID == 0 || !rightDesc.StartKey.Equal(desc.EndKey) {
+		return errors.Errorf("%s: could not find the right neighbor of the range", r)
+	}
+
+	// Don't merge ranges that belong to different zones or tables, as the
+	// split queue would split them again.
+	if sysCfg.NeedsSplit(desc.StartKey, rightDesc.EndKey) {
+		log.VEventf(ctx, 2, "not merging %s with r%d: the ranges must stay split",
+			r, rightDesc.RangeID)
+		return nil
+	}
+
+	zone, err := sysCfg.GetZoneConfigForKey(desc.StartKey)
+	if err != nil {
+		return err
+	}
+	b := &client.Batch{}
+	b.AddRawRequest(&roachpb.RangeStatsRequest{
+		Span: roachpb.Span{Key: rightDesc.StartKey.AsRawKey()},
+	})
+	if err := mq.db.Run(ctx, b); err != nil {
+		return err
+	}
+	rightStats := b.RawResponse().Responses[0].GetInner().(*roachpb.RangeStatsResponse).MVCCStats
+	leftStats := r.GetMVCCStats()
+	if mergedSize := leftStats.Total() + rightStats.Total(); mergedSize >= zone.RangeMaxBytes {
+		log.VEventf(ctx, 2, "not merging %s with r%d: the merged range would be too large (%d bytes)",
+			r, rightDesc.RangeID, mergedSize)
+		return nil
+	}
+
+	// Collocate the right neighbor with this range, with the lease on this
+	// store.
+	rightRepl, err := r.store.GetReplica(rightDesc.RangeID)
+	if !replicaSetsEqual(desc.Replicas, rightDesc.Replicas) || err != nil ||
+		!rightRepl.OwnsValidLease(r.store.Clock().Now()) {
+		targets := []roachpb.ReplicationTarget{{
+			NodeID:  r.store.Ident.NodeID,
+			StoreID: r.store.Ident.StoreID,
+		}}
+		for _, repl := range desc.Replicas {
+			if repl.StoreID != r.store.Ident.StoreID {
+				targets = append(targets, roachpb.ReplicationTarget{
+					NodeID:  repl.NodeID,
+					StoreID: repl.StoreID,
+				})
+			}
+		}
+		log.VEventf(ctx, 1, "relocating r%d to %v before merging it", rightDesc.RangeID, targets)
+		if err := RelocateRange(ctx, mq.db, rightDesc, targets); err != nil {
+			return err
+		}
+		// The relocation changed the descriptor of the right neighbor.
+		if err := mq.db.GetProto(ctx, keys.RangeDescriptorKey(desc.EndKey), &rightDesc); err != nil {
+			return err
+		}
+	}
+
+	log.VEventf(ctx, 1, "merging %s with r%d", r, rightDesc.RangeID)
+	if _, pErr := r.adminMergeWithDescriptor(ctx, &rightDesc); pErr != nil {
+		return pErr.GoError()
+	}
+
+	// The merged range may still be smaller than the minimum size.
+	mq.MaybeAdd(r, r.store.Clock().Now())
+	return nil
+}
+
+// timer returns interval between processing successive queued merges.
+func (*mergeQueue) timer(_ time.Duration) time.Duration {
+	return mergeQueueTimerDuration
+}
+
+// purgatoryChan returns nil.
+func (*mergeQueue) purgatoryChan() <-chan struct{} {
+	return nil
+}
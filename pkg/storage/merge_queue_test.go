@@ -0,0 +1,109 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"math"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/config"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+)
+
+// TestMergeQueueShouldQueue verifies that shouldQueue only queues ranges
+// which are smaller than the minimum size of their zone and have a mergeable
+// right neighbor.
+func TestMergeQueueShouldQueue(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.TODO())
+	tc.Start(t, stopper)
+
+	// Set zone configs.
+	config.TestingSetZoneConfig(2000, config.ZoneConfig{RangeMinBytes: 1 << 20, RangeMaxBytes: 64 << 20})
+	config.TestingSetZoneConfig(2001, config.ZoneConfig{RangeMinBytes: 0, RangeMaxBytes: 64 << 20})
+
+	table2000 := roachpb.RKey(keys.MakeTablePrefix(2000))
+	table2001 := roachpb.RKey(keys.MakeTablePrefix(2001))
+
+	testCases := []struct {
+		start, end roachpb.RKey
+		bytes      int64
+		shouldQ    bool
+		priority   float64
+	}{
+		// System ranges are never merged.
+		{roachpb.RKeyMin, roachpb.RKey(keys.MetaMax), 0, false, 0},
+		// The last range has no right neighbor.
+		{table2000, roachpb.RKeyMax, 0, false, 0},
+		// Empty range.
+		{table2000, table2000.PrefixEnd(), 0, true, 1},
+		// Half the minimum size.
+		{table2000, table2000.PrefixEnd(), 1 << 19, true, 0.5},
+		// Minimum size.
+		{table2000, table2000.PrefixEnd(), 1 << 20, false, 0},
+		// No minimum size in the zone.
+		{table2001, table2001.PrefixEnd(), 0, false, 0},
+	}
+
+	mergeQ := newMergeQueue(tc.store, nil, tc.gossip)
+
+	cfg, ok := tc.gossip.GetSystemConfig()
+	if !ok {
+		t.Fatal("config not set")
+	}
+
+	for _, enabled := range []bool{false, true} {
+		mergeQueueEnabled.Override(&tc.store.ClusterSettings().SV, enabled)
+		for i, test := range testCases {
+			// Create a replica for testing that is not hooked up to the store. This
+			// ensures that the store won't be mucking with our replica concurrently
+			// during testing (e.g. via the system config gossip update).
+			copy := *tc.repl.Desc()
+			copy.StartKey = test.start
+			copy.EndKey = test.end
+			repl, err := NewReplica(&copy, tc.store, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			repl.mu.Lock()
+			repl.mu.state.Stats = &enginepb.MVCCStats{KeyBytes: test.bytes}
+			repl.mu.Unlock()
+
+			expectedShouldQ, expectedPriority := test.shouldQ, test.priority
+			if !enabled {
+				expectedShouldQ, expectedPriority = false, 0
+			}
+			shouldQ, priority := mergeQ.shouldQueue(context.TODO(), hlc.Timestamp{}, repl, cfg)
+			if shouldQ != expectedShouldQ {
+				t.Errorf("%d (enabled=%t): should queue expected %t; got %t",
+					i, enabled, expectedShouldQ, shouldQ)
+			}
+			if math.Abs(priority-expectedPriority) > 0.00001 {
+				t.Errorf("%d (enabled=%t): priority expected %f; got %f",
+					i, enabled, expectedPriority, priority)
+			}
+		}
+	}
+}
@@ -234,6 +234,9 @@ type Replica struct {
 	// writeStats tracks the number of keys written by applied raft commands
 	// in order to aid in replica rebalancing decisions.
 	writeStats *replicaStats
+	// loadBasedSplitter samples the keys of incoming BatchRequests when the
+	// range is hot in order to find a split key which spreads its load.
+	loadBasedSplitter loadBasedSplitter
 
 	// creatingReplica is set when a replica is created as uninitialized
 	// via a raft message.
@@ -281,6 +284,17 @@ type Replica struct {
 		queues [numSpanScope]*CommandQueue
 	}
 
+	rangefeedMu struct {
+		// Protects all fields in the rangefeedMu struct.
+		//
+		// Locking notes: Replica.raftMu < Replica.rangefeedMu. Replica.mu is
+		// never held while acquiring rangefeedMu.
+		syncutil.Mutex
+		// The processor publishing applied writes to the replica's rangefeed
+		// registrations, or nil if there are none.
+		proc *rangeFeedProcessor
+	}
+
 	mu struct {
 		// Protects all fields in the mu struct.
 		syncutil.RWMutex
@@ -342,6 +356,13 @@ type Replica struct {
 		minLeaseProposedTS hlc.Timestamp
 		// Max bytes before split.
 		maxBytes int64
+		// mergeFrozen is set while the range is being subsumed by its left
+		// neighbor. A frozen replica doesn't serve reads or propose commands
+		// other than lease requests; see freezeForMerge.
+		mergeFrozen bool
+		// closedTS tracks the timestamp below which the leaseholder has
+		// promised not to accept writes; see closedTimestampTracker.
+		closedTS closedTimestampTracker
 		// proposals stores the Raft in-flight commands which
 		// originated at this Replica, i.e. all commands for which
 		// propose has been called, but which have not yet
@@ -864,6 +885,7 @@ func (r *Replica) setReplicaIDRaftMuLockedMuLocked(replicaID roachpb.ReplicaID)
 	var err error
 	if r.raftMu.sideloaded, err = newDiskSideloadStorage(
 		r.store.cfg.Settings, r.mu.state.Desc.RangeID, replicaID, r.store.Engine().GetAuxiliaryDir(),
+		r.store.Engine(),
 	); err != nil {
 		return errors.Wrap(err, "while initializing sideloaded storage")
 	}
@@ -875,7 +897,9 @@ func (r *Replica) setReplicaIDRaftMuLockedMuLocked(replicaID roachpb.ReplicaID)
 			// Old directory not found.
 		} else {
 			// Old directory found, so we have something to move over to the new one.
-			if err := os.Rename(prevSideloadedDir, r.raftMu.sideloaded.Dir()); err != nil {
+			if err := moveSideloadedDir(
+				r.store.Engine(), prevSideloadedDir, r.raftMu.sideloaded.Dir(),
+			); err != nil {
 				return errors.Wrap(err, "while moving sideloaded directory")
 			}
 		}
@@ -1141,6 +1165,14 @@ func (r *Replica) IsFirstRange() bool {
 	return r.RangeID == 1
 }
 
+// isMergeFrozen returns true if the replica is frozen while it is subsumed
+// by its left neighbor.
+func (r *Replica) isMergeFrozen() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.mu.mergeFrozen
+}
+
 // IsDestroyed returns a non-nil error if the replica has been destroyed.
 func (r *Replica) IsDestroyed() error {
 	r.mu.RLock()
@@ -1763,6 +1795,7 @@ func (r *Replica) Send(
 
 	if r.leaseholderStats != nil && ba.Header.GatewayNodeID != 0 {
 		r.leaseholderStats.record(ba.Header.GatewayNodeID)
+		r.recordBatchForLoadBasedSplitting(ba)
 	}
 
 	if err := r.checkBatchRequest(ba); err != nil {
@@ -2403,9 +2436,12 @@ func (r *Replica) executeAdminBatch(
 func (r *Replica) executeReadOnlyBatch(
 	ctx context.Context, ba roachpb.BatchRequest,
 ) (br *roachpb.BatchResponse, pErr *roachpb.Error) {
-	// If the read is consistent, the read requires the range lease.
+	// If the read is consistent, the read requires the range lease, unless
+	// its timestamp is closed and it can be served by this follower.
 	if ba.ReadConsistency != roachpb.INCONSISTENT {
-		if _, pErr = r.redirectOnOrAcquireLease(ctx); pErr != nil {
+		if r.canServeFollowerRead(ba) {
+			log.Event(ctx, "serving follower read")
+		} else if _, pErr = r.redirectOnOrAcquireLease(ctx); pErr != nil {
 			return nil, pErr
 		}
 	}
@@ -2438,6 +2474,9 @@ func (r *Replica) executeReadOnlyBatch(
 	if err := r.IsDestroyed(); err != nil {
 		return nil, roachpb.NewError(err)
 	}
+	if r.isMergeFrozen() {
+		return nil, roachpb.NewError(roachpb.NewRangeNotFoundError(r.RangeID))
+	}
 
 	rSpan, err := keys.Range(ba)
 	if err != nil {
@@ -2891,6 +2930,12 @@ func (r *Replica) propose(
 		return nil, nil, noop, roachpb.NewError(err)
 	}
 
+	// Forward the batch past the closed timestamp. The write is tracked until
+	// it has been assigned a lease index (or failed), which prevents the
+	// closed timestamp from advancing past it in the meantime.
+	untrackClosedTimestamp := r.forwardPastClosedTimestamp(&ba)
+	defer untrackClosedTimestamp()
+
 	idKey := makeIDKey()
 	proposal, pErr := r.requestToProposal(ctx, idKey, ba, endCmds, spans)
 	log.Event(proposal.ctx, "evaluated request")
@@ -2967,6 +3012,12 @@ func (r *Replica) propose(
 	if err := r.mu.destroyed; err != nil {
 		return nil, nil, undoQuotaAcquisition, roachpb.NewError(err)
 	}
+	// Lease requests are let through so that the frozen replica keeps its
+	// lease until the merge commits; it must not expire and be acquired by a
+	// replica that isn't frozen.
+	if r.mu.mergeFrozen && !ba.IsLeaseRequest() {
+		return nil, nil, undoQuotaAcquisition, roachpb.NewError(roachpb.NewRangeNotFoundError(r.RangeID))
+	}
 
 	repDesc, err := r.getReplicaDescriptorRLocked()
 	if err != nil {
@@ -2991,6 +3042,19 @@ func (r *Replica) propose(
 		delete(r.mu.proposals, proposal.idKey)
 		return nil, nil, undoQuotaAcquisition, roachpb.NewError(err)
 	}
+	if canAckBeforeApplication(ba, proposal) {
+		// The client has asked to be acknowledged as soon as its writes
+		// have been proposed; it will prove their success with QueryIntent
+		// requests before relying on them. The proposal continues to apply
+		// in the background under a context which outlives the client's,
+		// as if the client had abandoned it.
+		reply := *proposal.Local.Reply
+		proposal.ctx = r.AnnotateCtx(context.TODO())
+		ch := make(chan proposalResult, 1)
+		ch <- proposalResult{Reply: &reply}
+		close(ch)
+		return ch, func() bool { return false }, undoQuotaAcquisition, nil
+	}
 	// Must not use `proposal` in the closure below as a proposal which is not
 	// present in r.mu.proposals is no longer protected by the mutex. Abandoning
 	// a command only abandons the associated context. As soon as we propose a
@@ -3013,6 +3077,21 @@ func (r *Replica) propose(
 	return proposal.doneCh, tryAbandon, undoQuotaAcquisition, nil
 }
 
+// canAckBeforeApplication returns whether the evaluated proposal for the
+// given batch may be acknowledged to the client before it has applied. This
+// is the case for transactional batches which requested asynchronous
+// consensus, evaluated without an error and do not end their transaction:
+// a commit must never be acknowledged before it is durable.
+func canAckBeforeApplication(ba roachpb.BatchRequest, proposal *ProposalData) bool {
+	if !ba.AsyncConsensus || ba.Txn == nil {
+		return false
+	}
+	if _, hasET := ba.GetArg(roachpb.EndTransaction); hasET {
+		return false
+	}
+	return proposal.Local.Err == nil && proposal.Local.Reply != nil
+}
+
 // submitProposalLocked proposes or re-proposes a command in r.mu.proposals.
 // The replica lock must be held.
 func (r *Replica) submitProposalLocked(p *ProposalData) error {
@@ -3625,6 +3704,12 @@ func (r *Replica) tick() (bool, error) {
 		if !enablePreVote {
 			r.mu.internalRaftGroup.TickQuiesced()
 		}
+		if r.shouldPublishClosedTimestampLocked(r.store.Clock().Now()) {
+			// Resend the quiesce heartbeats, which carry a new closed timestamp
+			// for the followers.
+			ctx := r.AnnotateCtx(context.TODO())
+			r.quiesceAndNotifyLocked(ctx, r.raftStatusRLocked())
+		}
 		return false, nil
 	}
 	if r.maybeQuiesceLocked() {
@@ -3656,7 +3741,7 @@ func (r *Replica) maybeTickQuiesced() bool {
 	r.mu.Lock()
 	if r.mu.internalRaftGroup == nil {
 		done = true
-	} else if r.mu.quiescent {
+	} else if r.mu.quiescent && !r.shouldPublishClosedTimestampLocked(r.store.Clock().Now()) {
 		done = true
 		if !enablePreVote {
 			// NB: It is safe to call TickQuiesced without holding Replica.raftMu
@@ -3866,6 +3951,7 @@ func (r *Replica) quiesceAndNotifyLocked(ctx context.Context, status *raft.Statu
 	}
 
 	r.quiesceLocked()
+	closedTS, leaseAppliedIndex := r.closeTimestampLocked(r.store.Clock().Now())
 	for id := range status.Progress {
 		if roachpb.ReplicaID(id) == r.mu.replicaID {
 			continue
@@ -3887,7 +3973,9 @@ func (r *Replica) quiesceAndNotifyLocked(ctx context.Context, status *raft.Statu
 			Commit: status.Commit,
 		}
 
-		if r.maybeCoalesceHeartbeat(ctx, msg, toReplica, fromReplica, true) {
+		if r.maybeCoalesceHeartbeat(
+			ctx, msg, toReplica, fromReplica, true, closedTS, leaseAppliedIndex,
+		) {
 			continue
 		}
 
@@ -4062,6 +4150,8 @@ func (r *Replica) maybeCoalesceHeartbeat(
 	msg raftpb.Message,
 	toReplica, fromReplica roachpb.ReplicaDescriptor,
 	quiesce bool,
+	closedTS hlc.Timestamp,
+	leaseAppliedIndex uint64,
 ) bool {
 	var hbMap map[roachpb.StoreIdent][]RaftHeartbeat
 	switch msg.Type {
@@ -4081,6 +4171,9 @@ func (r *Replica) maybeCoalesceHeartbeat(
 		Term:          msg.Term,
 		Commit:        msg.Commit,
 		Quiesce:       quiesce,
+
+		ClosedTimestamp:   closedTS,
+		LeaseAppliedIndex: leaseAppliedIndex,
 	}
 	if log.V(4) {
 		log.Infof(ctx, "coalescing beat: %+v", beat)
@@ -4099,6 +4192,11 @@ func (r *Replica) sendRaftMessage(ctx context.Context, msg raftpb.Message) {
 	r.mu.Lock()
 	fromReplica, fromErr := r.getReplicaDescriptorByIDRLocked(roachpb.ReplicaID(msg.From), r.mu.lastToReplica)
 	toReplica, toErr := r.getReplicaDescriptorByIDRLocked(roachpb.ReplicaID(msg.To), r.mu.lastFromReplica)
+	var closedTS hlc.Timestamp
+	var leaseAppliedIndex uint64
+	if msg.Type == raftpb.MsgHeartbeat {
+		closedTS, leaseAppliedIndex = r.closeTimestampLocked(r.store.Clock().Now())
+	}
 	r.mu.Unlock()
 
 	if fromErr != nil {
@@ -4120,7 +4218,7 @@ func (r *Replica) sendRaftMessage(ctx context.Context, msg raftpb.Message) {
 		return
 	}
 
-	if r.maybeCoalesceHeartbeat(ctx, msg, toReplica, fromReplica, false) {
+	if r.maybeCoalesceHeartbeat(ctx, msg, toReplica, fromReplica, false, closedTS, leaseAppliedIndex) {
 		return
 	}
 
@@ -4400,6 +4498,9 @@ func (r *Replica) processRaftCommand(
 		// values) here. If the key range we are ingesting into isn't empty,
 		// we're not using AddSSTable but a plain WriteBatch.
 		if raftCmd.ReplicatedEvalResult.AddSSTable != nil {
+			// Ingested SSTables bypass the write batch, so the rangefeeds of the
+			// replica can't follow them.
+			r.disconnectRangeFeedsRaftMuLocked(r.rangeKeyMismatchErrorForRangeFeeds())
 			addSSTablePreApply(
 				ctx,
 				r.store.cfg.Settings,
@@ -4460,13 +4561,20 @@ func (r *Replica) processRaftCommand(
 			lResult = proposal.Local
 		}
 
+		// The rangefeeds of the replica only learn about writes which were
+		// committed.
+		appliedBatch := writeBatch
+		if pErr != nil {
+			appliedBatch = nil
+		}
+
 		// Handle the EvalResult, executing any side effects of the last
 		// state machine transition.
 		//
 		// Note that this must happen after committing (the engine.Batch), but
 		// before notifying a potentially waiting client.
 		r.handleEvalResultRaftMuLocked(ctx, lResult,
-			raftCmd.ReplicatedEvalResult, raftIndex, leaseIndex)
+			raftCmd.ReplicatedEvalResult, appliedBatch, raftIndex, leaseIndex)
 	}
 
 	if proposedLocally {
@@ -4548,8 +4656,10 @@ func (r *Replica) acquireMergeLock(
 		log.Fatalf(ctx, "unable to find merge RHS replica: %s", err)
 	}
 
-	// TODO(peter,tschottdorf): This is necessary but likely not sufficient. The
-	// right hand side of the merge can still race on reads. See #8630.
+	// Reads and proposals on the right hand side were stopped by
+	// freezeForMerge on the lease holder before the merge was proposed, and
+	// all the replicas of the right hand side had applied the commands
+	// committed before that (see waitForApplication).
 	rightRng.raftMu.Lock()
 	return func(storagebase.ReplicatedEvalResult) {
 		rightRng.raftMu.Unlock()
@@ -0,0 +1,219 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+)
+
+// closedTimestampTracker tracks the closed timestamp of a replica. A closed
+// timestamp is a promise made by the leaseholder not to accept any further
+// writes at or below it. The leaseholder periodically publishes its closed
+// timestamp to the followers along with the lease applied index of the last
+// command it has proposed (see Replica.closeTimestampLocked). A follower
+// which has applied that command has seen every write at or below the closed
+// timestamp and can serve consistent reads at those timestamps without
+// consulting the leaseholder.
+//
+// The closed timestamp is not persisted. A replica which restarts or receives
+// the lease starts out with whatever it has learned as a follower, which is
+// sufficient since the timestamp cache of a new leaseholder forwards all
+// writes past the start of its lease.
+//
+// All methods require Replica.mu to be held.
+type closedTimestampTracker struct {
+	// closed is the highest closed timestamp that the replica can rely on.
+	// On the leaseholder, writes are forwarded past it.
+	closed hlc.Timestamp
+	// closedAt is the time at which the replica last closed a timestamp as
+	// the leaseholder.
+	closedAt hlc.Timestamp
+	// pending is a closed timestamp received from the leaseholder which
+	// becomes usable once the replica has applied pendingLAI.
+	pending    hlc.Timestamp
+	pendingLAI uint64
+	// evaluating holds the timestamps of writes which have been forwarded
+	// past the closed timestamp but haven't been assigned a lease index yet.
+	// The closed timestamp must not advance past any of them.
+	evaluating map[int64]hlc.Timestamp
+	nextID     int64
+}
+
+// track registers a write which is about to be evaluated at the given
+// timestamp. It returns the timestamp, forwarded past the closed timestamp,
+// at which the write must be evaluated instead and a token for untrack.
+func (t *closedTimestampTracker) track(ts hlc.Timestamp) (hlc.Timestamp, int64) {
+	ts.Forward(t.closed.Next())
+	if t.evaluating == nil {
+		t.evaluating = map[int64]hlc.Timestamp{}
+	}
+	t.nextID++
+	t.evaluating[t.nextID] = ts
+	return ts, t.nextID
+}
+
+// untrack stops tracking the write with the given token. It is a no-op if
+// the write is no longer tracked.
+func (t *closedTimestampTracker) untrack(id int64) {
+	delete(t.evaluating, id)
+}
+
+// close advances the closed timestamp towards target without passing any
+// write which is still being evaluated, and returns the new closed timestamp.
+func (t *closedTimestampTracker) close(target, now hlc.Timestamp) hlc.Timestamp {
+	for _, ts := range t.evaluating {
+		if prev := ts.Prev(); prev.Less(target) {
+			target = prev
+		}
+	}
+	t.closed.Forward(target)
+	t.closedAt = now
+	return t.closed
+}
+
+// update records a closed timestamp published by the leaseholder, which the
+// replica can rely on once it has applied the given lease index. appliedLAI
+// is the replica's current lease applied index.
+func (t *closedTimestampTracker) update(closed hlc.Timestamp, lai, appliedLAI uint64) {
+	t.closed = t.get(appliedLAI)
+	t.pending, t.pendingLAI = hlc.Timestamp{}, 0
+	if lai <= appliedLAI {
+		t.closed.Forward(closed)
+	} else if t.closed.Less(closed) {
+		t.pending, t.pendingLAI = closed, lai
+	}
+}
+
+// get returns the closed timestamp the replica can rely on given its lease
+// applied index.
+func (t *closedTimestampTracker) get(appliedLAI uint64) hlc.Timestamp {
+	closed := t.closed
+	if t.pendingLAI != 0 && t.pendingLAI <= appliedLAI {
+		closed.Forward(t.pending)
+	}
+	return closed
+}
+
+// forwardPastClosedTimestamp forwards the timestamp of a batch which may
+// write past the closed timestamp of the replica and tracks the write until
+// it has been proposed. The returned function stops tracking the write; it
+// must be called once the write has been assigned a lease index or has
+// failed to be proposed.
+func (r *Replica) forwardPastClosedTimestamp(ba *roachpb.BatchRequest) func() {
+	var writes bool
+	for _, union := range ba.Requests {
+		if roachpb.ConsultsTimestampCache(union.GetInner()) {
+			writes = true
+			break
+		}
+	}
+	if !writes {
+		return func() {}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var id int64
+	if ba.Txn != nil {
+		var ts hlc.Timestamp
+		if ts, id = r.mu.closedTS.track(ba.Txn.Timestamp); ts != ba.Txn.Timestamp {
+			txn := ba.Txn.Clone()
+			txn.Timestamp = ts
+			ba.Txn = &txn
+		}
+	} else {
+		ba.Timestamp, id = r.mu.closedTS.track(ba.Timestamp)
+	}
+	return func() {
+		r.mu.Lock()
+		r.mu.closedTS.untrack(id)
+		r.mu.Unlock()
+	}
+}
+
+// closeTimestampLocked closes a new timestamp if the replica holds a valid
+// lease and timestamps are being closed. It returns the closed timestamp and
+// the lease index which a follower needs to have applied to rely on it, or
+// zero values if no timestamp was closed.
+func (r *Replica) closeTimestampLocked(now hlc.Timestamp) (hlc.Timestamp, uint64) {
+	target := storagebase.ClosedTimestampTarget(&r.store.cfg.Settings.SV, now)
+	if target == (hlc.Timestamp{}) || !r.ownsValidLeaseRLocked(now) {
+		return hlc.Timestamp{}, 0
+	}
+	// Every write at or below the closed timestamp has been assigned a lease
+	// index at or below lai. Writes which were assigned a lower index but
+	// haven't applied yet will be rejected and evaluated again when a later
+	// command applies first.
+	lai := r.mu.lastAssignedLeaseIndex
+	if lai < r.mu.state.LeaseAppliedIndex {
+		lai = r.mu.state.LeaseAppliedIndex
+	}
+	return r.mu.closedTS.close(target, now), lai
+}
+
+// shouldPublishClosedTimestampLocked returns true if the replica is the
+// quiesced Raft leader and leaseholder of the range and hasn't published a
+// closed timestamp for a full target duration. Without Raft heartbeats, the
+// followers of a quiesced range would otherwise stop learning about new
+// closed timestamps.
+func (r *Replica) shouldPublishClosedTimestampLocked(now hlc.Timestamp) bool {
+	if !r.mu.quiescent || r.mu.replicaID != r.mu.leaderID {
+		return false
+	}
+	target := storagebase.ClosedTimestampTarget(&r.store.cfg.Settings.SV, now)
+	if target == (hlc.Timestamp{}) || !r.ownsValidLeaseRLocked(now) {
+		return false
+	}
+	targetDuration := storagebase.ClosedTimestampTargetDuration.Get(&r.store.cfg.Settings.SV)
+	return r.mu.closedTS.closedAt.Add(targetDuration.Nanoseconds(), 0).Less(now)
+}
+
+// updateClosedTimestamp records a closed timestamp received from the
+// leaseholder in a heartbeat addressed to the given replica ID.
+func (r *Replica) updateClosedTimestamp(
+	replicaID roachpb.ReplicaID, closed hlc.Timestamp, lai uint64,
+) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.mu.replicaID != replicaID {
+		return
+	}
+	r.mu.closedTS.update(closed, lai, r.mu.state.LeaseAppliedIndex)
+}
+
+// canServeFollowerRead returns true if the batch is a consistent read which
+// the replica can serve without holding the lease because its timestamp
+// (including the uncertainty interval of its transaction) is closed.
+func (r *Replica) canServeFollowerRead(ba roachpb.BatchRequest) bool {
+	if ba.ReadConsistency != roachpb.CONSISTENT ||
+		!storagebase.FollowerReadsEnabled.Get(&r.store.cfg.Settings.SV) {
+		return false
+	}
+	ts := ba.Timestamp
+	if ba.Txn != nil {
+		ts.Forward(ba.Txn.MaxTimestamp)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.mu.state.Lease.OwnedBy(r.store.StoreID()) {
+		// The leaseholder serves reads the regular way, which also updates the
+		// timestamp cache.
+		return false
+	}
+	return !r.mu.closedTS.get(r.mu.state.LeaseAppliedIndex).Less(ts)
+}
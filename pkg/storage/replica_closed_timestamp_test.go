@@ -0,0 +1,120 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+)
+
+func TestClosedTimestampTracker(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ts := func(wallTime int64) hlc.Timestamp {
+		return hlc.Timestamp{WallTime: wallTime}
+	}
+
+	var lh closedTimestampTracker
+	// A write which is being evaluated holds back the closed timestamp.
+	if fwd, id := lh.track(ts(10)); fwd != ts(10) {
+		t.Fatalf("expected write at %s to keep its timestamp, got %s", ts(10), fwd)
+	} else {
+		if closed := lh.close(ts(20), ts(50)); !closed.Less(ts(10)) {
+			t.Fatalf("expected closed timestamp below %s, got %s", ts(10), closed)
+		}
+		lh.untrack(id)
+	}
+	if closed := lh.close(ts(20), ts(50)); closed != ts(20) {
+		t.Fatalf("expected closed timestamp %s, got %s", ts(20), closed)
+	}
+	// The closed timestamp never regresses.
+	if closed := lh.close(ts(15), ts(55)); closed != ts(20) {
+		t.Fatalf("expected closed timestamp %s, got %s", ts(20), closed)
+	}
+	// New writes are forwarded past the closed timestamp.
+	if fwd, _ := lh.track(ts(10)); fwd != ts(20).Next() {
+		t.Fatalf("expected write to be forwarded to %s, got %s", ts(20).Next(), fwd)
+	}
+
+	var f closedTimestampTracker
+	// A closed timestamp can't be used before its lease index has applied.
+	f.update(ts(30), 5 /* lai */, 3 /* appliedLAI */)
+	if closed := f.get(4); closed != (hlc.Timestamp{}) {
+		t.Fatalf("expected no closed timestamp, got %s", closed)
+	}
+	if closed := f.get(5); closed != ts(30) {
+		t.Fatalf("expected closed timestamp %s, got %s", ts(30), closed)
+	}
+	f.update(ts(40), 7, 5)
+	if closed := f.get(6); closed != ts(30) {
+		t.Fatalf("expected closed timestamp %s, got %s", ts(30), closed)
+	}
+	if closed := f.get(7); closed != ts(40) {
+		t.Fatalf("expected closed timestamp %s, got %s", ts(40), closed)
+	}
+	f.update(ts(50), 7, 7)
+	if closed := f.get(7); closed != ts(50) {
+		t.Fatalf("expected closed timestamp %s, got %s", ts(50), closed)
+	}
+}
+
+// TestReplicaClosedTimestamp verifies that the leaseholder forwards writes
+// past its closed timestamp and doesn't serve reads as a follower.
+func TestReplicaClosedTimestamp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.TODO())
+	tc.Start(t, stopper)
+
+	sv := &tc.store.ClusterSettings().SV
+	storagebase.FollowerReadsEnabled.Override(sv, true)
+	storagebase.ClosedTimestampTargetDuration.Override(sv, 10*time.Second)
+	tc.manualClock.Set((time.Minute).Nanoseconds())
+
+	tc.repl.mu.Lock()
+	closed, _ := tc.repl.closeTimestampLocked(tc.Clock().Now())
+	tc.repl.mu.Unlock()
+	if expected := tc.Clock().Now().Add(-(10 * time.Second).Nanoseconds(), 0); closed.Less(expected) {
+		t.Fatalf("expected closed timestamp of at least %s, got %s", expected, closed)
+	}
+
+	pArgs := putArgs([]byte("a"), []byte("value"))
+	_, respH, pErr := SendWrapped(context.Background(), tc.Sender(), roachpb.Header{
+		Timestamp: hlc.Timestamp{WallTime: time.Second.Nanoseconds()},
+	}, &pArgs)
+	if pErr != nil {
+		t.Fatal(pErr)
+	}
+	if !closed.Less(respH.Timestamp) {
+		t.Errorf("expected write to be forwarded past %s; got %s", closed, respH.Timestamp)
+	}
+
+	var ba roachpb.BatchRequest
+	ba.Timestamp = hlc.Timestamp{WallTime: time.Second.Nanoseconds()}
+	gArgs := getArgs([]byte("a"))
+	ba.Add(&gArgs)
+	if tc.repl.canServeFollowerRead(ba) {
+		t.Errorf("expected leaseholder not to serve follower reads")
+	}
+}
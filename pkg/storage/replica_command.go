@@ -132,6 +132,8 @@ var commands = map[roachpb.Method]Command{
 	roachpb.GC:                 {DeclareKeys: declareKeysGC, Eval: evalGC},
 	roachpb.PushTxn:            {DeclareKeys: declareKeysPushTransaction, Eval: evalPushTxn},
 	roachpb.QueryTxn:           {DeclareKeys: DefaultDeclareKeys, Eval: evalQueryTxn},
+	roachpb.QueryIntent:        {DeclareKeys: declareKeysQueryIntent, Eval: evalQueryIntent},
+	roachpb.RecoverTxn:         {DeclareKeys: declareKeysRecoverTransaction, Eval: evalRecoverTxn},
 	roachpb.ResolveIntent:      {DeclareKeys: declareKeysResolveIntent, Eval: evalResolveIntent},
 	roachpb.ResolveIntentRange: {DeclareKeys: declareKeysResolveIntentRange, Eval: evalResolveIntentRange},
 	roachpb.Merge:              {DeclareKeys: DefaultDeclareKeys, Eval: evalMerge},
@@ -139,6 +141,7 @@ var commands = map[roachpb.Method]Command{
 	roachpb.RequestLease:       {DeclareKeys: declareKeysRequestLease, Eval: evalRequestLease},
 	roachpb.TransferLease:      {DeclareKeys: declareKeysRequestLease, Eval: evalTransferLease},
 	roachpb.LeaseInfo:          {DeclareKeys: declareKeysLeaseInfo, Eval: evalLeaseInfo},
+	roachpb.RangeStats:         {DeclareKeys: declareKeysRangeStats, Eval: evalRangeStats},
 	roachpb.ComputeChecksum:    {DeclareKeys: DefaultDeclareKeys, Eval: evalComputeChecksum},
 	roachpb.WriteBatch:         writeBatchCmd,
 	roachpb.Export:             exportCmd,
@@ -653,6 +656,11 @@ func declareKeysEndTransaction(
 // evalEndTransaction either commits or aborts (rolls back) an extant
 // transaction according to the args.Commit parameter. Rolling back
 // an already rolled-back txn is ok.
+//
+// If the request lists in-flight writes, the transaction is instead moved
+// to the STAGING status: it is committed as soon as all of these writes
+// have succeeded, though until the transaction record is finalized this can
+// only be determined by checking for their intents (see evalRecoverTxn).
 func evalEndTransaction(
 	ctx context.Context, batch engine.ReadWriter, cArgs CommandArgs, resp roachpb.Response,
 ) (EvalResult, error) {
@@ -727,7 +735,7 @@ func evalEndTransaction(
 			args.IntentSpans, reply.Txn), args, true, /* alwaysReturn */
 		), roachpb.NewTransactionAbortedError()
 
-	case roachpb.PENDING:
+	case roachpb.PENDING, roachpb.STAGING:
 		if h.Txn.Epoch < reply.Txn.Epoch {
 			// TODO(tschottdorf): this leaves the Txn record (and more
 			// importantly, intents) dangling; we can't currently write on
@@ -746,6 +754,14 @@ func evalEndTransaction(
 				fmt.Sprintf("timestamp regression: %s", h.Txn.OrigTimestamp),
 			)
 		}
+		if reply.Txn.Status == roachpb.STAGING && !args.Commit && h.Txn.Epoch == reply.Txn.Epoch {
+			// All of the in-flight writes may already have succeeded, in which
+			// case the transaction is implicitly committed and must not be
+			// rolled back. Its fate is decided by recovering it instead.
+			return EvalResult{}, roachpb.NewTransactionStatusError(
+				"cannot roll back a STAGING transaction which may be implicitly committed",
+			)
+		}
 
 	default:
 		return EvalResult{}, roachpb.NewTransactionStatusError(
@@ -793,10 +809,14 @@ func evalEndTransaction(
 				"transaction deadline exceeded")
 		}
 
+		if len(args.InFlightWrites) > 0 {
+			return evalStagingEndTransaction(ctx, batch, cArgs, reply.Txn)
+		}
 		reply.Txn.Status = roachpb.COMMITTED
 	} else {
 		reply.Txn.Status = roachpb.ABORTED
 	}
+	reply.Txn.InFlightWrites = nil
 
 	desc, err := cArgs.EvalCtx.Desc()
 	if err != nil {
@@ -845,6 +865,30 @@ func evalEndTransaction(
 	return pd, nil
 }
 
+// evalStagingEndTransaction moves the transaction record to the STAGING
+// status, recording the writes which were sent in parallel with the
+// EndTransaction. The transaction's intents are not resolved: they are
+// resolved once the record has been finalized, either by the coordinator
+// or by a recovery (see evalRecoverTxn).
+func evalStagingEndTransaction(
+	ctx context.Context, batch engine.ReadWriter, cArgs CommandArgs, txn *roachpb.Transaction,
+) (EvalResult, error) {
+	args := cArgs.Args.(*roachpb.EndTransactionRequest)
+	if args.InternalCommitTrigger != nil {
+		return EvalResult{}, errors.Errorf("cannot stage transaction with a commit trigger")
+	}
+	txn.Status = roachpb.STAGING
+	txn.InFlightWrites = args.InFlightWrites
+	txn.Intents = args.IntentSpans
+	key := keys.TransactionKey(txn.Key, txn.ID)
+	if err := engine.MVCCPutProto(ctx, batch, cArgs.Stats, key, hlc.Timestamp{}, nil, txn); err != nil {
+		return EvalResult{}, err
+	}
+	var result EvalResult
+	result.Local.updatedTxn = txn
+	return result, nil
+}
+
 // isEndTransactionExceedingDeadline returns true if the transaction
 // exceeded its deadline.
 func isEndTransactionExceedingDeadline(t hlc.Timestamp, args roachpb.EndTransactionRequest) bool {
@@ -1429,7 +1473,7 @@ func evalHeartbeatTxn(
 		return EvalResult{}, errors.Errorf("heartbeat for transaction %s failed; record not present", h.Txn)
 	}
 
-	if txn.Status == roachpb.PENDING {
+	if !txn.Status.IsFinalized() {
 		txn.LastHeartbeat.Forward(args.Now)
 		if err := engine.MVCCPutProto(ctx, batch, cArgs.Stats, key, hlc.Timestamp{}, nil, &txn); err != nil {
 			return EvalResult{}, err
@@ -1572,6 +1616,11 @@ func declareKeysPushTransaction(
 // Txn already committed/aborted: If pushee txn is committed or
 // aborted return success.
 //
+// Txn staging: If the pusher would otherwise win against a STAGING pushee,
+// return success with the pushee unchanged. The pushee may already be
+// implicitly committed, so it can neither be aborted nor have its
+// timestamp pushed; instead, the pusher must recover it.
+//
 // Txn Timeout: If pushee txn entry isn't present or its LastHeartbeat
 // timestamp isn't set, use its as LastHeartbeat. If current time -
 // LastHeartbeat > 2 * DefaultHeartbeatInterval, then the pushee txn
@@ -1669,7 +1718,7 @@ func evalPushTxn(
 	reply.PusheeTxn = existTxn.Clone()
 
 	// If already committed or aborted, return success.
-	if reply.PusheeTxn.Status != roachpb.PENDING {
+	if reply.PusheeTxn.Status.IsFinalized() {
 		// Trivial noop.
 		return EvalResult{}, nil
 	}
@@ -1733,6 +1782,12 @@ func evalPushTxn(
 		return EvalResult{}, err
 	}
 
+	// A STAGING transaction can't be pushed; the pusher has to recover it.
+	if reply.PusheeTxn.Status == roachpb.STAGING {
+		reply.PusheeTxn = existTxn.Clone()
+		return EvalResult{}, nil
+	}
+
 	// Upgrade priority of pushed transaction to one less than pusher's.
 	reply.PusheeTxn.UpgradePriority(args.PusherTxn.Priority - 1)
 
@@ -1794,6 +1849,128 @@ func evalQueryTxn(
 	return EvalResult{}, nil
 }
 
+func declareKeysQueryIntent(
+	desc roachpb.RangeDescriptor, header roachpb.Header, req roachpb.Request, spans *SpanSet,
+) {
+	DefaultDeclareKeys(desc, header, req, spans)
+	// The intent being queried may belong to a write which is still in
+	// flight. Declaring the key as written (rather than read) makes the
+	// QueryIntent wait in the command queue until that write has applied
+	// or failed, regardless of the timestamps involved.
+	spans.Add(SpanReadWrite, req.Header())
+}
+
+// evalQueryIntent checks whether an intent of the given transaction
+// exists at the requested key. It is used by transaction coordinators to
+// prove that writes which were acknowledged before they finished
+// replicating have succeeded. The intent must belong to the same epoch of
+// the transaction; it is returned so that, if it was pushed, the caller can
+// move the transaction's timestamp forward to the intent's timestamp.
+func evalQueryIntent(
+	ctx context.Context, batch engine.ReadWriter, cArgs CommandArgs, resp roachpb.Response,
+) (EvalResult, error) {
+	args := cArgs.Args.(*roachpb.QueryIntentRequest)
+	h := cArgs.Header
+	reply := resp.(*roachpb.QueryIntentResponse)
+
+	// Read inconsistently at the maximum timestamp so that an intent at the
+	// key is returned regardless of the timestamp it was written at.
+	_, intents, err := engine.MVCCGet(ctx, batch, args.Key, hlc.MaxTimestamp,
+		false /* consistent */, nil /* txn */)
+	if err != nil {
+		return EvalResult{}, err
+	}
+	for i := range intents {
+		if intent := &intents[i]; intent.Txn.ID == args.Txn.ID && intent.Txn.Epoch == args.Txn.Epoch {
+			reply.FoundIntent = true
+			reply.Intent = intent
+			break
+		}
+	}
+	if !reply.FoundIntent {
+		if args.ErrorIfMissing {
+			return EvalResult{}, roachpb.NewTransactionRetryError(roachpb.RETRY_ASYNC_WRITE_FAILURE)
+		}
+		return EvalResult{}, nil
+	}
+	// If the intent was pushed, so was the transaction: the requests which
+	// follow in the batch, such as an EndTransaction, must see the pushed
+	// timestamp.
+	if h.Txn != nil && h.Txn.Timestamp.Less(reply.Intent.Txn.Timestamp) {
+		clonedTxn := h.Txn.Clone()
+		clonedTxn.Timestamp.Forward(reply.Intent.Txn.Timestamp)
+		reply.Txn = &clonedTxn
+	}
+	return EvalResult{}, nil
+}
+
+func declareKeysRecoverTransaction(
+	_ roachpb.RangeDescriptor, header roachpb.Header, req roachpb.Request, spans *SpanSet,
+) {
+	rt := req.(*roachpb.RecoverTxnRequest)
+	spans.Add(SpanReadWrite, roachpb.Span{Key: keys.TransactionKey(rt.Txn.Key, rt.Txn.ID)})
+}
+
+// evalRecoverTxn finalizes a STAGING transaction after the caller has
+// checked whether all of its in-flight writes have succeeded: if they have,
+// the transaction is implicitly committed and its record is marked as
+// COMMITTED. Otherwise, the caller has prevented the missing writes from
+// ever succeeding and the record is marked as ABORTED.
+//
+// If the record is no longer STAGING at the epoch and timestamp the caller
+// checked, it is returned unchanged. Unless the record is COMMITTED, this is
+// an error if the caller found the transaction to be implicitly committed,
+// as an implicitly committed transaction must never change its outcome.
+func evalRecoverTxn(
+	ctx context.Context, batch engine.ReadWriter, cArgs CommandArgs, resp roachpb.Response,
+) (EvalResult, error) {
+	args := cArgs.Args.(*roachpb.RecoverTxnRequest)
+	reply := resp.(*roachpb.RecoverTxnResponse)
+
+	if cArgs.Header.Txn != nil {
+		return EvalResult{}, errTransactionUnsupported
+	}
+	if !bytes.Equal(args.Key, args.Txn.Key) {
+		return EvalResult{}, errors.Errorf("request key %s does not match txn key %s", args.Key, args.Txn.Key)
+	}
+	key := keys.TransactionKey(args.Txn.Key, args.Txn.ID)
+
+	ok, err := engine.MVCCGetProto(ctx, batch, key, hlc.Timestamp{},
+		true /* consistent */, nil /* txn */, &reply.RecoveredTxn)
+	if err != nil {
+		return EvalResult{}, err
+	} else if !ok {
+		return EvalResult{}, errors.Errorf("transaction record for %s not found", args.Txn.ID)
+	}
+	txn := &reply.RecoveredTxn
+
+	switch {
+	case txn.Status == roachpb.COMMITTED:
+		return EvalResult{}, nil
+	case txn.Status != roachpb.STAGING ||
+		txn.Epoch != args.Txn.Epoch ||
+		txn.Timestamp != args.Txn.Timestamp:
+		if args.ImplicitlyCommitted {
+			return EvalResult{}, errors.Errorf(
+				"implicitly committed transaction %s was modified: %s", args.Txn.ID, txn)
+		}
+		return EvalResult{}, nil
+	}
+
+	if args.ImplicitlyCommitted {
+		txn.Status = roachpb.COMMITTED
+	} else {
+		txn.Status = roachpb.ABORTED
+	}
+	txn.InFlightWrites = nil
+	if err := engine.MVCCPutProto(ctx, batch, cArgs.Stats, key, hlc.Timestamp{}, nil, txn); err != nil {
+		return EvalResult{}, err
+	}
+	result := intentsToEvalResult(roachpb.AsIntents(txn.Intents, txn), args, false /* !alwaysReturn */)
+	result.Local.updatedTxn = txn
+	return result, nil
+}
+
 // setAbortCache clears any abort cache entry if poison is false.
 // Otherwise, if poison is true, creates an entry for this transaction
 // in the abort cache to prevent future reads or writes from
@@ -3230,12 +3407,22 @@ func splitTrigger(
 // reassigned key range is carried out seamlessly through a merge
 // trigger carried out as part of the commit of that transaction.  A
 // merge requires that the two ranges are collocated on the same set
-// of replicas.
+// of replicas, and that this store holds the range lease of both.
 //
 // The supplied RangeDescriptor is used as a form of optimistic lock. See the
 // comment of "AdminSplit" for more information on this pattern.
 func (r *Replica) AdminMerge(
 	ctx context.Context, args roachpb.AdminMergeRequest,
+) (roachpb.AdminMergeResponse, *roachpb.Error) {
+	return r.adminMergeWithDescriptor(ctx, nil /* expectedRightDesc */)
+}
+
+// adminMergeWithDescriptor merges the right neighbor of this range into it.
+// If expectedRightDesc is not nil, the merge fails if the descriptor of the
+// right neighbor doesn't match it; the merge queue uses this to make sure that
+// the range it merges is the one it evaluated.
+func (r *Replica) adminMergeWithDescriptor(
+	ctx context.Context, expectedRightDesc *roachpb.RangeDescriptor,
 ) (roachpb.AdminMergeResponse, *roachpb.Error) {
 	var reply roachpb.AdminMergeResponse
 
@@ -3254,19 +3441,43 @@ func (r *Replica) AdminMerge(
 	// descriptor end key. We look up the descriptor here only to get
 	// the new end key and then repeat the lookup inside the
 	// transaction.
-	{
-		rightRng := r.store.LookupReplica(origLeftDesc.EndKey, nil)
-		if rightRng == nil {
-			return reply, roachpb.NewErrorf("ranges not collocated")
-		}
+	rightRng := r.store.LookupReplica(origLeftDesc.EndKey, nil)
+	if rightRng == nil {
+		return reply, roachpb.NewErrorf("ranges not collocated")
+	}
+	updatedLeftDesc.EndKey = rightRng.Desc().EndKey
+	if expectedRightDesc != nil && !expectedRightDesc.EndKey.Equal(updatedLeftDesc.EndKey) {
+		return reply, roachpb.NewErrorf("range changed during merge; %s != %s",
+			expectedRightDesc.EndKey, updatedLeftDesc.EndKey)
+	}
 
-		updatedLeftDesc.EndKey = rightRng.Desc().EndKey
-		log.Infof(ctx, "initiating a merge of %s into this range", rightRng)
+	// The right hand side must be served by this store while it is merged, so
+	// that the timestamp cache of this store covers all the reads served by
+	// either range. This acquires the lease if nobody holds it.
+	if _, pErr := rightRng.redirectOnOrAcquireLease(ctx); pErr != nil {
+		return reply, roachpb.NewErrorf("range lease of %s not held by this store: %s", rightRng, pErr)
 	}
+	log.Infof(ctx, "initiating a merge of %s into this range", rightRng)
+
+	// unfreeze is set once the right hand side has been frozen for the merge.
+	// On success, the right hand side replica is destroyed by the merge
+	// trigger and unfreezing it is a no-op.
+	var unfreeze func()
+	defer func() {
+		if unfreeze != nil {
+			unfreeze()
+		}
+	}()
 
 	if err := r.store.DB().Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
 		log.Event(ctx, "merge closure begins")
 		txn.SetDebugName(mergeTxnName)
+		// The right hand side must accept the deletion of its descriptor, so
+		// unfreeze it if this is a retry.
+		if unfreeze != nil {
+			unfreeze()
+			unfreeze = nil
+		}
 		// Update the range descriptor for the receiving range.
 		{
 			b := txn.NewBatch()
@@ -3296,18 +3507,50 @@ func (r *Replica) AdminMerge(
 		}
 		if !bytes.Equal(rightDesc.EndKey, updatedLeftDesc.EndKey) {
 			// This merge raced with a split of the right-hand range.
-			// TODO(bdarnell): needs a test.
 			return errors.Errorf("range changed during merge; %s != %s", rightDesc.EndKey, updatedLeftDesc.EndKey)
 		}
+		if expectedRightDesc != nil && !rightDesc.Equal(expectedRightDesc) {
+			// The right-hand range was split and merged back, or its replicas
+			// changed, since the caller looked at it.
+			return errors.Errorf("range changed during merge; %s != %s", &rightDesc, expectedRightDesc)
+		}
 		if !replicaSetsEqual(origLeftDesc.Replicas, rightDesc.Replicas) {
 			return errors.Errorf("ranges not collocated")
 		}
 
-		b := txn.NewBatch()
+		// Remove the range descriptor for the deleted range. The intent on it
+		// also prevents concurrent splits of the right hand side, which need to
+		// update the descriptor.
+		{
+			b := txn.NewBatch()
+			b.Del(rightDescKey)
+			if err := txn.Run(ctx, b); err != nil {
+				return err
+			}
+		}
 
-		// Remove the range descriptor for the deleted range.
-		b.Del(rightDescKey)
+		// Stop the right hand side from serving requests until the merge
+		// trigger destroys it. Otherwise, it could serve reads or apply writes
+		// that the left hand side doesn't know about once it has subsumed the
+		// key range.
+		var err error
+		if unfreeze, err = rightRng.freezeForMerge(ctx); err != nil {
+			return err
+		}
+		if !rightRng.OwnsValidLease(r.store.Clock().Now()) {
+			return errors.Errorf("range lease of %s not held by this store", rightRng)
+		}
+		// All the replicas of the right hand side must have applied its
+		// commands before they are subsumed; otherwise, the commands that a
+		// follower hasn't applied yet would be lost when it applies the merge.
+		rightRng.mu.RLock()
+		rightAppliedIndex := rightRng.mu.state.RaftAppliedIndex
+		rightRng.mu.RUnlock()
+		if err := r.waitForApplication(ctx, &rightDesc, rightAppliedIndex); err != nil {
+			return err
+		}
 
+		b := txn.NewBatch()
 		if err := mergeRangeAddressing(b, origLeftDesc, &updatedLeftDesc); err != nil {
 			return err
 		}
@@ -3331,6 +3574,66 @@ func (r *Replica) AdminMerge(
 	return reply, nil
 }
 
+// freezeForMerge stops the replica from serving reads and from proposing
+// commands (including lease transfers, but not the lease requests that
+// extend its lease), and waits for the reads and the proposals that are in
+// flight to complete. Requests sent to a frozen replica
+// fail with a RangeNotFoundError, which makes the clients retry them once the
+// range has been subsumed by its left neighbor. The returned function
+// unfreezes the replica; it must be called if the merge fails.
+func (r *Replica) freezeForMerge(ctx context.Context) (func(), error) {
+	r.mu.Lock()
+	r.mu.mergeFrozen = true
+	r.mu.Unlock()
+	unfreeze := func() {
+		r.mu.Lock()
+		r.mu.mergeFrozen = false
+		r.mu.Unlock()
+	}
+
+	// Reads hold readOnlyCmdMu while they are evaluated.
+	r.readOnlyCmdMu.Lock()
+	r.readOnlyCmdMu.Unlock()
+
+	for re := retry.StartWithCtx(ctx, base.DefaultRetryOptions()); re.Next(); {
+		r.mu.Lock()
+		pending := len(r.mu.proposals)
+		r.mu.Unlock()
+		if pending == 0 {
+			return unfreeze, nil
+		}
+	}
+	unfreeze()
+	return nil, errors.Errorf("%s: in-flight commands did not complete: %v", r, ctx.Err())
+}
+
+// waitForApplication waits until every replica of the given range has applied
+// the range's Raft log up to the given index. It fails if a replica can't be
+// reached or shuts down before it has caught up.
+func (r *Replica) waitForApplication(
+	ctx context.Context, desc *roachpb.RangeDescriptor, appliedIndex uint64,
+) error {
+	for _, replica := range desc.Replicas {
+		addr, err := r.store.cfg.Transport.resolver(replica.NodeID)
+		if err != nil {
+			return errors.Wrapf(err, "could not resolve node ID %d", replica.NodeID)
+		}
+		conn, err := r.store.cfg.Transport.rpcContext.GRPCDial(addr.String())
+		if err != nil {
+			return errors.Wrapf(err, "could not dial node ID %d address %s", replica.NodeID, addr)
+		}
+		req := &WaitForApplicationRequest{
+			StoreRequestHeader: StoreRequestHeader{NodeID: replica.NodeID, StoreID: replica.StoreID},
+			RangeID:            desc.RangeID,
+			AppliedIndex:       appliedIndex,
+		}
+		if _, err := NewConsistencyClient(conn).WaitForApplication(ctx, req); err != nil {
+			return errors.Wrapf(err, "replica %s did not apply index %d", replica, appliedIndex)
+		}
+	}
+	return nil
+}
+
 // mergeTrigger is called on a successful commit of an AdminMerge
 // transaction. It recomputes stats for the receiving range.
 //
@@ -3363,6 +3666,18 @@ func mergeTrigger(
 		return EvalResult{}, errors.Errorf("RHS range ID must be provided: %d", rightRangeID)
 	}
 
+	// The right hand side must still be frozen and hold its lease on this
+	// store when the merge commits. Otherwise, another replica could have
+	// acquired the lease after it expired and served commands that the left
+	// hand side doesn't know about.
+	rightRepl, err := rec.repl.store.GetReplica(rightRangeID)
+	if err != nil {
+		return EvalResult{}, err
+	}
+	if !rightRepl.isMergeFrozen() || !rightRepl.OwnsValidLease(rec.repl.store.Clock().Now()) {
+		return EvalResult{}, errors.Errorf("range lease of %s lost during merge", rightRepl)
+	}
+
 	// Compute stats for premerged range, including current transaction.
 	mergedMS, err := rec.GetMVCCStats()
 	if err != nil {
@@ -3908,12 +4223,34 @@ func evalLeaseInfo(
 	return EvalResult{}, nil
 }
 
-// TestingRelocateRange relocates a given range to a given set of stores. The first
+func declareKeysRangeStats(
+	desc roachpb.RangeDescriptor, header roachpb.Header, req roachpb.Request, spans *SpanSet,
+) {
+	DefaultDeclareKeys(desc, header, req, spans)
+	spans.Add(SpanReadOnly, roachpb.Span{Key: keys.RangeStatsKey(header.RangeID)})
+}
+
+// evalRangeStats returns the MVCC statistics for a range.
+func evalRangeStats(
+	ctx context.Context, batch engine.ReadWriter, cArgs CommandArgs, resp roachpb.Response,
+) (EvalResult, error) {
+	reply := resp.(*roachpb.RangeStatsResponse)
+	ms, err := cArgs.EvalCtx.GetMVCCStats()
+	if err != nil {
+		return EvalResult{}, err
+	}
+	reply.MVCCStats = ms
+	return EvalResult{}, nil
+}
+
+// RelocateRange relocates a given range to a given set of stores. The first
 // store in the slice becomes the new leaseholder.
 //
-// This is best-effort; if replication queues are enabled and a change in
-// membership happens at the same time, there will be errors.
-func TestingRelocateRange(
+// It is used by TESTING_RELOCATE and by the merge queue, which collocates
+// adjacent ranges before merging them. This is best-effort; if replication
+// queues are enabled and a change in membership happens at the same time,
+// there will be errors.
+func RelocateRange(
 	ctx context.Context,
 	db *client.DB,
 	rangeDesc roachpb.RangeDescriptor,
@@ -16,7 +16,6 @@ package storage
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 	"time"
@@ -619,12 +618,14 @@ func addSSTablePreApply(
 			// ingestion may apply twice (we ingest before we mark the Raft
 			// command as committed). Just unlink the file (RocksDB created a
 			// hard link); after that we're free to write it again.
-			if err := os.Remove(path); err != nil {
+			if err := eng.DeleteFile(path); err != nil {
 				log.Fatalf(ctx, "while removing existing file during ingestion of %s: %s", path, err)
 			}
 		}
 
-		if err := ioutil.WriteFile(path, sst.Data, 0600); err != nil {
+		// Write the file through the engine so that it is encrypted if the
+		// engine's files are.
+		if err := eng.WriteFile(path, sst.Data); err != nil {
 			log.Fatalf(ctx, "while ingesting %s: %s", path, err)
 		}
 	}
@@ -1019,8 +1020,17 @@ func (r *Replica) handleEvalResultRaftMuLocked(
 	ctx context.Context,
 	lResult *LocalEvalResult,
 	rResult storagebase.ReplicatedEvalResult,
+	writeBatch *storagebase.WriteBatch,
 	raftAppliedIndex, leaseAppliedIndex uint64,
 ) {
+	// Publish the committed writes to the rangefeeds of the replica. Splits
+	// and merges change the bounds of the range, so they disconnect the
+	// rangefeeds instead; their clients reconnect from their last checkpoint.
+	if rResult.Split != nil || rResult.Merge != nil {
+		r.disconnectRangeFeedsRaftMuLocked(r.rangeKeyMismatchErrorForRangeFeeds())
+	} else {
+		r.handleRangeFeedWriteBatchRaftMuLocked(ctx, writeBatch, leaseAppliedIndex)
+	}
 	shouldAssert := r.handleReplicatedEvalResult(ctx, rResult, raftAppliedIndex, leaseAppliedIndex)
 	if lResult != nil {
 		r.handleLocalEvalResult(ctx, *lResult)
@@ -714,6 +714,11 @@ func (r *Replica) applySnapshot(
 		return nil
 	}
 
+	// The snapshot replaces the data of the range wholesale, which the
+	// rangefeeds of the replica can't follow.
+	r.disconnectRangeFeedsRaftMuLocked(roachpb.NewError(roachpb.NewRangeKeyMismatchError(
+		s.Desc.StartKey.AsRawKey(), s.Desc.EndKey.AsRawKey(), nil)))
+
 	var stats struct {
 		clear   time.Time
 		batch   time.Time
@@ -0,0 +1,562 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+)
+
+const (
+	// rangeFeedEventBufferSize is the number of events buffered for each
+	// rangefeed registration. A registration which falls further behind is
+	// disconnected and has to reconnect from its last checkpoint.
+	rangeFeedEventBufferSize = 4096
+	// rangeFeedCheckpointInterval is the interval at which rangefeed
+	// registrations are sent checkpoints.
+	rangeFeedCheckpointInterval = 200 * time.Millisecond
+)
+
+// rangeFeedRegistration is a single RangeFeed stream served by a replica.
+type rangeFeedRegistration struct {
+	span roachpb.Span
+	// eventC buffers the events destined for the stream. Values and
+	// checkpoints are added to it in the order in which they must be sent.
+	eventC chan *roachpb.RangeFeedEvent
+	// errC receives the error which ends the registration.
+	errC chan *roachpb.Error
+	// resolved is the last checkpoint sent to the registration.
+	resolved hlc.Timestamp
+}
+
+// rangeFeedProcessor publishes the writes applied by a replica to the
+// rangefeed registrations of the replica. It translates the write batches of
+// applied Raft commands into committed values and keeps track of the
+// unresolved intents on the range, which hold back the checkpoints of the
+// registrations: an intent may still commit at its timestamp, so no
+// checkpoint at or above it can be sent.
+//
+// A processor exists while the replica has at least one registration. All
+// fields are protected by Replica.rangefeedMu.
+type rangeFeedProcessor struct {
+	regs []*rangeFeedRegistration
+	// intents maps the keys of the unresolved intents on the range to their
+	// timestamps.
+	intents map[string]hlc.Timestamp
+	// lai is the lease applied index of the last command published.
+	lai uint64
+	// closed is the closed timestamp the processor can rely on given the
+	// commands it has published. A closed timestamp which requires a later
+	// command to have applied is held in pending until then.
+	closed     hlc.Timestamp
+	pending    hlc.Timestamp
+	pendingLAI uint64
+}
+
+// newRangeFeedProcessor creates a processor for the range with the given
+// descriptor, seeding its intents by scanning the range.
+func newRangeFeedProcessor(
+	reader engine.Reader, desc *roachpb.RangeDescriptor, lai uint64,
+) (*rangeFeedProcessor, error) {
+	p := &rangeFeedProcessor{
+		intents: map[string]hlc.Timestamp{},
+		lai:     lai,
+	}
+	start := desc.StartKey.AsRawKey()
+	if start.Compare(keys.LocalMax) < 0 {
+		start = keys.LocalMax
+	}
+	iter := reader.NewIterator(false /* prefix */)
+	defer iter.Close()
+	var meta enginepb.MVCCMetadata
+	for iter.Seek(engine.MakeMVCCMetadataKey(start)); ; iter.NextKey() {
+		if ok, err := iter.Valid(); err != nil {
+			return nil, err
+		} else if !ok || iter.UnsafeKey().Key.Compare(desc.EndKey.AsRawKey()) >= 0 {
+			break
+		}
+		unsafeKey := iter.UnsafeKey()
+		if unsafeKey.IsValue() {
+			continue
+		}
+		if err := protoutil.Unmarshal(iter.UnsafeValue(), &meta); err != nil {
+			return nil, errors.Wrapf(err, "unmarshaling mvcc meta: %s", unsafeKey)
+		}
+		if meta.Txn != nil {
+			p.intents[string(unsafeKey.Key)] = hlc.Timestamp(meta.Timestamp)
+		}
+	}
+	return p, nil
+}
+
+// forwardClosed records a closed timestamp which the processor can rely on
+// once it has published the command with the given lease applied index.
+func (p *rangeFeedProcessor) forwardClosed(closed hlc.Timestamp, lai uint64) {
+	p.applyPending()
+	if lai <= p.lai {
+		p.closed.Forward(closed)
+	} else if p.pendingLAI == 0 && p.closed.Less(closed) {
+		// Keep an older pending timestamp in place so that a steady stream of
+		// newer ones doesn't starve it.
+		p.pending, p.pendingLAI = closed, lai
+	}
+}
+
+func (p *rangeFeedProcessor) applyPending() {
+	if p.pendingLAI != 0 && p.pendingLAI <= p.lai {
+		p.closed.Forward(p.pending)
+		p.pending, p.pendingLAI = hlc.Timestamp{}, 0
+	}
+}
+
+// resolvedTimestamp returns the timestamp at or below which the processor
+// will not publish any further values.
+func (p *rangeFeedProcessor) resolvedTimestamp() hlc.Timestamp {
+	p.applyPending()
+	resolved := p.closed
+	for _, ts := range p.intents {
+		if prev := ts.Prev(); prev.Less(resolved) {
+			resolved = prev
+		}
+	}
+	return resolved
+}
+
+// publish sends an event to every registration whose span contains the key.
+// Registrations which can't keep up are disconnected.
+func (p *rangeFeedProcessor) publish(key roachpb.Key, value roachpb.Value) {
+	event := &roachpb.RangeFeedEvent{}
+	event.SetValue(&roachpb.RangeFeedValue{Key: key, Value: value})
+	for _, reg := range p.regs {
+		if reg.span.Contains(roachpb.Span{Key: key}) {
+			p.send(reg, event)
+		}
+	}
+}
+
+// checkpoint sends a checkpoint to every registration whose resolved
+// timestamp has advanced.
+func (p *rangeFeedProcessor) checkpoint() {
+	resolved := p.resolvedTimestamp()
+	for _, reg := range p.regs {
+		if !reg.resolved.Less(resolved) {
+			continue
+		}
+		reg.resolved = resolved
+		event := &roachpb.RangeFeedEvent{}
+		event.SetValue(&roachpb.RangeFeedCheckpoint{Span: reg.span, ResolvedTS: resolved})
+		p.send(reg, event)
+	}
+}
+
+func (p *rangeFeedProcessor) send(reg *rangeFeedRegistration, event *roachpb.RangeFeedEvent) {
+	select {
+	case reg.eventC <- event:
+	default:
+		p.disconnect(reg, roachpb.NewErrorf("rangefeed registration for %s fell behind", reg.span))
+	}
+}
+
+// disconnect ends the given registration with an error.
+func (p *rangeFeedProcessor) disconnect(reg *rangeFeedRegistration, pErr *roachpb.Error) {
+	for i := range p.regs {
+		if p.regs[i] == reg {
+			p.regs = append(p.regs[:i], p.regs[i+1:]...)
+			reg.errC <- pErr
+			return
+		}
+	}
+}
+
+// disconnectAll ends all registrations with an error.
+func (p *rangeFeedProcessor) disconnectAll(pErr *roachpb.Error) {
+	for _, reg := range p.regs {
+		reg.errC <- pErr
+	}
+	p.regs = nil
+}
+
+// consumeWriteBatch publishes the values committed by an applied write batch
+// and updates the unresolved intents. Values committed by resolving an intent
+// at its original timestamp don't appear in the batch and are read from the
+// given reader, which must reflect the applied batch.
+func (p *rangeFeedProcessor) consumeWriteBatch(reader engine.Reader, repr []byte) error {
+	r, err := engine.NewRocksDBBatchReader(repr)
+	if err != nil {
+		return err
+	}
+
+	// versionKey identifies a version of a key written by the batch.
+	type versionKey struct {
+		key string
+		ts  hlc.Timestamp
+	}
+	type keyState struct {
+		// intent is the timestamp of the intent written for the key by the
+		// batch, if any. cleared is set when the batch removed the intent or
+		// metadata of the key afterwards.
+		intent  hlc.Timestamp
+		cleared bool
+	}
+	var order []string
+	metas := map[string]*keyState{}
+	values := map[versionKey][]byte{}
+	var valueKeys []versionKey
+	clearedValues := map[versionKey]bool{}
+	var meta enginepb.MVCCMetadata
+
+	for r.Next() {
+		mvccKey, err := r.MVCCKey()
+		if err != nil {
+			return err
+		}
+		// Only user data can be subscribed to.
+		if mvccKey.Key.Compare(keys.LocalMax) < 0 {
+			continue
+		}
+		switch r.BatchType() {
+		case engine.BatchTypeValue:
+			if mvccKey.IsValue() {
+				vk := versionKey{string(mvccKey.Key), mvccKey.Timestamp}
+				if _, ok := values[vk]; !ok {
+					valueKeys = append(valueKeys, vk)
+				}
+				values[vk] = r.Value()
+				delete(clearedValues, vk)
+				continue
+			}
+			if err := protoutil.Unmarshal(r.Value(), &meta); err != nil {
+				return errors.Wrapf(err, "unmarshaling mvcc meta: %s", mvccKey)
+			}
+			if meta.Txn == nil {
+				// Inline values aren't MVCC values and aren't published.
+				continue
+			}
+			k := string(mvccKey.Key)
+			if metas[k] == nil {
+				metas[k] = &keyState{}
+				order = append(order, k)
+			}
+			metas[k].intent, metas[k].cleared = hlc.Timestamp(meta.Timestamp), false
+		case engine.BatchTypeDeletion:
+			if mvccKey.IsValue() {
+				vk := versionKey{string(mvccKey.Key), mvccKey.Timestamp}
+				delete(values, vk)
+				clearedValues[vk] = true
+				continue
+			}
+			k := string(mvccKey.Key)
+			if metas[k] == nil {
+				metas[k] = &keyState{}
+				order = append(order, k)
+			}
+			metas[k].cleared = true
+		default:
+			return errors.Errorf("unexpected write batch entry type %d", r.BatchType())
+		}
+	}
+	if err := r.Error(); err != nil {
+		return err
+	}
+
+	// Values written by the batch are committed unless they are the
+	// provisional value of an intent left behind by the batch.
+	for _, vk := range valueKeys {
+		value, ok := values[vk]
+		if !ok {
+			continue
+		}
+		if state := metas[vk.key]; state != nil && !state.cleared && state.intent == vk.ts {
+			continue
+		}
+		p.publish(roachpb.Key(vk.key), roachpb.Value{
+			RawBytes:  append([]byte(nil), value...),
+			Timestamp: vk.ts,
+		})
+	}
+
+	// An intent which was removed without its provisional value being
+	// removed or moved has been committed at its original timestamp.
+	for _, k := range order {
+		state := metas[k]
+		intentTS, hadIntent := p.intents[k]
+		if state.intent != (hlc.Timestamp{}) {
+			intentTS, hadIntent = state.intent, true
+		}
+		if !state.cleared {
+			p.intents[k] = state.intent
+			continue
+		}
+		delete(p.intents, k)
+		if !hadIntent {
+			continue
+		}
+		vk := versionKey{k, intentTS}
+		if _, ok := values[vk]; ok || clearedValues[vk] {
+			continue
+		}
+		key := engine.MVCCKey{Key: roachpb.Key(k), Timestamp: intentTS}
+		value, err := reader.Get(key)
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			continue
+		}
+		p.publish(key.Key, roachpb.Value{RawBytes: value, Timestamp: intentTS})
+	}
+	return nil
+}
+
+// RangeFeed registers a rangefeed over the span of the request and streams
+// the values committed to it as well as periodic checkpoints until the
+// stream's context is canceled or an error occurs. If the request carries a
+// timestamp, all values committed after that timestamp are sent first.
+func (r *Replica) RangeFeed(
+	args *roachpb.RangeFeedRequest, stream roachpb.Internal_RangeFeedServer,
+) *roachpb.Error {
+	if !storagebase.RangefeedEnabled.Get(&r.store.cfg.Settings.SV) {
+		return roachpb.NewErrorf("rangefeeds require the kv.rangefeed.enabled setting")
+	}
+	ctx := r.AnnotateCtx(stream.Context())
+
+	reg := &rangeFeedRegistration{
+		span:   args.Span,
+		eventC: make(chan *roachpb.RangeFeedEvent, rangeFeedEventBufferSize),
+		errC:   make(chan *roachpb.Error, 1),
+	}
+	// Register under raftMu so that the catch-up snapshot and the published
+	// values neither overlap nor leave a gap.
+	var catchUp engine.Reader
+	r.raftMu.Lock()
+	if err := r.registerRangeFeedRaftMuLocked(reg); err != nil {
+		r.raftMu.Unlock()
+		return roachpb.NewError(err)
+	}
+	if args.Timestamp != (hlc.Timestamp{}) {
+		catchUp = r.store.Engine().NewSnapshot()
+	}
+	r.raftMu.Unlock()
+	defer r.unregisterRangeFeed(reg)
+
+	if catchUp != nil {
+		err := catchUpScan(catchUp, args.Span, args.Timestamp, func(kv roachpb.KeyValue) error {
+			var event roachpb.RangeFeedEvent
+			event.SetValue(&roachpb.RangeFeedValue{Key: kv.Key, Value: kv.Value})
+			return stream.Send(&event)
+		})
+		catchUp.Close()
+		if err != nil {
+			return roachpb.NewError(err)
+		}
+	}
+
+	ticker := time.NewTicker(rangeFeedCheckpointInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case event := <-reg.eventC:
+			if err := stream.Send(event); err != nil {
+				return roachpb.NewError(err)
+			}
+		case pErr := <-reg.errC:
+			return pErr
+		case <-ticker.C:
+			if err := r.IsDestroyed(); err != nil {
+				return roachpb.NewError(err)
+			}
+			r.checkpointRangeFeeds()
+		case <-ctx.Done():
+			return roachpb.NewError(ctx.Err())
+		case <-r.store.Stopper().ShouldQuiesce():
+			return roachpb.NewError(&roachpb.NodeUnavailableError{})
+		}
+	}
+}
+
+// registerRangeFeedRaftMuLocked adds a registration to the replica, creating
+// the replica's rangefeed processor if necessary.
+func (r *Replica) registerRangeFeedRaftMuLocked(reg *rangeFeedRegistration) error {
+	r.mu.RLock()
+	desc := r.mu.state.Desc
+	lai := r.mu.state.LeaseAppliedIndex
+	r.mu.RUnlock()
+	if !desc.ContainsKeyRange(roachpb.RKey(reg.span.Key), roachpb.RKey(reg.span.EndKey)) {
+		return roachpb.NewRangeKeyMismatchError(reg.span.Key, reg.span.EndKey, desc)
+	}
+
+	r.rangefeedMu.Lock()
+	defer r.rangefeedMu.Unlock()
+	if r.rangefeedMu.proc == nil {
+		proc, err := newRangeFeedProcessor(r.store.Engine(), desc, lai)
+		if err != nil {
+			return err
+		}
+		r.rangefeedMu.proc = proc
+	}
+	r.rangefeedMu.proc.regs = append(r.rangefeedMu.proc.regs, reg)
+	return nil
+}
+
+// unregisterRangeFeed removes a registration from the replica, tearing down
+// the replica's rangefeed processor if it was the last one.
+func (r *Replica) unregisterRangeFeed(reg *rangeFeedRegistration) {
+	r.rangefeedMu.Lock()
+	defer r.rangefeedMu.Unlock()
+	p := r.rangefeedMu.proc
+	if p == nil {
+		return
+	}
+	for i := range p.regs {
+		if p.regs[i] == reg {
+			p.regs = append(p.regs[:i], p.regs[i+1:]...)
+			break
+		}
+	}
+	if len(p.regs) == 0 {
+		r.rangefeedMu.proc = nil
+	}
+}
+
+// checkpointRangeFeeds closes a new timestamp if the replica is the
+// leaseholder and sends checkpoints to the rangefeed registrations of the
+// replica.
+func (r *Replica) checkpointRangeFeeds() {
+	r.mu.Lock()
+	closed, lai := r.closeTimestampLocked(r.store.Clock().Now())
+	if closed == (hlc.Timestamp{}) {
+		lai = r.mu.state.LeaseAppliedIndex
+		closed = r.mu.closedTS.get(lai)
+	}
+	r.mu.Unlock()
+
+	r.rangefeedMu.Lock()
+	defer r.rangefeedMu.Unlock()
+	if p := r.rangefeedMu.proc; p != nil {
+		p.forwardClosed(closed, lai)
+		p.checkpoint()
+	}
+}
+
+// handleRangeFeedWriteBatchRaftMuLocked publishes the values committed by an
+// applied write batch to the rangefeed registrations of the replica.
+func (r *Replica) handleRangeFeedWriteBatchRaftMuLocked(
+	ctx context.Context, writeBatch *storagebase.WriteBatch, leaseAppliedIndex uint64,
+) {
+	r.rangefeedMu.Lock()
+	defer r.rangefeedMu.Unlock()
+	p := r.rangefeedMu.proc
+	if p == nil {
+		return
+	}
+	if writeBatch != nil {
+		if err := p.consumeWriteBatch(r.store.Engine(), writeBatch.Data); err != nil {
+			log.Errorf(ctx, "disconnecting rangefeeds: %s", err)
+			r.rangefeedMu.proc = nil
+			p.disconnectAll(roachpb.NewError(err))
+			return
+		}
+	}
+	if leaseAppliedIndex > p.lai {
+		p.lai = leaseAppliedIndex
+	}
+}
+
+// disconnectRangeFeedsRaftMuLocked ends all rangefeed registrations of the
+// replica with the given error. This is necessary when the replica's data
+// changes in ways which can't be translated into values, such as when a
+// snapshot is applied or the range splits or merges. The clients reconnect
+// from their last checkpoint.
+func (r *Replica) disconnectRangeFeedsRaftMuLocked(pErr *roachpb.Error) {
+	r.rangefeedMu.Lock()
+	defer r.rangefeedMu.Unlock()
+	if p := r.rangefeedMu.proc; p != nil {
+		r.rangefeedMu.proc = nil
+		p.disconnectAll(pErr)
+	}
+}
+
+// rangeKeyMismatchErrorForRangeFeeds returns the error with which rangefeeds
+// are disconnected when the replica's data changes in ways they can't
+// follow. Clients treat it like a stale range descriptor.
+func (r *Replica) rangeKeyMismatchErrorForRangeFeeds() *roachpb.Error {
+	desc := r.Desc()
+	return roachpb.NewError(roachpb.NewRangeKeyMismatchError(
+		desc.StartKey.AsRawKey(), desc.EndKey.AsRawKey(), nil))
+}
+
+// catchUpScan calls f for every committed value in the span with a timestamp
+// above startTS, in key order.
+func catchUpScan(
+	reader engine.Reader, span roachpb.Span, startTS hlc.Timestamp, f func(roachpb.KeyValue) error,
+) error {
+	iter := reader.NewIterator(false /* prefix */)
+	defer iter.Close()
+	var meta enginepb.MVCCMetadata
+	var intentKey roachpb.Key
+	var intentTS hlc.Timestamp
+	iter.Seek(engine.MakeMVCCMetadataKey(span.Key))
+	for {
+		if ok, err := iter.Valid(); err != nil {
+			return err
+		} else if !ok || iter.UnsafeKey().Key.Compare(span.EndKey) >= 0 {
+			return nil
+		}
+		unsafeKey := iter.UnsafeKey()
+		if !unsafeKey.IsValue() {
+			if err := protoutil.Unmarshal(iter.UnsafeValue(), &meta); err != nil {
+				return errors.Wrapf(err, "unmarshaling mvcc meta: %s", unsafeKey)
+			}
+			intentKey = intentKey[:0]
+			if meta.Txn != nil {
+				intentKey = append(intentKey, unsafeKey.Key...)
+				intentTS = hlc.Timestamp(meta.Timestamp)
+			}
+			iter.Next()
+			continue
+		}
+		if !startTS.Less(unsafeKey.Timestamp) {
+			// The remaining versions of the key are older still.
+			iter.NextKey()
+			continue
+		}
+		// Skip provisional values; they are published once their intent is
+		// resolved.
+		if unsafeKey.Timestamp != intentTS || !unsafeKey.Key.Equal(intentKey) {
+			kv := roachpb.KeyValue{
+				Key: append(roachpb.Key(nil), unsafeKey.Key...),
+				Value: roachpb.Value{
+					RawBytes:  append([]byte(nil), iter.UnsafeValue()...),
+					Timestamp: unsafeKey.Timestamp,
+				},
+			}
+			if err := f(kv); err != nil {
+				return err
+			}
+		}
+		iter.Next()
+	}
+}
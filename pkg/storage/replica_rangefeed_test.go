@@ -0,0 +1,149 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestRangeFeedProcessorConsumeWriteBatch(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	eng := engine.NewInMem(roachpb.Attributes{}, 1<<20)
+	defer eng.Close()
+
+	desc := &roachpb.RangeDescriptor{
+		StartKey: roachpb.RKey(keys.LocalMax),
+		EndKey:   roachpb.RKeyMax,
+	}
+	p, err := newRangeFeedProcessor(eng, desc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := &rangeFeedRegistration{
+		span:   roachpb.Span{Key: roachpb.Key("a"), EndKey: roachpb.Key("z")},
+		eventC: make(chan *roachpb.RangeFeedEvent, 16),
+		errC:   make(chan *roachpb.Error, 1),
+	}
+	p.regs = append(p.regs, reg)
+
+	apply := func(f func(engine.ReadWriter) error) {
+		batch := eng.NewBatch()
+		defer batch.Close()
+		if err := f(batch); err != nil {
+			t.Fatal(err)
+		}
+		repr := batch.Repr()
+		if err := batch.Commit(false /* sync */); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.consumeWriteBatch(eng, repr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectValues := func(expected ...string) {
+		for _, e := range expected {
+			select {
+			case event := <-reg.eventC:
+				val, ok := event.GetValue().(*roachpb.RangeFeedValue)
+				if !ok {
+					t.Fatalf("expected value event, got %+v", event.GetValue())
+				}
+				if string(val.Key) != e {
+					t.Fatalf("expected value for key %q, got %q", e, val.Key)
+				}
+			default:
+				t.Fatalf("expected value for key %q, got nothing", e)
+			}
+		}
+		if len(reg.eventC) != 0 {
+			t.Fatalf("unexpected events: %d", len(reg.eventC))
+		}
+	}
+	value := roachpb.MakeValueFromString("v")
+	ts := func(wallTime int64) hlc.Timestamp { return hlc.Timestamp{WallTime: wallTime} }
+
+	// A non-transactional write is published right away.
+	apply(func(rw engine.ReadWriter) error {
+		return engine.MVCCPut(ctx, rw, nil, roachpb.Key("a"), ts(1), value, nil)
+	})
+	expectValues("a")
+
+	// Writes outside the registration's span aren't published.
+	apply(func(rw engine.ReadWriter) error {
+		return engine.MVCCPut(ctx, rw, nil, roachpb.Key("zz"), ts(1), value, nil)
+	})
+	expectValues()
+
+	// An intent is published once it is resolved as committed, and holds back
+	// the resolved timestamp until then.
+	txn := newTransaction("test", roachpb.Key("b"), 1, enginepb.SERIALIZABLE, nil)
+	txn.Timestamp, txn.OrigTimestamp = ts(5), ts(5)
+	apply(func(rw engine.ReadWriter) error {
+		return engine.MVCCPut(ctx, rw, nil, roachpb.Key("b"), txn.Timestamp, value, txn)
+	})
+	expectValues()
+	p.forwardClosed(ts(10), 0)
+	if resolved := p.resolvedTimestamp(); resolved != ts(5).Prev() {
+		t.Fatalf("expected resolved timestamp %s, got %s", ts(5).Prev(), resolved)
+	}
+	apply(func(rw engine.ReadWriter) error {
+		return engine.MVCCResolveWriteIntent(ctx, rw, nil, roachpb.Intent{
+			Span: roachpb.Span{Key: roachpb.Key("b")}, Txn: txn.TxnMeta, Status: roachpb.COMMITTED,
+		})
+	})
+	expectValues("b")
+	if resolved := p.resolvedTimestamp(); resolved != ts(10) {
+		t.Fatalf("expected resolved timestamp %s, got %s", ts(10), resolved)
+	}
+
+	// An aborted intent is never published.
+	txn = newTransaction("test", roachpb.Key("c"), 1, enginepb.SERIALIZABLE, nil)
+	txn.Timestamp, txn.OrigTimestamp = ts(11), ts(11)
+	apply(func(rw engine.ReadWriter) error {
+		return engine.MVCCPut(ctx, rw, nil, roachpb.Key("c"), txn.Timestamp, value, txn)
+	})
+	apply(func(rw engine.ReadWriter) error {
+		return engine.MVCCResolveWriteIntent(ctx, rw, nil, roachpb.Intent{
+			Span: roachpb.Span{Key: roachpb.Key("c")}, Txn: txn.TxnMeta, Status: roachpb.ABORTED,
+		})
+	})
+	expectValues()
+	if len(p.intents) != 0 {
+		t.Fatalf("expected no intents, got %v", p.intents)
+	}
+
+	// The catch-up scan returns the committed values above the given
+	// timestamp.
+	var scanned []string
+	if err := catchUpScan(eng, reg.span, ts(1), func(kv roachpb.KeyValue) error {
+		scanned = append(scanned, string(kv.Key))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(scanned) != 1 || scanned[0] != "b" {
+		t.Fatalf("expected catch-up scan to return [b], got %v", scanned)
+	}
+}
@@ -26,32 +26,82 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/pkg/errors"
 )
 
 var _ sideloadStorage = &diskSideloadStorage{}
 
 type diskSideloadStorage struct {
-	st         *cluster.Settings
+	st *cluster.Settings
+	// eng, if set, is the engine through whose env the files are accessed,
+	// so that they are encrypted along with the engine's own files.
+	// Directories are always managed directly.
+	eng        engine.Engine
 	dir        string
 	dirCreated bool
 }
 
 func newDiskSideloadStorage(
-	st *cluster.Settings, rangeID roachpb.RangeID, replicaID roachpb.ReplicaID, baseDir string,
+	st *cluster.Settings,
+	rangeID roachpb.RangeID,
+	replicaID roachpb.ReplicaID,
+	baseDir string,
+	eng engine.Engine,
 ) (sideloadStorage, error) {
 	ss := &diskSideloadStorage{
-		dir: filepath.Join(
-			baseDir,
-			"sideloading",
-			fmt.Sprintf("%d", rangeID%1000), // sharding
-			fmt.Sprintf("%d.%d", rangeID, replicaID),
-		),
-		st: st,
+		dir: sideloadDir(baseDir, rangeID, replicaID),
+		st:  st,
+	}
+	// In-memory engines keep their files in memory, but the auxiliary
+	// directory they hand out is on disk.
+	if _, ok := eng.(engine.InMem); !ok {
+		ss.eng = eng
 	}
 	return ss, nil
 }
 
+func sideloadDir(baseDir string, rangeID roachpb.RangeID, replicaID roachpb.ReplicaID) string {
+	return filepath.Join(
+		baseDir,
+		"sideloading",
+		fmt.Sprintf("%d", rangeID%1000), // sharding
+		fmt.Sprintf("%d.%d", rangeID, replicaID),
+	)
+}
+
+// moveSideloadedDir moves the sideloaded files of a replica to a new
+// directory, keeping the engine informed if it is given.
+func moveSideloadedDir(eng engine.Engine, oldDir, newDir string) error {
+	if _, ok := eng.(engine.InMem); ok || eng == nil {
+		return os.Rename(oldDir, newDir)
+	}
+	return eng.RenameFile(oldDir, newDir)
+}
+
+func (ss *diskSideloadStorage) writeFile(filename string, contents []byte) error {
+	if ss.eng == nil {
+		// Use 0644 since that's what RocksDB uses:
+		// https://github.com/facebook/rocksdb/blob/56656e12d67d8a63f1e4c4214da9feeec2bd442b/env/env_posix.cc#L171
+		return ioutil.WriteFile(filename, contents, 0644)
+	}
+	return ss.eng.WriteFile(filename, contents)
+}
+
+func (ss *diskSideloadStorage) readFile(filename string) ([]byte, error) {
+	if ss.eng == nil {
+		return ioutil.ReadFile(filename)
+	}
+	return ss.eng.ReadFile(filename)
+}
+
+func (ss *diskSideloadStorage) deleteFile(filename string) error {
+	if ss.eng == nil {
+		return os.Remove(filename)
+	}
+	return ss.eng.DeleteFile(filename)
+}
+
 func (ss *diskSideloadStorage) createDir() error {
 	err := os.MkdirAll(ss.dir, 0755)
 	ss.dirCreated = ss.dirCreated || err == nil
@@ -76,28 +126,22 @@ func (ss *diskSideloadStorage) PutIfNotExists(
 	}
 	// File does not exist yet. There's a chance the whole path is missing (for
 	// example after Clear()), in which case handle that transparently.
-	for {
-		// Use 0644 since that's what RocksDB uses:
-		// https://github.com/facebook/rocksdb/blob/56656e12d67d8a63f1e4c4214da9feeec2bd442b/env/env_posix.cc#L171
-		if err := ioutil.WriteFile(filename, contents, 0644); err == nil {
-			return nil
-		} else if !os.IsNotExist(err) {
-			return err
-		}
+	if _, err := os.Stat(ss.dir); os.IsNotExist(err) {
 		if err := ss.createDir(); err != nil {
 			return err
 		}
-		continue
+	} else if err != nil {
+		return err
 	}
+	return ss.writeFile(filename, contents)
 }
 
 func (ss *diskSideloadStorage) Get(ctx context.Context, index, term uint64) ([]byte, error) {
 	filename := ss.filename(ctx, index, term)
-	b, err := ioutil.ReadFile(filename)
-	if os.IsNotExist(err) {
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
 		return nil, errSideloadedFileNotFound
 	}
-	return b, err
+	return ss.readFile(filename)
 }
 
 func (ss *diskSideloadStorage) Filename(ctx context.Context, index, term uint64) (string, error) {
@@ -113,16 +157,26 @@ func (ss *diskSideloadStorage) Purge(ctx context.Context, index, term uint64) er
 }
 
 func (ss *diskSideloadStorage) purgeFile(ctx context.Context, filename string) error {
-	if err := os.Remove(filename); err != nil {
-		if os.IsNotExist(err) {
-			return errSideloadedFileNotFound
-		}
-		return err
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return errSideloadedFileNotFound
 	}
-	return nil
+	return ss.deleteFile(filename)
 }
 
 func (ss *diskSideloadStorage) Clear(_ context.Context) error {
+	if ss.eng != nil {
+		// Delete the files through the engine first so that it forgets
+		// about them.
+		matches, err := filepath.Glob(filepath.Join(ss.dir, "*"))
+		if err != nil {
+			return err
+		}
+		for _, match := range matches {
+			if err := ss.deleteFile(match); err != nil {
+				return errors.Wrapf(err, "while purging %q", match)
+			}
+		}
+	}
 	err := os.RemoveAll(ss.dir)
 	ss.dirCreated = ss.dirCreated && err != nil
 	return err
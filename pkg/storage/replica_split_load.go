@@ -0,0 +1,225 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// splitByLoadEnabled controls whether ranges are split when the rate of
+// requests they receive exceeds splitByLoadQPSThreshold.
+var splitByLoadEnabled = settings.RegisterBoolSetting(
+	"kv.range_split.by_load_enabled",
+	"allow automatic splits of ranges based on where load is concentrated",
+	false,
+)
+
+// splitByLoadQPSThreshold is the number of requests per second a range must
+// receive before it is considered for a load-based split.
+var splitByLoadQPSThreshold = settings.RegisterIntSetting(
+	"kv.range_split.load_qps_threshold",
+	"the QPS over which a range becomes a candidate for load-based splitting",
+	250,
+)
+
+const (
+	// splitKeySampleSize is the number of request keys sampled to find a
+	// load-based split key.
+	splitKeySampleSize = 20
+	// splitKeyMinCounter is the number of requests which must have been
+	// compared against a sampled key before it is considered as a split key.
+	splitKeyMinCounter = 100
+	// splitKeyThreshold is the maximum imbalance between the requests to
+	// either side of a sampled key for the key to be considered as a split
+	// key, as a fraction of these requests.
+	splitKeyThreshold = 0.25
+	// splitKeyContainedThreshold is the maximum fraction of requests which
+	// may span a sampled key for the key to be considered as a split key.
+	splitKeyContainedThreshold = 0.50
+	// splitKeyMinDuration is the minimum duration over which requests are
+	// sampled before a load-based split key is suggested.
+	splitKeyMinDuration = 10 * time.Second
+)
+
+// splitKeySample is a sampled request key, along with the number of requests
+// seen since it was sampled which fell to its left, to its right, or which
+// contained it.
+type splitKeySample struct {
+	key                    roachpb.Key
+	left, right, contained int
+}
+
+// splitFinder finds a key which splits a range into two halves receiving
+// similar numbers of requests. It keeps a reservoir sample of the start keys
+// of the requests it is shown, and counts for each sampled key the requests
+// on either side of it. The sampled key whose sides are the most balanced
+// and which is spanned by the fewest requests is chosen as the split key.
+type splitFinder struct {
+	startTime time.Time
+	samples   [splitKeySampleSize]splitKeySample
+	count     int
+}
+
+// newSplitFinder returns a splitFinder which starts sampling at the given
+// time.
+func newSplitFinder(startTime time.Time) *splitFinder {
+	return &splitFinder{startTime: startTime}
+}
+
+// ready returns whether enough time has passed since the splitFinder started
+// sampling for it to suggest a split key.
+func (f *splitFinder) ready(now time.Time) bool {
+	return now.Sub(f.startTime) >= splitKeyMinDuration
+}
+
+// record records a request for the given span. intn is used to choose which
+// sample, if any, the request's key replaces.
+func (f *splitFinder) record(span roachpb.Span, intn func(int) int) {
+	var idx int
+	f.count++
+	if f.count <= splitKeySampleSize {
+		idx = f.count - 1
+	} else if idx = intn(f.count); idx >= splitKeySampleSize {
+		// The request's key isn't sampled; count it against the samples. A
+		// request starting at a sampled key would end up to its right.
+		for i := range f.samples {
+			s := &f.samples[i]
+			if s.key.Compare(span.Key) <= 0 {
+				s.right++
+			} else if len(span.EndKey) > 0 && s.key.Compare(span.EndKey) < 0 {
+				s.contained++
+			} else {
+				s.left++
+			}
+		}
+		return
+	}
+	f.samples[idx] = splitKeySample{key: span.Key}
+}
+
+// key returns the best split key found so far, or nil if none of the sampled
+// keys balances the requests well enough.
+func (f *splitFinder) key() roachpb.Key {
+	bestIdx := -1
+	bestScore := 2.0
+	for i, s := range f.samples {
+		if s.left+s.right+s.contained < splitKeyMinCounter {
+			continue
+		}
+		balanceScore := math.Abs(float64(s.left-s.right)) / float64(s.left+s.right)
+		containedScore := float64(s.contained) / float64(s.left+s.right+s.contained)
+		if balanceScore >= splitKeyThreshold || containedScore >= splitKeyContainedThreshold {
+			continue
+		}
+		if score := balanceScore + containedScore; score < bestScore {
+			bestIdx = i
+			bestScore = score
+		}
+	}
+	if bestIdx == -1 {
+		return nil
+	}
+	return f.samples[bestIdx].key
+}
+
+// loadBasedSplitter tracks the requests to a replica whose range receives
+// more requests than splitByLoadQPSThreshold, in order to find a key at
+// which the range can be split to spread its load.
+type loadBasedSplitter struct {
+	syncutil.Mutex
+	finder *splitFinder
+	// lastQueued is the time at which the replica was last offered to the
+	// split queue because of its load.
+	lastQueued time.Time
+}
+
+// exceedsLoadSplitThreshold returns whether load-based splitting is enabled
+// and the replica's range receives more requests than the threshold.
+func (r *Replica) exceedsLoadSplitThreshold() bool {
+	st := r.store.ClusterSettings()
+	if !splitByLoadEnabled.Get(&st.SV) || r.leaseholderStats == nil {
+		return false
+	}
+	qps, dur := r.leaseholderStats.avgQPS()
+	return dur >= MinStatsDuration && qps >= float64(splitByLoadQPSThreshold.Get(&st.SV))
+}
+
+// recordBatchForLoadBasedSplitting samples the keys of the batch if the
+// replica's range is hot, and offers the replica to the split queue once a
+// key which would split its load evenly has been found.
+func (r *Replica) recordBatchForLoadBasedSplitting(ba roachpb.BatchRequest) {
+	if !r.exceedsLoadSplitThreshold() {
+		r.resetLoadBasedSplitter()
+		return
+	}
+
+	now := timeutil.Now()
+	r.loadBasedSplitter.Lock()
+	if r.loadBasedSplitter.finder == nil {
+		r.loadBasedSplitter.finder = newSplitFinder(now)
+	}
+	finder := r.loadBasedSplitter.finder
+	for _, union := range ba.Requests {
+		// Range-local keys can't be split at.
+		if span := union.GetInner().Header(); !keys.IsLocal(span.Key) {
+			finder.record(span, rand.Intn)
+		}
+	}
+	shouldQueue := finder.ready(now) && finder.key() != nil &&
+		now.Sub(r.loadBasedSplitter.lastQueued) >= time.Second
+	if shouldQueue {
+		r.loadBasedSplitter.lastQueued = now
+	}
+	r.loadBasedSplitter.Unlock()
+
+	if shouldQueue && r.store.splitQueue != nil {
+		r.store.splitQueue.MaybeAdd(r, r.store.Clock().Now())
+	}
+}
+
+// loadBasedSplitKey returns the key at which the replica's range should be
+// split to spread its load, or nil if no such key has been found. The key
+// never falls between the column families of a SQL row.
+func (r *Replica) loadBasedSplitKey() roachpb.Key {
+	r.loadBasedSplitter.Lock()
+	defer r.loadBasedSplitter.Unlock()
+	finder := r.loadBasedSplitter.finder
+	if finder == nil || !finder.ready(timeutil.Now()) {
+		return nil
+	}
+	splitKey := finder.key()
+	if splitKey == nil {
+		return nil
+	}
+	if safeSplitKey, err := keys.EnsureSafeSplitKey(splitKey); err == nil && len(safeSplitKey) != 0 {
+		splitKey = safeSplitKey
+	}
+	return splitKey
+}
+
+// resetLoadBasedSplitter discards the request keys sampled so far.
+func (r *Replica) resetLoadBasedSplitter() {
+	r.loadBasedSplitter.Lock()
+	defer r.loadBasedSplitter.Unlock()
+	r.loadBasedSplitter.finder = nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func splitFinderTestKey(i int) roachpb.Key {
+	return roachpb.Key(fmt.Sprintf("key-%03d", i))
+}
+
+// TestSplitFinderKey verifies that the split finder suggests a key which
+// balances the requests to either side of it, and no key if there is no
+// such key.
+func TestSplitFinderKey(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testCases := []struct {
+		name string
+		// span returns the span of the i-th request.
+		span     func(r *rand.Rand, i int) roachpb.Span
+		expSplit bool
+	}{
+		{
+			name: "uniform point requests",
+			span: func(r *rand.Rand, _ int) roachpb.Span {
+				return roachpb.Span{Key: splitFinderTestKey(r.Intn(100))}
+			},
+			expSplit: true,
+		},
+		{
+			name: "single key",
+			span: func(_ *rand.Rand, _ int) roachpb.Span {
+				return roachpb.Span{Key: splitFinderTestKey(7)}
+			},
+			expSplit: false,
+		},
+		{
+			name: "spanning requests",
+			span: func(_ *rand.Rand, i int) roachpb.Span {
+				return roachpb.Span{Key: splitFinderTestKey(i % 2), EndKey: splitFinderTestKey(100)}
+			},
+			expSplit: false,
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(1))
+			start := time.Unix(0, 0)
+			f := newSplitFinder(start)
+			for i := 0; i < 10000; i++ {
+				f.record(c.span(r, i), r.Intn)
+			}
+			if f.ready(start.Add(splitKeyMinDuration - 1)) {
+				t.Fatal("expected split finder not to be ready")
+			}
+			if !f.ready(start.Add(splitKeyMinDuration)) {
+				t.Fatal("expected split finder to be ready")
+			}
+
+			key := f.key()
+			if !c.expSplit {
+				if key != nil {
+					t.Fatalf("expected no split key, got %s", key)
+				}
+				return
+			}
+			// The requests are spread evenly over 100 keys, so the split key
+			// must lie in the middle of them.
+			if key.Compare(splitFinderTestKey(35)) < 0 || key.Compare(splitFinderTestKey(65)) > 0 {
+				t.Fatalf("expected split key between %s and %s, got %s",
+					splitFinderTestKey(35), splitFinderTestKey(65), key)
+			}
+		})
+	}
+}
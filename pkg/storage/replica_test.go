@@ -8921,3 +8921,386 @@ func TestShouldReplicaQuiesce(t *testing.T) {
 		return q
 	})
 }
+
+// TestReplicaQueryIntent verifies that QueryIntent finds an intent only if it
+// belongs to the queried epoch of the transaction, and that it returns a
+// retry error for a missing intent if asked to.
+func TestReplicaQueryIntent(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.TODO())
+	tc.Start(t, stopper)
+
+	key := roachpb.Key("a")
+	txn := newTransaction("test", key, 1, enginepb.SERIALIZABLE, tc.Clock())
+	txn.Sequence = 1
+	pArgs := putArgs(key, []byte("value"))
+	_, respH, pErr := SendWrapped(context.Background(), tc.Sender(), roachpb.Header{Txn: txn}, &pArgs)
+	if pErr != nil {
+		t.Fatal(pErr)
+	}
+	txn = respH.Txn
+
+	queryIntent := func(key roachpb.Key, meta enginepb.TxnMeta, errorIfMissing bool) (bool, *roachpb.Error) {
+		qiArgs := roachpb.QueryIntentRequest{
+			Span:           roachpb.Span{Key: key},
+			Txn:            meta,
+			ErrorIfMissing: errorIfMissing,
+		}
+		resp, pErr := tc.SendWrappedWith(roachpb.Header{Txn: txn}, &qiArgs)
+		if pErr != nil {
+			return false, pErr
+		}
+		return resp.(*roachpb.QueryIntentResponse).FoundIntent, nil
+	}
+
+	laterSeq := txn.TxnMeta
+	laterSeq.Sequence++
+	earlierTS := txn.TxnMeta
+	earlierTS.Timestamp = txn.OrigTimestamp.Prev()
+	laterEpoch := txn.TxnMeta
+	laterEpoch.Epoch++
+	otherTxn := newTransaction("other", key, 1, enginepb.SERIALIZABLE, tc.Clock()).TxnMeta
+
+	testCases := []struct {
+		key   roachpb.Key
+		meta  enginepb.TxnMeta
+		found bool
+	}{
+		{key, txn.TxnMeta, true},
+		{key, laterSeq, true},
+		{key, earlierTS, true},
+		{key, laterEpoch, false},
+		{key, otherTxn, false},
+		{roachpb.Key("b"), txn.TxnMeta, false},
+	}
+	for i, c := range testCases {
+		found, pErr := queryIntent(c.key, c.meta, false /* errorIfMissing */)
+		if pErr != nil {
+			t.Fatalf("%d: unexpected error: %s", i, pErr)
+		}
+		if found != c.found {
+			t.Errorf("%d: expected found=%t, got %t", i, c.found, found)
+		}
+		_, pErr = queryIntent(c.key, c.meta, true /* errorIfMissing */)
+		if c.found {
+			if pErr != nil {
+				t.Errorf("%d: unexpected error: %s", i, pErr)
+			}
+		} else if retryErr, ok := pErr.GetDetail().(*roachpb.TransactionRetryError); !ok ||
+			retryErr.Reason != roachpb.RETRY_ASYNC_WRITE_FAILURE {
+			t.Errorf("%d: expected async write failure retry error, got %v", i, pErr)
+		}
+	}
+}
+
+// TestReplicaQueryIntentPushed verifies that QueryIntent finds an intent
+// which was pushed above the queried transaction's timestamp, returns it,
+// and moves the timestamp of the transaction in the batch forward to the
+// intent's.
+func TestReplicaQueryIntentPushed(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.TODO())
+	tc.Start(t, stopper)
+
+	key := roachpb.Key("a")
+	txn := newTransaction("test", key, 1, enginepb.SERIALIZABLE, tc.Clock())
+	var ba roachpb.BatchRequest
+	bt, btH := beginTxnArgs(key, txn)
+	put := putArgs(key, []byte("value"))
+	ba.Header = btH
+	ba.Add(&bt)
+	ba.Add(&put)
+	br, pErr := tc.Sender().Send(context.Background(), ba)
+	if pErr != nil {
+		t.Fatal(pErr)
+	}
+	txn = br.Txn
+
+	// Push the intent, as a PushTxn with PUSH_TIMESTAMP followed by the
+	// resolution of the intent would.
+	pushed := txn.TxnMeta
+	pushed.Timestamp = tc.Clock().Now().Add(10, 0)
+	rArgs := &roachpb.ResolveIntentRequest{
+		Span:      roachpb.Span{Key: key},
+		IntentTxn: pushed,
+		Status:    roachpb.PENDING,
+	}
+	if _, pErr := tc.SendWrapped(rArgs); pErr != nil {
+		t.Fatal(pErr)
+	}
+
+	qiArgs := roachpb.QueryIntentRequest{
+		Span:           roachpb.Span{Key: key},
+		Txn:            txn.TxnMeta,
+		ErrorIfMissing: true,
+	}
+	resp, pErr := tc.SendWrappedWith(roachpb.Header{Txn: txn}, &qiArgs)
+	if pErr != nil {
+		t.Fatal(pErr)
+	}
+	reply := resp.(*roachpb.QueryIntentResponse)
+	if !reply.FoundIntent || reply.Intent == nil {
+		t.Fatalf("expected the pushed intent to be found, got %+v", reply)
+	}
+	if reply.Intent.Txn.Timestamp != pushed.Timestamp {
+		t.Errorf("expected intent at %s, got %s", pushed.Timestamp, reply.Intent.Txn.Timestamp)
+	}
+	if reply.Txn == nil || reply.Txn.Timestamp != pushed.Timestamp {
+		t.Errorf("expected the transaction to be pushed to %s, got %v", pushed.Timestamp, reply.Txn)
+	}
+
+	// An EndTransaction following the QueryIntent in the batch sees the
+	// pushed timestamp and can't commit at the original one.
+	ba = roachpb.BatchRequest{}
+	etArgs, etH := endTxnArgs(txn, true /* commit */)
+	etArgs.IntentSpans = []roachpb.Span{{Key: key}}
+	ba.Header = etH
+	ba.Add(&qiArgs)
+	ba.Add(&etArgs)
+	_, pErr = tc.Sender().Send(context.Background(), ba)
+	if retryErr, ok := pErr.GetDetail().(*roachpb.TransactionRetryError); !ok ||
+		retryErr.Reason != roachpb.RETRY_SERIALIZABLE {
+		t.Errorf("expected serializable retry error, got %v", pErr)
+	}
+}
+
+// TestReplicaAsyncConsensus verifies that a transactional write sent with
+// AsyncConsensus is acknowledged before it applies, and that a QueryIntent
+// for its key waits for it to apply.
+func TestReplicaAsyncConsensus(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	var blockApply int32
+	unblockApply := make(chan struct{})
+	cfg := TestStoreConfig(nil)
+	cfg.TestingKnobs.TestingApplyFilter = func(storagebase.ApplyFilterArgs) *roachpb.Error {
+		if atomic.LoadInt32(&blockApply) == 1 {
+			<-unblockApply
+		}
+		return nil
+	}
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.TODO())
+	tc.StartWithStoreConfig(t, stopper, cfg)
+
+	key := roachpb.Key("a")
+	txn := newTransaction("test", key, 1, enginepb.SERIALIZABLE, tc.Clock())
+	txn.Sequence = 1
+
+	atomic.StoreInt32(&blockApply, 1)
+	unblocked := false
+	unblock := func() {
+		if !unblocked {
+			unblocked = true
+			atomic.StoreInt32(&blockApply, 0)
+			close(unblockApply)
+		}
+	}
+	defer unblock()
+
+	pArgs := putArgs(key, []byte("value"))
+	putErrC := make(chan *roachpb.Error, 1)
+	var respTxn *roachpb.Transaction
+	go func() {
+		_, respH, pErr := SendWrapped(context.Background(), tc.Sender(),
+			roachpb.Header{Txn: txn, AsyncConsensus: true}, &pArgs)
+		respTxn = respH.Txn
+		putErrC <- pErr
+	}()
+	select {
+	case pErr := <-putErrC:
+		if pErr != nil {
+			t.Fatal(pErr)
+		}
+	case <-time.After(testutils.DefaultSucceedsSoonDuration):
+		t.Fatal("write with AsyncConsensus was not acknowledged before applying")
+	}
+
+	qiErrC := make(chan *roachpb.Error, 1)
+	go func() {
+		qiArgs := roachpb.QueryIntentRequest{
+			Span:           roachpb.Span{Key: key},
+			Txn:            respTxn.TxnMeta,
+			ErrorIfMissing: true,
+		}
+		_, pErr := tc.SendWrappedWith(roachpb.Header{Txn: respTxn}, &qiArgs)
+		qiErrC <- pErr
+	}()
+	select {
+	case pErr := <-qiErrC:
+		t.Fatalf("QueryIntent returned before the write applied: %v", pErr)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	unblock()
+	if pErr := <-qiErrC; pErr != nil {
+		t.Fatal(pErr)
+	}
+}
+
+// TestReplicaParallelCommit verifies that an EndTransaction listing
+// in-flight writes leaves the transaction record STAGING, that such a
+// transaction can neither be rolled back nor pushed, and that recovering it
+// commits or aborts it depending on whether its writes succeeded.
+func TestReplicaParallelCommit(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.TODO())
+	tc.Start(t, stopper)
+
+	for i, implicitlyCommitted := range []bool{false, true} {
+		key := roachpb.Key(fmt.Sprintf("a-%d", i))
+		inFlightKey := roachpb.Key(fmt.Sprintf("b-%d", i))
+		txn := newTransaction("test", key, 1, enginepb.SERIALIZABLE, tc.Clock())
+		_, btH := beginTxnArgs(key, txn)
+		put := putArgs(key, []byte("value"))
+		if _, pErr := maybeWrapWithBeginTransaction(context.Background(), tc.Sender(), btH, &put); pErr != nil {
+			t.Fatalf("%d: %s", i, pErr)
+		}
+		txn.Writing = true
+		txn.Sequence++
+		if implicitlyCommitted {
+			put := putArgs(inFlightKey, []byte("value"))
+			if _, pErr := tc.SendWrappedWith(roachpb.Header{Txn: txn}, &put); pErr != nil {
+				t.Fatalf("%d: %s", i, pErr)
+			}
+		}
+
+		args, h := endTxnArgs(txn, true)
+		args.IntentSpans = []roachpb.Span{{Key: key}, {Key: inFlightKey}}
+		args.InFlightWrites = []roachpb.SequencedWrite{{Key: inFlightKey, Sequence: txn.Sequence}}
+		txn.Sequence++
+		resp, pErr := tc.SendWrappedWith(h, &args)
+		if pErr != nil {
+			t.Fatalf("%d: %s", i, pErr)
+		}
+		staged := resp.Header().Txn
+		if staged.Status != roachpb.STAGING || len(staged.InFlightWrites) != 1 {
+			t.Fatalf("%d: expected STAGING txn with one in-flight write, got %s", i, staged)
+		}
+
+		// A STAGING transaction may be implicitly committed and can't be
+		// rolled back.
+		rbArgs, rbH := endTxnArgs(txn, false)
+		txn.Sequence++
+		if _, pErr := tc.SendWrappedWith(rbH, &rbArgs); !testutils.IsPError(pErr, "cannot roll back a STAGING transaction") {
+			t.Fatalf("%d: expected rollback to fail, got %v", i, pErr)
+		}
+
+		// Nor can it be pushed, even once it has expired.
+		pusher := newTransaction("pusher", key, 1, enginepb.SERIALIZABLE, tc.Clock())
+		pushArgs := pushTxnArgs(pusher, staged, roachpb.PUSH_ABORT)
+		pushArgs.Now = staged.LastActive().Add(2*base.DefaultHeartbeatInterval.Nanoseconds()+1, 0)
+		pushResp, pErr := tc.SendWrapped(&pushArgs)
+		if pErr != nil {
+			t.Fatalf("%d: %s", i, pErr)
+		}
+		if pushee := pushResp.(*roachpb.PushTxnResponse).PusheeTxn; pushee.Status != roachpb.STAGING {
+			t.Fatalf("%d: expected pushee to remain STAGING, got %s", i, pushee)
+		}
+
+		// The recovery decides the transaction's fate.
+		rtArgs := roachpb.RecoverTxnRequest{
+			Span:                roachpb.Span{Key: key},
+			Txn:                 staged.TxnMeta,
+			ImplicitlyCommitted: implicitlyCommitted,
+		}
+		rtResp, pErr := tc.SendWrapped(&rtArgs)
+		if pErr != nil {
+			t.Fatalf("%d: %s", i, pErr)
+		}
+		expStatus := roachpb.ABORTED
+		if implicitlyCommitted {
+			expStatus = roachpb.COMMITTED
+		}
+		recovered := rtResp.(*roachpb.RecoverTxnResponse).RecoveredTxn
+		if recovered.Status != expStatus || len(recovered.InFlightWrites) != 0 {
+			t.Fatalf("%d: expected %s txn without in-flight writes, got %s", i, expStatus, recovered)
+		}
+
+		// Recovering the transaction again with the opposite outcome must not
+		// change it.
+		rtArgs.ImplicitlyCommitted = !implicitlyCommitted
+		if _, pErr := tc.SendWrapped(&rtArgs); implicitlyCommitted != (pErr == nil) {
+			t.Fatalf("%d: unexpected result recovering %s txn again: %v", i, expStatus, pErr)
+		}
+	}
+}
+
+// TestRecoverStagingTxnWithPipelinedWrite verifies that a STAGING
+// transaction whose in-flight writes include a write pipelined before the
+// batch committing it is only recovered as committed if that write
+// succeeded too.
+func TestRecoverStagingTxnWithPipelinedWrite(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.TODO())
+	tc.Start(t, stopper)
+	ctx := context.Background()
+
+	for i, pipelinedWriteMissing := range []bool{false, true} {
+		key := roachpb.Key(fmt.Sprintf("a-%d", i))
+		pipelinedKey := roachpb.Key(fmt.Sprintf("b-%d", i))
+		finalKey := roachpb.Key(fmt.Sprintf("c-%d", i))
+		txn := newTransaction("test", key, 1, enginepb.SERIALIZABLE, tc.Clock())
+		_, btH := beginTxnArgs(key, txn)
+		put := putArgs(key, []byte("value"))
+		if _, pErr := maybeWrapWithBeginTransaction(ctx, tc.Sender(), btH, &put); pErr != nil {
+			t.Fatalf("%d: %s", i, pErr)
+		}
+		txn.Writing = true
+
+		// The pipelined write is lost if it is missing.
+		txn.Sequence++
+		pipelinedSeq := txn.Sequence
+		if !pipelinedWriteMissing {
+			put := putArgs(pipelinedKey, []byte("value"))
+			if _, pErr := tc.SendWrappedWith(roachpb.Header{Txn: txn}, &put); pErr != nil {
+				t.Fatalf("%d: %s", i, pErr)
+			}
+		}
+
+		// The write of the batch committing the transaction succeeds.
+		txn.Sequence++
+		finalSeq := txn.Sequence
+		put = putArgs(finalKey, []byte("value"))
+		if _, pErr := tc.SendWrappedWith(roachpb.Header{Txn: txn}, &put); pErr != nil {
+			t.Fatalf("%d: %s", i, pErr)
+		}
+
+		args, h := endTxnArgs(txn, true)
+		args.IntentSpans = []roachpb.Span{{Key: key}, {Key: pipelinedKey}, {Key: finalKey}}
+		args.InFlightWrites = []roachpb.SequencedWrite{
+			{Key: pipelinedKey, Sequence: pipelinedSeq},
+			{Key: finalKey, Sequence: finalSeq},
+		}
+		txn.Sequence++
+		resp, pErr := tc.SendWrappedWith(h, &args)
+		if pErr != nil {
+			t.Fatalf("%d: %s", i, pErr)
+		}
+		staged := resp.Header().Txn
+		if staged.Status != roachpb.STAGING {
+			t.Fatalf("%d: expected STAGING txn, got %s", i, staged)
+		}
+
+		recovered, err := recoverStagingTxn(ctx, tc.store.DB(), *staged)
+		if err != nil {
+			t.Fatalf("%d: %s", i, err)
+		}
+		expStatus := roachpb.COMMITTED
+		if pipelinedWriteMissing {
+			expStatus = roachpb.ABORTED
+		}
+		if recovered.Status != expStatus {
+			t.Fatalf("%d: expected %s txn, got %s", i, expStatus, recovered)
+		}
+	}
+}
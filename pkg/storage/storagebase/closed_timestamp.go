@@ -0,0 +1,72 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storagebase
+
+import (
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+)
+
+// FollowerReadsEnabled controls whether leaseholders close timestamps and
+// whether sufficiently old reads are routed to the nearest replica.
+var FollowerReadsEnabled = settings.RegisterBoolSetting(
+	"kv.follower_reads.enabled",
+	"set to true to let follower replicas serve sufficiently old read-only requests",
+	false,
+)
+
+// RangefeedEnabled controls whether replicas accept rangefeed registrations.
+// Rangefeeds rely on closed timestamps to checkpoint their progress, so
+// leaseholders also close timestamps when it is set.
+var RangefeedEnabled = settings.RegisterBoolSetting(
+	"kv.rangefeed.enabled",
+	"if set, rangefeed registration is enabled",
+	false,
+)
+
+// ClosedTimestampTargetDuration is how far behind the present leaseholders
+// keep their closed timestamp. Writes below the closed timestamp are pushed
+// above it.
+var ClosedTimestampTargetDuration = settings.RegisterNonNegativeDurationSetting(
+	"kv.closed_timestamp.target_duration",
+	"if follower reads or rangefeeds are enabled, leaseholders stop accepting writes this far in the past; set to 0 to disable closing timestamps",
+	30*time.Second,
+)
+
+// ClosedTimestampTarget returns the timestamp a leaseholder should try to
+// close at the given time, or a zero timestamp if timestamps are not being
+// closed because neither follower reads nor rangefeeds are enabled.
+func ClosedTimestampTarget(sv *settings.Values, now hlc.Timestamp) hlc.Timestamp {
+	target := ClosedTimestampTargetDuration.Get(sv)
+	if !(FollowerReadsEnabled.Get(sv) || RangefeedEnabled.Get(sv)) || target == 0 {
+		return hlc.Timestamp{}
+	}
+	return now.Add(-target.Nanoseconds(), 0)
+}
+
+// FollowerReadTimestamp returns the timestamp at or below which a read can be
+// expected to be served by a follower replica, or a zero timestamp if follower
+// reads are disabled. A follower learns of the closed timestamp with a delay
+// of up to another target duration (for idle ranges), so this is twice the
+// target duration in the past.
+func FollowerReadTimestamp(sv *settings.Values, now hlc.Timestamp) hlc.Timestamp {
+	target := ClosedTimestampTargetDuration.Get(sv)
+	if !FollowerReadsEnabled.Get(sv) || target == 0 {
+		return hlc.Timestamp{}
+	}
+	return now.Add(-2*target.Nanoseconds(), 0)
+}
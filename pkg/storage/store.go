@@ -351,6 +351,7 @@ type Store struct {
 	rangeIDAlloc       *idAllocator                // Range ID allocator
 	gcQueue            *gcQueue                    // Garbage collection queue
 	splitQueue         *splitQueue                 // Range splitting queue
+	mergeQueue         *mergeQueue                 // Range merging queue
 	replicateQueue     *replicateQueue             // Replication queue
 	replicaGCQueue     *replicaGCQueue             // Replica GC queue
 	raftLogQueue       *raftLogQueue               // Raft log truncation queue
@@ -358,6 +359,7 @@ type Store struct {
 	tsMaintenanceQueue *timeSeriesMaintenanceQueue // Time series maintenance queue
 	scanner            *replicaScanner             // Replica scanner
 	consistencyQueue   *consistencyQueue           // Replica consistency check queue
+	storeRebalancer    *StoreRebalancer            // Load-based rebalancer
 	metrics            *StoreMetrics
 	intentResolver     *intentResolver
 	raftEntryCache     *raftEntryCache
@@ -725,6 +727,8 @@ type StoreTestingKnobs struct {
 	DisableReplicaRebalancing bool
 	// DisableSplitQueue disables the split queue.
 	DisableSplitQueue bool
+	// DisableMergeQueue disables the merge queue.
+	DisableMergeQueue bool
 	// DisableTimeSeriesMaintenanceQueue disables the time series maintenance
 	// queue.
 	DisableTimeSeriesMaintenanceQueue bool
@@ -880,14 +884,16 @@ func NewStore(cfg StoreConfig, eng engine.Engine, nodeDesc *roachpb.NodeDescript
 		)
 		s.gcQueue = newGCQueue(s, s.cfg.Gossip)
 		s.splitQueue = newSplitQueue(s, s.db, s.cfg.Gossip)
+		s.mergeQueue = newMergeQueue(s, s.db, s.cfg.Gossip)
 		s.replicateQueue = newReplicateQueue(s, s.cfg.Gossip, s.allocator, s.cfg.Clock)
 		s.replicaGCQueue = newReplicaGCQueue(s, s.db, s.cfg.Gossip)
 		s.raftLogQueue = newRaftLogQueue(s, s.db, s.cfg.Gossip)
 		s.raftSnapshotQueue = newRaftSnapshotQueue(s, s.cfg.Gossip, s.cfg.Clock)
 		s.consistencyQueue = newConsistencyQueue(s, s.cfg.Gossip)
 		s.scanner.AddQueues(
-			s.gcQueue, s.splitQueue, s.replicateQueue, s.replicaGCQueue,
+			s.gcQueue, s.splitQueue, s.mergeQueue, s.replicateQueue, s.replicaGCQueue,
 			s.raftLogQueue, s.raftSnapshotQueue, s.consistencyQueue)
+		s.storeRebalancer = newStoreRebalancer(s, s.replicateQueue)
 
 		if s.cfg.TimeSeriesDataStore != nil {
 			s.tsMaintenanceQueue = newTimeSeriesMaintenanceQueue(
@@ -906,6 +912,9 @@ func NewStore(cfg StoreConfig, eng engine.Engine, nodeDesc *roachpb.NodeDescript
 	if cfg.TestingKnobs.DisableSplitQueue {
 		s.setSplitQueueActive(false)
 	}
+	if cfg.TestingKnobs.DisableMergeQueue {
+		s.setMergeQueueActive(false)
+	}
 	if cfg.TestingKnobs.DisableTimeSeriesMaintenanceQueue {
 		s.setTimeSeriesMaintenanceQueueActive(false)
 	}
@@ -1204,6 +1213,10 @@ func (s *Store) Start(ctx context.Context, stopper *stop.Stopper) error {
 			}
 		})
 
+		// Start the store rebalancer, which moves load off of the store if it
+		// serves many more queries than the other stores in the cluster.
+		s.storeRebalancer.Start(context.Background(), s.stopper)
+
 		// Run metrics computation up front to populate initial statistics.
 		if err = s.ComputeMetrics(ctx, -1); err != nil {
 			log.Infof(ctx, "%s: failed initial metrics computation: %s", s, err)
@@ -1846,6 +1859,10 @@ func splitPostApply(
 	rightRng.mu.Lock()
 	// Copy the minLeaseProposedTS from the LHS.
 	rightRng.mu.minLeaseProposedTS = r.mu.minLeaseProposedTS
+	// Copy the closed timestamp from the LHS. Readers of the LHS which
+	// haven't applied the split yet rely on it for keys now in the RHS, so
+	// the RHS leaseholder must not accept writes below it either.
+	rightRng.mu.closedTS.closed = r.mu.closedTS.get(r.mu.state.LeaseAppliedIndex)
 	rightLease := *rightRng.mu.state.Lease
 	rightRng.mu.Unlock()
 	r.mu.Unlock()
@@ -1939,6 +1956,7 @@ func (s *Store) SplitRange(ctx context.Context, origRng, newRng *Replica) error
 	// spans that are now owned by the new range.
 	origRng.leaseholderStats.resetRequestCounts()
 	origRng.writeStats.splitRequestCounts(newRng.writeStats)
+	origRng.resetLoadBasedSplitter()
 
 	if kr := s.mu.replicasByKey.ReplaceOrInsert(origRng); kr != nil {
 		return errors.Errorf("replicasByKey unexpectedly contains %s when inserting replica %s", kr, origRng)
@@ -2036,9 +2054,9 @@ func (s *Store) maybeMergeTimestampCaches(
 	defer subsumedRep.mu.Unlock()
 	subsumedLease := *subsumedRep.mu.state.Lease
 
-	// Merge support is currently incomplete and incorrect. In particular, the
-	// lease holders must be colocated and the subsumed range appropriately
-	// quiesced. See also #2433.
+	// AdminMerge makes sure that the lease holders are colocated and freezes
+	// the subsumed range before committing the merge, which also prevents its
+	// lease from being transferred. See also #2433.
 	now := s.Clock().Now()
 	if subsumedRep.isLeaseValidRLocked(subsumedLease, now) &&
 		subsumingLease.Replica.StoreID != subsumedLease.Replica.StoreID {
@@ -2298,12 +2316,18 @@ func (s *Store) Capacity() (roachpb.StoreCapacity, error) {
 	now := s.cfg.Clock.Now()
 	var leaseCount int32
 	var logicalBytes int64
+	var totalQueriesPerSecond float64
 	var totalWritesPerSecond float64
 	bytesPerReplica := make([]float64, 0, capacity.RangeCount)
 	writesPerReplica := make([]float64, 0, capacity.RangeCount)
 	newStoreReplicaVisitor(s).Visit(func(r *Replica) bool {
 		if r.OwnsValidLease(now) {
 			leaseCount++
+			if r.leaseholderStats != nil {
+				if qps, dur := r.leaseholderStats.avgQPS(); dur >= MinStatsDuration {
+					totalQueriesPerSecond += qps
+				}
+			}
 		}
 		mvccStats := r.GetMVCCStats()
 		logicalBytes += mvccStats.Total()
@@ -2319,6 +2343,7 @@ func (s *Store) Capacity() (roachpb.StoreCapacity, error) {
 	})
 	capacity.LeaseCount = leaseCount
 	capacity.LogicalBytes = logicalBytes
+	capacity.QueriesPerSecond = totalQueriesPerSecond
 	capacity.WritesPerSecond = totalWritesPerSecond
 	capacity.BytesPerReplica = roachpb.PercentilesFromData(bytesPerReplica)
 	capacity.WritesPerReplica = roachpb.PercentilesFromData(writesPerReplica)
@@ -2403,6 +2428,19 @@ func (s *Store) deadReplicas() roachpb.StoreDeadReplicas {
 	}
 }
 
+// RangeFeed registers a rangefeed on the replica addressed by the request and
+// streams its events until the stream's context is canceled or an error
+// occurs.
+func (s *Store) RangeFeed(
+	args *roachpb.RangeFeedRequest, stream roachpb.Internal_RangeFeedServer,
+) *roachpb.Error {
+	repl, err := s.GetReplica(args.RangeID)
+	if err != nil {
+		return roachpb.NewError(err)
+	}
+	return repl.RangeFeed(args, stream)
+}
+
 // Send fetches a range based on the header's replica, assembles method, args &
 // reply into a Raft Cmd struct and executes the command using the fetched
 // range.
@@ -2874,6 +2912,12 @@ func (s *Store) uncoalesceBeats(
 			log.Infof(ctx, "uncoalesced beat: %+v", beatReqs[i])
 		}
 
+		if beat.ClosedTimestamp != (hlc.Timestamp{}) {
+			if repl, err := s.GetReplica(beat.RangeID); err == nil {
+				repl.updateClosedTimestamp(beat.ToReplicaID, beat.ClosedTimestamp, beat.LeaseAppliedIndex)
+			}
+		}
+
 		if err := s.HandleRaftUncoalescedRequest(ctx, &beatReqs[i], respStream); err != nil {
 			log.Errorf(ctx, "could not handle uncoalesced heartbeat %s", err)
 		}
@@ -4234,6 +4278,7 @@ func (s *Store) AllocatorDryRun(
 	if err != nil {
 		log.Eventf(ctx, "error simulating allocator on replica %s: %s", repl, err)
 	}
+	s.storeRebalancer.dryRun(ctx, repl, sysCfg)
 	return collect(), nil
 }
 
@@ -4266,6 +4311,9 @@ func (s *Store) setReplicateQueueActive(active bool) {
 func (s *Store) setSplitQueueActive(active bool) {
 	s.splitQueue.SetDisabled(!active)
 }
+func (s *Store) setMergeQueueActive(active bool) {
+	s.mergeQueue.SetDisabled(!active)
+}
 func (s *Store) setTimeSeriesMaintenanceQueueActive(active bool) {
 	s.tsMaintenanceQueue.SetDisabled(!active)
 }
@@ -0,0 +1,503 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/config"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+)
+
+const (
+	// storeRebalancerTimerDuration is the duration between passes of the store
+	// rebalancer over the local store's replicas.
+	storeRebalancerTimerDuration = time.Minute
+)
+
+var (
+	metaStoreRebalancerLeaseTransferCount = metric.Metadata{
+		Name: "rebalancing.lease.transfers",
+		Help: "Number of lease transfers motivated by store-level load imbalances"}
+	metaStoreRebalancerRangeRebalanceCount = metric.Metadata{
+		Name: "rebalancing.range.rebalances",
+		Help: "Number of range rebalance operations motivated by store-level load imbalances"}
+)
+
+// qpsBasedRebalancingEnabled controls whether the store rebalancer moves
+// leases and replicas off of stores which serve more queries per second than
+// the other stores in the cluster.
+var qpsBasedRebalancingEnabled = settings.RegisterBoolSetting(
+	"kv.allocator.qps_based_rebalancing.enabled",
+	"set to enable rebalancing of range replicas and leases based on the queries per second served by each store",
+	false,
+)
+
+// qpsRebalanceThreshold is the same as statRebalanceThreshold, but for the
+// queries per second served by a store.
+var qpsRebalanceThreshold = settings.RegisterNonNegativeFloatSetting(
+	"kv.allocator.qps_rebalance_threshold",
+	"minimum fraction away from the mean a store's QPS can be before it is considered overfull or underfull",
+	0.25,
+)
+
+func overfullQPSThreshold(st *cluster.Settings, mean float64) float64 {
+	return mean * (1 + qpsRebalanceThreshold.Get(&st.SV))
+}
+
+// StoreRebalancerMetrics is the set of metrics for the store rebalancer.
+type StoreRebalancerMetrics struct {
+	LeaseTransferCount  *metric.Counter
+	RangeRebalanceCount *metric.Counter
+}
+
+func makeStoreRebalancerMetrics() StoreRebalancerMetrics {
+	return StoreRebalancerMetrics{
+		LeaseTransferCount:  metric.NewCounter(metaStoreRebalancerLeaseTransferCount),
+		RangeRebalanceCount: metric.NewCounter(metaStoreRebalancerRangeRebalanceCount),
+	}
+}
+
+// StoreRebalancer periodically checks whether the local store serves
+// considerably more queries per second than the average store in the
+// cluster. If it does, it moves the leases and, failing that, the replicas of
+// its hottest ranges to stores which serve fewer queries.
+//
+// Unlike the replicate queue, which looks at one range at a time and can
+// therefore only balance lease and replica counts, the store rebalancer looks
+// at the load of the store as a whole, so a small number of hot ranges can't
+// concentrate on a single store.
+type StoreRebalancer struct {
+	metrics StoreRebalancerMetrics
+	st      *cluster.Settings
+	store   *Store
+	rq      *replicateQueue
+}
+
+// newStoreRebalancer returns a new instance of StoreRebalancer.
+func newStoreRebalancer(store *Store, rq *replicateQueue) *StoreRebalancer {
+	sr := &StoreRebalancer{
+		metrics: makeStoreRebalancerMetrics(),
+		st:      store.ClusterSettings(),
+		store:   store,
+		rq:      rq,
+	}
+	store.metrics.registry.AddMetricStruct(&sr.metrics)
+	return sr
+}
+
+// Start runs an infinite loop in a goroutine which periodically checks whether
+// the store is overloaded and rebalances its load if it is.
+func (sr *StoreRebalancer) Start(ctx context.Context, stopper *stop.Stopper) {
+	ctx = log.WithLogTag(sr.store.AnnotateCtx(ctx), "store-rebalancer", nil)
+	stopper.RunWorker(ctx, func(ctx context.Context) {
+		ticker := time.NewTicker(storeRebalancerTimerDuration)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-stopper.ShouldStop():
+				return
+			}
+			if !qpsBasedRebalancingEnabled.Get(&sr.st.SV) {
+				continue
+			}
+			sysCfg, ok := sr.store.cfg.Gossip.GetSystemConfig()
+			if !ok {
+				log.VEventf(ctx, 1, "no system config available, skipping rebalancing")
+				continue
+			}
+			sr.rebalanceStore(ctx, sysCfg)
+		}
+	})
+}
+
+// replicaWithStats is a replica along with the queries per second it serves
+// as the leaseholder of its range.
+type replicaWithStats struct {
+	repl *Replica
+	qps  float64
+}
+
+// rebalanceState is the view of the cluster's load on which the store
+// rebalancer bases its decisions. The capacities in storeMap are adjusted as
+// leases and replicas are moved.
+type rebalanceState struct {
+	storeMap map[roachpb.StoreID]*roachpb.StoreDescriptor
+	meanQPS  float64
+	maxQPS   float64
+}
+
+// makeRebalanceState builds a rebalanceState from the store pool's view of the
+// cluster. It returns false if the local store isn't part of that view.
+func (sr *StoreRebalancer) makeRebalanceState(ctx context.Context) (rebalanceState, bool) {
+	sl, _, _ := sr.rq.allocator.storePool.getStoreList(roachpb.RangeID(0), storeFilterNone)
+	state := rebalanceState{
+		storeMap: make(map[roachpb.StoreID]*roachpb.StoreDescriptor, len(sl.stores)),
+		meanQPS:  sl.candidateQueriesPerSecond.mean,
+		maxQPS:   overfullQPSThreshold(sr.st, sl.candidateQueriesPerSecond.mean),
+	}
+	for i := range sl.stores {
+		state.storeMap[sl.stores[i].StoreID] = &sl.stores[i]
+	}
+	if _, ok := state.storeMap[sr.store.StoreID()]; !ok {
+		log.VEventf(ctx, 1, "local store s%d missing from store pool", sr.store.StoreID())
+		return rebalanceState{}, false
+	}
+	return state, true
+}
+
+// overfull returns whether the local store serves more queries per second
+// than the threshold, logging its decision.
+func (sr *StoreRebalancer) overfull(ctx context.Context, state rebalanceState) bool {
+	localQPS := state.storeMap[sr.store.StoreID()].Capacity.QueriesPerSecond
+	if localQPS <= state.maxQPS {
+		log.VEventf(ctx, 1, "local QPS %.2f is below max threshold %.2f (mean=%.2f); no rebalancing needed",
+			localQPS, state.maxQPS, state.meanQPS)
+		return false
+	}
+	log.VEventf(ctx, 1, "local QPS %.2f is above max threshold %.2f (mean=%.2f); considering load-based rebalancing",
+		localQPS, state.maxQPS, state.meanQPS)
+	return true
+}
+
+// rebalanceStore moves leases and replicas of the local store's hottest
+// ranges to other stores until the local store is no longer overfull or it
+// runs out of ranges which can be moved.
+func (sr *StoreRebalancer) rebalanceStore(ctx context.Context, sysCfg config.SystemConfig) {
+	state, ok := sr.makeRebalanceState(ctx)
+	if !ok || !sr.overfull(ctx, state) {
+		return
+	}
+	localDesc := state.storeMap[sr.store.StoreID()]
+	for _, rs := range sr.hottestReplicas() {
+		if localDesc.Capacity.QueriesPerSecond <= state.maxQPS {
+			break
+		}
+		if err := sr.rebalanceReplica(ctx, sysCfg, rs, state, false /* dryRun */); err != nil {
+			log.Warning(ctx, err)
+		}
+	}
+	if localDesc.Capacity.QueriesPerSecond > state.maxQPS {
+		log.Infof(ctx, "ran out of ranges to rebalance; local QPS %.2f is still above max threshold %.2f",
+			localDesc.Capacity.QueriesPerSecond, state.maxQPS)
+	}
+}
+
+// hottestReplicas returns the replicas for which the local store holds the
+// lease, ordered from the most to the least queries per second served.
+func (sr *StoreRebalancer) hottestReplicas() []replicaWithStats {
+	now := sr.store.Clock().Now()
+	var replicas []replicaWithStats
+	newStoreReplicaVisitor(sr.store).Visit(func(repl *Replica) bool {
+		if repl.leaseholderStats == nil || !repl.OwnsValidLease(now) {
+			return true
+		}
+		if qps, dur := repl.leaseholderStats.avgQPS(); dur >= MinStatsDuration {
+			replicas = append(replicas, replicaWithStats{repl: repl, qps: qps})
+		}
+		return true
+	})
+	sort.Slice(replicas, func(i, j int) bool {
+		return replicas[i].qps > replicas[j].qps
+	})
+	return replicas
+}
+
+// rebalanceReplica moves the load of the given replica off of the local
+// store, preferably by transferring its lease to another of its range's
+// replicas, and otherwise by moving the replica to another store. The
+// capacities in state are updated to reflect the change.
+func (sr *StoreRebalancer) rebalanceReplica(
+	ctx context.Context, sysCfg config.SystemConfig, rs replicaWithStats, state rebalanceState, dryRun bool,
+) error {
+	repl := rs.repl
+	desc := repl.Desc()
+	zone, err := sysCfg.GetZoneConfigForKey(desc.StartKey)
+	if err != nil {
+		return err
+	}
+	localDesc := state.storeMap[sr.store.StoreID()]
+
+	candidates := filterBehindReplicas(repl.RaftStatus(), desc.Replicas, 0 /* brandNewReplicaID */)
+	if preferred := sr.rq.allocator.preferredLeaseholders(zone, candidates); len(preferred) > 0 {
+		candidates = preferred
+	}
+	if target, ok := chooseLeaseTarget(
+		candidates, zone.Constraints, localDesc.StoreID, rs.qps, state.maxQPS, state.storeMap,
+	); ok {
+		targetDesc := state.storeMap[target.StoreID]
+		log.VEventf(ctx, 1, "transferring lease for r%d (qps=%.2f) to s%d (qps=%.2f)",
+			desc.RangeID, rs.qps, target.StoreID, targetDesc.Capacity.QueriesPerSecond)
+		if !dryRun {
+			if err := repl.AdminTransferLease(ctx, target.StoreID); err != nil {
+				return errors.Wrapf(err, "%s: unable to transfer lease to s%d", repl, target.StoreID)
+			}
+			sr.metrics.LeaseTransferCount.Inc(1)
+		}
+		moveQPS(localDesc, targetDesc, rs.qps)
+		return nil
+	}
+
+	// The per-replica constraints are analyzed for the replicas which aren't
+	// moved.
+	var remaining []roachpb.ReplicaDescriptor
+	for _, r := range desc.Replicas {
+		if r.StoreID != localDesc.StoreID {
+			remaining = append(remaining, r)
+		}
+	}
+	getStoreDesc := func(storeID roachpb.StoreID) (roachpb.StoreDescriptor, bool) {
+		if storeDesc, ok := state.storeMap[storeID]; ok {
+			return *storeDesc, true
+		}
+		return roachpb.StoreDescriptor{}, false
+	}
+	replicaConstraints := analyzeConstraints(getStoreDesc, remaining, zone)
+
+	var targetDesc roachpb.StoreDescriptor
+	var found bool
+	for _, constraints := range relocationConstraints(zone, desc.Replicas, localDesc.StoreID, state.storeMap) {
+		targetDesc, found = chooseReplicaTarget(
+			desc, constraints, replicaConstraints, *localDesc, rs.qps, state.meanQPS, state.maxQPS,
+			state.storeMap)
+		if found {
+			break
+		}
+	}
+	if found {
+		log.VEventf(ctx, 1, "moving r%d (qps=%.2f) from s%d to s%d (qps=%.2f)",
+			desc.RangeID, rs.qps, localDesc.StoreID, targetDesc.StoreID, targetDesc.Capacity.QueriesPerSecond)
+		if !dryRun {
+			// The new replica is listed first so that it receives the lease.
+			targets := []roachpb.ReplicationTarget{{
+				NodeID:  targetDesc.Node.NodeID,
+				StoreID: targetDesc.StoreID,
+			}}
+			for _, r := range remaining {
+				targets = append(targets, roachpb.ReplicationTarget{NodeID: r.NodeID, StoreID: r.StoreID})
+			}
+			if err := RelocateRange(ctx, sr.store.DB(), *desc, targets); err != nil {
+				return errors.Wrapf(err, "%s: unable to relocate range to s%d", repl, targetDesc.StoreID)
+			}
+			sr.metrics.RangeRebalanceCount.Inc(1)
+			storePool := sr.rq.allocator.storePool
+			storePool.updateLocalStoreAfterRebalance(targetDesc.StoreID, repl, roachpb.ADD_REPLICA)
+			storePool.updateLocalStoreAfterRebalance(localDesc.StoreID, repl, roachpb.REMOVE_REPLICA)
+		}
+		moveQPS(localDesc, state.storeMap[targetDesc.StoreID], rs.qps)
+		return nil
+	}
+
+	log.VEventf(ctx, 1, "no lease or replica target found for r%d (qps=%.2f)", desc.RangeID, rs.qps)
+	return nil
+}
+
+// dryRun logs the decision the store rebalancer would make for the given
+// replica without carrying it out. It is used by allocator dry runs.
+func (sr *StoreRebalancer) dryRun(ctx context.Context, repl *Replica, sysCfg config.SystemConfig) {
+	if !qpsBasedRebalancingEnabled.Get(&sr.st.SV) {
+		log.VEventf(ctx, 1, "QPS-based rebalancing is disabled")
+		return
+	}
+	state, ok := sr.makeRebalanceState(ctx)
+	if !ok || !sr.overfull(ctx, state) {
+		return
+	}
+	if repl.leaseholderStats == nil || !repl.OwnsValidLease(sr.store.Clock().Now()) {
+		log.VEventf(ctx, 1, "local store doesn't hold the lease for r%d", repl.RangeID)
+		return
+	}
+	qps, dur := repl.leaseholderStats.avgQPS()
+	if dur < MinStatsDuration {
+		log.VEventf(ctx, 1, "not enough QPS stats for r%d (%s < %s)", repl.RangeID, dur, MinStatsDuration)
+		return
+	}
+	rs := replicaWithStats{repl: repl, qps: qps}
+	if err := sr.rebalanceReplica(ctx, sysCfg, rs, state, true /* dryRun */); err != nil {
+		log.VEventf(ctx, 1, "error simulating store rebalancer on replica %s: %s", repl, err)
+	}
+}
+
+// chooseLeaseTarget returns the replica with the lowest QPS among the given
+// candidates which satisfies the constraints and which can take on the given
+// QPS without becoming overfull.
+func chooseLeaseTarget(
+	candidates []roachpb.ReplicaDescriptor,
+	constraints config.Constraints,
+	localStoreID roachpb.StoreID,
+	qps float64,
+	maxQPS float64,
+	storeMap map[roachpb.StoreID]*roachpb.StoreDescriptor,
+) (roachpb.ReplicaDescriptor, bool) {
+	var target roachpb.ReplicaDescriptor
+	var targetDesc *roachpb.StoreDescriptor
+	for _, candidate := range candidates {
+		if candidate.StoreID == localStoreID {
+			continue
+		}
+		storeDesc, ok := storeMap[candidate.StoreID]
+		if !ok {
+			continue
+		}
+		if ok, _ := constraintCheck(*storeDesc, constraints); !ok {
+			continue
+		}
+		if storeDesc.Capacity.QueriesPerSecond+qps > maxQPS {
+			continue
+		}
+		if targetDesc == nil || lessLoaded(storeDesc, targetDesc) {
+			target, targetDesc = candidate, storeDesc
+		}
+	}
+	return target, targetDesc != nil
+}
+
+// relocationConstraints returns the constraints that the target of a
+// replica relocation must satisfy, in the order in which they should be
+// tried. The new replica receives the range's lease, so if the zone has lease
+// preferences, the target must satisfy one of them as well as the zone's
+// constraints. Only the preferences up to the first one satisfied by a
+// replica which isn't moved are returned, since the lease would otherwise be
+// transferred back to that replica. If no store satisfies any preference,
+// the preferences are ignored.
+func relocationConstraints(
+	zone config.ZoneConfig,
+	replicas []roachpb.ReplicaDescriptor,
+	localStoreID roachpb.StoreID,
+	storeMap map[roachpb.StoreID]*roachpb.StoreDescriptor,
+) []config.Constraints {
+	if len(zone.LeasePreferences) == 0 {
+		return []config.Constraints{zone.Constraints}
+	}
+	satisfiedBy := func(storeDesc *roachpb.StoreDescriptor, preference config.Constraints) bool {
+		ok, _ := constraintCheck(*storeDesc, preference)
+		return ok
+	}
+	var result []config.Constraints
+	satisfiable := false
+	for _, p := range zone.LeasePreferences {
+		preference := config.Constraints{Constraints: p.Constraints}
+		constraints := zone.Constraints
+		constraints.Constraints = append(
+			append([]config.Constraint(nil), zone.Constraints.Constraints...), p.Constraints...)
+		result = append(result, constraints)
+		for _, r := range replicas {
+			if storeDesc, ok := storeMap[r.StoreID]; ok && r.StoreID != localStoreID &&
+				satisfiedBy(storeDesc, preference) {
+				return result
+			}
+		}
+		for _, storeDesc := range storeMap {
+			if satisfiedBy(storeDesc, preference) {
+				satisfiable = true
+			}
+		}
+	}
+	if !satisfiable {
+		return []config.Constraints{zone.Constraints}
+	}
+	return result
+}
+
+// chooseReplicaTarget returns the store with the lowest QPS among the stores
+// which don't yet have a replica of the given range, which satisfy the
+// constraints, which can replace the replica on the removed store given the
+// per-replica constraints, which serve fewer than the mean QPS and which can
+// take on the given QPS without becoming overfull. The per-replica
+// constraints must be analyzed for the replicas other than the removed one.
+func chooseReplicaTarget(
+	desc *roachpb.RangeDescriptor,
+	constraints config.Constraints,
+	replicaConstraints analyzedConstraints,
+	removed roachpb.StoreDescriptor,
+	qps float64,
+	meanQPS float64,
+	maxQPS float64,
+	storeMap map[roachpb.StoreID]*roachpb.StoreDescriptor,
+) (roachpb.StoreDescriptor, bool) {
+	var targetDesc *roachpb.StoreDescriptor
+	for _, storeDesc := range storeMap {
+		if !preexistingReplicaCheck(storeDesc.Node.NodeID, desc.Replicas) {
+			continue
+		}
+		if ok, _ := constraintCheck(*storeDesc, constraints); !ok || !maxCapacityCheck(*storeDesc) {
+			continue
+		}
+		if !replaceConstraintsCheck(*storeDesc, removed, replicaConstraints) {
+			continue
+		}
+		if storeDesc.Capacity.QueriesPerSecond >= meanQPS ||
+			storeDesc.Capacity.QueriesPerSecond+qps > maxQPS {
+			continue
+		}
+		if targetDesc == nil || lessLoaded(storeDesc, targetDesc) {
+			targetDesc = storeDesc
+		}
+	}
+	if targetDesc == nil {
+		return roachpb.StoreDescriptor{}, false
+	}
+	return *targetDesc, true
+}
+
+// replaceConstraintsCheck returns whether the store may receive a replica in
+// place of the one on the removed store given the zone's per-replica
+// constraints, analyzed for the other replicas. The store must satisfy each
+// of the per-replica constraints which the removed store satisfies and which
+// the other replicas satisfy too few times.
+func replaceConstraintsCheck(
+	store, removed roachpb.StoreDescriptor, constraints analyzedConstraints,
+) bool {
+	if valid, _ := allocateConstraintsCheck(store, constraints); !valid {
+		return false
+	}
+	for i, replicaConstraints := range constraints.replicaConstraints {
+		if len(constraints.satisfiedBy[i]) >= int(replicaConstraints.NumReplicas) {
+			continue
+		}
+		if ok, _ := constraintCheck(removed, replicaConstraints); !ok {
+			continue
+		}
+		if ok, _ := constraintCheck(store, replicaConstraints); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// lessLoaded returns whether store a serves fewer queries per second than
+// store b, breaking ties by store ID.
+func lessLoaded(a, b *roachpb.StoreDescriptor) bool {
+	if a.Capacity.QueriesPerSecond != b.Capacity.QueriesPerSecond {
+		return a.Capacity.QueriesPerSecond < b.Capacity.QueriesPerSecond
+	}
+	return a.StoreID < b.StoreID
+}
+
+// moveQPS moves the given QPS from one store's capacity to another's.
+func moveQPS(from, to *roachpb.StoreDescriptor, qps float64) {
+	from.Capacity.QueriesPerSecond -= qps
+	to.Capacity.QueriesPerSecond += qps
+}
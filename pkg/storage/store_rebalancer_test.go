@@ -0,0 +1,270 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/config"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+// storeRebalancerTestStoreMap returns a map of five stores on separate nodes
+// whose QPS are given by qps. Store 5 has the attribute "ssd".
+func storeRebalancerTestStoreMap(qps [5]float64) map[roachpb.StoreID]*roachpb.StoreDescriptor {
+	storeMap := make(map[roachpb.StoreID]*roachpb.StoreDescriptor)
+	for i, q := range qps {
+		desc := &roachpb.StoreDescriptor{
+			StoreID: roachpb.StoreID(i + 1),
+			Node:    roachpb.NodeDescriptor{NodeID: roachpb.NodeID(i + 1)},
+			Capacity: roachpb.StoreCapacity{
+				Capacity:         200,
+				Available:        100,
+				LogicalBytes:     100,
+				QueriesPerSecond: q,
+			},
+		}
+		if i == 4 {
+			desc.Attrs = roachpb.Attributes{Attrs: []string{"ssd"}}
+		}
+		storeMap[desc.StoreID] = desc
+	}
+	return storeMap
+}
+
+func TestChooseLeaseTarget(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	replicas := []roachpb.ReplicaDescriptor{
+		{NodeID: 1, StoreID: 1, ReplicaID: 1},
+		{NodeID: 2, StoreID: 2, ReplicaID: 2},
+		{NodeID: 3, StoreID: 3, ReplicaID: 3},
+	}
+	ssd := config.Constraints{Constraints: []config.Constraint{
+		{Type: config.Constraint_REQUIRED, Value: "ssd"},
+	}}
+
+	testCases := []struct {
+		name        string
+		qps         [5]float64
+		replicaQPS  float64
+		constraints config.Constraints
+		expTarget   roachpb.StoreID
+	}{
+		{
+			name:       "least loaded replica",
+			qps:        [5]float64{3000, 1000, 500, 0, 0},
+			replicaQPS: 100,
+			expTarget:  3,
+		},
+		{
+			name:       "target would become overfull",
+			qps:        [5]float64{3000, 1000, 1200, 0, 0},
+			replicaQPS: 300,
+			expTarget:  2,
+		},
+		{
+			name:       "all targets would become overfull",
+			qps:        [5]float64{3000, 1000, 1200, 0, 0},
+			replicaQPS: 2000,
+			expTarget:  0,
+		},
+		{
+			name:        "constraints",
+			qps:         [5]float64{3000, 1000, 500, 0, 0},
+			replicaQPS:  100,
+			constraints: ssd,
+			expTarget:   0,
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			storeMap := storeRebalancerTestStoreMap(c.qps)
+			target, ok := chooseLeaseTarget(
+				replicas, c.constraints, 1 /* localStoreID */, c.replicaQPS, 1500 /* maxQPS */, storeMap)
+			if ok != (c.expTarget != 0) {
+				t.Fatalf("expected target s%d, got %v (ok=%t)", c.expTarget, target, ok)
+			}
+			if ok && target.StoreID != c.expTarget {
+				t.Fatalf("expected target s%d, got s%d", c.expTarget, target.StoreID)
+			}
+		})
+	}
+}
+
+func TestChooseReplicaTarget(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	desc := &roachpb.RangeDescriptor{
+		RangeID: 1,
+		Replicas: []roachpb.ReplicaDescriptor{
+			{NodeID: 1, StoreID: 1, ReplicaID: 1},
+			{NodeID: 2, StoreID: 2, ReplicaID: 2},
+			{NodeID: 3, StoreID: 3, ReplicaID: 3},
+		},
+	}
+	ssd := config.Constraints{Constraints: []config.Constraint{
+		{Type: config.Constraint_REQUIRED, Value: "ssd"},
+	}}
+
+	testCases := []struct {
+		name               string
+		qps                [5]float64
+		replicaQPS         float64
+		constraints        config.Constraints
+		replicaConstraints []config.Constraints
+		expTarget          roachpb.StoreID
+	}{
+		{
+			name:       "least loaded store without a replica",
+			qps:        [5]float64{3000, 0, 0, 400, 200},
+			replicaQPS: 100,
+			expTarget:  5,
+		},
+		{
+			name:        "constraints",
+			qps:         [5]float64{3000, 0, 0, 200, 400},
+			replicaQPS:  100,
+			constraints: ssd,
+			expTarget:   5,
+		},
+		{
+			name:       "per-replica constraints satisfied by the removed replica",
+			qps:        [5]float64{3000, 0, 0, 400, 200},
+			replicaQPS: 100,
+			replicaConstraints: []config.Constraints{{
+				Constraints: []config.Constraint{{Type: config.Constraint_PROHIBITED, Value: "ssd"}},
+				NumReplicas: 3,
+			}},
+			expTarget: 4,
+		},
+		{
+			name:       "stores above the mean",
+			qps:        [5]float64{3000, 0, 0, 1000, 1100},
+			replicaQPS: 100,
+			expTarget:  0,
+		},
+		{
+			name:       "target would become overfull",
+			qps:        [5]float64{3000, 0, 0, 400, 200},
+			replicaQPS: 1400,
+			expTarget:  0,
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			storeMap := storeRebalancerTestStoreMap(c.qps)
+			getStoreDesc := func(storeID roachpb.StoreID) (roachpb.StoreDescriptor, bool) {
+				return *storeMap[storeID], true
+			}
+			zone := config.ZoneConfig{NumReplicas: 3, ReplicaConstraints: c.replicaConstraints}
+			replicaConstraints := analyzeConstraints(getStoreDesc, desc.Replicas[1:], zone)
+			target, ok := chooseReplicaTarget(
+				desc, c.constraints, replicaConstraints, *storeMap[1], c.replicaQPS,
+				1000 /* meanQPS */, 1500 /* maxQPS */, storeMap)
+			if ok != (c.expTarget != 0) {
+				t.Fatalf("expected target s%d, got %v (ok=%t)", c.expTarget, target, ok)
+			}
+			if ok && target.StoreID != c.expTarget {
+				t.Fatalf("expected target s%d, got s%d", c.expTarget, target.StoreID)
+			}
+		})
+	}
+}
+
+func TestRelocationConstraints(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ssd := config.Constraint{Type: config.Constraint_REQUIRED, Value: "ssd"}
+	noSSD := config.Constraint{Type: config.Constraint_PROHIBITED, Value: "ssd"}
+	nvme := config.Constraint{Type: config.Constraint_REQUIRED, Value: "nvme"}
+	zoneConstraint := config.Constraint{Type: config.Constraint_REQUIRED, Key: "region", Value: "us"}
+	constraints := func(cs ...config.Constraint) config.Constraints {
+		return config.Constraints{Constraints: cs}
+	}
+
+	testCases := []struct {
+		name     string
+		stores   []roachpb.StoreID
+		zone     config.ZoneConfig
+		expected []config.Constraints
+	}{
+		{
+			name:     "no lease preferences",
+			stores:   []roachpb.StoreID{1, 2, 3},
+			zone:     config.ZoneConfig{Constraints: constraints(zoneConstraint)},
+			expected: []config.Constraints{constraints(zoneConstraint)},
+		},
+		{
+			name:   "lease preference",
+			stores: []roachpb.StoreID{1, 2, 3},
+			zone: config.ZoneConfig{
+				Constraints:      constraints(zoneConstraint),
+				LeasePreferences: []config.LeasePreference{{Constraints: []config.Constraint{ssd}}},
+			},
+			expected: []config.Constraints{constraints(zoneConstraint, ssd)},
+		},
+		{
+			name:   "lease preferences in order",
+			stores: []roachpb.StoreID{1, 2, 3},
+			zone: config.ZoneConfig{
+				LeasePreferences: []config.LeasePreference{
+					{Constraints: []config.Constraint{nvme}},
+					{Constraints: []config.Constraint{ssd}},
+				},
+			},
+			expected: []config.Constraints{constraints(nvme), constraints(ssd)},
+		},
+		{
+			name:   "lease preference satisfied by a remaining replica",
+			stores: []roachpb.StoreID{1, 2, 5},
+			zone: config.ZoneConfig{
+				LeasePreferences: []config.LeasePreference{
+					{Constraints: []config.Constraint{noSSD}},
+					{Constraints: []config.Constraint{ssd}},
+				},
+			},
+			expected: []config.Constraints{constraints(noSSD)},
+		},
+		{
+			name:   "unsatisfiable lease preference",
+			stores: []roachpb.StoreID{1, 2, 3},
+			zone: config.ZoneConfig{
+				LeasePreferences: []config.LeasePreference{{Constraints: []config.Constraint{nvme}}},
+			},
+			expected: []config.Constraints{{}},
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			storeMap := storeRebalancerTestStoreMap([5]float64{})
+			var replicas []roachpb.ReplicaDescriptor
+			for i, storeID := range c.stores {
+				replicas = append(replicas, roachpb.ReplicaDescriptor{
+					NodeID: roachpb.NodeID(storeID), StoreID: storeID, ReplicaID: roachpb.ReplicaID(i + 1),
+				})
+			}
+			result := relocationConstraints(c.zone, replicas, 1 /* localStoreID */, storeMap)
+			if !reflect.DeepEqual(result, c.expected) {
+				t.Fatalf("expected %v, got %v", c.expected, result)
+			}
+		})
+	}
+}
@@ -219,6 +219,18 @@ func (ls *Stores) Send(
 	return br, pErr
 }
 
+// RangeFeed registers a rangefeed on the replica addressed by the request.
+// The store is looked up from the store ID specified in the header.
+func (ls *Stores) RangeFeed(
+	args *roachpb.RangeFeedRequest, stream roachpb.Internal_RangeFeedServer,
+) *roachpb.Error {
+	store, err := ls.GetStore(args.Replica.StoreID)
+	if err != nil {
+		return roachpb.NewError(err)
+	}
+	return store.RangeFeed(args, stream)
+}
+
 // LookupReplica looks up replica by key [range]. Lookups are done
 // by consulting each store in turn via Store.LookupReplica(key).
 // Returns RangeID and replica on success; RangeKeyMismatch error
@@ -20,8 +20,10 @@ import (
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 
+	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
 )
 
 // Server implements ConsistencyServer.
@@ -74,3 +76,35 @@ func (is Server) CollectChecksum(
 		})
 	return resp, err
 }
+
+// WaitForApplication implements ConsistencyServer. It returns once the
+// replica has applied its Raft log up to the requested index, and fails if
+// the context is canceled or the server shuts down first.
+func (is Server) WaitForApplication(
+	ctx context.Context, req *WaitForApplicationRequest,
+) (*WaitForApplicationResponse, error) {
+	resp := &WaitForApplicationResponse{}
+	err := is.execStoreCommand(req.StoreRequestHeader,
+		func(s *Store) error {
+			retryOpts := base.DefaultRetryOptions()
+			retryOpts.Closer = s.Stopper().ShouldQuiesce()
+			for re := retry.StartWithCtx(ctx, retryOpts); re.Next(); {
+				r, err := s.GetReplica(req.RangeID)
+				if err != nil {
+					return err
+				}
+				r.mu.RLock()
+				appliedIndex := r.mu.state.RaftAppliedIndex
+				r.mu.RUnlock()
+				if appliedIndex >= req.AppliedIndex {
+					return nil
+				}
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return errors.Errorf("r%d: server shutting down before applying index %d",
+				req.RangeID, req.AppliedIndex)
+		})
+	return resp, err
+}
@@ -0,0 +1,80 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// recoverStagingTxn decides the outcome of a STAGING transaction whose
+// coordinator may have failed and returns the finalized transaction.
+//
+// The transaction is implicitly committed if all of the writes it sent in
+// parallel with its EndTransaction have succeeded at its timestamp, which is
+// checked by querying their intents. The queries are sent at the
+// transaction's timestamp and update the timestamp cache, so that a write
+// which is found missing can't succeed at that timestamp afterwards; in that
+// case, or if an intent was pushed above the transaction's timestamp, the
+// transaction is aborted.
+func recoverStagingTxn(
+	ctx context.Context, db *client.DB, txn roachpb.Transaction,
+) (roachpb.Transaction, error) {
+	log.Eventf(ctx, "recovering STAGING transaction %s", txn.ID)
+
+	b := &client.Batch{}
+	b.Header.Timestamp = txn.Timestamp
+	for _, w := range txn.InFlightWrites {
+		meta := txn.TxnMeta
+		meta.Sequence = w.Sequence
+		b.AddRawRequest(&roachpb.QueryIntentRequest{
+			Span: roachpb.Span{Key: w.Key},
+			Txn:  meta,
+		})
+	}
+	if err := db.Run(ctx, b); err != nil {
+		return roachpb.Transaction{}, err
+	}
+	implicitlyCommitted := true
+	for _, resp := range b.RawResponse().Responses {
+		qi := resp.GetInner().(*roachpb.QueryIntentResponse)
+		if !qi.FoundIntent || txn.Timestamp.Less(qi.Intent.Txn.Timestamp) {
+			implicitlyCommitted = false
+			break
+		}
+	}
+
+	b = &client.Batch{}
+	b.AddRawRequest(&roachpb.RecoverTxnRequest{
+		Span:                roachpb.Span{Key: txn.Key},
+		Txn:                 txn.TxnMeta,
+		ImplicitlyCommitted: implicitlyCommitted,
+	})
+	if err := db.Run(ctx, b); err != nil {
+		return roachpb.Transaction{}, err
+	}
+	recovered := b.RawResponse().Responses[0].GetInner().(*roachpb.RecoverTxnResponse).RecoveredTxn
+	if !recovered.Status.IsFinalized() {
+		// The transaction was restarted or staged again by its coordinator
+		// since we read its record, so it is still alive.
+		return roachpb.Transaction{}, errors.Errorf("failed to recover %s: %s", txn.ID, recovered)
+	}
+	log.Eventf(ctx, "recovered %s as %s", txn.ID, recovered.Status)
+	return recovered, nil
+}
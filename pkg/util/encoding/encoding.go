@@ -31,6 +31,7 @@ import (
 	"github.com/cockroachdb/apd"
 	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/ipaddr"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 )
@@ -865,6 +866,7 @@ const (
 	// Do not change SentinelType from 15. This value is specifically used for bit
 	// manipulation in EncodeValueTag.
 	SentinelType Type = 15 // Used in the Value encoding.
+	JSON         Type = 16
 )
 
 // PeekType peeks at the type of the value encoded at the start of b.
@@ -1314,6 +1316,20 @@ func EncodeUntaggedIPAddrValue(appendTo []byte, u ipaddr.IPAddr) []byte {
 	return u.ToBuffer(appendTo)
 }
 
+// EncodeJSONValue encodes a JSON value with its value tag, appends it to the
+// supplied buffer, and returns the final buffer.
+func EncodeJSONValue(appendTo []byte, colID uint32, j json.JSON) []byte {
+	appendTo = EncodeValueTag(appendTo, colID, JSON)
+	return EncodeUntaggedJSONValue(appendTo, j)
+}
+
+// EncodeUntaggedJSONValue encodes a JSON value, appends it to the supplied
+// buffer, and returns the final buffer. The value is stored in its canonical
+// textual representation.
+func EncodeUntaggedJSONValue(appendTo []byte, j json.JSON) []byte {
+	return EncodeUntaggedBytesValue(appendTo, []byte(j.String()))
+}
+
 // DecodeValueTag decodes a value encoded by EncodeValueTag, used as a prefix in
 // each of the other EncodeFooValue methods.
 //
@@ -1538,6 +1554,25 @@ func DecodeUntaggedIPAddrValue(b []byte) (remaining []byte, u ipaddr.IPAddr, err
 	return remaining, u, err
 }
 
+// DecodeJSONValue decodes a value encoded by EncodeJSONValue.
+func DecodeJSONValue(b []byte) (remaining []byte, j json.JSON, err error) {
+	b, err = decodeValueTypeAssert(b, JSON)
+	if err != nil {
+		return b, nil, err
+	}
+	return DecodeUntaggedJSONValue(b)
+}
+
+// DecodeUntaggedJSONValue decodes a value encoded by EncodeUntaggedJSONValue.
+func DecodeUntaggedJSONValue(b []byte) (remaining []byte, j json.JSON, err error) {
+	remaining, data, err := DecodeUntaggedBytesValue(b)
+	if err != nil {
+		return b, nil, err
+	}
+	j, err = json.ParseJSON(string(data))
+	return remaining, j, err
+}
+
 func decodeValueTypeAssert(b []byte, expected Type) ([]byte, error) {
 	_, dataOffset, _, typ, err := DecodeValueTag(b)
 	if err != nil {
@@ -1581,7 +1616,7 @@ func PeekValueLength(b []byte) (typeOffset int, length int, err error) {
 		return typeOffset, dataOffset + n, err
 	case Float:
 		return typeOffset, dataOffset + floatValueEncodedLength, nil
-	case Bytes, Array:
+	case Bytes, Array, JSON:
 		_, n, i, err := DecodeNonsortingUvarint(b)
 		return typeOffset, dataOffset + n + int(i), err
 	case Decimal:
@@ -1718,6 +1753,13 @@ func PrettyPrintValueEncoded(b []byte) ([]byte, string, error) {
 			return b, "", err
 		}
 		return b, ipAddr.String(), nil
+	case JSON:
+		var j json.JSON
+		b, j, err = DecodeJSONValue(b)
+		if err != nil {
+			return b, "", err
+		}
+		return b, j.String(), nil
 	default:
 		return b, "", errors.Errorf("unknown type %s", typ)
 	}
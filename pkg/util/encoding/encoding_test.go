@@ -27,6 +27,7 @@ import (
 	"github.com/cockroachdb/apd"
 	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/ipaddr"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
 	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
@@ -1803,6 +1804,11 @@ func TestPrettyPrintValueEncoded(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Bad test case. Attempted ipaddr.ParseINet(%q) got err: %d", ip, err)
 	}
+	jsonStr := `{"a": [1, "b", null]}`
+	j, err := json.ParseJSON(jsonStr)
+	if err != nil {
+		t.Fatalf("Bad test case. Attempted json.ParseJSON(%q) got err: %d", jsonStr, err)
+	}
 	tests := []struct {
 		buf      []byte
 		expected string
@@ -1821,6 +1827,7 @@ func TestPrettyPrintValueEncoded(t *testing.T) {
 		{EncodeBytesValue(nil, NoColumnID, []byte("foo")), "foo"},
 		{EncodeIPAddrValue(nil, NoColumnID, ipAddr), ip},
 		{EncodeUUIDValue(nil, NoColumnID, u), uuidStr},
+		{EncodeJSONValue(nil, NoColumnID, j), jsonStr},
 	}
 	for i, test := range tests {
 		remaining, str, err := PrettyPrintValueEncoded(test.buf)
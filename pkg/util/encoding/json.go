@@ -0,0 +1,260 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package encoding
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+)
+
+// JSON values are key encoded in two steps. The value is first serialized
+// into a self-delimiting byte string whose bytewise order matches the order
+// defined by json.JSON.Compare; that byte string is then encoded with
+// EncodeBytes{Ascending,Descending}, which makes the encoding usable in keys
+// and lets PeekLength skip over it.
+//
+// The serialization of a value starts with a tag identifying its type. The
+// tags are assigned in the sort order of the types. Strings and numbers
+// follow with their ascending key encoding, arrays and objects with their
+// length followed by their elements (or key/value pairs, in key order).
+const (
+	jsonNullTag byte = iota + 1
+	jsonStringTag
+	jsonNumberTag
+	jsonFalseTag
+	jsonTrueTag
+	jsonArrayTag
+	jsonObjectTag
+)
+
+// EncodeJSONAscending encodes a JSON value. The encoded bytes are appended to
+// the supplied buffer and the final buffer is returned.
+func EncodeJSONAscending(b []byte, j json.JSON) []byte {
+	return EncodeBytesAscending(b, encodeJSONKey(nil, j))
+}
+
+// EncodeJSONDescending is the descending version of EncodeJSONAscending.
+func EncodeJSONDescending(b []byte, j json.JSON) []byte {
+	return EncodeBytesDescending(b, encodeJSONKey(nil, j))
+}
+
+// DecodeJSONAscending decodes a JSON value encoded with EncodeJSONAscending.
+func DecodeJSONAscending(b []byte) ([]byte, json.JSON, error) {
+	b, data, err := DecodeBytesAscending(b, nil)
+	if err != nil {
+		return b, nil, err
+	}
+	j, err := decodeJSONKeyBytes(data)
+	return b, j, err
+}
+
+// DecodeJSONDescending decodes a JSON value encoded with EncodeJSONDescending.
+func DecodeJSONDescending(b []byte) ([]byte, json.JSON, error) {
+	b, data, err := DecodeBytesDescending(b, nil)
+	if err != nil {
+		return b, nil, err
+	}
+	j, err := decodeJSONKeyBytes(data)
+	return b, j, err
+}
+
+func encodeJSONKey(b []byte, j json.JSON) []byte {
+	switch j.Type() {
+	case json.NullJSONType:
+		return append(b, jsonNullTag)
+	case json.FalseJSONType:
+		return append(b, jsonFalseTag)
+	case json.TrueJSONType:
+		return append(b, jsonTrueTag)
+	case json.StringJSONType:
+		s, _ := json.AsString(j)
+		return EncodeStringAscending(append(b, jsonStringTag), s)
+	case json.NumberJSONType:
+		d, _ := json.AsDecimal(j)
+		return EncodeDecimalAscending(append(b, jsonNumberTag), d)
+	case json.ArrayJSONType:
+		elems, _ := json.AsArray(j)
+		b = EncodeUvarintAscending(append(b, jsonArrayTag), uint64(len(elems)))
+		for _, e := range elems {
+			b = encodeJSONKey(b, e)
+		}
+		return b
+	case json.ObjectJSONType:
+		keys, _ := json.ObjectKeys(j)
+		b = EncodeUvarintAscending(append(b, jsonObjectTag), uint64(len(keys)))
+		for _, k := range keys {
+			b = EncodeStringAscending(b, k)
+			b = encodeJSONKey(b, j.FetchValKey(k))
+		}
+		return b
+	default:
+		panic(errors.Errorf("unknown JSON type %s", j.Type()))
+	}
+}
+
+func decodeJSONKeyBytes(b []byte) (json.JSON, error) {
+	b, j, err := decodeJSONKey(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != 0 {
+		return nil, errors.Errorf("%d trailing bytes in encoded JSON value", len(b))
+	}
+	return j, nil
+}
+
+func decodeJSONKey(b []byte) ([]byte, json.JSON, error) {
+	if len(b) == 0 {
+		return nil, nil, errors.Errorf("insufficient bytes to decode JSON value")
+	}
+	tag := b[0]
+	b = b[1:]
+	switch tag {
+	case jsonNullTag:
+		return b, json.NullJSONValue, nil
+	case jsonFalseTag:
+		return b, json.FalseJSONValue, nil
+	case jsonTrueTag:
+		return b, json.TrueJSONValue, nil
+	case jsonStringTag:
+		b, s, err := DecodeBytesAscending(b, nil)
+		if err != nil {
+			return b, nil, err
+		}
+		return b, json.FromString(string(s)), nil
+	case jsonNumberTag:
+		b, d, err := DecodeDecimalAscending(b, nil)
+		if err != nil {
+			return b, nil, err
+		}
+		return b, json.FromDecimal(d), nil
+	case jsonArrayTag:
+		b, n, err := DecodeUvarintAscending(b)
+		if err != nil {
+			return b, nil, err
+		}
+		elems := make([]json.JSON, n)
+		for i := range elems {
+			if b, elems[i], err = decodeJSONKey(b); err != nil {
+				return b, nil, err
+			}
+		}
+		return b, json.FromArray(elems), nil
+	case jsonObjectTag:
+		b, n, err := DecodeUvarintAscending(b)
+		if err != nil {
+			return b, nil, err
+		}
+		builder := json.NewObjectBuilder(int(n))
+		for i := uint64(0); i < n; i++ {
+			var k []byte
+			if b, k, err = DecodeBytesAscending(b, nil); err != nil {
+				return b, nil, err
+			}
+			var v json.JSON
+			if b, v, err = decodeJSONKey(b); err != nil {
+				return b, nil, err
+			}
+			builder.Add(string(k), v)
+		}
+		return b, builder.Build(), nil
+	default:
+		return b, nil, errors.Errorf("unknown JSON tag %d", tag)
+	}
+}
+
+// Inverted index keys for a JSON value are built from the paths leading from
+// the root of the value to each of its scalars and empty arrays or objects.
+// A path is serialized as a sequence of steps, each an array step (the tag
+// alone) or an object step (the tag followed by the key), followed by the
+// serialization of the value at the end of the path. The step tags are
+// distinct from the type tags above, so serialized paths are prefix-free.
+const (
+	jsonInvertedArrayStepTag byte = jsonObjectTag + 1 + iota
+	jsonInvertedObjectStepTag
+)
+
+// EncodeJSONInvertedIndexKeys returns the inverted index keys for j, each
+// consisting of b followed by the serialized path, encoded with
+// EncodeBytesAscending. The keys are sorted and free of duplicates.
+func EncodeJSONInvertedIndexKeys(b []byte, j json.JSON) [][]byte {
+	var keys [][]byte
+	addJSONPaths(nil, j, func(path []byte, _ bool) {
+		keys = append(keys, EncodeBytesAscending(append([]byte(nil), b...), path))
+	})
+	sort.Slice(keys, func(i, k int) bool { return bytes.Compare(keys[i], keys[k]) < 0 })
+	out := keys[:0]
+	for i := range keys {
+		if i == 0 || !bytes.Equal(keys[i], keys[i-1]) {
+			out = append(out, keys[i])
+		}
+	}
+	return out
+}
+
+// EncodeJSONContainmentKey returns an inverted index key, as produced by
+// EncodeJSONInvertedIndexKeys, that the keys of every value containing j (in
+// the sense of json.JSON.Contains) include. It returns false if there is no
+// such key, which is the case when j is a scalar (which is also contained in
+// arrays having it as an element) or when j contains no scalars (empty
+// arrays and objects are contained in non-empty ones).
+func EncodeJSONContainmentKey(b []byte, j json.JSON) ([]byte, bool) {
+	switch j.Type() {
+	case json.ArrayJSONType, json.ObjectJSONType:
+	default:
+		return nil, false
+	}
+	var key []byte
+	addJSONPaths(nil, j, func(path []byte, scalar bool) {
+		if key == nil && scalar {
+			key = EncodeBytesAscending(append([]byte(nil), b...), path)
+		}
+	})
+	return key, key != nil
+}
+
+// addJSONPaths calls fn with the serialization of every path in j, each
+// prefixed by path, and whether the path ends in a scalar. The slice passed
+// to fn is only valid for the duration of the call.
+func addJSONPaths(path []byte, j json.JSON, fn func(path []byte, scalar bool)) {
+	switch j.Type() {
+	case json.ArrayJSONType:
+		elems, _ := json.AsArray(j)
+		if len(elems) == 0 {
+			fn(encodeJSONKey(path, j), false)
+			return
+		}
+		path = append(path, jsonInvertedArrayStepTag)
+		for _, e := range elems {
+			addJSONPaths(path, e, fn)
+		}
+	case json.ObjectJSONType:
+		keys, _ := json.ObjectKeys(j)
+		if len(keys) == 0 {
+			fn(encodeJSONKey(path, j), false)
+			return
+		}
+		for _, k := range keys {
+			step := EncodeStringAscending(append(path, jsonInvertedObjectStepTag), k)
+			addJSONPaths(step, j.FetchValKey(k), fn)
+		}
+	default:
+		fn(encodeJSONKey(path, j), true)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package encoding
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+func TestEncodeDecodeJSON(t *testing.T) {
+	rng, seed := randutil.NewPseudoRand()
+
+	values := make([]json.JSON, 200)
+	for i := range values {
+		values[i] = json.RandJSON(rng, 3)
+	}
+
+	for _, dir := range []Direction{Ascending, Descending} {
+		encoded := make([][]byte, len(values))
+		for i, j := range values {
+			if dir == Ascending {
+				encoded[i] = EncodeJSONAscending([]byte{0x01}, j)
+			} else {
+				encoded[i] = EncodeJSONDescending([]byte{0x01}, j)
+			}
+			// Trailing bytes must be left alone by the decoder and by
+			// PeekLength.
+			enc := append(encoded[i][1:], 0xab)
+			if l, err := PeekLength(enc); err != nil {
+				t.Fatal(err)
+			} else if l != len(enc)-1 {
+				t.Fatalf("seed %d: expected length %d, got %d", seed, len(enc)-1, l)
+			}
+			var rem []byte
+			var decoded json.JSON
+			var err error
+			if dir == Ascending {
+				rem, decoded, err = DecodeJSONAscending(enc)
+			} else {
+				rem, decoded, err = DecodeJSONDescending(enc)
+			}
+			if err != nil {
+				t.Fatalf("seed %d: %s: %v", seed, j, err)
+			}
+			if !bytes.Equal(rem, []byte{0xab}) {
+				t.Fatalf("seed %d: unexpected remaining bytes %x", seed, rem)
+			}
+			if decoded.Compare(j) != 0 {
+				t.Fatalf("seed %d: expected %s, got %s", seed, j, decoded)
+			}
+		}
+
+		// The encodings must sort in the same order as the values.
+		for i := range values {
+			for k := range values {
+				c := values[i].Compare(values[k])
+				if dir == Descending {
+					c = -c
+				}
+				if bc := bytes.Compare(encoded[i], encoded[k]); (bc < 0) != (c < 0) || (bc > 0) != (c > 0) {
+					if c == 0 {
+						// Equal numbers may have different encodings (1.0 vs 1).
+						continue
+					}
+					t.Fatalf("seed %d: %s vs %s: expected %d, got %d", seed, values[i], values[k], c, bc)
+				}
+			}
+		}
+	}
+}
+
+func TestJSONInvertedIndexKeys(t *testing.T) {
+	parse := func(s string) json.JSON {
+		j, err := json.ParseJSON(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return j
+	}
+	hasKey := func(keys [][]byte, key []byte) bool {
+		for _, k := range keys {
+			if bytes.Equal(k, key) {
+				return true
+			}
+		}
+		return false
+	}
+
+	testCases := []struct {
+		value   string
+		numKeys int
+	}{
+		{`1`, 1},
+		{`[]`, 1},
+		{`{}`, 1},
+		{`[1, 1, 2]`, 2},
+		{`{"a": [1, {"b": null}], "c": {}}`, 3},
+		{`[[1, 2], [1]]`, 2},
+	}
+	for _, tc := range testCases {
+		keys := EncodeJSONInvertedIndexKeys([]byte{0x01}, parse(tc.value))
+		if len(keys) != tc.numKeys {
+			t.Errorf("%s: expected %d keys, got %d", tc.value, tc.numKeys, len(keys))
+		}
+		for i := range keys {
+			if i > 0 && bytes.Compare(keys[i-1], keys[i]) >= 0 {
+				t.Errorf("%s: keys not sorted and unique", tc.value)
+			}
+			if l, err := PeekLength(keys[i][1:]); err != nil {
+				t.Fatal(err)
+			} else if l != len(keys[i])-1 {
+				t.Errorf("%s: expected length %d, got %d", tc.value, len(keys[i])-1, l)
+			}
+		}
+	}
+
+	containmentCases := []struct {
+		value, contained string
+		ok               bool
+	}{
+		{`{"a": [1, {"b": null}], "c": {}}`, `{"a": [{"b": null}]}`, true},
+		{`{"a": [1, {"b": null}], "c": {}}`, `{"c": {}, "a": [1]}`, true},
+		{`[[1, 2], 3]`, `[[2]]`, true},
+		{`[1, 2]`, `1`, false},
+		{`{"a": {"b": 1}}`, `{"a": {}}`, false},
+		{`[[]]`, `[]`, false},
+	}
+	for _, tc := range containmentCases {
+		value, contained := parse(tc.value), parse(tc.contained)
+		if !value.Contains(contained) {
+			t.Fatalf("%s does not contain %s", tc.value, tc.contained)
+		}
+		key, ok := EncodeJSONContainmentKey([]byte{0x01}, contained)
+		if ok != tc.ok {
+			t.Errorf("%s: expected %t, got %t", tc.contained, tc.ok, ok)
+		}
+		if ok && !hasKey(EncodeJSONInvertedIndexKeys([]byte{0x01}, value), key) {
+			t.Errorf("keys of %s do not include the containment key of %s", tc.value, tc.contained)
+		}
+	}
+
+	rng, seed := randutil.NewPseudoRand()
+	for i := 0; i < 100; i++ {
+		j := json.RandJSON(rng, 3)
+		outer := json.FromArray([]json.JSON{json.RandJSON(rng, 2), j})
+		key, ok := EncodeJSONContainmentKey(nil, json.FromArray([]json.JSON{j}))
+		if ok && !hasKey(EncodeJSONInvertedIndexKeys(nil, outer), key) {
+			t.Fatalf("seed %d: keys of %s do not include the containment key of [%s]", seed, outer, j)
+		}
+	}
+}
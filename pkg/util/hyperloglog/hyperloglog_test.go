@@ -0,0 +1,116 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package hyperloglog
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func insertRange(s *Sketch, from, to uint64) {
+	var buf [8]byte
+	for i := from; i < to; i++ {
+		binary.BigEndian.PutUint64(buf[:], i)
+		s.Insert(buf[:])
+	}
+}
+
+func checkEstimate(t *testing.T, s *Sketch, expected uint64) {
+	est := s.Estimate()
+	// Allow for five standard errors.
+	tolerance := 5 * 1.04 / math.Sqrt(float64(uint64(1)<<s.Precision()))
+	if diff := math.Abs(float64(est) - float64(expected)); diff > tolerance*float64(expected) {
+		t.Errorf("estimate %d too far from %d (tolerance %.2f%%)", est, expected, tolerance*100)
+	}
+}
+
+func TestSketchEstimate(t *testing.T) {
+	for _, n := range []uint64{0, 1, 10, 100, 1000, 10000, 100000} {
+		s, err := New(DefaultPrecision)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Insert every value twice; duplicates must not affect the estimate.
+		insertRange(s, 0, n)
+		insertRange(s, 0, n)
+		if n == 0 {
+			if est := s.Estimate(); est != 0 {
+				t.Errorf("expected estimate 0 for empty sketch, got %d", est)
+			}
+			continue
+		}
+		checkEstimate(t, s, n)
+	}
+}
+
+func TestSketchMerge(t *testing.T) {
+	a, err := New(DefaultPrecision)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := New(DefaultPrecision)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The two sketches overlap on [5000, 10000).
+	insertRange(a, 0, 10000)
+	insertRange(b, 5000, 20000)
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	checkEstimate(t, a, 20000)
+
+	c, err := New(MinPrecision)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Merge(c); err == nil {
+		t.Error("expected error merging sketches with different precisions")
+	}
+}
+
+func TestSketchEncoding(t *testing.T) {
+	s, err := New(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	insertRange(s, 0, 500)
+	data, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Sketch
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Precision() != s.Precision() {
+		t.Fatalf("expected precision %d, got %d", s.Precision(), decoded.Precision())
+	}
+	if a, e := decoded.Estimate(), s.Estimate(); a != e {
+		t.Fatalf("expected estimate %d, got %d", e, a)
+	}
+	if err := decoded.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Fatal("expected error decoding truncated sketch")
+	}
+}
+
+func TestNewInvalidPrecision(t *testing.T) {
+	for _, p := range []uint8{0, MinPrecision - 1, MaxPrecision + 1} {
+		if _, err := New(p); err == nil {
+			t.Errorf("expected error for precision %d", p)
+		}
+	}
+}
@@ -0,0 +1,709 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package json implements the in-memory representation of JSON documents
+// used by the JSONB SQL type.
+package json
+
+import (
+	"bytes"
+	gojson "encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"math/rand"
+	"sort"
+	"strings"
+	"unicode/utf8"
+	"unsafe"
+
+	"github.com/cockroachdb/apd"
+	"github.com/pkg/errors"
+)
+
+// Type is the type of a JSON value.
+type Type int
+
+// Type values, in ascending sort order. The order matches the one used by
+// Postgres to compare JSONB values of differing types.
+const (
+	NullJSONType Type = iota
+	StringJSONType
+	NumberJSONType
+	FalseJSONType
+	TrueJSONType
+	ArrayJSONType
+	ObjectJSONType
+)
+
+var typeNames = [...]string{
+	NullJSONType:   "null",
+	StringJSONType: "string",
+	NumberJSONType: "number",
+	FalseJSONType:  "boolean",
+	TrueJSONType:   "boolean",
+	ArrayJSONType:  "array",
+	ObjectJSONType: "object",
+}
+
+// String returns the name of the type as reported by jsonb_typeof.
+func (t Type) String() string {
+	return typeNames[t]
+}
+
+// JSON is a JSON value. JSON values are immutable.
+type JSON interface {
+	fmt.Stringer
+
+	// Type returns the type of the value.
+	Type() Type
+
+	// Format writes the canonical textual representation of the value to
+	// buf. Object keys are printed in sorted order and every separator is
+	// followed by a single space, matching the output of Postgres' JSONB.
+	Format(buf *bytes.Buffer)
+
+	// Compare returns -1, 0 or 1 depending on whether the receiver sorts
+	// before, equal to or after other. Values of differing types sort
+	// according to their Type; arrays and objects compare by their length
+	// first and then element-wise.
+	Compare(other JSON) int
+
+	// FetchValKey returns the value stored under key in an object, or nil if
+	// the receiver is not an object or has no such key.
+	FetchValKey(key string) JSON
+
+	// FetchValIdx returns the idx-th element of an array, or nil if the
+	// receiver is not an array or the index is out of bounds. Negative
+	// indexes count from the end of the array.
+	FetchValIdx(idx int) JSON
+
+	// Exists implements the `?` operator: it reports whether key is a key of
+	// an object, an element of an array of strings, or equal to a string
+	// scalar.
+	Exists(key string) bool
+
+	// Contains implements the `@>` operator.
+	Contains(other JSON) bool
+
+	// AsText returns the value as it is returned by the `->>` operator:
+	// strings are returned unquoted, null is returned as nil and other
+	// values are returned in their textual representation.
+	AsText() *string
+
+	// StripNulls returns the value with all object fields with null values
+	// removed, recursively.
+	StripNulls() JSON
+
+	// Size returns the approximate in-memory size of the value in bytes.
+	Size() uintptr
+}
+
+type jsonNull struct{}
+type jsonTrue struct{}
+type jsonFalse struct{}
+type jsonNumber apd.Decimal
+type jsonString string
+type jsonArray []JSON
+
+type jsonKeyValuePair struct {
+	k jsonString
+	v JSON
+}
+
+// jsonObject stores its pairs sorted by key, without duplicates.
+type jsonObject []jsonKeyValuePair
+
+var _ JSON = jsonNull{}
+var _ JSON = jsonTrue{}
+var _ JSON = jsonFalse{}
+var _ JSON = &jsonNumber{}
+var _ JSON = jsonString("")
+var _ JSON = jsonArray(nil)
+var _ JSON = jsonObject(nil)
+
+// NullJSONValue, TrueJSONValue and FalseJSONValue are the JSON scalars
+// null, true and false.
+var (
+	NullJSONValue  JSON = jsonNull{}
+	TrueJSONValue  JSON = jsonTrue{}
+	FalseJSONValue JSON = jsonFalse{}
+)
+
+// FromBool returns the JSON boolean b.
+func FromBool(b bool) JSON {
+	if b {
+		return TrueJSONValue
+	}
+	return FalseJSONValue
+}
+
+// FromString returns the JSON string s.
+func FromString(s string) JSON {
+	return jsonString(s)
+}
+
+// FromDecimal returns the JSON number d.
+func FromDecimal(d apd.Decimal) JSON {
+	n := jsonNumber(d)
+	return &n
+}
+
+// FromInt returns the JSON number i.
+func FromInt(i int) JSON {
+	var d apd.Decimal
+	d.SetInt64(int64(i))
+	return FromDecimal(d)
+}
+
+// FromArray returns a JSON array containing elems.
+func FromArray(elems []JSON) JSON {
+	return jsonArray(elems)
+}
+
+// ObjectBuilder constructs JSON objects.
+type ObjectBuilder struct {
+	pairs jsonObject
+}
+
+// NewObjectBuilder returns an ObjectBuilder with room for n pairs.
+func NewObjectBuilder(n int) *ObjectBuilder {
+	return &ObjectBuilder{pairs: make(jsonObject, 0, n)}
+}
+
+// Add adds a key/value pair to the object under construction. If the key is
+// added more than once, the last value wins.
+func (b *ObjectBuilder) Add(k string, v JSON) {
+	b.pairs = append(b.pairs, jsonKeyValuePair{k: jsonString(k), v: v})
+}
+
+// Build returns the constructed object. The builder must not be used
+// afterwards.
+func (b *ObjectBuilder) Build() JSON {
+	pairs := b.pairs
+	sort.Stable(pairs)
+	// Deduplicate, keeping the last occurrence of every key.
+	out := pairs[:0]
+	for i := range pairs {
+		if i+1 < len(pairs) && pairs[i+1].k == pairs[i].k {
+			continue
+		}
+		out = append(out, pairs[i])
+	}
+	b.pairs = nil
+	return out
+}
+
+func (o jsonObject) Len() int           { return len(o) }
+func (o jsonObject) Less(i, j int) bool { return o[i].k < o[j].k }
+func (o jsonObject) Swap(i, j int)      { o[i], o[j] = o[j], o[i] }
+
+// AsString returns the contents of a JSON string.
+func AsString(j JSON) (string, bool) {
+	s, ok := j.(jsonString)
+	return string(s), ok
+}
+
+// AsDecimal returns the value of a JSON number.
+func AsDecimal(j JSON) (*apd.Decimal, bool) {
+	n, ok := j.(*jsonNumber)
+	return (*apd.Decimal)(n), ok
+}
+
+// AsArray returns the elements of a JSON array. The returned slice must not
+// be modified.
+func AsArray(j JSON) ([]JSON, bool) {
+	a, ok := j.(jsonArray)
+	return a, ok
+}
+
+// ObjectKeys returns the keys of a JSON object in sorted order.
+func ObjectKeys(j JSON) ([]string, bool) {
+	o, ok := j.(jsonObject)
+	if !ok {
+		return nil, false
+	}
+	keys := make([]string, len(o))
+	for i := range o {
+		keys[i] = string(o[i].k)
+	}
+	return keys, true
+}
+
+// Pretty returns the textual representation of j indented over multiple
+// lines, as returned by jsonb_pretty.
+func Pretty(j JSON) (string, error) {
+	var buf bytes.Buffer
+	if err := gojson.Indent(&buf, []byte(j.String()), "", "    "); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// ParseJSON parses the textual representation of a JSON document.
+func ParseJSON(s string) (JSON, error) {
+	decoder := gojson.NewDecoder(strings.NewReader(s))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		if err == io.EOF {
+			return nil, errors.New("unexpected end of JSON input")
+		}
+		return nil, err
+	}
+	// Reject trailing garbage after the document.
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.Errorf("trailing characters after JSON document")
+	}
+	return MakeJSON(v)
+}
+
+// MakeJSON converts the result of decoding a document with the standard
+// library's encoding/json (with UseNumber set) into a JSON value.
+func MakeJSON(d interface{}) (JSON, error) {
+	switch v := d.(type) {
+	case nil:
+		return NullJSONValue, nil
+	case bool:
+		return FromBool(v), nil
+	case string:
+		return FromString(v), nil
+	case gojson.Number:
+		dec, _, err := apd.NewFromString(string(v))
+		if err != nil {
+			return nil, err
+		}
+		return FromDecimal(*dec), nil
+	case []interface{}:
+		elems := make([]JSON, len(v))
+		for i := range v {
+			var err error
+			if elems[i], err = MakeJSON(v[i]); err != nil {
+				return nil, err
+			}
+		}
+		return FromArray(elems), nil
+	case map[string]interface{}:
+		b := NewObjectBuilder(len(v))
+		for k, e := range v {
+			j, err := MakeJSON(e)
+			if err != nil {
+				return nil, err
+			}
+			b.Add(k, j)
+		}
+		return b.Build(), nil
+	default:
+		return nil, errors.Errorf("unexpected value of type %T", d)
+	}
+}
+
+// RandJSON generates a random JSON value whose nesting depth is at most
+// depth.
+func RandJSON(rng *rand.Rand, depth int) JSON {
+	n := 5
+	if depth <= 0 {
+		// Only generate scalars.
+		n = 3
+	}
+	switch rng.Intn(n) {
+	case 0:
+		return FromBool(rng.Intn(2) == 1)
+	case 1:
+		return FromInt(rng.Intn(1000) - 500)
+	case 2:
+		switch rng.Intn(3) {
+		case 0:
+			return NullJSONValue
+		default:
+			return FromString(fmt.Sprintf("s%d", rng.Intn(100)))
+		}
+	case 3:
+		elems := make([]JSON, rng.Intn(4))
+		for i := range elems {
+			elems[i] = RandJSON(rng, depth-1)
+		}
+		return FromArray(elems)
+	default:
+		l := rng.Intn(4)
+		b := NewObjectBuilder(l)
+		for i := 0; i < l; i++ {
+			b.Add(fmt.Sprintf("k%d", rng.Intn(10)), RandJSON(rng, depth-1))
+		}
+		return b.Build()
+	}
+}
+
+func (jsonNull) Type() Type      { return NullJSONType }
+func (jsonTrue) Type() Type      { return TrueJSONType }
+func (jsonFalse) Type() Type     { return FalseJSONType }
+func (*jsonNumber) Type() Type   { return NumberJSONType }
+func (jsonString) Type() Type    { return StringJSONType }
+func (jsonArray) Type() Type     { return ArrayJSONType }
+func (jsonObject) Type() Type    { return ObjectJSONType }
+func (jsonNull) String() string  { return "null" }
+func (jsonTrue) String() string  { return "true" }
+func (jsonFalse) String() string { return "false" }
+
+func (j *jsonNumber) String() string { return formatToString(j) }
+func (j jsonString) String() string  { return formatToString(j) }
+func (j jsonArray) String() string   { return formatToString(j) }
+func (j jsonObject) String() string  { return formatToString(j) }
+
+func formatToString(j JSON) string {
+	var buf bytes.Buffer
+	j.Format(&buf)
+	return buf.String()
+}
+
+func (jsonNull) Format(buf *bytes.Buffer)  { buf.WriteString("null") }
+func (jsonTrue) Format(buf *bytes.Buffer)  { buf.WriteString("true") }
+func (jsonFalse) Format(buf *bytes.Buffer) { buf.WriteString("false") }
+
+func (j *jsonNumber) Format(buf *bytes.Buffer) {
+	buf.WriteString((*apd.Decimal)(j).Text('f'))
+}
+
+func (j jsonString) Format(buf *bytes.Buffer) {
+	encodeJSONString(buf, string(j))
+}
+
+func (j jsonArray) Format(buf *bytes.Buffer) {
+	buf.WriteByte('[')
+	for i := range j {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		j[i].Format(buf)
+	}
+	buf.WriteByte(']')
+}
+
+func (j jsonObject) Format(buf *bytes.Buffer) {
+	buf.WriteByte('{')
+	for i := range j {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		encodeJSONString(buf, string(j[i].k))
+		buf.WriteString(": ")
+		j[i].v.Format(buf)
+	}
+	buf.WriteByte('}')
+}
+
+const hexAlphabet = "0123456789abcdef"
+
+// encodeJSONString writes s to buf as a quoted JSON string. Unlike the
+// standard library, it does not escape HTML characters.
+func encodeJSONString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= 0x20 && c != '"' && c != '\\' {
+			if c < utf8.RuneSelf {
+				i++
+				continue
+			}
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				buf.WriteString(s[start:i])
+				buf.WriteString(`�`)
+				i += size
+				start = i
+				continue
+			}
+			i += size
+			continue
+		}
+		buf.WriteString(s[start:i])
+		switch c {
+		case '"', '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			buf.WriteString(`\u00`)
+			buf.WriteByte(hexAlphabet[c>>4])
+			buf.WriteByte(hexAlphabet[c&0xf])
+		}
+		i++
+		start = i
+	}
+	buf.WriteString(s[start:])
+	buf.WriteByte('"')
+}
+
+func cmpType(a, b JSON) int {
+	at, bt := a.Type(), b.Type()
+	if at < bt {
+		return -1
+	} else if at > bt {
+		return 1
+	}
+	return 0
+}
+
+func cmpLen(a, b int) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+func (j jsonNull) Compare(other JSON) int  { return cmpType(j, other) }
+func (j jsonTrue) Compare(other JSON) int  { return cmpType(j, other) }
+func (j jsonFalse) Compare(other JSON) int { return cmpType(j, other) }
+
+func (j *jsonNumber) Compare(other JSON) int {
+	if c := cmpType(j, other); c != 0 {
+		return c
+	}
+	return (*apd.Decimal)(j).Cmp((*apd.Decimal)(other.(*jsonNumber)))
+}
+
+func (j jsonString) Compare(other JSON) int {
+	if c := cmpType(j, other); c != 0 {
+		return c
+	}
+	return strings.Compare(string(j), string(other.(jsonString)))
+}
+
+func (j jsonArray) Compare(other JSON) int {
+	if c := cmpType(j, other); c != 0 {
+		return c
+	}
+	o := other.(jsonArray)
+	if c := cmpLen(len(j), len(o)); c != 0 {
+		return c
+	}
+	for i := range j {
+		if c := j[i].Compare(o[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func (j jsonObject) Compare(other JSON) int {
+	if c := cmpType(j, other); c != 0 {
+		return c
+	}
+	o := other.(jsonObject)
+	if c := cmpLen(len(j), len(o)); c != 0 {
+		return c
+	}
+	for i := range j {
+		if c := strings.Compare(string(j[i].k), string(o[i].k)); c != 0 {
+			return c
+		}
+		if c := j[i].v.Compare(o[i].v); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func (jsonNull) FetchValKey(string) JSON    { return nil }
+func (jsonTrue) FetchValKey(string) JSON    { return nil }
+func (jsonFalse) FetchValKey(string) JSON   { return nil }
+func (*jsonNumber) FetchValKey(string) JSON { return nil }
+func (jsonString) FetchValKey(string) JSON  { return nil }
+func (jsonArray) FetchValKey(string) JSON   { return nil }
+
+func (j jsonObject) FetchValKey(key string) JSON {
+	i := sort.Search(len(j), func(i int) bool { return string(j[i].k) >= key })
+	if i < len(j) && string(j[i].k) == key {
+		return j[i].v
+	}
+	return nil
+}
+
+func (jsonNull) FetchValIdx(int) JSON    { return nil }
+func (jsonTrue) FetchValIdx(int) JSON    { return nil }
+func (jsonFalse) FetchValIdx(int) JSON   { return nil }
+func (*jsonNumber) FetchValIdx(int) JSON { return nil }
+func (jsonString) FetchValIdx(int) JSON  { return nil }
+func (jsonObject) FetchValIdx(int) JSON  { return nil }
+
+func (j jsonArray) FetchValIdx(idx int) JSON {
+	if idx < 0 {
+		idx += len(j)
+	}
+	if idx < 0 || idx >= len(j) {
+		return nil
+	}
+	return j[idx]
+}
+
+func (jsonNull) Exists(string) bool    { return false }
+func (jsonTrue) Exists(string) bool    { return false }
+func (jsonFalse) Exists(string) bool   { return false }
+func (*jsonNumber) Exists(string) bool { return false }
+
+func (j jsonString) Exists(key string) bool {
+	return string(j) == key
+}
+
+func (j jsonArray) Exists(key string) bool {
+	for _, e := range j {
+		if s, ok := e.(jsonString); ok && string(s) == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (j jsonObject) Exists(key string) bool {
+	return j.FetchValKey(key) != nil
+}
+
+// Contains follows the Postgres semantics for JSONB containment: scalars
+// contain only themselves, objects contain objects whose pairs they contain,
+// and arrays contain arrays (or, at the top level, scalars) whose elements
+// are each contained by some of their own elements.
+func (j jsonNull) Contains(other JSON) bool    { return j.Compare(other) == 0 }
+func (j jsonTrue) Contains(other JSON) bool    { return j.Compare(other) == 0 }
+func (j jsonFalse) Contains(other JSON) bool   { return j.Compare(other) == 0 }
+func (j *jsonNumber) Contains(other JSON) bool { return j.Compare(other) == 0 }
+func (j jsonString) Contains(other JSON) bool  { return j.Compare(other) == 0 }
+
+func (j jsonArray) Contains(other JSON) bool {
+	switch o := other.(type) {
+	case jsonArray:
+		for _, oe := range o {
+			if !j.containsElem(oe) {
+				return false
+			}
+		}
+		return true
+	case jsonObject:
+		return false
+	default:
+		// A top-level array contains the scalars it has as elements.
+		return j.containsElem(other)
+	}
+}
+
+func (j jsonArray) containsElem(other JSON) bool {
+	for _, e := range j {
+		switch other.(type) {
+		case jsonArray, jsonObject:
+			if e.Type() == other.Type() && e.Contains(other) {
+				return true
+			}
+		default:
+			if e.Compare(other) == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (j jsonObject) Contains(other JSON) bool {
+	o, ok := other.(jsonObject)
+	if !ok {
+		return false
+	}
+	for _, p := range o {
+		v := j.FetchValKey(string(p.k))
+		if v == nil || v.Type() != p.v.Type() {
+			return false
+		}
+		if !v.Contains(p.v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (jsonNull) AsText() *string { return nil }
+
+func (j jsonTrue) AsText() *string    { s := j.String(); return &s }
+func (j jsonFalse) AsText() *string   { s := j.String(); return &s }
+func (j *jsonNumber) AsText() *string { s := j.String(); return &s }
+func (j jsonArray) AsText() *string   { s := j.String(); return &s }
+func (j jsonObject) AsText() *string  { s := j.String(); return &s }
+
+func (j jsonString) AsText() *string {
+	s := string(j)
+	return &s
+}
+
+func (j jsonNull) StripNulls() JSON    { return j }
+func (j jsonTrue) StripNulls() JSON    { return j }
+func (j jsonFalse) StripNulls() JSON   { return j }
+func (j *jsonNumber) StripNulls() JSON { return j }
+func (j jsonString) StripNulls() JSON  { return j }
+
+func (j jsonArray) StripNulls() JSON {
+	res := make(jsonArray, len(j))
+	for i := range j {
+		res[i] = j[i].StripNulls()
+	}
+	return res
+}
+
+func (j jsonObject) StripNulls() JSON {
+	res := make(jsonObject, 0, len(j))
+	for _, p := range j {
+		if p.v.Type() == NullJSONType {
+			continue
+		}
+		res = append(res, jsonKeyValuePair{k: p.k, v: p.v.StripNulls()})
+	}
+	return res
+}
+
+func (jsonNull) Size() uintptr  { return 0 }
+func (jsonTrue) Size() uintptr  { return 0 }
+func (jsonFalse) Size() uintptr { return 0 }
+
+func (j *jsonNumber) Size() uintptr {
+	intVal := (*apd.Decimal)(j).Coeff
+	return unsafe.Sizeof(*j) + uintptr(cap(intVal.Bits()))*unsafe.Sizeof(big.Word(0))
+}
+
+func (j jsonString) Size() uintptr {
+	return unsafe.Sizeof(j) + uintptr(len(j))
+}
+
+func (j jsonArray) Size() uintptr {
+	sz := unsafe.Sizeof(j)
+	for _, e := range j {
+		sz += e.Size() + unsafe.Sizeof(e)
+	}
+	return sz
+}
+
+func (j jsonObject) Size() uintptr {
+	sz := unsafe.Sizeof(j)
+	for _, p := range j {
+		sz += p.k.Size() + p.v.Size() + unsafe.Sizeof(p.v)
+	}
+	return sz
+}
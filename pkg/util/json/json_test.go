@@ -0,0 +1,221 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package json
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`1`, `1`},
+		{`-1.50`, `-1.50`},
+		{`1e2`, `100`},
+		{`true`, `true`},
+		{`false`, `false`},
+		{`null`, `null`},
+		{`"a"`, `"a"`},
+		{`"\u0001\"\\\n"`, `"\u0001\"\\\n"`},
+		{`"<>&"`, `"<>&"`},
+		{`[]`, `[]`},
+		{`{}`, `{}`},
+		{`[1,2,  "a"]`, `[1, 2, "a"]`},
+		{`{"b":1,"a":[true,null]}`, `{"a": [true, null], "b": 1}`},
+		{`{"a":1,"a":2}`, `{"a": 2}`},
+		{` {"a" : {"b": {}}} `, `{"a": {"b": {}}}`},
+	}
+	for _, tc := range testCases {
+		j, err := ParseJSON(tc.input)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.input, err)
+		}
+		if s := j.String(); s != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.input, tc.expected, s)
+		}
+		// The canonical representation must parse to an equal value.
+		j2, err := ParseJSON(j.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if j.Compare(j2) != 0 {
+			t.Errorf("%s: round trip produced %s", tc.input, j2)
+		}
+	}
+}
+
+func TestJSONParseErrors(t *testing.T) {
+	for _, input := range []string{``, `{`, `[1,]`, `{"a"}`, `1 2`, `{} x`, `'a'`} {
+		if _, err := ParseJSON(input); err == nil {
+			t.Errorf("%q: expected error", input)
+		}
+	}
+}
+
+func TestJSONCompare(t *testing.T) {
+	// Values in ascending order.
+	ordered := []string{
+		`null`,
+		`""`,
+		`"a"`,
+		`"b"`,
+		`-1`,
+		`1`,
+		`1.5`,
+		`false`,
+		`true`,
+		`[]`,
+		`[2]`,
+		`[1, 2]`,
+		`[1, 3]`,
+		`{}`,
+		`{"a": 2}`,
+		`{"b": 1}`,
+		`{"a": 1, "b": 1}`,
+	}
+	for i := range ordered {
+		a, err := ParseJSON(ordered[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := range ordered {
+			b, err := ParseJSON(ordered[j])
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := 0
+			if i < j {
+				expected = -1
+			} else if i > j {
+				expected = 1
+			}
+			if c := a.Compare(b); c != expected {
+				t.Errorf("compare(%s, %s): expected %d, got %d", a, b, expected, c)
+			}
+		}
+	}
+}
+
+func TestJSONContains(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected bool
+	}{
+		{`1`, `1`, true},
+		{`1`, `2`, false},
+		{`[1, 2, 3]`, `[3, 1]`, true},
+		{`[1, 2, 3]`, `[1, 1]`, true},
+		{`[1, 2, 3]`, `[4]`, false},
+		{`[1, 2, 3]`, `1`, true},
+		{`[[1, 2]]`, `[[1]]`, true},
+		{`[[1, 2]]`, `[1]`, false},
+		{`[1, [2]]`, `[]`, true},
+		{`{"a": 1, "b": 2}`, `{"a": 1}`, true},
+		{`{"a": 1, "b": 2}`, `{"a": 2}`, false},
+		{`{"a": {"b": [1, 2]}}`, `{"a": {"b": [2]}}`, true},
+		{`{"a": [1]}`, `{"a": 1}`, false},
+		{`{"a": 1}`, `{}`, true},
+		{`{"a": 1}`, `[]`, false},
+		{`[{"a": 1, "b": 2}]`, `[{"a": 1}]`, true},
+	}
+	for _, tc := range testCases {
+		a, err := ParseJSON(tc.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ParseJSON(tc.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c := a.Contains(b); c != tc.expected {
+			t.Errorf("%s @> %s: expected %t, got %t", tc.a, tc.b, tc.expected, c)
+		}
+	}
+}
+
+func TestJSONFetch(t *testing.T) {
+	j, err := ParseJSON(`{"a": [1, "x", {"b": null}], "c": "d"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := j.FetchValKey("c"); v == nil || v.String() != `"d"` {
+		t.Errorf("expected \"d\", got %v", v)
+	}
+	if v := j.FetchValKey("z"); v != nil {
+		t.Errorf("expected nil, got %s", v)
+	}
+	a := j.FetchValKey("a")
+	if v := a.FetchValIdx(-1); v == nil || v.String() != `{"b": null}` {
+		t.Errorf("expected {\"b\": null}, got %v", v)
+	}
+	if v := a.FetchValIdx(3); v != nil {
+		t.Errorf("expected nil, got %s", v)
+	}
+	if s := a.FetchValIdx(1).AsText(); s == nil || *s != "x" {
+		t.Errorf("expected x, got %v", s)
+	}
+	if s := a.FetchValIdx(2).FetchValKey("b").AsText(); s != nil {
+		t.Errorf("expected nil, got %s", *s)
+	}
+	if !j.Exists("a") || j.Exists("x") || !a.Exists("x") {
+		t.Errorf("unexpected result for Exists")
+	}
+	if s := j.StripNulls().String(); s != `{"a": [1, "x", {}], "c": "d"}` {
+		t.Errorf("unexpected result for StripNulls: %s", s)
+	}
+}
+
+func TestJSONPretty(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`1`, `1`},
+		{`"a b"`, `"a b"`},
+		{`[]`, `[]`},
+		{`[1, "<"]`, "[\n    1,\n    \"<\"\n]"},
+		{`{"a": {"b": null}}`, "{\n    \"a\": {\n        \"b\": null\n    }\n}"},
+	}
+	for _, tc := range testCases {
+		j, err := ParseJSON(tc.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := Pretty(j)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.input, tc.expected, s)
+		}
+	}
+}
+
+func TestRandJSONRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	for i := 0; i < 1000; i++ {
+		j := RandJSON(rng, 3)
+		j2, err := ParseJSON(j.String())
+		if err != nil {
+			t.Fatalf("%s: %v", j, err)
+		}
+		if j.Compare(j2) != 0 {
+			t.Fatalf("expected %s, got %s", j, j2)
+		}
+	}
+}